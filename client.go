@@ -33,10 +33,13 @@ import (
 	"github.com/bluenviron/gortsplib/v5/pkg/headers"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v5/pkg/mikey"
+	"github.com/bluenviron/gortsplib/v5/pkg/onvif"
+	"github.com/bluenviron/gortsplib/v5/pkg/pcap"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpreceiver"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpsender"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtptime"
 	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+	"github.com/bluenviron/gortsplib/v5/pkg/trackmetrics"
 )
 
 const (
@@ -108,54 +111,6 @@ func isAnyPort(p int) bool {
 	return p == 0 || p == 1
 }
 
-func findBaseURL(sd *sdp.SessionDescription, res *base.Response, u *base.URL) (*base.URL, error) {
-	// use global control attribute
-	if control, ok := sd.Attribute("control"); ok && control != "*" {
-		ret, err := base.ParseURL(control)
-		if err != nil {
-			return nil, fmt.Errorf("invalid control attribute: '%v'", control)
-		}
-
-		// add credentials
-		ret.User = u.User
-
-		return ret, nil
-	}
-
-	// use Content-Base
-	if cb, ok := res.Header["Content-Base"]; ok {
-		if len(cb) != 1 {
-			return nil, fmt.Errorf("invalid Content-Base: '%v'", cb)
-		}
-
-		if strings.HasPrefix(cb[0], "/") {
-			// parse as a relative path
-			ret, err := base.ParseURL(u.Scheme + "://" + u.Host + cb[0])
-			if err != nil {
-				return nil, fmt.Errorf("invalid Content-Base: '%v'", cb)
-			}
-
-			// add credentials
-			ret.User = u.User
-
-			return ret, nil
-		}
-
-		ret, err := base.ParseURL(cb[0])
-		if err != nil {
-			return nil, fmt.Errorf("invalid Content-Base: '%v'", cb)
-		}
-
-		// add credentials
-		ret.User = u.User
-
-		return ret, nil
-	}
-
-	// use URL of request
-	return u, nil
-}
-
 type clientAnnounceDataFormat struct {
 	localSSRC uint32
 }
@@ -289,6 +244,17 @@ func supportsGetParameter(header base.Header) bool {
 	return false
 }
 
+func requiresReplay(header base.Header) bool {
+	for _, v := range header["Require"] {
+		for r := range strings.SplitSeq(v, ",") {
+			if strings.Trim(r, " ") == onvif.ReplayRequire {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func interfaceOfConn(c net.Conn) (*net.Interface, error) {
 	var localIP net.IP
 
@@ -394,6 +360,11 @@ type pauseReq struct {
 	res chan clientRes
 }
 
+type teardownMediaReq struct {
+	media *description.Media
+	res   chan clientRes
+}
+
 type clientRes struct {
 	sd  *description.Session // describe only
 	res *base.Response
@@ -403,6 +374,24 @@ type clientRes struct {
 // ClientOnRequestFunc is the prototype of Client.OnRequest.
 type ClientOnRequestFunc func(*base.Request)
 
+// cloneRequest returns a copy of a request that is not affected by later changes to the original one.
+func cloneRequest(req *base.Request) *base.Request {
+	ret := *req
+
+	if req.URL != nil {
+		ret.URL = req.URL.Clone()
+	}
+
+	if req.Header != nil {
+		ret.Header = make(base.Header, len(req.Header))
+		for k, v := range req.Header {
+			ret.Header[k] = append(base.HeaderValue(nil), v...)
+		}
+	}
+
+	return &ret
+}
+
 // ClientOnResponseFunc is the prototype of Client.OnResponse.
 type ClientOnResponseFunc func(*base.Response)
 
@@ -415,6 +404,21 @@ type ClientOnPacketsLostFunc func(lost uint64)
 // ClientOnDecodeErrorFunc is the prototype of Client.OnDecodeError.
 type ClientOnDecodeErrorFunc func(err error)
 
+// ClientOnUnknownPacketFunc is the prototype of Client.OnUnknownPacket.
+type ClientOnUnknownPacketFunc func(medi *description.Media, pkt *rtp.Packet)
+
+// ClientOnWriteErrorFunc is the prototype of Client.OnWriteError.
+type ClientOnWriteErrorFunc func(err error)
+
+// ClientOnSSRCChangeFunc is the prototype of Client.OnSSRCChange.
+type ClientOnSSRCChangeFunc func(medi *description.Media, forma format.Format, prev uint32, cur uint32)
+
+// ClientOnParamsChangeFunc is the prototype of Client.OnParamsChange.
+type ClientOnParamsChangeFunc func(medi *description.Media, forma format.Format)
+
+// ClientOnTrackMetricsChangeFunc is the prototype of Client.OnTrackMetricsChange.
+type ClientOnTrackMetricsChangeFunc func(medi *description.Media, forma format.Format, metrics trackmetrics.Metrics)
+
 // OnPacketRTPFunc is the prototype of the callback passed to OnPacketRTP().
 type OnPacketRTPFunc func(*rtp.Packet)
 
@@ -446,20 +450,62 @@ type Client struct {
 	// timeout of write operations.
 	// It defaults to 10 seconds.
 	WriteTimeout time.Duration
+	// TCP keepalive settings of the control connection.
+	// Keepalive probes are sent when the connection is idle and allow to detect
+	// half-open connections (for instance, when the other side has lost power)
+	// within Idle + Interval * Count, instead of the operating system default.
+	// It defaults to nil (Go defaults: probes every 15 seconds).
+	TCPKeepAlive *net.KeepAliveConfig
+	// linger policy of the control connection, that is the time to wait,
+	// when the connection is closed, for unsent data to be delivered.
+	// Zero discards unsent data and resets the connection.
+	// It is rounded down to seconds.
+	// It defaults to nil (operating system default).
+	TCPLinger *time.Duration
 	// a TLS configuration to connect to TLS/RTSPS servers.
 	// It defaults to nil.
 	TLSConfig *tls.Config
 	// tunneling method.
+	// TunnelQUIC is experimental and always uses TLSConfig, regardless of the scheme.
 	Tunnel Tunnel
 	// transport protocol (UDP, Multicast or TCP).
 	// If nil, it is chosen automatically (first UDP, then, if it fails, TCP).
 	// It defaults to nil.
 	Protocol *Protocol
+	// policy that chooses transport protocols, depending on the ones that can be used.
+	// It can't be used together with Protocol.
+	// It defaults to TransportPolicyFixed when Protocol is set, otherwise to TransportPolicyAuto.
+	TransportPolicy TransportPolicy
 	// enable communication with servers which don't provide UDP server ports
 	// or use different server ports than the announced ones.
 	// This can be a security issue.
 	// It defaults to false.
 	AnyPortEnable bool
+	// enable communication with servers which reply with non-consecutive
+	// interleaved channels, without interleaved channels,
+	// or with interleaved channels already used by other medias.
+	// Shared channels are demultiplexed by payload type (RTP) and SSRC (RTCP).
+	// It defaults to false.
+	AnyInterleavedIDsEnable bool
+	// enable communication with servers which reply with a CSeq
+	// that doesn't match the one of the request.
+	// When enabled, responses are matched with requests by order.
+	// It defaults to false.
+	AnyCSeqEnable bool
+	// level of conformance to the specification that is required from the server.
+	// Lenient also allows to fall back to the request URL when Content-Base is invalid.
+	// It defaults to base.ConformanceDefault.
+	Conformance base.Conformance
+	// limits enforced when reading responses, requests and interleaved frames from the server.
+	// Zero values are replaced by default values.
+	Limits base.Limits
+	// how the query of the DESCRIBE URL (or Content-Base) is handled in control URLs of medias,
+	// that are used in SETUP and per-track TEARDOWN requests.
+	// Some servers require the query to be placed at the end of control URLs,
+	// others reject it.
+	// Aggregate requests (PLAY, PAUSE) always use the base URL, including its query.
+	// It defaults to base.ControlQueryAppend.
+	ControlQuery base.ControlQuery
 	// If the client is reading with UDP, it must receive
 	// at least a packet within this timeout, otherwise it switches to TCP.
 	// It defaults to 3 seconds.
@@ -468,20 +514,98 @@ type Client struct {
 	// This can be increased to reduce packet losses.
 	// It defaults to the operating system default value.
 	UDPReadBufferSize int
+	// range of local ports used with the UDP transport,
+	// when ports are not provided to Setup().
+	// It defaults to 10000-65535.
+	UDPPortMin int
+	UDPPortMax int
+	// number of packets sent to each server port before PLAY,
+	// in order to open NAT and firewall bindings.
+	// It defaults to 1.
+	UDPHolePunchCount int
+	// interval between packets sent to open NAT and firewall bindings.
+	// It defaults to 20 milliseconds.
+	UDPHolePunchInterval time.Duration
+	// payload of RTP packets sent to open NAT and firewall bindings.
+	// It defaults to nil (empty packets).
+	UDPHolePunchPayload []byte
 	// Size of the queue of outgoing packets.
 	// It defaults to 256.
 	WriteQueueSize int
+	// Whether to report write errors through OnWriteError instead of returning them.
+	// When enabled, WritePacketRTP() and WritePacketRTCP() discard packets when the queue is full,
+	// and errors that occur while sending UDP packets don't close the client.
+	// Errors that occur while sending interleaved frames (TCP transport) are still fatal,
+	// since the stream can't be recovered.
+	// It defaults to false.
+	AsyncWriteErrors bool
+	// model used to process incoming packets.
+	// It defaults to PacketProcessingDefault.
+	PacketProcessing PacketProcessing
+	// Size of the queue of incoming packets,
+	// used when PacketProcessing is not PacketProcessingDefault.
+	// It defaults to 256.
+	ReadQueueSize int
 	// maximum size of outgoing RTP / RTCP packets.
 	// This must be less than the UDP MTU (1472 bytes).
 	// It defaults to 1472.
 	MaxPacketSize int
+	// maximum size of outgoing RTP packets sent in interleaved frames (TCP transport).
+	// Bigger packets are split when the codec allows it (H264, H265),
+	// and sequence numbers of following packets are shifted.
+	// This is not applied to secure sessions.
+	// It defaults to zero (MaxPacketSize).
+	MaxInterleavedFrameSize int
 	// user agent header.
 	// It defaults to "gortsplib"
 	UserAgent string
 	// disable automatic RTCP sender reports.
 	DisableRTCPSenderReports bool
+	// period of RTCP sender and receiver reports.
+	// It defaults to 10 seconds for sender reports and 5 seconds for receiver reports.
+	RTCPReportPeriod time.Duration
+	// fraction of the RTP bandwidth that can be used by RTCP reports (RFC3550, section 6.2).
+	// When set, reports are skipped until the RTP traffic is big enough,
+	// reducing RTCP overhead of low-bitrate streams.
+	// It defaults to zero, that means that reports are sent at every period.
+	RTCPBandwidthFraction float64
 	// explicitly request back channels to the server.
 	RequestBackChannels bool
+	// enable ONVIF replay, that allows to play recordings stored by NVRs.
+	// When enabled, requests contain the "onvif-replay" requirement,
+	// PLAY requests contain replay options
+	// and PacketNTP() returns the absolute time carried by packets.
+	// It defaults to nil.
+	Replay *ClientReplay
+	// scale to request when playing, for fast-forward or rewind playback.
+	// The value confirmed by the server can be read with Scale().
+	// It defaults to nil.
+	PlayScale *float64
+	// speed to request when playing, that is the delivery rate.
+	// The value confirmed by the server can be read with Speed().
+	// It defaults to nil.
+	PlaySpeed *float64
+	// accept SSRC changes of incoming streams, resetting sequence numbers,
+	// reorder buffer and jitter of the format, instead of discarding packets.
+	// This is common when cameras restart their encoder.
+	// It defaults to false.
+	AllowSSRCChange bool
+	// minimum relative change of bitrate, frame rate or key frame interval
+	// of an incoming format that triggers OnTrackMetricsChange.
+	// It defaults to 0.1 (10%).
+	TrackMetricsThreshold float64
+	// tolerate interleaved frames sent by the server before streaming has started,
+	// for instance right after SETUP, instead of treating them as protocol errors.
+	// Frames received while streaming is not active are buffered
+	// and delivered once PLAY is sent.
+	// It defaults to false.
+	EarlyMediaEnable bool
+	// a capture where all RTP and RTCP packets, sent and received, are written.
+	// Packets exchanged through TCP are written as UDP datagrams whose ports
+	// are equal to the interleaved channel.
+	// This is meant for diagnostic purposes.
+	// It defaults to nil.
+	PacketDump *pcap.Writer
 
 	//
 	// system functions (all optional)
@@ -496,9 +620,13 @@ type Client struct {
 	//
 	// callbacks (all optional)
 	//
-	// called when sending a request to the server.
+	// called when sending a request to the server,
+	// including retries with credentials and keepalives.
+	// The request can be modified before it is sent.
+	// Retries are performed with a copy of the request, therefore each attempt is observed separately.
 	OnRequest ClientOnRequestFunc
-	// called when receiving a response from the server.
+	// called when receiving a response from the server,
+	// after it has been parsed and before it is processed.
 	OnResponse ClientOnResponseFunc
 	// called when receiving a request from the server.
 	OnServerRequest ClientOnRequestFunc
@@ -510,6 +638,24 @@ type Client struct {
 	OnPacketsLost ClientOnPacketsLostFunc
 	// called when a non-fatal decode error occurs.
 	OnDecodeError ClientOnDecodeErrorFunc
+	// called when a RTP packet is received with a payload type
+	// that doesn't match any format of the media, for instance after a codec change.
+	// When set, these packets are not reported through OnDecodeError.
+	// It is called by the reading routine and must not block.
+	OnUnknownPacket ClientOnUnknownPacketFunc
+	// called when a packet can't be written and AsyncWriteErrors is true.
+	OnWriteError ClientOnWriteErrorFunc
+	// called when the SSRC of an incoming format changes and AllowSSRCChange is true.
+	OnSSRCChange ClientOnSSRCChangeFunc
+	// called when codec parameters (H264 SPS/PPS, H265 VPS/SPS/PPS) of an incoming format
+	// are received in-band and are different from the current ones.
+	// Parameters of the format are updated before the call, therefore the new resolution
+	// can be read with the format methods.
+	OnParamsChange ClientOnParamsChangeFunc
+	// called when bitrate, frame rate or key frame interval of an incoming format
+	// change by more than TrackMetricsThreshold.
+	// Current values can also be read with Stats().
+	OnTrackMetricsChange ClientOnTrackMetricsChangeFunc
 
 	//
 	// private
@@ -523,24 +669,31 @@ type Client struct {
 	ctx                  context.Context
 	ctxCancel            func()
 	propsMutex           sync.RWMutex
+	serverInfo           ClientServerInfo
 	state                clientState
 	nconn                net.Conn
 	conn                 *conn.Conn
 	session              string
 	sender               *auth.Sender
 	cseq                 int
+	skippedCSeqs         []string
 	optionsSent          bool
 	useGetParameter      bool
 	lastDescribeURL      *base.URL
 	lastDescribeDesc     *description.Session
 	baseURL              *base.URL
 	announceData         map[*description.Media]*clientAnnounceDataMedia // record
+	transportPolicy      TransportPolicy
 	setuppedTransport    *SessionTransport
+	transportFallbacks   []liberrors.ClientSetupAttempt
 	backChannelSetupped  bool
 	stdChannelSetupped   bool
 	setuppedMedias       map[*description.Media]*clientMedia
+	setuppedMediasOrd    []*clientMedia
 	tcpCallbackByChannel map[int]readFunc
 	lastRange            *headers.Range
+	confirmedScale       *float64
+	confirmedSpeed       *float64
 	checkTimeoutTimer    *time.Timer
 	checkTimeoutInitial  bool
 	tcpLastFrameTime     *int64
@@ -549,6 +702,8 @@ type Client struct {
 	closeError           error
 	writerMutex          sync.RWMutex
 	writer               *asyncprocessor.Processor
+	readProcessor        *asyncprocessor.Processor
+	sinks                []*ClientSink
 	reader               *clientReader
 	timeDecoder          *rtptime.GlobalDecoder
 	mustClose            bool
@@ -558,17 +713,18 @@ type Client struct {
 	bytesSent            *uint64
 
 	// in
-	chOptions     chan optionsReq
-	chDescribe    chan describeReq
-	chAnnounce    chan announceReq
-	chSetup       chan setupReq
-	chPlay        chan playReq
-	chRecord      chan recordReq
-	chPause       chan pauseReq
-	chResponse    chan *base.Response
-	chRequest     chan *base.Request
-	chReadError   chan error
-	chWriterError chan error
+	chOptions       chan optionsReq
+	chDescribe      chan describeReq
+	chAnnounce      chan announceReq
+	chSetup         chan setupReq
+	chPlay          chan playReq
+	chRecord        chan recordReq
+	chPause         chan pauseReq
+	chTeardownMedia chan teardownMediaReq
+	chResponse      chan *base.Response
+	chRequest       chan *base.Request
+	chReadError     chan error
+	chWriterError   chan error
 
 	// out
 	done chan struct{}
@@ -591,14 +747,48 @@ func (c *Client) Start() error {
 	} else if (c.WriteQueueSize & (c.WriteQueueSize - 1)) != 0 {
 		return fmt.Errorf("WriteQueueSize must be a power of two")
 	}
+	if c.ReadQueueSize == 0 {
+		c.ReadQueueSize = 256
+	} else if (c.ReadQueueSize & (c.ReadQueueSize - 1)) != 0 {
+		return fmt.Errorf("ReadQueueSize must be a power of two")
+	}
 	if c.MaxPacketSize == 0 {
 		c.MaxPacketSize = udpMaxPayloadSize
 	} else if c.MaxPacketSize > udpMaxPayloadSize {
 		return fmt.Errorf("MaxPacketSize must be less than %d", udpMaxPayloadSize)
 	}
+	err := c.Limits.Validate()
+	if err != nil {
+		return err
+	}
 	if c.UserAgent == "" {
 		c.UserAgent = clientUserAgent
 	}
+	if c.UDPPortMin == 0 && c.UDPPortMax == 0 {
+		c.UDPPortMin = 10000
+		c.UDPPortMax = 65535
+	} else if (c.UDPPortMin%2) != 0 || c.UDPPortMax <= c.UDPPortMin {
+		return fmt.Errorf("UDPPortMin must be even and less than UDPPortMax")
+	}
+	if c.UDPHolePunchCount == 0 {
+		c.UDPHolePunchCount = 1
+	}
+	switch {
+	case c.TransportPolicy != nil && c.Protocol != nil:
+		return fmt.Errorf("Protocol and TransportPolicy can't be used together")
+
+	case c.TransportPolicy != nil:
+		c.transportPolicy = c.TransportPolicy
+
+	case c.Protocol != nil:
+		c.transportPolicy = TransportPolicyFixed{Protocol: *c.Protocol}
+
+	default:
+		c.transportPolicy = TransportPolicyAuto{}
+	}
+	if c.UDPHolePunchInterval == 0 {
+		c.UDPHolePunchInterval = 20 * time.Millisecond
+	}
 
 	// system functions
 	if c.DialContext == nil {
@@ -647,11 +837,37 @@ func (c *Client) Start() error {
 			log.Println(err.Error())
 		}
 	}
+	if c.OnWriteError == nil {
+		c.OnWriteError = func(err error) {
+			log.Println(err.Error())
+		}
+	}
+	if c.OnSSRCChange == nil {
+		c.OnSSRCChange = func(_ *description.Media, _ format.Format, prev uint32, cur uint32) {
+			log.Printf("SSRC changed from %d to %d", prev, cur)
+		}
+	}
+	if c.OnParamsChange == nil {
+		c.OnParamsChange = func(_ *description.Media, _ format.Format) {
+		}
+	}
+	if c.OnTrackMetricsChange == nil {
+		c.OnTrackMetricsChange = func(_ *description.Media, _ format.Format, _ trackmetrics.Metrics) {
+		}
+	}
 
 	// private
 	if c.timeNow == nil {
 		c.timeNow = time.Now
 	}
+	if c.RTCPReportPeriod != 0 {
+		if c.senderReportPeriod == 0 {
+			c.senderReportPeriod = c.RTCPReportPeriod
+		}
+		if c.receiverReportPeriod == 0 {
+			c.receiverReportPeriod = c.RTCPReportPeriod
+		}
+	}
 	if c.senderReportPeriod == 0 {
 		c.senderReportPeriod = 10 * time.Second
 	}
@@ -680,6 +896,7 @@ func (c *Client) Start() error {
 	c.chPlay = make(chan playReq)
 	c.chRecord = make(chan recordReq)
 	c.chPause = make(chan pauseReq)
+	c.chTeardownMedia = make(chan teardownMediaReq)
 	c.chResponse = make(chan *base.Response)
 	c.chRequest = make(chan *base.Request)
 	c.chReadError = make(chan error)
@@ -809,6 +1026,14 @@ func (c *Client) runInner() error {
 				return err
 			}
 
+		case req := <-c.chTeardownMedia:
+			res, err := c.doTeardownMedia(req.media)
+			req.res <- clientRes{res: res, err: err}
+
+			if c.mustClose {
+				return err
+			}
+
 		case <-c.checkTimeoutTimer.C:
 			err := c.doCheckTimeout()
 			if err != nil {
@@ -825,7 +1050,13 @@ func (c *Client) runInner() error {
 
 		case res := <-c.chResponse:
 			c.OnResponse(res)
+
 			// these are responses to keepalives, ignore them.
+			// When AnyCSeqEnable is set, a response with an unknown CSeq
+			// is the response to the oldest skipped request.
+			if !c.removeSkippedCSeq(res) && len(c.skippedCSeqs) != 0 {
+				c.skippedCSeqs = c.skippedCSeqs[1:]
+			}
 
 		case req := <-c.chRequest:
 			err := c.handleServerRequest(req)
@@ -847,6 +1078,24 @@ func (c *Client) runInner() error {
 	}
 }
 
+// removeSkippedCSeq removes the CSeq of a response from the CSeqs of requests whose response has been skipped.
+// It returns true if the CSeq was found.
+func (c *Client) removeSkippedCSeq(res *base.Response) bool {
+	cseq, ok := res.Header["CSeq"]
+	if !ok || len(cseq) != 1 {
+		return false
+	}
+
+	for i, v := range c.skippedCSeqs {
+		if v == strings.TrimSpace(cseq[0]) {
+			c.skippedCSeqs = append(c.skippedCSeqs[:i], c.skippedCSeqs[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *Client) waitResponse(requestCseqStr string) (*base.Response, error) {
 	t := time.NewTimer(c.ReadTimeout)
 	defer t.Stop()
@@ -866,7 +1115,23 @@ func (c *Client) waitResponse(requestCseqStr string) (*base.Response, error) {
 			c.OnResponse(res)
 
 			// accept response if CSeq equals request CSeq, or if CSeq is not present
-			if cseq, ok := res.Header["CSeq"]; !ok || len(cseq) != 1 || strings.TrimSpace(cseq[0]) == requestCseqStr {
+			cseq, ok := res.Header["CSeq"]
+			if !ok || len(cseq) != 1 || strings.TrimSpace(cseq[0]) == requestCseqStr {
+				return res, nil
+			}
+
+			// when AnyCSeqEnable is set, match responses by order:
+			// responses to requests whose response has been skipped come first.
+			if c.AnyCSeqEnable {
+				if c.removeSkippedCSeq(res) {
+					continue
+				}
+
+				if len(c.skippedCSeqs) != 0 {
+					c.skippedCSeqs = c.skippedCSeqs[1:]
+					continue
+				}
+
 				return res, nil
 			}
 
@@ -942,6 +1207,16 @@ func (c *Client) doClose() {
 	for _, cm := range c.setuppedMedias {
 		cm.close()
 	}
+
+	if c.readProcessor != nil {
+		c.readProcessor.Close()
+		c.readProcessor = nil
+	}
+
+	for _, s := range c.sinks {
+		s.close()
+	}
+	c.sinks = nil
 }
 
 func (c *Client) reset() {
@@ -951,6 +1226,7 @@ func (c *Client) reset() {
 	c.session = ""
 	c.sender = nil
 	c.cseq = 0
+	c.skippedCSeqs = nil
 	c.optionsSent = false
 	c.useGetParameter = false
 	c.baseURL = nil
@@ -958,6 +1234,7 @@ func (c *Client) reset() {
 	c.backChannelSetupped = false
 	c.stdChannelSetupped = false
 	c.setuppedMedias = nil
+	c.setuppedMediasOrd = nil
 	c.tcpCallbackByChannel = nil
 }
 
@@ -977,6 +1254,12 @@ func (c *Client) checkState(allowed map[clientState]struct{}) error {
 }
 
 func (c *Client) trySwitchingProtocol() error {
+	c.addTransportFallback(liberrors.ClientSetupAttempt{
+		URL:        c.baseURL,
+		Protocol:   c.setuppedTransport.Protocol,
+		StatusCode: base.StatusOK,
+		Err:        liberrors.ErrClientSwitchToTCP{},
+	})
 	c.OnTransportSwitch(liberrors.ErrClientSwitchToTCP{})
 
 	prevBaseURL := c.baseURL
@@ -989,13 +1272,20 @@ func (c *Client) trySwitchingProtocol() error {
 	}
 
 	// some Hikvision cameras require a describe before a setup
-	_, _, err := c.doDescribe(c.lastDescribeURL)
-	if err != nil {
-		return err
+	if c.lastDescribeURL != nil {
+		_, _, err := c.doDescribe(c.lastDescribeURL)
+		if err != nil {
+			return err
+		}
 	}
 
 	for i, cm := range prevMedias {
-		_, err = c.doSetup(prevBaseURL, cm.media, 0, 0)
+		// medias removed with TeardownMedia() are not setupped again
+		if cm.isTornDown() {
+			continue
+		}
+
+		_, err := c.doSetup(prevBaseURL, cm.media, 0, 0)
 		if err != nil {
 			return err
 		}
@@ -1006,7 +1296,7 @@ func (c *Client) trySwitchingProtocol() error {
 		}
 	}
 
-	_, err = c.doPlay(c.lastRange)
+	_, err := c.doPlay(c.lastRange)
 	if err != nil {
 		return err
 	}
@@ -1107,6 +1397,16 @@ func (c *Client) destroyWriter() {
 	c.writerMutex.Unlock()
 }
 
+// writeError returns err, or reports it through OnWriteError
+// when AsyncWriteErrors is enabled.
+func (c *Client) writeError(err error) error {
+	if c.AsyncWriteErrors {
+		c.OnWriteError(err)
+		return nil
+	}
+	return err
+}
+
 func (c *Client) connOpen() error {
 	if c.nconn != nil {
 		return nil
@@ -1135,26 +1435,59 @@ func (c *Client) connOpen() error {
 		}
 	}
 
+	dialContext := func(ctx context.Context, network, address string) (net.Conn, error) {
+		nconn, err := c.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		err = setTCPConnOptions(nconn, c.TCPKeepAlive, c.TCPLinger)
+		if err != nil {
+			nconn.Close()
+			return nil, err
+		}
+
+		return nconn, nil
+	}
+
 	var nconn net.Conn
 
 	switch c.Tunnel {
 	case TunnelHTTP:
 		var err error
-		nconn, err = newClientTunnelHTTP(dialCtx, c.DialContext, addr, tlsConfig)
+		nconn, err = newClientTunnelHTTP(dialCtx, dialContext, addr, tlsConfig)
 		if err != nil {
 			return err
 		}
 
 	case TunnelWebSocket:
 		var err error
-		nconn, err = newClientTunnelWebSocket(dialCtx, c.DialContext, addr, tlsConfig)
+		nconn, err = newClientTunnelWebSocket(dialCtx, dialContext, addr, tlsConfig)
+		if err != nil {
+			return err
+		}
+
+	case TunnelQUIC:
+		// QUIC is always encrypted
+		if tlsConfig == nil {
+			tlsConfig = c.TLSConfig
+			if tlsConfig == nil {
+				host, _, _ := net.SplitHostPort(addr)
+				tlsConfig = &tls.Config{
+					ServerName: host,
+				}
+			}
+		}
+
+		var err error
+		nconn, err = newClientTunnelQUIC(dialCtx, addr, tlsConfig)
 		if err != nil {
 			return err
 		}
 
 	default:
 		var err error
-		nconn, err = c.DialContext(dialCtx, "tcp", addr)
+		nconn, err = dialContext(dialCtx, "tcp", addr)
 		if err != nil {
 			return err
 		}
@@ -1166,7 +1499,9 @@ func (c *Client) connOpen() error {
 
 	c.nconn = nconn
 	bc := bytecounter.New(c.nconn, c.bytesReceived, c.bytesSent)
-	c.conn = conn.NewConn(bufio.NewReader(bc), bc)
+	c.conn = conn.NewConn(bufio.NewReaderSize(bc, c.Limits.ReaderSize()), bc)
+	c.conn.SetLimits(&c.Limits)
+	c.conn.SetConformance(c.Conformance)
 	c.reader = &clientReader{
 		c: c,
 	}
@@ -1197,6 +1532,13 @@ func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error
 
 	req.Header["User-Agent"] = base.HeaderValue{c.UserAgent}
 
+	// requests retried with authentication already contain the requirement.
+	if c.Replay != nil && req.Method != base.Options && !requiresReplay(req.Header) {
+		req.Header["Require"] = base.HeaderValue{
+			strings.Join(append(req.Header["Require"], onvif.ReplayRequire), ", "),
+		}
+	}
+
 	if c.sender != nil {
 		c.sender.AddAuthorization(req)
 	}
@@ -1210,6 +1552,9 @@ func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error
 	}
 
 	if skipResponse {
+		if c.AnyCSeqEnable {
+			c.skippedCSeqs = append(c.skippedCSeqs, cseqStr)
+		}
 		return nil, nil
 	}
 
@@ -1219,6 +1564,8 @@ func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error
 		return nil, err
 	}
 
+	c.updateServerInfo(req, res)
+
 	// get session from response
 	if v, ok := res.Header["Session"]; ok {
 		var sx headers.Session
@@ -1252,7 +1599,8 @@ func (c *Client) do(req *base.Request, skipResponse bool) (*base.Response, error
 		}
 		c.sender = sender
 
-		return c.do(req, skipResponse)
+		// retry with a copy, in order not to alter the request passed to OnRequest.
+		return c.do(cloneRequest(req), skipResponse)
 	}
 
 	return res, nil
@@ -1295,10 +1643,50 @@ func (c *Client) isInTCPTimeout() bool {
 	return now.Sub(lft) >= c.ReadTimeout
 }
 
+func (c *Client) transportCapabilities(profile headers.TransportProfile) TransportCapabilities {
+	caps := TransportCapabilities{
+		Record:   c.state == clientStatePreRecord || c.state == clientStateRecord,
+		Secure:   profile == headers.TransportProfileSAVP,
+		Tunneled: c.Tunnel != TunnelNone,
+	}
+
+	if c.Tunnel == TunnelNone && (profile == headers.TransportProfileSAVP || c.Scheme == "rtsp") {
+		caps.Protocols = append(caps.Protocols, ProtocolUDP)
+		if !caps.Record {
+			caps.Protocols = append(caps.Protocols, ProtocolUDPMulticast)
+		}
+	}
+
+	caps.Protocols = append(caps.Protocols, ProtocolTCP)
+
+	return caps
+}
+
+// canSwitchToTCP returns whether the transport policy allows to switch to TCP
+// after the first protocol fails.
+func (c *Client) canSwitchToTCP(profile headers.TransportProfile) bool {
+	protocols := c.transportPolicy.Protocols(c.transportCapabilities(profile))
+	return len(protocols) > 1 && slices.Contains(protocols[1:], ProtocolTCP)
+}
+
+// preferredProtocol returns the first protocol chosen by the transport policy
+// when all protocols can be used.
+func (c *Client) preferredProtocol() Protocol {
+	protocols := c.transportPolicy.Protocols(TransportCapabilities{
+		Record:    true,
+		Secure:    true,
+		Protocols: []Protocol{ProtocolUDP, ProtocolUDPMulticast, ProtocolTCP},
+	})
+	if len(protocols) == 0 {
+		return ProtocolTCP
+	}
+	return protocols[0]
+}
+
 func (c *Client) doCheckTimeout() error {
 	if c.setuppedTransport.Protocol == ProtocolUDP ||
 		c.setuppedTransport.Protocol == ProtocolUDPMulticast {
-		if c.checkTimeoutInitial && !c.backChannelSetupped && c.Protocol == nil {
+		if c.checkTimeoutInitial && !c.backChannelSetupped && c.canSwitchToTCP(c.setuppedTransport.Profile) {
 			c.checkTimeoutInitial = false
 
 			if !c.atLeastOneUDPPacketHasBeenReceived() {
@@ -1470,9 +1858,14 @@ func (c *Client) doDescribe(u *base.URL) (*description.Session, *base.Response,
 		return nil, nil, liberrors.ErrClientSDPInvalid{Err: err}
 	}
 
-	baseURL, err := findBaseURL(&ssd, res, u)
+	control, _ := ssd.Attribute("control")
+
+	baseURL, err := base.ResolveBaseURL(u, control, res)
 	if err != nil {
-		return nil, nil, err
+		if c.Conformance != base.ConformanceLenient {
+			return nil, nil, err
+		}
+		baseURL = u
 	}
 	desc.BaseURL = baseURL
 
@@ -1503,7 +1896,7 @@ func (c *Client) doAnnounce(u *base.URL, desc *description.Session) (*base.Respo
 		return nil, err
 	}
 
-	if c.Protocol != nil && *c.Protocol == ProtocolUDPMulticast {
+	if c.preferredProtocol() == ProtocolUDPMulticast {
 		return nil, fmt.Errorf("recording with UDP multicast is not supported")
 	}
 
@@ -1516,7 +1909,7 @@ func (c *Client) doAnnounce(u *base.URL, desc *description.Session) (*base.Respo
 	var secure bool
 
 	// Determine secure flag: TCP+RTSPS depends on media profile, others depend on scheme
-	if c.Protocol != nil && *c.Protocol == ProtocolTCP && c.Scheme == "rtsps" {
+	if c.preferredProtocol() == ProtocolTCP && c.Scheme == "rtsps" {
 		// Check for all medias: if any media uses a secure profile, then secure is true
 		for _, medi := range desc.Medias {
 			if isSecure(medi.Profile) {
@@ -1586,6 +1979,17 @@ func (c *Client) doSetup(
 	medi *description.Media,
 	rtpPort int,
 	rtcpPort int,
+) (*base.Response, error) {
+	var attempts []liberrors.ClientSetupAttempt
+	return c.doSetupInner(baseURL, medi, rtpPort, rtcpPort, &attempts)
+}
+
+func (c *Client) doSetupInner(
+	baseURL *base.URL,
+	medi *description.Media,
+	rtpPort int,
+	rtcpPort int,
+	attempts *[]liberrors.ClientSetupAttempt,
 ) (*base.Response, error) {
 	err := c.checkState(map[clientState]struct{}{
 		clientStateInitial:   {},
@@ -1621,15 +2025,7 @@ func (c *Client) doSetup(
 		protocol = c.setuppedTransport.Protocol
 		th.Profile = c.setuppedTransport.Profile
 
-	// use transport from config, secure flag from server
-	case c.Protocol != nil:
-		protocol = *c.Protocol
-		if isSecure(medi.Profile) && c.Scheme == "rtsps" {
-			th.Profile = headers.TransportProfileSAVP
-		} else {
-			th.Profile = headers.TransportProfileAVP
-		}
-
+	// use transport from policy, secure flag from server
 	default:
 		if isSecure(medi.Profile) && c.Scheme == "rtsps" {
 			th.Profile = headers.TransportProfileSAVP
@@ -1637,14 +2033,11 @@ func (c *Client) doSetup(
 			th.Profile = headers.TransportProfileAVP
 		}
 
-		// try
-		// - UDP if unencrypted or secure is supported by server
-		// - otherwise, TCP
-		if c.Tunnel == TunnelNone && (th.Profile == headers.TransportProfileSAVP || c.Scheme == "rtsp") {
-			protocol = ProtocolUDP
-		} else {
-			protocol = ProtocolTCP
+		protocols := c.transportPolicy.Protocols(c.transportCapabilities(th.Profile))
+		if len(protocols) == 0 {
+			return nil, liberrors.ErrClientNoTransportProtocol{}
 		}
+		protocol = protocols[0]
 	}
 
 	var localSSRCs map[uint8]uint32
@@ -1667,6 +2060,7 @@ func (c *Client) doSetup(
 	var udpRTPListener *clientUDPListener
 	var udpRTCPListener *clientUDPListener
 	var tcpChannel int
+	var tcpRTCPChannel int
 	var srtpInCtx *wrappedSRTPContext
 	var srtpOutCtx *wrappedSRTPContext
 
@@ -1724,7 +2118,11 @@ func (c *Client) doSetup(
 		th.InterleavedIDs = &[2]int{ch, ch + 1}
 	}
 
-	mediaURL, err := medi.URL(baseURL)
+	if medi.IsBackChannel && !c.RequestBackChannels {
+		return nil, fmt.Errorf("we are setupping a back channel but we did not request back channels")
+	}
+
+	mediaURL, err := base.ResolveControlWithQuery(baseURL, medi.Control, c.ControlQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -1734,10 +2132,6 @@ func (c *Client) doSetup(
 	}
 
 	if medi.IsBackChannel {
-		if !c.RequestBackChannels {
-			return nil, fmt.Errorf("we are setupping a back channel but we did not request back channels")
-		}
-
 		header["Require"] = base.HeaderValue{"www.onvif.org/ver20/backchannel"}
 	}
 
@@ -1790,33 +2184,52 @@ func (c *Client) doSetup(
 		return nil, err
 	}
 
+	addAttempt := func(err error) liberrors.ClientSetupAttempt {
+		a := liberrors.ClientSetupAttempt{
+			URL:        mediaURL,
+			Protocol:   protocol,
+			StatusCode: res.StatusCode,
+			Err:        err,
+		}
+		*attempts = append(*attempts, a)
+		return a
+	}
+
+	// return an error containing all attempts
+	failSetup := func(err error) error {
+		addAttempt(err)
+		return liberrors.ErrClientSetupFailed{Attempts: *attempts}
+	}
+
 	if res.StatusCode != base.StatusOK {
 		// switch transport automatically
 		if res.StatusCode == base.StatusUnsupportedTransport &&
-			c.setuppedTransport == nil && c.Protocol == nil {
+			c.setuppedTransport == nil && c.canSwitchToTCP(th.Profile) {
+			c.addTransportFallback(addAttempt(liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage}))
 			c.OnTransportSwitch(liberrors.ErrClientSwitchToTCP2{})
 			c.setuppedTransport = &SessionTransport{
 				Protocol: ProtocolTCP,
 				Profile:  th.Profile,
 			}
 
-			return c.doSetup(baseURL, medi, 0, 0)
+			return c.doSetupInner(baseURL, medi, 0, 0, attempts)
 		}
 
-		return nil, liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage}
+		return nil, failSetup(liberrors.ErrClientBadStatusCode{Code: res.StatusCode, Message: res.StatusMessage})
 	}
 
 	var thRes headers.Transport
 	err = thRes.Unmarshal(res.Header["Transport"])
 	if err != nil {
-		return nil, liberrors.ErrClientTransportHeaderInvalid{Err: err}
+		return nil, failSetup(liberrors.ErrClientTransportHeaderInvalid{Err: err})
 	}
 
 	switch protocol {
 	case ProtocolUDP, ProtocolUDPMulticast:
 		if thRes.Protocol == headers.TransportProtocolTCP {
 			// switch transport automatically
-			if c.setuppedTransport == nil && c.Protocol == nil {
+			if c.setuppedTransport == nil && c.canSwitchToTCP(th.Profile) {
+				c.addTransportFallback(addAttempt(liberrors.ErrClientServerRequestedTCP{}))
 				c.OnTransportSwitch(liberrors.ErrClientSwitchToTCP2{})
 
 				c.baseURL = baseURL
@@ -1829,28 +2242,30 @@ func (c *Client) doSetup(
 				}
 
 				// some Hikvision cameras require a describe before a setup
-				_, _, err = c.doDescribe(c.lastDescribeURL)
-				if err != nil {
-					return nil, err
+				if c.lastDescribeURL != nil {
+					_, _, err = c.doDescribe(c.lastDescribeURL)
+					if err != nil {
+						return nil, err
+					}
 				}
 
-				return c.doSetup(baseURL, medi, 0, 0)
+				return c.doSetupInner(baseURL, medi, 0, 0, attempts)
 			}
 
-			return nil, liberrors.ErrClientServerRequestedTCP{}
+			return nil, failSetup(liberrors.ErrClientServerRequestedTCP{})
 		}
 	}
 
 	switch protocol {
 	case ProtocolUDP:
 		if thRes.Delivery != nil && *thRes.Delivery != headers.TransportDeliveryUnicast {
-			return nil, liberrors.ErrClientTransportHeaderInvalidDelivery{}
+			return nil, failSetup(liberrors.ErrClientTransportHeaderInvalidDelivery{})
 		}
 
 		serverPortsValid := thRes.ServerPorts != nil && !isAnyPort(thRes.ServerPorts[0]) && !isAnyPort(thRes.ServerPorts[1])
 
 		if (c.state == clientStatePreRecord || !c.AnyPortEnable) && !serverPortsValid {
-			return nil, liberrors.ErrClientServerPortsNotProvided{}
+			return nil, failSetup(liberrors.ErrClientServerPortsNotProvided{})
 		}
 
 		var remoteIP net.IP
@@ -1895,7 +2310,7 @@ func (c *Client) doSetup(
 
 	case ProtocolUDPMulticast:
 		if thRes.Delivery == nil || *thRes.Delivery != headers.TransportDeliveryMulticast {
-			return nil, liberrors.ErrClientTransportHeaderInvalidDelivery{}
+			return nil, failSetup(liberrors.ErrClientTransportHeaderInvalidDelivery{})
 		}
 
 		var remoteIP net.IP
@@ -1916,7 +2331,7 @@ func (c *Client) doSetup(
 
 		var destIP net.IP
 		if thRes.Destination2 == nil {
-			return nil, liberrors.ErrClientTransportHeaderNoDestination{}
+			return nil, failSetup(liberrors.ErrClientTransportHeaderNoDestination{})
 		}
 		if ip := net.ParseIP(*thRes.Destination2); ip != nil {
 			destIP = ip
@@ -1930,7 +2345,7 @@ func (c *Client) doSetup(
 		}
 
 		if thRes.Ports == nil {
-			return nil, liberrors.ErrClientTransportHeaderNoPorts{}
+			return nil, failSetup(liberrors.ErrClientTransportHeaderNoPorts{})
 		}
 
 		var intf *net.Interface
@@ -1966,28 +2381,36 @@ func (c *Client) doSetup(
 
 	case ProtocolTCP:
 		if thRes.Protocol != headers.TransportProtocolTCP {
-			return nil, liberrors.ErrClientServerRequestedUDP{}
+			return nil, failSetup(liberrors.ErrClientServerRequestedUDP{})
 		}
 
 		if thRes.Delivery != nil && *thRes.Delivery != headers.TransportDeliveryUnicast {
-			return nil, liberrors.ErrClientTransportHeaderInvalidDelivery{}
+			return nil, failSetup(liberrors.ErrClientTransportHeaderInvalidDelivery{})
 		}
 
 		if thRes.InterleavedIDs == nil {
-			return nil, liberrors.ErrClientTransportHeaderNoInterleavedIDs{}
-		}
+			if !c.AnyInterleavedIDsEnable {
+				return nil, failSetup(liberrors.ErrClientTransportHeaderNoInterleavedIDs{})
+			}
 
-		if (thRes.InterleavedIDs[0] + 1) != thRes.InterleavedIDs[1] {
-			return nil, liberrors.ErrClientTransportHeaderInvalidInterleavedIDs{}
+			// assume that the server is using the requested channels
+			thRes.InterleavedIDs = th.InterleavedIDs
 		}
 
-		if c.isChannelPairInUse(thRes.InterleavedIDs[0]) {
-			return &base.Response{
-				StatusCode: base.StatusBadRequest,
-			}, liberrors.ErrClientTransportHeaderInterleavedIDsInUse{}
+		if !c.AnyInterleavedIDsEnable {
+			if (thRes.InterleavedIDs[0] + 1) != thRes.InterleavedIDs[1] {
+				return nil, failSetup(liberrors.ErrClientTransportHeaderInvalidInterleavedIDs{})
+			}
+
+			if c.isChannelPairInUse(thRes.InterleavedIDs[0]) {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, liberrors.ErrClientTransportHeaderInterleavedIDsInUse{}
+			}
 		}
 
 		tcpChannel = thRes.InterleavedIDs[0]
+		tcpRTCPChannel = thRes.InterleavedIDs[1]
 	}
 
 	if thRes.Profile != th.Profile {
@@ -2013,7 +2436,7 @@ func (c *Client) doSetup(
 		case medi.KeyMgmtMikey != nil:
 			mikeyMsg = medi.KeyMgmtMikey
 
-		case c.lastDescribeDesc.KeyMgmtMikey != nil:
+		case c.lastDescribeDesc != nil && c.lastDescribeDesc.KeyMgmtMikey != nil:
 			mikeyMsg = c.lastDescribeDesc.KeyMgmtMikey
 
 		default:
@@ -2033,6 +2456,7 @@ func (c *Client) doSetup(
 		udpRTPListener:  udpRTPListener,
 		udpRTCPListener: udpRTCPListener,
 		tcpChannel:      tcpChannel,
+		tcpRTCPChannel:  tcpRTCPChannel,
 		localSSRCs:      localSSRCs,
 		srtpInCtx:       srtpInCtx,
 		srtpOutCtx:      srtpOutCtx,
@@ -2048,6 +2472,7 @@ func (c *Client) doSetup(
 		c.setuppedMedias = make(map[*description.Media]*clientMedia)
 	}
 	c.setuppedMedias[medi] = cm
+	c.setuppedMediasOrd = append(c.setuppedMediasOrd, cm)
 
 	c.baseURL = baseURL
 	c.setuppedTransport = &SessionTransport{
@@ -2072,7 +2497,8 @@ func (c *Client) doSetup(
 
 func (c *Client) isChannelPairInUse(channel int) bool {
 	for _, cm := range c.setuppedMedias {
-		if (cm.tcpChannel+1) == channel || cm.tcpChannel == channel || cm.tcpChannel == (channel+1) {
+		if cm.tcpRTCPChannel == channel || cm.tcpChannel == channel ||
+			cm.tcpChannel == (channel+1) || cm.tcpRTCPChannel == (channel+1) {
 			return true
 		}
 	}
@@ -2124,6 +2550,19 @@ func (c *Client) SetupAll(baseURL *base.URL, medias []*description.Media) error
 	return nil
 }
 
+// SetupSession setups all the medias of a session description that has been
+// obtained out of band (for instance, from a SDP file or from prior knowledge),
+// without sending a DESCRIBE request.
+// If the session description doesn't provide a base URL, u is used.
+func (c *Client) SetupSession(u *base.URL, desc *description.Session) error {
+	baseURL := desc.BaseURL
+	if baseURL == nil {
+		baseURL = u
+	}
+
+	return c.SetupAll(baseURL, desc.Medias)
+}
+
 func (c *Client) doPlay(ra *headers.Range) (*base.Response, error) {
 	err := c.checkState(map[clientState]struct{}{
 		clientStatePrePlay: {},
@@ -2153,38 +2592,37 @@ func (c *Client) doPlay(ra *headers.Range) (*base.Response, error) {
 		header["Require"] = base.HeaderValue{"www.onvif.org/ver20/backchannel"}
 	}
 
+	if c.PlayScale != nil {
+		header["Scale"] = headers.Scale{Value: *c.PlayScale}.Marshal()
+	}
+
+	if c.PlaySpeed != nil {
+		header["Speed"] = headers.Speed{Value: *c.PlaySpeed}.Marshal()
+	}
+
+	if c.Replay != nil {
+		header["Rate-Control"] = headers.RateControl{Value: !c.Replay.DisableRateControl}.Marshal()
+
+		if c.Replay.Immediate {
+			header["Immediate"] = headers.Immediate{Value: true}.Marshal()
+		}
+	}
+
 	// when protocol is UDP,
-	// open the firewall by sending empty packets to the remote part.
+	// open the firewall by sending packets to the remote part.
 	// do this before sending the PLAY request.
 	if c.setuppedTransport.Protocol == ProtocolUDP {
-		for _, cm := range c.setuppedMedias {
-			if !cm.media.IsBackChannel && cm.udpRTPListener.writeAddr != nil {
-				buf, _ := (&rtp.Packet{Header: rtp.Header{Version: 2}}).Marshal()
-				if cm.srtpOutCtx != nil {
-					encr := make([]byte, cm.c.MaxPacketSize)
-					encr, err = cm.srtpOutCtx.encryptRTP(encr, buf, nil)
-					if err != nil {
-						return nil, err
-					}
-					buf = encr
-				}
-				err = cm.udpRTPListener.write(buf)
-				if err != nil {
-					return nil, err
-				}
+		for i := range c.UDPHolePunchCount {
+			if i != 0 {
+				time.Sleep(c.UDPHolePunchInterval)
+			}
 
-				buf, _ = (&rtcp.ReceiverReport{}).Marshal()
-				if cm.srtpOutCtx != nil {
-					encr := make([]byte, cm.c.MaxPacketSize)
-					encr, err = cm.srtpOutCtx.encryptRTCP(encr, buf, nil)
+			for _, cm := range c.setuppedMedias {
+				if !cm.media.IsBackChannel && cm.udpRTPListener.writeAddr != nil {
+					err = cm.punchHoles()
 					if err != nil {
 						return nil, err
 					}
-					buf = encr
-				}
-				err = cm.udpRTCPListener.write(buf)
-				if err != nil {
-					return nil, err
 				}
 			}
 		}
@@ -2215,6 +2653,21 @@ func (c *Client) doPlay(ra *headers.Range) (*base.Response, error) {
 
 	c.lastRange = ra
 
+	c.propsMutex.Lock()
+	c.confirmedScale = nil
+	c.confirmedSpeed = nil
+
+	var scale headers.Scale
+	if scale.Unmarshal(res.Header["Scale"]) == nil {
+		c.confirmedScale = &scale.Value
+	}
+
+	var speed headers.Speed
+	if speed.Unmarshal(res.Header["Speed"]) == nil {
+		c.confirmedSpeed = &speed.Value
+	}
+	c.propsMutex.Unlock()
+
 	return res, nil
 }
 
@@ -2338,6 +2791,80 @@ func (c *Client) Pause() (*base.Response, error) {
 	}
 }
 
+func (c *Client) doTeardownMedia(medi *description.Media) (*base.Response, error) {
+	err := c.checkState(map[clientState]struct{}{
+		clientStatePrePlay:   {},
+		clientStatePlay:      {},
+		clientStatePreRecord: {},
+		clientStateRecord:    {},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cm, ok := c.setuppedMedias[medi]
+	if !ok || cm.isTornDown() {
+		return nil, liberrors.ErrClientMediaNotSetup{}
+	}
+
+	if len(c.Medias()) == 1 {
+		return nil, fmt.Errorf("the last media cannot be torn down, close the client instead")
+	}
+
+	mediaURL, err := base.ResolveControlWithQuery(c.baseURL, medi.Control, c.ControlQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.do(&base.Request{
+		Method: base.Teardown,
+		URL:    mediaURL,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, liberrors.ErrClientBadStatusCode{
+			Code: res.StatusCode, Message: res.StatusMessage,
+		}
+	}
+
+	cm.tearDown()
+
+	return res, nil
+}
+
+// TeardownMedia sends a TEARDOWN request addressed to a single media,
+// that is removed from the session while the other medias keep working.
+// This can be called only after Setup(), and cannot be used to remove the last media.
+func (c *Client) TeardownMedia(medi *description.Media) (*base.Response, error) {
+	cres := make(chan clientRes)
+	select {
+	case c.chTeardownMedia <- teardownMediaReq{media: medi, res: cres}:
+		res := <-cres
+		return res.res, res.err
+
+	case <-c.done:
+		return nil, c.closeError
+	}
+}
+
+// Medias returns the medias that are currently setupped.
+// Medias removed with TeardownMedia() are not included.
+func (c *Client) Medias() []*description.Media {
+	c.propsMutex.RLock()
+	defer c.propsMutex.RUnlock()
+
+	var ret []*description.Media
+	for _, cm := range c.setuppedMediasOrd {
+		if !cm.isTornDown() {
+			ret = append(ret, cm.media)
+		}
+	}
+	return ret
+}
+
 // OnPacketRTPAny sets a callback that is called when a RTP packet is read from any setupped media.
 func (c *Client) OnPacketRTPAny(cb OnPacketRTPAnyFunc) {
 	for _, cm := range c.setuppedMedias {
@@ -2367,6 +2894,31 @@ func (c *Client) OnPacketRTP(medi *description.Media, forma format.Format, cb On
 	ct.onPacketRTP = cb
 }
 
+// AddSink adds a sink that receives RTP packets of a format,
+// in addition to the callback set with OnPacketRTP.
+// The same sink can be added to multiple formats.
+// It must be called before Play().
+func (c *Client) AddSink(medi *description.Media, forma format.Format, sink *ClientSink) {
+	if sink.processor == nil {
+		sink.initialize(c)
+		c.sinks = append(c.sinks, sink)
+	}
+
+	cm := c.setuppedMedias[medi]
+	ct := cm.formats[forma.PayloadType()]
+	ct.sinks = append(ct.sinks, sink)
+}
+
+// AddSinkAny adds a sink that receives RTP packets of any setupped media.
+// It must be called before Play().
+func (c *Client) AddSinkAny(sink *ClientSink) {
+	for _, cm := range c.setuppedMedias {
+		for _, forma := range cm.media.Formats {
+			c.AddSink(cm.media, forma, sink)
+		}
+	}
+}
+
 // OnPacketRTCP sets a callback that is called when a RTCP packet is read.
 func (c *Client) OnPacketRTCP(medi *description.Media, cb OnPacketRTCPFunc) {
 	cm := c.setuppedMedias[medi]
@@ -2381,6 +2933,26 @@ func (c *Client) WritePacketRTP(medi *description.Media, pkt *rtp.Packet) error
 // WritePacketRTPWithNTP writes a RTP packet to the server.
 // ntp is the absolute timestamp of the packet, and is sent with periodic RTCP sender reports.
 func (c *Client) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet, ntp time.Time) error {
+	return c.writePacketRTP(medi, pkt, ntp, time.Time{})
+}
+
+// TryWritePacketRTP writes a RTP packet to the server.
+// If the write queue is full, the packet is discarded and ErrClientWouldBlock is returned,
+// allowing the caller to implement its own dropping strategy.
+// OnWriteError is not called.
+func (c *Client) TryWritePacketRTP(medi *description.Media, pkt *rtp.Packet) error {
+	return c.writePacketRTP(medi, pkt, c.timeNow(), time.Now())
+}
+
+// WritePacketRTPWithTimeout writes a RTP packet to the server.
+// If the write queue is full, it waits until there's space or the timeout expires,
+// then it returns ErrClientWouldBlock.
+// OnWriteError is not called.
+func (c *Client) WritePacketRTPWithTimeout(medi *description.Media, pkt *rtp.Packet, timeout time.Duration) error {
+	return c.writePacketRTP(medi, pkt, c.timeNow(), time.Now().Add(timeout))
+}
+
+func (c *Client) writePacketRTP(medi *description.Media, pkt *rtp.Packet, ntp time.Time, deadline time.Time) error {
 	select {
 	case <-c.done:
 		return c.closeError
@@ -2388,8 +2960,12 @@ func (c *Client) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet,
 	}
 
 	cm := c.setuppedMedias[medi]
+	if cm.isTornDown() {
+		return liberrors.ErrClientMediaNotSetup{}
+	}
+
 	cf := cm.formats[pkt.PayloadType]
-	return cf.writePacketRTP(pkt, ntp)
+	return cf.writePacketRTP(pkt, ntp, deadline)
 }
 
 // WritePacketRTCP writes a RTCP packet to the server.
@@ -2401,6 +2977,10 @@ func (c *Client) WritePacketRTCP(medi *description.Media, pkt rtcp.Packet) error
 	}
 
 	cm := c.setuppedMedias[medi]
+	if cm.isTornDown() {
+		return liberrors.ErrClientMediaNotSetup{}
+	}
+
 	return cm.writePacketRTCP(pkt)
 }
 
@@ -2414,12 +2994,43 @@ func (c *Client) PacketPTS(medi *description.Media, pkt *rtp.Packet) (int64, boo
 
 // PacketNTP returns the NTP (absolute timestamp) of an incoming RTP packet.
 // The NTP is computed from RTCP sender reports.
+// When ONVIF replay is enabled, the NTP is read from the replay RTP header extension.
 func (c *Client) PacketNTP(medi *description.Media, pkt *rtp.Packet) (time.Time, bool) {
+	if c.Replay != nil {
+		var ext onvif.ReplayExtension
+		err := ext.Unmarshal(&pkt.Header)
+		if err == nil {
+			return ext.NTP, true
+		}
+	}
+
 	cm := c.setuppedMedias[medi]
 	ct := cm.formats[pkt.PayloadType]
 	return ct.rtpReceiver.PacketNTP(pkt.Timestamp)
 }
 
+// Scale returns the scale confirmed by the server in the last PLAY response.
+func (c *Client) Scale() (float64, bool) {
+	c.propsMutex.RLock()
+	defer c.propsMutex.RUnlock()
+
+	if c.confirmedScale == nil {
+		return 0, false
+	}
+	return *c.confirmedScale, true
+}
+
+// Speed returns the speed confirmed by the server in the last PLAY response.
+func (c *Client) Speed() (float64, bool) {
+	c.propsMutex.RLock()
+	defer c.propsMutex.RUnlock()
+
+	if c.confirmedSpeed == nil {
+		return 0, false
+	}
+	return *c.confirmedSpeed, true
+}
+
 // Transport returns transport details.
 func (c *Client) Transport() *ClientTransport {
 	c.propsMutex.RLock()
@@ -2429,7 +3040,42 @@ func (c *Client) Transport() *ClientTransport {
 		Conn: ConnTransport{
 			Tunnel: c.Tunnel,
 		},
-		Session: c.setuppedTransport,
+		Session:   c.setuppedTransport,
+		Fallbacks: append([]liberrors.ClientSetupAttempt(nil), c.transportFallbacks...),
+	}
+}
+
+// addTransportFallback records an attempt that caused an automatic transport switch.
+func (c *Client) addTransportFallback(a liberrors.ClientSetupAttempt) {
+	c.propsMutex.Lock()
+	defer c.propsMutex.Unlock()
+
+	c.transportFallbacks = append(c.transportFallbacks, a)
+}
+
+// ServerInfo returns informations that the server provided about itself.
+func (c *Client) ServerInfo() *ClientServerInfo {
+	c.propsMutex.RLock()
+	defer c.propsMutex.RUnlock()
+
+	ret := c.serverInfo
+	return &ret
+}
+
+func (c *Client) updateServerInfo(req *base.Request, res *base.Response) {
+	c.propsMutex.Lock()
+	defer c.propsMutex.Unlock()
+
+	if v, ok := res.Header["Server"]; ok && len(v) == 1 {
+		c.serverInfo.Server = v[0]
+	}
+
+	if req.Method == base.Options && res.StatusCode == base.StatusOK {
+		c.serverInfo.Methods = nil
+		for _, m := range parseHeaderList(res.Header["Public"]) {
+			c.serverInfo.Methods = append(c.serverInfo.Methods, base.Method(m))
+		}
+		c.serverInfo.Supported = parseHeaderList(res.Header["Supported"])
 	}
 }
 
@@ -2443,12 +3089,13 @@ func (c *Client) Stats() *ClientStats {
 
 		for med, sm := range c.setuppedMedias {
 			ret[med] = SessionStatsMedia{
-				BytesReceived:       atomic.LoadUint64(sm.bytesReceived),
-				BytesSent:           atomic.LoadUint64(sm.bytesSent),
-				RTPPacketsInError:   atomic.LoadUint64(sm.rtpPacketsInError),
-				RTCPPacketsReceived: atomic.LoadUint64(sm.rtcpPacketsReceived),
-				RTCPPacketsSent:     atomic.LoadUint64(sm.rtcpPacketsSent),
-				RTCPPacketsInError:  atomic.LoadUint64(sm.rtcpPacketsInError),
+				BytesReceived:                atomic.LoadUint64(sm.bytesReceived),
+				BytesSent:                    atomic.LoadUint64(sm.bytesSent),
+				RTPPacketsInError:            atomic.LoadUint64(sm.rtpPacketsInError),
+				RTPPacketsUnknownPayloadType: atomic.LoadUint64(sm.rtpPacketsUnknownPayloadType),
+				RTCPPacketsReceived:          atomic.LoadUint64(sm.rtcpPacketsReceived),
+				RTCPPacketsSent:              atomic.LoadUint64(sm.rtcpPacketsSent),
+				RTCPPacketsInError:           atomic.LoadUint64(sm.rtcpPacketsInError),
 				Formats: func() map[format.Format]SessionStatsFormat {
 					ret := make(map[format.Format]SessionStatsFormat, len(sm.formats))
 
@@ -2465,6 +3112,12 @@ func (c *Client) Stats() *ClientStats {
 							}
 							return nil
 						}()
+						metrics := func() trackmetrics.Metrics {
+							if fo.metrics != nil {
+								return fo.metrics.Metrics()
+							}
+							return trackmetrics.Metrics{}
+						}()
 
 						ret[fo.format] = SessionStatsFormat{ //nolint:dupl
 							RTPPacketsReceived: atomic.LoadUint64(fo.rtpPacketsReceived),
@@ -2510,6 +3163,21 @@ func (c *Client) Stats() *ClientStats {
 								}
 								return 0
 							}(),
+							RemoteClockOffset: func() time.Duration {
+								if recvStats != nil {
+									return recvStats.ClockOffset
+								}
+								return 0
+							}(),
+							RemoteClockDrift: func() float64 {
+								if recvStats != nil {
+									return recvStats.ClockDrift
+								}
+								return 0
+							}(),
+							Bitrate:          metrics.Bitrate,
+							FrameRate:        metrics.FrameRate,
+							KeyFrameInterval: metrics.KeyFrameInterval,
 						}
 					}
 
@@ -2575,6 +3243,13 @@ func (c *Client) Stats() *ClientStats {
 				}
 				return v
 			}(),
+			RTPPacketsUnknownPayloadType: func() uint64 {
+				v := uint64(0)
+				for _, ms := range mediaStats {
+					v += ms.RTPPacketsUnknownPayloadType
+				}
+				return v
+			}(),
 			RTPPacketsJitter: func() float64 {
 				v := float64(0)
 				n := float64(0)
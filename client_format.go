@@ -11,6 +11,7 @@ import (
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpreceiver"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpsender"
+	"github.com/bluenviron/gortsplib/v5/pkg/trackmetrics"
 )
 
 type clientFormat struct {
@@ -18,9 +19,12 @@ type clientFormat struct {
 	format      format.Format
 	localSSRC   uint32
 	onPacketRTP OnPacketRTPFunc
+	sinks       []*ClientSink
 
-	rtpReceiver           *rtpreceiver.Receiver // play
-	rtpSender             *rtpsender.Sender     // record or back channel
+	rtpReceiver           *rtpreceiver.Receiver   // play
+	metrics               *trackmetrics.Estimator // play
+	rtpSender             *rtpsender.Sender       // record or back channel
+	refragmenter          *rtpRefragmenter        // record or back channel
 	writePacketRTPInQueue func([]byte) error
 	rtpPacketsReceived    *uint64
 	rtpPacketsSent        *uint64
@@ -40,9 +44,10 @@ func (cf *clientFormat) initialize() {
 
 	if cf.cm.c.state == clientStatePreRecord || cf.cm.media.IsBackChannel {
 		cf.rtpSender = &rtpsender.Sender{
-			ClockRate: cf.format.ClockRate(),
-			Period:    cf.cm.c.senderReportPeriod,
-			TimeNow:   cf.cm.c.timeNow,
+			ClockRate:         cf.format.ClockRate(),
+			Period:            cf.cm.c.senderReportPeriod,
+			BandwidthFraction: cf.cm.c.RTCPBandwidthFraction,
+			TimeNow:           cf.cm.c.timeNow,
 			WritePacketRTCP: func(pkt rtcp.Packet) {
 				if !cf.cm.c.DisableRTCPSenderReports {
 					cf.cm.c.WritePacketRTCP(cf.cm.media, pkt) //nolint:errcheck
@@ -50,23 +55,45 @@ func (cf *clientFormat) initialize() {
 			},
 		}
 		cf.rtpSender.Initialize()
+
+		if cf.cm.udpRTPListener == nil && cf.cm.c.MaxInterleavedFrameSize != 0 && !cf.cm.secure {
+			cf.refragmenter = &rtpRefragmenter{
+				format:  cf.format,
+				maxSize: cf.cm.c.MaxInterleavedFrameSize,
+			}
+			cf.refragmenter.initialize()
+		}
 	} else {
 		cf.rtpReceiver = &rtpreceiver.Receiver{
 			ClockRate:            cf.format.ClockRate(),
 			LocalSSRC:            cf.localSSRC,
 			UnrealiableTransport: (cf.cm.udpRTPListener != nil),
 			Period:               cf.cm.c.receiverReportPeriod,
+			BandwidthFraction:    cf.cm.c.RTCPBandwidthFraction,
 			TimeNow:              cf.cm.c.timeNow,
 			WritePacketRTCP: func(pkt rtcp.Packet) {
 				if cf.cm.udpRTPListener != nil && cf.cm.udpRTCPListener.writeAddr != nil {
 					cf.cm.c.WritePacketRTCP(cf.cm.media, pkt) //nolint:errcheck
 				}
 			},
+			AllowSSRCChange: cf.cm.c.AllowSSRCChange,
+			OnSSRCChange: func(prev uint32, cur uint32) {
+				cf.cm.c.OnSSRCChange(cf.cm.media, cf.format, prev, cur)
+			},
 		}
 		err := cf.rtpReceiver.Initialize()
 		if err != nil {
 			panic(err)
 		}
+
+		cf.metrics = &trackmetrics.Estimator{
+			Format:    cf.format,
+			Threshold: cf.cm.c.TrackMetricsThreshold,
+			OnChange: func(metrics trackmetrics.Metrics) {
+				cf.cm.c.OnTrackMetricsChange(cf.cm.media, cf.format, metrics)
+			},
+		}
+		cf.metrics.Initialize()
 	}
 }
 
@@ -108,15 +135,48 @@ func (cf *clientFormat) readPacketRTP(pkt *rtp.Packet) {
 	atomic.AddUint64(cf.rtpPacketsReceived, uint64(len(pkts)))
 
 	for _, pkt := range pkts {
+		if updateParams(cf.format, pkt) {
+			cf.cm.c.OnParamsChange(cf.cm.media, cf.format)
+		}
+
+		cf.metrics.ProcessPacket(pkt, now)
+
 		cf.onPacketRTP(pkt)
+
+		for _, s := range cf.sinks {
+			s.push(cf.cm.media, cf.format, pkt)
+		}
 	}
 }
 
-func (cf *clientFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time) error {
+// writePacketRTP writes a RTP packet.
+// If deadline is not zero, the packet is not discarded when the write queue is full:
+// the function waits until there's space or the deadline expires.
+func (cf *clientFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time, deadline time.Time) error {
 	pkt.SSRC = cf.localSSRC
 
 	cf.rtpSender.ProcessPacket(pkt, ntp, cf.format.PTSEqualsDTS(pkt))
 
+	if cf.refragmenter != nil {
+		pkts, err := cf.refragmenter.process(pkt)
+		if err != nil {
+			return err
+		}
+
+		for _, pkt := range pkts {
+			err = cf.writePacketRTPMarshaled(pkt, deadline)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return cf.writePacketRTPMarshaled(pkt, deadline)
+}
+
+func (cf *clientFormat) writePacketRTPMarshaled(pkt *rtp.Packet, deadline time.Time) error {
 	maxPlainPacketSize := cf.cm.c.MaxPacketSize
 	if cf.cm.srtpOutCtx != nil {
 		maxPlainPacketSize -= srtpOverhead
@@ -145,11 +205,21 @@ func (cf *clientFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time) error {
 		return nil
 	}
 
-	ok := cf.cm.c.writer.Push(func() error {
+	cb := func() error {
 		return cf.writePacketRTPInQueue(buf)
-	})
+	}
+
+	if !deadline.IsZero() {
+		ok := cf.cm.c.writer.PushDeadline(cb, deadline)
+		if !ok {
+			return liberrors.ErrClientWouldBlock{}
+		}
+		return nil
+	}
+
+	ok := cf.cm.c.writer.Push(cb)
 	if !ok {
-		return liberrors.ErrClientWriteQueueFull{}
+		return cf.cm.c.writeError(liberrors.ErrClientWriteQueueFull{})
 	}
 
 	return nil
@@ -158,7 +228,7 @@ func (cf *clientFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time) error {
 func (cf *clientFormat) writePacketRTPInQueueUDP(payload []byte) error {
 	err := cf.cm.udpRTPListener.write(payload)
 	if err != nil {
-		return err
+		return cf.cm.c.writeError(err)
 	}
 
 	atomic.AddUint64(cf.cm.bytesSent, uint64(len(payload)))
@@ -175,6 +245,8 @@ func (cf *clientFormat) writePacketRTPInQueueTCP(payload []byte) error {
 		return err
 	}
 
+	dumpPacketTCP(cf.cm.c.PacketDump, cf.cm.c.nconn, true, cf.cm.tcpChannel, payload)
+
 	atomic.AddUint64(cf.cm.bytesSent, uint64(len(payload)))
 	atomic.AddUint64(cf.rtpPacketsSent, 1)
 	return nil
@@ -1,6 +1,7 @@
 package gortsplib
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/gortsplib/v5/internal/asyncprocessor"
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 )
@@ -48,15 +50,18 @@ func createUDPListenerPair(
 		return l1, l2, nil
 	}
 
-	// pick two consecutive ports in range 65535-10000
+	// pick two consecutive ports in range UDPPortMin-UDPPortMax,
+	// starting from a random pair.
 	// RTP port must be even and RTCP port odd
-	for {
-		v, err := randInRange((65535 - 10000) / 2)
-		if err != nil {
-			return nil, nil, err
-		}
+	pairCount := (c.UDPPortMax - c.UDPPortMin + 1) / 2
+
+	start, err := randInRange(pairCount - 1)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		rtpPort := v*2 + 10000
+	for i := range pairCount {
+		rtpPort := ((start+i)%pairCount)*2 + c.UDPPortMin
 		rtcpPort := rtpPort + 1
 
 		l1 := &clientUDPListener{
@@ -80,6 +85,8 @@ func createUDPListenerPair(
 
 		return l1, l2, nil
 	}
+
+	return nil, nil, fmt.Errorf("no free UDP ports in range %d-%d", c.UDPPortMin, c.UDPPortMax)
 }
 
 type clientMedia struct {
@@ -89,19 +96,23 @@ type clientMedia struct {
 	udpRTPListener  *clientUDPListener
 	udpRTCPListener *clientUDPListener
 	tcpChannel      int
+	tcpRTCPChannel  int
 	localSSRCs      map[uint8]uint32
 	srtpInCtx       *wrappedSRTPContext
 	srtpOutCtx      *wrappedSRTPContext
-
-	onPacketRTCP           OnPacketRTCPFunc
-	formats                map[uint8]*clientFormat
-	writePacketRTCPInQueue func([]byte) error
-	bytesReceived          *uint64
-	bytesSent              *uint64
-	rtpPacketsInError      *uint64
-	rtcpPacketsReceived    *uint64
-	rtcpPacketsSent        *uint64
-	rtcpPacketsInError     *uint64
+	readProcessor   *asyncprocessor.Processor
+
+	onPacketRTCP                 OnPacketRTCPFunc
+	formats                      map[uint8]*clientFormat
+	writePacketRTCPInQueue       func([]byte) error
+	bytesReceived                *uint64
+	bytesSent                    *uint64
+	rtpPacketsInError            *uint64
+	rtpPacketsUnknownPayloadType *uint64
+	rtcpPacketsReceived          *uint64
+	rtcpPacketsSent              *uint64
+	rtcpPacketsInError           *uint64
+	tornDown                     *int32
 }
 
 func (cm *clientMedia) initialize() {
@@ -109,12 +120,25 @@ func (cm *clientMedia) initialize() {
 	cm.bytesReceived = new(uint64)
 	cm.bytesSent = new(uint64)
 	cm.rtpPacketsInError = new(uint64)
+	cm.rtpPacketsUnknownPayloadType = new(uint64)
 	cm.rtcpPacketsReceived = new(uint64)
 	cm.rtcpPacketsSent = new(uint64)
 	cm.rtcpPacketsInError = new(uint64)
+	cm.tornDown = new(int32)
 
 	cm.formats = make(map[uint8]*clientFormat)
 
+	switch cm.c.PacketProcessing {
+	case PacketProcessingSingle:
+		if cm.c.readProcessor == nil {
+			cm.c.readProcessor = newReadProcessor(cm.c.ReadQueueSize)
+		}
+		cm.readProcessor = cm.c.readProcessor
+
+	case PacketProcessingPerMedia:
+		cm.readProcessor = newReadProcessor(cm.c.ReadQueueSize)
+	}
+
 	for _, forma := range cm.media.Formats {
 		f := &clientFormat{
 			cm:          cm,
@@ -129,13 +153,16 @@ func (cm *clientMedia) initialize() {
 	if cm.udpRTPListener != nil {
 		cm.writePacketRTCPInQueue = cm.writePacketRTCPInQueueUDP
 
+		var readRTP, readRTCP readFunc
 		if cm.c.state == clientStatePreRecord || cm.media.IsBackChannel {
-			cm.udpRTPListener.readFunc = cm.readPacketRTPUDPRecord
-			cm.udpRTCPListener.readFunc = cm.readPacketRTCPUDPRecord
+			readRTP, readRTCP = cm.readPacketRTPUDPRecord, cm.readPacketRTCPUDPRecord
 		} else {
-			cm.udpRTPListener.readFunc = cm.readPacketRTPUDPPlay
-			cm.udpRTCPListener.readFunc = cm.readPacketRTCPUDPPlay
+			readRTP, readRTCP = cm.readPacketRTPUDPPlay, cm.readPacketRTCPUDPPlay
 		}
+		readRTP, readRTCP = cm.asyncReadFunc(readRTP, false), cm.asyncReadFunc(readRTCP, true)
+
+		cm.udpRTPListener.readFunc = demuxRTPPort(readRTP, readRTCP)
+		cm.udpRTCPListener.readFunc = demuxRTCPPort(readRTP, readRTCP)
 	} else {
 		cm.writePacketRTCPInQueue = cm.writePacketRTCPInQueueTCP
 
@@ -144,24 +171,109 @@ func (cm *clientMedia) initialize() {
 		}
 
 		if cm.c.state == clientStatePreRecord || cm.media.IsBackChannel {
-			cm.c.tcpCallbackByChannel[cm.tcpChannel] = cm.readPacketRTPTCPRecord
-			cm.c.tcpCallbackByChannel[cm.tcpChannel+1] = cm.readPacketRTCPTCPRecord
+			cm.setTCPCallback(cm.tcpChannel, false, cm.asyncReadFunc(cm.readPacketRTPTCPRecord, false))
+			cm.setTCPCallback(cm.tcpRTCPChannel, true, cm.asyncReadFunc(cm.readPacketRTCPTCPRecord, true))
 		} else {
-			cm.c.tcpCallbackByChannel[cm.tcpChannel] = cm.readPacketRTPTCPPlay
-			cm.c.tcpCallbackByChannel[cm.tcpChannel+1] = cm.readPacketRTCPTCPPlay
+			cm.setTCPCallback(cm.tcpChannel, false, cm.asyncReadFunc(cm.readPacketRTPTCPPlay, false))
+			cm.setTCPCallback(cm.tcpRTCPChannel, true, cm.asyncReadFunc(cm.readPacketRTCPTCPPlay, true))
+		}
+	}
+}
+
+// setTCPCallback sets the callback of an interleaved channel.
+// When the channel is shared with another media (or with the other packet type),
+// packets are routed to the callback only if they belong to the media.
+func (cm *clientMedia) setTCPCallback(channel int, isRTCP bool, cb readFunc) {
+	// callbacks can't be removed while the connection is reading,
+	// therefore they are disabled when the media is torn down.
+	cb2 := cb
+	cb = func(payload []byte) bool {
+		if cm.isTornDown() {
+			return false
+		}
+		return cb2(payload)
+	}
+
+	prev, ok := cm.c.tcpCallbackByChannel[channel]
+	if !ok {
+		cm.c.tcpCallbackByChannel[channel] = cb
+		return
+	}
+
+	cm.c.tcpCallbackByChannel[channel] = func(payload []byte) bool {
+		if cm.ownsTCPPayload(payload, isRTCP) {
+			return cb(payload)
 		}
+		return prev(payload)
 	}
 }
 
+func (cm *clientMedia) ownsTCPPayload(payload []byte, isRTCP bool) bool {
+	if len(payload) < 8 {
+		return false
+	}
+
+	// https://datatracker.ietf.org/doc/html/rfc5761#section-4
+	if (payload[1] >= 192 && payload[1] <= 223) != isRTCP {
+		return false
+	}
+
+	if isRTCP {
+		return cm.findFormatByRemoteSSRC(binary.BigEndian.Uint32(payload[4:])) != nil
+	}
+
+	_, ok := cm.formats[payload[1]&0x7F]
+	return ok
+}
+
 func (cm *clientMedia) close() {
 	cm.stop()
 
+	if cm.udpRTPListener != nil {
+		cm.udpRTPListener.close()
+		cm.udpRTCPListener.close()
+	}
+
+	if cm.c.PacketProcessing == PacketProcessingPerMedia {
+		cm.readProcessor.Close()
+	}
+
 	for _, ct := range cm.formats {
 		ct.close()
 	}
 }
 
+// asyncReadFunc moves processing of incoming packets
+// to the routine selected by PacketProcessing.
+func (cm *clientMedia) asyncReadFunc(cb readFunc, isRTCP bool) readFunc {
+	if cm.readProcessor == nil {
+		return cb
+	}
+
+	return asyncReadFunc(cm.readProcessor, cb, func() {
+		if isRTCP {
+			cm.onPacketRTCPDecodeError(liberrors.ErrClientReadQueueFull{})
+		} else {
+			cm.onPacketRTPDecodeError(liberrors.ErrClientReadQueueFull{})
+		}
+	})
+}
+
+// tearDown stops the media after it has been removed from the session by TeardownMedia().
+func (cm *clientMedia) tearDown() {
+	atomic.StoreInt32(cm.tornDown, 1)
+	cm.stop()
+}
+
+func (cm *clientMedia) isTornDown() bool {
+	return atomic.LoadInt32(cm.tornDown) != 0
+}
+
 func (cm *clientMedia) start() {
+	if cm.isTornDown() {
+		return
+	}
+
 	if cm.udpRTPListener != nil {
 		cm.udpRTPListener.start()
 		cm.udpRTCPListener.start()
@@ -175,6 +287,38 @@ func (cm *clientMedia) stop() {
 	}
 }
 
+// punchHoles sends a RTP and a RTCP packet to the server,
+// in order to open NAT and firewall bindings.
+func (cm *clientMedia) punchHoles() error {
+	buf, _ := (&rtp.Packet{
+		Header:  rtp.Header{Version: 2},
+		Payload: cm.c.UDPHolePunchPayload,
+	}).Marshal()
+	if cm.srtpOutCtx != nil {
+		encr := make([]byte, cm.c.MaxPacketSize)
+		encr, err := cm.srtpOutCtx.encryptRTP(encr, buf, nil)
+		if err != nil {
+			return err
+		}
+		buf = encr
+	}
+	err := cm.udpRTPListener.write(buf)
+	if err != nil {
+		return err
+	}
+
+	buf, _ = (&rtcp.ReceiverReport{}).Marshal()
+	if cm.srtpOutCtx != nil {
+		encr := make([]byte, cm.c.MaxPacketSize)
+		encr, err = cm.srtpOutCtx.encryptRTCP(encr, buf, nil)
+		if err != nil {
+			return err
+		}
+		buf = encr
+	}
+	return cm.udpRTCPListener.write(buf)
+}
+
 func (cm *clientMedia) findFormatByRemoteSSRC(ssrc uint32) *clientFormat {
 	for _, cf := range cm.formats {
 		if v, ok := cf.remoteSSRC(); ok && v == ssrc {
@@ -229,7 +373,7 @@ func (cm *clientMedia) readPacketRTPTCPPlay(payload []byte) bool {
 
 	forma, ok := cm.formats[pkt.PayloadType]
 	if !ok {
-		cm.onPacketRTPDecodeError(liberrors.ErrClientRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+		cm.onPacketRTPUnknownPayloadType(pkt)
 		return false
 	}
 
@@ -314,7 +458,7 @@ func (cm *clientMedia) readPacketRTPUDPPlay(payload []byte) bool {
 
 	forma, ok := cm.formats[pkt.PayloadType]
 	if !ok {
-		cm.onPacketRTPDecodeError(liberrors.ErrClientRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+		cm.onPacketRTPUnknownPayloadType(pkt)
 		return false
 	}
 
@@ -387,6 +531,16 @@ func (cm *clientMedia) onPacketRTPDecodeError(err error) {
 	cm.c.OnDecodeError(err)
 }
 
+func (cm *clientMedia) onPacketRTPUnknownPayloadType(pkt *rtp.Packet) {
+	atomic.AddUint64(cm.rtpPacketsUnknownPayloadType, 1)
+
+	if cm.c.OnUnknownPacket != nil {
+		cm.c.OnUnknownPacket(cm.media, pkt)
+	} else {
+		cm.onPacketRTPDecodeError(liberrors.ErrClientRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+	}
+}
+
 func (cm *clientMedia) onPacketRTCPDecodeError(err error) {
 	atomic.AddUint64(cm.rtcpPacketsInError, 1)
 	cm.c.OnDecodeError(err)
@@ -427,7 +581,7 @@ func (cm *clientMedia) writePacketRTCP(pkt rtcp.Packet) error {
 		return cm.writePacketRTCPInQueue(buf)
 	})
 	if !ok {
-		return liberrors.ErrClientWriteQueueFull{}
+		return cm.c.writeError(liberrors.ErrClientWriteQueueFull{})
 	}
 
 	return nil
@@ -436,7 +590,7 @@ func (cm *clientMedia) writePacketRTCP(pkt rtcp.Packet) error {
 func (cm *clientMedia) writePacketRTCPInQueueUDP(payload []byte) error {
 	err := cm.udpRTCPListener.write(payload)
 	if err != nil {
-		return err
+		return cm.c.writeError(err)
 	}
 
 	atomic.AddUint64(cm.bytesSent, uint64(len(payload)))
@@ -445,7 +599,7 @@ func (cm *clientMedia) writePacketRTCPInQueueUDP(payload []byte) error {
 }
 
 func (cm *clientMedia) writePacketRTCPInQueueTCP(payload []byte) error {
-	cm.c.tcpFrame.Channel = cm.tcpChannel + 1
+	cm.c.tcpFrame.Channel = cm.tcpRTCPChannel
 	cm.c.tcpFrame.Payload = payload
 	cm.c.nconn.SetWriteDeadline(time.Now().Add(cm.c.WriteTimeout))
 	err := cm.c.conn.WriteInterleavedFrame(cm.c.tcpFrame, cm.c.tcpBuffer)
@@ -453,6 +607,8 @@ func (cm *clientMedia) writePacketRTCPInQueueTCP(payload []byte) error {
 		return err
 	}
 
+	dumpPacketTCP(cm.c.PacketDump, cm.c.nconn, true, cm.tcpRTCPChannel, payload)
+
 	atomic.AddUint64(cm.bytesSent, uint64(len(payload)))
 	atomic.AddUint64(cm.rtcpPacketsSent, 1)
 	return nil
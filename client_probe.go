@@ -0,0 +1,200 @@
+package gortsplib
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+)
+
+func probeVideoSize(forma format.Format) (int, int) {
+	switch forma := forma.(type) {
+	case *format.H264:
+		return forma.Width(), forma.Height()
+
+	case *format.H265:
+		return forma.Width(), forma.Height()
+	}
+
+	return 0, 0
+}
+
+// ClientProbeFormat contains informations about a format, collected by Client.Probe().
+type ClientProbeFormat struct {
+	Format format.Format
+
+	// codec name.
+	Codec string
+
+	// video resolution, extracted from the SPS.
+	// It is zero when the SPS is not available.
+	Width  int
+	Height int
+}
+
+// ClientProbeMedia contains informations about a media, collected by Client.Probe().
+type ClientProbeMedia struct {
+	Media   *description.Media
+	Formats []ClientProbeFormat
+}
+
+// ClientProbeReport is the report returned by Client.Probe().
+type ClientProbeReport struct {
+	// methods advertised by the server.
+	// It is nil when the server does not advertise them.
+	Methods []base.Method
+
+	// authentication methods requested by the server.
+	// It is nil when authentication is not required.
+	AuthMethods []headers.AuthMethod
+
+	// available medias.
+	Medias []ClientProbeMedia
+
+	// transport protocols accepted by the server.
+	Protocols []Protocol
+}
+
+// Probe connects to the address and collects informations about the server and the stream,
+// without reading it.
+// Transport protocols are checked by setupping the first media with each of them.
+//
+// If authentication fails, the report is returned together with the error.
+// The client is closed when the method returns.
+func (c *Client) Probe(address string) (*ClientProbeReport, error) {
+	u, err := base.ParseURL(address)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Scheme = u.Scheme
+	c.Host = u.Host
+
+	report := &ClientProbeReport{}
+
+	onResponse := c.OnResponse
+	c.OnResponse = func(res *base.Response) {
+		if res.StatusCode == base.StatusUnauthorized {
+			for _, v := range res.Header["WWW-Authenticate"] {
+				var auth headers.Authenticate
+				err2 := auth.Unmarshal(base.HeaderValue{v})
+				if err2 == nil && !slices.Contains(report.AuthMethods, auth.Method) {
+					report.AuthMethods = append(report.AuthMethods, auth.Method)
+				}
+			}
+		}
+
+		if onResponse != nil {
+			onResponse(res)
+		}
+	}
+	defer func() {
+		c.OnResponse = onResponse
+	}()
+
+	err = c.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Options(u)
+	if err != nil {
+		c.Close()
+		return probeReportOrNil(report, err)
+	}
+
+	if pub, ok := res.Header["Public"]; ok && len(pub) == 1 {
+		for m := range strings.SplitSeq(pub[0], ",") {
+			report.Methods = append(report.Methods, base.Method(strings.Trim(m, " ")))
+		}
+	}
+
+	desc, _, err := c.Describe(u)
+	c.Close()
+	if err != nil {
+		return probeReportOrNil(report, err)
+	}
+
+	for _, medi := range desc.Medias {
+		pm := ClientProbeMedia{
+			Media: medi,
+		}
+
+		for _, forma := range medi.Formats {
+			pf := ClientProbeFormat{
+				Format: forma,
+				Codec:  forma.Codec(),
+			}
+			pf.Width, pf.Height = probeVideoSize(forma)
+			pm.Formats = append(pm.Formats, pf)
+		}
+
+		report.Medias = append(report.Medias, pm)
+	}
+
+	if len(desc.Medias) == 0 {
+		return report, nil
+	}
+
+	for _, protocol := range []Protocol{ProtocolUDP, ProtocolUDPMulticast, ProtocolTCP} {
+		if protocol != ProtocolTCP && c.Tunnel != TunnelNone {
+			continue
+		}
+
+		if c.probeProtocol(protocol, desc) {
+			report.Protocols = append(report.Protocols, protocol)
+		}
+	}
+
+	return report, nil
+}
+
+func probeReportOrNil(report *ClientProbeReport, err error) (*ClientProbeReport, error) {
+	var eerr liberrors.ErrClientBadStatusCode
+	if errors.As(err, &eerr) && eerr.Code == base.StatusUnauthorized {
+		return report, err
+	}
+	return nil, err
+}
+
+// probeProtocol checks whether the server accepts a transport protocol,
+// by setupping the first media with a secondary client.
+func (c *Client) probeProtocol(protocol Protocol, desc *description.Session) bool {
+	pc := &Client{
+		Scheme:                  c.Scheme,
+		Host:                    c.Host,
+		ReadTimeout:             c.ReadTimeout,
+		WriteTimeout:            c.WriteTimeout,
+		TCPKeepAlive:            c.TCPKeepAlive,
+		TCPLinger:               c.TCPLinger,
+		TLSConfig:               c.TLSConfig,
+		Tunnel:                  c.Tunnel,
+		Protocol:                &protocol,
+		AnyPortEnable:           c.AnyPortEnable,
+		AnyInterleavedIDsEnable: c.AnyInterleavedIDsEnable,
+		AnyCSeqEnable:           c.AnyCSeqEnable,
+		ControlQuery:            c.ControlQuery,
+		UDPReadBufferSize:       c.UDPReadBufferSize,
+		UserAgent:               c.UserAgent,
+		RequestBackChannels:     c.RequestBackChannels,
+		Replay:                  c.Replay,
+		DialContext:             c.DialContext,
+		ListenPacket:            c.ListenPacket,
+		OnRequest:               c.OnRequest,
+		OnResponse:              c.OnResponse,
+	}
+
+	err := pc.Start()
+	if err != nil {
+		return false
+	}
+	defer pc.Close()
+
+	_, err = pc.Setup(desc.BaseURL, desc.Medias[0], 0, 0)
+	return err == nil
+}
@@ -7,11 +7,15 @@ import (
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 )
 
+// maximum number of early interleaved frames that are buffered.
+const clientReaderMaxEarlyFrames = 256
+
 type clientReader struct {
 	c *Client
 
 	mutex                  sync.Mutex
 	allowInterleavedFrames bool
+	earlyFrames            []*base.InterleavedFrame
 
 	terminate chan struct{}
 
@@ -29,6 +33,17 @@ func (r *clientReader) setAllowInterleavedFrames(v bool) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	r.allowInterleavedFrames = v
+
+	if v {
+		// deliver frames received before transport was started
+		for _, fr := range r.earlyFrames {
+			if cb, ok := r.c.tcpCallbackByChannel[fr.Channel]; ok {
+				cb(fr.Payload)
+			}
+		}
+	}
+
+	r.earlyFrames = nil
 }
 
 func (r *clientReader) close() {
@@ -71,10 +86,26 @@ func (r *clientReader) runInner() error {
 			r.mutex.Lock()
 
 			if !r.allowInterleavedFrames {
+				if !r.c.EarlyMediaEnable {
+					r.mutex.Unlock()
+					return liberrors.ErrClientUnexpectedFrame{}
+				}
+
+				dumpPacketTCP(r.c.PacketDump, r.c.nconn, false, what.Channel, what.Payload)
+
+				// store a copy, since the frame is reused by the next read
+				if len(r.earlyFrames) < clientReaderMaxEarlyFrames {
+					r.earlyFrames = append(r.earlyFrames, &base.InterleavedFrame{
+						Channel: what.Channel,
+						Payload: what.Payload,
+					})
+				}
 				r.mutex.Unlock()
-				return liberrors.ErrClientUnexpectedFrame{}
+				continue
 			}
 
+			dumpPacketTCP(r.c.PacketDump, r.c.nconn, false, what.Channel, what.Payload)
+
 			if cb, ok := r.c.tcpCallbackByChannel[what.Channel]; ok {
 				cb(what.Payload)
 			}
@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"strings"
 	"sync"
@@ -21,6 +22,7 @@ import (
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
 	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v5/pkg/mikey"
 	"github.com/bluenviron/gortsplib/v5/pkg/ntp"
 	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
@@ -1550,3 +1552,236 @@ func TestClientRecordIgnoreTCPRTPPackets(t *testing.T) {
 
 	<-rtcpReceived
 }
+
+func TestClientRecordAsyncWriteErrors(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	queueFull := make(chan struct{})
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+
+	go func() {
+		defer close(serverDone)
+
+		nconn, err2 := l.Accept()
+		require.NoError(t, err2)
+		defer nconn.Close()
+		br := bufio.NewReader(nconn)
+		conn := conn.NewConn(br, nconn)
+
+		req, err2 := conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Options, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Announce),
+					string(base.Setup),
+					string(base.Record),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Announce, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err2 = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err2)
+
+		th := headers.Transport{
+			Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Record, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		// stop reading until the queue of the client is full
+		<-queueFull
+
+		io.Copy(io.Discard, br) //nolint:errcheck
+	}()
+
+	var once sync.Once
+
+	c := Client{
+		Protocol:         ptrOf(ProtocolTCP),
+		WriteQueueSize:   1,
+		AsyncWriteErrors: true,
+		OnWriteError: func(err error) {
+			require.EqualError(t, err, "write queue is full")
+			once.Do(func() { close(queueFull) })
+		},
+	}
+
+	medi := testH264Media
+	medias := []*description.Media{medi}
+
+	err = record(&c, "rtsp://localhost:8554/teststream", medias, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	pkt := testRTPPacket
+	pkt.Payload = bytes.Repeat([]byte{1}, 1400)
+
+	for {
+		err = c.WritePacketRTP(medi, &pkt)
+		require.NoError(t, err)
+
+		select {
+		case <-queueFull:
+			return
+		default:
+		}
+	}
+}
+
+func TestClientRecordTryWritePacketRTP(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer l.Close()
+
+	queueFull := make(chan struct{})
+
+	serverDone := make(chan struct{})
+	defer func() { <-serverDone }()
+
+	go func() {
+		defer close(serverDone)
+
+		nconn, err2 := l.Accept()
+		require.NoError(t, err2)
+		defer nconn.Close()
+		br := bufio.NewReader(nconn)
+		conn := conn.NewConn(br, nconn)
+
+		req, err2 := conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Options, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Announce),
+					string(base.Setup),
+					string(base.Record),
+				}, ", ")},
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Announce, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Setup, req.Method)
+
+		var inTH headers.Transport
+		err2 = inTH.Unmarshal(req.Header["Transport"])
+		require.NoError(t, err2)
+
+		th := headers.Transport{
+			Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+			Protocol:       headers.TransportProtocolTCP,
+			InterleavedIDs: inTH.InterleavedIDs,
+		}
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Transport": th.Marshal(),
+			},
+		})
+		require.NoError(t, err2)
+
+		req, err2 = conn.ReadRequest()
+		require.NoError(t, err2)
+		require.Equal(t, base.Record, req.Method)
+
+		err2 = conn.WriteResponse(&base.Response{
+			StatusCode: base.StatusOK,
+		})
+		require.NoError(t, err2)
+
+		// stop reading until the queue of the client is full
+		<-queueFull
+
+		io.Copy(io.Discard, br) //nolint:errcheck
+	}()
+
+	c := Client{
+		Protocol:       ptrOf(ProtocolTCP),
+		WriteQueueSize: 1,
+	}
+
+	medi := testH264Media
+	medias := []*description.Media{medi}
+
+	err = record(&c, "rtsp://localhost:8554/teststream", medias, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	pkt := testRTPPacket
+	pkt.Payload = bytes.Repeat([]byte{1}, 1400)
+
+	for {
+		err = c.TryWritePacketRTP(medi, &pkt)
+		if err != nil {
+			require.Equal(t, liberrors.ErrClientWouldBlock{}, err)
+			break
+		}
+	}
+
+	for {
+		err = c.WritePacketRTPWithTimeout(medi, &pkt, 100*time.Millisecond)
+		if err != nil {
+			require.Equal(t, liberrors.ErrClientWouldBlock{}, err)
+			break
+		}
+	}
+
+	close(queueFull)
+
+	err = c.WritePacketRTPWithTimeout(medi, &pkt, 5*time.Second)
+	require.NoError(t, err)
+}
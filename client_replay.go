@@ -0,0 +1,13 @@
+package gortsplib
+
+// ClientReplay contains ONVIF replay options.
+type ClientReplay struct {
+	// send data as fast as possible instead of at the nominal rate.
+	// This is useful to export recordings.
+	DisableRateControl bool
+
+	// ask the server to execute PLAY requests immediately,
+	// discarding data of previous requests that has not been sent yet.
+	// This is useful to seek.
+	Immediate bool
+}
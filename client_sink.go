@@ -0,0 +1,65 @@
+package gortsplib
+
+import (
+	"sync/atomic"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/internal/asyncprocessor"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// ClientSink is a destination of RTP packets read by a Client,
+// that can be used alongside OnPacketRTP and other sinks
+// (for instance, to archive raw RTP packets while decoding them).
+//
+// Each sink has its own queue and routine, therefore a slow sink
+// doesn't slow down the others. When the queue is full, packets
+// directed to the sink are discarded and counted.
+//
+// Packets are shared between sinks and must not be modified.
+type ClientSink struct {
+	// called when a RTP packet is read.
+	OnPacketRTP OnPacketRTPAnyFunc
+
+	// size of the queue of the sink.
+	// It must be a power of two.
+	// It defaults to Client.ReadQueueSize.
+	QueueSize int
+
+	processor *asyncprocessor.Processor
+	dropped   *uint64
+}
+
+func (s *ClientSink) initialize(c *Client) {
+	if s.QueueSize == 0 {
+		s.QueueSize = c.ReadQueueSize
+	}
+
+	s.dropped = new(uint64)
+	s.processor = newReadProcessor(s.QueueSize)
+}
+
+func (s *ClientSink) close() {
+	s.processor.Close()
+}
+
+func (s *ClientSink) push(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+	ok := s.processor.Push(func() error {
+		s.OnPacketRTP(medi, forma, pkt)
+		return nil
+	})
+	if !ok {
+		atomic.AddUint64(s.dropped, 1)
+	}
+}
+
+// Dropped returns the number of packets that were discarded
+// because the queue of the sink was full.
+func (s *ClientSink) Dropped() uint64 {
+	if s.dropped == nil {
+		return 0
+	}
+	return atomic.LoadUint64(s.dropped)
+}
@@ -1,8 +1,15 @@
 package gortsplib
 
+import (
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+)
+
 // ClientTransport contains details about the client transport.
 type ClientTransport struct {
 	Conn ConnTransport
 	// present only when SETUP has been called at least once.
 	Session *SessionTransport
+	// attempts that failed and caused an automatic switch to another transport protocol,
+	// either during SETUP or during PLAY, when no UDP packets are received.
+	Fallbacks []liberrors.ClientSetupAttempt
 }
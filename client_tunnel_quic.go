@@ -0,0 +1,43 @@
+package gortsplib
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"golang.org/x/net/quic"
+)
+
+func newClientTunnelQUIC(
+	ctx context.Context,
+	addr string,
+	tlsConfig *tls.Config,
+) (net.Conn, error) {
+	ep, err := quic.Listen("udp", ":0", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	closeEndpoint := func() {
+		ctx2, ctx2Cancel := context.WithTimeout(context.Background(), quicCloseTimeout)
+		defer ctx2Cancel()
+		ep.Close(ctx2) //nolint:errcheck
+	}
+
+	qconn, err := ep.Dial(ctx, "udp", addr, &quic.Config{
+		TLSConfig: quicTLSConfig(tlsConfig),
+	})
+	if err != nil {
+		closeEndpoint()
+		return nil, err
+	}
+
+	control, err := qconn.NewStream(ctx)
+	if err != nil {
+		qconn.Abort(nil)
+		closeEndpoint()
+		return nil, err
+	}
+
+	return newQUICFramePerStreamConn(qconn, control, closeEndpoint), nil
+}
@@ -136,6 +136,8 @@ func (u *clientUDPListener) run() {
 		now := u.c.timeNow()
 		atomic.StoreInt64(u.lastPacketTime, now.Unix())
 
+		dumpPacketUDP(u.c.PacketDump, uaddr, u.pc.LocalAddr(), buf[:n])
+
 		if u.readFunc(buf[:n]) {
 			createNewBuffer()
 		}
@@ -147,5 +149,10 @@ func (u *clientUDPListener) write(payload []byte) error {
 	// https://github.com/golang/go/issues/27203#issuecomment-534386117
 	u.pc.SetWriteDeadline(time.Now().Add(u.c.WriteTimeout))
 	_, err := u.pc.WriteTo(payload, u.writeAddr)
-	return err
+	if err != nil {
+		return err
+	}
+
+	dumpPacketUDP(u.c.PacketDump, u.pc.LocalAddr(), u.writeAddr, payload)
+	return nil
 }
@@ -8,6 +8,13 @@ const (
 	TunnelNone Tunnel = iota
 	TunnelHTTP
 	TunnelWebSocket
+
+	// RTSP over QUIC, frame-per-stream (experimental).
+	// Requests and responses are sent through a QUIC stream,
+	// while each interleaved frame is sent through a dedicated unidirectional stream,
+	// in order to avoid head-of-line blocking.
+	// QUIC datagrams are not used, therefore lost frames are retransmitted.
+	TunnelQUIC
 )
 
 // ConnTransport contains details about the transport of a connection.
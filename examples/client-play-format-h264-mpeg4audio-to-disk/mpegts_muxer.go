@@ -5,16 +5,12 @@ import (
 	"os"
 	"sync"
 
-	"github.com/bluenviron/gortsplib/v5/pkg/format"
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
-	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
-)
 
-func multiplyAndDivide(v, m, d int64) int64 {
-	secs := v / d
-	dec := v % d
-	return (secs*m + dec*m/d)
-}
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/mpegts"
+	"github.com/bluenviron/gortsplib/v5/pkg/paramsets"
+)
 
 // mpegtsMuxer allows to save a H264 / MPEG-4 audio stream into a MPEG-TS file.
 type mpegtsMuxer struct {
@@ -22,9 +18,10 @@ type mpegtsMuxer struct {
 	h264Format       *format.H264
 	mpeg4AudioFormat *format.MPEG4Audio
 
+	h264Params      *paramsets.H264
 	f               *os.File
 	b               *bufio.Writer
-	w               *mpegts.Writer
+	m               *mpegts.Muxer
 	h264Track       *mpegts.Track
 	mpeg4AudioTrack *mpegts.Track
 	dtsExtractor    *h264.DTSExtractor
@@ -33,6 +30,9 @@ type mpegtsMuxer struct {
 
 // initialize initializes a mpegtsMuxer.
 func (e *mpegtsMuxer) initialize() error {
+	e.h264Params = &paramsets.H264{}
+	e.h264Params.SPS, e.h264Params.PPS = e.h264Format.SafeParams()
+
 	var err error
 	e.f, err = os.Create(e.fileName)
 	if err != nil {
@@ -41,18 +41,20 @@ func (e *mpegtsMuxer) initialize() error {
 	e.b = bufio.NewWriter(e.f)
 
 	e.h264Track = &mpegts.Track{
-		Codec: &mpegts.CodecH264{},
+		Format: e.h264Format,
 	}
 
 	e.mpeg4AudioTrack = &mpegts.Track{
-		Codec: &mpegts.CodecMPEG4Audio{
-			Config: *e.mpeg4AudioFormat.Config,
-		},
+		Format: e.mpeg4AudioFormat,
 	}
 
-	e.w = &mpegts.Writer{W: e.b, Tracks: []*mpegts.Track{e.h264Track, e.mpeg4AudioTrack}}
-	err = e.w.Initialize()
+	e.m = &mpegts.Muxer{
+		W:      e.b,
+		Tracks: []*mpegts.Track{e.h264Track, e.mpeg4AudioTrack},
+	}
+	err = e.m.Initialize()
 	if err != nil {
+		e.f.Close()
 		return err
 	}
 
@@ -70,46 +72,27 @@ func (e *mpegtsMuxer) writeH264(au [][]byte, pts int64) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	var filteredAU [][]byte //nolint:prealloc
+	// remove AUDs, store SPS and PPS, and add them before access units that contain an IDR,
+	// in order to allow the DTS extractor to read them.
+	au = e.h264Params.Normalize(au)
 
 	nonIDRPresent := false
 	idrPresent := false
 
 	for _, nalu := range au {
-		typ := h264.NALUType(nalu[0] & 0x1F)
-		switch typ {
-		case h264.NALUTypeSPS:
-			e.h264Format.SPS = nalu
-			continue
-
-		case h264.NALUTypePPS:
-			e.h264Format.PPS = nalu
-			continue
-
-		case h264.NALUTypeAccessUnitDelimiter:
-			continue
-
+		switch h264.NALUType(nalu[0] & 0x1F) {
 		case h264.NALUTypeIDR:
 			idrPresent = true
 
 		case h264.NALUTypeNonIDR:
 			nonIDRPresent = true
 		}
-
-		filteredAU = append(filteredAU, nalu)
 	}
 
-	au = filteredAU
-
-	if au == nil || (!nonIDRPresent && !idrPresent) {
+	if !nonIDRPresent && !idrPresent {
 		return nil
 	}
 
-	// add SPS and PPS before access unit that contains an IDR
-	if idrPresent {
-		au = append([][]byte{e.h264Format.SPS, e.h264Format.PPS}, au...)
-	}
-
 	if e.dtsExtractor == nil {
 		// skip samples silently until we find one with a IDR
 		if !idrPresent {
@@ -125,7 +108,7 @@ func (e *mpegtsMuxer) writeH264(au [][]byte, pts int64) error {
 	}
 
 	// encode into MPEG-TS
-	return e.w.WriteH264(e.h264Track, pts, dts, au)
+	return e.m.WriteH264(e.h264Track, pts, dts, au)
 }
 
 // writeMPEG4Audio writes MPEG-4 audio access units into MPEG-TS.
@@ -133,6 +116,5 @@ func (e *mpegtsMuxer) writeMPEG4Audio(aus [][]byte, pts int64) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	return e.w.WriteMPEG4Audio(e.mpeg4AudioTrack,
-		multiplyAndDivide(pts, 90000, int64(e.mpeg4AudioFormat.ClockRate())), aus)
+	return e.m.WriteMPEG4Audio(e.mpeg4AudioTrack, pts, aus)
 }
@@ -58,8 +58,7 @@ func main() {
 	// setup H264 -> MPEG-TS muxer
 	mpegtsMuxer := &mpegtsMuxer{
 		fileName: "mystream.ts",
-		sps:      forma.SPS,
-		pps:      forma.PPS,
+		format:   forma,
 	}
 	err = mpegtsMuxer.initialize()
 	if err != nil {
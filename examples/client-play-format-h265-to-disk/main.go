@@ -58,9 +58,7 @@ func main() {
 	// setup H265 -> MPEG-TS muxer
 	mpegtsMuxer := &mpegtsMuxer{
 		fileName: "mystream.ts",
-		vps:      forma.VPS,
-		sps:      forma.SPS,
-		pps:      forma.PPS,
+		format:   forma,
 	}
 	err = mpegtsMuxer.initialize()
 	if err != nil {
@@ -5,25 +5,30 @@ import (
 	"os"
 
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
-	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/mpegts"
+	"github.com/bluenviron/gortsplib/v5/pkg/paramsets"
 )
 
 // mpegtsMuxer allows to save a H265 stream into a MPEG-TS file.
 type mpegtsMuxer struct {
 	fileName string
-	vps      []byte
-	sps      []byte
-	pps      []byte
+	format   *format.H265
 
+	params       *paramsets.H265
 	f            *os.File
 	b            *bufio.Writer
-	w            *mpegts.Writer
+	m            *mpegts.Muxer
 	track        *mpegts.Track
 	dtsExtractor *h265.DTSExtractor
 }
 
 // initialize initializes a mpegtsMuxer.
 func (e *mpegtsMuxer) initialize() error {
+	e.params = &paramsets.H265{}
+	e.params.VPS, e.params.SPS, e.params.PPS = e.format.SafeParams()
+
 	var err error
 	e.f, err = os.Create(e.fileName)
 	if err != nil {
@@ -32,12 +37,16 @@ func (e *mpegtsMuxer) initialize() error {
 	e.b = bufio.NewWriter(e.f)
 
 	e.track = &mpegts.Track{
-		Codec: &mpegts.CodecH265{},
+		Format: e.format,
 	}
 
-	e.w = &mpegts.Writer{W: e.b, Tracks: []*mpegts.Track{e.track}}
-	err = e.w.Initialize()
+	e.m = &mpegts.Muxer{
+		W:      e.b,
+		Tracks: []*mpegts.Track{e.track},
+	}
+	err = e.m.Initialize()
 	if err != nil {
+		e.f.Close()
 		return err
 	}
 
@@ -52,49 +61,17 @@ func (e *mpegtsMuxer) close() {
 
 // writeH265 writes a H265 access unit into MPEG-TS.
 func (e *mpegtsMuxer) writeH265(au [][]byte, pts int64) error {
-	var filteredAU [][]byte //nolint:prealloc
-
-	isRandomAccess := false
-
-	for _, nalu := range au {
-		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
-		switch typ {
-		case h265.NALUType_VPS_NUT:
-			e.vps = nalu
-			continue
-
-		case h265.NALUType_SPS_NUT:
-			e.sps = nalu
-			continue
-
-		case h265.NALUType_PPS_NUT:
-			e.pps = nalu
-			continue
-
-		case h265.NALUType_AUD_NUT:
-			continue
+	// remove AUDs, store VPS, SPS and PPS, and add them before random access units,
+	// in order to allow the DTS extractor to read them.
+	au = e.params.Normalize(au)
 
-		case h265.NALUType_IDR_W_RADL, h265.NALUType_IDR_N_LP, h265.NALUType_CRA_NUT:
-			isRandomAccess = true
-		}
-
-		filteredAU = append(filteredAU, nalu)
-	}
-
-	au = filteredAU
-
-	if au == nil {
+	if len(au) == 0 {
 		return nil
 	}
 
-	// add VPS, SPS and PPS before random access access unit
-	if isRandomAccess {
-		au = append([][]byte{e.vps, e.sps, e.pps}, au...)
-	}
-
 	if e.dtsExtractor == nil {
 		// skip samples silently until we find one with a IDR
-		if !isRandomAccess {
+		if !h265.IsRandomAccess(au) {
 			return nil
 		}
 		e.dtsExtractor = &h265.DTSExtractor{}
@@ -107,5 +84,5 @@ func (e *mpegtsMuxer) writeH265(au [][]byte, pts int64) error {
 	}
 
 	// encode into MPEG-TS
-	return e.w.WriteH265(e.track, pts, dts, au)
+	return e.m.WriteH265(e.track, pts, dts, au)
 }
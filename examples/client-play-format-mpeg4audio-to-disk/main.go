@@ -7,7 +7,6 @@ import (
 	"github.com/bluenviron/gortsplib/v5"
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
-	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
 	"github.com/pion/rtp"
 )
 
@@ -58,11 +57,6 @@ func main() {
 	mpegtsMuxer := &mpegtsMuxer{
 		fileName: "mystream.ts",
 		format:   forma,
-		track: &mpegts.Track{
-			Codec: &mpegts.CodecMPEG4Audio{
-				Config: *forma.Config,
-			},
-		},
 	}
 	err = mpegtsMuxer.initialize()
 	if err != nil {
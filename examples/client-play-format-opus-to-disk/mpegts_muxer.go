@@ -5,24 +5,18 @@ import (
 	"os"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
-	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+	"github.com/bluenviron/gortsplib/v5/pkg/mpegts"
 )
 
-func multiplyAndDivide(v, m, d int64) int64 {
-	secs := v / d
-	dec := v % d
-	return (secs*m + dec*m/d)
-}
-
-// mpegtsMuxer allows to save a MPEG-4 audio stream into a MPEG-TS file.
+// mpegtsMuxer allows to save a Opus stream into a MPEG-TS file.
 type mpegtsMuxer struct {
 	fileName string
-	format   format.Format
-	track    *mpegts.Track
+	format   *format.Opus
 
-	f *os.File
-	b *bufio.Writer
-	w *mpegts.Writer
+	f     *os.File
+	b     *bufio.Writer
+	m     *mpegts.Muxer
+	track *mpegts.Track
 }
 
 // initialize initializes a mpegtsMuxer.
@@ -34,9 +28,17 @@ func (e *mpegtsMuxer) initialize() error {
 	}
 	e.b = bufio.NewWriter(e.f)
 
-	e.w = &mpegts.Writer{W: e.b, Tracks: []*mpegts.Track{e.track}}
-	err = e.w.Initialize()
+	e.track = &mpegts.Track{
+		Format: e.format,
+	}
+
+	e.m = &mpegts.Muxer{
+		W:      e.b,
+		Tracks: []*mpegts.Track{e.track},
+	}
+	err = e.m.Initialize()
 	if err != nil {
+		e.f.Close()
 		return err
 	}
 
@@ -49,7 +51,7 @@ func (e *mpegtsMuxer) close() {
 	e.f.Close()
 }
 
-// writeOpus writes Opus packets into MPEG-TS.
+// writeOpus writes a Opus packet into MPEG-TS.
 func (e *mpegtsMuxer) writeOpus(pkt []byte, pts int64) error {
-	return e.w.WriteOpus(e.track, multiplyAndDivide(pts, 90000, int64(e.format.ClockRate())), [][]byte{pkt})
+	return e.m.WriteOpus(e.track, pts, [][]byte{pkt})
 }
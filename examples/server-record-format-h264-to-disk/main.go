@@ -86,8 +86,7 @@ func (sh *serverHandler) OnAnnounce(ctx *gortsplib.ServerHandlerOnAnnounceCtx) (
 	// setup H264 -> MPEGTS muxer
 	mpegtsMuxer := &mpegtsMuxer{
 		fileName: "mystream.ts",
-		sps:      forma.SPS,
-		pps:      forma.PPS,
+		format:   forma,
 	}
 	err = mpegtsMuxer.initialize()
 	if err != nil {
@@ -5,24 +5,30 @@ import (
 	"os"
 
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
-	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/mpegts"
+	"github.com/bluenviron/gortsplib/v5/pkg/paramsets"
 )
 
 // mpegtsMuxer allows to save a H264 stream into a MPEG-TS file.
 type mpegtsMuxer struct {
 	fileName string
-	sps      []byte
-	pps      []byte
+	format   *format.H264
 
+	params       *paramsets.H264
 	f            *os.File
 	b            *bufio.Writer
-	w            *mpegts.Writer
+	m            *mpegts.Muxer
 	track        *mpegts.Track
 	dtsExtractor *h264.DTSExtractor
 }
 
 // initialize initializes a mpegtsMuxer.
 func (e *mpegtsMuxer) initialize() error {
+	e.params = &paramsets.H264{}
+	e.params.SPS, e.params.PPS = e.format.SafeParams()
+
 	var err error
 	e.f, err = os.Create(e.fileName)
 	if err != nil {
@@ -31,12 +37,16 @@ func (e *mpegtsMuxer) initialize() error {
 	e.b = bufio.NewWriter(e.f)
 
 	e.track = &mpegts.Track{
-		Codec: &mpegts.CodecH264{},
+		Format: e.format,
 	}
 
-	e.w = &mpegts.Writer{W: e.b, Tracks: []*mpegts.Track{e.track}}
-	err = e.w.Initialize()
+	e.m = &mpegts.Muxer{
+		W:      e.b,
+		Tracks: []*mpegts.Track{e.track},
+	}
+	err = e.m.Initialize()
 	if err != nil {
+		e.f.Close()
 		return err
 	}
 
@@ -51,46 +61,27 @@ func (e *mpegtsMuxer) close() {
 
 // writeH264 writes a H264 access unit into MPEG-TS.
 func (e *mpegtsMuxer) writeH264(au [][]byte, pts int64) error {
-	var filteredAU [][]byte //nolint:prealloc
+	// remove AUDs, store SPS and PPS, and add them before access units that contain an IDR,
+	// in order to allow the DTS extractor to read them.
+	au = e.params.Normalize(au)
 
 	nonIDRPresent := false
 	idrPresent := false
 
 	for _, nalu := range au {
-		typ := h264.NALUType(nalu[0] & 0x1F)
-		switch typ {
-		case h264.NALUTypeSPS:
-			e.sps = nalu
-			continue
-
-		case h264.NALUTypePPS:
-			e.pps = nalu
-			continue
-
-		case h264.NALUTypeAccessUnitDelimiter:
-			continue
-
+		switch h264.NALUType(nalu[0] & 0x1F) {
 		case h264.NALUTypeIDR:
 			idrPresent = true
 
 		case h264.NALUTypeNonIDR:
 			nonIDRPresent = true
 		}
-
-		filteredAU = append(filteredAU, nalu)
 	}
 
-	au = filteredAU
-
-	if au == nil || (!nonIDRPresent && !idrPresent) {
+	if !nonIDRPresent && !idrPresent {
 		return nil
 	}
 
-	// add SPS and PPS before every access unit that contains an IDR
-	if idrPresent {
-		au = append([][]byte{e.sps, e.pps}, au...)
-	}
-
 	if e.dtsExtractor == nil {
 		// skip samples silently until we find one with a IDR
 		if !idrPresent {
@@ -106,5 +97,5 @@ func (e *mpegtsMuxer) writeH264(au [][]byte, pts int64) error {
 	}
 
 	// encode into MPEG-TS
-	return e.w.WriteH264(e.track, pts, dts, au)
+	return e.m.WriteH264(e.track, pts, dts, au)
 }
@@ -0,0 +1,143 @@
+package gortsplib
+
+import (
+	"bytes"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// splitAggregationUnit returns the NALUs contained into an aggregation unit
+// (STAP-A in case of H264, AP in case of H265).
+func splitAggregationUnit(payload []byte) [][]byte {
+	var nalus [][]byte
+
+	for len(payload) >= 2 {
+		size := int(uint16(payload[0])<<8 | uint16(payload[1]))
+		payload = payload[2:]
+
+		if size == 0 || size > len(payload) {
+			break
+		}
+
+		nalus = append(nalus, payload[:size])
+		payload = payload[size:]
+	}
+
+	return nalus
+}
+
+func updateParamsH264(forma *format.H264, pkt *rtp.Packet) bool {
+	if len(pkt.Payload) == 0 {
+		return false
+	}
+
+	var nalus [][]byte
+
+	switch typ := h264.NALUType(pkt.Payload[0] & 0x1F); typ {
+	case h264.NALUTypeSPS, h264.NALUTypePPS:
+		nalus = [][]byte{pkt.Payload}
+
+	case 24: // STAP-A
+		nalus = splitAggregationUnit(pkt.Payload[1:])
+
+	default:
+		return false
+	}
+
+	sps, pps := forma.SafeParams()
+	changed := false
+
+	for _, nalu := range nalus {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			if !bytes.Equal(nalu, sps) {
+				sps = bytes.Clone(nalu)
+				changed = true
+			}
+
+		case h264.NALUTypePPS:
+			if !bytes.Equal(nalu, pps) {
+				pps = bytes.Clone(nalu)
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		forma.SafeSetParams(sps, pps)
+	}
+
+	return changed
+}
+
+func updateParamsH265(forma *format.H265, pkt *rtp.Packet) bool {
+	if len(pkt.Payload) < 2 {
+		return false
+	}
+
+	var nalus [][]byte
+
+	switch typ := h265.NALUType((pkt.Payload[0] >> 1) & 0b111111); typ {
+	case h265.NALUType_VPS_NUT, h265.NALUType_SPS_NUT, h265.NALUType_PPS_NUT:
+		nalus = [][]byte{pkt.Payload}
+
+	case h265.NALUType_AggregationUnit:
+		// aggregation units with DONL fields are not supported
+		if forma.MaxDONDiff != 0 {
+			return false
+		}
+		nalus = splitAggregationUnit(pkt.Payload[2:])
+
+	default:
+		return false
+	}
+
+	vps, sps, pps := forma.SafeParams()
+	changed := false
+
+	for _, nalu := range nalus {
+		switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+		case h265.NALUType_VPS_NUT:
+			if !bytes.Equal(nalu, vps) {
+				vps = bytes.Clone(nalu)
+				changed = true
+			}
+
+		case h265.NALUType_SPS_NUT:
+			if !bytes.Equal(nalu, sps) {
+				sps = bytes.Clone(nalu)
+				changed = true
+			}
+
+		case h265.NALUType_PPS_NUT:
+			if !bytes.Equal(nalu, pps) {
+				pps = bytes.Clone(nalu)
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		forma.SafeSetParams(vps, sps, pps)
+	}
+
+	return changed
+}
+
+// updateParams updates the parameters of a format with the ones transmitted in-band,
+// and returns whether they changed.
+func updateParams(forma format.Format, pkt *rtp.Packet) bool {
+	switch forma := forma.(type) {
+	case *format.H264:
+		return updateParamsH264(forma, pkt)
+
+	case *format.H265:
+		return updateParamsH265(forma, pkt)
+	}
+
+	return false
+}
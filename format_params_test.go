@@ -0,0 +1,47 @@
+package gortsplib
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func TestUpdateParamsH265(t *testing.T) {
+	forma := &format.H265{
+		PayloadTyp: 96,
+		VPS:        []byte{0x40, 0x01, 0x01},
+		SPS:        []byte{0x42, 0x01, 0x01},
+		PPS:        []byte{0x44, 0x01, 0x01},
+	}
+
+	// aggregation unit with the same parameters
+	changed := updateParams(forma, &rtp.Packet{
+		Payload: []byte{
+			0x60, 0x01,
+			0x00, 0x03, 0x40, 0x01, 0x01,
+			0x00, 0x03, 0x42, 0x01, 0x01,
+			0x00, 0x03, 0x44, 0x01, 0x01,
+		},
+	})
+	require.False(t, changed)
+
+	// single SPS
+	changed = updateParams(forma, &rtp.Packet{
+		Payload: []byte{0x42, 0x01, 0x02},
+	})
+	require.True(t, changed)
+
+	vps, sps, pps := forma.SafeParams()
+	require.Equal(t, []byte{0x40, 0x01, 0x01}, vps)
+	require.Equal(t, []byte{0x42, 0x01, 0x02}, sps)
+	require.Equal(t, []byte{0x44, 0x01, 0x01}, pps)
+
+	// non-parameter NALU
+	changed = updateParams(forma, &rtp.Packet{
+		Payload: []byte{0x26, 0x01, 0x03},
+	})
+	require.False(t, changed)
+}
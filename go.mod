@@ -3,6 +3,8 @@ module github.com/bluenviron/gortsplib/v5
 go 1.24.0
 
 require (
+	github.com/abema/go-mp4 v1.4.1
+	github.com/asticode/go-astits v1.13.0
 	github.com/bluenviron/mediacommon/v2 v2.5.1
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
@@ -10,18 +12,28 @@ require (
 	github.com/pion/rtp v1.8.23
 	github.com/pion/sdp/v3 v3.0.16
 	github.com/pion/srtp/v3 v3.0.8
+	github.com/pion/webrtc/v4 v4.1.6
 	github.com/stretchr/testify v1.11.1
 	golang.org/x/net v0.46.0
 )
 
 require (
 	github.com/asticode/go-astikit v0.30.0 // indirect
-	github.com/asticode/go-astits v1.13.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pion/datachannel v1.5.10 // indirect
+	github.com/pion/dtls/v3 v3.0.7 // indirect
+	github.com/pion/ice/v4 v4.0.10 // indirect
+	github.com/pion/interceptor v0.1.41 // indirect
 	github.com/pion/logging v0.2.4 // indirect
+	github.com/pion/mdns/v2 v2.0.7 // indirect
 	github.com/pion/randutil v0.1.0 // indirect
-	github.com/pion/transport/v3 v3.0.7 // indirect
+	github.com/pion/sctp v1.8.40 // indirect
+	github.com/pion/stun/v3 v3.0.0 // indirect
+	github.com/pion/transport/v3 v3.0.8 // indirect
+	github.com/pion/turn/v4 v4.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/wlynxg/anet v0.0.5 // indirect
+	golang.org/x/crypto v0.43.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -3,6 +3,7 @@ package asyncprocessor
 
 import (
 	"context"
+	"time"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/ringbuffer"
 )
@@ -15,7 +16,7 @@ type Processor struct {
 	OnError    func(context.Context, error)
 
 	running   bool
-	buffer    *ringbuffer.RingBuffer
+	buffer    *ringbuffer.Typed[func() error]
 	ctx       context.Context
 	ctxCancel func()
 
@@ -24,7 +25,7 @@ type Processor struct {
 
 // Initialize initializes the processor.
 func (w *Processor) Initialize() {
-	w.buffer, _ = ringbuffer.New(uint64(w.BufferSize))
+	w.buffer, _ = ringbuffer.NewTyped[func() error](uint64(w.BufferSize))
 	w.ctx, w.ctxCancel = context.WithCancel(context.Background())
 	w.done = make(chan struct{})
 }
@@ -54,12 +55,12 @@ func (w *Processor) run() {
 
 func (w *Processor) runInner() error {
 	for {
-		tmp, ok := w.buffer.Pull()
+		cb, ok := w.buffer.Pull()
 		if !ok {
 			return nil
 		}
 
-		err := tmp.(func() error)()
+		err := cb()
 		if err != nil {
 			return err
 		}
@@ -70,3 +71,12 @@ func (w *Processor) runInner() error {
 func (w *Processor) Push(cb func() error) bool {
 	return w.buffer.Push(cb)
 }
+
+// PushDeadline pushes data to the queue.
+// If the queue is full, it waits until there's space or the deadline expires.
+func (w *Processor) PushDeadline(cb func() error, deadline time.Time) bool {
+	ctx, ctxCancel := context.WithDeadline(w.ctx, deadline)
+	defer ctxCancel()
+
+	return w.buffer.PushContext(ctx, cb)
+}
@@ -0,0 +1,25 @@
+// Package mediatime contains utilities to handle timestamps of media streams.
+package mediatime
+
+import (
+	"time"
+)
+
+// MultiplyAndDivide computes v*m/d.
+// It avoids an int64 overflow and preserves resolution by splitting division into two parts:
+// first it adds the integer part, then the decimal part.
+func MultiplyAndDivide(v, m, d int64) int64 {
+	secs := v / d
+	dec := v % d
+	return secs*m + dec*m/d
+}
+
+// DurationToTimestamp converts a duration into a timestamp expressed in clock rate units.
+func DurationToTimestamp(d time.Duration, clockRate int) int64 {
+	return MultiplyAndDivide(int64(d), int64(clockRate), int64(time.Second))
+}
+
+// TimestampToDuration converts a timestamp expressed in clock rate units into a duration.
+func TimestampToDuration(v int64, clockRate int) time.Duration {
+	return time.Duration(MultiplyAndDivide(v, int64(time.Second), int64(clockRate)))
+}
@@ -0,0 +1,28 @@
+package mediatime
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiplyAndDivide(t *testing.T) {
+	require.Equal(t, int64(90000), MultiplyAndDivide(48000, 90000, 48000))
+	require.Equal(t, int64(1875), MultiplyAndDivide(1000, 90000, 48000))
+	require.Equal(t, int64(-90000), MultiplyAndDivide(-48000, 90000, 48000))
+}
+
+func TestDurationToTimestamp(t *testing.T) {
+	require.Equal(t, int64(135000), DurationToTimestamp(1500*time.Millisecond, 90000))
+
+	// a direct multiplication would overflow
+	require.Equal(t, int64(math.MaxInt64/int64(time.Second)*90000+76500),
+		DurationToTimestamp(time.Duration(math.MaxInt64/int64(time.Second))*time.Second+850*time.Millisecond, 90000))
+}
+
+func TestTimestampToDuration(t *testing.T) {
+	require.Equal(t, 1500*time.Millisecond, TimestampToDuration(135000, 90000))
+	require.Equal(t, 20833333*time.Nanosecond, TimestampToDuration(1000, 48000))
+}
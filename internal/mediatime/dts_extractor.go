@@ -0,0 +1,50 @@
+package mediatime
+
+import (
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/dtsextractor"
+)
+
+// H264DTSExtractor is a H264 DTS extractor that starts from the first random access unit.
+type H264DTSExtractor struct {
+	extractor *dtsextractor.H264
+}
+
+// Extract extracts the DTS of an access unit.
+// It returns false when the DTS can't be computed yet.
+func (e *H264DTSExtractor) Extract(au [][]byte, pts int64) (int64, bool, error) {
+	if e.extractor == nil {
+		// DTS can be computed starting from a random access unit only
+		if !h264.IsRandomAccess(au) {
+			return 0, false, nil
+		}
+		e.extractor = &dtsextractor.H264{}
+		e.extractor.Initialize()
+	}
+
+	dts, err := e.extractor.Extract(au, pts)
+	return dts, err == nil, err
+}
+
+// H265DTSExtractor is a H265 DTS extractor that starts from the first random access unit.
+type H265DTSExtractor struct {
+	extractor *dtsextractor.H265
+}
+
+// Extract extracts the DTS of an access unit.
+// It returns false when the DTS can't be computed yet.
+func (e *H265DTSExtractor) Extract(au [][]byte, pts int64) (int64, bool, error) {
+	if e.extractor == nil {
+		// DTS can be computed starting from a random access unit only
+		if !h265.IsRandomAccess(au) {
+			return 0, false, nil
+		}
+		e.extractor = &dtsextractor.H265{}
+		e.extractor.Initialize()
+	}
+
+	dts, err := e.extractor.Extract(au, pts)
+	return dts, err == nil, err
+}
@@ -0,0 +1,39 @@
+package mediatime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+func TestH264DTSExtractor(t *testing.T) {
+	var e H264DTSExtractor
+
+	// non-IDR before the first IDR
+	_, ok, err := e.Extract([][]byte{{0x41, 0x9a}}, 0)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	dts, ok, err := e.Extract([][]byte{testSPS, testPPS, {0x65, 0x88, 0x84}}, 3000)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(3000), dts)
+}
+
+func TestH265DTSExtractor(t *testing.T) {
+	var e H265DTSExtractor
+
+	// non-IRAP before the first IRAP
+	_, ok, err := e.Extract([][]byte{{0x02, 0x01}}, 0)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
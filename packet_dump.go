@@ -0,0 +1,47 @@
+package gortsplib
+
+import (
+	"net"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/pcap"
+)
+
+func addrToUDPAddr(addr net.Addr, port int) *net.UDPAddr {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr
+
+	case *net.TCPAddr:
+		return &net.UDPAddr{IP: addr.IP, Port: port, Zone: addr.Zone}
+	}
+
+	return &net.UDPAddr{IP: net.IPv4zero, Port: port}
+}
+
+// dumpPacketUDP writes a packet exchanged through UDP into a capture.
+func dumpPacketUDP(w *pcap.Writer, src net.Addr, dst net.Addr, payload []byte) {
+	if w == nil {
+		return
+	}
+
+	// errors are not fatal, since the capture is for diagnostic purposes only.
+	_ = w.WriteUDP(time.Now(), addrToUDPAddr(src, 0), addrToUDPAddr(dst, 0), payload)
+}
+
+// dumpPacketTCP writes a packet exchanged through an interleaved channel into a capture.
+// The packet is written as an UDP datagram whose ports are equal to the channel.
+func dumpPacketTCP(w *pcap.Writer, nconn net.Conn, outgoing bool, channel int, payload []byte) {
+	if w == nil {
+		return
+	}
+
+	src := addrToUDPAddr(nconn.RemoteAddr(), channel)
+	dst := addrToUDPAddr(nconn.LocalAddr(), channel)
+	if outgoing {
+		src, dst = dst, src
+	}
+
+	// errors are not fatal, since the capture is for diagnostic purposes only.
+	_ = w.WriteUDP(time.Now(), src, dst, payload)
+}
@@ -0,0 +1,52 @@
+package gortsplib
+
+import (
+	"context"
+
+	"github.com/bluenviron/gortsplib/v5/internal/asyncprocessor"
+)
+
+// PacketProcessing is the model used to process incoming packets.
+type PacketProcessing int
+
+// packet processing models.
+const (
+	// packets are processed by the routine that reads them:
+	// with UDP, each port has its own routine,
+	// while with TCP, packets of all medias are processed by the connection routine.
+	PacketProcessingDefault PacketProcessing = iota
+
+	// packets of all medias are processed by a single routine,
+	// in the same order in which they are received.
+	PacketProcessingSingle
+
+	// packets of each media (RTP and RTCP) are processed by a dedicated routine,
+	// allowing to decode multiple medias in parallel on multiple cores.
+	PacketProcessingPerMedia
+)
+
+func newReadProcessor(queueSize int) *asyncprocessor.Processor {
+	p := &asyncprocessor.Processor{
+		BufferSize: queueSize,
+		OnError:    func(context.Context, error) {},
+	}
+	p.Initialize()
+	p.Start()
+	return p
+}
+
+// asyncReadFunc returns a readFunc that moves processing of payloads
+// to the routine of the processor.
+// Payloads are always retained, since they are processed later.
+func asyncReadFunc(p *asyncprocessor.Processor, cb readFunc, onQueueFull func()) readFunc {
+	return func(payload []byte) bool {
+		ok := p.Push(func() error {
+			cb(payload)
+			return nil
+		})
+		if !ok {
+			onQueueFull()
+		}
+		return true
+	}
+}
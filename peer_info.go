@@ -0,0 +1,43 @@
+package gortsplib
+
+import (
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+// parseHeaderList returns the items of a comma-separated header.
+func parseHeaderList(v base.HeaderValue) []string {
+	var ret []string
+
+	for _, entry := range v {
+		for item := range strings.SplitSeq(entry, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				ret = append(ret, item)
+			}
+		}
+	}
+
+	return ret
+}
+
+// ClientServerInfo contains informations that the server provided about itself.
+// It can be used to identify the software or firmware of the server.
+type ClientServerInfo struct {
+	// value of the Server header of the last response.
+	Server string
+	// methods declared in the Public header of the OPTIONS response.
+	Methods []base.Method
+	// features declared in the Supported header of the OPTIONS response.
+	Supported []string
+}
+
+// ServerConnClientInfo contains informations that the client provided about itself.
+// It can be used to identify the software or firmware of the client.
+type ServerConnClientInfo struct {
+	// value of the User-Agent header of the last request.
+	UserAgent string
+	// features declared in the Supported header of the last OPTIONS request.
+	Supported []string
+}
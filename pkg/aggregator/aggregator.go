@@ -0,0 +1,539 @@
+// Package aggregator contains a utility to read medias from multiple sources
+// and merge them into a single stream.
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtprestamper"
+)
+
+// Source is a source of an Aggregator.
+type Source struct {
+	// URL of the source.
+	URL string
+
+	// URLs that are used, in order of priority, when URL is not available (optional).
+	// While a fallback URL is in use, URLs with higher priority are checked periodically
+	// and the source switches back to them as soon as they recover.
+	FallbackURLs []string
+
+	// function that selects the medias of the source that are added
+	// to the aggregated description (optional).
+	// It is called every time the source is read, with the description of the source,
+	// and must return medias with the same codecs every time.
+	// It defaults to a function that selects all medias.
+	SelectMedias func(desc *description.Session) []*description.Media
+
+	// function that creates the Client used to read the source (optional).
+	// It can be used to set transport protocol, credentials and TLS configuration.
+	// Scheme and Host are filled automatically.
+	// It defaults to a function that returns a Client with default settings.
+	NewClient func() *gortsplib.Client
+}
+
+// Aggregator reads medias from multiple sources with independent Clients
+// and merges them into a single description, that can be used to create a ServerStream.
+//
+// Each source is read by a dedicated routine, that reconnects after errors
+// independently of other sources. Sequence numbers and timestamps are made continuous
+// across reconnections and payload types are kept stable, therefore the aggregated stream
+// is not affected by restarts of single sources.
+//
+// Packets are provided with their absolute timestamp, taken from RTCP sender reports of the source,
+// that can be passed to ServerStream.WritePacketRTPWithNTP in order to allow readers
+// to synchronize medias coming from different sources.
+type Aggregator struct {
+	// sources.
+	Sources []*Source
+
+	// pause between reconnection attempts.
+	// It is applied after all URLs of a source have failed.
+	// It defaults to 2 seconds.
+	ReconnectPause time.Duration
+
+	// period between checks of URLs with higher priority than the one in use.
+	// It defaults to 10 seconds.
+	HealthCheckPeriod time.Duration
+
+	// called when all sources have been read at least once
+	// and the aggregated description is available.
+	// Packets are provided only after this has been called.
+	OnReady func(desc *description.Session)
+
+	// called when a RTP packet is received from a source.
+	// medi is the media of the aggregated description.
+	// ntp is the absolute timestamp of the packet, or the reception time
+	// if the source didn't send any RTCP sender report yet.
+	OnPacketRTP func(medi *description.Media, pkt *rtp.Packet, ntp time.Time)
+
+	// called when the reading of a source fails,
+	// before a reconnection attempt.
+	OnSourceError func(source *Source, err error)
+
+	// called when a source starts being read from a URL
+	// that is different from the previous one.
+	OnSourceSwitch func(source *Source, url string)
+
+	ctx       context.Context
+	ctxCancel func()
+	wg        sync.WaitGroup
+
+	mutex   sync.RWMutex
+	sources []*aggregatorSource
+	ready   bool
+	desc    *description.Session
+}
+
+// Initialize initializes Aggregator and starts reading sources.
+func (a *Aggregator) Initialize() error {
+	if len(a.Sources) == 0 {
+		return fmt.Errorf("no sources provided")
+	}
+	if a.ReconnectPause == 0 {
+		a.ReconnectPause = 2 * time.Second
+	}
+	if a.HealthCheckPeriod == 0 {
+		a.HealthCheckPeriod = 10 * time.Second
+	}
+	if a.OnReady == nil {
+		a.OnReady = func(*description.Session) {}
+	}
+	if a.OnPacketRTP == nil {
+		a.OnPacketRTP = func(*description.Media, *rtp.Packet, time.Time) {}
+	}
+	if a.OnSourceError == nil {
+		a.OnSourceError = func(*Source, error) {}
+	}
+	if a.OnSourceSwitch == nil {
+		a.OnSourceSwitch = func(*Source, string) {}
+	}
+
+	a.sources = make([]*aggregatorSource, len(a.Sources))
+
+	for i, src := range a.Sources {
+		urls := make([]*base.URL, 1+len(src.FallbackURLs))
+
+		for j, raw := range append([]string{src.URL}, src.FallbackURLs...) {
+			u, err := base.ParseURL(raw)
+			if err != nil {
+				return fmt.Errorf("invalid URL of source %d: %w", i, err)
+			}
+			urls[j] = u
+		}
+
+		a.sources[i] = &aggregatorSource{
+			a:    a,
+			src:  src,
+			urls: urls,
+		}
+	}
+
+	a.ctx, a.ctxCancel = context.WithCancel(context.Background())
+
+	for _, as := range a.sources {
+		a.wg.Add(1)
+		go as.run()
+	}
+
+	return nil
+}
+
+// Close stops reading sources.
+func (a *Aggregator) Close() {
+	a.ctxCancel()
+	a.wg.Wait()
+}
+
+// Description returns the aggregated description.
+// It is nil until all sources have been read at least once.
+func (a *Aggregator) Description() *description.Session {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.desc
+}
+
+func (a *Aggregator) sourceDescribed() {
+	a.mutex.Lock()
+
+	for _, as := range a.sources {
+		if as.medias == nil {
+			a.mutex.Unlock()
+			return
+		}
+	}
+
+	if a.ready {
+		a.mutex.Unlock()
+		return
+	}
+
+	var medias []*description.Media
+
+	for _, as := range a.sources {
+		for _, am := range as.medias {
+			medias = append(medias, am.media)
+		}
+	}
+
+	a.desc = &description.Session{
+		Medias: medias,
+	}
+
+	a.mutex.Unlock()
+
+	a.OnReady(a.desc)
+
+	a.mutex.Lock()
+	a.ready = true
+	a.mutex.Unlock()
+}
+
+func (a *Aggregator) isReady() bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.ready
+}
+
+type aggregatorFormat struct {
+	format    format.Format
+	restamper *rtprestamper.Restamper
+}
+
+type aggregatorMedia struct {
+	media   *description.Media
+	formats []*aggregatorFormat
+}
+
+type errSwitch struct {
+	index int
+}
+
+func (e errSwitch) Error() string {
+	return "switching to a URL with higher priority"
+}
+
+type aggregatorSource struct {
+	a    *Aggregator
+	src  *Source
+	urls []*base.URL
+
+	// written once, when the source is read for the first time
+	medias []*aggregatorMedia
+
+	lastIndex int
+}
+
+func (as *aggregatorSource) run() {
+	defer as.a.wg.Done()
+
+	index := 0
+
+	for {
+		err := as.runInner(index)
+
+		select {
+		case <-as.a.ctx.Done():
+			return
+		default:
+		}
+
+		var sw errSwitch
+		if errors.As(err, &sw) {
+			index = sw.index
+			continue
+		}
+
+		as.a.OnSourceError(as.src, err)
+
+		// try the URL with next priority
+		index++
+		if index < len(as.urls) {
+			continue
+		}
+
+		index = 0
+
+		select {
+		case <-time.After(as.a.ReconnectPause):
+		case <-as.a.ctx.Done():
+			return
+		}
+	}
+}
+
+func (as *aggregatorSource) selectMedias(desc *description.Session) []*description.Media {
+	if as.src.SelectMedias != nil {
+		return as.src.SelectMedias(desc)
+	}
+	return desc.Medias
+}
+
+func (as *aggregatorSource) rawURL(index int) string {
+	if index == 0 {
+		return as.src.URL
+	}
+	return as.src.FallbackURLs[index-1]
+}
+
+func (as *aggregatorSource) newClient(u *base.URL) *gortsplib.Client {
+	var c *gortsplib.Client
+	if as.src.NewClient != nil {
+		c = as.src.NewClient()
+	} else {
+		c = &gortsplib.Client{}
+	}
+
+	c.Scheme = u.Scheme
+	c.Host = u.Host
+
+	return c
+}
+
+// checkMedias checks that medias of the source are compatible
+// with the ones of the aggregated description.
+func (as *aggregatorSource) checkMedias(medias []*description.Media) error {
+	if len(medias) != len(as.medias) {
+		return fmt.Errorf("number of medias changed from %d to %d", len(as.medias), len(medias))
+	}
+
+	for i, medi := range medias {
+		am := as.medias[i]
+
+		if len(medi.Formats) != len(am.formats) {
+			return fmt.Errorf("formats of media %d changed", i)
+		}
+
+		for j, forma := range medi.Formats {
+			if forma.Codec() != am.formats[j].format.Codec() ||
+				forma.ClockRate() != am.formats[j].format.ClockRate() {
+				return fmt.Errorf("formats of media %d changed", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mapMedias checks that medias of the source are compatible with the ones
+// of the aggregated description, or creates aggregated medias.
+func (as *aggregatorSource) mapMedias(medias []*description.Media) error {
+	if len(medias) == 0 {
+		return fmt.Errorf("no medias selected")
+	}
+
+	as.a.mutex.RLock()
+	existing := as.medias
+	as.a.mutex.RUnlock()
+
+	if existing == nil {
+		aggMedias := make([]*aggregatorMedia, len(medias))
+
+		for i, medi := range medias {
+			am := &aggregatorMedia{
+				media: medi.Clone(),
+			}
+			am.media.Control = ""
+
+			for _, forma := range am.media.Formats {
+				r := &rtprestamper.Restamper{
+					ClockRate: forma.ClockRate(),
+				}
+				r.Initialize()
+
+				am.formats = append(am.formats, &aggregatorFormat{
+					format:    forma,
+					restamper: r,
+				})
+			}
+
+			aggMedias[i] = am
+		}
+
+		as.a.mutex.Lock()
+		as.medias = aggMedias
+		as.a.mutex.Unlock()
+
+		return nil
+	}
+
+	err := as.checkMedias(medias)
+	if err != nil {
+		return err
+	}
+
+	// timestamps of the new session are not related to previous ones
+	for _, am := range existing {
+		for _, af := range am.formats {
+			af.restamper.Reset()
+		}
+	}
+
+	return nil
+}
+
+// checkURL checks whether a URL is available and provides compatible medias.
+func (as *aggregatorSource) checkURL(index int) bool {
+	u := as.urls[index]
+	c := as.newClient(u)
+
+	err := c.Start()
+	if err != nil {
+		return false
+	}
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	if err != nil {
+		return false
+	}
+
+	return as.checkMedias(as.selectMedias(desc)) == nil
+}
+
+// runHealthCheck periodically checks URLs with higher priority than the one in use,
+// and returns the index of the first one that is available.
+func (as *aggregatorSource) runHealthCheck(index int, done chan struct{}, healthy chan int) {
+	t := time.NewTicker(as.a.HealthCheckPeriod)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			for i := range index {
+				if as.checkURL(i) {
+					healthy <- i
+					return
+				}
+			}
+
+		case <-done:
+			return
+		}
+	}
+}
+
+func (as *aggregatorSource) runInner(index int) error {
+	u := as.urls[index]
+	c := as.newClient(u)
+
+	err := c.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+	defer close(done)
+
+	// close the client when the aggregator is closed
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		select {
+		case <-as.a.ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	if err != nil {
+		return err
+	}
+
+	medias := as.selectMedias(desc)
+
+	err = as.mapMedias(medias)
+	if err != nil {
+		return err
+	}
+
+	err = c.SetupAll(desc.BaseURL, medias)
+	if err != nil {
+		return err
+	}
+
+	for i, medi := range medias {
+		am := as.medias[i]
+
+		for j, forma := range medi.Formats {
+			af := am.formats[j]
+			payloadType := af.format.PayloadType()
+
+			c.OnPacketRTP(medi, forma, func(pkt *rtp.Packet) {
+				if !as.a.isReady() {
+					return
+				}
+
+				ntp, ok := c.PacketNTP(medi, pkt)
+				if !ok {
+					ntp = time.Now()
+				}
+
+				pkt = af.restamper.Process(pkt)
+
+				if pkt.PayloadType != payloadType {
+					pkt2 := *pkt
+					pkt2.PayloadType = payloadType
+					pkt = &pkt2
+				}
+
+				as.a.OnPacketRTP(am.media, pkt, ntp)
+			})
+		}
+	}
+
+	as.a.sourceDescribed()
+
+	_, err = c.Play(nil)
+	if err != nil {
+		return err
+	}
+
+	if index != as.lastIndex {
+		as.lastIndex = index
+		as.a.OnSourceSwitch(as.src, as.rawURL(index))
+	}
+
+	healthy := make(chan int, 1)
+
+	if index != 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			as.runHealthCheck(index, done, healthy)
+		}()
+	}
+
+	waitErr := make(chan error, 1)
+
+	go func() {
+		waitErr <- c.Wait()
+	}()
+
+	select {
+	case err = <-waitErr:
+		return err
+
+	case i := <-healthy:
+		return errSwitch{index: i}
+	}
+}
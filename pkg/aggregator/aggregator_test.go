@@ -0,0 +1,295 @@
+package aggregator
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func ptrOf[T any](v T) *T {
+	p := new(T)
+	*p = v
+	return p
+}
+
+type testServerHandler struct {
+	mutex  sync.RWMutex
+	stream *gortsplib.ServerStream
+}
+
+func (sh *testServerHandler) getStream() *gortsplib.ServerStream {
+	sh.mutex.RLock()
+	defer sh.mutex.RUnlock()
+	return sh.stream
+}
+
+func (sh *testServerHandler) OnDescribe(
+	_ *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.getStream(), nil
+}
+
+func (sh *testServerHandler) OnSetup(
+	_ *gortsplib.ServerHandlerOnSetupCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.getStream(), nil
+}
+
+func (sh *testServerHandler) OnPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func newTestServer(t *testing.T, address string, medi *description.Media) (*gortsplib.Server, *gortsplib.ServerStream) {
+	h := &testServerHandler{}
+
+	s := &gortsplib.Server{
+		Handler:     h,
+		RTSPAddress: address,
+	}
+	err := s.Start()
+	require.NoError(t, err)
+
+	stream := &gortsplib.ServerStream{
+		Server: s,
+		Desc: &description.Session{
+			Medias: []*description.Media{medi},
+		},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+
+	h.mutex.Lock()
+	h.stream = stream
+	h.mutex.Unlock()
+
+	return s, stream
+}
+
+func testVideoMedia() *description.Media {
+	return &description.Media{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		}},
+	}
+}
+
+func testAudioMedia(payloadType uint8) *description.Media {
+	return &description.Media{
+		Type: description.MediaTypeAudio,
+		Formats: []format.Format{&format.G711{
+			PayloadTyp:   payloadType,
+			MULaw:        false,
+			SampleRate:   8000,
+			ChannelCount: 1,
+		}},
+	}
+}
+
+type testPacket struct {
+	media *description.Media
+	pkt   *rtp.Packet
+}
+
+func TestAggregator(t *testing.T) {
+	s1, stream1 := newTestServer(t, "localhost:8554", testVideoMedia())
+	defer s1.Close()
+	defer stream1.Close()
+
+	s2, stream2 := newTestServer(t, "localhost:8555", testAudioMedia(8))
+
+	ready := make(chan *description.Session, 1)
+	sourceErr := make(chan error, 1)
+	packets := make(chan testPacket, 100)
+
+	newClient := func() *gortsplib.Client {
+		return &gortsplib.Client{
+			Protocol: ptrOf(gortsplib.ProtocolTCP),
+		}
+	}
+
+	a := &Aggregator{
+		Sources: []*Source{
+			{
+				URL:       "rtsp://localhost:8554/video",
+				NewClient: newClient,
+			},
+			{
+				URL:       "rtsp://localhost:8555/audio",
+				NewClient: newClient,
+			},
+		},
+		ReconnectPause: 100 * time.Millisecond,
+		OnReady: func(desc *description.Session) {
+			ready <- desc
+		},
+		OnPacketRTP: func(medi *description.Media, pkt *rtp.Packet, _ time.Time) {
+			packets <- testPacket{medi, pkt}
+		},
+		OnSourceError: func(_ *Source, err error) {
+			select {
+			case sourceErr <- err:
+			default:
+			}
+		},
+	}
+	err := a.Initialize()
+	require.NoError(t, err)
+	defer a.Close()
+
+	desc := <-ready
+	require.Len(t, desc.Medias, 2)
+	require.Equal(t, description.MediaTypeVideo, desc.Medias[0].Type)
+	require.Equal(t, description.MediaTypeAudio, desc.Medias[1].Type)
+	require.Equal(t, desc, a.Description())
+
+	// wait for sources to start playing
+	time.Sleep(200 * time.Millisecond)
+
+	writePacket := func(stream *gortsplib.ServerStream, payloadType uint8, seqNum uint16) {
+		err2 := stream.WritePacketRTP(stream.Desc.Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    payloadType,
+				SequenceNumber: seqNum,
+				Timestamp:      uint32(seqNum) * 100,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		})
+		require.NoError(t, err2)
+	}
+
+	writePacket(stream1, 96, 100)
+	pkt := <-packets
+	require.Equal(t, desc.Medias[0], pkt.media)
+	require.Equal(t, uint16(100), pkt.pkt.SequenceNumber)
+
+	writePacket(stream2, 8, 200)
+	pkt = <-packets
+	require.Equal(t, desc.Medias[1], pkt.media)
+	require.Equal(t, uint16(200), pkt.pkt.SequenceNumber)
+
+	// restart the second source with a different payload type and sequence number
+
+	stream2.Close()
+	s2.Close()
+
+	<-sourceErr
+
+	s2, stream2 = newTestServer(t, "localhost:8555", testAudioMedia(97))
+	defer s2.Close()
+	defer stream2.Close()
+
+	// wait for the source to reconnect
+	var received *testPacket
+
+	for range 50 {
+		writePacket(stream2, 97, 5000)
+
+		select {
+		case pkt = <-packets:
+			received = &pkt
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if received != nil {
+			break
+		}
+	}
+
+	require.NotNil(t, received)
+	require.Equal(t, desc.Medias[1], received.media)
+	require.Equal(t, uint8(8), received.pkt.PayloadType)
+	require.Equal(t, uint16(201), received.pkt.SequenceNumber)
+}
+
+func TestAggregatorFallback(t *testing.T) {
+	s2, stream2 := newTestServer(t, "localhost:8555", testVideoMedia())
+	defer s2.Close()
+	defer stream2.Close()
+
+	ready := make(chan struct{})
+	switches := make(chan string, 10)
+	packets := make(chan testPacket, 100)
+
+	a := &Aggregator{
+		Sources: []*Source{{
+			URL:          "rtsp://localhost:8554/primary",
+			FallbackURLs: []string{"rtsp://localhost:8555/fallback"},
+			NewClient: func() *gortsplib.Client {
+				return &gortsplib.Client{
+					Protocol: ptrOf(gortsplib.ProtocolTCP),
+				}
+			},
+		}},
+		ReconnectPause:    100 * time.Millisecond,
+		HealthCheckPeriod: 100 * time.Millisecond,
+		OnReady: func(_ *description.Session) {
+			close(ready)
+		},
+		OnPacketRTP: func(medi *description.Media, pkt *rtp.Packet, _ time.Time) {
+			packets <- testPacket{medi, pkt}
+		},
+		OnSourceSwitch: func(_ *Source, url string) {
+			switches <- url
+		},
+	}
+	err := a.Initialize()
+	require.NoError(t, err)
+	defer a.Close()
+
+	<-ready
+
+	// primary is not available
+	require.Equal(t, "rtsp://localhost:8555/fallback", <-switches)
+
+	// primary recovers
+	s1, stream1 := newTestServer(t, "localhost:8554", testVideoMedia())
+	defer s1.Close()
+	defer stream1.Close()
+
+	require.Equal(t, "rtsp://localhost:8554/primary", <-switches)
+
+	// wait for the source to start playing
+	var received *testPacket
+
+	for range 50 {
+		err = stream1.WritePacketRTP(stream1.Desc.Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: 100,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		})
+		require.NoError(t, err)
+
+		select {
+		case pkt := <-packets:
+			received = &pkt
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if received != nil {
+			break
+		}
+	}
+
+	require.NotNil(t, received)
+	require.Equal(t, a.Description().Medias[0], received.media)
+}
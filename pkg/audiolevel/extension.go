@@ -0,0 +1,84 @@
+// Package audiolevel contains utilities to deal with the audio level RTP header extension.
+package audiolevel
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pion/rtp"
+)
+
+// URI is the URI of the extension, that is used in the extmap SDP attribute.
+const URI = "urn:ietf:params:rtp-hdrext:ssrc-audio-level"
+
+// Extension is the client-to-mixer audio level RTP header extension.
+// Specification: RFC6464
+type Extension struct {
+	// audio level of the packet, in -dBov.
+	// 0 is the loudest level, 127 is silence.
+	Level uint8
+
+	// whether the packet contains voice.
+	Voice bool
+}
+
+// Unmarshal decodes the extension from a RTP header.
+func (e *Extension) Unmarshal(h *rtp.Header, id uint8) error {
+	buf := h.GetExtension(id)
+	if buf == nil {
+		return fmt.Errorf("audio level extension not found")
+	}
+
+	var ext rtp.AudioLevelExtension
+	err := ext.Unmarshal(buf)
+	if err != nil {
+		return err
+	}
+
+	e.Level = ext.Level
+	e.Voice = ext.Voice
+
+	return nil
+}
+
+// Marshal encodes the extension into a RTP header.
+// Other extensions of the header are preserved.
+func (e Extension) Marshal(h *rtp.Header, id uint8) error {
+	buf, err := rtp.AudioLevelExtension{
+		Level: e.Level,
+		Voice: e.Voice,
+	}.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return h.SetExtension(id, buf)
+}
+
+// Compute computes the audio level of 16-bit linear PCM samples, in -dBov.
+func Compute(samples []int16) uint8 {
+	if len(samples) == 0 {
+		return 127
+	}
+
+	sum := float64(0)
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+
+	meanSquare := sum / float64(len(samples))
+	if meanSquare == 0 {
+		return 127
+	}
+
+	level := -10 * math.Log10(meanSquare/(32768*32768))
+
+	switch {
+	case level < 0:
+		return 0
+	case level > 127:
+		return 127
+	}
+
+	return uint8(math.Round(level))
+}
@@ -0,0 +1,77 @@
+package audiolevel
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensionMarshalUnmarshal(t *testing.T) {
+	h := rtp.Header{
+		Version: 2,
+	}
+
+	err := h.SetExtension(2, []byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = Extension{
+		Level: 35,
+		Voice: true,
+	}.Marshal(&h, 1)
+	require.NoError(t, err)
+
+	byts, err := (&rtp.Packet{Header: h, Payload: []byte{5}}).Marshal()
+	require.NoError(t, err)
+
+	var pkt rtp.Packet
+	err = pkt.Unmarshal(byts)
+	require.NoError(t, err)
+
+	var ext Extension
+	err = ext.Unmarshal(&pkt.Header, 1)
+	require.NoError(t, err)
+	require.Equal(t, Extension{Level: 35, Voice: true}, ext)
+
+	// other extensions are preserved
+	require.Equal(t, []byte{1, 2, 3}, pkt.Header.GetExtension(2))
+
+	err = ext.Unmarshal(&pkt.Header, 3)
+	require.EqualError(t, err, "audio level extension not found")
+
+	err = Extension{Level: 128}.Marshal(&h, 1)
+	require.Error(t, err)
+}
+
+func TestCompute(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		samples []int16
+		level   uint8
+	}{
+		{
+			"silence",
+			[]int16{0, 0, 0, 0},
+			127,
+		},
+		{
+			"full scale",
+			[]int16{-32768, -32768, -32768, -32768},
+			0,
+		},
+		{
+			"half scale",
+			[]int16{16384, -16384, 16384, -16384},
+			6,
+		},
+		{
+			"quiet",
+			[]int16{33, -33, 33, -33},
+			60,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.level, Compute(ca.samples))
+		})
+	}
+}
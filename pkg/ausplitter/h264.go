@@ -0,0 +1,123 @@
+// Package ausplitter contains utilities to split sequences of H264 and H265 NALUs into access units.
+package ausplitter
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
+)
+
+// max_size(first_mb_in_slice) + max_size(slice_type), with emulation prevention bytes.
+const maxBytesToGetH264SliceHeader = 12
+
+// H264SliceHeader is the beginning of the header of a H264 slice.
+// Specification: ITU-T Rec. H.264, 7.3.3
+type H264SliceHeader struct {
+	FirstMBInSlice uint32
+	SliceType      uint32
+}
+
+// Unmarshal decodes the header from a slice NALU.
+func (h *H264SliceHeader) Unmarshal(nalu []byte) error {
+	if len(nalu) < 2 {
+		return fmt.Errorf("not enough bits")
+	}
+
+	buf := nalu[1:]
+	lb := min(len(buf), maxBytesToGetH264SliceHeader)
+
+	r := bits.Reader{Buf: h264.EmulationPreventionRemove(buf[:lb])}
+
+	var err error
+	h.FirstMBInSlice, err = r.ReadUE()
+	if err != nil {
+		return err
+	}
+
+	h.SliceType, err = r.ReadUE()
+	if err != nil {
+		return err
+	}
+
+	if h.SliceType > 9 {
+		return fmt.Errorf("invalid slice type: %d", h.SliceType)
+	}
+
+	return nil
+}
+
+// H264 splits a sequence of H264 NALUs into access units.
+// It can be used with cameras that don't send access unit delimiters,
+// in order to group NALUs correctly before encoding them into RTP packets,
+// and therefore to place the marker bit at the end of each frame.
+// Specification: ITU-T Rec. H.264, 7.4.1.2.3
+type H264 struct {
+	au        [][]byte
+	vclFound  bool
+	prevIDR   bool
+	prevIsRef bool
+}
+
+// Push pushes a NALU.
+// If the NALU begins a new access unit, the previous access unit is returned.
+func (s *H264) Push(nalu []byte) ([][]byte, error) {
+	if len(nalu) == 0 {
+		return nil, fmt.Errorf("empty NALU")
+	}
+
+	typ := h264.NALUType(nalu[0] & 0x1F)
+	vcl := (typ == h264.NALUTypeNonIDR || typ == h264.NALUTypeIDR)
+	idr := (typ == h264.NALUTypeIDR)
+	isRef := ((nalu[0] >> 5) & 0x03) != 0
+	newAU := false
+
+	switch typ {
+	case h264.NALUTypeNonIDR, h264.NALUTypeIDR:
+		var h H264SliceHeader
+		err := h.Unmarshal(nalu)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slice header: %w", err)
+		}
+
+		// the first slice of a primary coded picture begins a new access unit.
+		newAU = s.vclFound && (h.FirstMBInSlice == 0 || idr != s.prevIDR || isRef != s.prevIsRef)
+
+	case h264.NALUTypeAccessUnitDelimiter, h264.NALUTypeSPS, h264.NALUTypePPS, h264.NALUTypeSEI,
+		14, 15, 16, 17, 18:
+		newAU = s.vclFound
+	}
+
+	var ret [][]byte
+
+	if newAU {
+		ret = s.au
+		s.au = nil
+		s.vclFound = false
+	}
+
+	if vcl {
+		s.vclFound = true
+		s.prevIDR = idr
+		s.prevIsRef = isRef
+	}
+
+	if len(s.au) >= h264.MaxNALUsPerAccessUnit {
+		s.au = nil
+		s.vclFound = false
+		return nil, fmt.Errorf("NALU count exceeds maximum allowed (%d)", h264.MaxNALUsPerAccessUnit)
+	}
+
+	s.au = append(s.au, nalu)
+
+	return ret, nil
+}
+
+// Flush returns the access unit that is currently being built.
+func (s *H264) Flush() [][]byte {
+	ret := s.au
+	s.au = nil
+	s.vclFound = false
+	return ret
+}
@@ -0,0 +1,79 @@
+package ausplitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
+)
+
+func h264Slice(idr bool, firstMB uint32, sliceType uint32) []byte {
+	var w bits.Writer
+
+	if idr {
+		w.WriteBits(0x65, 8)
+	} else {
+		w.WriteBits(0x41, 8)
+	}
+
+	w.WriteUE(firstMB)
+	w.WriteUE(sliceType)
+	w.WriteTrailingBits()
+
+	return w.Bytes()
+}
+
+func TestH264SliceHeaderUnmarshal(t *testing.T) {
+	var h H264SliceHeader
+	err := h.Unmarshal(h264Slice(false, 35, 6))
+	require.NoError(t, err)
+	require.Equal(t, H264SliceHeader{
+		FirstMBInSlice: 35,
+		SliceType:      6,
+	}, h)
+
+	err = h.Unmarshal([]byte{0x41})
+	require.Error(t, err)
+}
+
+func TestH264(t *testing.T) {
+	sps := []byte{0x67, 0x42, 0xc0, 0x28}
+	pps := []byte{0x68, 0xce, 0x3c, 0x80}
+	sei := []byte{0x06, 0x05, 0x01, 0x00}
+
+	nalus := [][]byte{
+		sps,
+		pps,
+		h264Slice(true, 0, 7),
+		h264Slice(true, 40, 7),
+		h264Slice(false, 0, 5),
+		h264Slice(false, 40, 5),
+		sei,
+		h264Slice(false, 0, 5),
+		h264Slice(false, 0, 5),
+	}
+
+	var s H264
+	var aus [][][]byte
+
+	for _, nalu := range nalus {
+		au, err := s.Push(nalu)
+		require.NoError(t, err)
+
+		if au != nil {
+			aus = append(aus, au)
+		}
+	}
+
+	aus = append(aus, s.Flush())
+
+	require.Equal(t, [][][]byte{
+		{nalus[0], nalus[1], nalus[2], nalus[3]},
+		{nalus[4], nalus[5]},
+		{nalus[6], nalus[7]},
+		{nalus[8]},
+	}, aus)
+
+	require.Nil(t, s.Flush())
+}
@@ -0,0 +1,77 @@
+package ausplitter
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+)
+
+func isH265VCL(typ h265.NALUType) bool {
+	return typ < 32
+}
+
+// H265 splits a sequence of H265 NALUs into access units.
+// It can be used with cameras that don't send access unit delimiters,
+// in order to group NALUs correctly before encoding them into RTP packets,
+// and therefore to place the marker bit at the end of each frame.
+// Specification: ITU-T Rec. H.265, 7.4.2.4.4
+type H265 struct {
+	au       [][]byte
+	vclFound bool
+}
+
+// Push pushes a NALU.
+// If the NALU begins a new access unit, the previous access unit is returned.
+func (s *H265) Push(nalu []byte) ([][]byte, error) {
+	if len(nalu) < 2 {
+		return nil, fmt.Errorf("NALU is too short")
+	}
+
+	typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+	newAU := false
+
+	switch {
+	case isH265VCL(typ):
+		if len(nalu) < 3 {
+			return nil, fmt.Errorf("invalid slice segment header: not enough bits")
+		}
+
+		firstSliceSegmentInPic := (nalu[2] >> 7) != 0
+		newAU = s.vclFound && firstSliceSegmentInPic
+
+	case typ == h265.NALUType_VPS_NUT, typ == h265.NALUType_SPS_NUT, typ == h265.NALUType_PPS_NUT,
+		typ == h265.NALUType_AUD_NUT, typ == h265.NALUType_PREFIX_SEI_NUT,
+		(typ >= 41 && typ <= 44), (typ >= 48 && typ <= 55):
+		newAU = s.vclFound
+	}
+
+	var ret [][]byte
+
+	if newAU {
+		ret = s.au
+		s.au = nil
+		s.vclFound = false
+	}
+
+	if isH265VCL(typ) {
+		s.vclFound = true
+	}
+
+	if len(s.au) >= h265.MaxNALUsPerAccessUnit {
+		s.au = nil
+		s.vclFound = false
+		return nil, fmt.Errorf("NALU count exceeds maximum allowed (%d)", h265.MaxNALUsPerAccessUnit)
+	}
+
+	s.au = append(s.au, nalu)
+
+	return ret, nil
+}
+
+// Flush returns the access unit that is currently being built.
+func (s *H265) Flush() [][]byte {
+	ret := s.au
+	s.au = nil
+	s.vclFound = false
+	return ret
+}
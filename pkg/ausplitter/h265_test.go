@@ -0,0 +1,54 @@
+package ausplitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func h265Slice(typ byte, first bool) []byte {
+	b := byte(0x00)
+	if first {
+		b = 0x80
+	}
+	return []byte{typ << 1, 0x01, b, 0xAA}
+}
+
+func TestH265(t *testing.T) {
+	vps := []byte{0x40, 0x01, 0x0c}
+	sps := []byte{0x42, 0x01, 0x01}
+	pps := []byte{0x44, 0x01, 0xc1}
+	suffixSEI := []byte{0x50, 0x01, 0x01}
+
+	nalus := [][]byte{
+		vps,
+		sps,
+		pps,
+		h265Slice(19, true),
+		h265Slice(19, false),
+		suffixSEI,
+		h265Slice(1, true),
+		h265Slice(1, false),
+		h265Slice(1, true),
+	}
+
+	var s H265
+	var aus [][][]byte
+
+	for _, nalu := range nalus {
+		au, err := s.Push(nalu)
+		require.NoError(t, err)
+
+		if au != nil {
+			aus = append(aus, au)
+		}
+	}
+
+	aus = append(aus, s.Flush())
+
+	require.Equal(t, [][][]byte{
+		{nalus[0], nalus[1], nalus[2], nalus[3], nalus[4], nalus[5]},
+		{nalus[6], nalus[7]},
+		{nalus[8]},
+	}, aus)
+}
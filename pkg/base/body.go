@@ -7,15 +7,19 @@ import (
 	"strconv"
 )
 
-const (
-	rtspMaxContentLength = 128 * 1024
-)
-
 type body []byte
 
 func (b *body) unmarshal(header Header, rb *bufio.Reader) error {
+	return b.unmarshalWithLimits(header, rb, nil, ConformanceDefault)
+}
+
+func (b *body) unmarshalWithLimits(header Header, rb *bufio.Reader, limits *Limits, conformance Conformance) error {
 	cls, ok := header["Content-Length"]
 	if !ok || len(cls) != 1 {
+		if _, ok = header["Content-Type"]; ok && conformance == ConformanceStrict {
+			return fmt.Errorf("Content-Type is present but Content-Length is missing")
+		}
+
 		*b = nil
 		return nil
 	}
@@ -25,9 +29,8 @@ func (b *body) unmarshal(header Header, rb *bufio.Reader) error {
 		return fmt.Errorf("invalid Content-Length")
 	}
 
-	if cl > rtspMaxContentLength {
-		return fmt.Errorf("Content-Length exceeds %d (it's %d)",
-			rtspMaxContentLength, cl)
+	if cl > uint64(limits.maxBodySize()) {
+		return ErrBodyTooBig{Size: cl, Max: limits.maxBodySize()}
 	}
 
 	*b = make([]byte, cl)
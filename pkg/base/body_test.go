@@ -33,6 +33,18 @@ func TestBodyUnmarshal(t *testing.T) {
 	}
 }
 
+func TestBodyUnmarshalLimits(t *testing.T) {
+	var p body
+	err := p.unmarshalWithLimits(
+		Header{
+			"Content-Length": HeaderValue{"5"},
+		},
+		bufio.NewReader(bytes.NewReader([]byte{1, 2, 3, 4, 5})),
+		&Limits{MaxBodySize: 4},
+		ConformanceDefault)
+	require.Equal(t, ErrBodyTooBig{Size: 5, Max: 4}, err)
+}
+
 func TestBodyMarshal(t *testing.T) {
 	for _, ca := range casesBody {
 		t.Run(ca.name, func(t *testing.T) {
@@ -0,0 +1,49 @@
+package base
+
+import (
+	"strings"
+)
+
+// Conformance is the level of conformance to the specification
+// that is required when decoding messages.
+//
+// Tolerances enabled by each level:
+//
+//	                                          Strict   Default   Lenient
+//	header keys surrounded by spaces          error    kept      trimmed
+//	Content-Type without Content-Length       error    no body   no body
+//	lowercase protocol ("rtsp/1.0")           error    error     accepted
+//	spaces inside request URLs                error    error     encoded
+//	invalid Content-Base (client only)        error    error     request URL
+type Conformance int
+
+// conformance levels.
+const (
+	// tolerate common deviations from the specification.
+	ConformanceDefault Conformance = iota
+
+	// reject messages that do not comply with the specification.
+	ConformanceStrict
+
+	// tolerate additional deviations, produced by broken devices.
+	ConformanceLenient
+)
+
+// String implements fmt.Stringer.
+func (c Conformance) String() string {
+	switch c {
+	case ConformanceStrict:
+		return "strict"
+
+	case ConformanceLenient:
+		return "lenient"
+	}
+	return "default"
+}
+
+func (c Conformance) isProtocolValid(proto string) bool {
+	if c == ConformanceLenient {
+		return strings.EqualFold(proto, rtspProtocol10)
+	}
+	return proto == rtspProtocol10
+}
@@ -0,0 +1,131 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformanceRequest(t *testing.T) {
+	for _, ca := range []struct {
+		name   string
+		byts   string
+		strict string
+		def    string
+		req    Request
+	}{
+		{
+			"key with spaces",
+			"OPTIONS rtsp://example.com/media.mp4 RTSP/1.0\r\n" +
+				"CSeq : 1\r\n" +
+				"\r\n",
+			"header key is surrounded by spaces: 'CSeq '",
+			"",
+			Request{
+				Method: Options,
+				URL:    mustParseURL("rtsp://example.com/media.mp4"),
+				Header: Header{
+					"CSeq": HeaderValue{"1"},
+				},
+			},
+		},
+		{
+			"missing content-length",
+			"ANNOUNCE rtsp://example.com/media.mp4 RTSP/1.0\r\n" +
+				"CSeq: 1\r\n" +
+				"Content-Type: application/sdp\r\n" +
+				"\r\n",
+			"Content-Type is present but Content-Length is missing",
+			"",
+			Request{
+				Method: Announce,
+				URL:    mustParseURL("rtsp://example.com/media.mp4"),
+				Header: Header{
+					"CSeq":         HeaderValue{"1"},
+					"Content-Type": HeaderValue{"application/sdp"},
+				},
+			},
+		},
+		{
+			"lowercase protocol",
+			"OPTIONS rtsp://example.com/media.mp4 rtsp/1.0\r\n" +
+				"CSeq: 1\r\n" +
+				"\r\n",
+			"expected 'RTSP/1.0', got [114 116 115 112 47 49 46 48]",
+			"expected 'RTSP/1.0', got [114 116 115 112 47 49 46 48]",
+			Request{
+				Method: Options,
+				URL:    mustParseURL("rtsp://example.com/media.mp4"),
+				Header: Header{
+					"CSeq": HeaderValue{"1"},
+				},
+			},
+		},
+		{
+			"spaces in url",
+			"DESCRIBE rtsp://example.com/my stream RTSP/1.0\r\n" +
+				"CSeq: 1\r\n" +
+				"\r\n",
+			"expected 'RTSP/1.0', got [115 116 114 101 97 109 32 82 84 83 80 47 49 46 48]",
+			"expected 'RTSP/1.0', got [115 116 114 101 97 109 32 82 84 83 80 47 49 46 48]",
+			Request{
+				Method: Describe,
+				URL:    mustParseURL("rtsp://example.com/my%20stream"),
+				Header: Header{
+					"CSeq": HeaderValue{"1"},
+				},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			for _, conformance := range []Conformance{ConformanceStrict, ConformanceDefault, ConformanceLenient} {
+				t.Run(conformance.String(), func(t *testing.T) {
+					var req Request
+					err := req.UnmarshalWithLimits(bufio.NewReader(bytes.NewBufferString(ca.byts)),
+						nil, conformance)
+
+					var expErr string
+					switch conformance {
+					case ConformanceStrict:
+						expErr = ca.strict
+					case ConformanceDefault:
+						expErr = ca.def
+					}
+
+					if expErr != "" {
+						require.EqualError(t, err, expErr)
+						return
+					}
+
+					require.NoError(t, err)
+
+					if conformance == ConformanceLenient {
+						require.Equal(t, ca.req, req)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestConformanceResponse(t *testing.T) {
+	byts := "rtsp/1.0 200 OK\r\n" +
+		"CSeq: 1\r\n" +
+		"\r\n"
+
+	var res Response
+	err := res.UnmarshalWithLimits(bufio.NewReader(bytes.NewBufferString(byts)), nil, ConformanceDefault)
+	require.Error(t, err)
+
+	err = res.UnmarshalWithLimits(bufio.NewReader(bytes.NewBufferString(byts)), nil, ConformanceLenient)
+	require.NoError(t, err)
+	require.Equal(t, Response{
+		StatusCode:    StatusOK,
+		StatusMessage: "OK",
+		Header: Header{
+			"CSeq": HeaderValue{"1"},
+		},
+	}, res)
+}
@@ -2,18 +2,13 @@ package base
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"strings"
 )
 
-const (
-	headerMaxEntryCount  = 255
-	headerMaxKeyLength   = 512
-	headerMaxValueLength = 2048
-)
-
 func headerKeyNormalize(in string) string {
 	switch strings.ToLower(in) {
 	case "rtp-info":
@@ -38,6 +33,10 @@ type HeaderValue []string
 type Header map[string]HeaderValue
 
 func (h *Header) unmarshal(br *bufio.Reader) error {
+	return h.unmarshalWithLimits(br, nil, ConformanceDefault)
+}
+
+func (h *Header) unmarshalWithLimits(br *bufio.Reader, limits *Limits, conformance Conformance) error {
 	*h = make(Header)
 	count := 0
 
@@ -55,17 +54,31 @@ func (h *Header) unmarshal(br *bufio.Reader) error {
 			break
 		}
 
-		if count >= headerMaxEntryCount {
-			return fmt.Errorf("headers count exceeds %d", headerMaxEntryCount)
+		if count >= limits.maxHeaderCount() {
+			return ErrHeaderCountExceeded{Max: limits.maxHeaderCount()}
 		}
 
 		key := string([]byte{byt})
-		byts, err := readBytesLimited(br, ':', headerMaxKeyLength-1)
+		byts, err := readBytesLimited(br, ':', limits.maxHeaderKeyLength()-1)
 		if err != nil {
+			var eb errBufferLengthExceeded
+			if errors.As(err, &eb) {
+				return ErrHeaderKeyTooLong{Max: limits.maxHeaderKeyLength()}
+			}
 			return fmt.Errorf("value is missing")
 		}
 
 		key += string(byts[:len(byts)-1])
+
+		if trimmed := strings.Trim(key, " \t"); trimmed != key {
+			switch conformance {
+			case ConformanceStrict:
+				return fmt.Errorf("header key is surrounded by spaces: '%s'", key)
+			case ConformanceLenient:
+				key = trimmed
+			}
+		}
+
 		key = headerKeyNormalize(key)
 
 		// https://tools.ietf.org/html/rfc2616
@@ -82,8 +95,12 @@ func (h *Header) unmarshal(br *bufio.Reader) error {
 		}
 		br.UnreadByte() //nolint:errcheck
 
-		byts, err = readBytesLimited(br, '\r', headerMaxValueLength)
+		byts, err = readBytesLimited(br, '\r', limits.maxHeaderValueLength())
 		if err != nil {
+			var eb errBufferLengthExceeded
+			if errors.As(err, &eb) {
+				return ErrHeaderValueTooLong{Max: limits.maxHeaderValueLength()}
+			}
 			return err
 		}
 		val := string(byts[:len(byts)-1])
@@ -3,6 +3,7 @@ package base
 import (
 	"bufio"
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -119,6 +120,81 @@ func TestHeaderUnmarshal(t *testing.T) {
 	}
 }
 
+func TestHeaderUnmarshalLimits(t *testing.T) {
+	limits := &Limits{
+		MaxHeaderCount:       2,
+		MaxHeaderKeyLength:   8,
+		MaxHeaderValueLength: 8,
+	}
+
+	for _, ca := range []struct {
+		name string
+		dec  []byte
+		err  error
+	}{
+		{
+			"count",
+			[]byte("A: 1\r\nB: 2\r\nC: 3\r\n\r\n"),
+			ErrHeaderCountExceeded{Max: 2},
+		},
+		{
+			"key",
+			[]byte("Averylongkey: 1\r\n\r\n"),
+			ErrHeaderKeyTooLong{Max: 8},
+		},
+		{
+			"value",
+			[]byte("A: averylongvalue\r\n\r\n"),
+			ErrHeaderValueTooLong{Max: 8},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Header
+			err := h.unmarshalWithLimits(bufio.NewReader(bytes.NewBuffer(ca.dec)), limits, ConformanceDefault)
+			require.Equal(t, ca.err, err)
+		})
+	}
+}
+
+func TestHeaderUnmarshalLongValue(t *testing.T) {
+	limits := &Limits{
+		MaxHeaderValueLength: 6000,
+	}
+	require.Equal(t, 6000, limits.ReaderSize())
+	require.Equal(t, 4096, (*Limits)(nil).ReaderSize())
+
+	for _, ca := range []struct {
+		name string
+		size int
+		err  error
+	}{
+		{
+			"within limit",
+			5999,
+			nil,
+		},
+		{
+			"exceeding limit",
+			6000,
+			ErrHeaderValueTooLong{Max: 6000},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			dec := "A: " + strings.Repeat("a", ca.size) + "\r\n\r\n"
+
+			var h Header
+			err := h.unmarshalWithLimits(bufio.NewReaderSize(bytes.NewBufferString(dec), limits.ReaderSize()),
+				limits, ConformanceDefault)
+			if ca.err != nil {
+				require.Equal(t, ca.err, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, Header{"A": HeaderValue{strings.Repeat("a", ca.size)}}, h)
+			}
+		})
+	}
+}
+
 func TestHeaderWrite(t *testing.T) {
 	for _, ca := range cases {
 		t.Run(ca.name, func(t *testing.T) {
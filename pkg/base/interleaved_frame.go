@@ -23,6 +23,12 @@ type InterleavedFrame struct {
 
 // Unmarshal decodes an interleaved frame.
 func (f *InterleavedFrame) Unmarshal(br *bufio.Reader) error {
+	return f.UnmarshalWithLimits(br, nil)
+}
+
+// UnmarshalWithLimits decodes an interleaved frame and enforces the given limits.
+// If limits is nil, default limits are used.
+func (f *InterleavedFrame) UnmarshalWithLimits(br *bufio.Reader, limits *Limits) error {
 	var header [4]byte
 	_, err := io.ReadFull(br, header[:])
 	if err != nil {
@@ -33,9 +39,12 @@ func (f *InterleavedFrame) Unmarshal(br *bufio.Reader) error {
 		return fmt.Errorf("invalid magic byte (0x%.2x)", header[0])
 	}
 
-	// it's useless to check payloadLen since it's limited to 65535
 	payloadLen := int(uint16(header[2])<<8 | uint16(header[3]))
 
+	if payloadLen > limits.maxInterleavedFrameSize() {
+		return ErrInterleavedFrameTooBig{Size: payloadLen, Max: limits.maxInterleavedFrameSize()}
+	}
+
 	f.Channel = int(header[1])
 	f.Payload = make([]byte, payloadLen)
 
@@ -70,3 +79,12 @@ func (f InterleavedFrame) Marshal() ([]byte, error) {
 	_, err := f.MarshalTo(buf)
 	return buf, err
 }
+
+// WriteTo writes an InterleavedFrame into a io.Writer.
+// The payload is written directly, without copying it into an intermediate buffer.
+// Header and payload may be written with separate calls to w.Write().
+func (f InterleavedFrame) WriteTo(w io.Writer) (int64, error) {
+	payloadLen := len(f.Payload)
+	head := []byte{InterleavedFrameMagicByte, byte(f.Channel), byte(payloadLen >> 8), byte(payloadLen)}
+	return writeHeadAndBody(w, head, f.Payload)
+}
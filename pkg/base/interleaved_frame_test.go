@@ -44,6 +44,14 @@ func TestInterleavedFrameUnmarshal(t *testing.T) {
 	}
 }
 
+func TestInterleavedFrameUnmarshalLimits(t *testing.T) {
+	var f InterleavedFrame
+	err := f.UnmarshalWithLimits(
+		bufio.NewReader(bytes.NewBuffer([]byte{0x24, 0x6, 0x0, 0x4, 0x1, 0x2, 0x3, 0x4})),
+		&Limits{MaxInterleavedFrameSize: 3})
+	require.Equal(t, ErrInterleavedFrameTooBig{Size: 4, Max: 3}, err)
+}
+
 func TestInterleavedFrameMarshal(t *testing.T) {
 	for _, ca := range casesInterleavedFrame {
 		t.Run(ca.name, func(t *testing.T) {
@@ -54,6 +62,18 @@ func TestInterleavedFrameMarshal(t *testing.T) {
 	}
 }
 
+func TestInterleavedFrameWriteTo(t *testing.T) {
+	for _, ca := range casesInterleavedFrame {
+		t.Run(ca.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := ca.dec.WriteTo(&buf)
+			require.NoError(t, err)
+			require.Equal(t, int64(len(ca.enc)), n)
+			require.Equal(t, ca.enc, buf.Bytes())
+		})
+	}
+}
+
 func FuzzInterleavedFrameUnmarshal(f *testing.F) {
 	for _, ca := range casesInterleavedFrame {
 		f.Add(ca.enc)
@@ -0,0 +1,153 @@
+package base
+
+import (
+	"fmt"
+)
+
+const (
+	defaultMaxHeaderCount          = 255
+	defaultMaxHeaderKeyLength      = 512
+	defaultMaxHeaderValueLength    = 2048
+	defaultMaxBodySize             = 128 * 1024
+	defaultMaxInterleavedFrameSize = 65535
+
+	minReaderSize = 4096
+)
+
+// Limits are limits enforced when decoding messages,
+// in order to prevent memory exhaustion caused by malicious or broken peers.
+// Zero values are replaced by default values. Negative values are invalid.
+// Readers that are used to decode messages must have a buffer of at least ReaderSize() bytes.
+type Limits struct {
+	// maximum number of header entries.
+	// It defaults to 255.
+	MaxHeaderCount int
+
+	// maximum length of a header key.
+	// It defaults to 512.
+	MaxHeaderKeyLength int
+
+	// maximum length of a header value.
+	// It defaults to 2048.
+	MaxHeaderValueLength int
+
+	// maximum size of a body.
+	// It defaults to 128 KiB.
+	MaxBodySize int
+
+	// maximum size of the payload of an interleaved frame.
+	// It defaults to 65535.
+	MaxInterleavedFrameSize int
+}
+
+// Validate checks that limits are valid.
+func (l *Limits) Validate() error {
+	for _, v := range []struct {
+		name  string
+		value int
+	}{
+		{"MaxHeaderCount", l.MaxHeaderCount},
+		{"MaxHeaderKeyLength", l.MaxHeaderKeyLength},
+		{"MaxHeaderValueLength", l.MaxHeaderValueLength},
+		{"MaxBodySize", l.MaxBodySize},
+		{"MaxInterleavedFrameSize", l.MaxInterleavedFrameSize},
+	} {
+		if v.value < 0 {
+			return fmt.Errorf("%s can't be negative", v.name)
+		}
+	}
+	return nil
+}
+
+// ReaderSize returns the minimum buffer size of a bufio.Reader
+// that is able to decode messages within the limits.
+func (l *Limits) ReaderSize() int {
+	return max(minReaderSize, l.maxHeaderKeyLength(), l.maxHeaderValueLength())
+}
+
+func (l *Limits) maxHeaderCount() int {
+	if l == nil || l.MaxHeaderCount <= 0 {
+		return defaultMaxHeaderCount
+	}
+	return l.MaxHeaderCount
+}
+
+func (l *Limits) maxHeaderKeyLength() int {
+	if l == nil || l.MaxHeaderKeyLength <= 0 {
+		return defaultMaxHeaderKeyLength
+	}
+	return l.MaxHeaderKeyLength
+}
+
+func (l *Limits) maxHeaderValueLength() int {
+	if l == nil || l.MaxHeaderValueLength <= 0 {
+		return defaultMaxHeaderValueLength
+	}
+	return l.MaxHeaderValueLength
+}
+
+func (l *Limits) maxBodySize() int {
+	if l == nil || l.MaxBodySize <= 0 {
+		return defaultMaxBodySize
+	}
+	return l.MaxBodySize
+}
+
+func (l *Limits) maxInterleavedFrameSize() int {
+	if l == nil || l.MaxInterleavedFrameSize <= 0 {
+		return defaultMaxInterleavedFrameSize
+	}
+	return l.MaxInterleavedFrameSize
+}
+
+// ErrHeaderCountExceeded is returned when a message has too many header entries.
+type ErrHeaderCountExceeded struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrHeaderCountExceeded) Error() string {
+	return fmt.Sprintf("headers count exceeds %d", e.Max)
+}
+
+// ErrHeaderKeyTooLong is returned when a header key is too long.
+type ErrHeaderKeyTooLong struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrHeaderKeyTooLong) Error() string {
+	return fmt.Sprintf("header key length exceeds %d", e.Max)
+}
+
+// ErrHeaderValueTooLong is returned when a header value is too long.
+type ErrHeaderValueTooLong struct {
+	Max int
+}
+
+// Error implements the error interface.
+func (e ErrHeaderValueTooLong) Error() string {
+	return fmt.Sprintf("header value length exceeds %d", e.Max)
+}
+
+// ErrBodyTooBig is returned when the body of a message is too big.
+type ErrBodyTooBig struct {
+	Size uint64
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrBodyTooBig) Error() string {
+	return fmt.Sprintf("Content-Length exceeds %d (it's %d)", e.Max, e.Size)
+}
+
+// ErrInterleavedFrameTooBig is returned when the payload of an interleaved frame is too big.
+type ErrInterleavedFrameTooBig struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrInterleavedFrameTooBig) Error() string {
+	return fmt.Sprintf("interleaved frame size exceeds %d (it's %d)", e.Max, e.Size)
+}
@@ -4,7 +4,9 @@ package base
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -48,6 +50,13 @@ type Request struct {
 
 // Unmarshal reads a request.
 func (req *Request) Unmarshal(br *bufio.Reader) error {
+	return req.UnmarshalWithLimits(br, nil, ConformanceDefault)
+}
+
+// UnmarshalWithLimits reads a Request, enforcing the given limits and conformance level.
+// If limits is nil, default limits are used.
+// The buffer of br must be at least limits.ReaderSize() bytes long.
+func (req *Request) UnmarshalWithLimits(br *bufio.Reader, limits *Limits, conformance Conformance) error {
 	byts, err := readBytesLimited(br, ' ', requestMaxMethodLength)
 	if err != nil {
 		return err
@@ -58,11 +67,37 @@ func (req *Request) Unmarshal(br *bufio.Reader) error {
 		return fmt.Errorf("empty method")
 	}
 
-	byts, err = readBytesLimited(br, ' ', requestMaxURLLength)
-	if err != nil {
-		return err
+	var rawURL string
+	var proto string
+
+	if conformance == ConformanceLenient {
+		// URL may contain spaces, therefore protocol is the last token of the line
+		byts, err = readBytesLimited(br, '\r', requestMaxURLLength+requestMaxProtocolLength)
+		if err != nil {
+			return err
+		}
+		line := string(byts[:len(byts)-1])
+
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			return fmt.Errorf("protocol is missing")
+		}
+
+		rawURL = strings.ReplaceAll(line[:i], " ", "%20")
+		proto = line[i+1:]
+	} else {
+		byts, err = readBytesLimited(br, ' ', requestMaxURLLength)
+		if err != nil {
+			return err
+		}
+		rawURL = string(byts[:len(byts)-1])
+
+		byts, err = readBytesLimited(br, '\r', requestMaxProtocolLength)
+		if err != nil {
+			return err
+		}
+		proto = string(byts[:len(byts)-1])
 	}
-	rawURL := string(byts[:len(byts)-1])
 
 	if rawURL != "*" {
 		var ur *URL
@@ -75,14 +110,8 @@ func (req *Request) Unmarshal(br *bufio.Reader) error {
 		req.URL = nil
 	}
 
-	byts, err = readBytesLimited(br, '\r', requestMaxProtocolLength)
-	if err != nil {
-		return err
-	}
-	proto := byts[:len(byts)-1]
-
-	if string(proto) != rtspProtocol10 {
-		return fmt.Errorf("expected '%s', got %v", rtspProtocol10, proto)
+	if !conformance.isProtocolValid(proto) {
+		return fmt.Errorf("expected '%s', got %v", rtspProtocol10, []byte(proto))
 	}
 
 	err = readByteEqual(br, '\n')
@@ -90,12 +119,12 @@ func (req *Request) Unmarshal(br *bufio.Reader) error {
 		return err
 	}
 
-	err = req.Header.unmarshal(br)
+	err = req.Header.unmarshalWithLimits(br, limits, conformance)
 	if err != nil {
 		return err
 	}
 
-	err = (*body)(&req.Body).unmarshal(req.Header, br)
+	err = (*body)(&req.Body).unmarshalWithLimits(req.Header, br, limits, conformance)
 	if err != nil {
 		return err
 	}
@@ -166,6 +195,25 @@ func (req Request) Marshal() ([]byte, error) {
 	return buf, err
 }
 
+// WriteTo writes a Request into a io.Writer.
+// The body is written directly, without copying it into an intermediate buffer.
+// Head and body may be written with separate calls to w.Write().
+func (req Request) WriteTo(w io.Writer) (int64, error) {
+	b := req.Body
+
+	if len(b) != 0 {
+		req.Header["Content-Length"] = HeaderValue{strconv.FormatInt(int64(len(b)), 10)}
+		req.Body = nil
+	}
+
+	head, err := req.Marshal()
+	if err != nil {
+		return 0, err
+	}
+
+	return writeHeadAndBody(w, head, b)
+}
+
 // String implements fmt.Stringer.
 func (req Request) String() string {
 	buf, _ := req.Marshal()
@@ -168,6 +168,18 @@ func TestRequestMarshal(t *testing.T) {
 	}
 }
 
+func TestRequestWriteTo(t *testing.T) {
+	for _, ca := range casesRequest {
+		t.Run(ca.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := ca.req.WriteTo(&buf)
+			require.NoError(t, err)
+			require.Equal(t, int64(len(ca.byts)), n)
+			require.Equal(t, ca.byts, buf.Bytes())
+		})
+	}
+}
+
 func TestRequestString(t *testing.T) {
 	byts := []byte("OPTIONS rtsp://example.com/media.mp4 RTSP/1.0\r\n" +
 		"CSeq: 1\r\n" +
@@ -3,6 +3,7 @@ package base
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"strconv"
 )
 
@@ -134,13 +135,20 @@ type Response struct {
 
 // Unmarshal reads a response.
 func (res *Response) Unmarshal(br *bufio.Reader) error {
+	return res.UnmarshalWithLimits(br, nil, ConformanceDefault)
+}
+
+// UnmarshalWithLimits reads a Response, enforcing the given limits and conformance level.
+// If limits is nil, default limits are used.
+// The buffer of br must be at least limits.ReaderSize() bytes long.
+func (res *Response) UnmarshalWithLimits(br *bufio.Reader, limits *Limits, conformance Conformance) error {
 	byts, err := readBytesLimited(br, ' ', 255)
 	if err != nil {
 		return err
 	}
 	proto := byts[:len(byts)-1]
 
-	if string(proto) != rtspProtocol10 {
+	if !conformance.isProtocolValid(string(proto)) {
 		return fmt.Errorf("expected '%s', got %v", rtspProtocol10, proto)
 	}
 
@@ -167,12 +175,12 @@ func (res *Response) Unmarshal(br *bufio.Reader) error {
 		return err
 	}
 
-	err = res.Header.unmarshal(br)
+	err = res.Header.unmarshalWithLimits(br, limits, conformance)
 	if err != nil {
 		return err
 	}
 
-	err = (*body)(&res.Body).unmarshal(res.Header, br)
+	err = (*body)(&res.Body).unmarshalWithLimits(res.Header, br, limits, conformance)
 	if err != nil {
 		return err
 	}
@@ -243,6 +251,25 @@ func (res Response) Marshal() ([]byte, error) {
 	return buf, err
 }
 
+// WriteTo writes a Response into a io.Writer.
+// The body is written directly, without copying it into an intermediate buffer.
+// Head and body may be written with separate calls to w.Write().
+func (res Response) WriteTo(w io.Writer) (int64, error) {
+	b := res.Body
+
+	if len(b) != 0 {
+		res.Header["Content-Length"] = HeaderValue{strconv.FormatInt(int64(len(b)), 10)}
+		res.Body = nil
+	}
+
+	head, err := res.Marshal()
+	if err != nil {
+		return 0, err
+	}
+
+	return writeHeadAndBody(w, head, b)
+}
+
 // String implements fmt.Stringer.
 func (res Response) String() string {
 	buf, _ := res.Marshal()
@@ -156,6 +156,18 @@ func TestResponseMarshal(t *testing.T) {
 	}
 }
 
+func TestResponseWriteTo(t *testing.T) {
+	for _, ca := range casesResponse {
+		t.Run(ca.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := ca.res.WriteTo(&buf)
+			require.NoError(t, err)
+			require.Equal(t, int64(len(ca.out)), n)
+			require.Equal(t, ca.out, buf.Bytes())
+		})
+	}
+}
+
 func TestResponseMarshalAutoFillStatus(t *testing.T) {
 	res := &Response{
 		StatusCode: StatusMethodNotAllowed,
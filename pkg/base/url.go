@@ -14,8 +14,74 @@ type URL url.URL
 
 var escapeRegexp = regexp.MustCompile(`^(.+?)://(.*?)@(.*?)/(.*?)$`)
 
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// unescapeLenient decodes percent-encoded sequences,
+// keeping invalid sequences as they are.
+func unescapeLenient(s string) string {
+	var buf strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			buf.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+		} else {
+			buf.WriteByte(s[i])
+		}
+	}
+
+	return buf.String()
+}
+
+// escapeUserinfo re-encodes credentials, in order to support
+// unescaped special characters (like @, % and unicode characters)
+// that are often found in URLs provided by users.
+func escapeUserinfo(s string) string {
+	i := strings.Index(s, "://")
+	if i < 0 {
+		return s
+	}
+
+	rest := s[i+3:]
+
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+
+	at := strings.LastIndex(rest[:end], "@")
+	if at < 0 {
+		return s
+	}
+
+	var user *url.Userinfo
+	if username, password, ok := strings.Cut(rest[:at], ":"); ok {
+		user = url.UserPassword(unescapeLenient(username), unescapeLenient(password))
+	} else {
+		user = url.User(unescapeLenient(username))
+	}
+
+	return s[:i+3] + user.String() + rest[at:]
+}
+
 // ParseURL parses a RTSP URL.
+// Credentials can contain special characters, that are decoded leniently.
 func ParseURL(s string) (*URL, error) {
+	s = escapeUserinfo(s)
+
 	// https://github.com/golang/go/issues/30611
 	m := escapeRegexp.FindStringSubmatch(s)
 	if m != nil {
@@ -49,6 +115,12 @@ func (u *URL) String() string {
 	return (*url.URL)(u).String()
 }
 
+// Redacted returns the URL with the password replaced by "xxxxx".
+// It is meant to be used in logs.
+func (u *URL) Redacted() string {
+	return (*url.URL)(u).Redacted()
+}
+
 // Clone clones a URL.
 func (u *URL) Clone() *URL {
 	return (*URL)(&url.URL{
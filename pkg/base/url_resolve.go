@@ -0,0 +1,206 @@
+package base
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func isAbsoluteURL(s string) bool {
+	return strings.HasPrefix(s, "rtsp://") ||
+		strings.HasPrefix(s, "rtsps://")
+}
+
+// resolveRelative resolves a relative reference (like "/path" or "path/")
+// against a URL, as described in RFC 3986.
+func resolveRelative(u *URL, ref string) (*URL, error) {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := (*url.URL)(u.Clone()).ResolveReference(refURL)
+	if ret.Fragment != "" {
+		return nil, fmt.Errorf("URLs with fragments are not supported")
+	}
+
+	return (*URL)(ret), nil
+}
+
+func parseBaseHeader(name string, v HeaderValue, requestURL *URL) (*URL, error) {
+	if len(v) != 1 {
+		return nil, fmt.Errorf("invalid %s: '%v'", name, v)
+	}
+
+	str := strings.TrimSpace(v[0])
+
+	var ret *URL
+	var err error
+
+	if isAbsoluteURL(str) {
+		ret, err = ParseURL(str)
+	} else {
+		ret, err = resolveRelative(requestURL, str)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: '%v'", name, v)
+	}
+
+	// add credentials
+	ret.User = requestURL.User
+
+	return ret, nil
+}
+
+// ResolveBaseURL returns the base URL of a stream, that is used to resolve
+// control attributes of medias.
+// It uses, in order of priority, the session-level control attribute,
+// the Content-Base header, the Content-Location header and the request URL.
+// Relative values are resolved against the request URL.
+// Credentials are always copied from the request URL.
+// Specification: RFC2326, appendix C.1.1
+func ResolveBaseURL(requestURL *URL, sessionControl string, res *Response) (*URL, error) {
+	sessionControl = strings.TrimSpace(sessionControl)
+
+	if sessionControl != "" && sessionControl != "*" {
+		if !isAbsoluteURL(sessionControl) {
+			// a relative control attribute is resolved against
+			// the base URL provided by the headers.
+			baseURL, err := ResolveBaseURL(requestURL, "", res)
+			if err != nil {
+				return nil, err
+			}
+
+			return ResolveControl(baseURL, sessionControl)
+		}
+
+		ret, err := ParseURL(sessionControl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid control attribute: '%v'", sessionControl)
+		}
+
+		// add credentials
+		ret.User = requestURL.User
+
+		return ret, nil
+	}
+
+	if res != nil {
+		if v, ok := res.Header["Content-Base"]; ok {
+			return parseBaseHeader("Content-Base", v, requestURL)
+		}
+
+		if v, ok := res.Header["Content-Location"]; ok {
+			return parseBaseHeader("Content-Location", v, requestURL)
+		}
+	}
+
+	return requestURL, nil
+}
+
+// ControlQuery is the way the query of the base URL is handled
+// when relative control attributes are resolved.
+type ControlQuery int
+
+// control query modes.
+const (
+	// the control attribute is appended after the query,
+	// for instance rtsp://host/path?query/trackID=0.
+	ControlQueryAppend ControlQuery = iota
+
+	// the control attribute is inserted before the query,
+	// for instance rtsp://host/path/trackID=0?query.
+	ControlQueryPreserve
+
+	// the query is removed,
+	// for instance rtsp://host/path/trackID=0.
+	ControlQueryDrop
+)
+
+// ResolveControlWithQuery is like ResolveControl,
+// but allows to choose how the query of the base URL is handled.
+func ResolveControlWithQuery(baseURL *URL, control string, mode ControlQuery) (*URL, error) {
+	if mode == ControlQueryAppend || baseURL == nil || baseURL.RawQuery == "" {
+		return ResolveControl(baseURL, control)
+	}
+
+	control = strings.TrimSpace(control)
+
+	if control == "" || control == "*" || isAbsoluteURL(control) {
+		return ResolveControl(baseURL, control)
+	}
+
+	withoutQuery := baseURL.Clone()
+	withoutQuery.RawQuery = ""
+	withoutQuery.ForceQuery = false
+
+	ur, err := ResolveControl(withoutQuery, control)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == ControlQueryPreserve {
+		if ur.RawQuery != "" {
+			ur.RawQuery = baseURL.RawQuery + "&" + ur.RawQuery
+		} else {
+			ur.RawQuery = baseURL.RawQuery
+		}
+	}
+
+	return ur, nil
+}
+
+// ResolveControl resolves the control attribute of a media against a base URL.
+//
+// Absolute control attributes keep the host and credentials of the base URL,
+// since servers often return addresses that are not reachable by clients.
+// Relative control attributes are appended to the base URL, including its query,
+// since this is what most servers expect.
+func ResolveControl(baseURL *URL, control string) (*URL, error) {
+	if baseURL == nil {
+		return nil, fmt.Errorf("Content-Base header not provided")
+	}
+
+	control = strings.TrimSpace(control)
+
+	// no control attribute, use base URL
+	if control == "" || control == "*" {
+		return baseURL, nil
+	}
+
+	// control attribute contains an absolute URL
+	if isAbsoluteURL(control) {
+		ur, err := ParseURL(control)
+		if err != nil {
+			return nil, err
+		}
+
+		// copy host and credentials
+		ur.Host = baseURL.Host
+		ur.User = baseURL.User
+		return ur, nil
+	}
+
+	// control attribute contains a relative control attribute
+	// insert the control attribute at the end of the URL
+	// if there's a query, insert it after the query
+	// otherwise insert it after the path
+	strURL := baseURL.String()
+
+	switch {
+	case control[0] == '?':
+
+	case strings.HasSuffix(strURL, "/"):
+		control = strings.TrimLeft(control, "/")
+
+	case control[0] != '/':
+		strURL += "/"
+	}
+
+	ur, err := ParseURL(strURL + control)
+	if err != nil {
+		return nil, fmt.Errorf("invalid control attribute: '%v'", control)
+	}
+
+	return ur, nil
+}
@@ -3,8 +3,30 @@ package base
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"net"
 )
 
+type errBufferLengthExceeded struct {
+	n int
+}
+
+func (e errBufferLengthExceeded) Error() string {
+	return fmt.Sprintf("buffer length exceeds %d", e.n)
+}
+
+// writeHeadAndBody writes a head and a body without merging them into a single buffer.
+// When w is a net.Conn, they are written with a single system call.
+func writeHeadAndBody(w io.Writer, head []byte, body []byte) (int64, error) {
+	if len(body) == 0 {
+		n, err := w.Write(head)
+		return int64(n), err
+	}
+
+	bufs := net.Buffers{head, body}
+	return bufs.WriteTo(w)
+}
+
 func readByteEqual(rb *bufio.Reader, cmp byte) error {
 	byt, err := rb.ReadByte()
 	if err != nil {
@@ -30,5 +52,5 @@ func readBytesLimited(rb *bufio.Reader, delim byte, n int) ([]byte, error) {
 			return byts, nil
 		}
 	}
-	return nil, fmt.Errorf("buffer length exceeds %d", n)
+	return nil, errBufferLengthExceeded{n: n}
 }
@@ -0,0 +1,90 @@
+package bits
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWrite(t *testing.T) {
+	var w Writer
+	w.WriteBits(0b101, 3)
+	w.WriteFlag(true)
+	w.WriteUE(0)
+	w.WriteUE(7)
+	w.WriteSE(-3)
+	w.WriteSE(4)
+	w.WriteUE(0xFFFFFFFE)
+	w.WriteBits(0x123456789ABCDEF0, 64)
+	w.WriteTrailingBits()
+
+	require.True(t, w.ByteAligned())
+
+	r := Reader{Buf: w.Bytes()}
+
+	v, err := r.ReadBits(3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0b101), v)
+
+	f, err := r.ReadFlag()
+	require.NoError(t, err)
+	require.Equal(t, true, f)
+
+	ue, err := r.ReadUE()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), ue)
+
+	ue, err = r.ReadUE()
+	require.NoError(t, err)
+	require.Equal(t, uint32(7), ue)
+
+	se, err := r.ReadSE()
+	require.NoError(t, err)
+	require.Equal(t, int32(-3), se)
+
+	se, err = r.ReadSE()
+	require.NoError(t, err)
+	require.Equal(t, int32(4), se)
+
+	ue, err = r.ReadUE()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0xFFFFFFFE), ue)
+
+	v, err = r.ReadBits(64)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x123456789ABCDEF0), v)
+
+	require.False(t, r.MoreRBSPData())
+
+	f, err = r.ReadFlag()
+	require.NoError(t, err)
+	require.Equal(t, true, f)
+
+	require.True(t, r.ByteAligned())
+	require.Equal(t, 0, r.Remaining())
+}
+
+func TestWriterBytes(t *testing.T) {
+	var w Writer
+	w.WriteUE(3)
+	w.WriteSE(1)
+	w.WriteTrailingBits()
+	require.Equal(t, []byte{0b00100010, 0b10000000}, w.Bytes())
+}
+
+func TestReaderErrors(t *testing.T) {
+	r := Reader{Buf: []byte{0x00}}
+	_, err := r.ReadUE()
+	require.EqualError(t, err, "not enough bits")
+
+	r = Reader{Buf: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x80}}
+	_, err = r.ReadUE()
+	require.EqualError(t, err, "invalid Exp-Golomb code")
+
+	r = Reader{Buf: []byte{0xFF}}
+	_, err = r.ReadBits(9)
+	require.EqualError(t, err, "not enough bits")
+
+	err = r.SkipBits(9)
+	require.EqualError(t, err, "not enough bits")
+}
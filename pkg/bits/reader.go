@@ -0,0 +1,138 @@
+// Package bits contains a reader and a writer of bitstreams,
+// that support Exp-Golomb codes used by H264 and H265 NAL units.
+package bits
+
+import (
+	"fmt"
+)
+
+// maximum number of leading zeros of an Exp-Golomb code that fits into an uint32.
+const maxGolombLeadingZeros = 32
+
+// Reader reads bits from a buffer.
+// In case of NAL units, emulation prevention bytes must be removed from the buffer in advance.
+type Reader struct {
+	// buffer.
+	Buf []byte
+
+	pos int
+}
+
+// Pos returns the position of the next bit to read.
+func (r *Reader) Pos() int {
+	return r.pos
+}
+
+// Remaining returns the number of bits that are still to be read.
+func (r *Reader) Remaining() int {
+	return len(r.Buf)*8 - r.pos
+}
+
+// ByteAligned returns whether the position is aligned to a byte boundary.
+func (r *Reader) ByteAligned() bool {
+	return (r.pos & 0x07) == 0
+}
+
+// SkipBits skips N bits.
+func (r *Reader) SkipBits(n int) error {
+	if n > r.Remaining() {
+		return fmt.Errorf("not enough bits")
+	}
+
+	r.pos += n
+	return nil
+}
+
+// ReadBits reads N bits, with N lower or equal than 64.
+func (r *Reader) ReadBits(n int) (uint64, error) {
+	if n > 64 {
+		return 0, fmt.Errorf("can't read more than 64 bits at once")
+	}
+
+	if n > r.Remaining() {
+		return 0, fmt.Errorf("not enough bits")
+	}
+
+	var v uint64
+
+	for n > 0 {
+		res := 8 - (r.pos & 0x07)
+		take := min(res, n)
+		b := (r.Buf[r.pos>>3] >> (res - take)) & (1<<take - 1)
+		v = (v << take) | uint64(b)
+		r.pos += take
+		n -= take
+	}
+
+	return v, nil
+}
+
+// ReadFlag reads a boolean flag.
+func (r *Reader) ReadFlag() (bool, error) {
+	v, err := r.ReadBits(1)
+	return v == 1, err
+}
+
+// ReadUE reads an unsigned Exp-Golomb code.
+func (r *Reader) ReadUE() (uint32, error) {
+	leadingZeros := 0
+
+	for {
+		b, err := r.ReadBits(1)
+		if err != nil {
+			return 0, err
+		}
+
+		if b != 0 {
+			break
+		}
+
+		leadingZeros++
+		if leadingZeros > maxGolombLeadingZeros {
+			return 0, fmt.Errorf("invalid Exp-Golomb code")
+		}
+	}
+
+	v, err := r.ReadBits(leadingZeros)
+	if err != nil {
+		return 0, err
+	}
+
+	v = (1 << leadingZeros) - 1 + v
+	if v > 0xFFFFFFFF {
+		return 0, fmt.Errorf("invalid Exp-Golomb code")
+	}
+
+	return uint32(v), nil
+}
+
+// ReadSE reads a signed Exp-Golomb code.
+func (r *Reader) ReadSE() (int32, error) {
+	v, err := r.ReadUE()
+	if err != nil {
+		return 0, err
+	}
+
+	if (v & 0x01) != 0 {
+		return int32((int64(v) + 1) / 2), nil
+	}
+	return int32(-(int64(v) / 2)), nil
+}
+
+// MoreRBSPData returns whether there's more data before the RBSP trailing bits.
+// Specification: ITU-T Rec. H.264, 7.2
+func (r *Reader) MoreRBSPData() bool {
+	// find the position of the rbsp_stop_one_bit, that is the last bit equal to one.
+	for i := len(r.Buf) - 1; i >= 0; i-- {
+		if b := r.Buf[i]; b != 0 {
+			stopPos := i*8 + 7
+			for (b & 0x01) == 0 {
+				b >>= 1
+				stopPos--
+			}
+			return r.pos < stopPos
+		}
+	}
+
+	return false
+}
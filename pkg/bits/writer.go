@@ -0,0 +1,82 @@
+package bits
+
+// Writer writes bits into a buffer.
+// In case of NAL units, emulation prevention bytes must be added to the buffer afterwards.
+type Writer struct {
+	buf []byte
+	pos int
+}
+
+// Pos returns the number of written bits.
+func (w *Writer) Pos() int {
+	return w.pos
+}
+
+// ByteAligned returns whether the position is aligned to a byte boundary.
+func (w *Writer) ByteAligned() bool {
+	return (w.pos & 0x07) == 0
+}
+
+// WriteBits writes the N least significant bits of v, with N lower or equal than 64.
+func (w *Writer) WriteBits(v uint64, n int) {
+	for n > 0 {
+		if (w.pos & 0x07) == 0 {
+			w.buf = append(w.buf, 0)
+		}
+
+		res := 8 - (w.pos & 0x07)
+		take := min(res, n)
+		b := byte((v >> (n - take)) & (1<<take - 1))
+		w.buf[w.pos>>3] |= b << (res - take)
+		w.pos += take
+		n -= take
+	}
+}
+
+// WriteFlag writes a boolean flag.
+func (w *Writer) WriteFlag(v bool) {
+	if v {
+		w.WriteBits(1, 1)
+	} else {
+		w.WriteBits(0, 1)
+	}
+}
+
+// WriteUE writes an unsigned Exp-Golomb code.
+func (w *Writer) WriteUE(v uint32) {
+	v2 := uint64(v) + 1
+
+	leadingZeros := 0
+	for tmp := v2; tmp > 1; tmp >>= 1 {
+		leadingZeros++
+	}
+
+	w.WriteBits(0, leadingZeros)
+	w.WriteBits(v2, leadingZeros+1)
+}
+
+// WriteSE writes a signed Exp-Golomb code.
+func (w *Writer) WriteSE(v int32) {
+	if v > 0 {
+		w.WriteUE(uint32(int64(v)*2 - 1))
+	} else {
+		w.WriteUE(uint32(-int64(v) * 2))
+	}
+}
+
+// WriteTrailingBits writes the RBSP trailing bits,
+// that are a bit equal to one, followed by bits equal to zero until the next byte boundary.
+// Specification: ITU-T Rec. H.264, 7.3.2.11
+func (w *Writer) WriteTrailingBits() {
+	w.WriteBits(1, 1)
+
+	if !w.ByteAligned() {
+		w.WriteBits(0, 8-(w.pos&0x07))
+	}
+}
+
+// Bytes returns written bytes.
+// Bits of the last byte that have not been written are equal to zero.
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
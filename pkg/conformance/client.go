@@ -0,0 +1,401 @@
+package conformance
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+)
+
+const clientCheckerSessionID = "12345678"
+
+// clientTranscript contains what has been received from the client under test.
+type clientTranscript struct {
+	requests  []*base.Request
+	responses []*base.Response
+	err       error
+}
+
+func (tr *clientTranscript) requestsWithMethod(method base.Method) []*base.Request {
+	var ret []*base.Request
+	for _, req := range tr.requests {
+		if req.Method == method {
+			ret = append(ret, req)
+		}
+	}
+	return ret
+}
+
+// firstSuccessfulIndex returns the index of the first request
+// with the given method that has been accepted.
+func (tr *clientTranscript) firstSuccessfulIndex(method base.Method) int {
+	for i, req := range tr.requests {
+		if req.Method == method && tr.responses[i].StatusCode == base.StatusOK {
+			return i
+		}
+	}
+	return -1
+}
+
+func (tr *clientTranscript) firstIndex(method base.Method) int {
+	for i, req := range tr.requests {
+		if req.Method == method {
+			return i
+		}
+	}
+	return -1
+}
+
+var clientChecks = []check[*clientTranscript]{
+	{
+		"cseq",
+		"requests contain a valid CSeq",
+		func(tr *clientTranscript) error {
+			if len(tr.requests) == 0 {
+				return errSkipped{fmt.Errorf("no requests received")}
+			}
+
+			for _, req := range tr.requests {
+				v, ok := req.Header["CSeq"]
+				if !ok || len(v) != 1 {
+					return fmt.Errorf("%v request without CSeq", req.Method)
+				}
+
+				_, err := strconv.ParseUint(v[0], 10, 31)
+				if err != nil {
+					return fmt.Errorf("%v request with invalid CSeq: %v", req.Method, v[0])
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		"cseq-increasing",
+		"CSeq is increased with each request",
+		func(tr *clientTranscript) error {
+			if len(tr.requests) < 2 {
+				return errSkipped{fmt.Errorf("not enough requests received")}
+			}
+
+			prev := int64(-1)
+
+			for _, req := range tr.requests {
+				v, ok := req.Header["CSeq"]
+				if !ok || len(v) != 1 {
+					return errSkipped{fmt.Errorf("%v request without CSeq", req.Method)}
+				}
+
+				cur, err := strconv.ParseInt(v[0], 10, 64)
+				if err != nil {
+					return errSkipped{err}
+				}
+
+				if cur <= prev {
+					return fmt.Errorf("CSeq of %v request (%d) is not greater than the previous one (%d)",
+						req.Method, cur, prev)
+				}
+				prev = cur
+			}
+
+			return nil
+		},
+	},
+	{
+		"user-agent",
+		"requests contain a User-Agent header",
+		func(tr *clientTranscript) error {
+			if len(tr.requests) == 0 {
+				return errSkipped{fmt.Errorf("no requests received")}
+			}
+
+			for _, req := range tr.requests {
+				if _, ok := req.Header["User-Agent"]; !ok {
+					return fmt.Errorf("%v request without User-Agent", req.Method)
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		"describe-accept",
+		"DESCRIBE requests accept application/sdp",
+		func(tr *clientTranscript) error {
+			reqs := tr.requestsWithMethod(base.Describe)
+			if len(reqs) == 0 {
+				return errSkipped{fmt.Errorf("no DESCRIBE requests received")}
+			}
+
+			for _, req := range reqs {
+				v, ok := req.Header["Accept"]
+				if !ok || len(v) != 1 || !strings.Contains(v[0], "application/sdp") {
+					return fmt.Errorf("DESCRIBE request doesn't accept application/sdp: %v", v)
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		"setup-transport",
+		"SETUP requests contain a valid Transport header",
+		func(tr *clientTranscript) error {
+			reqs := tr.requestsWithMethod(base.Setup)
+			if len(reqs) == 0 {
+				return errSkipped{fmt.Errorf("no SETUP requests received")}
+			}
+
+			for _, req := range reqs {
+				var ths headers.Transports
+				err := ths.Unmarshal(req.Header["Transport"])
+				if err != nil {
+					return fmt.Errorf("invalid Transport header: %w", err)
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		"session",
+		"requests sent after SETUP contain the session ID",
+		func(tr *clientTranscript) error {
+			i := tr.firstSuccessfulIndex(base.Setup)
+			if i < 0 {
+				return errSkipped{fmt.Errorf("no SETUP requests accepted")}
+			}
+
+			for _, req := range tr.requests[i+1:] {
+				switch req.Method {
+				case base.Setup, base.Play, base.Pause, base.Teardown:
+					var sx headers.Session
+					err := sx.Unmarshal(req.Header["Session"])
+					if err != nil {
+						return fmt.Errorf("%v request without a valid Session header", req.Method)
+					}
+
+					if sx.Session != clientCheckerSessionID {
+						return fmt.Errorf("%v request with wrong session ID: %v", req.Method, sx.Session)
+					}
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		"play-after-setup",
+		"PLAY is sent after SETUP",
+		func(tr *clientTranscript) error {
+			i := tr.firstIndex(base.Play)
+			if i < 0 {
+				return errSkipped{fmt.Errorf("no PLAY requests received")}
+			}
+
+			j := tr.firstSuccessfulIndex(base.Setup)
+			if j < 0 || j > i {
+				return fmt.Errorf("PLAY request sent before SETUP")
+			}
+
+			return nil
+		},
+	},
+	{
+		"teardown",
+		"TEARDOWN is sent before closing the connection",
+		func(tr *clientTranscript) error {
+			if tr.firstSuccessfulIndex(base.Setup) < 0 {
+				return errSkipped{fmt.Errorf("no SETUP requests accepted")}
+			}
+
+			if tr.firstIndex(base.Teardown) < 0 {
+				return fmt.Errorf("connection closed without TEARDOWN (%w)", tr.err)
+			}
+
+			return nil
+		},
+	},
+}
+
+// ClientChecker checks whether a RTSP client complies with the specification.
+// It acts as a server, waits for a client and checks the requests it sends.
+// The client is expected to read the stream and to close the session.
+type ClientChecker struct {
+	// address to listen on.
+	Address string
+
+	// description of the stream offered to the client.
+	// It defaults to a single H264 media.
+	Description *description.Session
+
+	// timeout of the whole session.
+	// It defaults to 10 seconds.
+	Timeout time.Duration
+
+	ln  net.Listener
+	sdp []byte
+}
+
+// Initialize initializes ClientChecker.
+// After it returns, the client can connect.
+func (cc *ClientChecker) Initialize() error {
+	if cc.Description == nil {
+		cc.Description = &description.Session{
+			Medias: []*description.Media{{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					PacketizationMode: 1,
+				}},
+			}},
+		}
+	}
+
+	if cc.Timeout == 0 {
+		cc.Timeout = 10 * time.Second
+	}
+
+	desc := cc.Description.Clone()
+	for i, medi := range desc.Medias {
+		medi.Control = "trackID=" + strconv.FormatInt(int64(i), 10)
+	}
+
+	var err error
+	cc.sdp, err = desc.Marshal()
+	if err != nil {
+		return err
+	}
+
+	cc.ln, err = net.Listen("tcp", cc.Address)
+	return err
+}
+
+// Close closes ClientChecker.
+func (cc *ClientChecker) Close() {
+	cc.ln.Close()
+}
+
+// Run waits for a client, serves it and returns a report.
+func (cc *ClientChecker) Run() (*Report, error) {
+	deadline := time.Now().Add(cc.Timeout)
+	cc.ln.(*net.TCPListener).SetDeadline(deadline) //nolint:errcheck
+
+	nconn, err := cc.ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	defer nconn.Close()
+
+	nconn.SetDeadline(deadline) //nolint:errcheck
+
+	tr := &clientTranscript{}
+	tr.err = cc.serve(conn.NewConn(bufio.NewReader(nconn), nconn), tr)
+
+	return runChecks(nconn.RemoteAddr().String(), tr, clientChecks), nil
+}
+
+func (cc *ClientChecker) serve(c *conn.Conn, tr *clientTranscript) error {
+	for {
+		what, err := c.Read()
+		if err != nil {
+			return err
+		}
+
+		req, ok := what.(*base.Request)
+		if !ok {
+			continue
+		}
+
+		res := cc.handleRequest(req)
+		res.Header["CSeq"] = req.Header["CSeq"]
+
+		tr.requests = append(tr.requests, req)
+		tr.responses = append(tr.responses, res)
+
+		err = c.WriteResponse(res)
+		if err != nil {
+			return err
+		}
+
+		if req.Method == base.Teardown {
+			return nil
+		}
+	}
+}
+
+func (cc *ClientChecker) handleRequest(req *base.Request) *base.Response {
+	switch req.Method {
+	case base.Options:
+		return &base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Setup),
+					string(base.Play),
+					string(base.GetParameter),
+					string(base.Teardown),
+				}, ", ")},
+			},
+		}
+
+	case base.Describe:
+		return &base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{req.URL.String() + "/"},
+			},
+			Body: cc.sdp,
+		}
+
+	case base.Setup:
+		var ths headers.Transports
+		err := ths.Unmarshal(req.Header["Transport"])
+		if err != nil {
+			return &base.Response{StatusCode: base.StatusBadRequest, Header: base.Header{}}
+		}
+
+		// only the TCP transport protocol is offered.
+		for _, th := range ths {
+			if th.Protocol == headers.TransportProtocolTCP && th.InterleavedIDs != nil {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+					Header: base.Header{
+						"Transport": headers.Transport{
+							Protocol:       headers.TransportProtocolTCP,
+							Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+							InterleavedIDs: th.InterleavedIDs,
+						}.Marshal(),
+						"Session": headers.Session{
+							Session: clientCheckerSessionID,
+						}.Marshal(),
+					},
+				}
+			}
+		}
+
+		return &base.Response{StatusCode: base.StatusUnsupportedTransport, Header: base.Header{}}
+
+	case base.Play, base.GetParameter, base.Teardown:
+		return &base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Session": headers.Session{
+					Session: clientCheckerSessionID,
+				}.Marshal(),
+			},
+		}
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented, Header: base.Header{}}
+}
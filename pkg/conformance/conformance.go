@@ -0,0 +1,115 @@
+// Package conformance contains utilities to check whether RTSP servers and clients
+// comply with the specification.
+package conformance
+
+import (
+	"time"
+)
+
+// Status is the status of a check.
+type Status string
+
+// statuses.
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Result is the result of a check.
+type Result struct {
+	// name of the check.
+	Name string `json:"name"`
+
+	// description of the check.
+	Description string `json:"description"`
+
+	// status of the check.
+	Status Status `json:"status"`
+
+	// error that caused the check to fail or to be skipped.
+	Error string `json:"error,omitempty"`
+
+	// duration of the check.
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is a list of check results.
+// It can be encoded in JSON.
+type Report struct {
+	// address of the server or client under test.
+	Target string `json:"target"`
+
+	// results.
+	Results []Result `json:"results"`
+}
+
+// Passed returns whether no check failed.
+func (r *Report) Passed() bool {
+	for _, res := range r.Results {
+		if res.Status == StatusFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Result returns the result of a check.
+func (r *Report) Result(name string) (Result, bool) {
+	for _, res := range r.Results {
+		if res.Name == name {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// errSkipped is returned by checks whose preconditions are not met.
+type errSkipped struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e errSkipped) Error() string {
+	return e.err.Error()
+}
+
+type check[T any] struct {
+	name        string
+	description string
+	run         func(T) error
+}
+
+func runChecks[T any](target string, env T, checks []check[T]) *Report {
+	report := &Report{
+		Target: target,
+	}
+
+	for _, ch := range checks {
+		start := time.Now()
+		err := ch.run(env)
+
+		res := Result{
+			Name:        ch.name,
+			Description: ch.description,
+			Duration:    time.Since(start),
+		}
+
+		switch err := err.(type) {
+		case nil:
+			res.Status = StatusPassed
+
+		case errSkipped:
+			res.Status = StatusSkipped
+			res.Error = err.Error()
+
+		default:
+			res.Status = StatusFailed
+			res.Error = err.Error()
+		}
+
+		report.Results = append(report.Results, res)
+	}
+
+	return report
+}
@@ -0,0 +1,169 @@
+package conformance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+type testServerHandler struct {
+	stream *gortsplib.ServerStream
+}
+
+func (sh *testServerHandler) OnDescribe(
+	_ *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.stream, nil
+}
+
+func (sh *testServerHandler) OnSetup(
+	_ *gortsplib.ServerHandlerOnSetupCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.stream, nil
+}
+
+func (sh *testServerHandler) OnPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func TestServerChecker(t *testing.T) {
+	h := &testServerHandler{}
+
+	s := &gortsplib.Server{
+		Handler:     h,
+		RTSPAddress: "localhost:8554",
+	}
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	h.stream = &gortsplib.ServerStream{
+		Server: s,
+		Desc: &description.Session{
+			Medias: []*description.Media{{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					PacketizationMode: 1,
+				}},
+			}},
+		},
+	}
+	err = h.stream.Initialize()
+	require.NoError(t, err)
+	defer h.stream.Close()
+
+	writerTerminate := make(chan struct{})
+	writerDone := make(chan struct{})
+	defer func() { <-writerDone }()
+	defer close(writerTerminate)
+
+	go func() {
+		defer close(writerDone)
+
+		t := time.NewTicker(50 * time.Millisecond)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				h.stream.WritePacketRTP(h.stream.Desc.Medias[0], &rtp.Packet{ //nolint:errcheck
+					Header: rtp.Header{
+						Version:     2,
+						PayloadType: 96,
+					},
+					Payload: []byte{5},
+				})
+
+			case <-writerTerminate:
+				return
+			}
+		}
+	}()
+
+	sc := &ServerChecker{
+		URL: "rtsp://localhost:8554/teststream",
+	}
+	report, err := sc.Run()
+	require.NoError(t, err)
+
+	for _, res := range report.Results {
+		require.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+	}
+	require.True(t, report.Passed())
+
+	_, err = json.Marshal(report)
+	require.NoError(t, err)
+}
+
+func TestServerCheckerUnreachable(t *testing.T) {
+	sc := &ServerChecker{
+		URL:     "rtsp://localhost:8554/teststream",
+		Timeout: 1 * time.Second,
+	}
+	report, err := sc.Run()
+	require.NoError(t, err)
+	require.False(t, report.Passed())
+
+	res, ok := report.Result("options")
+	require.True(t, ok)
+	require.Equal(t, StatusFailed, res.Status)
+}
+
+func TestClientChecker(t *testing.T) {
+	cc := &ClientChecker{
+		Address: "localhost:8554",
+	}
+	err := cc.Initialize()
+	require.NoError(t, err)
+	defer cc.Close()
+
+	clientDone := make(chan struct{})
+	defer func() { <-clientDone }()
+
+	go func() {
+		defer close(clientDone)
+
+		c := gortsplib.Client{}
+
+		u, err2 := base.ParseURL("rtsp://localhost:8554/teststream")
+		require.NoError(t, err2)
+
+		c.Scheme = u.Scheme
+		c.Host = u.Host
+
+		err2 = c.Start()
+		require.NoError(t, err2)
+		defer c.Close()
+
+		desc, _, err2 := c.Describe(u)
+		require.NoError(t, err2)
+
+		err2 = c.SetupAll(desc.BaseURL, desc.Medias)
+		require.NoError(t, err2)
+
+		_, err2 = c.Play(nil)
+		require.NoError(t, err2)
+	}()
+
+	report, err := cc.Run()
+	require.NoError(t, err)
+
+	for _, res := range report.Results {
+		require.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+	}
+}
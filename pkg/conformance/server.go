@@ -0,0 +1,528 @@
+package conformance
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/auth"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+const userAgent = "gortsplib-conformance"
+
+func canonicalAddr(u *base.URL) string {
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "rtsps" {
+			port = "322"
+		} else {
+			port = "554"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// serverConn is a connection to the server under test.
+type serverConn struct {
+	sc *ServerChecker
+
+	nconn  net.Conn
+	conn   *conn.Conn
+	cseq   int
+	sender *auth.Sender
+}
+
+func (c *serverConn) initialize() error {
+	var err error
+	c.nconn, err = net.DialTimeout("tcp", canonicalAddr(c.sc.url), c.sc.Timeout)
+	if err != nil {
+		return err
+	}
+
+	if c.sc.url.Scheme == "rtsps" {
+		c.nconn = tls.Client(c.nconn, c.sc.TLSConfig)
+	}
+
+	c.nconn.SetDeadline(time.Now().Add(c.sc.Timeout)) //nolint:errcheck
+	c.conn = conn.NewConn(bufio.NewReader(c.nconn), c.nconn)
+
+	return nil
+}
+
+func (c *serverConn) close() {
+	c.nconn.Close()
+}
+
+// readResponse reads a response, skipping interleaved frames.
+func (c *serverConn) readResponse() (*base.Response, error) {
+	for {
+		what, err := c.conn.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		switch what := what.(type) {
+		case *base.Response:
+			return what, nil
+
+		case *base.Request:
+			return nil, fmt.Errorf("unexpected request from server: %v", what.Method)
+		}
+	}
+}
+
+// do sends a request and reads the response.
+// CSeq, User-Agent and credentials are added automatically.
+func (c *serverConn) do(req *base.Request) (*base.Response, error) {
+	if req.Header == nil {
+		req.Header = make(base.Header)
+	}
+
+	if _, ok := req.Header["CSeq"]; !ok {
+		c.cseq++
+		req.Header["CSeq"] = base.HeaderValue{strconv.FormatInt(int64(c.cseq), 10)}
+	}
+
+	req.Header["User-Agent"] = base.HeaderValue{userAgent}
+
+	if c.sender != nil {
+		c.sender.AddAuthorization(req)
+	}
+
+	err := c.conn.WriteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.readResponse()
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Equal(res.Header["CSeq"], req.Header["CSeq"]) {
+		return nil, fmt.Errorf("CSeq of response (%v) doesn't match the one of the request (%v)",
+			res.Header["CSeq"], req.Header["CSeq"])
+	}
+
+	if res.StatusCode == base.StatusUnauthorized && c.sender == nil && c.sc.url.User != nil {
+		pass, _ := c.sc.url.User.Password()
+
+		c.sender = &auth.Sender{
+			WWWAuth: res.Header["WWW-Authenticate"],
+			User:    c.sc.url.User.Username(),
+			Pass:    pass,
+		}
+		err = c.sender.Initialize()
+		if err != nil {
+			return nil, err
+		}
+
+		delete(req.Header, "CSeq")
+		return c.do(req)
+	}
+
+	return res, nil
+}
+
+func (c *serverConn) describe() (*description.Session, error) {
+	res, err := c.do(&base.Request{
+		Method: base.Describe,
+		URL:    c.sc.url,
+		Header: base.Header{
+			"Accept": base.HeaderValue{"application/sdp"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, fmt.Errorf("bad status code: %v", res.StatusCode)
+	}
+
+	ct, ok := res.Header["Content-Type"]
+	if !ok || len(ct) != 1 || strings.Split(ct[0], ";")[0] != "application/sdp" {
+		return nil, fmt.Errorf("Content-Type is not application/sdp: %v", ct)
+	}
+
+	var ssd sdp.SessionDescription
+	err = ssd.Unmarshal(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SDP: %w", err)
+	}
+
+	var desc description.Session
+	err = desc.Unmarshal(&ssd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SDP: %w", err)
+	}
+
+	if len(desc.Medias) == 0 {
+		return nil, fmt.Errorf("SDP doesn't contain any media")
+	}
+
+	control, _ := ssd.Attribute("control")
+
+	desc.BaseURL, err = base.ResolveBaseURL(c.sc.url, control, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &desc, nil
+}
+
+// setupTCP describes the stream and setups its first media with the TCP transport protocol.
+func (c *serverConn) setupTCP() (*headers.Session, error) {
+	desc, err := c.describe()
+	if err != nil {
+		return nil, errSkipped{err}
+	}
+
+	u, err := base.ResolveControl(desc.BaseURL, desc.Medias[0].Control)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.do(&base.Request{
+		Method: base.Setup,
+		URL:    u,
+		Header: base.Header{
+			"Transport": headers.Transport{
+				Protocol:       headers.TransportProtocolTCP,
+				Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+				Mode:           ptrOf(headers.TransportModePlay),
+				InterleavedIDs: &[2]int{0, 1},
+			}.Marshal(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, fmt.Errorf("bad status code: %v", res.StatusCode)
+	}
+
+	var th headers.Transport
+	err = th.Unmarshal(res.Header["Transport"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid Transport header: %w", err)
+	}
+
+	if th.Protocol != headers.TransportProtocolTCP {
+		return nil, fmt.Errorf("server replied with a different transport protocol")
+	}
+
+	if th.InterleavedIDs == nil {
+		return nil, fmt.Errorf("interleaved channels are missing")
+	}
+
+	var sx headers.Session
+	err = sx.Unmarshal(res.Header["Session"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid Session header: %w", err)
+	}
+
+	return &sx, nil
+}
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
+var serverChecks = []check[*ServerChecker]{
+	{
+		"options",
+		"OPTIONS returns 200 and a Public header",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				res, err := c.do(&base.Request{
+					Method: base.Options,
+					URL:    sc.url,
+				})
+				if err != nil {
+					return err
+				}
+
+				if res.StatusCode != base.StatusOK {
+					return fmt.Errorf("bad status code: %v", res.StatusCode)
+				}
+
+				if _, ok := res.Header["Public"]; !ok {
+					return fmt.Errorf("Public header is missing")
+				}
+
+				return nil
+			})
+		},
+	},
+	{
+		"cseq",
+		"responses contain the CSeq of the request",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				_, err := c.do(&base.Request{
+					Method: base.Options,
+					URL:    sc.url,
+					Header: base.Header{
+						"CSeq": base.HeaderValue{"3456"},
+					},
+				})
+				return err
+			})
+		},
+	},
+	{
+		"missing-cseq",
+		"requests without CSeq are rejected with 400",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				err := c.conn.WriteRequest(&base.Request{
+					Method: base.Options,
+					URL:    sc.url,
+					Header: base.Header{
+						"User-Agent": base.HeaderValue{userAgent},
+					},
+				})
+				if err != nil {
+					return err
+				}
+
+				res, err := c.readResponse()
+				if err != nil {
+					return err
+				}
+
+				if res.StatusCode != base.StatusBadRequest {
+					return fmt.Errorf("bad status code: %v", res.StatusCode)
+				}
+
+				return nil
+			})
+		},
+	},
+	{
+		"describe",
+		"DESCRIBE returns a valid SDP",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				_, err := c.describe()
+				return err
+			})
+		},
+	},
+	{
+		"setup-tcp",
+		"SETUP with the TCP transport protocol returns a session and interleaved channels",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				_, err := c.setupTCP()
+				return err
+			})
+		},
+	},
+	{
+		"setup-udp",
+		"SETUP with the UDP transport protocol returns server ports, or 461 if UDP is not supported",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				desc, err := c.describe()
+				if err != nil {
+					return errSkipped{err}
+				}
+
+				u, err := base.ResolveControl(desc.BaseURL, desc.Medias[0].Control)
+				if err != nil {
+					return err
+				}
+
+				res, err := c.do(&base.Request{
+					Method: base.Setup,
+					URL:    u,
+					Header: base.Header{
+						"Transport": headers.Transport{
+							Protocol:    headers.TransportProtocolUDP,
+							Delivery:    ptrOf(headers.TransportDeliveryUnicast),
+							Mode:        ptrOf(headers.TransportModePlay),
+							ClientPorts: &[2]int{35466, 35467},
+						}.Marshal(),
+					},
+				})
+				if err != nil {
+					return err
+				}
+
+				switch res.StatusCode {
+				case base.StatusOK:
+					var th headers.Transport
+					err = th.Unmarshal(res.Header["Transport"])
+					if err != nil {
+						return fmt.Errorf("invalid Transport header: %w", err)
+					}
+
+					if th.Protocol != headers.TransportProtocolUDP {
+						return fmt.Errorf("server replied with a different transport protocol")
+					}
+
+					if th.ServerPorts == nil {
+						return fmt.Errorf("server ports are missing")
+					}
+
+					return nil
+
+				case base.StatusUnsupportedTransport:
+					return nil
+				}
+
+				return fmt.Errorf("bad status code: %v", res.StatusCode)
+			})
+		},
+	},
+	{
+		"play",
+		"PLAY returns 200 and RTP packets are received through the TCP connection",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				sx, err := c.setupTCP()
+				if err != nil {
+					return errSkipped{err}
+				}
+
+				res, err := c.do(&base.Request{
+					Method: base.Play,
+					URL:    sc.url,
+					Header: base.Header{
+						"Session": base.HeaderValue{sx.Session},
+					},
+				})
+				if err != nil {
+					return err
+				}
+
+				if res.StatusCode != base.StatusOK {
+					return fmt.Errorf("bad status code: %v", res.StatusCode)
+				}
+
+				for {
+					what, err := c.conn.Read()
+					if err != nil {
+						return fmt.Errorf("no RTP packets received: %w", err)
+					}
+
+					if fr, ok := what.(*base.InterleavedFrame); ok && fr.Channel == 0 {
+						return nil
+					}
+				}
+			})
+		},
+	},
+	{
+		"session-not-found",
+		"requests with an unknown session are rejected with 454",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				res, err := c.do(&base.Request{
+					Method: base.Play,
+					URL:    sc.url,
+					Header: base.Header{
+						"Session": base.HeaderValue{"conformance-unknown"},
+					},
+				})
+				if err != nil {
+					return err
+				}
+
+				if res.StatusCode != base.StatusSessionNotFound {
+					return fmt.Errorf("bad status code: %v", res.StatusCode)
+				}
+
+				return nil
+			})
+		},
+	},
+	{
+		"teardown",
+		"TEARDOWN returns 200",
+		func(sc *ServerChecker) error {
+			return sc.withConn(func(c *serverConn) error {
+				sx, err := c.setupTCP()
+				if err != nil {
+					return errSkipped{err}
+				}
+
+				res, err := c.do(&base.Request{
+					Method: base.Teardown,
+					URL:    sc.url,
+					Header: base.Header{
+						"Session": base.HeaderValue{sx.Session},
+					},
+				})
+				if err != nil {
+					return err
+				}
+
+				if res.StatusCode != base.StatusOK {
+					return fmt.Errorf("bad status code: %v", res.StatusCode)
+				}
+
+				return nil
+			})
+		},
+	},
+}
+
+// ServerChecker checks whether a RTSP server complies with the specification.
+type ServerChecker struct {
+	// URL of a stream provided by the server.
+	// It can contain credentials.
+	URL string
+
+	// timeout of each check.
+	// It defaults to 10 seconds.
+	Timeout time.Duration
+
+	// TLS configuration used with RTSPS URLs.
+	TLSConfig *tls.Config
+
+	url *base.URL
+}
+
+// Run runs all checks and returns a report.
+func (sc *ServerChecker) Run() (*Report, error) {
+	var err error
+	sc.url, err = base.ParseURL(sc.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.Timeout == 0 {
+		sc.Timeout = 10 * time.Second
+	}
+
+	return runChecks(sc.url.CloneWithoutCredentials().String(), sc, serverChecks), nil
+}
+
+// withConn runs a callback with a new connection to the server.
+func (sc *ServerChecker) withConn(cb func(c *serverConn) error) error {
+	c := &serverConn{
+		sc: sc,
+	}
+	err := c.initialize()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	return cb(c)
+}
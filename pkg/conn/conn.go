@@ -4,17 +4,25 @@ package conn
 import (
 	"bufio"
 	"io"
+	"sync"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 )
 
 // Conn is a RTSP connection.
 type Conn struct {
-	br *bufio.Reader
-	w  io.Writer
+	br            *bufio.Reader
+	w             io.Writer
+	limits        *base.Limits
+	conformance   base.Conformance
+	customMethods []base.Method
 
 	// reuse interleaved frames. they should never be passed to secondary routines
 	fr base.InterleavedFrame
+
+	// messages may be written by multiple routines
+	// and may be split into multiple calls to w.Write().
+	writeMutex sync.Mutex
 }
 
 // NewConn allocates a Conn.
@@ -25,6 +33,33 @@ func NewConn(br *bufio.Reader, w io.Writer) *Conn {
 	}
 }
 
+// SetLimits sets limits enforced when reading messages.
+// If limits is nil, default limits are used.
+// The buffer of the reader passed to NewConn must be at least limits.ReaderSize() bytes long.
+func (c *Conn) SetLimits(limits *base.Limits) {
+	c.limits = limits
+}
+
+// SetConformance sets the conformance level required when reading messages.
+func (c *Conn) SetConformance(conformance base.Conformance) {
+	c.conformance = conformance
+}
+
+// SetCustomMethods sets non-standard methods of requests that can be read.
+func (c *Conn) SetCustomMethods(methods []base.Method) {
+	c.customMethods = methods
+}
+
+func (c *Conn) isCustomMethod(byts []byte) bool {
+	for _, method := range c.customMethods {
+		prefix := string(method) + " "
+		if prefix[0] == byts[0] && prefix[1] == byts[1] {
+			return true
+		}
+	}
+	return false
+}
+
 // Read reads a Request, a Response or an Interleaved frame.
 func (c *Conn) Read() (any, error) {
 	for {
@@ -49,7 +84,8 @@ func (c *Conn) Read() (any, error) {
 			(byts[0] == 'P' && byts[1] == 'L') ||
 			(byts[0] == 'R' && byts[1] == 'E') ||
 			(byts[0] == 'S' && byts[1] == 'E') ||
-			(byts[0] == 'T' && byts[1] == 'E') {
+			(byts[0] == 'T' && byts[1] == 'E') ||
+			c.isCustomMethod(byts) {
 			return c.ReadRequest()
 		}
 
@@ -62,39 +98,55 @@ func (c *Conn) Read() (any, error) {
 // ReadRequest reads a Request.
 func (c *Conn) ReadRequest() (*base.Request, error) {
 	var req base.Request
-	err := req.Unmarshal(c.br)
+	err := req.UnmarshalWithLimits(c.br, c.limits, c.conformance)
 	return &req, err
 }
 
 // ReadResponse reads a Response.
 func (c *Conn) ReadResponse() (*base.Response, error) {
 	var res base.Response
-	err := res.Unmarshal(c.br)
+	err := res.UnmarshalWithLimits(c.br, c.limits, c.conformance)
 	return &res, err
 }
 
 // ReadInterleavedFrame reads a InterleavedFrame.
 func (c *Conn) ReadInterleavedFrame() (*base.InterleavedFrame, error) {
-	err := c.fr.Unmarshal(c.br)
+	err := c.fr.UnmarshalWithLimits(c.br, c.limits)
 	return &c.fr, err
 }
 
 // WriteRequest writes a request.
+// The body is not copied into an intermediate buffer.
 func (c *Conn) WriteRequest(req *base.Request) error {
-	buf, _ := req.Marshal()
-	_, err := c.w.Write(buf)
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	_, err := req.WriteTo(c.w)
 	return err
 }
 
 // WriteResponse writes a response.
+// The body is not copied into an intermediate buffer.
 func (c *Conn) WriteResponse(res *base.Response) error {
-	buf, _ := res.Marshal()
-	_, err := c.w.Write(buf)
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	_, err := res.WriteTo(c.w)
 	return err
 }
 
 // WriteInterleavedFrame writes an interleaved frame.
+// If buf is big enough, the frame is marshaled into buf and written with a single call,
+// otherwise the payload is written directly, without copying it.
 func (c *Conn) WriteInterleavedFrame(fr *base.InterleavedFrame, buf []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if len(buf) < fr.MarshalSize() {
+		_, err := fr.WriteTo(c.w)
+		return err
+	}
+
 	n, _ := fr.MarshalTo(buf)
 	_, err := c.w.Write(buf[:n])
 	return err
@@ -110,6 +110,51 @@ func TestReadError(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestReadLimits(t *testing.T) {
+	buf := bytes.NewBuffer([]byte("OPTIONS rtsp://example.com/media.mp4 RTSP/1.0\r\n" +
+		"CSeq: 1\r\n" +
+		"Require: implicit-play\r\n" +
+		"\r\n"))
+	conn := NewConn(bufio.NewReader(buf), buf)
+	conn.SetLimits(&base.Limits{MaxHeaderCount: 1})
+	_, err := conn.Read()
+	require.Equal(t, base.ErrHeaderCountExceeded{Max: 1}, err)
+}
+
+func TestReadConformance(t *testing.T) {
+	buf := bytes.NewBuffer([]byte("OPTIONS rtsp://example.com/media.mp4 rtsp/1.0\r\n" +
+		"CSeq: 1\r\n" +
+		"\r\n"))
+	conn := NewConn(bufio.NewReader(buf), buf)
+	conn.SetConformance(base.ConformanceLenient)
+	dec, err := conn.Read()
+	require.NoError(t, err)
+	require.Equal(t, &base.Request{
+		Method: base.Options,
+		URL:    mustParseURL("rtsp://example.com/media.mp4"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	}, dec)
+}
+
+func TestReadCustomMethod(t *testing.T) {
+	buf := bytes.NewBuffer([]byte("MYMETHOD rtsp://example.com/media.mp4 RTSP/1.0\r\n" +
+		"CSeq: 1\r\n" +
+		"\r\n"))
+	conn := NewConn(bufio.NewReader(buf), buf)
+	conn.SetCustomMethods([]base.Method{"MYMETHOD"})
+	dec, err := conn.Read()
+	require.NoError(t, err)
+	require.Equal(t, &base.Request{
+		Method: "MYMETHOD",
+		URL:    mustParseURL("rtsp://example.com/media.mp4"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	}, dec)
+}
+
 func TestWriteRequest(t *testing.T) {
 	var buf bytes.Buffer
 	conn := NewConn(bufio.NewReader(&buf), &buf)
@@ -153,3 +198,14 @@ func TestWriteInterleavedFrame(t *testing.T) {
 	}, make([]byte, 1024))
 	require.NoError(t, err)
 }
+
+func TestWriteInterleavedFrameWithoutBuffer(t *testing.T) {
+	var buf bytes.Buffer
+	conn := NewConn(bufio.NewReader(&buf), &buf)
+	err := conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: 6,
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x24, 6, 0, 4, 0x01, 0x02, 0x03, 0x04}, buf.Bytes())
+}
@@ -13,6 +13,7 @@ import (
 
 	psdp "github.com/pion/sdp/v3"
 
+	"github.com/bluenviron/gortsplib/v5/pkg/audiolevel"
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
 	"github.com/bluenviron/gortsplib/v5/pkg/headers"
@@ -37,6 +38,30 @@ func isBackChannel(attributes []psdp.Attribute) bool {
 	return false
 }
 
+func getAudioLevelExtension(attributes []psdp.Attribute) (uint8, string, string, error) {
+	for _, attr := range attributes {
+		if attr.Key != "extmap" {
+			continue
+		}
+
+		// extmap:<id>[/<direction>] <uri> [<attributes>]
+		parts := strings.Fields(attr.Value)
+		if len(parts) < 2 || parts[1] != audiolevel.URI {
+			continue
+		}
+
+		id, direction, _ := strings.Cut(parts[0], "/")
+
+		tmp, err := strconv.ParseUint(id, 10, 8)
+		if err != nil || tmp == 0 {
+			return 0, "", "", fmt.Errorf("invalid extmap: %v", attr.Value)
+		}
+
+		return uint8(tmp), direction, strings.Join(parts[2:], " "), nil
+	}
+	return 0, "", "", nil
+}
+
 func sortedKeys(fmtp map[string]string) []string {
 	keys := make([]string, len(fmtp))
 	i := 0
@@ -85,6 +110,17 @@ type Media struct {
 	// key-mgmt attribute.
 	KeyMgmtMikey *mikey.Message
 
+	// ID of the audio level RTP header extension (RFC6464),
+	// that is declared with the extmap attribute.
+	// Zero means that the extension is not in use.
+	AudioLevelExtensionID uint8
+
+	// direction of the audio level RTP header extension (optional).
+	AudioLevelExtensionDirection string
+
+	// extension attributes of the audio level RTP header extension (optional).
+	AudioLevelExtensionAttributes string
+
 	// Control attribute.
 	Control string
 
@@ -126,6 +162,13 @@ func (m *Media) Unmarshal(md *psdp.MediaDescription) error {
 		}
 	}
 
+	var err error
+	m.AudioLevelExtensionID, m.AudioLevelExtensionDirection, m.AudioLevelExtensionAttributes,
+		err = getAudioLevelExtension(md.Attributes)
+	if err != nil {
+		return err
+	}
+
 	m.Control = getAttribute(md.Attributes, "control")
 
 	m.Formats = nil
@@ -188,6 +231,22 @@ func (m Media) Marshal() (*psdp.MediaDescription, error) {
 		})
 	}
 
+	if m.AudioLevelExtensionID != 0 {
+		v := strconv.FormatUint(uint64(m.AudioLevelExtensionID), 10)
+		if m.AudioLevelExtensionDirection != "" {
+			v += "/" + m.AudioLevelExtensionDirection
+		}
+		v += " " + audiolevel.URI
+		if m.AudioLevelExtensionAttributes != "" {
+			v += " " + m.AudioLevelExtensionAttributes
+		}
+
+		md.Attributes = append(md.Attributes, psdp.Attribute{
+			Key:   "extmap",
+			Value: v,
+		})
+	}
+
 	md.Attributes = append(md.Attributes, psdp.Attribute{
 		Key:   "control",
 		Value: m.Control,
@@ -224,40 +283,7 @@ func (m Media) Marshal() (*psdp.MediaDescription, error) {
 
 // URL returns the absolute URL of the media.
 func (m Media) URL(contentBase *base.URL) (*base.URL, error) {
-	if contentBase == nil {
-		return nil, fmt.Errorf("Content-Base header not provided")
-	}
-
-	// no control attribute, use base URL
-	if m.Control == "" {
-		return contentBase, nil
-	}
-
-	// control attribute contains an absolute path
-	if strings.HasPrefix(m.Control, "rtsp://") ||
-		strings.HasPrefix(m.Control, "rtsps://") {
-		ur, err := base.ParseURL(m.Control)
-		if err != nil {
-			return nil, err
-		}
-
-		// copy host and credentials
-		ur.Host = contentBase.Host
-		ur.User = contentBase.User
-		return ur, nil
-	}
-
-	// control attribute contains a relative control attribute
-	// insert the control attribute at the end of the URL
-	// if there's a query, insert it after the query
-	// otherwise insert it after the path
-	strURL := contentBase.String()
-	if m.Control[0] != '?' && m.Control[0] != '/' && !strings.HasSuffix(strURL, "/") {
-		strURL += "/"
-	}
-
-	ur, _ := base.ParseURL(strURL + m.Control)
-	return ur, nil
+	return base.ResolveControl(contentBase, m.Control)
 }
 
 // FindFormat finds a certain format among all the formats in the media.
@@ -270,3 +296,15 @@ func (m Media) FindFormat(forma any) bool {
 	}
 	return false
 }
+
+// Clone returns a deep copy of the media.
+// Formats are cloned too, therefore the copy is not affected by subsequent changes
+// of codec parameters of the original media.
+func (m *Media) Clone() *Media {
+	c := *m
+	c.Formats = make([]format.Format, len(m.Formats))
+	for i, forma := range m.Formats {
+		c.Formats[i] = forma.Clone()
+	}
+	return &c
+}
@@ -3,6 +3,7 @@ package description
 import (
 	"encoding/base64"
 	"fmt"
+	"slices"
 	"strings"
 
 	psdp "github.com/pion/sdp/v3"
@@ -75,6 +76,31 @@ func (d *Session) FindFormat(forma any) *Media {
 	return nil
 }
 
+// Clone returns a deep copy of the description.
+// Medias and formats are cloned too, therefore the copy is not affected by subsequent changes
+// of codec parameters of the original description.
+func (d *Session) Clone() *Session {
+	c := *d
+
+	if d.BaseURL != nil {
+		c.BaseURL = d.BaseURL.Clone()
+	}
+
+	if d.FECGroups != nil {
+		c.FECGroups = make([]SessionFECGroup, len(d.FECGroups))
+		for i, group := range d.FECGroups {
+			c.FECGroups[i] = slices.Clone(group)
+		}
+	}
+
+	c.Medias = make([]*Media, len(d.Medias))
+	for i, medi := range d.Medias {
+		c.Medias[i] = medi.Clone()
+	}
+
+	return &c
+}
+
 // Unmarshal decodes the description from SDP.
 func (d *Session) Unmarshal(ssd *sdp.SessionDescription) error {
 	d.Title = string(ssd.SessionName)
@@ -270,6 +270,7 @@ var casesSession = []struct {
 			"m=audio 0 RTP/AVP 111 103 104 9 102 0 8 106 105 13 110 112 113 126\r\n" +
 			"a=mid:audio\r\n" +
 			"a=sendonly\r\n" +
+			"a=extmap:1 urn:ietf:params:rtp-hdrext:ssrc-audio-level\r\n" +
 			"a=control\r\n" +
 			"a=rtpmap:111 opus/48000/2\r\n" +
 			"a=fmtp:111 sprop-stereo=0\r\n" +
@@ -307,9 +308,10 @@ var casesSession = []struct {
 			Title: ``,
 			Medias: []*Media{
 				{
-					ID:            "audio",
-					Type:          MediaTypeAudio,
-					IsBackChannel: true,
+					ID:                    "audio",
+					Type:                  MediaTypeAudio,
+					IsBackChannel:         true,
+					AudioLevelExtensionID: 1,
 					Formats: []format.Format{
 						&format.Opus{
 							PayloadTyp:   111,
@@ -343,39 +345,32 @@ var casesSession = []struct {
 							SampleRate:   8000,
 							ChannelCount: 1,
 						},
-						&format.Generic{
+						&format.ComfortNoise{
 							PayloadTyp: 106,
-							RTPMa:      "CN/32000",
 							ClockRat:   32000,
 						},
-						&format.Generic{
+						&format.ComfortNoise{
 							PayloadTyp: 105,
-							RTPMa:      "CN/16000",
 							ClockRat:   16000,
 						},
-						&format.Generic{
+						&format.ComfortNoise{
 							PayloadTyp: 13,
-							RTPMa:      "CN/8000",
 							ClockRat:   8000,
 						},
-						&format.Generic{
+						&format.TelephoneEvent{
 							PayloadTyp: 110,
-							RTPMa:      "telephone-event/48000",
 							ClockRat:   48000,
 						},
-						&format.Generic{
+						&format.TelephoneEvent{
 							PayloadTyp: 112,
-							RTPMa:      "telephone-event/32000",
 							ClockRat:   32000,
 						},
-						&format.Generic{
+						&format.TelephoneEvent{
 							PayloadTyp: 113,
-							RTPMa:      "telephone-event/16000",
 							ClockRat:   16000,
 						},
-						&format.Generic{
+						&format.TelephoneEvent{
 							PayloadTyp: 126,
-							RTPMa:      "telephone-event/8000",
 							ClockRat:   8000,
 						},
 					},
@@ -827,6 +822,45 @@ var casesSession = []struct {
 			},
 		},
 	},
+	{
+		"audio level extension",
+		"v=0\r\n" +
+			"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+			"s=Stream\r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"t=0 0\r\n" +
+			"m=audio 0 RTP/AVP 0\r\n" +
+			"a=rtpmap:0 PCMU/8000\r\n" +
+			"a=extmap:3/sendrecv urn:ietf:params:rtp-hdrext:ssrc-audio-level vad=on\r\n" +
+			"a=control:trackID=0\r\n",
+		"v=0\r\n" +
+			"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+			"s=Stream\r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"t=0 0\r\n" +
+			"m=audio 0 RTP/AVP 0\r\n" +
+			"a=extmap:3/sendrecv urn:ietf:params:rtp-hdrext:ssrc-audio-level vad=on\r\n" +
+			"a=control:trackID=0\r\n" +
+			"a=rtpmap:0 PCMU/8000\r\n",
+		Session{
+			Title: "Stream",
+			Medias: []*Media{
+				{
+					Type:                          MediaTypeAudio,
+					AudioLevelExtensionID:         3,
+					AudioLevelExtensionDirection:  "sendrecv",
+					AudioLevelExtensionAttributes: "vad=on",
+					Control:                       "trackID=0",
+					Formats: []format.Format{&format.G711{
+						PayloadTyp:   0,
+						MULaw:        true,
+						SampleRate:   8000,
+						ChannelCount: 1,
+					}},
+				},
+			},
+		},
+	},
 }
 
 func TestSessionUnmarshal(t *testing.T) {
@@ -975,3 +1009,20 @@ func FuzzSessionUnmarshal(f *testing.F) {
 		require.NoError(t, err)
 	})
 }
+
+func TestSessionClone(t *testing.T) {
+	for _, ca := range casesSession {
+		t.Run(ca.name, func(t *testing.T) {
+			c := ca.desc.Clone()
+			require.Equal(t, &ca.desc, c)
+
+			for i, medi := range ca.desc.Medias {
+				require.NotSame(t, medi, c.Medias[i])
+
+				for j, forma := range medi.Formats {
+					require.NotSame(t, forma, c.Medias[i].Formats[j])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Package dtsextractor contains H264 and H265 DTS extractors that support streams
+// without timing informations or with unsupported POC configurations.
+package dtsextractor
+
+import (
+	"fmt"
+	"slices"
+)
+
+// maximum number of reordered frames.
+const maxReorderedFrames = 16
+
+// Mode is the mode of a DTS extractor.
+type Mode int
+
+// modes.
+const (
+	// DTS is computed with the picture order count (POC).
+	// If this fails, the extractor switches to ModeFallback.
+	ModeAuto Mode = iota
+
+	// DTS is computed by subtracting from PTS the reordering delay,
+	// that is the product of the number of reordered frames and the frame duration.
+	// The number of reordered frames is read from the SPS, or is derived from
+	// the display order of frames (that is, from their POC).
+	// The frame duration is read from the SPS, or is computed from an assumed frame rate.
+	ModeFallback
+)
+
+// ErrSPSMissing is returned when a SPS has not been received yet.
+type ErrSPSMissing struct{}
+
+// Error implements the error interface.
+func (ErrSPSMissing) Error() string {
+	return "SPS not received yet"
+}
+
+// ErrPOCFailed is passed to OnFallback when the POC-based extractor fails.
+type ErrPOCFailed struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrPOCFailed) Error() string {
+	return "unable to extract DTS from POC: " + e.Err.Error()
+}
+
+// Unwrap implements errors.Unwrap.
+func (e ErrPOCFailed) Unwrap() error {
+	return e.Err
+}
+
+// ErrDTSGreaterThanPTS is returned when the DTS of a frame can't be lower or equal than its PTS,
+// while being greater than the DTS of the previous frame.
+// This happens in fallback mode when the number of reordered frames increases.
+// The frame should be discarded.
+type ErrDTSGreaterThanPTS struct {
+	DTS int64
+	PTS int64
+}
+
+// Error implements the error interface.
+func (e ErrDTSGreaterThanPTS) Error() string {
+	return fmt.Sprintf("DTS (%d) is greater than PTS (%d)", e.DTS, e.PTS)
+}
+
+// fallbackState is the state of the fallback extractor.
+// Since frames are reordered by at most reorderedFrames positions,
+// the DTS of a frame is the lowest PTS among the frames that are still to be decoded,
+// after reorderedFrames frames have been received.
+type fallbackState struct {
+	frameDuration   int64
+	reorderedFrames int
+	pending         []int64
+	maxPTSFilled    bool
+	maxPTS          int64
+	prevDTSFilled   bool
+	prevDTS         int64
+}
+
+func (s *fallbackState) reset(frameRate float64, reorderedFrames int) {
+	s.frameDuration = max(1, int64(90000/frameRate))
+	s.reorderedFrames = min(reorderedFrames, maxReorderedFrames)
+	s.pending = nil
+	s.maxPTSFilled = false
+}
+
+func (s *fallbackState) push(pts int64) int64 {
+	if !s.maxPTSFilled || pts > s.maxPTS {
+		s.maxPTSFilled = true
+		s.maxPTS = pts
+	} else if pts < s.maxPTS {
+		// the frame is displayed before a previous one, therefore frames are reordered.
+		n := int((s.maxPTS - pts + s.frameDuration - 1) / s.frameDuration)
+		if n > s.reorderedFrames {
+			s.reorderedFrames = min(n, maxReorderedFrames)
+		}
+	}
+
+	i, _ := slices.BinarySearch(s.pending, pts)
+	s.pending = slices.Insert(s.pending, i, pts)
+
+	if len(s.pending) > s.reorderedFrames {
+		dts := s.pending[0]
+		s.pending = s.pending[1:]
+		return dts
+	}
+
+	// not enough frames have been received yet, therefore the DTS is estimated.
+	return s.pending[0] - int64(s.reorderedFrames-len(s.pending)+1)*s.frameDuration
+}
+
+// update updates the state with a DTS computed by another extractor.
+func (s *fallbackState) update(pts int64, dts int64) {
+	s.push(pts)
+	s.prevDTSFilled = true
+	s.prevDTS = dts
+}
+
+func (s *fallbackState) extract(pts int64) (int64, error) {
+	dts := s.push(pts)
+
+	if s.prevDTSFilled && dts <= s.prevDTS {
+		dts = s.prevDTS + 1
+	}
+
+	if dts > pts {
+		return 0, ErrDTSGreaterThanPTS{DTS: dts, PTS: pts}
+	}
+
+	s.prevDTSFilled = true
+	s.prevDTS = dts
+
+	return dts, nil
+}
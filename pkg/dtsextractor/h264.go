@@ -0,0 +1,106 @@
+package dtsextractor
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+)
+
+// H264 is a DTS extractor for H264 streams.
+// In ModeAuto, DTS is computed with the picture order count (POC).
+// If this fails, for instance because the SPS uses an unsupported POC type,
+// the extractor switches to ModeFallback, that doesn't depend on POC.
+type H264 struct {
+	// mode.
+	// It defaults to ModeAuto.
+	Mode Mode
+
+	// frame rate assumed in fallback mode when the SPS doesn't contain timing informations.
+	// It defaults to 30.
+	AssumedFrameRate float64
+
+	// called when the extractor switches to fallback mode,
+	// with the error that caused the switch.
+	OnFallback func(error)
+
+	sps      []byte
+	spsp     *h264.SPS
+	fallback bool
+	poc      *h264.DTSExtractor
+	state    fallbackState
+}
+
+// Initialize initializes H264.
+func (e *H264) Initialize() {
+	if e.AssumedFrameRate == 0 {
+		e.AssumedFrameRate = 30
+	}
+	if e.OnFallback == nil {
+		e.OnFallback = func(error) {}
+	}
+
+	e.resetState()
+}
+
+// resetState resets the state of the extractor.
+// It is called when the SPS changes, therefore the POC-based extractor is tried again.
+func (e *H264) resetState() {
+	e.fallback = (e.Mode == ModeFallback)
+
+	e.poc = &h264.DTSExtractor{}
+	e.poc.Initialize()
+
+	frameRate := e.AssumedFrameRate
+	reorderedFrames := 0
+
+	if e.spsp != nil {
+		if fps := e.spsp.FPS(); fps > 0 {
+			frameRate = fps
+		}
+		if e.spsp.VUI != nil && e.spsp.VUI.BitstreamRestriction != nil {
+			reorderedFrames = int(e.spsp.VUI.BitstreamRestriction.MaxNumReorderFrames)
+		}
+	}
+
+	e.state.reset(frameRate, reorderedFrames)
+}
+
+// Fallback returns whether the extractor is in fallback mode.
+func (e *H264) Fallback() bool {
+	return e.fallback
+}
+
+// Extract extracts the DTS of an access unit.
+func (e *H264) Extract(au [][]byte, pts int64) (int64, error) {
+	for _, nalu := range au {
+		if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeSPS && !bytes.Equal(e.sps, nalu) {
+			var spsp h264.SPS
+			err := spsp.Unmarshal(nalu)
+			if err != nil {
+				return 0, fmt.Errorf("invalid SPS: %w", err)
+			}
+
+			e.sps = nalu
+			e.spsp = &spsp
+			e.resetState()
+		}
+	}
+
+	if e.spsp == nil {
+		return 0, ErrSPSMissing{}
+	}
+
+	if !e.fallback {
+		dts, err := e.poc.Extract(au, pts)
+		if err == nil {
+			e.state.update(pts, dts)
+			return dts, nil
+		}
+
+		e.fallback = true
+		e.OnFallback(ErrPOCFailed{Err: err})
+	}
+
+	return e.state.extract(pts)
+}
@@ -0,0 +1,118 @@
+package dtsextractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
+)
+
+// generateSPS generates a SPS without VUI.
+func generateSPS(picOrderCntType uint32) []byte {
+	var w bits.Writer
+
+	w.WriteBits(0x67, 8)
+	w.WriteBits(66, 8)   // profile_idc
+	w.WriteBits(0xC0, 8) // constraint flags
+	w.WriteBits(30, 8)   // level_idc
+	w.WriteUE(0)         // seq_parameter_set_id
+	w.WriteUE(0)         // log2_max_frame_num_minus4
+	w.WriteUE(picOrderCntType)
+
+	switch picOrderCntType {
+	case 0:
+		w.WriteUE(2) // log2_max_pic_order_cnt_lsb_minus4
+
+	case 1:
+		w.WriteFlag(false) // delta_pic_order_always_zero_flag
+		w.WriteSE(0)       // offset_for_non_ref_pic
+		w.WriteSE(0)       // offset_for_top_to_bottom_field
+		w.WriteUE(0)       // num_ref_frames_in_pic_order_cnt_cycle
+	}
+
+	w.WriteUE(1)       // max_num_ref_frames
+	w.WriteFlag(false) // gaps_in_frame_num_value_allowed_flag
+	w.WriteUE(19)      // pic_width_in_mbs_minus1
+	w.WriteUE(14)      // pic_height_in_map_units_minus1
+	w.WriteFlag(true)  // frame_mbs_only_flag
+	w.WriteFlag(true)  // direct_8x8_inference_flag
+	w.WriteFlag(false) // frame_cropping_flag
+	w.WriteFlag(false) // vui_parameters_present_flag
+	w.WriteTrailingBits()
+
+	return w.Bytes()
+}
+
+type testFrame struct {
+	pts int64
+	dts int64
+	err error
+}
+
+func TestH264Fallback(t *testing.T) {
+	var fallbackErr error
+
+	e := &H264{
+		OnFallback: func(err error) {
+			fallbackErr = err
+		},
+	}
+	e.Initialize()
+
+	_, err := e.Extract([][]byte{{0x65, 0x88}}, 0)
+	require.Equal(t, ErrSPSMissing{}, err)
+
+	sps := generateSPS(1)
+	pps := []byte{0x68, 0xce, 0x3c, 0x80}
+
+	var out []testFrame
+
+	for i, pts := range []int64{0, 3, 1, 2, 6, 4, 5, 9, 7, 8} {
+		au := [][]byte{{0x41, 0x9a}}
+		if i == 0 {
+			au = [][]byte{sps, pps, {0x65, 0x88}}
+		}
+
+		dts, err2 := e.Extract(au, pts*3000)
+		out = append(out, testFrame{pts * 3000, dts, err2})
+	}
+
+	require.True(t, e.Fallback())
+
+	var pocErr ErrPOCFailed
+	require.ErrorAs(t, fallbackErr, &pocErr)
+
+	require.Equal(t, []testFrame{
+		{0, 0, nil},
+		{9000, 9000, nil},
+		{3000, 0, ErrDTSGreaterThanPTS{DTS: 9001, PTS: 3000}},
+		{6000, 0, ErrDTSGreaterThanPTS{DTS: 9001, PTS: 6000}},
+		{18000, 9001, nil},
+		{12000, 9002, nil},
+		{15000, 12000, nil},
+		{27000, 15000, nil},
+		{21000, 18000, nil},
+		{24000, 21000, nil},
+	}, out)
+}
+
+func TestH264FallbackMode(t *testing.T) {
+	e := &H264{
+		Mode:             ModeFallback,
+		AssumedFrameRate: 25,
+	}
+	e.Initialize()
+
+	sps := generateSPS(0)
+	pps := []byte{0x68, 0xce, 0x3c, 0x80}
+
+	dts, err := e.Extract([][]byte{sps, pps, {0x65, 0x88}}, 90000)
+	require.NoError(t, err)
+	require.Equal(t, int64(90000), dts)
+	require.True(t, e.Fallback())
+
+	dts, err = e.Extract([][]byte{{0x41, 0x9a}}, 90000+3600)
+	require.NoError(t, err)
+	require.Equal(t, int64(90000+3600), dts)
+}
@@ -0,0 +1,108 @@
+package dtsextractor
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+)
+
+// H265 is a DTS extractor for H265 streams.
+// In ModeAuto, DTS is computed with the picture order count (POC).
+// If this fails, for instance because slices are split into multiple segments,
+// the extractor switches to ModeFallback, that doesn't depend on POC.
+// In fallback mode, the number of reordered frames is read from the SPS,
+// and is the one of the highest sub-layer.
+type H265 struct {
+	// mode.
+	// It defaults to ModeAuto.
+	Mode Mode
+
+	// frame rate assumed in fallback mode when the SPS doesn't contain timing informations.
+	// It defaults to 30.
+	AssumedFrameRate float64
+
+	// called when the extractor switches to fallback mode,
+	// with the error that caused the switch.
+	OnFallback func(error)
+
+	sps      []byte
+	spsp     *h265.SPS
+	fallback bool
+	poc      *h265.DTSExtractor
+	state    fallbackState
+}
+
+// Initialize initializes H265.
+func (e *H265) Initialize() {
+	if e.AssumedFrameRate == 0 {
+		e.AssumedFrameRate = 30
+	}
+	if e.OnFallback == nil {
+		e.OnFallback = func(error) {}
+	}
+
+	e.resetState()
+}
+
+// resetState resets the state of the extractor.
+// It is called when the SPS changes, therefore the POC-based extractor is tried again.
+func (e *H265) resetState() {
+	e.fallback = (e.Mode == ModeFallback)
+
+	e.poc = &h265.DTSExtractor{}
+	e.poc.Initialize()
+
+	frameRate := e.AssumedFrameRate
+	reorderedFrames := 0
+
+	if e.spsp != nil {
+		if fps := e.spsp.FPS(); fps > 0 {
+			frameRate = fps
+		}
+		for _, v := range e.spsp.MaxNumReorderPics {
+			reorderedFrames = max(reorderedFrames, int(v))
+		}
+	}
+
+	e.state.reset(frameRate, reorderedFrames)
+}
+
+// Fallback returns whether the extractor is in fallback mode.
+func (e *H265) Fallback() bool {
+	return e.fallback
+}
+
+// Extract extracts the DTS of an access unit.
+func (e *H265) Extract(au [][]byte, pts int64) (int64, error) {
+	for _, nalu := range au {
+		if h265.NALUType((nalu[0]>>1)&0b111111) == h265.NALUType_SPS_NUT && !bytes.Equal(e.sps, nalu) {
+			var spsp h265.SPS
+			err := spsp.Unmarshal(nalu)
+			if err != nil {
+				return 0, fmt.Errorf("invalid SPS: %w", err)
+			}
+
+			e.sps = nalu
+			e.spsp = &spsp
+			e.resetState()
+		}
+	}
+
+	if e.spsp == nil {
+		return 0, ErrSPSMissing{}
+	}
+
+	if !e.fallback {
+		dts, err := e.poc.Extract(au, pts)
+		if err == nil {
+			e.state.update(pts, dts)
+			return dts, nil
+		}
+
+		e.fallback = true
+		e.OnFallback(ErrPOCFailed{Err: err})
+	}
+
+	return e.state.extract(pts)
+}
@@ -0,0 +1,66 @@
+package dtsextractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestH265Fallback(t *testing.T) {
+	var fallbackErr error
+
+	e := &H265{
+		OnFallback: func(err error) {
+			fallbackErr = err
+		},
+	}
+	e.Initialize()
+
+	idr := []byte{0x26, 0x1, 0xaf, 0x8, 0x42, 0x23, 0x48, 0x8a, 0x43, 0xe2}
+
+	_, err := e.Extract([][]byte{idr}, 0)
+	require.Equal(t, ErrSPSMissing{}, err)
+
+	vps := []byte{
+		0x40, 0x01, 0x0c, 0x01, 0xff, 0xff, 0x01, 0x60,
+		0x00, 0x00, 0x03, 0x00, 0x90, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x03, 0x00, 0x78, 0x99, 0x98, 0x09,
+	}
+	sps := []byte{
+		0x42, 0x01, 0x01, 0x01, 0x60, 0x00, 0x00, 0x03,
+		0x00, 0x90, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03,
+		0x00, 0x78, 0xa0, 0x03, 0xc0, 0x80, 0x10, 0xe5,
+		0x96, 0x66, 0x69, 0x24, 0xca, 0xe0, 0x10, 0x00,
+		0x00, 0x03, 0x00, 0x10, 0x00, 0x00, 0x03, 0x01,
+		0xe0, 0x80,
+	}
+	pps := []byte{0x44, 0x1, 0xc1, 0x72, 0xb4, 0x62, 0x40}
+
+	dts, err := e.Extract([][]byte{vps, sps, pps, idr}, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(-6000), dts)
+	require.False(t, e.Fallback())
+
+	var out []testFrame
+
+	// slices split into multiple segments are not supported by the POC-based extractor.
+	// The SPS contains 2 reordered frames and a frame rate of 30.
+	for _, pts := range []int64{3, 1, 2, 6, 4, 5} {
+		dts, err = e.Extract([][]byte{{0x02, 0x01, 0x50, 0x00}}, pts*3000)
+		out = append(out, testFrame{pts * 3000, dts, err})
+	}
+
+	require.True(t, e.Fallback())
+
+	var pocErr ErrPOCFailed
+	require.ErrorAs(t, fallbackErr, &pocErr)
+
+	require.Equal(t, []testFrame{
+		{9000, -3000, nil},
+		{3000, 0, nil},
+		{6000, 3000, nil},
+		{18000, 6000, nil},
+		{12000, 9000, nil},
+		{15000, 12000, nil},
+	}, out)
+}
@@ -0,0 +1,75 @@
+package flv
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+)
+
+const (
+	// AAC, 44kHz, 16 bit, stereo. These values are ignored by decoders,
+	// that use the AudioSpecificConfig instead.
+	audioHeaderAAC = 10<<4 | 3<<2 | 1<<1 | 1
+
+	aacPacketTypeSequenceHeader = 0
+	aacPacketTypeRaw            = 1
+)
+
+// AudioData is the content of an audio tag that contains MPEG-4 Audio.
+// Specification: Adobe Flash Video File Format Specification v10.1, annex E.4.2
+type AudioData struct {
+	// whether the tag is a AAC sequence header, that contains the configuration.
+	IsSequenceHeader bool
+
+	// configuration (sequence headers only).
+	Config *mpeg4audio.AudioSpecificConfig
+
+	// access unit (non-sequence headers only).
+	AU []byte
+}
+
+// Marshal encodes an AudioData.
+func (d AudioData) Marshal() ([]byte, error) {
+	if d.IsSequenceHeader {
+		if d.Config == nil {
+			return nil, fmt.Errorf("config not provided")
+		}
+
+		enc, err := d.Config.Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte{audioHeaderAAC, aacPacketTypeSequenceHeader}, enc...), nil
+	}
+
+	return append([]byte{audioHeaderAAC, aacPacketTypeRaw}, d.AU...), nil
+}
+
+// Unmarshal decodes an AudioData.
+func (d *AudioData) Unmarshal(buf []byte) error {
+	if len(buf) < 2 {
+		return fmt.Errorf("audio data is too short")
+	}
+
+	if (buf[0] >> 4) != 10 {
+		return fmt.Errorf("unsupported audio codec: %d", buf[0]>>4)
+	}
+
+	switch buf[1] {
+	case aacPacketTypeSequenceHeader:
+		d.IsSequenceHeader = true
+		d.AU = nil
+		d.Config = &mpeg4audio.AudioSpecificConfig{}
+		return d.Config.Unmarshal(buf[2:])
+
+	case aacPacketTypeRaw:
+		d.IsSequenceHeader = false
+		d.Config = nil
+		d.AU = buf[2:]
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported AAC packet type: %d", buf[1])
+	}
+}
@@ -0,0 +1,187 @@
+package flv
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+var testConfig = &mpeg4audio.AudioSpecificConfig{
+	Type:         2,
+	SampleRate:   44100,
+	ChannelCount: 2,
+}
+
+func TestVideoDataMarshalUnmarshal(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		data VideoData
+	}{
+		{
+			"sequence header",
+			VideoData{
+				IsSequenceHeader: true,
+				KeyFrame:         true,
+				SPS:              testSPS,
+				PPS:              testPPS,
+			},
+		},
+		{
+			"key frame",
+			VideoData{
+				KeyFrame:        true,
+				CompositionTime: 66,
+				AU:              [][]byte{{5, 1}, {5, 2}},
+			},
+		},
+		{
+			"negative composition time",
+			VideoData{
+				CompositionTime: -33,
+				AU:              [][]byte{{1, 2}},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			buf, err := ca.data.Marshal()
+			require.NoError(t, err)
+
+			var dec VideoData
+			err = dec.Unmarshal(buf)
+			require.NoError(t, err)
+			require.Equal(t, ca.data, dec)
+		})
+	}
+}
+
+func TestAudioDataMarshalUnmarshal(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		data AudioData
+	}{
+		{
+			"sequence header",
+			AudioData{
+				IsSequenceHeader: true,
+				Config:           testConfig,
+			},
+		},
+		{
+			"raw",
+			AudioData{
+				AU: []byte{1, 2, 3, 4},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			buf, err := ca.data.Marshal()
+			require.NoError(t, err)
+
+			var dec AudioData
+			err = dec.Unmarshal(buf)
+			require.NoError(t, err)
+			require.Equal(t, ca.data, dec)
+		})
+	}
+}
+
+func TestMuxerReader(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := &Muxer{
+		W: &buf,
+		VideoFormat: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+		AudioFormat: &format.MPEG4Audio{
+			PayloadTyp:       97,
+			Config:           testConfig,
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	// non-IDR frames are discarded until a IDR frame is received
+	err = m.WriteH264(0, [][]byte{{1, 0}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(90000, [][]byte{{9, 240}, testSPS, testPPS, {5, 1}})
+	require.NoError(t, err)
+
+	err = m.WriteMPEG4Audio(44100, [][]byte{{1, 2}, {3, 4}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(90000+3000, [][]byte{{1, 1}})
+	require.NoError(t, err)
+
+	// parameters change
+	newPPS := []byte{0x08, 0x06, 0x07, 0x09}
+	err = m.WriteH264(90000+6000, [][]byte{testSPS, newPPS, {5, 2}})
+	require.NoError(t, err)
+
+	r := &Reader{R: &buf}
+	err = r.Initialize()
+	require.NoError(t, err)
+	require.True(t, r.HasAudio)
+	require.True(t, r.HasVideo)
+
+	type entry struct {
+		typ  TagType
+		ts   uint32
+		data any
+	}
+
+	var entries []entry
+
+	for {
+		var tag *Tag
+		tag, err = r.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		switch tag.Type {
+		case TagTypeVideo:
+			var d VideoData
+			err = d.Unmarshal(tag.Data)
+			require.NoError(t, err)
+			entries = append(entries, entry{tag.Type, tag.Timestamp, d})
+
+		case TagTypeAudio:
+			var d AudioData
+			err = d.Unmarshal(tag.Data)
+			require.NoError(t, err)
+			entries = append(entries, entry{tag.Type, tag.Timestamp, d})
+		}
+	}
+
+	require.Equal(t, []entry{
+		{TagTypeAudio, 0, AudioData{IsSequenceHeader: true, Config: testConfig}},
+		{TagTypeVideo, 0, VideoData{IsSequenceHeader: true, KeyFrame: true, SPS: testSPS, PPS: testPPS}},
+		{TagTypeVideo, 0, VideoData{KeyFrame: true, AU: [][]byte{{5, 1}}}},
+		{TagTypeAudio, 0, AudioData{AU: []byte{1, 2}}},
+		{TagTypeAudio, 23, AudioData{AU: []byte{3, 4}}},
+		{TagTypeVideo, 33, VideoData{AU: [][]byte{{1, 1}}}},
+		{TagTypeVideo, 66, VideoData{IsSequenceHeader: true, KeyFrame: true, SPS: testSPS, PPS: newPPS}},
+		{TagTypeVideo, 66, VideoData{KeyFrame: true, AU: [][]byte{{5, 2}}}},
+	}, entries)
+}
@@ -0,0 +1,224 @@
+package flv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/dtsextractor"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+const (
+	headerFlagAudio = 0x04
+	headerFlagVideo = 0x01
+)
+
+// Muxer writes H264 and MPEG-4 Audio access units into a FLV stream,
+// that can be sent to a RTMP server or saved to disk.
+// AVC and AAC sequence headers are generated automatically.
+//
+// Timestamps are expressed in clock rate units of each track and
+// must share the same origin (like the ones returned by Client.PacketPTS()).
+type Muxer struct {
+	// destination of the stream.
+	W io.Writer
+
+	// video format. Optional.
+	VideoFormat *format.H264
+
+	// audio format. Optional.
+	AudioFormat *format.MPEG4Audio
+
+	sps             []byte
+	pps             []byte
+	videoHeaderSent bool
+	dtsExtractor    *dtsextractor.H264
+	startSet        bool
+	start           time.Duration
+}
+
+// Initialize initializes Muxer and writes the FLV header.
+func (m *Muxer) Initialize() error {
+	if m.W == nil {
+		return fmt.Errorf("W not provided")
+	}
+
+	if m.VideoFormat == nil && m.AudioFormat == nil {
+		return fmt.Errorf("no formats provided")
+	}
+
+	flags := byte(0)
+
+	if m.VideoFormat != nil {
+		flags |= headerFlagVideo
+		m.sps, m.pps = m.VideoFormat.SafeParams()
+	}
+
+	if m.AudioFormat != nil {
+		if m.AudioFormat.Config == nil {
+			return fmt.Errorf("audio config not provided")
+		}
+		flags |= headerFlagAudio
+	}
+
+	// Specification: Adobe Flash Video File Format Specification v10.1, annex E.2
+	_, err := m.W.Write([]byte{'F', 'L', 'V', 1, flags, 0, 0, 0, 9, 0, 0, 0, 0})
+	if err != nil {
+		return err
+	}
+
+	if m.AudioFormat != nil {
+		return m.writeData(TagTypeAudio, 0, AudioData{
+			IsSequenceHeader: true,
+			Config:           m.AudioFormat.Config,
+		})
+	}
+
+	return nil
+}
+
+// WriteH264 writes a H264 access unit.
+// SPS and PPS are moved into AVC sequence headers, that are sent
+// before the first IDR frame and every time parameters change.
+func (m *Muxer) WriteH264(pts int64, au [][]byte) error {
+	if m.VideoFormat == nil {
+		return fmt.Errorf("video format not provided")
+	}
+
+	filtered := make([][]byte, 0, len(au))
+	paramsChanged := false
+	randomAccess := false
+
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			if !bytes.Equal(nalu, m.sps) {
+				m.sps = nalu
+				paramsChanged = true
+			}
+			continue
+
+		case h264.NALUTypePPS:
+			if !bytes.Equal(nalu, m.pps) {
+				m.pps = nalu
+				paramsChanged = true
+			}
+			continue
+
+		case h264.NALUTypeAccessUnitDelimiter:
+			continue
+
+		case h264.NALUTypeIDR:
+			randomAccess = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	if !m.videoHeaderSent || paramsChanged {
+		// wait for a IDR frame
+		if !randomAccess || m.sps == nil || m.pps == nil {
+			return nil
+		}
+
+		m.dtsExtractor = &dtsextractor.H264{}
+		m.dtsExtractor.Initialize()
+	}
+
+	// the DTS extractor needs parameters in order to work
+	withParams := filtered
+	if randomAccess {
+		withParams = append([][]byte{m.sps, m.pps}, filtered...)
+	}
+
+	dts, err := m.dtsExtractor.Extract(withParams, pts)
+	if err != nil {
+		return err
+	}
+
+	ts := m.timestamp(mediatime.TimestampToDuration(dts, m.VideoFormat.ClockRate()))
+
+	if !m.videoHeaderSent || paramsChanged {
+		err = m.writeData(TagTypeVideo, ts, VideoData{
+			IsSequenceHeader: true,
+			SPS:              m.sps,
+			PPS:              m.pps,
+		})
+		if err != nil {
+			return err
+		}
+		m.videoHeaderSent = true
+	}
+
+	return m.writeData(TagTypeVideo, ts, VideoData{
+		KeyFrame:        randomAccess,
+		CompositionTime: int32(mediatime.TimestampToDuration(pts-dts, m.VideoFormat.ClockRate()) / time.Millisecond),
+		AU:              filtered,
+	})
+}
+
+// WriteMPEG4Audio writes MPEG-4 Audio access units.
+func (m *Muxer) WriteMPEG4Audio(pts int64, aus [][]byte) error {
+	if m.AudioFormat == nil {
+		return fmt.Errorf("audio format not provided")
+	}
+
+	for i, au := range aus {
+		auPTS := pts + int64(i)*mpeg4audio.SamplesPerAccessUnit
+		ts := m.timestamp(mediatime.TimestampToDuration(auPTS, m.AudioFormat.ClockRate()))
+
+		err := m.writeData(TagTypeAudio, ts, AudioData{
+			AU: au,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timestamp converts a duration into a FLV timestamp,
+// relative to the first written access unit.
+func (m *Muxer) timestamp(d time.Duration) uint32 {
+	if !m.startSet {
+		m.startSet = true
+		m.start = d
+	}
+
+	d -= m.start
+	if d < 0 {
+		return 0
+	}
+
+	return uint32(d / time.Millisecond)
+}
+
+func (m *Muxer) writeData(typ TagType, ts uint32, data interface{ Marshal() ([]byte, error) }) error {
+	buf, err := data.Marshal()
+	if err != nil {
+		return err
+	}
+
+	buf, err = Tag{
+		Type:      typ,
+		Timestamp: ts,
+		Data:      buf,
+	}.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = m.W.Write(buf)
+	return err
+}
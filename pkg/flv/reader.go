@@ -0,0 +1,63 @@
+package flv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Reader reads tags from a FLV stream.
+// Content of audio and video tags can be decoded with AudioData and VideoData.
+type Reader struct {
+	// source of the stream.
+	R io.Reader
+
+	// whether the stream contains audio (filled by Initialize).
+	HasAudio bool
+
+	// whether the stream contains video (filled by Initialize).
+	HasVideo bool
+}
+
+// Initialize initializes Reader and reads the FLV header.
+func (r *Reader) Initialize() error {
+	if r.R == nil {
+		return fmt.Errorf("R not provided")
+	}
+
+	var header [9]byte
+	_, err := io.ReadFull(r.R, header[:])
+	if err != nil {
+		return err
+	}
+
+	if header[0] != 'F' || header[1] != 'L' || header[2] != 'V' {
+		return fmt.Errorf("invalid FLV signature")
+	}
+
+	if header[3] != 1 {
+		return fmt.Errorf("unsupported FLV version: %d", header[3])
+	}
+
+	r.HasAudio = (header[4] & headerFlagAudio) != 0
+	r.HasVideo = (header[4] & headerFlagVideo) != 0
+
+	offset := binary.BigEndian.Uint32(header[5:])
+	if offset < 9 {
+		return fmt.Errorf("invalid header size: %d", offset)
+	}
+
+	// skip the remaining part of the header and the first previous tag size
+	_, err = io.CopyN(io.Discard, r.R, int64(offset-9)+4)
+	return err
+}
+
+// Read reads a tag.
+func (r *Reader) Read() (*Tag, error) {
+	var tag Tag
+	err := tag.Read(r.R)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
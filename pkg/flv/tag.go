@@ -0,0 +1,87 @@
+// Package flv contains utilities to convert H264 and MPEG-4 Audio frames to and from FLV tags,
+// that are used by RTMP.
+package flv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	tagHeaderSize = 11
+)
+
+// TagType is the type of a tag.
+type TagType uint8
+
+// tag types.
+const (
+	TagTypeAudio      TagType = 8
+	TagTypeVideo      TagType = 9
+	TagTypeScriptData TagType = 18
+)
+
+// Tag is a FLV tag.
+// Specification: Adobe Flash Video File Format Specification v10.1, annex E.4.1
+type Tag struct {
+	Type TagType
+
+	// timestamp in milliseconds.
+	Timestamp uint32
+
+	Data []byte
+}
+
+// Marshal encodes a tag, followed by its size.
+func (t Tag) Marshal() ([]byte, error) {
+	if len(t.Data) > 0xFFFFFF {
+		return nil, fmt.Errorf("tag data is too big")
+	}
+
+	buf := make([]byte, tagHeaderSize+len(t.Data)+4)
+	buf[0] = byte(t.Type)
+	buf[1] = byte(len(t.Data) >> 16)
+	buf[2] = byte(len(t.Data) >> 8)
+	buf[3] = byte(len(t.Data))
+	buf[4] = byte(t.Timestamp >> 16)
+	buf[5] = byte(t.Timestamp >> 8)
+	buf[6] = byte(t.Timestamp)
+	buf[7] = byte(t.Timestamp >> 24)
+	// stream ID is always zero
+	copy(buf[tagHeaderSize:], t.Data)
+	binary.BigEndian.PutUint32(buf[tagHeaderSize+len(t.Data):], uint32(tagHeaderSize+len(t.Data)))
+
+	return buf, nil
+}
+
+// Read reads a tag, followed by its size.
+func (t *Tag) Read(r io.Reader) error {
+	var header [tagHeaderSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return err
+	}
+
+	if (header[0] & 0x20) != 0 {
+		return fmt.Errorf("encrypted tags are not supported")
+	}
+
+	t.Type = TagType(header[0] & 0x1F)
+	size := uint32(header[1])<<16 | uint32(header[2])<<8 | uint32(header[3])
+	t.Timestamp = uint32(header[7])<<24 | uint32(header[4])<<16 | uint32(header[5])<<8 | uint32(header[6])
+
+	t.Data = make([]byte, size+4)
+	_, err = io.ReadFull(r, t.Data)
+	if err != nil {
+		return err
+	}
+
+	if binary.BigEndian.Uint32(t.Data[size:]) != tagHeaderSize+size {
+		return fmt.Errorf("invalid previous tag size")
+	}
+
+	t.Data = t.Data[:size]
+
+	return nil
+}
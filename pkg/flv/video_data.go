@@ -0,0 +1,169 @@
+package flv
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+)
+
+const (
+	videoFrameTypeKey   = 1
+	videoFrameTypeInter = 2
+	videoCodecIDAVC     = 7
+
+	avcPacketTypeSequenceHeader = 0
+	avcPacketTypeNALU           = 1
+)
+
+// VideoData is the content of a video tag that contains H264.
+// Specification: Adobe Flash Video File Format Specification v10.1, annex E.4.3
+type VideoData struct {
+	// whether the tag is a AVC sequence header, that contains SPS and PPS.
+	IsSequenceHeader bool
+
+	// whether the tag contains a key frame.
+	KeyFrame bool
+
+	// difference between PTS and DTS, in milliseconds.
+	CompositionTime int32
+
+	// SPS and PPS (sequence headers only).
+	SPS []byte
+	PPS []byte
+
+	// access unit (non-sequence headers only).
+	AU [][]byte
+}
+
+// Marshal encodes a VideoData.
+func (d VideoData) Marshal() ([]byte, error) {
+	frameType := byte(videoFrameTypeInter)
+	if d.KeyFrame || d.IsSequenceHeader {
+		frameType = videoFrameTypeKey
+	}
+
+	buf := []byte{frameType<<4 | videoCodecIDAVC}
+
+	if d.IsSequenceHeader {
+		if len(d.SPS) < 4 || len(d.PPS) == 0 {
+			return nil, fmt.Errorf("invalid SPS or PPS")
+		}
+
+		buf = append(buf, avcPacketTypeSequenceHeader, 0, 0, 0)
+
+		// AVCDecoderConfigurationRecord
+		// Specification: ISO 14496-15, section 5.3.3.1
+		buf = append(buf, 1, d.SPS[1], d.SPS[2], d.SPS[3], 0xFC|3, 0xE0|1)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(d.SPS)))
+		buf = append(buf, d.SPS...)
+		buf = append(buf, 1)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(d.PPS)))
+		buf = append(buf, d.PPS...)
+
+		return buf, nil
+	}
+
+	ct := uint32(d.CompositionTime)
+	buf = append(buf, avcPacketTypeNALU, byte(ct>>16), byte(ct>>8), byte(ct))
+
+	avcc, err := h264.AVCC(d.AU).Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(buf, avcc...), nil
+}
+
+// Unmarshal decodes a VideoData.
+func (d *VideoData) Unmarshal(buf []byte) error {
+	if len(buf) < 5 {
+		return fmt.Errorf("video data is too short")
+	}
+
+	if (buf[0] & 0x0F) != videoCodecIDAVC {
+		return fmt.Errorf("unsupported video codec: %d", buf[0]&0x0F)
+	}
+
+	d.KeyFrame = (buf[0] >> 4) == videoFrameTypeKey
+
+	// sign-extend the 24-bit composition time
+	d.CompositionTime = int32(uint32(buf[2])<<24|uint32(buf[3])<<16|uint32(buf[4])<<8) >> 8
+
+	switch buf[1] {
+	case avcPacketTypeSequenceHeader:
+		d.IsSequenceHeader = true
+		d.AU = nil
+		return d.unmarshalConfig(buf[5:])
+
+	case avcPacketTypeNALU:
+		d.IsSequenceHeader = false
+		d.SPS = nil
+		d.PPS = nil
+
+		var avcc h264.AVCC
+		err := avcc.Unmarshal(buf[5:])
+		if err != nil {
+			return err
+		}
+		d.AU = avcc
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported AVC packet type: %d", buf[1])
+	}
+}
+
+func (d *VideoData) unmarshalConfig(buf []byte) error {
+	if len(buf) < 6 {
+		return fmt.Errorf("AVC configuration is too short")
+	}
+
+	if buf[0] != 1 {
+		return fmt.Errorf("unsupported AVC configuration version: %d", buf[0])
+	}
+
+	pos := 5
+	var err error
+
+	if (buf[pos] & 0x1F) == 0 {
+		return fmt.Errorf("SPS not found")
+	}
+	pos++
+
+	d.SPS, pos, err = readParameterSet(buf, pos)
+	if err != nil {
+		return err
+	}
+
+	// skip additional SPS
+	for i := 1; i < int(buf[5]&0x1F); i++ {
+		_, pos, err = readParameterSet(buf, pos)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(buf) <= pos || buf[pos] == 0 {
+		return fmt.Errorf("PPS not found")
+	}
+	pos++
+
+	d.PPS, _, err = readParameterSet(buf, pos)
+	return err
+}
+
+func readParameterSet(buf []byte, pos int) ([]byte, int, error) {
+	if len(buf) < pos+2 {
+		return nil, 0, fmt.Errorf("AVC configuration is too short")
+	}
+
+	le := int(binary.BigEndian.Uint16(buf[pos:]))
+	pos += 2
+
+	if len(buf) < pos+le {
+		return nil, 0, fmt.Errorf("AVC configuration is too short")
+	}
+
+	return buf[pos : pos+le], pos + le, nil
+}
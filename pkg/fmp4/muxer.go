@@ -0,0 +1,266 @@
+// Package fmp4 contains a fragmented MP4 (CMAF) muxer.
+package fmp4
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	mcfmp4 "github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4/seekablebuffer"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+)
+
+// Muxer is a fragmented MP4 (CMAF) muxer.
+// It takes access units with their timestamps and produces
+// an initialization segment and media segments.
+//
+// Timestamps are expressed in clock rate units of each track and
+// must share the same origin (like the ones returned by Client.PacketPTS()).
+// Segments start with a random access sample of the leading track,
+// that is the first video track, or the first track if there are no video tracks.
+type Muxer struct {
+	// tracks.
+	Tracks []*Track
+
+	// minimum duration of segments.
+	// It defaults to 1 second.
+	SegmentMinDuration time.Duration
+
+	// called when a media segment is ready.
+	OnSegment func(seg []byte) error
+
+	leader             *Track
+	started            bool
+	segmentStartDTS    int64
+	nextSequenceNumber uint32
+}
+
+// Initialize initializes Muxer.
+func (m *Muxer) Initialize() error {
+	if len(m.Tracks) == 0 {
+		return fmt.Errorf("no tracks provided")
+	}
+
+	if m.OnSegment == nil {
+		return fmt.Errorf("OnSegment not provided")
+	}
+
+	if m.SegmentMinDuration == 0 {
+		m.SegmentMinDuration = 1 * time.Second
+	}
+
+	for i, track := range m.Tracks {
+		err := track.initialize(i + 1)
+		if err != nil {
+			return err
+		}
+
+		if m.leader == nil && track.isVideo {
+			m.leader = track
+		}
+	}
+
+	if m.leader == nil {
+		m.leader = m.Tracks[0]
+	}
+
+	m.nextSequenceNumber = 1
+
+	return nil
+}
+
+// Init returns the initialization segment.
+// Parameters of video tracks must be available, either from the format
+// or from access units written previously.
+func (m *Muxer) Init() ([]byte, error) {
+	init := mcfmp4.Init{}
+
+	for _, track := range m.Tracks {
+		codec, err := track.codec()
+		if err != nil {
+			return nil, err
+		}
+
+		init.Tracks = append(init.Tracks, &mcfmp4.InitTrack{
+			ID:        track.id,
+			TimeScale: uint32(track.clockRate),
+			Codec:     codec,
+		})
+	}
+
+	var buf seekablebuffer.Buffer
+	err := init.Marshal(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteH264 writes a H264 access unit.
+func (m *Muxer) WriteH264(track *Track, pts int64, dts int64, au [][]byte) error {
+	au = removeEmptyNALUs(au)
+	if len(au) == 0 {
+		return nil
+	}
+
+	track.updateH264Params(au)
+
+	var sample mcfmp4.Sample
+	err := sample.FillH264(int32(pts-dts), au)
+	if err != nil {
+		return err
+	}
+
+	return m.writeVideo(track, dts, &sample, h264.IsRandomAccess(au))
+}
+
+// WriteH265 writes a H265 access unit.
+func (m *Muxer) WriteH265(track *Track, pts int64, dts int64, au [][]byte) error {
+	au = removeEmptyNALUs(au)
+	if len(au) == 0 {
+		return nil
+	}
+
+	track.updateH265Params(au)
+
+	var sample mcfmp4.Sample
+	err := sample.FillH265(int32(pts-dts), au)
+	if err != nil {
+		return err
+	}
+
+	return m.writeVideo(track, dts, &sample, h265.IsRandomAccess(au))
+}
+
+// WriteMPEG4Audio writes MPEG-4 Audio access units.
+func (m *Muxer) WriteMPEG4Audio(track *Track, pts int64, aus [][]byte) error {
+	for i, au := range aus {
+		dts := pts + int64(i)*mpeg4audio.SamplesPerAccessUnit
+		sample := &mcfmp4.Sample{
+			Duration: mpeg4audio.SamplesPerAccessUnit,
+			Payload:  au,
+		}
+
+		if track == m.leader {
+			err := m.writeLeader(track, dts, sample, true)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if m.checkStart(track, dts) {
+			track.push(dts, sample)
+		}
+	}
+
+	return nil
+}
+
+func (m *Muxer) writeVideo(track *Track, dts int64, sample *mcfmp4.Sample, randomAccess bool) error {
+	if track == m.leader {
+		return m.writeLeader(track, dts, sample, randomAccess)
+	}
+
+	if m.checkStart(track, dts) {
+		track.pushPending(dts, sample)
+	}
+
+	return nil
+}
+
+// checkStart checks whether a sample of a non-leading track can be added.
+func (m *Muxer) checkStart(track *Track, dts int64) bool {
+	// wait for the leading track
+	if !m.started {
+		return false
+	}
+
+	if !track.started {
+		track.started = true
+		track.startDTS = mediatime.DurationToTimestamp(
+			mediatime.TimestampToDuration(m.leader.startDTS, m.leader.clockRate), track.clockRate)
+	}
+
+	// discard samples before the start of the muxer
+	return dts >= track.startDTS
+}
+
+func (m *Muxer) writeLeader(track *Track, dts int64, sample *mcfmp4.Sample, randomAccess bool) error {
+	if !m.started {
+		// wait for a random access sample
+		if !randomAccess {
+			return nil
+		}
+
+		m.started = true
+		m.segmentStartDTS = dts
+		track.started = true
+		track.startDTS = dts
+	}
+
+	if track.isVideo {
+		track.pushPending(dts, sample)
+	} else {
+		track.push(dts, sample)
+	}
+
+	if randomAccess &&
+		mediatime.TimestampToDuration(dts-m.segmentStartDTS, track.clockRate) >= m.SegmentMinDuration {
+		// the sample that starts the new segment must not be part of the current one
+		var err error
+		if track.isVideo {
+			err = m.writeSegment()
+		} else {
+			last := track.samples[len(track.samples)-1]
+			track.samples = track.samples[:len(track.samples)-1]
+			err = m.writeSegment()
+			track.push(dts, last)
+		}
+		if err != nil {
+			return err
+		}
+
+		m.segmentStartDTS = dts
+	}
+
+	return nil
+}
+
+func (m *Muxer) writeSegment() error {
+	part := mcfmp4.Part{
+		SequenceNumber: m.nextSequenceNumber,
+	}
+
+	for _, track := range m.Tracks {
+		pt := track.popSamples()
+		if pt != nil {
+			part.Tracks = append(part.Tracks, pt)
+		}
+	}
+
+	if part.Tracks == nil {
+		return nil
+	}
+
+	m.nextSequenceNumber++
+
+	var buf seekablebuffer.Buffer
+	err := part.Marshal(&buf)
+	if err != nil {
+		return err
+	}
+
+	return m.OnSegment(buf.Bytes())
+}
+
+// Flush writes all the buffered samples into a segment.
+// Samples whose duration is still unknown are not written.
+func (m *Muxer) Flush() error {
+	return m.writeSegment()
+}
@@ -0,0 +1,196 @@
+package fmp4
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	mcfmp4 "github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+func TestMuxer(t *testing.T) {
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+	}
+
+	audioTrack := &Track{
+		Format: &format.MPEG4Audio{
+			PayloadTyp: 97,
+			Config: &mpeg4audio.AudioSpecificConfig{
+				Type:         2,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		},
+	}
+
+	var segments [][]byte
+
+	m := &Muxer{
+		Tracks:             []*Track{audioTrack, videoTrack},
+		SegmentMinDuration: 1 * time.Second,
+		OnSegment: func(seg []byte) error {
+			segments = append(segments, seg)
+			return nil
+		},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	_, err = m.Init()
+	require.EqualError(t, err, "H264 parameters are missing")
+
+	// audio before the first IDR is discarded
+	err = m.WriteMPEG4Audio(audioTrack, 0, [][]byte{{1, 2}})
+	require.NoError(t, err)
+
+	// non-IDR before the first IDR is discarded
+	err = m.WriteH264(videoTrack, 0, 0, [][]byte{{1}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(videoTrack, 90000, 90000, [][]byte{testSPS, testPPS, {5}})
+	require.NoError(t, err)
+
+	err = m.WriteMPEG4Audio(audioTrack, 44100, [][]byte{{3, 4}, {5, 6}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(videoTrack, 135000, 135000, [][]byte{{1}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(videoTrack, 180000, 180000, [][]byte{{5}})
+	require.NoError(t, err)
+
+	err = m.WriteMPEG4Audio(audioTrack, 88200, [][]byte{{7, 8}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(videoTrack, 225000, 225000, [][]byte{{1}})
+	require.NoError(t, err)
+
+	err = m.Flush()
+	require.NoError(t, err)
+
+	byts, err := m.Init()
+	require.NoError(t, err)
+
+	var init mcfmp4.Init
+	err = init.Unmarshal(bytes.NewReader(byts))
+	require.NoError(t, err)
+	require.Len(t, init.Tracks, 2)
+	require.Equal(t, uint32(44100), init.Tracks[0].TimeScale)
+	require.Equal(t, uint32(90000), init.Tracks[1].TimeScale)
+
+	require.Len(t, segments, 2)
+
+	var parts mcfmp4.Parts
+	err = parts.Unmarshal(segments[0])
+	require.NoError(t, err)
+	require.Equal(t, mcfmp4.Parts{{
+		SequenceNumber: 1,
+		Tracks: []*mcfmp4.PartTrack{
+			{
+				ID:       1,
+				BaseTime: 0,
+				Samples: []*mcfmp4.Sample{
+					{Duration: 1024, Payload: []byte{3, 4}},
+					{Duration: 1024, Payload: []byte{5, 6}},
+				},
+			},
+			{
+				ID:       2,
+				BaseTime: 0,
+				Samples: []*mcfmp4.Sample{
+					{
+						Duration: 45000,
+						Payload: append(append(append(
+							[]byte{0, 0, 0, byte(len(testSPS))}, testSPS...),
+							0, 0, 0, 4), append(testPPS, 0, 0, 0, 1, 5)...),
+					},
+					{
+						Duration:        45000,
+						IsNonSyncSample: true,
+						Payload:         []byte{0, 0, 0, 1, 1},
+					},
+				},
+			},
+		},
+	}}, parts)
+
+	parts = nil
+	err = parts.Unmarshal(segments[1])
+	require.NoError(t, err)
+	require.Equal(t, mcfmp4.Parts{{
+		SequenceNumber: 2,
+		Tracks: []*mcfmp4.PartTrack{
+			{
+				ID:       1,
+				BaseTime: 44100,
+				Samples: []*mcfmp4.Sample{
+					{Duration: 1024, Payload: []byte{7, 8}},
+				},
+			},
+			{
+				ID:       2,
+				BaseTime: 90000,
+				Samples: []*mcfmp4.Sample{
+					{Duration: 45000, Payload: []byte{0, 0, 0, 1, 5}},
+				},
+			},
+		},
+	}}, parts)
+}
+
+func TestMuxerEmptyNALUs(t *testing.T) {
+	h264Track := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+	}
+
+	h265Track := &Track{
+		Format: &format.H265{
+			PayloadTyp: 97,
+		},
+	}
+
+	m := &Muxer{
+		Tracks:             []*Track{h264Track, h265Track},
+		SegmentMinDuration: 1 * time.Second,
+		OnSegment: func([]byte) error {
+			return nil
+		},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	err = m.WriteH264(h264Track, 0, 0, [][]byte{{}})
+	require.NoError(t, err)
+
+	err = m.WriteH265(h265Track, 0, 0, [][]byte{{}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(h264Track, 90000, 90000, [][]byte{testSPS, {}, testPPS, {5}})
+	require.NoError(t, err)
+
+	require.Equal(t, testSPS, h264Track.sps)
+	require.Equal(t, testPPS, h264Track.pps)
+}
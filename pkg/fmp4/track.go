@@ -0,0 +1,157 @@
+package fmp4
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	mcfmp4 "github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/mp4"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// Track is a track of a Muxer.
+type Track struct {
+	// format of the track.
+	// Supported formats are H264, H265 and MPEG-4 Audio.
+	Format format.Format
+
+	id        int
+	clockRate int
+	isVideo   bool
+	vps       []byte
+	sps       []byte
+	pps       []byte
+
+	started    bool
+	startDTS   int64
+	samples    []*mcfmp4.Sample
+	baseTime   uint64
+	pending    *mcfmp4.Sample
+	pendingDTS int64
+}
+
+func (t *Track) initialize(id int) error {
+	t.id = id
+	t.clockRate = t.Format.ClockRate()
+
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		t.isVideo = true
+		t.sps, t.pps = forma.SafeParams()
+
+	case *format.H265:
+		t.isVideo = true
+		t.vps, t.sps, t.pps = forma.SafeParams()
+
+	case *format.MPEG4Audio:
+		if forma.Config == nil {
+			return fmt.Errorf("MPEG-4 Audio config is missing")
+		}
+
+	default:
+		return fmt.Errorf("unsupported format: %T", t.Format)
+	}
+
+	return nil
+}
+
+func (t *Track) codec() (mp4.Codec, error) {
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		if t.sps == nil || t.pps == nil {
+			return nil, fmt.Errorf("H264 parameters are missing")
+		}
+		return &mp4.CodecH264{SPS: t.sps, PPS: t.pps}, nil
+
+	case *format.H265:
+		if t.vps == nil || t.sps == nil || t.pps == nil {
+			return nil, fmt.Errorf("H265 parameters are missing")
+		}
+		return &mp4.CodecH265{VPS: t.vps, SPS: t.sps, PPS: t.pps}, nil
+
+	default: // *format.MPEG4Audio
+		return &mp4.CodecMPEG4Audio{Config: *forma.(*format.MPEG4Audio).Config}, nil
+	}
+}
+
+// removeEmptyNALUs removes zero-length NALUs, that can't be parsed.
+func removeEmptyNALUs(au [][]byte) [][]byte {
+	n := 0
+	for _, nalu := range au {
+		if len(nalu) != 0 {
+			n++
+		}
+	}
+
+	if n == len(au) {
+		return au
+	}
+
+	filtered := make([][]byte, 0, n)
+	for _, nalu := range au {
+		if len(nalu) != 0 {
+			filtered = append(filtered, nalu)
+		}
+	}
+	return filtered
+}
+
+func (t *Track) updateH264Params(au [][]byte) {
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			t.sps = nalu
+		case h264.NALUTypePPS:
+			t.pps = nalu
+		}
+	}
+}
+
+func (t *Track) updateH265Params(au [][]byte) {
+	for _, nalu := range au {
+		switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+		case h265.NALUType_VPS_NUT:
+			t.vps = nalu
+		case h265.NALUType_SPS_NUT:
+			t.sps = nalu
+		case h265.NALUType_PPS_NUT:
+			t.pps = nalu
+		}
+	}
+}
+
+// push adds a sample whose duration is known.
+func (t *Track) push(dts int64, sample *mcfmp4.Sample) {
+	if t.samples == nil {
+		t.baseTime = uint64(dts - t.startDTS)
+	}
+	t.samples = append(t.samples, sample)
+}
+
+// pushPending adds a sample whose duration is not known yet,
+// completing the previous one.
+func (t *Track) pushPending(dts int64, sample *mcfmp4.Sample) {
+	if t.pending != nil {
+		t.pending.Duration = uint32(dts - t.pendingDTS)
+		t.push(t.pendingDTS, t.pending)
+	}
+
+	t.pending = sample
+	t.pendingDTS = dts
+}
+
+func (t *Track) popSamples() *mcfmp4.PartTrack {
+	if t.samples == nil {
+		return nil
+	}
+
+	pt := &mcfmp4.PartTrack{
+		ID:       t.id,
+		BaseTime: t.baseTime,
+		Samples:  t.samples,
+	}
+	t.samples = nil
+	return pt
+}
@@ -70,6 +70,12 @@ func (f *AC3) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *AC3) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *AC3) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -92,6 +92,15 @@ func (f *AV1) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *AV1) Clone() Format {
+	c := *f
+	c.LevelIdx = clonePtr(f.LevelIdx)
+	c.Profile = clonePtr(f.Profile)
+	c.Tier = clonePtr(f.Tier)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *AV1) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
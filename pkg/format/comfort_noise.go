@@ -0,0 +1,97 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpsimpleaudio"
+)
+
+// ComfortNoise is the RTP format for comfort noise.
+// Specification: RFC3389
+type ComfortNoise struct {
+	PayloadTyp uint8
+	ClockRat   int
+}
+
+func (f *ComfortNoise) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	if ctx.payloadType == 13 {
+		f.ClockRat = 8000
+		return nil
+	}
+
+	clockRate, err := strconv.ParseUint(ctx.clock, 10, 31)
+	if err != nil || clockRate == 0 {
+		return fmt.Errorf("invalid clock rate: '%s'", ctx.clock)
+	}
+	f.ClockRat = int(clockRate)
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *ComfortNoise) Codec() string {
+	return "CN"
+}
+
+// ClockRate implements Format.
+func (f *ComfortNoise) ClockRate() int {
+	return f.ClockRat
+}
+
+// PayloadType implements Format.
+func (f *ComfortNoise) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *ComfortNoise) RTPMap() string {
+	return "CN/" + strconv.FormatInt(int64(f.ClockRat), 10)
+}
+
+// FMTP implements Format.
+func (f *ComfortNoise) FMTP() map[string]string {
+	return nil
+}
+
+// Clone implements Format.
+func (f *ComfortNoise) Clone() Format {
+	c := *f
+	return &c
+}
+
+// PTSEqualsDTS implements Format.
+func (f *ComfortNoise) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+// Each frame contains the noise level and optional spectral information.
+func (f *ComfortNoise) CreateDecoder() (*rtpsimpleaudio.Decoder, error) {
+	d := &rtpsimpleaudio.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *ComfortNoise) CreateEncoder() (*rtpsimpleaudio.Encoder, error) {
+	e := &rtpsimpleaudio.Encoder{
+		PayloadType: f.PayloadTyp,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,39 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComfortNoiseAttributes(t *testing.T) {
+	format := &ComfortNoise{
+		PayloadTyp: 13,
+		ClockRat:   8000,
+	}
+	require.Equal(t, "CN", format.Codec())
+	require.Equal(t, 8000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestComfortNoiseDecEncoder(t *testing.T) {
+	format := &ComfortNoise{
+		PayloadTyp: 13,
+		ClockRat:   8000,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkt, err := enc.Encode([]byte{0x40})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkt.PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkt)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x40}, byts)
+}
@@ -0,0 +1,134 @@
+package format
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpfragmented"
+)
+
+// FLAC is the RTP format for the FLAC codec.
+// There's no standard specification of FLAC over RTP. This format follows
+// the one used by existing servers, in which every RTP payload contains a FLAC frame,
+// that can be fragmented into multiple packets, and the metadata blocks
+// are transmitted in the "configuration" parameter.
+type FLAC struct {
+	PayloadTyp   uint8
+	SampleRate   int
+	ChannelCount int
+
+	// "fLaC" marker followed by metadata blocks (optional).
+	Configuration []byte
+}
+
+func (f *FLAC) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	tmp := strings.SplitN(ctx.clock, "/", 2)
+	if len(tmp) != 2 {
+		return fmt.Errorf("invalid clock (%v)", ctx.clock)
+	}
+
+	sampleRate, err := strconv.ParseUint(tmp[0], 10, 31)
+	if err != nil || sampleRate == 0 {
+		return fmt.Errorf("invalid sample rate: '%s'", tmp[0])
+	}
+	f.SampleRate = int(sampleRate)
+
+	channelCount, err := strconv.ParseUint(tmp[1], 10, 31)
+	if err != nil || channelCount == 0 {
+		return fmt.Errorf("invalid channel count: '%s'", tmp[1])
+	}
+	f.ChannelCount = int(channelCount)
+
+	for key, val := range ctx.fmtp {
+		if key == "configuration" {
+			var conf []byte
+			conf, err = base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				return fmt.Errorf("invalid config: %v", val)
+			}
+
+			f.Configuration = conf
+		}
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *FLAC) Codec() string {
+	return "FLAC"
+}
+
+// ClockRate implements Format.
+func (f *FLAC) ClockRate() int {
+	return f.SampleRate
+}
+
+// PayloadType implements Format.
+func (f *FLAC) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *FLAC) RTPMap() string {
+	return "FLAC/" + strconv.FormatInt(int64(f.SampleRate), 10) +
+		"/" + strconv.FormatInt(int64(f.ChannelCount), 10)
+}
+
+// FMTP implements Format.
+func (f *FLAC) FMTP() map[string]string {
+	if f.Configuration == nil {
+		return nil
+	}
+
+	fmtp := map[string]string{
+		"configuration": base64.StdEncoding.EncodeToString(f.Configuration),
+	}
+
+	return fmtp
+}
+
+// Clone implements Format.
+func (f *FLAC) Clone() Format {
+	c := *f
+	c.Configuration = bytes.Clone(f.Configuration)
+	return &c
+}
+
+// PTSEqualsDTS implements Format.
+func (f *FLAC) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *FLAC) CreateDecoder() (*rtpfragmented.Decoder, error) {
+	d := &rtpfragmented.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *FLAC) CreateEncoder() (*rtpfragmented.Encoder, error) {
+	e := &rtpfragmented.Encoder{
+		PayloadType: f.PayloadTyp,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
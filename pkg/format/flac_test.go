@@ -0,0 +1,41 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFLACAttributes(t *testing.T) {
+	format := &FLAC{
+		PayloadTyp:   96,
+		SampleRate:   44100,
+		ChannelCount: 2,
+	}
+	require.Equal(t, "FLAC", format.Codec())
+	require.Equal(t, 44100, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestFLACDecEncoder(t *testing.T) {
+	format := &FLAC{
+		PayloadTyp:   96,
+		SampleRate:   44100,
+		ChannelCount: 2,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([]byte{0xff, 0xf8, 0x01, 0x02})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xff, 0xf8, 0x01, 0x02}, byts)
+}
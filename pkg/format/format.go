@@ -53,6 +53,14 @@ func getCodecAndClock(rtpMap string) (string, string) {
 	return strings.ToLower(parts2[0]), parts2[1]
 }
 
+func clonePtr[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
 func decodeFMTP(enc string) map[string]string {
 	if enc == "" {
 		return nil
@@ -68,7 +76,10 @@ func decodeFMTP(enc string) map[string]string {
 		}
 
 		tmp := strings.SplitN(kv, "=", 2)
+
+		// parameters without a value are flags (for instance, "interlace" of RFC4175)
 		if len(tmp) != 2 {
+			ret[strings.ToLower(tmp[0])] = ""
 			continue
 		}
 
@@ -109,6 +120,11 @@ type Format interface {
 
 	// PTSEqualsDTS checks whether PTS is equal to DTS in RTP packets.
 	PTSEqualsDTS(*rtp.Packet) bool
+
+	// Clone returns a deep copy of the format.
+	// The copy is not affected by subsequent changes of codec parameters of the original format,
+	// therefore it can be used as a snapshot by routines that must not be influenced by them.
+	Clone() Format
 }
 
 // Unmarshal decodes a format from a media description.
@@ -152,6 +168,9 @@ func Unmarshal(md *psdp.MediaDescription, payloadTypeStr string) (Format, error)
 		case codec == "mp4v-es" && clock == "90000" && payloadType >= 96 && payloadType <= 127:
 			return &MPEG4Video{}
 
+		case codec == "raw" && clock == "90000" && payloadType >= 96 && payloadType <= 127:
+			return &RawVideo{}
+
 		// audio
 
 		case codec == "opus", codec == "multiopus" && payloadType >= 96 && payloadType <= 127:
@@ -160,7 +179,11 @@ func Unmarshal(md *psdp.MediaDescription, payloadTypeStr string) (Format, error)
 		case codec == "vorbis" && payloadType >= 96 && payloadType <= 127:
 			return &Vorbis{}
 
-		case codec == "mpeg4-generic" && payloadType >= 96 && payloadType <= 127:
+		case codec == "flac" && payloadType >= 96 && payloadType <= 127:
+			return &FLAC{}
+
+		case codec == "mpeg4-generic" && payloadType >= 96 && payloadType <= 127 &&
+			isMPEG4AudioAACMode(fmtp["mode"]):
 			return &MPEG4Audio{}
 
 		case codec == "mp4a-latm" && payloadType >= 96 && payloadType <= 127:
@@ -182,9 +205,15 @@ func Unmarshal(md *psdp.MediaDescription, payloadTypeStr string) (Format, error)
 			codec == "aal2-g726-40") && clock == "8000" && payloadType >= 96 && payloadType <= 127:
 			return &G726{}
 
-		case codec == "pcma", codec == "pcmu" && payloadType >= 96 && payloadType <= 127:
+		case (codec == "pcma" || codec == "pcmu") && payloadType >= 96 && payloadType <= 127:
 			return &G711{}
 
+		case codec == "telephone-event" && payloadType >= 96 && payloadType <= 127:
+			return &TelephoneEvent{}
+
+		case codec == "cn" && payloadType >= 96 && payloadType <= 127:
+			return &ComfortNoise{}
+
 		case codec == "l8", codec == "l16", codec == "l24" && payloadType >= 96 && payloadType <= 127:
 			return &LPCM{}
 
@@ -219,6 +248,9 @@ func Unmarshal(md *psdp.MediaDescription, payloadTypeStr string) (Format, error)
 		case payloadType == 10, payloadType == 11:
 			return &LPCM{}
 
+		case payloadType == 13:
+			return &ComfortNoise{}
+
 		// other
 
 		case payloadType == 33:
@@ -92,6 +92,48 @@ var casesFormat = []struct {
 		"G722/8000",
 		nil,
 	},
+	{
+		"audio telephone-event",
+		"v=0\n" +
+			"s=\n" +
+			"m=audio 0 RTP/AVP 101\n" +
+			"a=rtpmap:101 telephone-event/8000\n" +
+			"a=fmtp:101 0-15\n",
+		&TelephoneEvent{
+			PayloadTyp: 101,
+			ClockRat:   8000,
+		},
+		101,
+		"telephone-event/8000",
+		nil,
+	},
+	{
+		"audio comfort noise static",
+		"v=0\n" +
+			"s=\n" +
+			"m=audio 0 RTP/AVP 13\n",
+		&ComfortNoise{
+			PayloadTyp: 13,
+			ClockRat:   8000,
+		},
+		13,
+		"CN/8000",
+		nil,
+	},
+	{
+		"audio comfort noise dynamic",
+		"v=0\n" +
+			"s=\n" +
+			"m=audio 0 RTP/AVP 105\n" +
+			"a=rtpmap:105 CN/16000\n",
+		&ComfortNoise{
+			PayloadTyp: 105,
+			ClockRat:   16000,
+		},
+		105,
+		"CN/16000",
+		nil,
+	},
 	{
 		"audio g726 le 1",
 		"v=0\n" +
@@ -359,6 +401,81 @@ var casesFormat = []struct {
 			"sizelength":       "13",
 		},
 	},
+	{
+		"audio aac lbr with all fields",
+		"v=0\n" +
+			"s=\n" +
+			"m=audio 0 RTP/AVP 96\n" +
+			"a=rtpmap:96 mpeg4-generic/48000/2\n" +
+			"a=fmtp:96 streamtype=5; profile-level-id=14; mode=AAC-lbr; " +
+			"config=1190; SizeLength=6; IndexLength=2; IndexDeltaLength=2; " +
+			"CTSDeltaLength=4; DTSDeltaLength=4; RandomAccessIndication=1; " +
+			"StreamStateIndication=2; AuxiliaryDataSizeLength=8\n",
+		&MPEG4Audio{
+			PayloadTyp:     96,
+			Mode:           "AAC-lbr",
+			ProfileLevelID: 14,
+			Config: &mpeg4audio.AudioSpecificConfig{
+				Type:         mpeg4audio.ObjectTypeAACLC,
+				SampleRate:   48000,
+				ChannelCount: 2,
+			},
+			SizeLength:              6,
+			IndexLength:             2,
+			IndexDeltaLength:        2,
+			CTSDeltaLength:          4,
+			DTSDeltaLength:          4,
+			RandomAccessIndication:  true,
+			StreamStateIndication:   2,
+			AuxiliaryDataSizeLength: 8,
+		},
+		96,
+		"mpeg4-generic/48000/2",
+		map[string]string{
+			"streamtype":              "5",
+			"profile-level-id":        "14",
+			"mode":                    "AAC-lbr",
+			"config":                  "1190",
+			"sizelength":              "6",
+			"indexlength":             "2",
+			"indexdeltalength":        "2",
+			"ctsdeltalength":          "4",
+			"dtsdeltalength":          "4",
+			"randomaccessindication":  "1",
+			"streamstateindication":   "2",
+			"auxiliarydatasizelength": "8",
+		},
+	},
+	{
+		"audio mpeg-4 celp",
+		"v=0\n" +
+			"s=\n" +
+			"m=audio 0 RTP/AVP 96\n" +
+			"a=rtpmap:96 mpeg4-generic/8000/1\n" +
+			"a=fmtp:96 streamtype=5; profile-level-id=14; mode=CELP-cbr; " +
+			"config=440E00; constantSize=24\n",
+		&Generic{
+			PayloadTyp: 96,
+			RTPMa:      "mpeg4-generic/8000/1",
+			FMT: map[string]string{
+				"streamtype":       "5",
+				"profile-level-id": "14",
+				"mode":             "CELP-cbr",
+				"config":           "440E00",
+				"constantsize":     "24",
+			},
+			ClockRat: 8000,
+		},
+		96,
+		"mpeg4-generic/8000/1",
+		map[string]string{
+			"streamtype":       "5",
+			"profile-level-id": "14",
+			"mode":             "CELP-cbr",
+			"config":           "440E00",
+			"constantsize":     "24",
+		},
+	},
 	{
 		"audio aac he-aac v2 ps",
 		"v=0\n" +
@@ -629,6 +746,40 @@ var casesFormat = []struct {
 			"configuration": "AQIDBA==",
 		},
 	},
+	{
+		"audio flac",
+		"v=0\n" +
+			"s=\n" +
+			"m=audio 0 RTP/AVP 96\n" +
+			"a=rtpmap:96 FLAC/44100/2\n" +
+			"a=fmtp:96 configuration=ZkxhQw==\n",
+		&FLAC{
+			PayloadTyp:    96,
+			SampleRate:    44100,
+			ChannelCount:  2,
+			Configuration: []byte("fLaC"),
+		},
+		96,
+		"FLAC/44100/2",
+		map[string]string{
+			"configuration": "ZkxhQw==",
+		},
+	},
+	{
+		"audio flac without configuration",
+		"v=0\n" +
+			"s=\n" +
+			"m=audio 0 RTP/AVP 96\n" +
+			"a=rtpmap:96 FLAC/48000/1\n",
+		&FLAC{
+			PayloadTyp:   96,
+			SampleRate:   48000,
+			ChannelCount: 1,
+		},
+		96,
+		"FLAC/48000/1",
+		nil,
+	},
 	{
 		"audio opus",
 		"v=0\n" +
@@ -754,6 +905,36 @@ var casesFormat = []struct {
 				"D8AEE053C04641443000001B24C61766335382E3133342E313030",
 		},
 	},
+	{
+		"video raw",
+		"v=0\n" +
+			"s=\n" +
+			"m=video 0 RTP/AVP 96\n" +
+			"a=rtpmap:96 raw/90000\n" +
+			"a=fmtp:96 sampling=YCbCr-4:2:2; width=1920; height=1080; exactframerate=30000/1001; " +
+			"depth=10; colorimetry=BT709-2; interlace\n",
+		&RawVideo{
+			PayloadTyp:     96,
+			Sampling:       "YCbCr-4:2:2",
+			Depth:          10,
+			Width:          1920,
+			Height:         1080,
+			Colorimetry:    "BT709-2",
+			Interlaced:     true,
+			ExactFrameRate: "30000/1001",
+		},
+		96,
+		"raw/90000",
+		map[string]string{
+			"sampling":       "YCbCr-4:2:2",
+			"depth":          "10",
+			"width":          "1920",
+			"height":         "1080",
+			"colorimetry":    "BT709-2",
+			"interlace":      "1",
+			"exactframerate": "30000/1001",
+		},
+	},
 	{
 		"video h264",
 		"v=0\n" +
@@ -1264,6 +1445,32 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestClone(t *testing.T) {
+	for _, ca := range casesFormat {
+		t.Run(ca.name, func(t *testing.T) {
+			c := ca.dec.Clone()
+			require.Equal(t, ca.dec, c)
+			require.NotSame(t, ca.dec, c)
+		})
+	}
+}
+
+func TestCloneIndependentParams(t *testing.T) {
+	forma := &H264{
+		PayloadTyp: 96,
+		SPS:        []byte{0x01, 0x02},
+		PPS:        []byte{0x03, 0x04},
+	}
+
+	c := forma.Clone().(*H264)
+	forma.SafeSetParams([]byte{0x05, 0x06}, []byte{0x07, 0x08})
+	forma.SPS[0] = 0x09
+
+	sps, pps := c.SafeParams()
+	require.Equal(t, []byte{0x01, 0x02}, sps)
+	require.Equal(t, []byte{0x03, 0x04}, pps)
+}
+
 func FuzzUnmarshal(f *testing.F) {
 	for _, ca := range casesFormat {
 		f.Add(ca.in)
@@ -1308,6 +1515,9 @@ func FuzzUnmarshal(f *testing.F) {
 
 		case *Vorbis:
 			require.NotZero(t, f.ChannelCount)
+
+		case *FLAC:
+			require.NotZero(t, f.ChannelCount)
 		}
 	})
 }
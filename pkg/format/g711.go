@@ -13,7 +13,11 @@ import (
 // G711 is the RTP format for the G711 codec, encoded with mu-law or A-law.
 // Specification: RFC3551
 type G711 struct {
-	PayloadTyp   uint8
+	// payload type of packets.
+	// Static payload types (0 for mu-law, 8 for A-law) imply a sample rate of 8000Hz and a single channel.
+	// Other sample rates and channel counts require a dynamic payload type (96-127).
+	PayloadTyp uint8
+
 	MULaw        bool
 	SampleRate   int
 	ChannelCount int
@@ -98,6 +102,12 @@ func (f *G711) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *G711) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *G711) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -74,3 +74,28 @@ func TestG711DecEncoder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
 }
+
+func TestG711DecEncoderMultiChannel(t *testing.T) {
+	format := &G711{
+		PayloadTyp:   96,
+		MULaw:        true,
+		SampleRate:   16000,
+		ChannelCount: 2,
+	}
+
+	require.Equal(t, "PCMU/16000/2", format.RTPMap())
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([]byte{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, byts)
+}
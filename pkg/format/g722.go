@@ -44,6 +44,12 @@ func (f *G722) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *G722) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *G722) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
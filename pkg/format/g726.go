@@ -67,6 +67,12 @@ func (f *G726) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *G726) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *G726) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
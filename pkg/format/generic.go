@@ -2,6 +2,7 @@ package format
 
 import (
 	"fmt"
+	"maps"
 	"strconv"
 	"strings"
 
@@ -105,6 +106,13 @@ func (f *Generic) FMTP() map[string]string {
 	return f.FMT
 }
 
+// Clone implements Format.
+func (f *Generic) Clone() Format {
+	c := *f
+	c.FMT = maps.Clone(f.FMT)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *Generic) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
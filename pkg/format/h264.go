@@ -121,6 +121,19 @@ func (f *H264) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *H264) Clone() Format {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return &H264{
+		PayloadTyp:        f.PayloadTyp,
+		SPS:               bytes.Clone(f.SPS),
+		PPS:               bytes.Clone(f.PPS),
+		PacketizationMode: f.PacketizationMode,
+	}
+}
+
 // PTSEqualsDTS implements Format.
 func (f *H264) PTSEqualsDTS(pkt *rtp.Packet) bool {
 	if len(pkt.Payload) == 0 {
@@ -225,3 +238,54 @@ func (f *H264) SafeParams() ([]byte, []byte) {
 	defer f.mutex.RUnlock()
 	return f.SPS, f.PPS
 }
+
+func (f *H264) parsedSPS() (*h264.SPS, bool) {
+	sps, _ := f.SafeParams()
+	if sps == nil {
+		return nil, false
+	}
+
+	var spsp h264.SPS
+	err := spsp.Unmarshal(sps)
+	if err != nil {
+		return nil, false
+	}
+
+	return &spsp, true
+}
+
+// Width returns the width of the video, extracted from the SPS.
+// It returns zero when the SPS is not available or invalid.
+func (f *H264) Width() int {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.Width()
+	}
+	return 0
+}
+
+// Height returns the height of the video, extracted from the SPS.
+// It returns zero when the SPS is not available or invalid.
+func (f *H264) Height() int {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.Height()
+	}
+	return 0
+}
+
+// FrameRate returns the frame rate of the video, extracted from the timing informations of the SPS.
+// It returns zero when the SPS or timing informations are not available.
+func (f *H264) FrameRate() float64 {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.FPS()
+	}
+	return 0
+}
+
+// Profile returns the profile of the video (profile_idc), extracted from the SPS.
+// It returns zero when the SPS is not available or invalid.
+func (f *H264) Profile() uint8 {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.ProfileIdc
+	}
+	return 0
+}
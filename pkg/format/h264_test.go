@@ -28,6 +28,28 @@ func TestH264Attributes(t *testing.T) {
 	require.Equal(t, []byte{0x09, 0x0A}, pps)
 }
 
+func TestH264VideoParams(t *testing.T) {
+	format := &H264{
+		PayloadTyp: 96,
+		SPS: []byte{
+			0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+			0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+			0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+			0x20,
+		},
+	}
+	require.Equal(t, 1920, format.Width())
+	require.Equal(t, 1080, format.Height())
+	require.Equal(t, float64(30), format.FrameRate())
+	require.Equal(t, uint8(66), format.Profile())
+
+	format = &H264{PayloadTyp: 96}
+	require.Equal(t, 0, format.Width())
+	require.Equal(t, 0, format.Height())
+	require.Equal(t, float64(0), format.FrameRate())
+	require.Equal(t, uint8(0), format.Profile())
+}
+
 func TestH264PTSEqualsDTS(t *testing.T) {
 	format := &H264{
 		PayloadTyp:        96,
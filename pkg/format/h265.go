@@ -126,6 +126,20 @@ func (f *H265) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *H265) Clone() Format {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return &H265{
+		PayloadTyp: f.PayloadTyp,
+		VPS:        bytes.Clone(f.VPS),
+		SPS:        bytes.Clone(f.SPS),
+		PPS:        bytes.Clone(f.PPS),
+		MaxDONDiff: f.MaxDONDiff,
+	}
+}
+
 // PTSEqualsDTS implements Format.
 func (f *H265) PTSEqualsDTS(pkt *rtp.Packet) bool {
 	if len(pkt.Payload) == 0 {
@@ -238,3 +252,54 @@ func (f *H265) SafeParams() ([]byte, []byte, []byte) {
 	defer f.mutex.RUnlock()
 	return f.VPS, f.SPS, f.PPS
 }
+
+func (f *H265) parsedSPS() (*h265.SPS, bool) {
+	_, sps, _ := f.SafeParams()
+	if sps == nil {
+		return nil, false
+	}
+
+	var spsp h265.SPS
+	err := spsp.Unmarshal(sps)
+	if err != nil {
+		return nil, false
+	}
+
+	return &spsp, true
+}
+
+// Width returns the width of the video, extracted from the SPS.
+// It returns zero when the SPS is not available or invalid.
+func (f *H265) Width() int {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.Width()
+	}
+	return 0
+}
+
+// Height returns the height of the video, extracted from the SPS.
+// It returns zero when the SPS is not available or invalid.
+func (f *H265) Height() int {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.Height()
+	}
+	return 0
+}
+
+// FrameRate returns the frame rate of the video, extracted from the timing informations of the SPS.
+// It returns zero when the SPS or timing informations are not available.
+func (f *H265) FrameRate() float64 {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.FPS()
+	}
+	return 0
+}
+
+// Profile returns the profile of the video (general_profile_idc), extracted from the SPS.
+// It returns zero when the SPS is not available or invalid.
+func (f *H265) Profile() uint8 {
+	if spsp, ok := f.parsedSPS(); ok {
+		return spsp.ProfileTierLevel.GeneralProfileIdc
+	}
+	return 0
+}
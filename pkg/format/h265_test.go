@@ -31,6 +31,27 @@ func TestH265Attributes(t *testing.T) {
 	require.Equal(t, []byte{0x0B, 0x0C}, pps)
 }
 
+func TestH265VideoParams(t *testing.T) {
+	format := &H265{
+		PayloadTyp: 96,
+		SPS: []byte{
+			0x42, 0x01, 0x01, 0x01, 0x60, 0x00, 0x00, 0x03,
+			0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00, 0x00,
+			0x03, 0x00, 0x96, 0xa0, 0x05, 0xa2, 0x01, 0xe1,
+			0x63, 0x6b, 0x92, 0x4c, 0x9a, 0xe5, 0x99,
+		},
+	}
+	require.Equal(t, 720, format.Width())
+	require.Equal(t, 480, format.Height())
+	require.Equal(t, float64(0), format.FrameRate())
+	require.Equal(t, uint8(1), format.Profile())
+
+	format = &H265{PayloadTyp: 96}
+	require.Equal(t, 0, format.Width())
+	require.Equal(t, 0, format.Height())
+	require.Equal(t, uint8(0), format.Profile())
+}
+
 func TestH265PTSEqualsDTS(t *testing.T) {
 	format := &H265{
 		PayloadTyp: 96,
@@ -42,6 +42,12 @@ func (f *KLV) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *KLV) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *KLV) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
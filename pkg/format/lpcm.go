@@ -107,6 +107,12 @@ func (f *LPCM) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *LPCM) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *LPCM) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -44,6 +44,12 @@ func (f *MJPEG) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *MJPEG) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *MJPEG) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
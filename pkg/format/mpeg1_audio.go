@@ -44,6 +44,12 @@ func (f *MPEG1Audio) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *MPEG1Audio) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *MPEG1Audio) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -44,6 +44,12 @@ func (f *MPEG1Video) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *MPEG1Video) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *MPEG1Video) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -18,11 +18,45 @@ type MPEG4Audio struct {
 	// payload type of packets.
 	PayloadTyp uint8
 
-	ProfileLevelID   int
-	Config           *mpeg4audio.AudioSpecificConfig
-	SizeLength       int
-	IndexLength      int
-	IndexDeltaLength int
+	// mode (optional).
+	// It defaults to AAC-hbr.
+	Mode string
+
+	ProfileLevelID          int
+	Config                  *mpeg4audio.AudioSpecificConfig
+	SizeLength              int
+	IndexLength             int
+	IndexDeltaLength        int
+	CTSDeltaLength          int
+	DTSDeltaLength          int
+	RandomAccessIndication  bool
+	StreamStateIndication   int
+	AuxiliaryDataSizeLength int
+}
+
+// isMPEG4AudioAACMode returns whether a RFC3640 mode carries AAC, that can be decoded by MPEG4Audio.
+// Other modes (CELP-cbr, CELP-vbr, generic) are handled by Generic.
+func isMPEG4AudioAACMode(mode string) bool {
+	switch strings.ToLower(mode) {
+	case "", "aac-hbr", "aac_hbr", "aac-lbr", "aac_lbr":
+		return true
+	}
+	return false
+}
+
+func parseMPEG4AudioFieldLength(fmtp map[string]string, key string, name string, dest *int) error {
+	val, ok := fmtp[key]
+	if !ok {
+		return nil
+	}
+
+	n, err := strconv.ParseUint(val, 10, 31)
+	if err != nil || n > 100 {
+		return fmt.Errorf("invalid AAC %s: %v", name, val)
+	}
+
+	*dest = int(n)
+	return nil
 }
 
 func (f *MPEG4Audio) unmarshal(ctx *unmarshalContext) error {
@@ -36,10 +70,14 @@ func (f *MPEG4Audio) unmarshal(ctx *unmarshalContext) error {
 			}
 
 		case "mode":
-			if strings.ToLower(val) != "aac-hbr" && strings.ToLower(val) != "aac_hbr" {
+			if !isMPEG4AudioAACMode(val) {
 				return fmt.Errorf("unsupported AAC mode: %v", val)
 			}
 
+			if l := strings.ToLower(val); l != "aac-hbr" && l != "aac_hbr" {
+				f.Mode = val
+			}
+
 		case "profile-level-id":
 			tmp, err := strconv.ParseUint(val, 10, 31)
 			if err != nil {
@@ -80,6 +118,25 @@ func (f *MPEG4Audio) unmarshal(ctx *unmarshalContext) error {
 				return fmt.Errorf("invalid AAC IndexDeltaLength: %v", val)
 			}
 			f.IndexDeltaLength = int(n)
+
+		case "randomaccessindication":
+			f.RandomAccessIndication = (val == "1")
+		}
+	}
+
+	for _, field := range []struct {
+		key  string
+		name string
+		dest *int
+	}{
+		{"ctsdeltalength", "CTSDeltaLength", &f.CTSDeltaLength},
+		{"dtsdeltalength", "DTSDeltaLength", &f.DTSDeltaLength},
+		{"streamstateindication", "StreamStateIndication", &f.StreamStateIndication},
+		{"auxiliarydatasizelength", "AuxiliaryDataSizeLength", &f.AuxiliaryDataSizeLength},
+	} {
+		err := parseMPEG4AudioFieldLength(ctx.fmtp, field.key, field.name, field.dest)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -137,9 +194,14 @@ func (f *MPEG4Audio) FMTP() map[string]string {
 		profileLevelID = 1
 	}
 
+	mode := f.Mode
+	if mode == "" {
+		mode = "AAC-hbr"
+	}
+
 	fmtp := map[string]string{
 		"streamtype":       "5",
-		"mode":             "AAC-hbr",
+		"mode":             mode,
 		"profile-level-id": strconv.FormatInt(int64(profileLevelID), 10),
 	}
 
@@ -155,11 +217,38 @@ func (f *MPEG4Audio) FMTP() map[string]string {
 		fmtp["indexdeltalength"] = strconv.FormatInt(int64(f.IndexDeltaLength), 10)
 	}
 
+	if f.CTSDeltaLength > 0 {
+		fmtp["ctsdeltalength"] = strconv.FormatInt(int64(f.CTSDeltaLength), 10)
+	}
+
+	if f.DTSDeltaLength > 0 {
+		fmtp["dtsdeltalength"] = strconv.FormatInt(int64(f.DTSDeltaLength), 10)
+	}
+
+	if f.RandomAccessIndication {
+		fmtp["randomaccessindication"] = "1"
+	}
+
+	if f.StreamStateIndication > 0 {
+		fmtp["streamstateindication"] = strconv.FormatInt(int64(f.StreamStateIndication), 10)
+	}
+
+	if f.AuxiliaryDataSizeLength > 0 {
+		fmtp["auxiliarydatasizelength"] = strconv.FormatInt(int64(f.AuxiliaryDataSizeLength), 10)
+	}
+
 	fmtp["config"] = hex.EncodeToString(enc)
 
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *MPEG4Audio) Clone() Format {
+	c := *f
+	c.Config = clonePtr(f.Config)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *MPEG4Audio) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -168,9 +257,14 @@ func (f *MPEG4Audio) PTSEqualsDTS(*rtp.Packet) bool {
 // CreateDecoder creates a decoder able to decode the content of the format.
 func (f *MPEG4Audio) CreateDecoder() (*rtpmpeg4audio.Decoder, error) {
 	d := &rtpmpeg4audio.Decoder{
-		SizeLength:       f.SizeLength,
-		IndexLength:      f.IndexLength,
-		IndexDeltaLength: f.IndexDeltaLength,
+		SizeLength:              f.SizeLength,
+		IndexLength:             f.IndexLength,
+		IndexDeltaLength:        f.IndexDeltaLength,
+		CTSDeltaLength:          f.CTSDeltaLength,
+		DTSDeltaLength:          f.DTSDeltaLength,
+		RandomAccessIndication:  f.RandomAccessIndication,
+		StreamStateIndication:   f.StreamStateIndication,
+		AuxiliaryDataSizeLength: f.AuxiliaryDataSizeLength,
 	}
 
 	err := d.Init()
@@ -184,10 +278,14 @@ func (f *MPEG4Audio) CreateDecoder() (*rtpmpeg4audio.Decoder, error) {
 // CreateEncoder creates an encoder able to encode the content of the format.
 func (f *MPEG4Audio) CreateEncoder() (*rtpmpeg4audio.Encoder, error) {
 	e := &rtpmpeg4audio.Encoder{
-		PayloadType:      f.PayloadTyp,
-		SizeLength:       f.SizeLength,
-		IndexLength:      f.IndexLength,
-		IndexDeltaLength: f.IndexDeltaLength,
+		PayloadType:            f.PayloadTyp,
+		SizeLength:             f.SizeLength,
+		IndexLength:            f.IndexLength,
+		IndexDeltaLength:       f.IndexDeltaLength,
+		CTSDeltaLength:         f.CTSDeltaLength,
+		DTSDeltaLength:         f.DTSDeltaLength,
+		RandomAccessIndication: f.RandomAccessIndication,
+		StreamStateIndication:  f.StreamStateIndication,
 	}
 
 	err := e.Init()
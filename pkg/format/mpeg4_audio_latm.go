@@ -191,6 +191,15 @@ func (f *MPEG4AudioLATM) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *MPEG4AudioLATM) Clone() Format {
+	c := *f
+	c.Bitrate = clonePtr(f.Bitrate)
+	c.StreamMuxConfig = clonePtr(f.StreamMuxConfig)
+	c.SBREnabled = clonePtr(f.SBREnabled)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *MPEG4AudioLATM) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
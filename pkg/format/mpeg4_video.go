@@ -1,6 +1,7 @@
 package format
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"strconv"
@@ -87,6 +88,18 @@ func (f *MPEG4Video) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *MPEG4Video) Clone() Format {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return &MPEG4Video{
+		PayloadTyp:     f.PayloadTyp,
+		ProfileLevelID: f.ProfileLevelID,
+		Config:         bytes.Clone(f.Config),
+	}
+}
+
 // PTSEqualsDTS implements Format.
 func (f *MPEG4Video) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -42,6 +42,12 @@ func (f *MPEGTS) FMTP() map[string]string {
 	return nil
 }
 
+// Clone implements Format.
+func (f *MPEGTS) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *MPEGTS) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -160,6 +160,12 @@ func (f *Opus) FMTP() map[string]string {
 	}
 }
 
+// Clone implements Format.
+func (f *Opus) Clone() Format {
+	c := *f
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *Opus) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
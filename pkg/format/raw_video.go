@@ -0,0 +1,181 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtprawvideo"
+)
+
+// RawVideo is the RTP format for uncompressed video.
+// Specification: RFC4175
+type RawVideo struct {
+	PayloadTyp uint8
+
+	// color subsampling (for instance, "YCbCr-4:2:2").
+	Sampling string
+
+	// number of bits per sample.
+	Depth int
+
+	Width  int
+	Height int
+
+	// colorimetry (for instance, "BT709-2") (optional).
+	Colorimetry string
+
+	// whether the video is interlaced.
+	Interlaced bool
+
+	// frame rate, in the form "numerator" or "numerator/denominator" (optional).
+	ExactFrameRate string
+}
+
+func (f *RawVideo) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	for key, val := range ctx.fmtp {
+		switch key {
+		case "sampling":
+			f.Sampling = val
+
+		case "depth":
+			n, err := strconv.ParseUint(val, 10, 31)
+			if err != nil || n == 0 {
+				return fmt.Errorf("invalid depth: %v", val)
+			}
+			f.Depth = int(n)
+
+		case "width":
+			n, err := strconv.ParseUint(val, 10, 31)
+			if err != nil || n == 0 {
+				return fmt.Errorf("invalid width: %v", val)
+			}
+			f.Width = int(n)
+
+		case "height":
+			n, err := strconv.ParseUint(val, 10, 31)
+			if err != nil || n == 0 {
+				return fmt.Errorf("invalid height: %v", val)
+			}
+			f.Height = int(n)
+
+		case "colorimetry":
+			f.Colorimetry = val
+
+		case "interlace":
+			f.Interlaced = (val != "0")
+
+		case "exactframerate":
+			f.ExactFrameRate = val
+		}
+	}
+
+	if f.Sampling == "" {
+		return fmt.Errorf("sampling is missing")
+	}
+
+	if f.Depth == 0 {
+		return fmt.Errorf("depth is missing")
+	}
+
+	if f.Width == 0 || f.Height == 0 {
+		return fmt.Errorf("width or height is missing")
+	}
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *RawVideo) Codec() string {
+	return "Raw video"
+}
+
+// ClockRate implements Format.
+func (f *RawVideo) ClockRate() int {
+	return 90000
+}
+
+// PayloadType implements Format.
+func (f *RawVideo) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *RawVideo) RTPMap() string {
+	return "raw/90000"
+}
+
+// FMTP implements Format.
+func (f *RawVideo) FMTP() map[string]string {
+	fmtp := map[string]string{
+		"sampling": f.Sampling,
+		"depth":    strconv.FormatInt(int64(f.Depth), 10),
+		"width":    strconv.FormatInt(int64(f.Width), 10),
+		"height":   strconv.FormatInt(int64(f.Height), 10),
+	}
+
+	if f.Colorimetry != "" {
+		fmtp["colorimetry"] = f.Colorimetry
+	}
+
+	if f.Interlaced {
+		fmtp["interlace"] = "1"
+	}
+
+	if f.ExactFrameRate != "" {
+		fmtp["exactframerate"] = f.ExactFrameRate
+	}
+
+	return fmtp
+}
+
+// Clone implements Format.
+func (f *RawVideo) Clone() Format {
+	c := *f
+	return &c
+}
+
+// PTSEqualsDTS implements Format.
+func (f *RawVideo) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *RawVideo) CreateDecoder() (*rtprawvideo.Decoder, error) {
+	d := &rtprawvideo.Decoder{
+		Sampling:   f.Sampling,
+		Depth:      f.Depth,
+		Width:      f.Width,
+		Height:     f.Height,
+		Interlaced: f.Interlaced,
+	}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *RawVideo) CreateEncoder() (*rtprawvideo.Encoder, error) {
+	e := &rtprawvideo.Encoder{
+		PayloadType: f.PayloadTyp,
+		Sampling:    f.Sampling,
+		Depth:       f.Depth,
+		Width:       f.Width,
+		Height:      f.Height,
+		Interlaced:  f.Interlaced,
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,55 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawVideoAttributes(t *testing.T) {
+	format := &RawVideo{
+		PayloadTyp: 96,
+		Sampling:   "YCbCr-4:2:2",
+		Depth:      10,
+		Width:      1920,
+		Height:     1080,
+	}
+	require.Equal(t, "Raw video", format.Codec())
+	require.Equal(t, 90000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestRawVideoDecEncoder(t *testing.T) {
+	format := &RawVideo{
+		PayloadTyp: 96,
+		Sampling:   "YCbCr-4:2:2",
+		Depth:      8,
+		Width:      64,
+		Height:     32,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	frame := make([]byte, 64*32*2)
+	for i := range frame {
+		frame[i] = byte(i)
+	}
+
+	pkts, err := enc.Encode(frame)
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	var byts []byte
+
+	for _, pkt := range pkts {
+		byts, err = dec.Decode(pkt)
+	}
+
+	require.NoError(t, err)
+	require.Equal(t, frame, byts)
+}
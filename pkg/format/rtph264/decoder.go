@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/pion/rtp"
 
@@ -20,6 +22,39 @@ var ErrMorePacketsNeeded = errors.New("need more packets")
 var ErrNonStartingPacketAndNoPrevious = errors.New(
 	"received a non-starting fragment without any previous starting fragment")
 
+// ErrNALUSizeTooBig is returned when the size of a NALU exceeds the maximum allowed.
+type ErrNALUSizeTooBig struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrNALUSizeTooBig) Error() string {
+	return fmt.Sprintf("NALU size (%d) is too big, maximum is %d", e.Size, e.Max)
+}
+
+// ErrAccessUnitSizeTooBig is returned when the size of an access unit exceeds the maximum allowed.
+type ErrAccessUnitSizeTooBig struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrAccessUnitSizeTooBig) Error() string {
+	return fmt.Sprintf("access unit size (%d) is too big, maximum is %d", e.Size, e.Max)
+}
+
+// ErrNALUCountExceeded is returned when the number of NALUs of an access unit exceeds the maximum allowed.
+type ErrNALUCountExceeded struct {
+	Count int
+	Max   int
+}
+
+// Error implements the error interface.
+func (e ErrNALUCountExceeded) Error() string {
+	return fmt.Sprintf("NALU count (%d) exceeds maximum allowed (%d)", e.Count, e.Max)
+}
+
 func joinFragments(fragments [][]byte, size int) []byte {
 	ret := make([]byte, size)
 	n := 0
@@ -50,26 +85,65 @@ func auSize(au [][]byte) int {
 // Specification: RFC6184
 type Decoder struct {
 	// indicates the packetization mode.
+	// In interleaved mode (2), NALUs of each access unit are sorted by decoding order number (DON).
 	PacketizationMode int
 
+	// maximum size of a NALU (optional).
+	// It defaults to h264.MaxAccessUnitSize.
+	MaxNALUSize int
+
+	// maximum size of an access unit (optional).
+	// It defaults to h264.MaxAccessUnitSize.
+	MaxAccessUnitSize int
+
+	// maximum number of NALUs of an access unit (optional).
+	// It defaults to h264.MaxNALUsPerAccessUnit.
+	MaxNALUsPerAccessUnit int
+
+	// maximum time an access unit can stay in the buffer (optional).
+	// When exceeded, the access unit is returned without waiting for the marker bit
+	// or for a timestamp change. This bounds buffering of streams whose encoder never sets the marker bit.
+	// It defaults to zero, that means that there's no limit.
+	MaxLatency time.Duration
+
+	timeNow             func() time.Time
 	firstPacketReceived bool
 	fragments           [][]byte
 	fragmentsSize       int
 	fragmentNextSeqNum  uint16
+	fragmentsHasDON     bool
+	fragmentsDON        uint16
 	annexBMode          bool
+	prevDON             uint16
 
 	// for Decode()
 	frameBuffer          [][]byte
 	frameBufferLen       int
 	frameBufferSize      int
 	frameBufferTimestamp uint32
+	frameBufferStart     time.Time
+	frameBufferDONs      []uint16
 }
 
 // Init initializes the decoder.
 func (d *Decoder) Init() error {
-	if d.PacketizationMode >= 2 {
-		return fmt.Errorf("PacketizationMode >= 2 is not supported")
+	if d.PacketizationMode > 2 {
+		return fmt.Errorf("PacketizationMode > 2 is not supported")
+	}
+
+	if d.MaxNALUSize == 0 {
+		d.MaxNALUSize = h264.MaxAccessUnitSize
+	}
+	if d.MaxAccessUnitSize == 0 {
+		d.MaxAccessUnitSize = h264.MaxAccessUnitSize
+	}
+	if d.MaxNALUsPerAccessUnit == 0 {
+		d.MaxNALUsPerAccessUnit = h264.MaxNALUsPerAccessUnit
+	}
+	if d.timeNow == nil {
+		d.timeNow = time.Now
 	}
+
 	return nil
 }
 
@@ -78,31 +152,134 @@ func (d *Decoder) resetFragments() {
 	d.fragmentsSize = 0
 }
 
-func (d *Decoder) decodeNALUs(pkt *rtp.Packet) ([][]byte, error) {
+func decodeAggregationUnits(typ h264.NALUType, payload []byte) ([][]byte, error) {
+	var nalus [][]byte
+
+	for {
+		if len(payload) < 2 {
+			return nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+		}
+
+		size := uint16(payload[0])<<8 | uint16(payload[1])
+		payload = payload[2:]
+
+		if size == 0 {
+			// discard padding
+			if isAllZero(payload) {
+				break
+			}
+
+			return nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+		}
+
+		if int(size) > len(payload) {
+			return nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+		}
+
+		nalus = append(nalus, payload[:size])
+		payload = payload[size:]
+
+		if len(payload) == 0 {
+			break
+		}
+	}
+
+	if nalus == nil {
+		return nil, fmt.Errorf("%v packet doesn't contain any NALU", typ)
+	}
+
+	return nalus, nil
+}
+
+func decodeMultiTimeAggregationUnits(typ h264.NALUType, payload []byte) ([][]byte, []uint16, error) {
+	tsOffsetLen := 2
+	if typ == h264.NALUTypeMTAP24 {
+		tsOffsetLen = 3
+	}
+
+	if len(payload) < 2 {
+		return nil, nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+	}
+
+	donb := uint16(payload[0])<<8 | uint16(payload[1])
+	payload = payload[2:]
+
+	var nalus [][]byte
+	var dons []uint16
+
+	for {
+		if len(payload) < 2 {
+			return nil, nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+		}
+
+		size := int(uint16(payload[0])<<8 | uint16(payload[1]))
+		payload = payload[2:]
+
+		// DOND + TS offset + NALU
+		if size < (1+tsOffsetLen+1) || size > len(payload) {
+			return nil, nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
+		}
+
+		nalus = append(nalus, payload[1+tsOffsetLen:size])
+		dons = append(dons, donb+uint16(payload[0]))
+		payload = payload[size:]
+
+		if len(payload) == 0 {
+			break
+		}
+	}
+
+	return nalus, dons, nil
+}
+
+func (d *Decoder) fragmentsDONs() []uint16 {
+	if !d.fragmentsHasDON {
+		return nil
+	}
+	return []uint16{d.fragmentsDON}
+}
+
+func (d *Decoder) decodeNALUs(pkt *rtp.Packet) ([][]byte, []uint16, error) {
 	if len(pkt.Payload) < 1 {
 		d.resetFragments()
-		return nil, fmt.Errorf("payload is too short")
+		return nil, nil, fmt.Errorf("payload is too short")
 	}
 
 	typ := h264.NALUType(pkt.Payload[0] & 0x1F)
 	var nalus [][]byte
+	var dons []uint16
 
 	switch typ {
-	case h264.NALUTypeFUA:
-		if len(pkt.Payload) < 2 {
-			return nil, fmt.Errorf("invalid FU-A packet (invalid size)")
+	case h264.NALUTypeFUA, h264.NALUTypeFUB:
+		// FU-B is the first fragment of a NALU in interleaved mode, and contains the DON.
+		headerLen := 2
+		if typ == h264.NALUTypeFUB {
+			headerLen = 4
+		}
+
+		if len(pkt.Payload) < headerLen {
+			return nil, nil, fmt.Errorf("invalid %v packet (invalid size)", typ)
 		}
 
 		start := pkt.Payload[1] >> 7
 		end := (pkt.Payload[1] >> 6) & 0x01
 
+		if typ == h264.NALUTypeFUB && start != 1 {
+			d.resetFragments()
+			return nil, nil, fmt.Errorf("invalid FU-B packet (non-starting)")
+		}
+
 		if start == 1 {
 			d.resetFragments()
 
 			nri := (pkt.Payload[0] >> 5) & 0x03
 			typ := pkt.Payload[1] & 0x1F
-			d.fragmentsSize = len(pkt.Payload[1:])
-			d.fragments = append(d.fragments, []byte{(nri << 5) | typ}, pkt.Payload[2:])
+			d.fragmentsSize = 1 + len(pkt.Payload[headerLen:])
+			d.fragments = append(d.fragments, []byte{(nri << 5) | typ}, pkt.Payload[headerLen:])
+			d.fragmentsHasDON = (headerLen == 4)
+			if d.fragmentsHasDON {
+				d.fragmentsDON = uint16(pkt.Payload[2])<<8 | uint16(pkt.Payload[3])
+			}
 			d.fragmentNextSeqNum = pkt.SequenceNumber + 1
 			d.firstPacketReceived = true
 
@@ -116,90 +293,89 @@ func (d *Decoder) decodeNALUs(pkt *rtp.Packet) ([][]byte, error) {
 			// emit one fragmented NAL unit for sufficiently small P-frames.
 			if end != 0 {
 				nalus = [][]byte{joinFragments(d.fragments, d.fragmentsSize)}
+				dons = d.fragmentsDONs()
 				d.resetFragments()
 				break
 			}
 
-			return nil, ErrMorePacketsNeeded
+			return nil, nil, ErrMorePacketsNeeded
 		}
 
 		if d.fragmentsSize == 0 {
 			if !d.firstPacketReceived {
-				return nil, ErrNonStartingPacketAndNoPrevious
+				return nil, nil, ErrNonStartingPacketAndNoPrevious
 			}
 
-			return nil, fmt.Errorf("invalid FU-A packet (non-starting)")
+			return nil, nil, fmt.Errorf("invalid FU-A packet (non-starting)")
 		}
 
 		if pkt.SequenceNumber != d.fragmentNextSeqNum {
 			d.resetFragments()
-			return nil, fmt.Errorf("discarding frame since a RTP packet is missing")
+			return nil, nil, fmt.Errorf("discarding frame since a RTP packet is missing")
 		}
 
 		d.fragmentsSize += len(pkt.Payload[2:])
 
-		if d.fragmentsSize > h264.MaxAccessUnitSize {
+		if d.fragmentsSize > d.MaxNALUSize {
 			errSize := d.fragmentsSize
 			d.resetFragments()
-			return nil, fmt.Errorf("NALU size (%d) is too big, maximum is %d",
-				errSize, h264.MaxAccessUnitSize)
+			return nil, nil, ErrNALUSizeTooBig{Size: errSize, Max: d.MaxNALUSize}
 		}
 
 		d.fragments = append(d.fragments, pkt.Payload[2:])
 		d.fragmentNextSeqNum++
 
 		if end != 1 {
-			return nil, ErrMorePacketsNeeded
+			return nil, nil, ErrMorePacketsNeeded
 		}
 
 		nalus = [][]byte{joinFragments(d.fragments, d.fragmentsSize)}
+		dons = d.fragmentsDONs()
 		d.resetFragments()
 
 	case h264.NALUTypeSTAPA:
 		d.resetFragments()
 
-		payload := pkt.Payload[1:]
-
-		for {
-			if len(payload) < 2 {
-				return nil, fmt.Errorf("invalid STAP-A packet (invalid size)")
-			}
-
-			size := uint16(payload[0])<<8 | uint16(payload[1])
-			payload = payload[2:]
-
-			if size == 0 {
-				// discard padding
-				if isAllZero(payload) {
-					break
-				}
+		var err error
+		nalus, err = decodeAggregationUnits(typ, pkt.Payload[1:])
+		if err != nil {
+			return nil, nil, err
+		}
 
-				return nil, fmt.Errorf("invalid STAP-A packet (invalid size)")
-			}
+		d.firstPacketReceived = true
 
-			if int(size) > len(payload) {
-				return nil, fmt.Errorf("invalid STAP-A packet (invalid size)")
-			}
+	case h264.NALUTypeSTAPB:
+		d.resetFragments()
 
-			nalus = append(nalus, payload[:size])
-			payload = payload[size:]
+		if len(pkt.Payload) < 3 {
+			return nil, nil, fmt.Errorf("invalid STAP-B packet (invalid size)")
+		}
 
-			if len(payload) == 0 {
-				break
-			}
+		var err error
+		nalus, err = decodeAggregationUnits(typ, pkt.Payload[3:])
+		if err != nil {
+			return nil, nil, err
 		}
 
-		if nalus == nil {
-			return nil, fmt.Errorf("STAP-A packet doesn't contain any NALU")
+		// NALUs of a STAP-B have consecutive DONs
+		don := uint16(pkt.Payload[1])<<8 | uint16(pkt.Payload[2])
+		dons = make([]uint16, len(nalus))
+		for i := range nalus {
+			dons[i] = don + uint16(i)
 		}
 
 		d.firstPacketReceived = true
 
-	case h264.NALUTypeSTAPB, h264.NALUTypeMTAP16,
-		h264.NALUTypeMTAP24, h264.NALUTypeFUB:
+	case h264.NALUTypeMTAP16, h264.NALUTypeMTAP24:
 		d.resetFragments()
+
+		var err error
+		nalus, dons, err = decodeMultiTimeAggregationUnits(typ, pkt.Payload[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+
 		d.firstPacketReceived = true
-		return nil, fmt.Errorf("packet type not supported (%v)", typ)
 
 	default:
 		d.resetFragments()
@@ -209,15 +385,15 @@ func (d *Decoder) decodeNALUs(pkt *rtp.Packet) ([][]byte, error) {
 
 	nalus, err := d.removeAnnexB(nalus)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return nalus, nil
+	return nalus, dons, nil
 }
 
 // Decode decodes an access unit from a RTP packet.
 func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
-	nalus, err := d.decodeNALUs(pkt)
+	nalus, dons, err := d.decodeNALUs(pkt)
 	if err != nil {
 		return nil, err
 	}
@@ -226,10 +402,9 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 	// support splitting access units by timestamp.
 	// (some cameras do not use the Marker field, like the FLIR M400)
 	if d.frameBuffer != nil && pkt.Timestamp != d.frameBufferTimestamp {
-		ret := d.frameBuffer
-		d.resetFrameBuffer()
+		ret := d.takeFrameBuffer()
 
-		err = d.addToFrameBuffer(nalus, l, pkt.Timestamp)
+		err = d.addToFrameBuffer(nalus, dons, l, pkt.Timestamp)
 		if err != nil {
 			return nil, err
 		}
@@ -237,42 +412,93 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 		return ret, nil
 	}
 
-	err = d.addToFrameBuffer(nalus, l, pkt.Timestamp)
+	err = d.addToFrameBuffer(nalus, dons, l, pkt.Timestamp)
 	if err != nil {
 		return nil, err
 	}
 
-	if !pkt.Marker {
+	if !pkt.Marker && !d.maxLatencyExceeded() {
 		return nil, ErrMorePacketsNeeded
 	}
 
+	return d.takeFrameBuffer(), nil
+}
+
+// Flush returns the access unit that is currently buffered, if any.
+// It can be called periodically with streams whose encoder never sets the marker bit,
+// in order to return access units without waiting for the next one.
+// It must not be called concurrently with Decode().
+func (d *Decoder) Flush() [][]byte {
+	return d.takeFrameBuffer()
+}
+
+// takeFrameBuffer returns the buffered access unit and resets the buffer.
+// In interleaved mode, NALUs are sorted by decoding order number (DON).
+func (d *Decoder) takeFrameBuffer() [][]byte {
 	ret := d.frameBuffer
+
+	if d.PacketizationMode == 2 && len(ret) > 1 {
+		// DONs are compared with their distance from the first one, in order to support wrap-arounds.
+		dons := d.frameBufferDONs
+		idx := make([]int, len(ret))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(i, j int) bool {
+			return int16(dons[idx[i]]-dons[0]) < int16(dons[idx[j]]-dons[0])
+		})
+
+		sorted := make([][]byte, len(ret))
+		for i, j := range idx {
+			sorted[i] = ret[j]
+		}
+		ret = sorted
+	}
+
 	d.resetFrameBuffer()
+	return ret
+}
 
-	return ret, nil
+func (d *Decoder) maxLatencyExceeded() bool {
+	return d.MaxLatency != 0 && d.timeNow().Sub(d.frameBufferStart) >= d.MaxLatency
 }
 
 func (d *Decoder) resetFrameBuffer() {
 	d.frameBuffer = nil // do not reuse frameBuffer to avoid race conditions
 	d.frameBufferLen = 0
 	d.frameBufferSize = 0
+	d.frameBufferDONs = nil
 }
 
-func (d *Decoder) addToFrameBuffer(nalus [][]byte, l int, ts uint32) error {
-	if (d.frameBufferLen + l) > h264.MaxNALUsPerAccessUnit {
+func (d *Decoder) addToFrameBuffer(nalus [][]byte, dons []uint16, l int, ts uint32) error {
+	if (d.frameBufferLen + l) > d.MaxNALUsPerAccessUnit {
 		errCount := d.frameBufferLen + l
 		d.resetFrameBuffer()
-		return fmt.Errorf("NALU count (%d) exceeds maximum allowed (%d)",
-			errCount, h264.MaxNALUsPerAccessUnit)
+		return ErrNALUCountExceeded{Count: errCount, Max: d.MaxNALUsPerAccessUnit}
 	}
 
 	addSize := auSize(nalus)
 
-	if (d.frameBufferSize + addSize) > h264.MaxAccessUnitSize {
+	if (d.frameBufferSize + addSize) > d.MaxAccessUnitSize {
 		errSize := d.frameBufferSize + addSize
 		d.resetFrameBuffer()
-		return fmt.Errorf("access unit size (%d) is too big, maximum is %d",
-			errSize, h264.MaxAccessUnitSize)
+		return ErrAccessUnitSizeTooBig{Size: errSize, Max: d.MaxAccessUnitSize}
+	}
+
+	if d.frameBuffer == nil {
+		d.frameBufferStart = d.timeNow()
+	}
+
+	if d.PacketizationMode == 2 {
+		// NALUs without a DON are placed after the previous one.
+		for i := range nalus {
+			if i < len(dons) {
+				d.prevDON = dons[i]
+			} else {
+				d.prevDON++
+			}
+			d.frameBufferDONs = append(d.frameBufferDONs, d.prevDON)
+		}
 	}
 
 	d.frameBuffer = append(d.frameBuffer, nalus...)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
 	"github.com/pion/rtp"
@@ -272,6 +273,57 @@ func TestDecodeAccessUnit(t *testing.T) {
 	}
 }
 
+func TestDecodeInterleaved(t *testing.T) {
+	d := &Decoder{
+		PacketizationMode: 2,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	var au [][]byte
+
+	for i, payload := range [][]byte{
+		// MTAP16 with DONB=10
+		{
+			0x1a, 0x00, 0x0a,
+			0x00, 0x05, 0x02, 0x00, 0x00, 0x41, 0x01,
+			0x00, 0x05, 0x00, 0x00, 0x00, 0x41, 0x02,
+		},
+		// STAP-B with DON=11
+		{0x19, 0x00, 0x0b, 0x00, 0x02, 0x41, 0x03},
+		// FU-B with DON=9
+		{0x1d, 0x81, 0x00, 0x09, 0x04},
+		// FU-A
+		{0x1c, 0x41, 0x05},
+		// MTAP24 with DONB=13
+		{0x1b, 0x00, 0x0d, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00, 0x41, 0x06},
+	} {
+		au, err = d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         (i == 4),
+				PayloadType:    96,
+				SequenceNumber: 17645 + uint16(i),
+				Timestamp:      2289527317,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: payload,
+		})
+		if i != 4 {
+			require.Equal(t, ErrMorePacketsNeeded, err)
+		}
+	}
+
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		{0x01, 0x04, 0x05},
+		{0x41, 0x02},
+		{0x41, 0x03},
+		{0x41, 0x01},
+		{0x41, 0x06},
+	}, au)
+}
+
 func TestDecoderErrorNALUSize(t *testing.T) {
 	d := &Decoder{}
 	err := d.Init()
@@ -330,6 +382,46 @@ func TestDecoderErrorNALUCount(t *testing.T) {
 	require.EqualError(t, err, "NALU count (26) exceeds maximum allowed (25)")
 }
 
+func TestDecoderErrorCustomLimits(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		decoder *Decoder
+		err     error
+	}{
+		{
+			"access unit size",
+			&Decoder{MaxAccessUnitSize: 10},
+			ErrAccessUnitSizeTooBig{Size: 12, Max: 10},
+		},
+		{
+			"NALU count",
+			&Decoder{MaxNALUsPerAccessUnit: 2},
+			ErrNALUCountExceeded{Count: 3, Max: 2},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := ca.decoder.Init()
+			require.NoError(t, err)
+
+			for i := range uint16(3) {
+				_, err = ca.decoder.Decode(&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         false,
+						PayloadType:    96,
+						SequenceNumber: 17645 + i,
+						Timestamp:      2289527317,
+						SSRC:           0x9dbb7812,
+					},
+					Payload: []byte{0x02, 0x01, 0x02, 0x03},
+				})
+			}
+
+			require.Equal(t, ca.err, err)
+		})
+	}
+}
+
 func TestDecodeErrorMissingPacket(t *testing.T) {
 	d := &Decoder{}
 	err := d.Init()
@@ -397,3 +489,52 @@ func FuzzDecoder(f *testing.F) {
 		}
 	})
 }
+
+func TestDecodeMaxLatency(t *testing.T) {
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	d := &Decoder{
+		MaxLatency: 100 * time.Millisecond,
+		timeNow: func() time.Time {
+			return now
+		},
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         false,
+			PayloadType:    96,
+			SequenceNumber: 17647,
+			Timestamp:      2289531307,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x02, 0x01, 0x02},
+	}
+
+	_, err = d.Decode(pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	now = now.Add(50 * time.Millisecond)
+	pkt.SequenceNumber++
+
+	_, err = d.Decode(pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	now = now.Add(50 * time.Millisecond)
+	pkt.SequenceNumber++
+
+	au, err := d.Decode(pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x02, 0x01, 0x02}, {0x02, 0x01, 0x02}, {0x02, 0x01, 0x02}}, au)
+
+	pkt.SequenceNumber++
+
+	_, err = d.Decode(pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	require.Equal(t, [][]byte{{0x02, 0x01, 0x02}}, d.Flush())
+	require.Nil(t, d.Flush())
+}
@@ -3,6 +3,7 @@ package rtph265
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/pion/rtp"
 
@@ -19,6 +20,39 @@ var ErrMorePacketsNeeded = errors.New("need more packets")
 var ErrNonStartingPacketAndNoPrevious = errors.New(
 	"received a non-starting fragment without any previous starting fragment")
 
+// ErrNALUSizeTooBig is returned when the size of a NALU exceeds the maximum allowed.
+type ErrNALUSizeTooBig struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrNALUSizeTooBig) Error() string {
+	return fmt.Sprintf("NALU size (%d) is too big, maximum is %d", e.Size, e.Max)
+}
+
+// ErrAccessUnitSizeTooBig is returned when the size of an access unit exceeds the maximum allowed.
+type ErrAccessUnitSizeTooBig struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrAccessUnitSizeTooBig) Error() string {
+	return fmt.Sprintf("access unit size (%d) is too big, maximum is %d", e.Size, e.Max)
+}
+
+// ErrNALUCountExceeded is returned when the number of NALUs of an access unit exceeds the maximum allowed.
+type ErrNALUCountExceeded struct {
+	Count int
+	Max   int
+}
+
+// Error implements the error interface.
+func (e ErrNALUCountExceeded) Error() string {
+	return fmt.Sprintf("NALU count (%d) exceeds maximum allowed (%d)", e.Count, e.Max)
+}
+
 func joinFragments(fragments [][]byte, size int) []byte {
 	ret := make([]byte, size)
 	n := 0
@@ -42,15 +76,36 @@ type Decoder struct {
 	// indicates that NALUs have an additional field that specifies the decoding order.
 	MaxDONDiff int
 
+	// maximum size of a NALU (optional).
+	// It defaults to h265.MaxAccessUnitSize.
+	MaxNALUSize int
+
+	// maximum size of an access unit (optional).
+	// It defaults to h265.MaxAccessUnitSize.
+	MaxAccessUnitSize int
+
+	// maximum number of NALUs of an access unit (optional).
+	// It defaults to h265.MaxNALUsPerAccessUnit.
+	MaxNALUsPerAccessUnit int
+
+	// maximum time an access unit can stay in the buffer (optional).
+	// When exceeded, the access unit is returned without waiting for the marker bit
+	// or for a timestamp change. This bounds buffering of streams whose encoder never sets the marker bit.
+	// It defaults to zero, that means that there's no limit.
+	MaxLatency time.Duration
+
+	timeNow             func() time.Time
 	firstPacketReceived bool
 	fragments           [][]byte
 	fragmentsSize       int
 	fragmentNextSeqNum  uint16
 
 	// for Decode()
-	frameBuffer     [][]byte
-	frameBufferLen  int
-	frameBufferSize int
+	frameBuffer          [][]byte
+	frameBufferLen       int
+	frameBufferSize      int
+	frameBufferTimestamp uint32
+	frameBufferStart     time.Time
 }
 
 // Init initializes the decoder.
@@ -58,6 +113,20 @@ func (d *Decoder) Init() error {
 	if d.MaxDONDiff != 0 {
 		return fmt.Errorf("MaxDONDiff != 0 is not supported (yet)")
 	}
+
+	if d.MaxNALUSize == 0 {
+		d.MaxNALUSize = h265.MaxAccessUnitSize
+	}
+	if d.MaxAccessUnitSize == 0 {
+		d.MaxAccessUnitSize = h265.MaxAccessUnitSize
+	}
+	if d.MaxNALUsPerAccessUnit == 0 {
+		d.MaxNALUsPerAccessUnit = h265.MaxNALUsPerAccessUnit
+	}
+	if d.timeNow == nil {
+		d.timeNow = time.Now
+	}
+
 	return nil
 }
 
@@ -144,11 +213,10 @@ func (d *Decoder) decodeNALUs(pkt *rtp.Packet) ([][]byte, error) {
 
 		d.fragmentsSize += len(pkt.Payload[3:])
 
-		if d.fragmentsSize > h265.MaxAccessUnitSize {
+		if d.fragmentsSize > d.MaxNALUSize {
 			errSize := d.fragmentsSize
 			d.resetFragments()
-			return nil, fmt.Errorf("NALU size (%d) is too big, maximum is %d",
-				errSize, h265.MaxAccessUnitSize)
+			return nil, ErrNALUSizeTooBig{Size: errSize, Max: d.MaxNALUSize}
 		}
 
 		d.fragments = append(d.fragments, pkt.Payload[3:])
@@ -181,40 +249,77 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 	}
 	l := len(nalus)
 
-	if (d.frameBufferLen + l) > h265.MaxNALUsPerAccessUnit {
-		errCount := d.frameBufferLen + l
-		d.frameBuffer = nil
-		d.frameBufferLen = 0
-		d.frameBufferSize = 0
-		return nil, fmt.Errorf("NALU count (%d) exceeds maximum allowed (%d)",
-			errCount, h265.MaxNALUsPerAccessUnit)
-	}
+	// support splitting access units by timestamp.
+	// (some cameras do not use the Marker field)
+	if d.frameBuffer != nil && pkt.Timestamp != d.frameBufferTimestamp {
+		ret := d.frameBuffer
+		d.resetFrameBuffer()
 
-	addSize := auSize(nalus)
+		err = d.addToFrameBuffer(nalus, l, pkt.Timestamp)
+		if err != nil {
+			return nil, err
+		}
 
-	if (d.frameBufferSize + addSize) > h265.MaxAccessUnitSize {
-		errSize := d.frameBufferSize + addSize
-		d.frameBuffer = nil
-		d.frameBufferLen = 0
-		d.frameBufferSize = 0
-		return nil, fmt.Errorf("access unit size (%d) is too big, maximum is %d",
-			errSize, h265.MaxAccessUnitSize)
+		return ret, nil
 	}
 
-	d.frameBuffer = append(d.frameBuffer, nalus...)
-	d.frameBufferLen += l
-	d.frameBufferSize += addSize
+	err = d.addToFrameBuffer(nalus, l, pkt.Timestamp)
+	if err != nil {
+		return nil, err
+	}
 
-	if !pkt.Marker {
+	if !pkt.Marker && !d.maxLatencyExceeded() {
 		return nil, ErrMorePacketsNeeded
 	}
 
 	ret := d.frameBuffer
+	d.resetFrameBuffer()
 
-	// do not reuse frameBuffer to avoid race conditions
-	d.frameBuffer = nil
+	return ret, nil
+}
+
+// Flush returns the access unit that is currently buffered, if any.
+// It can be called periodically with streams whose encoder never sets the marker bit,
+// in order to return access units without waiting for the next one.
+// It must not be called concurrently with Decode().
+func (d *Decoder) Flush() [][]byte {
+	ret := d.frameBuffer
+	d.resetFrameBuffer()
+	return ret
+}
+
+func (d *Decoder) maxLatencyExceeded() bool {
+	return d.MaxLatency != 0 && d.timeNow().Sub(d.frameBufferStart) >= d.MaxLatency
+}
+
+func (d *Decoder) resetFrameBuffer() {
+	d.frameBuffer = nil // do not reuse frameBuffer to avoid race conditions
 	d.frameBufferLen = 0
 	d.frameBufferSize = 0
+}
 
-	return ret, nil
+func (d *Decoder) addToFrameBuffer(nalus [][]byte, l int, ts uint32) error {
+	if (d.frameBufferLen + l) > d.MaxNALUsPerAccessUnit {
+		errCount := d.frameBufferLen + l
+		d.resetFrameBuffer()
+		return ErrNALUCountExceeded{Count: errCount, Max: d.MaxNALUsPerAccessUnit}
+	}
+
+	addSize := auSize(nalus)
+
+	if (d.frameBufferSize + addSize) > d.MaxAccessUnitSize {
+		errSize := d.frameBufferSize + addSize
+		d.resetFrameBuffer()
+		return ErrAccessUnitSizeTooBig{Size: errSize, Max: d.MaxAccessUnitSize}
+	}
+
+	if d.frameBuffer == nil {
+		d.frameBufferStart = d.timeNow()
+	}
+
+	d.frameBuffer = append(d.frameBuffer, nalus...)
+	d.frameBufferLen += l
+	d.frameBufferSize += addSize
+	d.frameBufferTimestamp = ts
+	return nil
 }
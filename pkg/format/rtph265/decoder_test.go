@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
 	"github.com/pion/rtp"
@@ -99,6 +100,46 @@ func TestDecoderErrorNALUCount(t *testing.T) {
 	require.EqualError(t, err, "NALU count (22) exceeds maximum allowed (21)")
 }
 
+func TestDecoderErrorCustomLimits(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		decoder *Decoder
+		err     error
+	}{
+		{
+			"access unit size",
+			&Decoder{MaxAccessUnitSize: 10},
+			ErrAccessUnitSizeTooBig{Size: 12, Max: 10},
+		},
+		{
+			"NALU count",
+			&Decoder{MaxNALUsPerAccessUnit: 2},
+			ErrNALUCountExceeded{Count: 3, Max: 2},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := ca.decoder.Init()
+			require.NoError(t, err)
+
+			for i := range uint16(3) {
+				_, err = ca.decoder.Decode(&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         false,
+						PayloadType:    96,
+						SequenceNumber: 17645 + i,
+						Timestamp:      2289527317,
+						SSRC:           0x9dbb7812,
+					},
+					Payload: []byte{0x02, 0x01, 0x02, 0x03},
+				})
+			}
+
+			require.Equal(t, ca.err, err)
+		})
+	}
+}
+
 func TestDecodeErrorMissingPacket(t *testing.T) {
 	d := &Decoder{}
 	err := d.Init()
@@ -166,3 +207,85 @@ func FuzzDecoder(f *testing.F) {
 		}
 	})
 }
+
+func TestDecodeMaxLatency(t *testing.T) {
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	d := &Decoder{
+		MaxLatency: 100 * time.Millisecond,
+		timeNow: func() time.Time {
+			return now
+		},
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         false,
+			PayloadType:    96,
+			SequenceNumber: 17647,
+			Timestamp:      2289531307,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x02, 0x01, 0x02},
+	}
+
+	_, err = d.Decode(pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	now = now.Add(50 * time.Millisecond)
+	pkt.SequenceNumber++
+
+	_, err = d.Decode(pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	now = now.Add(50 * time.Millisecond)
+	pkt.SequenceNumber++
+
+	au, err := d.Decode(pkt)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x02, 0x01, 0x02}, {0x02, 0x01, 0x02}, {0x02, 0x01, 0x02}}, au)
+
+	pkt.SequenceNumber++
+
+	_, err = d.Decode(pkt)
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	require.Equal(t, [][]byte{{0x02, 0x01, 0x02}}, d.Flush())
+	require.Nil(t, d.Flush())
+}
+
+func TestDecodeTimestampSplitted(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         false,
+			PayloadType:    96,
+			SequenceNumber: 17647,
+			Timestamp:      2289531307,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x02, 0x01, 0x02},
+	})
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	au, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         false,
+			PayloadType:    96,
+			SequenceNumber: 17648,
+			Timestamp:      2289531308,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{0x02, 0x01, 0x03},
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x02, 0x01, 0x02}}, au)
+}
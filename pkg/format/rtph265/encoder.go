@@ -83,6 +83,8 @@ func (e *Encoder) Init() error {
 }
 
 // Encode encodes an access unit into RTP/H265 packets.
+// The marker bit is set in the last packet of the access unit.
+// Sequences of NALUs without access unit delimiters can be split into access units with ausplitter.
 func (e *Encoder) Encode(au [][]byte) ([]*rtp.Packet, error) {
 	var rets []*rtp.Packet
 	var batch [][]byte
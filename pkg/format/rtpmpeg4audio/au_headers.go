@@ -0,0 +1,107 @@
+package rtpmpeg4audio
+
+import (
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
+)
+
+// auHeaderParams contains the parameters of AU-headers.
+// Specification: RFC3640, 3.2.1
+type auHeaderParams struct {
+	sizeLength             int
+	indexLength            int
+	indexDeltaLength       int
+	ctsDeltaLength         int
+	dtsDeltaLength         int
+	randomAccessIndication bool
+	streamStateIndication  int
+}
+
+// empty returns whether AU-headers are empty.
+// In this case, the AU-header section, including the AU-headers-length field, is absent.
+func (p auHeaderParams) empty() bool {
+	return p.sizeLength == 0 &&
+		p.indexLength == 0 &&
+		p.indexDeltaLength == 0 &&
+		p.ctsDeltaLength == 0 &&
+		p.dtsDeltaLength == 0 &&
+		!p.randomAccessIndication &&
+		p.streamStateIndication == 0
+}
+
+// headerLen returns the size in bits of an AU-header written by the encoder,
+// that never writes CTS-delta and DTS-delta.
+func (p auHeaderParams) headerLen(first bool) int {
+	n := p.sizeLength
+
+	if first {
+		n += p.indexLength
+	} else {
+		n += p.indexDeltaLength
+	}
+
+	if p.ctsDeltaLength > 0 {
+		n++ // CTS-flag
+	}
+	if p.dtsDeltaLength > 0 {
+		n++ // DTS-flag
+	}
+	if p.randomAccessIndication {
+		n++ // RAP-flag
+	}
+	n += p.streamStateIndication
+
+	return n
+}
+
+// sectionLen returns the size in bytes of the AU-header section of a packet that contains N AUs.
+func (p auHeaderParams) sectionLen(n int) int {
+	if p.empty() {
+		return 0
+	}
+
+	headersLen := 0
+	for i := range n {
+		headersLen += p.headerLen(i == 0)
+	}
+
+	l := 2 + headersLen/8 // AU-headers-length
+	if (headersLen % 8) != 0 {
+		l++
+	}
+	return l
+}
+
+// marshalSection writes the AU-header section of a packet that contains AUs with given sizes.
+func (p auHeaderParams) marshalSection(sizes []int) []byte {
+	if p.empty() {
+		return nil
+	}
+
+	var w bits.Writer
+
+	for i, size := range sizes {
+		w.WriteBits(uint64(size), p.sizeLength)
+
+		// AU-Index and AU-Index-delta are always zero, since AUs are not interleaved
+		if i == 0 {
+			w.WriteBits(0, p.indexLength)
+		} else {
+			w.WriteBits(0, p.indexDeltaLength)
+		}
+
+		if p.ctsDeltaLength > 0 {
+			w.WriteFlag(false) // CTS-flag
+		}
+		if p.dtsDeltaLength > 0 {
+			w.WriteFlag(false) // DTS-flag
+		}
+		if p.randomAccessIndication {
+			w.WriteFlag(true) // RAP-flag
+		}
+		w.WriteBits(0, p.streamStateIndication)
+	}
+
+	headersLen := w.Pos()
+
+	return append([]byte{byte(headersLen >> 8), byte(headersLen)}, w.Bytes()...)
+}
@@ -4,14 +4,26 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/bluenviron/mediacommon/v2/pkg/bits"
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
 	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
 )
 
 // ErrMorePacketsNeeded is returned when more packets are needed.
 var ErrMorePacketsNeeded = errors.New("need more packets")
 
+// ErrAccessUnitSizeTooBig is returned when the size of an access unit exceeds the maximum allowed.
+type ErrAccessUnitSizeTooBig struct {
+	Size int
+	Max  int
+}
+
+// Error implements the error interface.
+func (e ErrAccessUnitSizeTooBig) Error() string {
+	return fmt.Sprintf("access unit size (%d) is too big, maximum is %d", e.Size, e.Max)
+}
+
 func joinFragments(fragments [][]byte, size int) []byte {
 	ret := make([]byte, size)
 	n := 0
@@ -33,51 +45,71 @@ type Decoder struct {
 	// The number of bits in which the AU-Index-delta field is encoded in any non-first AU-header.
 	IndexDeltaLength int
 
-	firstAUParsed      bool
-	adtsMode           bool
-	fragments          [][]byte
-	fragmentsSize      int
-	fragmentNextSeqNum uint16
+	// The number of bits in which the CTS-delta field is encoded in the AU-header.
+	CTSDeltaLength int
+
+	// The number of bits in which the DTS-delta field is encoded in the AU-header.
+	DTSDeltaLength int
+
+	// Whether the AU-header contains the RAP-flag.
+	RandomAccessIndication bool
+
+	// The number of bits in which the Stream-state field is encoded in the AU-header.
+	StreamStateIndication int
+
+	// The number of bits in which the auxiliary-data-size field is encoded.
+	AuxiliaryDataSizeLength int
+
+	// size of AUs, used when SizeLength is zero (optional).
+	// If both SizeLength and ConstantSize are zero, each packet contains a single AU.
+	ConstantSize int
+
+	// maximum size of an access unit (optional).
+	// It defaults to mpeg4audio.MaxAccessUnitSize.
+	MaxAccessUnitSize int
+
+	params                auHeaderParams
+	firstAUParsed         bool
+	adtsMode              bool
+	fragments             [][]byte
+	fragmentsSize         int
+	fragmentsExpectedSize int
+	fragmentNextSeqNum    uint16
 }
 
 // Init initializes the decoder.
 func (d *Decoder) Init() error {
+	if d.MaxAccessUnitSize == 0 {
+		d.MaxAccessUnitSize = mpeg4audio.MaxAccessUnitSize
+	}
+
+	d.params = auHeaderParams{
+		sizeLength:             d.SizeLength,
+		indexLength:            d.IndexLength,
+		indexDeltaLength:       d.IndexDeltaLength,
+		ctsDeltaLength:         d.CTSDeltaLength,
+		dtsDeltaLength:         d.DTSDeltaLength,
+		randomAccessIndication: d.RandomAccessIndication,
+		streamStateIndication:  d.StreamStateIndication,
+	}
+
 	return nil
 }
 
 func (d *Decoder) resetFragments() {
 	d.fragments = d.fragments[:0]
 	d.fragmentsSize = 0
+	d.fragmentsExpectedSize = 0
 }
 
 // Decode decodes AUs (non-LATM) or audioMuxElements (LATM) from a RTP packet.
 func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
-	if len(pkt.Payload) < 2 {
-		d.resetFragments()
-		return nil, fmt.Errorf("payload is too short")
-	}
-
-	// AU-headers-length (16 bits)
-	headersLen := int(uint16(pkt.Payload[0])<<8 | uint16(pkt.Payload[1]))
-	if headersLen == 0 {
-		d.resetFragments()
-		return nil, fmt.Errorf("invalid AU-headers-length")
-	}
-	payload := pkt.Payload[2:]
-
-	// AU-headers
-	dataLens, err := d.readAUHeaders(payload, headersLen)
+	dataLens, payload, err := d.readSections(pkt.Payload)
 	if err != nil {
 		d.resetFragments()
 		return nil, err
 	}
 
-	pos := (headersLen / 8)
-	if (headersLen % 8) != 0 {
-		pos++
-	}
-	payload = payload[pos:]
-
 	var aus [][]byte
 
 	if d.fragmentsSize == 0 {
@@ -87,7 +119,7 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 			// AUs
 			aus = make([][]byte, len(dataLens))
 			for i, dataLen := range dataLens {
-				if len(payload) < int(dataLen) {
+				if len(payload) < dataLen {
 					return nil, fmt.Errorf("payload is too short")
 				}
 
@@ -99,12 +131,17 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 				return nil, fmt.Errorf("a fragmented packet can only contain one AU")
 			}
 
-			if len(payload) < int(dataLens[0]) {
-				return nil, fmt.Errorf("payload is too short")
+			fragmentLen := dataLens[0]
+
+			// AU-size can be the size of the entire AU, instead of the size of the fragment.
+			// Specification: RFC3640, 3.2.1.1
+			if fragmentLen > len(payload) {
+				d.fragmentsExpectedSize = fragmentLen
+				fragmentLen = len(payload)
 			}
 
-			d.fragmentsSize = int(dataLens[0])
-			d.fragments = append(d.fragments, payload[:dataLens[0]])
+			d.fragmentsSize = fragmentLen
+			d.fragments = append(d.fragments, payload[:fragmentLen])
 			d.fragmentNextSeqNum = pkt.SequenceNumber + 1
 			return nil, ErrMorePacketsNeeded
 		}
@@ -115,7 +152,11 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 			return nil, fmt.Errorf("a fragmented packet can only contain one AU")
 		}
 
-		if len(payload) < int(dataLens[0]) {
+		fragmentLen := dataLens[0]
+
+		if d.fragmentsExpectedSize != 0 {
+			fragmentLen = min(len(payload), d.fragmentsExpectedSize-d.fragmentsSize)
+		} else if len(payload) < fragmentLen {
 			d.resetFragments()
 			return nil, fmt.Errorf("payload is too short")
 		}
@@ -125,22 +166,29 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 			return nil, fmt.Errorf("discarding frame since a RTP packet is missing")
 		}
 
-		d.fragmentsSize += int(dataLens[0])
+		d.fragmentsSize += fragmentLen
 
-		if d.fragmentsSize > mpeg4audio.MaxAccessUnitSize {
+		if d.fragmentsSize > d.MaxAccessUnitSize {
 			errSize := d.fragmentsSize
 			d.resetFragments()
-			return nil, fmt.Errorf("access unit size (%d) is too big, maximum is %d",
-				errSize, mpeg4audio.MaxAccessUnitSize)
+			return nil, ErrAccessUnitSizeTooBig{Size: errSize, Max: d.MaxAccessUnitSize}
 		}
 
-		d.fragments = append(d.fragments, payload[:dataLens[0]])
+		d.fragments = append(d.fragments, payload[:fragmentLen])
 		d.fragmentNextSeqNum++
 
 		if !pkt.Marker {
 			return nil, ErrMorePacketsNeeded
 		}
 
+		if d.fragmentsExpectedSize != 0 && d.fragmentsSize != d.fragmentsExpectedSize {
+			errSize := d.fragmentsSize
+			expectedSize := d.fragmentsExpectedSize
+			d.resetFragments()
+			return nil, fmt.Errorf("fragmented AU size (%d) is different than AU-size (%d)",
+				errSize, expectedSize)
+		}
+
 		aus = [][]byte{joinFragments(d.fragments, d.fragmentsSize)}
 		d.resetFragments()
 	}
@@ -148,62 +196,161 @@ func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
 	return d.removeADTS(aus)
 }
 
-func (d *Decoder) readAUHeaders(buf []byte, headersLen int) ([]uint64, error) {
-	firstRead := false
-
-	count := 0
-	for i := 0; i < headersLen; {
-		if i == 0 {
-			i += d.SizeLength
-			i += d.IndexLength
-		} else {
-			i += d.SizeLength
-			i += d.IndexDeltaLength
+// readSections reads the AU-header section and the auxiliary section,
+// and returns sizes of AUs and the remaining payload.
+// Specification: RFC3640, 3.2
+func (d *Decoder) readSections(payload []byte) ([]int, []byte, error) {
+	if d.params.empty() {
+		if d.AuxiliaryDataSizeLength > 0 {
+			return nil, nil, fmt.Errorf("auxiliary section without AU-headers is not supported")
 		}
-		count++
+
+		return d.constantSizes(payload)
 	}
 
-	dataLens := make([]uint64, count)
+	if len(payload) < 2 {
+		return nil, nil, fmt.Errorf("payload is too short")
+	}
 
-	pos := 0
-	i := 0
+	// AU-headers-length (16 bits)
+	headersLen := int(uint16(payload[0])<<8 | uint16(payload[1]))
+	if headersLen == 0 {
+		return nil, nil, fmt.Errorf("invalid AU-headers-length")
+	}
+	payload = payload[2:]
 
-	for headersLen > 0 {
-		dataLen, err := bits.ReadBits(buf, &pos, d.SizeLength)
+	headersLenBytes := headersLen / 8
+	if (headersLen % 8) != 0 {
+		headersLenBytes++
+	}
+
+	if len(payload) < headersLenBytes {
+		return nil, nil, fmt.Errorf("payload is too short")
+	}
+
+	// AU-headers
+	dataLens, err := d.readAUHeaders(payload[:headersLenBytes], headersLen, len(payload)-headersLenBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload = payload[headersLenBytes:]
+
+	// auxiliary section
+	if d.AuxiliaryDataSizeLength > 0 {
+		r := bits.Reader{Buf: payload}
+
+		auxLen, err := r.ReadBits(d.AuxiliaryDataSizeLength)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		err = r.SkipBits(int(auxLen))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pos := (r.Pos() + 7) / 8
+		payload = payload[pos:]
+	}
+
+	return dataLens, payload, nil
+}
+
+func (d *Decoder) constantSizes(payload []byte) ([]int, []byte, error) {
+	if d.ConstantSize == 0 {
+		return []int{len(payload)}, payload, nil
+	}
+
+	if (len(payload) % d.ConstantSize) != 0 {
+		return nil, nil, fmt.Errorf("payload size (%d) is not a multiple of the constant size (%d)",
+			len(payload), d.ConstantSize)
+	}
+
+	dataLens := make([]int, len(payload)/d.ConstantSize)
+	for i := range dataLens {
+		dataLens[i] = d.ConstantSize
+	}
+
+	return dataLens, payload, nil
+}
+
+func (d *Decoder) readAUHeaders(buf []byte, headersLen int, payloadLen int) ([]int, error) {
+	r := bits.Reader{Buf: buf}
+	var dataLens []int
+
+	for r.Pos() < headersLen {
+		first := (dataLens == nil)
+
+		var dataLen int
+
+		if d.SizeLength > 0 {
+			v, err := r.ReadBits(d.SizeLength)
+			if err != nil {
+				return nil, err
+			}
+			dataLen = int(v)
+		} else if d.ConstantSize > 0 {
+			dataLen = d.ConstantSize
+		} else {
+			dataLen = payloadLen
 		}
-		headersLen -= d.SizeLength
 
-		if !firstRead {
-			firstRead = true
+		if first {
 			if d.IndexLength > 0 {
-				var auIndex uint64
-				auIndex, err = bits.ReadBits(buf, &pos, d.IndexLength)
+				auIndex, err := r.ReadBits(d.IndexLength)
 				if err != nil {
 					return nil, err
 				}
-				headersLen -= d.IndexLength
 
 				if auIndex != 0 {
 					return nil, fmt.Errorf("AU-index different than zero is not supported")
 				}
 			}
 		} else if d.IndexDeltaLength > 0 {
-			var auIndexDelta uint64
-			auIndexDelta, err = bits.ReadBits(buf, &pos, d.IndexDeltaLength)
+			auIndexDelta, err := r.ReadBits(d.IndexDeltaLength)
 			if err != nil {
 				return nil, err
 			}
-			headersLen -= d.IndexDeltaLength
 
 			if auIndexDelta != 0 {
 				return nil, fmt.Errorf("AU-index-delta different than zero is not supported")
 			}
 		}
 
-		dataLens[i] = dataLen
-		i++
+		// CTS-delta and DTS-delta are not used, since AUs are not interleaved
+		for _, deltaLen := range []int{d.CTSDeltaLength, d.DTSDeltaLength} {
+			if deltaLen > 0 {
+				present, err := r.ReadFlag()
+				if err != nil {
+					return nil, err
+				}
+
+				if present {
+					err = r.SkipBits(deltaLen)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		// RAP-flag and Stream-state
+		skip := d.StreamStateIndication
+		if d.RandomAccessIndication {
+			skip++
+		}
+
+		err := r.SkipBits(skip)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Pos() > headersLen {
+			return nil, fmt.Errorf("invalid AU-headers-length")
+		}
+
+		dataLens = append(dataLens, dataLen)
 	}
 
 	return dataLens, nil
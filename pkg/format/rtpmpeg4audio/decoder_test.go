@@ -7,6 +7,8 @@ import (
 
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
 )
 
 func TestDecodeGeneric(t *testing.T) {
@@ -113,6 +115,158 @@ func TestDecodeGenericErrorMissingPacket(t *testing.T) {
 	require.EqualError(t, err, "discarding frame since a RTP packet is missing")
 }
 
+func TestDecodeGenericErrorAccessUnitSize(t *testing.T) {
+	d := &Decoder{
+		SizeLength:        13,
+		IndexLength:       3,
+		IndexDeltaLength:  3,
+		MaxAccessUnitSize: 2000,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	for i := range uint16(2) {
+		_, err = d.Decode(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         false,
+				PayloadType:    96,
+				SequenceNumber: 17645 + i,
+				SSRC:           0x9dbb7812,
+			},
+			Payload: mergeBytes(
+				[]byte{0x00, 0x10, 0x2d, 0x80},
+				bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, 182),
+			),
+		})
+	}
+
+	require.Equal(t, ErrAccessUnitSizeTooBig{Size: 2912, Max: 2000}, err)
+}
+
+func TestDecodeGenericFragmentedEntireAUSize(t *testing.T) {
+	d := &Decoder{
+		SizeLength:       13,
+		IndexLength:      3,
+		IndexDeltaLength: 3,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	// AU-size contains the size of the entire AU (2000) in every fragment
+	_, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         false,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: mergeBytes(
+			[]byte{0x00, 0x10, 0x3e, 0x80},
+			bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 250),
+		),
+	})
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	aus, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 17646,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: mergeBytes(
+			[]byte{0x00, 0x10, 0x3e, 0x80},
+			bytes.Repeat([]byte{0x05, 0x06, 0x07, 0x08}, 250),
+		),
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{mergeBytes(
+		bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 250),
+		bytes.Repeat([]byte{0x05, 0x06, 0x07, 0x08}, 250),
+	)}, aus)
+}
+
+func TestDecodeGenericConstantSize(t *testing.T) {
+	// CELP-cbr, without AU-headers
+	d := &Decoder{
+		ConstantSize: 4,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	aus, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}}, aus)
+}
+
+func TestDecodeGenericAllFields(t *testing.T) {
+	d := &Decoder{
+		SizeLength:              6,
+		IndexLength:             2,
+		IndexDeltaLength:        2,
+		CTSDeltaLength:          4,
+		DTSDeltaLength:          4,
+		RandomAccessIndication:  true,
+		StreamStateIndication:   2,
+		AuxiliaryDataSizeLength: 8,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	var w bits.Writer
+
+	// first AU-header
+	w.WriteBits(2, 6)     // AU-size
+	w.WriteBits(0, 2)     // AU-Index
+	w.WriteFlag(false)    // CTS-flag
+	w.WriteFlag(true)     // DTS-flag
+	w.WriteBits(0b101, 4) // DTS-delta
+	w.WriteFlag(true)     // RAP-flag
+	w.WriteBits(1, 2)     // Stream-state
+
+	// second AU-header
+	w.WriteBits(3, 6)     // AU-size
+	w.WriteBits(0, 2)     // AU-Index-delta
+	w.WriteFlag(true)     // CTS-flag
+	w.WriteBits(0b011, 4) // CTS-delta
+	w.WriteFlag(false)    // DTS-flag
+	w.WriteFlag(false)    // RAP-flag
+	w.WriteBits(1, 2)     // Stream-state
+
+	headersLen := w.Pos()
+	headers := w.Bytes()
+
+	aus, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: mergeBytes(
+			[]byte{byte(headersLen >> 8), byte(headersLen)},
+			headers,
+			[]byte{12, 0xAB, 0xC0}, // auxiliary section
+			[]byte{1, 2, 3, 4, 5},
+		),
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1, 2}, {3, 4, 5}}, aus)
+}
+
 func FuzzDecoderGeneric(f *testing.F) {
 	f.Fuzz(func(t *testing.T, a []byte, am bool, b []byte, bm bool) {
 		d := &Decoder{
@@ -3,7 +3,6 @@ package rtpmpeg4audio
 import (
 	"crypto/rand"
 
-	"github.com/bluenviron/mediacommon/v2/pkg/bits"
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
 	"github.com/pion/rtp"
 )
@@ -45,6 +44,20 @@ type Encoder struct {
 	// The number of bits in which the AU-Index-delta field is encoded in any non-first AU-header.
 	IndexDeltaLength int
 
+	// The number of bits in which the CTS-delta field is encoded in the AU-header.
+	// CTS-delta is never written, but the CTS-flag is.
+	CTSDeltaLength int
+
+	// The number of bits in which the DTS-delta field is encoded in the AU-header.
+	// DTS-delta is never written, but the DTS-flag is.
+	DTSDeltaLength int
+
+	// Whether the AU-header contains the RAP-flag.
+	RandomAccessIndication bool
+
+	// The number of bits in which the Stream-state field is encoded in the AU-header.
+	StreamStateIndication int
+
 	// SSRC of packets (optional).
 	// It defaults to a random value.
 	SSRC *uint32
@@ -57,6 +70,7 @@ type Encoder struct {
 	// It defaults to 1450.
 	PayloadMaxSize int
 
+	params         auHeaderParams
 	sequenceNumber uint16
 }
 
@@ -81,6 +95,16 @@ func (e *Encoder) Init() error {
 		e.PayloadMaxSize = defaultPayloadMaxSize
 	}
 
+	e.params = auHeaderParams{
+		sizeLength:             e.SizeLength,
+		indexLength:            e.IndexLength,
+		indexDeltaLength:       e.IndexDeltaLength,
+		ctsDeltaLength:         e.CTSDeltaLength,
+		dtsDeltaLength:         e.DTSDeltaLength,
+		randomAccessIndication: e.RandomAccessIndication,
+		streamStateIndication:  e.StreamStateIndication,
+	}
+
 	e.sequenceNumber = *e.InitialSequenceNumber
 	return nil
 }
@@ -123,7 +147,8 @@ func (e *Encoder) Encode(aus [][]byte) ([]*rtp.Packet, error) {
 }
 
 func (e *Encoder) writeGenericBatch(aus [][]byte, timestamp uint32) ([]*rtp.Packet, error) {
-	if len(aus) != 1 || e.lenGenericAggregated(aus, nil) < e.PayloadMaxSize {
+	// AUs can be fragmented only when their size is written into AU-headers
+	if len(aus) != 1 || e.SizeLength == 0 || e.lenGenericAggregated(aus, nil) < e.PayloadMaxSize {
 		return e.writeGenericAggregated(aus, timestamp)
 	}
 
@@ -131,13 +156,8 @@ func (e *Encoder) writeGenericBatch(aus [][]byte, timestamp uint32) ([]*rtp.Pack
 }
 
 func (e *Encoder) writeGenericFragmented(au []byte, timestamp uint32) ([]*rtp.Packet, error) {
-	auHeadersLen := e.SizeLength + e.IndexLength
-	auHeadersLenBytes := auHeadersLen / 8
-	if (auHeadersLen % 8) != 0 {
-		auHeadersLenBytes++
-	}
-
-	avail := e.PayloadMaxSize - 2 - auHeadersLenBytes
+	sectionLen := e.params.sectionLen(1)
+	avail := e.PayloadMaxSize - sectionLen
 	le := len(au)
 	packetCount := packetCountGeneric(avail, le)
 
@@ -149,19 +169,13 @@ func (e *Encoder) writeGenericFragmented(au []byte, timestamp uint32) ([]*rtp.Pa
 			le = len(au)
 		}
 
-		payload := make([]byte, 2+auHeadersLenBytes+le)
+		payload := make([]byte, sectionLen+le)
 
-		// AU-headers-length
-		payload[0] = byte(auHeadersLen >> 8)
-		payload[1] = byte(auHeadersLen)
-
-		// AU-headers
-		pos := 0
-		bits.WriteBitsUnsafe(payload[2:], &pos, uint64(le), e.SizeLength)
-		bits.WriteBitsUnsafe(payload[2:], &pos, 0, e.IndexLength)
+		// AU-headers-length and AU-headers
+		copy(payload, e.params.marshalSection([]int{le}))
 
 		// AU
-		copy(payload[2+auHeadersLenBytes:], au)
+		copy(payload[sectionLen:], au)
 		au = au[le:]
 
 		ret[i] = &rtp.Packet{
@@ -183,65 +197,33 @@ func (e *Encoder) writeGenericFragmented(au []byte, timestamp uint32) ([]*rtp.Pa
 }
 
 func (e *Encoder) lenGenericAggregated(aus [][]byte, addAU []byte) int {
-	n := 2 // AU-headers-length
-
-	// AU-headers
-	auHeadersLen := 0
-	i := 0
-	for range aus {
-		if i == 0 {
-			auHeadersLen += e.SizeLength + e.IndexLength
-		} else {
-			auHeadersLen += e.SizeLength + e.IndexDeltaLength
-		}
-		i++
-	}
+	n := len(aus)
 	if addAU != nil {
-		if i == 0 {
-			auHeadersLen += e.SizeLength + e.IndexLength
-		} else {
-			auHeadersLen += e.SizeLength + e.IndexDeltaLength
-		}
-	}
-	n += auHeadersLen / 8
-	if (auHeadersLen % 8) != 0 {
 		n++
 	}
 
+	// AU-headers-length and AU-headers
+	l := e.params.sectionLen(n)
+
 	// AU
 	for _, au := range aus {
-		n += len(au)
+		l += len(au)
 	}
-	n += len(addAU)
+	l += len(addAU)
 
-	return n
+	return l
 }
 
 func (e *Encoder) writeGenericAggregated(aus [][]byte, timestamp uint32) ([]*rtp.Packet, error) {
 	payload := make([]byte, e.lenGenericAggregated(aus, nil))
 
-	// AU-headers
-	written := 0
-	pos := 0
+	sizes := make([]int, len(aus))
 	for i, au := range aus {
-		bits.WriteBitsUnsafe(payload[2:], &pos, uint64(len(au)), e.SizeLength)
-		written += e.SizeLength
-		if i == 0 {
-			bits.WriteBitsUnsafe(payload[2:], &pos, 0, e.IndexLength)
-			written += e.IndexLength
-		} else {
-			bits.WriteBitsUnsafe(payload[2:], &pos, 0, e.IndexDeltaLength)
-			written += e.IndexDeltaLength
-		}
-	}
-	pos = 2 + (written / 8)
-	if (written % 8) != 0 {
-		pos++
+		sizes[i] = len(au)
 	}
 
-	// AU-headers-length
-	payload[0] = byte(written >> 8)
-	payload[1] = byte(written)
+	// AU-headers-length and AU-headers
+	pos := copy(payload, e.params.marshalSection(sizes))
 
 	// AUs
 	for _, au := range aus {
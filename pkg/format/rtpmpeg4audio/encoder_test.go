@@ -495,3 +495,61 @@ func TestEncodeGeneric(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeDecodeAllFields(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		encoder *Encoder
+		decoder *Decoder
+	}{
+		{
+			"all fields",
+			&Encoder{
+				PayloadType:            96,
+				SizeLength:             6,
+				IndexLength:            2,
+				IndexDeltaLength:       2,
+				CTSDeltaLength:         4,
+				DTSDeltaLength:         4,
+				RandomAccessIndication: true,
+				StreamStateIndication:  2,
+			},
+			&Decoder{
+				SizeLength:             6,
+				IndexLength:            2,
+				IndexDeltaLength:       2,
+				CTSDeltaLength:         4,
+				DTSDeltaLength:         4,
+				RandomAccessIndication: true,
+				StreamStateIndication:  2,
+			},
+		},
+		{
+			"constant size",
+			&Encoder{
+				PayloadType: 96,
+			},
+			&Decoder{
+				ConstantSize: 3,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := ca.encoder.Init()
+			require.NoError(t, err)
+
+			err = ca.decoder.Init()
+			require.NoError(t, err)
+
+			aus := [][]byte{{1, 2, 3}, {4, 5, 6}}
+
+			pkts, err := ca.encoder.Encode(aus)
+			require.NoError(t, err)
+			require.Len(t, pkts, 1)
+
+			dec, err := ca.decoder.Decode(pkts[0])
+			require.NoError(t, err)
+			require.Equal(t, aus, dec)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package rtprawvideo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we received a non-starting
+// packet of a frame and we didn't received anything before.
+// It's normal to receive this when decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"received a non-starting packet without any previous starting packet")
+
+type lineHeader struct {
+	length int
+	field  int
+	lineNo int
+	offset int
+}
+
+// Decoder is a RTP decoder for uncompressed video.
+// Frames are returned in the pixel group format of RFC4175,
+// with lines stored one after the other.
+// Specification: RFC4175
+type Decoder struct {
+	// color subsampling (for instance, "YCbCr-4:2:2").
+	Sampling string
+
+	// number of bits per sample.
+	Depth int
+
+	// width of frames.
+	Width int
+
+	// height of frames.
+	Height int
+
+	// whether the video is interlaced.
+	// In this case, each frame is transmitted as two fields.
+	Interlaced bool
+
+	params             frameParams
+	frame              []byte
+	frameReceivedSize  int
+	frameStarted       bool
+	frameNextSeqNum    uint16
+	firstFrameReceived bool
+	firstFieldReceived bool
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return d.params.init(d.Sampling, d.Depth, d.Width, d.Height, d.Interlaced)
+}
+
+func (d *Decoder) resetFrame() {
+	d.frame = nil
+	d.frameReceivedSize = 0
+	d.frameStarted = false
+	d.firstFieldReceived = false
+}
+
+func (d *Decoder) decodeHeaders(payload []byte) ([]lineHeader, []byte, error) {
+	// extended sequence number
+	if len(payload) < 2 {
+		return nil, nil, fmt.Errorf("payload is too short")
+	}
+	payload = payload[2:]
+
+	var headers []lineHeader
+
+	for {
+		if len(payload) < 6 {
+			return nil, nil, fmt.Errorf("payload is too short")
+		}
+
+		headers = append(headers, lineHeader{
+			length: int(uint16(payload[0])<<8 | uint16(payload[1])),
+			field:  int(payload[2] >> 7),
+			lineNo: int(uint16(payload[2]&0x7F)<<8 | uint16(payload[3])),
+			offset: int(uint16(payload[4]&0x7F)<<8 | uint16(payload[5])),
+		})
+
+		continuation := (payload[4] >> 7) != 0
+		payload = payload[6:]
+
+		if !continuation {
+			break
+		}
+	}
+
+	return headers, payload, nil
+}
+
+func (d *Decoder) writeSegment(h lineHeader, data []byte) error {
+	if !d.Interlaced && h.field != 0 {
+		return fmt.Errorf("field identification is set in progressive video")
+	}
+
+	if (h.length%d.params.pgroupSize) != 0 || (h.offset%d.params.pgroupPixels) != 0 {
+		return fmt.Errorf("segment is not aligned to pixel groups")
+	}
+
+	if h.lineNo >= d.params.fieldHeight ||
+		(h.offset+h.length/d.params.pgroupSize*d.params.pgroupPixels) > d.Width {
+		return fmt.Errorf("segment is out of bounds")
+	}
+
+	if d.frame == nil {
+		d.frame = make([]byte, d.params.stride*d.Height)
+	}
+
+	pos := frameLine(h.lineNo, h.field, d.Interlaced)*d.params.stride +
+		h.offset/d.params.pgroupPixels*d.params.pgroupSize
+	copy(d.frame[pos:], data)
+	d.frameReceivedSize += h.length
+
+	return nil
+}
+
+// Decode decodes a frame from a RTP packet.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([]byte, error) {
+	if d.frameStarted && pkt.SequenceNumber != d.frameNextSeqNum {
+		d.resetFrame()
+		return nil, fmt.Errorf("discarding frame since a RTP packet is missing")
+	}
+
+	d.frameStarted = true
+	d.frameNextSeqNum = pkt.SequenceNumber + 1
+
+	headers, payload, err := d.decodeHeaders(pkt.Payload)
+	if err != nil {
+		d.resetFrame()
+		return nil, err
+	}
+
+	for _, h := range headers {
+		if len(payload) < h.length {
+			d.resetFrame()
+			return nil, fmt.Errorf("payload is too short")
+		}
+
+		err = d.writeSegment(h, payload[:h.length])
+		if err != nil {
+			d.resetFrame()
+			return nil, err
+		}
+
+		payload = payload[h.length:]
+	}
+
+	if !pkt.Marker {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	// the marker is set at the end of each field
+	if d.Interlaced && headers[len(headers)-1].field == 0 {
+		d.firstFieldReceived = true
+		return nil, ErrMorePacketsNeeded
+	}
+
+	frame := d.frame
+	complete := (d.frameReceivedSize == len(frame)) && (!d.Interlaced || d.firstFieldReceived)
+	d.resetFrame()
+
+	if !complete {
+		if !d.firstFrameReceived {
+			return nil, ErrNonStartingPacketAndNoPrevious
+		}
+		return nil, fmt.Errorf("discarding incomplete frame")
+	}
+
+	d.firstFrameReceived = true
+
+	return frame, nil
+}
@@ -0,0 +1,135 @@
+package rtprawvideo
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{
+				Sampling:   ca.sampling,
+				Depth:      ca.depth,
+				Width:      ca.width,
+				Height:     ca.height,
+				Interlaced: ca.interlaced,
+			}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var frame []byte
+
+			for _, pkt := range ca.pkts {
+				frame, err = d.Decode(pkt)
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, ca.frame, frame)
+		})
+	}
+}
+
+func TestDecodeMultipleLines(t *testing.T) {
+	d := &Decoder{
+		Sampling: "RGB",
+		Depth:    8,
+		Width:    2,
+		Height:   2,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	// lines are provided in reverse order
+	frame, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{
+			0x00, 0x00,
+			0x00, 0x06, 0x00, 0x01, 0x80, 0x00,
+			0x00, 0x06, 0x00, 0x00, 0x00, 0x00,
+			7, 8, 9, 10, 11, 12,
+			1, 2, 3, 4, 5, 6,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, frame)
+}
+
+func TestDecodeNonStartingPacketAndNoPrevious(t *testing.T) {
+	d := &Decoder{
+		Sampling: "RGB",
+		Depth:    8,
+		Width:    4,
+		Height:   2,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(cases[1].pkts[1])
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+
+	_, err = d.Decode(cases[1].pkts[0])
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	frame, err := d.Decode(cases[1].pkts[1])
+	require.NoError(t, err)
+	require.Equal(t, cases[1].frame, frame)
+}
+
+func TestDecodeMissingPacket(t *testing.T) {
+	d := &Decoder{
+		Sampling: "RGB",
+		Depth:    8,
+		Width:    4,
+		Height:   1,
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(cases[2].pkts[0])
+	require.Equal(t, ErrMorePacketsNeeded, err)
+
+	pkt := *cases[2].pkts[1]
+	pkt.SequenceNumber++
+	_, err = d.Decode(&pkt)
+	require.EqualError(t, err, "discarding frame since a RTP packet is missing")
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(_ *testing.T, a []byte, am bool, b []byte, bm bool) {
+		d := &Decoder{
+			Sampling: "YCbCr-4:2:2",
+			Depth:    10,
+			Width:    4,
+			Height:   4,
+		}
+		err := d.Init()
+		if err != nil {
+			panic(err)
+		}
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Marker:         am,
+				SequenceNumber: 17645,
+			},
+			Payload: a,
+		})
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				Marker:         bm,
+				SequenceNumber: 17646,
+			},
+			Payload: b,
+		})
+	})
+}
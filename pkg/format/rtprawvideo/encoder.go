@@ -0,0 +1,187 @@
+package rtprawvideo
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1450 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header) - 10 (SRTP overhead)
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP encoder for uncompressed video.
+// Frames must be provided in the pixel group format of RFC4175,
+// with lines stored one after the other.
+// Specification: RFC4175
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// color subsampling (for instance, "YCbCr-4:2:2").
+	Sampling string
+
+	// number of bits per sample.
+	Depth int
+
+	// width of frames.
+	Width int
+
+	// height of frames.
+	Height int
+
+	// whether the video is interlaced.
+	// In this case, each frame is transmitted as two fields,
+	// and the marker bit is set at the end of each field.
+	Interlaced bool
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1450.
+	PayloadMaxSize int
+
+	params         frameParams
+	sequenceNumber uint32
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	err := e.params.init(e.Sampling, e.Depth, e.Width, e.Height, e.Interlaced)
+	if err != nil {
+		return err
+	}
+
+	if e.SSRC == nil {
+		var v uint32
+		v, err = randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		var v uint32
+		v, err = randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	if e.PayloadMaxSize < (2 + 6 + e.params.pgroupSize) {
+		return fmt.Errorf("payload max size is too small")
+	}
+
+	e.sequenceNumber = uint32(*e.InitialSequenceNumber)
+	return nil
+}
+
+// Encode encodes a frame into RTP packets.
+func (e *Encoder) Encode(frame []byte) ([]*rtp.Packet, error) {
+	if len(frame) != e.params.stride*e.Height {
+		return nil, fmt.Errorf("invalid frame size: expected %d, got %d",
+			e.params.stride*e.Height, len(frame))
+	}
+
+	var ret []*rtp.Packet
+
+	fieldCount := 1
+	if e.Interlaced {
+		fieldCount = 2
+	}
+
+	for field := range fieldCount {
+		ret = append(ret, e.encodeField(frame, field)...)
+	}
+
+	return ret, nil
+}
+
+func (e *Encoder) encodeField(frame []byte, field int) []*rtp.Packet {
+	var ret []*rtp.Packet
+	lineNo := 0
+	offset := 0
+
+	for lineNo < e.params.fieldHeight {
+		avail := e.PayloadMaxSize - 2
+		var headers []lineHeader
+
+		for lineNo < e.params.fieldHeight && avail >= (6+e.params.pgroupSize) {
+			n := min(e.Width-offset,
+				(avail-6)/e.params.pgroupSize*e.params.pgroupPixels)
+			h := lineHeader{
+				length: n / e.params.pgroupPixels * e.params.pgroupSize,
+				field:  field,
+				lineNo: lineNo,
+				offset: offset,
+			}
+			headers = append(headers, h)
+			avail -= 6 + h.length
+
+			offset += n
+			if offset == e.Width {
+				lineNo++
+				offset = 0
+			}
+		}
+
+		payload := make([]byte, 2, e.PayloadMaxSize-avail)
+		payload[0] = byte(e.sequenceNumber >> 24)
+		payload[1] = byte(e.sequenceNumber >> 16)
+
+		for i, h := range headers {
+			var continuation byte
+			if i != (len(headers) - 1) {
+				continuation = 1
+			}
+
+			payload = append(payload,
+				byte(h.length>>8), byte(h.length),
+				byte(h.field<<7)|byte(h.lineNo>>8), byte(h.lineNo),
+				continuation<<7|byte(h.offset>>8), byte(h.offset))
+		}
+
+		for _, h := range headers {
+			pos := frameLine(h.lineNo, h.field, e.Interlaced)*e.params.stride +
+				h.offset/e.params.pgroupPixels*e.params.pgroupSize
+			payload = append(payload, frame[pos:pos+h.length]...)
+		}
+
+		ret = append(ret, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        rtpVersion,
+				PayloadType:    e.PayloadType,
+				SequenceNumber: uint16(e.sequenceNumber),
+				SSRC:           *e.SSRC,
+				Marker:         lineNo == e.params.fieldHeight,
+			},
+			Payload: payload,
+		})
+
+		e.sequenceNumber++
+	}
+
+	return ret
+}
@@ -0,0 +1,280 @@
+package rtprawvideo
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+func generateFrame(size int) []byte {
+	frame := make([]byte, size)
+	for i := range frame {
+		frame[i] = byte(i)
+	}
+	return frame
+}
+
+var cases = []struct {
+	name           string
+	sampling       string
+	depth          int
+	width          int
+	height         int
+	interlaced     bool
+	payloadMaxSize int
+	frame          []byte
+	pkts           []*rtp.Packet
+}{
+	{
+		"single packet",
+		"YCbCr-4:2:2",
+		8,
+		4,
+		2,
+		false,
+		0,
+		generateFrame(16),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{
+						0x00, 0x00,
+						0x00, 0x08, 0x00, 0x00, 0x80, 0x00,
+						0x00, 0x08, 0x00, 0x01, 0x00, 0x00,
+					},
+					generateFrame(16),
+				),
+			},
+		},
+	},
+	{
+		"partial lines",
+		"RGB",
+		8,
+		4,
+		2,
+		false,
+		2 + 6 + 15,
+		generateFrame(24),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x00},
+					generateFrame(12),
+				),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0x00, 0x00, 0x00, 0x0c, 0x00, 0x01, 0x00, 0x00},
+					generateFrame(24)[12:],
+				),
+			},
+		},
+	},
+	{
+		"split line",
+		"RGB",
+		8,
+		4,
+		1,
+		false,
+		2 + 6 + 6,
+		generateFrame(12),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00},
+					generateFrame(6),
+				),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x02},
+					generateFrame(12)[6:],
+				),
+			},
+		},
+	},
+	{
+		"interlaced",
+		"YCbCr-4:2:2",
+		10,
+		2,
+		2,
+		true,
+		0,
+		generateFrame(10),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00},
+					generateFrame(10)[:5],
+				),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0x00, 0x00, 0x00, 0x05, 0x80, 0x00, 0x00, 0x00},
+					generateFrame(10)[5:],
+				),
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				Sampling:              ca.sampling,
+				Depth:                 ca.depth,
+				Width:                 ca.width,
+				Height:                ca.height,
+				Interlaced:            ca.interlaced,
+				SSRC:                  ptrOf(uint32(0x9dbb7812)),
+				InitialSequenceNumber: ptrOf(uint16(0x44ed)),
+				PayloadMaxSize:        ca.payloadMaxSize,
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.frame)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeExtendedSequenceNumber(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		Sampling:              "RGB",
+		Depth:                 8,
+		Width:                 4,
+		Height:                2,
+		SSRC:                  ptrOf(uint32(0x9dbb7812)),
+		InitialSequenceNumber: ptrOf(uint16(0xffff)),
+		PayloadMaxSize:        2 + 6 + 12,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	pkts, err := e.Encode(generateFrame(24))
+	require.NoError(t, err)
+	require.Equal(t, uint16(0xffff), pkts[0].SequenceNumber)
+	require.Equal(t, []byte{0x00, 0x00}, pkts[0].Payload[:2])
+	require.Equal(t, uint16(0), pkts[1].SequenceNumber)
+	require.Equal(t, []byte{0x00, 0x01}, pkts[1].Payload[:2])
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+		Sampling:    "RGB",
+		Depth:       8,
+		Width:       4,
+		Height:      2,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
+
+func TestEncodeInvalidParams(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		sampling string
+		depth    int
+		width    int
+		height   int
+		err      string
+	}{
+		{"sampling", "YCbCr-4:2:0", 8, 4, 2, "unsupported sampling: 'YCbCr-4:2:0'"},
+		{"depth", "RGB", 9, 4, 2, "unsupported depth: 9"},
+		{"width", "YCbCr-4:2:2", 8, 3, 2, "invalid width: 3"},
+		{"height", "RGB", 8, 4, 0, "invalid height: 0"},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType: 96,
+				Sampling:    ca.sampling,
+				Depth:       ca.depth,
+				Width:       ca.width,
+				Height:      ca.height,
+			}
+			err := e.Init()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
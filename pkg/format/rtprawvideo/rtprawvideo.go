@@ -0,0 +1,110 @@
+// Package rtprawvideo contains a RTP decoder and encoder for uncompressed video.
+package rtprawvideo
+
+import (
+	"fmt"
+)
+
+const (
+	// maximum line number and pixel offset that can be written in a line header.
+	maxLineValue = 0x7FFF
+
+	// maximum size of a frame.
+	maxFrameSize = 128 * 1024 * 1024
+)
+
+// pixelGroup returns the size in bytes and the number of pixels
+// of the smallest group of pixels that ends on a byte boundary.
+// Specification: RFC4175, 4.3
+func pixelGroup(sampling string, depth int) (int, int, error) {
+	switch sampling {
+	case "RGB", "BGR", "YCbCr-4:4:4":
+		switch depth {
+		case 8:
+			return 3, 1, nil
+		case 10:
+			return 15, 4, nil
+		case 12:
+			return 9, 2, nil
+		case 16:
+			return 6, 1, nil
+		}
+
+	case "RGBA", "BGRA":
+		switch depth {
+		case 8:
+			return 4, 1, nil
+		case 10:
+			return 5, 1, nil
+		case 12:
+			return 6, 1, nil
+		case 16:
+			return 8, 1, nil
+		}
+
+	case "YCbCr-4:2:2":
+		switch depth {
+		case 8:
+			return 4, 2, nil
+		case 10:
+			return 5, 2, nil
+		case 12:
+			return 6, 2, nil
+		case 16:
+			return 8, 2, nil
+		}
+
+	default:
+		return 0, 0, fmt.Errorf("unsupported sampling: '%s'", sampling)
+	}
+
+	return 0, 0, fmt.Errorf("unsupported depth: %d", depth)
+}
+
+// frameParams contains parameters shared by the decoder and the encoder.
+type frameParams struct {
+	pgroupSize   int
+	pgroupPixels int
+	stride       int
+	fieldHeight  int
+}
+
+func (p *frameParams) init(sampling string, depth int, width int, height int, interlaced bool) error {
+	var err error
+	p.pgroupSize, p.pgroupPixels, err = pixelGroup(sampling, depth)
+	if err != nil {
+		return err
+	}
+
+	if width <= 0 || width > (maxLineValue+1) || (width%p.pgroupPixels) != 0 {
+		return fmt.Errorf("invalid width: %d", width)
+	}
+
+	p.fieldHeight = height
+	if interlaced {
+		if (height % 2) != 0 {
+			return fmt.Errorf("invalid height: %d", height)
+		}
+		p.fieldHeight = height / 2
+	}
+
+	if height <= 0 || p.fieldHeight > (maxLineValue+1) {
+		return fmt.Errorf("invalid height: %d", height)
+	}
+
+	p.stride = width / p.pgroupPixels * p.pgroupSize
+
+	if (p.stride * height) > maxFrameSize {
+		return fmt.Errorf("frame size (%d) is too big, maximum is %d", p.stride*height, maxFrameSize)
+	}
+
+	return nil
+}
+
+// frameLine returns the line of the frame that corresponds to a line of a field.
+func frameLine(lineNo int, field int, interlaced bool) int {
+	if interlaced {
+		return lineNo*2 + field
+	}
+	return lineNo
+}
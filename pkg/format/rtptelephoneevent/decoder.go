@@ -0,0 +1,46 @@
+package rtptelephoneevent
+
+import (
+	"errors"
+
+	"github.com/pion/rtp"
+)
+
+// ErrMorePacketsNeeded is returned when more packets are needed to complete an event.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// Decoder is a RTP/telephone-event decoder.
+// Specification: RFC4733
+type Decoder struct {
+	lastEndTimestamp *uint32
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	return nil
+}
+
+// Decode decodes an event from a RTP packet.
+// The event is returned when its end is received, while packets that
+// update the duration of the event or repeat its end return ErrMorePacketsNeeded.
+func (d *Decoder) Decode(pkt *rtp.Packet) (*Event, error) {
+	var evt Event
+	err := evt.Unmarshal(pkt.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if !evt.End {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	// end of the event is usually sent multiple times.
+	if d.lastEndTimestamp != nil && *d.lastEndTimestamp == pkt.Timestamp {
+		return nil, ErrMorePacketsNeeded
+	}
+
+	ts := pkt.Timestamp
+	d.lastEndTimestamp = &ts
+
+	return &evt, nil
+}
@@ -0,0 +1,45 @@
+package rtptelephoneevent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var events []*Event
+
+			for _, pkt := range ca.pkts {
+				evt, err2 := d.Decode(pkt)
+				if errors.Is(err2, ErrMorePacketsNeeded) {
+					continue
+				}
+				require.NoError(t, err2)
+				events = append(events, evt)
+			}
+
+			require.Equal(t, []*Event{&ca.event}, events)
+		})
+	}
+}
+
+func TestDecodeInvalidSize(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(cases[0].pkts[0])
+	require.ErrorIs(t, err, ErrMorePacketsNeeded)
+
+	pkt := *cases[0].pkts[0]
+	pkt.Payload = []byte{1, 2}
+	_, err = d.Decode(&pkt)
+	require.EqualError(t, err, "invalid event size (2)")
+}
@@ -0,0 +1,133 @@
+package rtptelephoneevent
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion = 2
+
+	// the end of an event is sent three times, as suggested by RFC4733, section 2.5.1.4.
+	endPacketCount = 3
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/telephone-event encoder.
+// Specification: RFC4733
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// duration of each packet, in clock rate units (optional).
+	// It defaults to 400 (50ms with a 8khz clock rate).
+	PacketDuration uint16
+
+	sequenceNumber uint16
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PacketDuration == 0 {
+		e.PacketDuration = 400
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	return nil
+}
+
+// Encode encodes an event into RTP packets.
+// Duration of the event must be filled, while End is ignored.
+// The first packet has the marker bit set; the following ones update the duration of the event;
+// the last ones signal the end of the event.
+// All packets share the same timestamp, that is the start of the event,
+// and must be sent every PacketDuration, except for the last ones, that can be sent together.
+func (e *Encoder) Encode(evt Event) ([]*rtp.Packet, error) {
+	if evt.Duration == 0 {
+		return nil, fmt.Errorf("invalid duration")
+	}
+
+	var packets []*rtp.Packet
+
+	for dur := e.PacketDuration; dur < evt.Duration; dur += e.PacketDuration {
+		pkt, err := e.encodePacket(Event{
+			Code:     evt.Code,
+			Volume:   evt.Volume,
+			Duration: dur,
+		}, packets == nil)
+		if err != nil {
+			return nil, err
+		}
+		packets = append(packets, pkt)
+	}
+
+	for range endPacketCount {
+		pkt, err := e.encodePacket(Event{
+			Code:     evt.Code,
+			End:      true,
+			Volume:   evt.Volume,
+			Duration: evt.Duration,
+		}, packets == nil)
+		if err != nil {
+			return nil, err
+		}
+		packets = append(packets, pkt)
+	}
+
+	return packets, nil
+}
+
+func (e *Encoder) encodePacket(evt Event, marker bool) (*rtp.Packet, error) {
+	payload, err := evt.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.PayloadType,
+			SequenceNumber: e.sequenceNumber,
+			SSRC:           *e.SSRC,
+			Marker:         marker,
+		},
+		Payload: payload,
+	}
+
+	e.sequenceNumber++
+
+	return pkt, nil
+}
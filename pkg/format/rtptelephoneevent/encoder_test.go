@@ -0,0 +1,148 @@
+package rtptelephoneevent
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
+var cases = []struct {
+	name  string
+	event Event
+	pkts  []*rtp.Packet
+}{
+	{
+		"digit",
+		Event{
+			Code:     5,
+			End:      true,
+			Volume:   10,
+			Duration: 1000,
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    101,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x05, 0x0a, 0x01, 0x90},
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    101,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x05, 0x0a, 0x03, 0x20},
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    101,
+					SequenceNumber: 17647,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x05, 0x8a, 0x03, 0xe8},
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    101,
+					SequenceNumber: 17648,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x05, 0x8a, 0x03, 0xe8},
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    101,
+					SequenceNumber: 17649,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x05, 0x8a, 0x03, 0xe8},
+			},
+		},
+	},
+	{
+		"short",
+		Event{
+			Code:     EventPound,
+			End:      true,
+			Duration: 160,
+		},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    101,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x0b, 0x80, 0x00, 0xa0},
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    101,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x0b, 0x80, 0x00, 0xa0},
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    101,
+					SequenceNumber: 17647,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{0x0b, 0x80, 0x00, 0xa0},
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           101,
+				SSRC:                  ptrOf(uint32(0x9dbb7812)),
+				InitialSequenceNumber: ptrOf(uint16(0x44ed)),
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.event)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 101,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
@@ -0,0 +1,70 @@
+package rtptelephoneevent
+
+import (
+	"fmt"
+)
+
+const (
+	eventSize = 4
+)
+
+// event codes of DTMF digits.
+const (
+	EventStar  = 10
+	EventPound = 11
+	EventA     = 12
+	EventB     = 13
+	EventC     = 14
+	EventD     = 15
+)
+
+// Event is a telephone event.
+// Specification: RFC4733, section 2.3
+type Event struct {
+	// event code.
+	// Codes 0-9 are DTMF digits, the others are defined by constants.
+	Code uint8
+
+	// whether this is the end of the event.
+	End bool
+
+	// power level of the tone, in -dBm0 (0-63).
+	Volume uint8
+
+	// duration of the event, in clock rate units.
+	Duration uint16
+}
+
+// Unmarshal decodes an event.
+func (e *Event) Unmarshal(buf []byte) error {
+	if len(buf) < eventSize {
+		return fmt.Errorf("invalid event size (%d)", len(buf))
+	}
+
+	e.Code = buf[0]
+	e.End = (buf[1] & 0x80) != 0
+	e.Volume = buf[1] & 0x3F
+	e.Duration = uint16(buf[2])<<8 | uint16(buf[3])
+
+	return nil
+}
+
+// Marshal encodes an event.
+func (e Event) Marshal() ([]byte, error) {
+	if e.Volume > 63 {
+		return nil, fmt.Errorf("invalid volume (%d)", e.Volume)
+	}
+
+	buf := make([]byte, eventSize)
+	buf[0] = e.Code
+
+	if e.End {
+		buf[1] = 0x80
+	}
+	buf[1] |= e.Volume
+
+	buf[2] = byte(e.Duration >> 8)
+	buf[3] = byte(e.Duration)
+
+	return buf, nil
+}
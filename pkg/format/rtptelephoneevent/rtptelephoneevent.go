@@ -0,0 +1,2 @@
+// Package rtptelephoneevent contains a RTP decoder and encoder for telephone events (DTMF).
+package rtptelephoneevent
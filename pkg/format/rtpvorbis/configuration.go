@@ -0,0 +1,180 @@
+package rtpvorbis
+
+import (
+	"fmt"
+)
+
+func readVarLen(buf []byte, pos *int) (int, error) {
+	v := 0
+
+	for {
+		if *pos >= len(buf) {
+			return 0, fmt.Errorf("not enough bytes")
+		}
+
+		b := buf[*pos]
+		*pos++
+
+		v = (v << 7) | int(b&0x7F)
+		if v > 0xFFFF {
+			return 0, fmt.Errorf("invalid length")
+		}
+
+		if (b & 0x80) == 0 {
+			return v, nil
+		}
+	}
+}
+
+func appendVarLen(buf []byte, v int) []byte {
+	n := 1
+	for tmp := v >> 7; tmp > 0; tmp >>= 7 {
+		n++
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		b := byte((v >> (7 * i)) & 0x7F)
+		if i != 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+	}
+
+	return buf
+}
+
+// PackedHeaders contains the headers that are needed to decode a Vorbis stream.
+type PackedHeaders struct {
+	// identifier of the configuration, that is written in every RTP packet.
+	Ident uint32
+
+	Identification []byte
+	Comment        []byte
+	Setup          []byte
+}
+
+func (h *PackedHeaders) unmarshal(buf []byte) (int, error) {
+	if len(buf) < 5 {
+		return 0, fmt.Errorf("not enough bytes")
+	}
+
+	h.Ident = uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2])
+	length := int(uint16(buf[3])<<8 | uint16(buf[4]))
+
+	n, err := h.unmarshalHeaders(buf[5:], length)
+	if err != nil {
+		return 0, err
+	}
+
+	return 5 + n, nil
+}
+
+// unmarshalHeaders decodes the number of headers, their lengths and the headers.
+// When length is negative, headers take the rest of the buffer.
+func (h *PackedHeaders) unmarshalHeaders(buf []byte, length int) (int, error) {
+	pos := 0
+
+	// number of headers minus one
+	headerCount, err := readVarLen(buf, &pos)
+	if err != nil {
+		return 0, err
+	}
+
+	if headerCount != 2 {
+		return 0, fmt.Errorf("unsupported number of headers (%d)", headerCount+1)
+	}
+
+	length1, err := readVarLen(buf, &pos)
+	if err != nil {
+		return 0, err
+	}
+
+	length2, err := readVarLen(buf, &pos)
+	if err != nil {
+		return 0, err
+	}
+
+	if length < 0 {
+		length = len(buf) - pos
+	}
+
+	if (length1+length2) > length || (len(buf)-pos) < length {
+		return 0, fmt.Errorf("invalid length")
+	}
+
+	h.Identification = buf[pos : pos+length1]
+	h.Comment = buf[pos+length1 : pos+length1+length2]
+	h.Setup = buf[pos+length1+length2 : pos+length]
+
+	return pos + length, nil
+}
+
+func (h PackedHeaders) marshal(buf []byte) ([]byte, error) {
+	length := len(h.Identification) + len(h.Comment) + len(h.Setup)
+	if length > 0xFFFF {
+		return nil, fmt.Errorf("headers are too big")
+	}
+
+	buf = append(buf,
+		byte(h.Ident>>16), byte(h.Ident>>8), byte(h.Ident),
+		byte(length>>8), byte(length))
+
+	return h.marshalHeaders(buf), nil
+}
+
+func (h PackedHeaders) marshalHeaders(buf []byte) []byte {
+	buf = appendVarLen(buf, 2)
+	buf = appendVarLen(buf, len(h.Identification))
+	buf = appendVarLen(buf, len(h.Comment))
+	buf = append(buf, h.Identification...)
+	buf = append(buf, h.Comment...)
+	buf = append(buf, h.Setup...)
+
+	return buf
+}
+
+// Configuration is a packed configuration, that is transmitted
+// out-of-band in the "configuration" parameter of the SDP or in-band inside RTP packets.
+// Specification: RFC5215, 3.2.1
+type Configuration []PackedHeaders
+
+// Unmarshal decodes a Configuration.
+func (c *Configuration) Unmarshal(buf []byte) error {
+	if len(buf) < 4 {
+		return fmt.Errorf("not enough bytes")
+	}
+
+	count := uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])
+	if count == 0 || count > 16 {
+		return fmt.Errorf("invalid number of packed headers (%d)", count)
+	}
+
+	buf = buf[4:]
+	*c = make(Configuration, count)
+
+	for i := range *c {
+		n, err := (*c)[i].unmarshal(buf)
+		if err != nil {
+			return err
+		}
+		buf = buf[n:]
+	}
+
+	return nil
+}
+
+// Marshal encodes a Configuration.
+func (c Configuration) Marshal() ([]byte, error) {
+	n := len(c)
+	buf := []byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+
+	for _, h := range c {
+		var err error
+		buf, err = h.marshal(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf, nil
+}
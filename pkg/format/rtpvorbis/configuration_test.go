@@ -0,0 +1,77 @@
+package rtpvorbis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesConfiguration = []struct {
+	name string
+	enc  []byte
+	dec  Configuration
+}{
+	{
+		"single",
+		[]byte{
+			0x00, 0x00, 0x00, 0x01, 0xc8, 0x8f, 0x21, 0x00,
+			0x09, 0x02, 0x03, 0x02, 1, 2, 3, 4, 5, 6,
+			7, 8, 9,
+		},
+		Configuration{{
+			Ident:          0xc88f21,
+			Identification: []byte{1, 2, 3},
+			Comment:        []byte{4, 5},
+			Setup:          []byte{6, 7, 8, 9},
+		}},
+	},
+	{
+		"long lengths",
+		append([]byte{
+			0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x01,
+			0x2d, 0x02, 0x82, 0x2c, 0x00,
+		}, make([]byte, 301)...),
+		Configuration{{
+			Ident:          1,
+			Identification: make([]byte, 300),
+			Comment:        []byte{},
+			Setup:          []byte{0},
+		}},
+	},
+}
+
+func TestConfigurationUnmarshal(t *testing.T) {
+	for _, ca := range casesConfiguration {
+		t.Run(ca.name, func(t *testing.T) {
+			var dec Configuration
+			err := dec.Unmarshal(ca.enc)
+			require.NoError(t, err)
+			require.Equal(t, ca.dec, dec)
+		})
+	}
+}
+
+func TestConfigurationMarshal(t *testing.T) {
+	for _, ca := range casesConfiguration {
+		t.Run(ca.name, func(t *testing.T) {
+			enc, err := ca.dec.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, ca.enc, enc)
+		})
+	}
+}
+
+func FuzzConfigurationUnmarshal(f *testing.F) {
+	for _, ca := range casesConfiguration {
+		f.Add(ca.enc)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var conf Configuration
+		err := conf.Unmarshal(b)
+		if err == nil {
+			_, err = conf.Marshal()
+			require.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package rtpvorbis
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// maximum size of a Vorbis packet.
+const maxPacketSize = 1 * 1024 * 1024
+
+// ErrMorePacketsNeeded is returned when more packets are needed.
+var ErrMorePacketsNeeded = errors.New("need more packets")
+
+// ErrNonStartingPacketAndNoPrevious is returned when we received a non-starting
+// fragment and we didn't received anything before.
+// It's normal to receive this when decoding a stream that has been already
+// running for some time.
+var ErrNonStartingPacketAndNoPrevious = errors.New(
+	"received a non-starting fragment without any previous starting fragment")
+
+// fragment type.
+const (
+	fragmentTypeNone         = 0
+	fragmentTypeStart        = 1
+	fragmentTypeContinuation = 2
+	fragmentTypeEnd          = 3
+)
+
+// Vorbis data type.
+const (
+	dataTypeRaw           = 0
+	dataTypeConfiguration = 1
+	dataTypeComment       = 2
+)
+
+func joinFragments(fragments [][]byte, size int) []byte {
+	ret := make([]byte, size)
+	n := 0
+	for _, p := range fragments {
+		n += copy(ret[n:], p)
+	}
+	return ret
+}
+
+// Decoder is a RTP/Vorbis decoder.
+// Specification: RFC5215
+type Decoder struct {
+	// called when an in-band configuration is received (optional).
+	OnConfiguration func(PackedHeaders)
+
+	firstPacketReceived bool
+	fragments           [][]byte
+	fragmentsSize       int
+	fragmentsIdent      uint32
+	fragmentsDataType   byte
+	fragmentNextSeqNum  uint16
+}
+
+// Init initializes the decoder.
+func (d *Decoder) Init() error {
+	if d.OnConfiguration == nil {
+		d.OnConfiguration = func(PackedHeaders) {}
+	}
+	return nil
+}
+
+func (d *Decoder) resetFragments() {
+	d.fragments = d.fragments[:0]
+	d.fragmentsSize = 0
+}
+
+func (d *Decoder) decodeData(pkt *rtp.Packet) (uint32, byte, [][]byte, error) {
+	if len(pkt.Payload) < 4 {
+		d.resetFragments()
+		return 0, 0, nil, fmt.Errorf("payload is too short")
+	}
+
+	ident := uint32(pkt.Payload[0])<<16 | uint32(pkt.Payload[1])<<8 | uint32(pkt.Payload[2])
+	fragmentType := pkt.Payload[3] >> 6
+	dataType := (pkt.Payload[3] >> 4) & 0x03
+	count := int(pkt.Payload[3] & 0x0F)
+	payload := pkt.Payload[4:]
+
+	switch fragmentType {
+	case fragmentTypeNone:
+		d.resetFragments()
+		d.firstPacketReceived = true
+
+		if count == 0 {
+			return 0, 0, nil, fmt.Errorf("invalid number of packets")
+		}
+
+		datas := make([][]byte, count)
+
+		for i := range datas {
+			if len(payload) < 2 {
+				return 0, 0, nil, fmt.Errorf("payload is too short")
+			}
+
+			le := int(uint16(payload[0])<<8 | uint16(payload[1]))
+			payload = payload[2:]
+
+			if len(payload) < le {
+				return 0, 0, nil, fmt.Errorf("payload is too short")
+			}
+
+			datas[i] = payload[:le]
+			payload = payload[le:]
+		}
+
+		return ident, dataType, datas, nil
+
+	case fragmentTypeStart:
+		d.resetFragments()
+		d.firstPacketReceived = true
+
+		if len(payload) < 2 {
+			return 0, 0, nil, fmt.Errorf("payload is too short")
+		}
+
+		le := int(uint16(payload[0])<<8 | uint16(payload[1]))
+		if len(payload[2:]) < le {
+			return 0, 0, nil, fmt.Errorf("payload is too short")
+		}
+
+		d.fragments = append(d.fragments, payload[2:2+le])
+		d.fragmentsSize = le
+		d.fragmentsIdent = ident
+		d.fragmentsDataType = dataType
+		d.fragmentNextSeqNum = pkt.SequenceNumber + 1
+
+		return 0, 0, nil, ErrMorePacketsNeeded
+
+	default:
+		if d.fragmentsSize == 0 {
+			if !d.firstPacketReceived {
+				return 0, 0, nil, ErrNonStartingPacketAndNoPrevious
+			}
+
+			return 0, 0, nil, fmt.Errorf("received a non-starting fragment")
+		}
+
+		if pkt.SequenceNumber != d.fragmentNextSeqNum {
+			d.resetFragments()
+			return 0, 0, nil, fmt.Errorf("discarding frame since a RTP packet is missing")
+		}
+
+		if ident != d.fragmentsIdent || dataType != d.fragmentsDataType {
+			d.resetFragments()
+			return 0, 0, nil, fmt.Errorf("fragments have different headers")
+		}
+
+		if len(payload) < 2 {
+			d.resetFragments()
+			return 0, 0, nil, fmt.Errorf("payload is too short")
+		}
+
+		le := int(uint16(payload[0])<<8 | uint16(payload[1]))
+		if len(payload[2:]) < le {
+			d.resetFragments()
+			return 0, 0, nil, fmt.Errorf("payload is too short")
+		}
+
+		d.fragmentsSize += le
+
+		if d.fragmentsSize > maxPacketSize {
+			errSize := d.fragmentsSize
+			d.resetFragments()
+			return 0, 0, nil, fmt.Errorf("packet size (%d) is too big, maximum is %d",
+				errSize, maxPacketSize)
+		}
+
+		d.fragments = append(d.fragments, payload[2:2+le])
+		d.fragmentNextSeqNum++
+
+		if fragmentType != fragmentTypeEnd {
+			return 0, 0, nil, ErrMorePacketsNeeded
+		}
+
+		data := joinFragments(d.fragments, d.fragmentsSize)
+		d.resetFragments()
+
+		return ident, dataType, [][]byte{data}, nil
+	}
+}
+
+// Decode decodes Vorbis packets from a RTP packet.
+// In-band configurations are passed to OnConfiguration.
+func (d *Decoder) Decode(pkt *rtp.Packet) ([][]byte, error) {
+	ident, dataType, datas, err := d.decodeData(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dataType {
+	case dataTypeRaw:
+		return datas, nil
+
+	case dataTypeConfiguration:
+		for _, data := range datas {
+			// the ident of in-band configurations is taken from the payload header.
+			h := PackedHeaders{Ident: ident}
+			_, err = h.unmarshalHeaders(data, -1)
+			if err != nil {
+				return nil, fmt.Errorf("invalid configuration: %w", err)
+			}
+
+			d.OnConfiguration(h)
+		}
+
+		return nil, ErrMorePacketsNeeded
+
+	case dataTypeComment:
+		// legacy comments are not used
+		return nil, ErrMorePacketsNeeded
+
+	default:
+		return nil, fmt.Errorf("unsupported Vorbis data type (%d)", dataType)
+	}
+}
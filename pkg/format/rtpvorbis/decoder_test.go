@@ -0,0 +1,118 @@
+package rtpvorbis
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			d := &Decoder{}
+			err := d.Init()
+			require.NoError(t, err)
+
+			var packets [][]byte
+
+			for _, pkt := range ca.pkts {
+				var packets2 [][]byte
+				packets2, err = d.Decode(pkt)
+				if err == ErrMorePacketsNeeded {
+					continue
+				}
+				require.NoError(t, err)
+				packets = append(packets, packets2...)
+			}
+
+			require.Equal(t, [][]byte{ca.packet}, packets)
+		})
+	}
+}
+
+func TestDecodeMultiplePackets(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	packets, err := d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{
+			0xc8, 0x8f, 0x21, 0x02, 0x00, 0x02, 0x01, 0x02,
+			0x00, 0x03, 0x03, 0x04, 0x05,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1, 2}, {3, 4, 5}}, packets)
+}
+
+func TestDecodeConfiguration(t *testing.T) {
+	var conf *PackedHeaders
+
+	d := &Decoder{
+		OnConfiguration: func(h PackedHeaders) {
+			conf = &h
+		},
+	}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{
+			0xc8, 0x8f, 0x21, 0x11, 0x00, 0x0c, 0x02, 0x03,
+			0x02, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		},
+	})
+	require.Equal(t, ErrMorePacketsNeeded, err)
+	require.Equal(t, &PackedHeaders{
+		Ident:          0xc88f21,
+		Identification: []byte{1, 2, 3},
+		Comment:        []byte{4, 5},
+		Setup:          []byte{6, 7, 8, 9},
+	}, conf)
+}
+
+func TestDecodeNonStartingPacketAndNoPrevious(t *testing.T) {
+	d := &Decoder{}
+	err := d.Init()
+	require.NoError(t, err)
+
+	_, err = d.Decode(cases[1].pkts[1])
+	require.Equal(t, ErrNonStartingPacketAndNoPrevious, err)
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Fuzz(func(_ *testing.T, a []byte, b []byte) {
+		d := &Decoder{}
+		err := d.Init()
+		if err != nil {
+			panic(err)
+		}
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				SequenceNumber: 17645,
+			},
+			Payload: a,
+		})
+
+		d.Decode(&rtp.Packet{ //nolint:errcheck
+			Header: rtp.Header{
+				SequenceNumber: 17646,
+			},
+			Payload: b,
+		})
+	})
+}
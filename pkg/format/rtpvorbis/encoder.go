@@ -0,0 +1,141 @@
+package rtpvorbis
+
+import (
+	"crypto/rand"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	rtpVersion            = 2
+	defaultPayloadMaxSize = 1450 // 1500 (UDP MTU) - 20 (IP header) - 8 (UDP header) - 12 (RTP header) - 10 (SRTP overhead)
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// Encoder is a RTP/Vorbis encoder.
+// Specification: RFC5215
+type Encoder struct {
+	// payload type of packets.
+	PayloadType uint8
+
+	// identifier of the configuration.
+	Ident uint32
+
+	// SSRC of packets (optional).
+	// It defaults to a random value.
+	SSRC *uint32
+
+	// initial sequence number of packets (optional).
+	// It defaults to a random value.
+	InitialSequenceNumber *uint16
+
+	// maximum size of packet payloads (optional).
+	// It defaults to 1450.
+	PayloadMaxSize int
+
+	sequenceNumber uint16
+}
+
+// Init initializes the encoder.
+func (e *Encoder) Init() error {
+	if e.SSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		e.SSRC = &v
+	}
+	if e.InitialSequenceNumber == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		v2 := uint16(v)
+		e.InitialSequenceNumber = &v2
+	}
+	if e.PayloadMaxSize == 0 {
+		e.PayloadMaxSize = defaultPayloadMaxSize
+	}
+
+	e.sequenceNumber = *e.InitialSequenceNumber
+	return nil
+}
+
+// Encode encodes a Vorbis packet into RTP packets.
+func (e *Encoder) Encode(packet []byte) ([]*rtp.Packet, error) {
+	return e.encodeData(dataTypeRaw, packet), nil
+}
+
+// EncodeConfiguration encodes an in-band configuration into RTP packets.
+// The configuration is identified by Ident.
+func (e *Encoder) EncodeConfiguration(h PackedHeaders) ([]*rtp.Packet, error) {
+	return e.encodeData(dataTypeConfiguration, h.marshalHeaders(nil)), nil
+}
+
+func (e *Encoder) payloadHeader(fragmentType byte, dataType byte, count byte) []byte {
+	return []byte{
+		byte(e.Ident >> 16), byte(e.Ident >> 8), byte(e.Ident),
+		fragmentType<<6 | dataType<<4 | count,
+	}
+}
+
+func (e *Encoder) newPacket(payload []byte) *rtp.Packet {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.PayloadType,
+			SequenceNumber: e.sequenceNumber,
+			SSRC:           *e.SSRC,
+			Marker:         false,
+		},
+		Payload: payload,
+	}
+	e.sequenceNumber++
+	return pkt
+}
+
+func (e *Encoder) encodeData(dataType byte, data []byte) []*rtp.Packet {
+	// payload header + length
+	avail := e.PayloadMaxSize - 6
+
+	if len(data) <= avail {
+		payload := append(e.payloadHeader(fragmentTypeNone, dataType, 1),
+			byte(len(data)>>8), byte(len(data)))
+		payload = append(payload, data...)
+
+		return []*rtp.Packet{e.newPacket(payload)}
+	}
+
+	var ret []*rtp.Packet
+
+	for i := 0; len(data) > 0; i++ {
+		le := min(avail, len(data))
+
+		var fragmentType byte
+		switch {
+		case i == 0:
+			fragmentType = fragmentTypeStart
+		case le == len(data):
+			fragmentType = fragmentTypeEnd
+		default:
+			fragmentType = fragmentTypeContinuation
+		}
+
+		payload := append(e.payloadHeader(fragmentType, dataType, 0),
+			byte(le>>8), byte(le))
+		payload = append(payload, data[:le]...)
+		data = data[le:]
+
+		ret = append(ret, e.newPacket(payload))
+	}
+
+	return ret
+}
@@ -0,0 +1,160 @@
+package rtpvorbis
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
+func mergeBytes(vals ...[]byte) []byte {
+	size := 0
+	for _, v := range vals {
+		size += len(v)
+	}
+	res := make([]byte, size)
+
+	pos := 0
+	for _, v := range vals {
+		n := copy(res[pos:], v)
+		pos += n
+	}
+
+	return res
+}
+
+var cases = []struct {
+	name   string
+	packet []byte
+	pkts   []*rtp.Packet
+}{
+	{
+		"single",
+		[]byte{0x01, 0x02, 0x03, 0x04},
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: []byte{
+					0xc8, 0x8f, 0x21, 0x01, 0x00, 0x04, 0x01, 0x02,
+					0x03, 0x04,
+				},
+			},
+		},
+	},
+	{
+		"fragmented",
+		bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 1000),
+		[]*rtp.Packet{
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17645,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0xc8, 0x8f, 0x21, 0x40, 0x05, 0xa4},
+					bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 361),
+				),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17646,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0xc8, 0x8f, 0x21, 0x80, 0x05, 0xa4},
+					bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 361),
+				),
+			},
+			{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         false,
+					PayloadType:    96,
+					SequenceNumber: 17647,
+					SSRC:           0x9dbb7812,
+				},
+				Payload: mergeBytes(
+					[]byte{0xc8, 0x8f, 0x21, 0xc0, 0x04, 0x58},
+					bytes.Repeat([]byte{0x01, 0x02, 0x03, 0x04}, 278),
+				),
+			},
+		},
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, ca := range cases {
+		t.Run(ca.name, func(t *testing.T) {
+			e := &Encoder{
+				PayloadType:           96,
+				Ident:                 0xc88f21,
+				SSRC:                  ptrOf(uint32(0x9dbb7812)),
+				InitialSequenceNumber: ptrOf(uint16(0x44ed)),
+			}
+			err := e.Init()
+			require.NoError(t, err)
+
+			pkts, err := e.Encode(ca.packet)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkts, pkts)
+		})
+	}
+}
+
+func TestEncodeConfiguration(t *testing.T) {
+	e := &Encoder{
+		PayloadType:           96,
+		Ident:                 0xc88f21,
+		SSRC:                  ptrOf(uint32(0x9dbb7812)),
+		InitialSequenceNumber: ptrOf(uint16(0x44ed)),
+	}
+	err := e.Init()
+	require.NoError(t, err)
+
+	pkts, err := e.EncodeConfiguration(PackedHeaders{
+		Identification: []byte{1, 2, 3},
+		Comment:        []byte{4, 5},
+		Setup:          []byte{6, 7, 8, 9},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*rtp.Packet{{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         false,
+			PayloadType:    96,
+			SequenceNumber: 17645,
+			SSRC:           0x9dbb7812,
+		},
+		Payload: []byte{
+			0xc8, 0x8f, 0x21, 0x11, 0x00, 0x0c, 0x02, 0x03,
+			0x02, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		},
+	}}, pkts)
+}
+
+func TestEncodeRandomInitialState(t *testing.T) {
+	e := &Encoder{
+		PayloadType: 96,
+	}
+	err := e.Init()
+	require.NoError(t, err)
+	require.NotEqual(t, nil, e.SSRC)
+	require.NotEqual(t, nil, e.InitialSequenceNumber)
+}
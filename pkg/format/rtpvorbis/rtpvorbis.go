@@ -0,0 +1,2 @@
+// Package rtpvorbis contains a RTP/Vorbis decoder and encoder.
+package rtpvorbis
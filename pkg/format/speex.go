@@ -73,6 +73,13 @@ func (f *Speex) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *Speex) Clone() Format {
+	c := *f
+	c.VBR = clonePtr(f.VBR)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *Speex) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
@@ -0,0 +1,92 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtptelephoneevent"
+)
+
+// TelephoneEvent is the RTP format for telephone events (DTMF).
+// Specification: RFC4733
+type TelephoneEvent struct {
+	PayloadTyp uint8
+	ClockRat   int
+}
+
+func (f *TelephoneEvent) unmarshal(ctx *unmarshalContext) error {
+	f.PayloadTyp = ctx.payloadType
+
+	clockRate, err := strconv.ParseUint(ctx.clock, 10, 31)
+	if err != nil || clockRate == 0 {
+		return fmt.Errorf("invalid clock rate: '%s'", ctx.clock)
+	}
+	f.ClockRat = int(clockRate)
+
+	return nil
+}
+
+// Codec implements Format.
+func (f *TelephoneEvent) Codec() string {
+	return "telephone-event"
+}
+
+// ClockRate implements Format.
+func (f *TelephoneEvent) ClockRate() int {
+	return f.ClockRat
+}
+
+// PayloadType implements Format.
+func (f *TelephoneEvent) PayloadType() uint8 {
+	return f.PayloadTyp
+}
+
+// RTPMap implements Format.
+func (f *TelephoneEvent) RTPMap() string {
+	return "telephone-event/" + strconv.FormatInt(int64(f.ClockRat), 10)
+}
+
+// FMTP implements Format.
+func (f *TelephoneEvent) FMTP() map[string]string {
+	return nil
+}
+
+// Clone implements Format.
+func (f *TelephoneEvent) Clone() Format {
+	c := *f
+	return &c
+}
+
+// PTSEqualsDTS implements Format.
+func (f *TelephoneEvent) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *TelephoneEvent) CreateDecoder() (*rtptelephoneevent.Decoder, error) {
+	d := &rtptelephoneevent.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+func (f *TelephoneEvent) CreateEncoder() (*rtptelephoneevent.Encoder, error) {
+	e := &rtptelephoneevent.Encoder{
+		PayloadType:    f.PayloadTyp,
+		PacketDuration: uint16(f.ClockRat / 20),
+	}
+
+	err := e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
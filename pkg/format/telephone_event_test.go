@@ -0,0 +1,59 @@
+package format
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtptelephoneevent"
+)
+
+func TestTelephoneEventAttributes(t *testing.T) {
+	format := &TelephoneEvent{
+		PayloadTyp: 101,
+		ClockRat:   8000,
+	}
+	require.Equal(t, "telephone-event", format.Codec())
+	require.Equal(t, 8000, format.ClockRate())
+	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
+}
+
+func TestTelephoneEventDecEncoder(t *testing.T) {
+	format := &TelephoneEvent{
+		PayloadTyp: 101,
+		ClockRat:   8000,
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode(rtptelephoneevent.Event{
+		Code:     rtptelephoneevent.EventStar,
+		Duration: 1600,
+	})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+	require.Len(t, pkts, 6)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	var evt *rtptelephoneevent.Event
+
+	for _, pkt := range pkts {
+		evt, err = dec.Decode(pkt)
+		if errors.Is(err, rtptelephoneevent.ErrMorePacketsNeeded) {
+			continue
+		}
+		require.NoError(t, err)
+		break
+	}
+
+	require.Equal(t, &rtptelephoneevent.Event{
+		Code:     rtptelephoneevent.EventStar,
+		End:      true,
+		Duration: 1600,
+	}, evt)
+}
@@ -1,12 +1,15 @@
 package format
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpvorbis"
 )
 
 // Vorbis is the RTP format for the Vorbis codec.
@@ -87,7 +90,48 @@ func (f *Vorbis) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *Vorbis) Clone() Format {
+	c := *f
+	c.Configuration = bytes.Clone(f.Configuration)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *Vorbis) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
 }
+
+// CreateDecoder creates a decoder able to decode the content of the format.
+func (f *Vorbis) CreateDecoder() (*rtpvorbis.Decoder, error) {
+	d := &rtpvorbis.Decoder{}
+
+	err := d.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// CreateEncoder creates an encoder able to encode the content of the format.
+// The identifier of packets is taken from the first packed header of the configuration.
+func (f *Vorbis) CreateEncoder() (*rtpvorbis.Encoder, error) {
+	var conf rtpvorbis.Configuration
+	err := conf.Unmarshal(f.Configuration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	e := &rtpvorbis.Encoder{
+		PayloadType: f.PayloadTyp,
+		Ident:       conf[0].Ident,
+	}
+
+	err = e.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -18,3 +18,31 @@ func TestVorbisAttributes(t *testing.T) {
 	require.Equal(t, 48000, format.ClockRate())
 	require.Equal(t, true, format.PTSEqualsDTS(&rtp.Packet{}))
 }
+
+func TestVorbisDecEncoder(t *testing.T) {
+	format := &Vorbis{
+		PayloadTyp:   96,
+		SampleRate:   48000,
+		ChannelCount: 2,
+		Configuration: []byte{
+			0x00, 0x00, 0x00, 0x01, 0xc8, 0x8f, 0x21, 0x00,
+			0x09, 0x02, 0x03, 0x02, 1, 2, 3, 4, 5, 6,
+			7, 8, 9,
+		},
+	}
+
+	enc, err := format.CreateEncoder()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0xc88f21), enc.Ident)
+
+	pkts, err := enc.Encode([]byte{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, err)
+	require.Equal(t, format.PayloadType(), pkts[0].PayloadType)
+
+	dec, err := format.CreateDecoder()
+	require.NoError(t, err)
+
+	byts, err := dec.Decode(pkts[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0x01, 0x02, 0x03, 0x04}}, byts)
+}
@@ -80,6 +80,14 @@ func (f *VP8) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *VP8) Clone() Format {
+	c := *f
+	c.MaxFR = clonePtr(f.MaxFR)
+	c.MaxFS = clonePtr(f.MaxFS)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *VP8) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
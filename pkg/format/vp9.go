@@ -92,6 +92,15 @@ func (f *VP9) FMTP() map[string]string {
 	return fmtp
 }
 
+// Clone implements Format.
+func (f *VP9) Clone() Format {
+	c := *f
+	c.MaxFR = clonePtr(f.MaxFR)
+	c.MaxFS = clonePtr(f.MaxFS)
+	c.ProfileID = clonePtr(f.ProfileID)
+	return &c
+}
+
 // PTSEqualsDTS implements Format.
 func (f *VP9) PTSEqualsDTS(*rtp.Packet) bool {
 	return true
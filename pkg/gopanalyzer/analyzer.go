@@ -0,0 +1,306 @@
+// Package gopanalyzer contains a utility to analyze the GOP structure of H264 streams.
+package gopanalyzer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
+)
+
+const (
+	// clock rate of H264 timestamps.
+	clockRate = 90000
+
+	// max_size(first_mb_in_slice) + max_size(slice_type), with emulation prevention bytes.
+	maxBytesToGetSliceType = 12
+)
+
+// SliceType is the type of a H264 slice.
+// Specification: ITU-T Rec. H.264, Table 7-6
+type SliceType int
+
+// slice types.
+const (
+	SliceTypeP  SliceType = 0
+	SliceTypeB  SliceType = 1
+	SliceTypeI  SliceType = 2
+	SliceTypeSP SliceType = 3
+	SliceTypeSI SliceType = 4
+)
+
+var sliceTypeLabels = map[SliceType]string{
+	SliceTypeP:  "P",
+	SliceTypeB:  "B",
+	SliceTypeI:  "I",
+	SliceTypeSP: "SP",
+	SliceTypeSI: "SI",
+}
+
+// String implements fmt.Stringer.
+func (t SliceType) String() string {
+	if l, ok := sliceTypeLabels[t]; ok {
+		return l
+	}
+	return fmt.Sprintf("unknown (%d)", int(t))
+}
+
+func getSliceType(nalu []byte) (SliceType, error) {
+	buf := nalu[1:]
+	lb := min(len(buf), maxBytesToGetSliceType)
+
+	r := bits.Reader{Buf: h264.EmulationPreventionRemove(buf[:lb])}
+
+	_, err := r.ReadUE() // first_mb_in_slice
+	if err != nil {
+		return 0, err
+	}
+
+	sliceType, err := r.ReadUE()
+	if err != nil {
+		return 0, err
+	}
+
+	// values 5-9 have the same meaning of values 0-4,
+	// and indicate that all slices of the picture have the same type.
+	sliceType %= 5
+
+	return SliceType(sliceType), nil
+}
+
+// GOP contains the structure of a group of pictures,
+// that starts with an IDR frame and ends before the next one.
+type GOP struct {
+	// PTS of the IDR frame, in 90kHz units.
+	PTS int64
+	// distance between the IDR frame and the next one.
+	Duration time.Duration
+	// number of frames.
+	Length int
+	// number of frames that contain I or SI slices only.
+	IFrames int
+	// number of frames that contain P or SP slices, and no B slices.
+	PFrames int
+	// number of frames that contain B slices.
+	BFrames int
+	// number of frames that are used as reference by other frames.
+	ReferenceFrames int
+	// number of B frames that are used as reference by other frames (B-pyramid).
+	ReferenceBFrames int
+	// number of slices.
+	Slices int
+	// maximum number of slices of a frame.
+	MaxSlicesPerFrame int
+}
+
+// Report is a summary of the GOPs in the history of Analyzer.
+type Report struct {
+	// number of GOPs.
+	GOPs int
+	// minimum, average and maximum number of frames of a GOP.
+	MinLength int
+	AvgLength float64
+	MaxLength int
+	// average distance between IDR frames.
+	IDRPeriod time.Duration
+	// whether B frames are present.
+	BFrames bool
+	// whether B frames are used as reference by other frames.
+	ReferenceBFrames bool
+	// average number of slices of a frame.
+	AvgSlicesPerFrame float64
+	// maximum number of slices of a frame.
+	MaxSlicesPerFrame int
+}
+
+// Analyzer is a utility to analyze the GOP structure of H264 streams.
+// It consumes access units and reports length of GOPs, usage of B frames,
+// reference structure and slice counts.
+type Analyzer struct {
+	// maximum number of GOPs kept in history and used to compute Report.
+	// It defaults to 16.
+	HistorySize int
+
+	// called when a GOP is complete, that is, when the IDR frame of the next GOP is received.
+	OnGOP func(GOP)
+
+	mutex   sync.Mutex
+	cur     *GOP
+	history []GOP
+}
+
+// Initialize initializes Analyzer.
+func (a *Analyzer) Initialize() {
+	if a.HistorySize == 0 {
+		a.HistorySize = 16
+	}
+	if a.OnGOP == nil {
+		a.OnGOP = func(GOP) {}
+	}
+}
+
+// ProcessAccessUnit processes an access unit.
+// pts is the PTS of the access unit, in 90kHz units.
+// Access units received before the first IDR frame are discarded.
+func (a *Analyzer) ProcessAccessUnit(au [][]byte, pts int64) error {
+	gop, completed, err := a.processAccessUnit(au, pts)
+	if err != nil {
+		return err
+	}
+
+	if completed {
+		a.OnGOP(gop)
+	}
+
+	return nil
+}
+
+func (a *Analyzer) processAccessUnit(au [][]byte, pts int64) (GOP, bool, error) {
+	idr := false
+	slices := 0
+	reference := false
+	hasB := false
+	hasP := false
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		typ := h264.NALUType(nalu[0] & 0x1F)
+
+		switch typ {
+		case h264.NALUTypeIDR, h264.NALUTypeNonIDR:
+			if typ == h264.NALUTypeIDR {
+				idr = true
+			}
+
+			sliceType, err := getSliceType(nalu)
+			if err != nil {
+				return GOP{}, false, fmt.Errorf("invalid slice header: %w", err)
+			}
+
+			switch sliceType {
+			case SliceTypeB:
+				hasB = true
+
+			case SliceTypeP, SliceTypeSP:
+				hasP = true
+			}
+
+			// nal_ref_idc
+			if (nalu[0] >> 5) != 0 {
+				reference = true
+			}
+
+			slices++
+		}
+	}
+
+	// access unit without slices
+	if slices == 0 {
+		return GOP{}, false, nil
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var completed *GOP
+
+	if idr {
+		if a.cur != nil {
+			a.cur.Duration = time.Duration((pts - a.cur.PTS) * int64(time.Second) / clockRate)
+			a.history = append(a.history, *a.cur)
+			if len(a.history) > a.HistorySize {
+				a.history = a.history[1:]
+			}
+			completed = a.cur
+		}
+
+		a.cur = &GOP{PTS: pts}
+	} else if a.cur == nil {
+		return GOP{}, false, nil
+	}
+
+	a.cur.Length++
+	a.cur.Slices += slices
+	a.cur.MaxSlicesPerFrame = max(a.cur.MaxSlicesPerFrame, slices)
+
+	switch {
+	case hasB:
+		a.cur.BFrames++
+		if reference {
+			a.cur.ReferenceBFrames++
+		}
+
+	case hasP:
+		a.cur.PFrames++
+
+	default:
+		a.cur.IFrames++
+	}
+
+	if reference {
+		a.cur.ReferenceFrames++
+	}
+
+	if completed != nil {
+		return *completed, true, nil
+	}
+	return GOP{}, false, nil
+}
+
+// GOPs returns completed GOPs in the history, from the oldest to the newest.
+func (a *Analyzer) GOPs() []GOP {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	ret := make([]GOP, len(a.history))
+	copy(ret, a.history)
+	return ret
+}
+
+// Report returns a summary of completed GOPs in the history.
+func (a *Analyzer) Report() Report {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var r Report
+
+	if len(a.history) == 0 {
+		return r
+	}
+
+	r.GOPs = len(a.history)
+	r.MinLength = a.history[0].Length
+
+	totLength := 0
+	totSlices := 0
+	var totDuration time.Duration
+
+	for _, gop := range a.history {
+		r.MinLength = min(r.MinLength, gop.Length)
+		r.MaxLength = max(r.MaxLength, gop.Length)
+		r.MaxSlicesPerFrame = max(r.MaxSlicesPerFrame, gop.MaxSlicesPerFrame)
+
+		if gop.BFrames != 0 {
+			r.BFrames = true
+		}
+		if gop.ReferenceBFrames != 0 {
+			r.ReferenceBFrames = true
+		}
+
+		totLength += gop.Length
+		totSlices += gop.Slices
+		totDuration += gop.Duration
+	}
+
+	r.AvgLength = float64(totLength) / float64(len(a.history))
+	r.IDRPeriod = totDuration / time.Duration(len(a.history))
+	r.AvgSlicesPerFrame = float64(totSlices) / float64(totLength)
+
+	return r
+}
@@ -0,0 +1,121 @@
+package gopanalyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/bits"
+)
+
+func slice(idr bool, refIdc byte, firstMB uint32, sliceType SliceType) []byte {
+	var w bits.Writer
+
+	typ := byte(1)
+	if idr {
+		typ = 5
+	}
+	w.WriteBits(uint64(refIdc<<5|typ), 8)
+
+	w.WriteUE(firstMB)
+	w.WriteUE(uint32(sliceType) + 5)
+	w.WriteTrailingBits()
+
+	return w.Bytes()
+}
+
+func TestAnalyzer(t *testing.T) {
+	var gops []GOP
+
+	a := &Analyzer{
+		OnGOP: func(g GOP) {
+			gops = append(gops, g)
+		},
+	}
+	a.Initialize()
+
+	pts := int64(0)
+
+	process := func(au [][]byte) {
+		err := a.ProcessAccessUnit(au, pts)
+		require.NoError(t, err)
+		pts += 3600
+	}
+
+	// frames before the first IDR are discarded
+	process([][]byte{slice(false, 2, 0, SliceTypeP)})
+
+	for range 3 {
+		process([][]byte{
+			{0x67, 0x42}, // SPS
+			{0x68, 0xce}, // PPS
+			slice(true, 3, 0, SliceTypeI),
+			slice(true, 3, 40, SliceTypeI),
+		})
+
+		for range 4 {
+			process([][]byte{slice(false, 2, 0, SliceTypeP)})
+			process([][]byte{slice(false, 2, 0, SliceTypeB)})
+			process([][]byte{slice(false, 0, 0, SliceTypeB)})
+		}
+	}
+
+	expectedGOP := GOP{
+		Duration:          520 * time.Millisecond,
+		Length:            13,
+		IFrames:           1,
+		PFrames:           4,
+		BFrames:           8,
+		ReferenceFrames:   9,
+		ReferenceBFrames:  4,
+		Slices:            14,
+		MaxSlicesPerFrame: 2,
+	}
+
+	gop1 := expectedGOP
+	gop1.PTS = 3600
+	gop2 := expectedGOP
+	gop2.PTS = 3600 + 13*3600
+
+	require.Equal(t, []GOP{gop1, gop2}, gops)
+	require.Equal(t, []GOP{gop1, gop2}, a.GOPs())
+
+	require.Equal(t, Report{
+		GOPs:              2,
+		MinLength:         13,
+		AvgLength:         13,
+		MaxLength:         13,
+		IDRPeriod:         520 * time.Millisecond,
+		BFrames:           true,
+		ReferenceBFrames:  true,
+		AvgSlicesPerFrame: 14.0 / 13,
+		MaxSlicesPerFrame: 2,
+	}, a.Report())
+}
+
+func TestAnalyzerHistorySize(t *testing.T) {
+	a := &Analyzer{
+		HistorySize: 2,
+	}
+	a.Initialize()
+
+	for i := range 5 {
+		err := a.ProcessAccessUnit([][]byte{slice(true, 3, 0, SliceTypeI)}, int64(i)*90000)
+		require.NoError(t, err)
+	}
+
+	gops := a.GOPs()
+	require.Len(t, gops, 2)
+	require.Equal(t, int64(2*90000), gops[0].PTS)
+	require.Equal(t, int64(3*90000), gops[1].PTS)
+	require.Equal(t, time.Second, a.Report().IDRPeriod)
+}
+
+func TestAnalyzerInvalidSlice(t *testing.T) {
+	a := &Analyzer{}
+	a.Initialize()
+
+	err := a.ProcessAccessUnit([][]byte{{0x65}}, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package headers
+
+import (
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+// Immediate is a Immediate header.
+// Specification: ONVIF Streaming Specification
+type Immediate struct {
+	// whether the request must be executed immediately,
+	// discarding data of previous requests that has not been sent yet.
+	Value bool
+}
+
+// Unmarshal decodes a Immediate header.
+func (h *Immediate) Unmarshal(v base.HeaderValue) error {
+	var err error
+	h.Value, err = unmarshalYesNo(v)
+	return err
+}
+
+// Marshal encodes a Immediate header.
+func (h Immediate) Marshal() base.HeaderValue {
+	return marshalYesNo(h.Value)
+}
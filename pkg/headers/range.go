@@ -203,8 +203,9 @@ func (r RangeNPT) marshal() string {
 	return ret
 }
 
+// fractional seconds are used by ONVIF replay.
 func unmarshalRangeUTCTime(t *time.Time, s string) error {
-	tmp, err := time.Parse("20060102T150405Z", s)
+	tmp, err := time.Parse("20060102T150405.999999999Z", s)
 	if err != nil {
 		return err
 	}
@@ -213,7 +214,7 @@ func unmarshalRangeUTCTime(t *time.Time, s string) error {
 }
 
 func marshalRangeUTCTime(t time.Time) string {
-	return t.Format("20060102T150405Z")
+	return t.UTC().Format("20060102T150405.999999999Z")
 }
 
 // RangeUTC is a range expressed in UTC units.
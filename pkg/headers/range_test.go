@@ -99,6 +99,17 @@ var casesRange = []struct {
 			},
 		},
 	},
+	{
+		"clock with fractional seconds",
+		base.HeaderValue{`clock=20090615T114900.440Z-20090615T115000Z`},
+		base.HeaderValue{`clock=20090615T114900.44Z-20090615T115000Z`},
+		Range{
+			Value: &RangeUTC{
+				Start: time.Date(2009, 6, 15, 11, 49, 0, 440000000, time.UTC),
+				End:   ptrOf(time.Date(2009, 6, 15, 11, 50, 0, 0, time.UTC)),
+			},
+		},
+	},
 	{
 		"time",
 		base.HeaderValue{`clock=19960213T143205Z-;time=19970123T143720Z`},
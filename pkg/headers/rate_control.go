@@ -0,0 +1,55 @@
+package headers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+func unmarshalYesNo(v base.HeaderValue) (bool, error) {
+	if len(v) == 0 {
+		return false, fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return false, fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(v[0])) {
+	case "yes":
+		return true, nil
+
+	case "no":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("invalid value (%v)", v[0])
+}
+
+func marshalYesNo(v bool) base.HeaderValue {
+	if v {
+		return base.HeaderValue{"yes"}
+	}
+	return base.HeaderValue{"no"}
+}
+
+// RateControl is a Rate-Control header.
+// Specification: ONVIF Streaming Specification
+type RateControl struct {
+	// whether data must be streamed at the nominal rate.
+	// If false, data is streamed as fast as possible.
+	Value bool
+}
+
+// Unmarshal decodes a Rate-Control header.
+func (h *RateControl) Unmarshal(v base.HeaderValue) error {
+	var err error
+	h.Value, err = unmarshalYesNo(v)
+	return err
+}
+
+// Marshal encodes a Rate-Control header.
+func (h RateControl) Marshal() base.HeaderValue {
+	return marshalYesNo(h.Value)
+}
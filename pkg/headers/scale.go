@@ -0,0 +1,45 @@
+package headers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+func unmarshalFloat(v base.HeaderValue) (float64, error) {
+	if len(v) == 0 {
+		return 0, fmt.Errorf("value not provided")
+	}
+
+	if len(v) > 1 {
+		return 0, fmt.Errorf("value provided multiple times (%v)", v)
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(v[0]), 64)
+}
+
+func marshalFloat(v float64) base.HeaderValue {
+	return base.HeaderValue{strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+// Scale is a Scale header.
+// Specification: RFC2326, 12.34
+type Scale struct {
+	// ratio between the playback rate and the normal rate.
+	// Negative values indicate reverse playback.
+	Value float64
+}
+
+// Unmarshal decodes a Scale header.
+func (h *Scale) Unmarshal(v base.HeaderValue) error {
+	var err error
+	h.Value, err = unmarshalFloat(v)
+	return err
+}
+
+// Marshal encodes a Scale header.
+func (h Scale) Marshal() base.HeaderValue {
+	return marshalFloat(h.Value)
+}
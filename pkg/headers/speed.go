@@ -0,0 +1,34 @@
+package headers
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+// Speed is a Speed header.
+// Specification: RFC2326, 12.35
+type Speed struct {
+	// ratio between the delivery rate and the normal rate.
+	Value float64
+}
+
+// Unmarshal decodes a Speed header.
+func (h *Speed) Unmarshal(v base.HeaderValue) error {
+	var err error
+	h.Value, err = unmarshalFloat(v)
+	if err != nil {
+		return err
+	}
+
+	if h.Value <= 0 {
+		return fmt.Errorf("invalid speed (%v)", h.Value)
+	}
+
+	return nil
+}
+
+// Marshal encodes a Speed header.
+func (h Speed) Marshal() base.HeaderValue {
+	return marshalFloat(h.Value)
+}
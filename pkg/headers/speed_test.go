@@ -0,0 +1,89 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+var casesSpeed = []struct {
+	name string
+	vin  base.HeaderValue
+	vout base.HeaderValue
+	h    Speed
+}{
+	{
+		"normal",
+		base.HeaderValue{`1.0`},
+		base.HeaderValue{`1`},
+		Speed{
+			Value: 1,
+		},
+	},
+	{
+		"fast",
+		base.HeaderValue{`2.5`},
+		base.HeaderValue{`2.5`},
+		Speed{
+			Value: 2.5,
+		},
+	},
+}
+
+func TestSpeedUnmarshal(t *testing.T) {
+	for _, ca := range casesSpeed {
+		t.Run(ca.name, func(t *testing.T) {
+			var h Speed
+			err := h.Unmarshal(ca.vin)
+			require.NoError(t, err)
+			require.Equal(t, ca.h, h)
+		})
+	}
+}
+
+func TestSpeedMarshal(t *testing.T) {
+	for _, ca := range casesSpeed {
+		t.Run(ca.name, func(t *testing.T) {
+			req := ca.h.Marshal()
+			require.Equal(t, ca.vout, req)
+		})
+	}
+}
+
+func FuzzSpeedUnmarshal(f *testing.F) {
+	for _, ca := range casesSpeed {
+		f.Add(ca.vin[0])
+	}
+
+	f.Fuzz(func(_ *testing.T, b string) {
+		var h Speed
+		err := h.Unmarshal(base.HeaderValue{b})
+		if err != nil {
+			return
+		}
+
+		h.Marshal()
+	})
+}
+
+func TestSpeedAdditionalErrors(t *testing.T) {
+	func() {
+		var h Speed
+		err := h.Unmarshal(base.HeaderValue{})
+		require.Error(t, err)
+	}()
+
+	func() {
+		var h Speed
+		err := h.Unmarshal(base.HeaderValue{"a", "b"})
+		require.Error(t, err)
+	}()
+
+	func() {
+		var h Speed
+		err := h.Unmarshal(base.HeaderValue{"-1"})
+		require.Error(t, err)
+	}()
+}
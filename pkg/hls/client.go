@@ -0,0 +1,124 @@
+package hls
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph265"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpmpeg4audio"
+)
+
+// ReadClient creates a track for each supported format of the given medias,
+// initializes the Muxer and writes into it the packets read by the Client.
+// It must be called after Client.Setup() and before Client.Play().
+func (m *Muxer) ReadClient(c *gortsplib.Client, medias []*description.Media) error {
+	type readerFunc func(pkt *rtp.Packet, pts int64) error
+
+	type reader struct {
+		medi  *description.Media
+		forma format.Format
+		cb    readerFunc
+	}
+
+	var readers []reader
+
+	for _, medi := range medias {
+		for _, forma := range medi.Formats {
+			track := &Track{Format: forma}
+
+			switch forma := forma.(type) {
+			case *format.H264:
+				dec, err := forma.CreateDecoder()
+				if err != nil {
+					return err
+				}
+
+				readers = append(readers, reader{medi, forma, func(pkt *rtp.Packet, pts int64) error {
+					au, err := dec.Decode(pkt)
+					if err != nil {
+						if errors.Is(err, rtph264.ErrNonStartingPacketAndNoPrevious) ||
+							errors.Is(err, rtph264.ErrMorePacketsNeeded) {
+							return nil
+						}
+						return err
+					}
+
+					return m.WriteH264(track, pts, au)
+				}})
+
+			case *format.H265:
+				dec, err := forma.CreateDecoder()
+				if err != nil {
+					return err
+				}
+
+				readers = append(readers, reader{medi, forma, func(pkt *rtp.Packet, pts int64) error {
+					au, err := dec.Decode(pkt)
+					if err != nil {
+						if errors.Is(err, rtph265.ErrNonStartingPacketAndNoPrevious) ||
+							errors.Is(err, rtph265.ErrMorePacketsNeeded) {
+							return nil
+						}
+						return err
+					}
+
+					return m.WriteH265(track, pts, au)
+				}})
+
+			case *format.MPEG4Audio:
+				dec, err := forma.CreateDecoder()
+				if err != nil {
+					return err
+				}
+
+				readers = append(readers, reader{medi, forma, func(pkt *rtp.Packet, pts int64) error {
+					aus, err := dec.Decode(pkt)
+					if err != nil {
+						if errors.Is(err, rtpmpeg4audio.ErrMorePacketsNeeded) {
+							return nil
+						}
+						return err
+					}
+
+					return m.WriteMPEG4Audio(track, pts, aus)
+				}})
+
+			default:
+				continue
+			}
+
+			m.Tracks = append(m.Tracks, track)
+		}
+	}
+
+	if readers == nil {
+		return fmt.Errorf("no supported formats found")
+	}
+
+	err := m.Initialize()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range readers {
+		c.OnPacketRTP(r.medi, r.forma, func(pkt *rtp.Packet) {
+			pts, ok := c.PacketPTS(r.medi, pkt)
+			if !ok {
+				return
+			}
+
+			err2 := r.cb(pkt, pts)
+			if err2 != nil {
+				m.OnError(err2)
+			}
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package hls
+
+import (
+	"bytes"
+	"io/fs"
+	"time"
+)
+
+type memFileInfo struct {
+	name string
+	size int64
+}
+
+func (i *memFileInfo) Name() string {
+	return i.name
+}
+
+func (i *memFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i *memFileInfo) Mode() fs.FileMode {
+	return 0o444
+}
+
+func (i *memFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i *memFileInfo) IsDir() bool {
+	return false
+}
+
+func (i *memFileInfo) Sys() any {
+	return nil
+}
+
+// memFile is a file stored in memory.
+type memFile struct {
+	*bytes.Reader
+	name string
+	size int64
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+func (f *memFile) Readdir(int) ([]fs.FileInfo, error) {
+	return nil, fs.ErrInvalid
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	return &memFileInfo{name: f.name, size: f.size}, nil
+}
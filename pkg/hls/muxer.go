@@ -0,0 +1,502 @@
+// Package hls contains a HLS muxer.
+package hls
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	mcfmp4 "github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/fmp4"
+	"github.com/bluenviron/gortsplib/v5/pkg/mpegts"
+)
+
+const (
+	playlistName = "index.m3u8"
+	initName     = "init.mp4"
+)
+
+// Variant is a HLS variant.
+type Variant int
+
+// variants.
+const (
+	VariantMPEGTS Variant = iota
+	VariantFMP4
+)
+
+type segment struct {
+	name     string
+	duration time.Duration
+}
+
+// segmentWriter redirects the output of the MPEG-TS muxer into the current segment.
+type segmentWriter struct {
+	buf *bytes.Buffer
+}
+
+func (w *segmentWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Muxer is a HLS muxer.
+// It takes access units of one or more tracks and produces
+// a media playlist and media segments.
+//
+// Files can be written into a directory or kept in memory;
+// in both cases, they can be served through the http.FileSystem interface
+// (for instance, with http.FileServer).
+//
+// Timestamps are expressed in clock rate units of each track and
+// must share the same origin (like the ones returned by Client.PacketPTS()).
+// Segments start with a random access unit of the leading track,
+// that is the first video track, or the first track if there are no video tracks.
+type Muxer struct {
+	// tracks.
+	Tracks []*Track
+
+	// variant.
+	// It defaults to VariantMPEGTS.
+	Variant Variant
+
+	// directory where files are written.
+	// If empty, files are kept in memory.
+	Directory string
+
+	// minimum duration of segments.
+	// It defaults to 1 second.
+	SegmentDuration time.Duration
+
+	// number of segments in the playlist.
+	// It defaults to 7.
+	SegmentCount int
+
+	// called when an error occurs while processing packets read by ReadClient().
+	// It defaults to a function that prints errors.
+	OnError func(err error)
+
+	mutex          sync.RWMutex
+	files          map[string][]byte
+	leader         *Track
+	segments       []*segment
+	nextSegmentID  uint64
+	mediaSequence  uint64
+	closed         bool
+	tsMuxer        *mpegts.Muxer
+	tsWriter       *segmentWriter
+	tsStarted      bool
+	tsSegmentStart int64
+	tsLastDTS      int64
+	fmp4Muxer      *fmp4.Muxer
+	fmp4InitDone   bool
+}
+
+// Initialize initializes Muxer.
+func (m *Muxer) Initialize() error {
+	if len(m.Tracks) == 0 {
+		return fmt.Errorf("no tracks provided")
+	}
+
+	if m.SegmentDuration == 0 {
+		m.SegmentDuration = 1 * time.Second
+	}
+	if m.SegmentCount == 0 {
+		m.SegmentCount = 7
+	}
+	if m.OnError == nil {
+		m.OnError = func(err error) {
+			log.Printf("%v", err)
+		}
+	}
+
+	for _, track := range m.Tracks {
+		err := track.initialize()
+		if err != nil {
+			return err
+		}
+
+		if m.leader == nil && track.isVideo {
+			m.leader = track
+		}
+	}
+
+	if m.leader == nil {
+		m.leader = m.Tracks[0]
+	}
+
+	if m.Directory == "" {
+		m.files = make(map[string][]byte)
+	}
+
+	switch m.Variant {
+	case VariantMPEGTS:
+		tsTracks := make([]*mpegts.Track, len(m.Tracks))
+		for i, track := range m.Tracks {
+			tsTracks[i] = track.tsTrack
+		}
+
+		m.tsWriter = &segmentWriter{buf: &bytes.Buffer{}}
+
+		m.tsMuxer = &mpegts.Muxer{
+			W:        m.tsWriter,
+			Tracks:   tsTracks,
+			PCRTrack: m.leader.tsTrack,
+		}
+		err := m.tsMuxer.Initialize()
+		if err != nil {
+			return err
+		}
+
+	case VariantFMP4:
+		fmp4Tracks := make([]*fmp4.Track, len(m.Tracks))
+		for i, track := range m.Tracks {
+			fmp4Tracks[i] = track.fmp4Track
+		}
+
+		m.fmp4Muxer = &fmp4.Muxer{
+			Tracks:             fmp4Tracks,
+			SegmentMinDuration: m.SegmentDuration,
+			OnSegment:          m.onFMP4Segment,
+		}
+		err := m.fmp4Muxer.Initialize()
+		if err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("invalid variant: %v", m.Variant)
+	}
+
+	return nil
+}
+
+// Close writes buffered data into a final segment and ends the playlist.
+func (m *Muxer) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+
+	switch m.Variant {
+	case VariantMPEGTS:
+		if m.tsStarted && m.tsLastDTS > m.tsSegmentStart {
+			err := m.addSegment(m.tsWriter.buf.Bytes(),
+				mediatime.TimestampToDuration(m.tsLastDTS-m.tsSegmentStart, m.leader.clockRate))
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		err := m.fmp4Muxer.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.writePlaylist()
+}
+
+// WriteH264 writes a H264 access unit.
+// DTS is computed automatically.
+func (m *Muxer) WriteH264(track *Track, pts int64, au [][]byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	au = track.addH264Params(au)
+
+	dts, ok, err := track.h264DTS.Extract(au, pts)
+	if err != nil || !ok {
+		return err
+	}
+
+	if m.Variant == VariantFMP4 {
+		return m.fmp4Muxer.WriteH264(track.fmp4Track, pts, dts, au)
+	}
+
+	if !m.prepareTS(track, dts, h264.IsRandomAccess(au)) {
+		return nil
+	}
+
+	return m.tsMuxer.WriteH264(track.tsTrack, pts, dts, au)
+}
+
+// WriteH265 writes a H265 access unit.
+// DTS is computed automatically.
+func (m *Muxer) WriteH265(track *Track, pts int64, au [][]byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	au = track.addH265Params(au)
+
+	dts, ok, err := track.h265DTS.Extract(au, pts)
+	if err != nil || !ok {
+		return err
+	}
+
+	if m.Variant == VariantFMP4 {
+		return m.fmp4Muxer.WriteH265(track.fmp4Track, pts, dts, au)
+	}
+
+	if !m.prepareTS(track, dts, h265.IsRandomAccess(au)) {
+		return nil
+	}
+
+	return m.tsMuxer.WriteH265(track.tsTrack, pts, dts, au)
+}
+
+// WriteMPEG4Audio writes MPEG-4 Audio access units.
+func (m *Muxer) WriteMPEG4Audio(track *Track, pts int64, aus [][]byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.Variant == VariantFMP4 {
+		return m.fmp4Muxer.WriteMPEG4Audio(track.fmp4Track, pts, aus)
+	}
+
+	for i, au := range aus {
+		auPTS := pts + int64(i)*mpeg4audio.SamplesPerAccessUnit
+
+		if !m.prepareTS(track, auPTS, true) {
+			continue
+		}
+
+		err := m.tsMuxer.WriteMPEG4Audio(track.tsTrack, auPTS, [][]byte{au})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareTS starts a new MPEG-TS segment when needed
+// and returns whether the access unit can be written.
+func (m *Muxer) prepareTS(track *Track, dts int64, randomAccess bool) bool {
+	if m.closed {
+		return false
+	}
+
+	if track != m.leader {
+		return m.tsStarted
+	}
+
+	if !m.tsStarted {
+		// wait for a random access unit
+		if !randomAccess {
+			return false
+		}
+
+		m.tsStarted = true
+		m.startTSSegment(dts)
+	} else if randomAccess {
+		duration := mediatime.TimestampToDuration(dts-m.tsSegmentStart, track.clockRate)
+
+		if duration >= m.SegmentDuration {
+			err := m.addSegment(m.tsWriter.buf.Bytes(), duration)
+			if err != nil {
+				m.OnError(err)
+			} else {
+				err = m.writePlaylist()
+				if err != nil {
+					m.OnError(err)
+				}
+			}
+
+			m.startTSSegment(dts)
+		}
+	}
+
+	m.tsLastDTS = dts
+
+	return true
+}
+
+func (m *Muxer) startTSSegment(dts int64) {
+	m.tsWriter.buf = &bytes.Buffer{}
+	m.tsSegmentStart = dts
+
+	// make the segment decodable independently
+	m.tsMuxer.WriteTables() //nolint:errcheck
+}
+
+func (m *Muxer) onFMP4Segment(seg []byte) error {
+	if !m.fmp4InitDone {
+		init, err := m.fmp4Muxer.Init()
+		if err != nil {
+			return err
+		}
+
+		err = m.writeFile(initName, init)
+		if err != nil {
+			return err
+		}
+
+		m.fmp4InitDone = true
+	}
+
+	var parts mcfmp4.Parts
+	err := parts.Unmarshal(seg)
+	if err != nil {
+		return err
+	}
+
+	// compute the segment duration from samples of the leading track
+	leaderID := 0
+	for i, track := range m.Tracks {
+		if track == m.leader {
+			leaderID = i + 1
+		}
+	}
+
+	var duration int64
+	for _, part := range parts {
+		for _, pt := range part.Tracks {
+			if pt.ID == leaderID {
+				for _, sa := range pt.Samples {
+					duration += int64(sa.Duration)
+				}
+			}
+		}
+	}
+
+	err = m.addSegment(seg, mediatime.TimestampToDuration(duration, m.leader.clockRate))
+	if err != nil {
+		return err
+	}
+
+	if m.closed {
+		return nil
+	}
+
+	return m.writePlaylist()
+}
+
+func (m *Muxer) addSegment(content []byte, duration time.Duration) error {
+	ext := ".ts"
+	if m.Variant == VariantFMP4 {
+		ext = ".mp4"
+	}
+
+	seg := &segment{
+		name:     "segment" + strconv.FormatUint(m.nextSegmentID, 10) + ext,
+		duration: duration,
+	}
+	m.nextSegmentID++
+
+	err := m.writeFile(seg.name, content)
+	if err != nil {
+		return err
+	}
+
+	m.segments = append(m.segments, seg)
+
+	if len(m.segments) > m.SegmentCount {
+		m.removeFile(m.segments[0].name)
+		m.segments = m.segments[1:]
+		m.mediaSequence++
+	}
+
+	return nil
+}
+
+func (m *Muxer) writePlaylist() error {
+	if len(m.segments) == 0 {
+		return nil
+	}
+
+	var targetDuration time.Duration
+	for _, seg := range m.segments {
+		if seg.duration > targetDuration {
+			targetDuration = seg.duration
+		}
+	}
+
+	version := "3"
+	if m.Variant == VariantFMP4 {
+		version = "7"
+	}
+
+	cnt := "#EXTM3U\n" +
+		"#EXT-X-VERSION:" + version + "\n" +
+		"#EXT-X-TARGETDURATION:" + strconv.FormatInt(int64(math.Ceil(targetDuration.Seconds())), 10) + "\n" +
+		"#EXT-X-MEDIA-SEQUENCE:" + strconv.FormatUint(m.mediaSequence, 10) + "\n"
+
+	if m.Variant == VariantFMP4 {
+		cnt += "#EXT-X-MAP:URI=\"" + initName + "\"\n"
+	}
+
+	for _, seg := range m.segments {
+		cnt += "#EXTINF:" + strconv.FormatFloat(seg.duration.Seconds(), 'f', 5, 64) + ",\n" +
+			seg.name + "\n"
+	}
+
+	if m.closed {
+		cnt += "#EXT-X-ENDLIST\n"
+	}
+
+	return m.writeFile(playlistName, []byte(cnt))
+}
+
+func (m *Muxer) writeFile(name string, content []byte) error {
+	if m.files != nil {
+		m.files[name] = content
+		return nil
+	}
+
+	// write into a temporary file and rename it,
+	// in order not to serve partial files.
+	fpath := filepath.Join(m.Directory, name)
+
+	err := os.WriteFile(fpath+".tmp", content, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(fpath+".tmp", fpath)
+}
+
+func (m *Muxer) removeFile(name string) {
+	if m.files != nil {
+		delete(m.files, name)
+		return
+	}
+
+	os.Remove(filepath.Join(m.Directory, name))
+}
+
+// Open implements http.FileSystem.
+func (m *Muxer) Open(name string) (http.File, error) {
+	if m.files == nil {
+		return http.Dir(m.Directory).Open(name)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	content, ok := m.files[filepath.Base(name)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return &memFile{
+		Reader: bytes.NewReader(content),
+		name:   filepath.Base(name),
+		size:   int64(len(content)),
+	}, nil
+}
@@ -0,0 +1,173 @@
+package hls
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+func readFile(t *testing.T, m *Muxer, name string) string {
+	f, err := m.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	byts, err := io.ReadAll(f)
+	require.NoError(t, err)
+
+	return string(byts)
+}
+
+func writeTestStream(t *testing.T, m *Muxer, videoTrack *Track, audioTrack *Track) {
+	// 4 seconds of video with an IDR every second
+	for i := 0; i < 100; i++ {
+		var au [][]byte
+		if (i % 25) == 0 {
+			au = [][]byte{{5, byte(i)}}
+		} else {
+			au = [][]byte{{1, byte(i)}}
+		}
+
+		err := m.WriteH264(videoTrack, int64(i)*3600, au)
+		require.NoError(t, err)
+
+		err = m.WriteMPEG4Audio(audioTrack, int64(i)*1764, [][]byte{{1, 2, 3}})
+		require.NoError(t, err)
+	}
+}
+
+func testTracks() (*Track, *Track) {
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+			SPS:               testSPS,
+			PPS:               testPPS,
+		},
+	}
+
+	audioTrack := &Track{
+		Format: &format.MPEG4Audio{
+			PayloadTyp: 97,
+			Config: &mpeg4audio.AudioSpecificConfig{
+				Type:         2,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		},
+	}
+
+	return videoTrack, audioTrack
+}
+
+func TestMuxerMPEGTS(t *testing.T) {
+	videoTrack, audioTrack := testTracks()
+
+	m := &Muxer{
+		Tracks:       []*Track{videoTrack, audioTrack},
+		SegmentCount: 2,
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	_, err = m.Open("index.m3u8")
+	require.Error(t, err)
+
+	writeTestStream(t, m, videoTrack, audioTrack)
+
+	require.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:3\n"+
+		"#EXT-X-TARGETDURATION:1\n"+
+		"#EXT-X-MEDIA-SEQUENCE:1\n"+
+		"#EXTINF:1.00000,\n"+
+		"segment1.ts\n"+
+		"#EXTINF:1.00000,\n"+
+		"segment2.ts\n", readFile(t, m, "index.m3u8"))
+
+	// old segments are removed
+	_, err = m.Open("segment0.ts")
+	require.Error(t, err)
+
+	seg := readFile(t, m, "segment1.ts")
+	require.Equal(t, 0, len(seg)%188)
+	require.Equal(t, byte(0x47), seg[0])
+
+	err = m.Close()
+	require.NoError(t, err)
+
+	require.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:3\n"+
+		"#EXT-X-TARGETDURATION:1\n"+
+		"#EXT-X-MEDIA-SEQUENCE:2\n"+
+		"#EXTINF:1.00000,\n"+
+		"segment2.ts\n"+
+		"#EXTINF:0.96000,\n"+
+		"segment3.ts\n"+
+		"#EXT-X-ENDLIST\n", readFile(t, m, "index.m3u8"))
+}
+
+func TestMuxerFMP4Directory(t *testing.T) {
+	dir := t.TempDir()
+
+	videoTrack, audioTrack := testTracks()
+
+	m := &Muxer{
+		Tracks:    []*Track{videoTrack, audioTrack},
+		Variant:   VariantFMP4,
+		Directory: dir,
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	writeTestStream(t, m, videoTrack, audioTrack)
+
+	err = m.Close()
+	require.NoError(t, err)
+
+	require.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:7\n"+
+		"#EXT-X-TARGETDURATION:1\n"+
+		"#EXT-X-MEDIA-SEQUENCE:0\n"+
+		"#EXT-X-MAP:URI=\"init.mp4\"\n"+
+		"#EXTINF:1.00000,\n"+
+		"segment0.mp4\n"+
+		"#EXTINF:1.00000,\n"+
+		"segment1.mp4\n"+
+		"#EXTINF:1.00000,\n"+
+		"segment2.mp4\n"+
+		"#EXTINF:0.96000,\n"+
+		"segment3.mp4\n"+
+		"#EXT-X-ENDLIST\n", readFile(t, m, "index.m3u8"))
+
+	for _, name := range []string{"init.mp4", "segment0.mp4", "segment3.mp4"} {
+		_, err = os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+	}
+}
+
+func TestMuxerErrors(t *testing.T) {
+	m := &Muxer{
+		Tracks:          []*Track{{Format: &format.G711{}}},
+		SegmentDuration: time.Second,
+	}
+	err := m.Initialize()
+	require.EqualError(t, err, "unsupported format: *format.G711")
+}
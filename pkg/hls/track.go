@@ -0,0 +1,103 @@
+package hls
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/fmp4"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/mpegts"
+)
+
+// Track is a track of a Muxer.
+type Track struct {
+	// format of the track.
+	// Supported formats are H264, H265 and MPEG-4 Audio.
+	Format format.Format
+
+	clockRate int
+	isVideo   bool
+	vps       []byte
+	sps       []byte
+	pps       []byte
+	h264DTS   mediatime.H264DTSExtractor
+	h265DTS   mediatime.H265DTSExtractor
+	tsTrack   *mpegts.Track
+	fmp4Track *fmp4.Track
+}
+
+func (t *Track) initialize() error {
+	t.clockRate = t.Format.ClockRate()
+
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		t.isVideo = true
+		t.sps, t.pps = forma.SafeParams()
+
+	case *format.H265:
+		t.isVideo = true
+		t.vps, t.sps, t.pps = forma.SafeParams()
+
+	case *format.MPEG4Audio:
+
+	default:
+		return fmt.Errorf("unsupported format: %T", t.Format)
+	}
+
+	t.tsTrack = &mpegts.Track{Format: t.Format}
+	t.fmp4Track = &fmp4.Track{Format: t.Format}
+
+	return nil
+}
+
+// addH264Params stores parameters and adds them to random access units that don't contain them,
+// since they are needed to compute DTS.
+func (t *Track) addH264Params(au [][]byte) [][]byte {
+	hasParams := false
+
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			t.sps = nalu
+			hasParams = true
+
+		case h264.NALUTypePPS:
+			t.pps = nalu
+		}
+	}
+
+	if !hasParams && t.sps != nil && t.pps != nil && h264.IsRandomAccess(au) {
+		au = append([][]byte{t.sps, t.pps}, au...)
+	}
+
+	return au
+}
+
+// addH265Params stores parameters and adds them to random access units that don't contain them,
+// since they are needed to compute DTS.
+func (t *Track) addH265Params(au [][]byte) [][]byte {
+	hasParams := false
+
+	for _, nalu := range au {
+		switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+		case h265.NALUType_VPS_NUT:
+			t.vps = nalu
+
+		case h265.NALUType_SPS_NUT:
+			t.sps = nalu
+			hasParams = true
+
+		case h265.NALUType_PPS_NUT:
+			t.pps = nalu
+		}
+	}
+
+	if !hasParams && t.vps != nil && t.sps != nil && t.pps != nil && h265.IsRandomAccess(au) {
+		au = append([][]byte{t.vps, t.sps, t.pps}, au...)
+	}
+
+	return au
+}
@@ -2,6 +2,7 @@ package liberrors
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 )
@@ -14,6 +15,11 @@ func (e ErrClientTerminated) Error() string {
 	return "terminated"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTerminated) Is(target error) bool {
+	return target == ErrTerminated
+}
+
 // ErrClientInvalidState is an error that can be returned by a client.
 type ErrClientInvalidState struct {
 	AllowedList []fmt.Stringer
@@ -26,6 +32,11 @@ func (e ErrClientInvalidState) Error() string {
 		e.AllowedList, e.State)
 }
 
+// Is implements errors.Is.
+func (e ErrClientInvalidState) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrClientSessionHeaderInvalid is an error that can be returned by a client.
 type ErrClientSessionHeaderInvalid struct {
 	Err error
@@ -36,6 +47,16 @@ func (e ErrClientSessionHeaderInvalid) Error() string {
 	return fmt.Sprintf("invalid session header: %v", e.Err)
 }
 
+// Unwrap returns the underlying error.
+func (e ErrClientSessionHeaderInvalid) Unwrap() error {
+	return e.Err
+}
+
+// Is implements errors.Is.
+func (e ErrClientSessionHeaderInvalid) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrClientBadStatusCode is an error that can be returned by a client.
 type ErrClientBadStatusCode struct {
 	Code    base.StatusCode
@@ -47,6 +68,21 @@ func (e ErrClientBadStatusCode) Error() string {
 	return fmt.Sprintf("bad status code: %d (%s)", e.Code, e.Message)
 }
 
+// Is implements errors.Is.
+// ErrAuthentication, ErrNotFound and ErrTransport are matched
+// by the corresponding status codes.
+func (e ErrClientBadStatusCode) Is(target error) bool {
+	switch target {
+	case ErrAuthentication:
+		return e.Code == base.StatusUnauthorized || e.Code == base.StatusForbidden
+	case ErrNotFound:
+		return e.Code == base.StatusNotFound
+	case ErrTransport:
+		return e.Code == base.StatusUnsupportedTransport
+	}
+	return false
+}
+
 // ErrClientContentTypeMissing is an error that can be returned by a client.
 type ErrClientContentTypeMissing struct{}
 
@@ -55,6 +91,11 @@ func (e ErrClientContentTypeMissing) Error() string {
 	return "Content-Type header is missing"
 }
 
+// Is implements errors.Is.
+func (e ErrClientContentTypeMissing) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrClientContentTypeUnsupported is an error that can be returned by a client.
 type ErrClientContentTypeUnsupported struct {
 	CT base.HeaderValue
@@ -65,6 +106,11 @@ func (e ErrClientContentTypeUnsupported) Error() string {
 	return fmt.Sprintf("unsupported Content-Type header '%v'", e.CT)
 }
 
+// Is implements errors.Is.
+func (e ErrClientContentTypeUnsupported) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrClientCannotSetupMediasDifferentURLs is an error that can be returned by a client.
 type ErrClientCannotSetupMediasDifferentURLs struct{}
 
@@ -81,6 +127,11 @@ func (e ErrClientUDPPortsZero) Error() string {
 	return "rtpPort and rtcpPort must be both zero or non-zero"
 }
 
+// Is implements errors.Is.
+func (e ErrClientUDPPortsZero) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientUDPPortsNotConsecutive is an error that can be returned by a client.
 type ErrClientUDPPortsNotConsecutive struct{}
 
@@ -89,6 +140,11 @@ func (e ErrClientUDPPortsNotConsecutive) Error() string {
 	return "rtcpPort must be rtpPort + 1"
 }
 
+// Is implements errors.Is.
+func (e ErrClientUDPPortsNotConsecutive) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientServerPortsNotProvided is an error that can be returned by a client.
 type ErrClientServerPortsNotProvided struct{}
 
@@ -97,6 +153,11 @@ func (e ErrClientServerPortsNotProvided) Error() string {
 	return "server ports have not been provided. Use AnyPortEnable to communicate with this server"
 }
 
+// Is implements errors.Is.
+func (e ErrClientServerPortsNotProvided) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientTransportHeaderInvalid is an error that can be returned by a client.
 type ErrClientTransportHeaderInvalid struct {
 	Err error
@@ -107,6 +168,16 @@ func (e ErrClientTransportHeaderInvalid) Error() string {
 	return fmt.Sprintf("invalid transport header: %v", e.Err)
 }
 
+// Unwrap returns the underlying error.
+func (e ErrClientTransportHeaderInvalid) Unwrap() error {
+	return e.Err
+}
+
+// Is implements errors.Is.
+func (e ErrClientTransportHeaderInvalid) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientServerRequestedTCP is an error that can be returned by a client.
 type ErrClientServerRequestedTCP struct{}
 
@@ -115,6 +186,24 @@ func (e ErrClientServerRequestedTCP) Error() string {
 	return "server wants to use the TCP transport protocol"
 }
 
+// Is implements errors.Is.
+func (e ErrClientServerRequestedTCP) Is(target error) bool {
+	return target == ErrTransport
+}
+
+// ErrClientNoTransportProtocol is an error that can be returned by a client.
+type ErrClientNoTransportProtocol struct{}
+
+// Error implements the error interface.
+func (e ErrClientNoTransportProtocol) Error() string {
+	return "transport policy doesn't allow any of the available transport protocols"
+}
+
+// Is implements errors.Is.
+func (e ErrClientNoTransportProtocol) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientServerRequestedUDP is an error that can be returned by a client.
 type ErrClientServerRequestedUDP struct{}
 
@@ -123,6 +212,11 @@ func (e ErrClientServerRequestedUDP) Error() string {
 	return "server wants to use the UDP transport protocol"
 }
 
+// Is implements errors.Is.
+func (e ErrClientServerRequestedUDP) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientTransportHeaderInvalidDelivery is an error that can be returned by a client.
 type ErrClientTransportHeaderInvalidDelivery struct{}
 
@@ -131,6 +225,11 @@ func (e ErrClientTransportHeaderInvalidDelivery) Error() string {
 	return "transport header contains an invalid delivery value"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTransportHeaderInvalidDelivery) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientTransportHeaderNoPorts is an error that can be returned by a client.
 type ErrClientTransportHeaderNoPorts struct{}
 
@@ -139,6 +238,11 @@ func (e ErrClientTransportHeaderNoPorts) Error() string {
 	return "transport header does not contain ports"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTransportHeaderNoPorts) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientTransportHeaderNoDestination is an error that can be returned by a client.
 type ErrClientTransportHeaderNoDestination struct{}
 
@@ -147,6 +251,11 @@ func (e ErrClientTransportHeaderNoDestination) Error() string {
 	return "transport header does not contain a destination"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTransportHeaderNoDestination) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientTransportHeaderNoInterleavedIDs is an error that can be returned by a client.
 type ErrClientTransportHeaderNoInterleavedIDs struct{}
 
@@ -155,6 +264,11 @@ func (e ErrClientTransportHeaderNoInterleavedIDs) Error() string {
 	return "transport header does not contain interleaved IDs"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTransportHeaderNoInterleavedIDs) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientTransportHeaderInvalidInterleavedIDs is an error that can be returned by a client.
 type ErrClientTransportHeaderInvalidInterleavedIDs struct{}
 
@@ -163,6 +277,11 @@ func (e ErrClientTransportHeaderInvalidInterleavedIDs) Error() string {
 	return "invalid interleaved IDs"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTransportHeaderInvalidInterleavedIDs) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientTransportHeaderInterleavedIDsInUse is an error that can be returned by a client.
 type ErrClientTransportHeaderInterleavedIDsInUse struct{}
 
@@ -171,6 +290,11 @@ func (e ErrClientTransportHeaderInterleavedIDsInUse) Error() string {
 	return "interleaved IDs are in use"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTransportHeaderInterleavedIDsInUse) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientUDPTimeout is an error that can be returned by a client.
 type ErrClientUDPTimeout struct{}
 
@@ -179,6 +303,11 @@ func (e ErrClientUDPTimeout) Error() string {
 	return "UDP timeout"
 }
 
+// Is implements errors.Is.
+func (e ErrClientUDPTimeout) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // ErrClientTCPTimeout is an error that can be returned by a client.
 type ErrClientTCPTimeout struct{}
 
@@ -187,6 +316,11 @@ func (e ErrClientTCPTimeout) Error() string {
 	return "TCP timeout"
 }
 
+// Is implements errors.Is.
+func (e ErrClientTCPTimeout) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // ErrClientRTPInfoInvalid is an error that can be returned by a client.
 type ErrClientRTPInfoInvalid struct {
 	Err error
@@ -197,6 +331,16 @@ func (e ErrClientRTPInfoInvalid) Error() string {
 	return fmt.Sprintf("invalid RTP-Info: %v", e.Err)
 }
 
+// Unwrap returns the underlying error.
+func (e ErrClientRTPInfoInvalid) Unwrap() error {
+	return e.Err
+}
+
+// Is implements errors.Is.
+func (e ErrClientRTPInfoInvalid) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrClientUnexpectedFrame is an error that can be returned by a client.
 type ErrClientUnexpectedFrame struct{}
 
@@ -205,6 +349,11 @@ func (e ErrClientUnexpectedFrame) Error() string {
 	return "received unexpected interleaved frame"
 }
 
+// Is implements errors.Is.
+func (e ErrClientUnexpectedFrame) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrClientRequestTimedOut is an error that can be returned by a client.
 type ErrClientRequestTimedOut struct{}
 
@@ -213,6 +362,11 @@ func (e ErrClientRequestTimedOut) Error() string {
 	return "request timed out"
 }
 
+// Is implements errors.Is.
+func (e ErrClientRequestTimedOut) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // ErrClientUnsupportedScheme is an error that can be returned by a client.
 type ErrClientUnsupportedScheme struct {
 	Scheme string
@@ -233,6 +387,11 @@ func (e ErrClientUnhandledMethod) Error() string {
 	return fmt.Sprintf("unhandled method: %v", e.Method)
 }
 
+// Is implements errors.Is.
+func (e ErrClientUnhandledMethod) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrClientWriteQueueFull is an error that can be returned by a client.
 type ErrClientWriteQueueFull struct{}
 
@@ -241,6 +400,22 @@ func (e ErrClientWriteQueueFull) Error() string {
 	return "write queue is full"
 }
 
+// ErrClientWouldBlock is an error that can be returned by a client.
+type ErrClientWouldBlock struct{}
+
+// Error implements the error interface.
+func (e ErrClientWouldBlock) Error() string {
+	return "write would block"
+}
+
+// ErrClientReadQueueFull is an error that can be returned by a client.
+type ErrClientReadQueueFull struct{}
+
+// Error implements the error interface.
+func (e ErrClientReadQueueFull) Error() string {
+	return "read queue is full"
+}
+
 // ErrClientRTPPacketUnknownPayloadType is an error that can be returned by a client.
 type ErrClientRTPPacketUnknownPayloadType struct {
 	PayloadType uint8
@@ -287,6 +462,11 @@ func (e ErrClientSwitchToTCP) Error() string {
 	return "no UDP packets received, switching to TCP"
 }
 
+// Is implements errors.Is.
+func (e ErrClientSwitchToTCP) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientSwitchToTCP2 is an error that can be returned by a client.
 type ErrClientSwitchToTCP2 struct{}
 
@@ -295,6 +475,11 @@ func (e ErrClientSwitchToTCP2) Error() string {
 	return "switching to TCP because server requested it"
 }
 
+// Is implements errors.Is.
+func (e ErrClientSwitchToTCP2) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrClientAuthSetup is an error that can be returned by a client.
 type ErrClientAuthSetup struct {
 	Err error
@@ -305,6 +490,16 @@ func (e ErrClientAuthSetup) Error() string {
 	return fmt.Sprintf("unable to setup authentication: %s", e.Err)
 }
 
+// Unwrap returns the underlying error.
+func (e ErrClientAuthSetup) Unwrap() error {
+	return e.Err
+}
+
+// Is implements errors.Is.
+func (e ErrClientAuthSetup) Is(target error) bool {
+	return target == ErrAuthentication
+}
+
 // ErrClientSDPInvalid is an error that can be returned by a client.
 type ErrClientSDPInvalid struct {
 	Err error
@@ -314,3 +509,62 @@ type ErrClientSDPInvalid struct {
 func (e ErrClientSDPInvalid) Error() string {
 	return fmt.Sprintf("invalid SDP: %v", e.Err)
 }
+
+// Unwrap returns the underlying error.
+func (e ErrClientSDPInvalid) Unwrap() error {
+	return e.Err
+}
+
+// Is implements errors.Is.
+func (e ErrClientSDPInvalid) Is(target error) bool {
+	return target == ErrProtocol
+}
+
+// ClientSetupAttempt is a SETUP attempt performed by a client.
+type ClientSetupAttempt struct {
+	// URL of the request.
+	URL *base.URL
+	// transport protocol that was requested.
+	Protocol fmt.Stringer
+	// status code returned by the server.
+	StatusCode base.StatusCode
+	// reason of the failure.
+	Err error
+}
+
+// String implements fmt.Stringer.
+func (a ClientSetupAttempt) String() string {
+	return fmt.Sprintf("%v: %v", a.Protocol, a.Err)
+}
+
+// ErrClientSetupFailed is an error that can be returned by a client.
+// It contains all SETUP attempts of a media, including the ones
+// that caused an automatic transport switch.
+type ErrClientSetupFailed struct {
+	Attempts []ClientSetupAttempt
+}
+
+// Error implements the error interface.
+func (e ErrClientSetupFailed) Error() string {
+	strs := make([]string, len(e.Attempts))
+	for i, a := range e.Attempts {
+		strs[i] = a.String()
+	}
+	return fmt.Sprintf("SETUP failed (%s)", strings.Join(strs, ", "))
+}
+
+// Unwrap returns the reason of the last attempt.
+func (e ErrClientSetupFailed) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// ErrClientMediaNotSetup is an error that can be returned by a client.
+type ErrClientMediaNotSetup struct{}
+
+// Error implements the error interface.
+func (e ErrClientMediaNotSetup) Error() string {
+	return "media is not setupped"
+}
@@ -1,2 +1,31 @@
 // Package liberrors contains errors returned by the library.
+//
+// Errors can be inspected with errors.As, in order to read their fields,
+// or with errors.Is, in order to check whether they belong to one of the
+// following categories.
 package liberrors
+
+import (
+	"errors"
+)
+
+// error categories.
+var (
+	// ErrTerminated is the category of errors caused by the termination of a client or server.
+	ErrTerminated = errors.New("terminated")
+
+	// ErrAuthentication is the category of authentication errors.
+	ErrAuthentication = errors.New("authentication error")
+
+	// ErrNotFound is the category of errors caused by missing resources.
+	ErrNotFound = errors.New("not found")
+
+	// ErrTransport is the category of transport negotiation errors.
+	ErrTransport = errors.New("transport error")
+
+	// ErrTimeout is the category of timeout errors.
+	ErrTimeout = errors.New("timeout")
+
+	// ErrProtocol is the category of protocol violations.
+	ErrProtocol = errors.New("protocol violation")
+)
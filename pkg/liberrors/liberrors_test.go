@@ -0,0 +1,83 @@
+package liberrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+func TestCategories(t *testing.T) {
+	for _, ca := range []struct {
+		name     string
+		err      error
+		category error
+	}{
+		{
+			"terminated",
+			ErrServerTerminated{},
+			ErrTerminated,
+		},
+		{
+			"authentication status code",
+			ErrClientBadStatusCode{Code: base.StatusUnauthorized},
+			ErrAuthentication,
+		},
+		{
+			"authentication server",
+			ErrServerAuth{},
+			ErrAuthentication,
+		},
+		{
+			"not found",
+			ErrClientBadStatusCode{Code: base.StatusNotFound},
+			ErrNotFound,
+		},
+		{
+			"transport",
+			ErrClientServerRequestedTCP{},
+			ErrTransport,
+		},
+		{
+			"timeout",
+			ErrClientRequestTimedOut{},
+			ErrTimeout,
+		},
+		{
+			"protocol",
+			ErrServerCSeqMissing{},
+			ErrProtocol,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", ca.err)
+			require.ErrorIs(t, err, ca.category)
+
+			for _, other := range []error{
+				ErrTerminated,
+				ErrAuthentication,
+				ErrNotFound,
+				ErrTransport,
+				ErrTimeout,
+				ErrProtocol,
+			} {
+				if other != ca.category {
+					require.NotErrorIs(t, err, other)
+				}
+			}
+		})
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+	err := fmt.Errorf("wrapped: %w", ErrClientSDPInvalid{Err: cause})
+	require.ErrorIs(t, err, cause)
+	require.ErrorIs(t, err, ErrProtocol)
+
+	var e ErrClientSDPInvalid
+	require.True(t, errors.As(err, &e))
+}
@@ -18,6 +18,11 @@ func (e ErrServerSessionNotFound) Error() string {
 	return "session not found"
 }
 
+// Is implements errors.Is.
+func (e ErrServerSessionNotFound) Is(target error) bool {
+	return target == ErrNotFound
+}
+
 // ErrServerSessionTimedOut is an error that can be returned by a server.
 type ErrServerSessionTimedOut struct{}
 
@@ -26,6 +31,11 @@ func (e ErrServerSessionTimedOut) Error() string {
 	return "session timed out"
 }
 
+// Is implements errors.Is.
+func (e ErrServerSessionTimedOut) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // ErrServerCSeqMissing is an error that can be returned by a server.
 type ErrServerCSeqMissing struct{}
 
@@ -34,6 +44,11 @@ func (e ErrServerCSeqMissing) Error() string {
 	return "CSeq is missing"
 }
 
+// Is implements errors.Is.
+func (e ErrServerCSeqMissing) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerInvalidState is an error that can be returned by a server.
 type ErrServerInvalidState struct {
 	AllowedList []fmt.Stringer
@@ -46,6 +61,11 @@ func (e ErrServerInvalidState) Error() string {
 		e.AllowedList, e.State)
 }
 
+// Is implements errors.Is.
+func (e ErrServerInvalidState) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerInvalidPath is an error that can be returned by a server.
 type ErrServerInvalidPath struct{}
 
@@ -54,6 +74,11 @@ func (e ErrServerInvalidPath) Error() string {
 	return "invalid path"
 }
 
+// Is implements errors.Is.
+func (e ErrServerInvalidPath) Is(target error) bool {
+	return target == ErrNotFound
+}
+
 // ErrServerContentTypeMissing is an error that can be returned by a server.
 type ErrServerContentTypeMissing = ErrClientContentTypeMissing
 
@@ -74,6 +99,11 @@ func (e ErrServerMediaAlreadySetup) Error() string {
 	return "media has already been setup"
 }
 
+// Is implements errors.Is.
+func (e ErrServerMediaAlreadySetup) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerMediaNotFound is an error that can be returned by a server.
 type ErrServerMediaNotFound struct{}
 
@@ -82,6 +112,11 @@ func (e ErrServerMediaNotFound) Error() string {
 	return "media not found"
 }
 
+// Is implements errors.Is.
+func (e ErrServerMediaNotFound) Is(target error) bool {
+	return target == ErrNotFound
+}
+
 // ErrServerTransportHeaderInvalidMode is an error that can be returned by a server.
 type ErrServerTransportHeaderInvalidMode struct {
 	Mode *headers.TransportMode
@@ -96,6 +131,11 @@ func (e ErrServerTransportHeaderInvalidMode) Error() string {
 	return fmt.Sprintf("transport header contains a invalid mode (%v)", m)
 }
 
+// Is implements errors.Is.
+func (e ErrServerTransportHeaderInvalidMode) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrServerTransportHeaderNoClientPorts is an error that can be returned by a server.
 type ErrServerTransportHeaderNoClientPorts struct{}
 
@@ -104,6 +144,11 @@ func (e ErrServerTransportHeaderNoClientPorts) Error() string {
 	return "transport header does not contain client ports"
 }
 
+// Is implements errors.Is.
+func (e ErrServerTransportHeaderNoClientPorts) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrServerTransportHeaderInvalidInterleavedIDs is an error that can be returned by a server.
 type ErrServerTransportHeaderInvalidInterleavedIDs struct{}
 
@@ -112,6 +157,11 @@ func (e ErrServerTransportHeaderInvalidInterleavedIDs) Error() string {
 	return "invalid interleaved IDs"
 }
 
+// Is implements errors.Is.
+func (e ErrServerTransportHeaderInvalidInterleavedIDs) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrServerTransportHeaderInterleavedIDsInUse is an error that can be returned by a server.
 type ErrServerTransportHeaderInterleavedIDsInUse struct{}
 
@@ -120,6 +170,11 @@ func (e ErrServerTransportHeaderInterleavedIDsInUse) Error() string {
 	return "interleaved IDs are in use"
 }
 
+// Is implements errors.Is.
+func (e ErrServerTransportHeaderInterleavedIDsInUse) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrServerMediasDifferentPaths is an error that can be returned by a server.
 type ErrServerMediasDifferentPaths struct{}
 
@@ -128,6 +183,11 @@ func (e ErrServerMediasDifferentPaths) Error() string {
 	return "can't setup medias with different paths"
 }
 
+// Is implements errors.Is.
+func (e ErrServerMediasDifferentPaths) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerInvalidKeyMgmtHeader is an error that can be returned by a server.
 type ErrServerInvalidKeyMgmtHeader struct {
 	Wrapped error
@@ -138,6 +198,11 @@ func (e ErrServerInvalidKeyMgmtHeader) Error() string {
 	return fmt.Sprintf("invalid KeyMgmt header: %s", e.Wrapped.Error())
 }
 
+// Is implements errors.Is.
+func (e ErrServerInvalidKeyMgmtHeader) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerMediasDifferentTransports is an error that can be returned by a server.
 type ErrServerMediasDifferentTransports struct{}
 
@@ -146,6 +211,11 @@ func (e ErrServerMediasDifferentTransports) Error() string {
 	return "can't setup medias with different transports"
 }
 
+// Is implements errors.Is.
+func (e ErrServerMediasDifferentTransports) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrServerNoMediasSetup is an error that can be returned by a server.
 type ErrServerNoMediasSetup struct{}
 
@@ -154,6 +224,11 @@ func (e ErrServerNoMediasSetup) Error() string {
 	return "no medias have been setup"
 }
 
+// Is implements errors.Is.
+func (e ErrServerNoMediasSetup) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerNotAllAnnouncedMediasSetup is an error that can be returned by a server.
 type ErrServerNotAllAnnouncedMediasSetup struct{}
 
@@ -162,6 +237,11 @@ func (e ErrServerNotAllAnnouncedMediasSetup) Error() string {
 	return "not all announced medias have been setup"
 }
 
+// Is implements errors.Is.
+func (e ErrServerNotAllAnnouncedMediasSetup) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerLinkedToOtherSession is an error that can be returned by a server.
 type ErrServerLinkedToOtherSession struct{}
 
@@ -196,6 +276,11 @@ func (e ErrServerInvalidSession) Error() string {
 	return "invalid session"
 }
 
+// Is implements errors.Is.
+func (e ErrServerInvalidSession) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerPathHasChanged is an error that can be returned by a server.
 type ErrServerPathHasChanged struct {
 	Prev string
@@ -207,6 +292,11 @@ func (e ErrServerPathHasChanged) Error() string {
 	return fmt.Sprintf("path has changed, was '%s', now is '%s'", e.Prev, e.Cur)
 }
 
+// Is implements errors.Is.
+func (e ErrServerPathHasChanged) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerCannotUseSessionCreatedByOtherIP is an error that can be returned by a server.
 type ErrServerCannotUseSessionCreatedByOtherIP struct{}
 
@@ -226,6 +316,11 @@ func (e ErrServerUDPPortsAlreadyInUse) Error() string {
 		e.Port, e.Port+1)
 }
 
+// Is implements errors.Is.
+func (e ErrServerUDPPortsAlreadyInUse) Is(target error) bool {
+	return target == ErrTransport
+}
+
 // ErrServerSessionNotInUse is an error that can be returned by a server.
 type ErrServerSessionNotInUse struct{}
 
@@ -234,6 +329,34 @@ func (e ErrServerSessionNotInUse) Error() string {
 	return "not in use"
 }
 
+// ErrServerAccessDenied is an error that can be returned by a server.
+type ErrServerAccessDenied struct {
+	IP net.IP
+}
+
+// Error implements the error interface.
+func (e ErrServerAccessDenied) Error() string {
+	return fmt.Sprintf("access denied to %v", e.IP)
+}
+
+// ErrServerSessionLimitReached is an error that can be returned by a server.
+type ErrServerSessionLimitReached struct {
+	Limit string
+}
+
+// Error implements the error interface.
+func (e ErrServerSessionLimitReached) Error() string {
+	return "session limit reached: " + e.Limit
+}
+
+// ErrServerSessionExpired is an error that can be returned by a server.
+type ErrServerSessionExpired struct{}
+
+// Error implements the error interface.
+func (e ErrServerSessionExpired) Error() string {
+	return "session expired"
+}
+
 // ErrServerUnexpectedFrame is an error that can be returned by a server.
 type ErrServerUnexpectedFrame = ErrClientUnexpectedFrame
 
@@ -245,9 +368,20 @@ func (e ErrServerUnexpectedResponse) Error() string {
 	return "received unexpected response"
 }
 
+// Is implements errors.Is.
+func (e ErrServerUnexpectedResponse) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerWriteQueueFull is an error that can be returned by a server.
 type ErrServerWriteQueueFull = ErrClientWriteQueueFull
 
+// ErrServerWouldBlock is an error that can be returned by a server.
+type ErrServerWouldBlock = ErrClientWouldBlock
+
+// ErrServerReadQueueFull is an error that can be returned by a server.
+type ErrServerReadQueueFull = ErrClientReadQueueFull
+
 // ErrServerRTPPacketUnknownPayloadType is an error that can be returned by a server.
 type ErrServerRTPPacketUnknownPayloadType = ErrClientRTPPacketUnknownPayloadType
 
@@ -268,6 +402,37 @@ func (e ErrServerStreamClosed) Error() string {
 	return "stream is closed"
 }
 
+// ErrServerStreamTransformUnsupported is an error that can be returned by a server.
+type ErrServerStreamTransformUnsupported struct{}
+
+// Error implements the error interface.
+func (e ErrServerStreamTransformUnsupported) Error() string {
+	return "streams with TransformPacketRTP can't be read with UDP-multicast or a secure profile"
+}
+
+// ErrServerMemoryBudgetExceeded is an error that can be returned by a server.
+type ErrServerMemoryBudgetExceeded struct{}
+
+// Error implements the error interface.
+func (e ErrServerMemoryBudgetExceeded) Error() string {
+	return "memory budget exceeded"
+}
+
+// ErrServerRateControlHeaderInvalid is an error that can be returned by a server.
+type ErrServerRateControlHeaderInvalid struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrServerRateControlHeaderInvalid) Error() string {
+	return "invalid Rate-Control header: " + e.Err.Error()
+}
+
+// Is implements errors.Is.
+func (e ErrServerRateControlHeaderInvalid) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerInvalidSetupPath is an error that can be returned by a server.
 type ErrServerInvalidSetupPath struct{}
 
@@ -278,6 +443,11 @@ func (ErrServerInvalidSetupPath) Error() string {
 		"unsupported RTSP dialect"
 }
 
+// Is implements errors.Is.
+func (e ErrServerInvalidSetupPath) Is(target error) bool {
+	return target == ErrProtocol
+}
+
 // ErrServerAuth is an error that can be returned by a server.
 // If a client did not provide credentials, it will be asked for
 // credentials instead of being kicked out.
@@ -287,3 +457,8 @@ type ErrServerAuth struct{}
 func (e ErrServerAuth) Error() string {
 	return "authentication error"
 }
+
+// Is implements errors.Is.
+func (e ErrServerAuth) Is(target error) bool {
+	return target == ErrAuthentication
+}
@@ -0,0 +1,378 @@
+// Package loadgen contains a load generator for RTSP servers,
+// that spins up concurrent readers or publishers and reports their performance.
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// Mode is the mode of clients.
+type Mode int
+
+// modes.
+const (
+	// clients read the stream.
+	ModeRead Mode = iota
+
+	// clients publish a stream.
+	ModePublish
+)
+
+// String implements fmt.Stringer.
+func (m Mode) String() string {
+	if m == ModePublish {
+		return "publish"
+	}
+	return "read"
+}
+
+// Report contains the results of a load test.
+type Report struct {
+	// number of clients that were started.
+	Clients int
+
+	// number of clients that failed to connect or that were disconnected.
+	FailedClients int
+
+	// first errors returned by clients.
+	Errors []string
+
+	// setup latency (time needed to connect, describe or announce, setup and play or record).
+	SetupLatencyMin time.Duration
+	SetupLatencyAvg time.Duration
+	SetupLatencyMax time.Duration
+
+	// received or sent RTP packets.
+	Packets uint64
+
+	// received or sent RTP payload bytes.
+	Bytes uint64
+
+	// lost RTP packets (readers only).
+	PacketsLost uint64
+
+	// duration of the test.
+	Duration time.Duration
+}
+
+// Throughput returns the throughput in bits per second.
+func (r *Report) Throughput() float64 {
+	if r.Duration == 0 {
+		return 0
+	}
+	return float64(r.Bytes*8) / r.Duration.Seconds()
+}
+
+// LossRate returns the ratio between lost packets and expected packets.
+func (r *Report) LossRate() float64 {
+	total := r.Packets + r.PacketsLost
+	if total == 0 {
+		return 0
+	}
+	return float64(r.PacketsLost) / float64(total)
+}
+
+const maxReportErrors = 10
+
+// Generator is a load generator.
+type Generator struct {
+	// URL of the target.
+	// When publishing, every client needs a different path:
+	// occurrences of "%d" are replaced with the index of the client.
+	URL string
+
+	// number of concurrent clients.
+	// It defaults to 1.
+	Clients int
+
+	// client mode.
+	// It defaults to ModeRead.
+	Mode Mode
+
+	// transport protocol.
+	// It defaults to nil (automatic).
+	Protocol *gortsplib.Protocol
+
+	// time needed to start all clients.
+	// Clients are started at regular intervals.
+	// It defaults to zero (all clients are started at once).
+	RampUp time.Duration
+
+	// stream published by clients.
+	// It defaults to a single H264 media.
+	Description *description.Session
+
+	// packets per second written by each publisher.
+	// It defaults to 30.
+	PacketRate int
+
+	// payload size of packets written by publishers.
+	// It defaults to 1000.
+	PayloadSize int
+
+	// called to customize each client before it is started.
+	// It defaults to nil.
+	OnClient func(c *gortsplib.Client)
+
+	mutex         sync.Mutex
+	report        Report
+	setupLatSum   time.Duration
+	setupLatCount int
+	packets       uint64
+	bytes         uint64
+	packetsLost   uint64
+}
+
+// Run starts clients and waits until the context is canceled,
+// then it stops clients and returns a report.
+func (g *Generator) Run(ctx context.Context) (*Report, error) {
+	if g.Clients == 0 {
+		g.Clients = 1
+	}
+	if g.Description == nil {
+		g.Description = &description.Session{
+			Medias: []*description.Media{{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					PacketizationMode: 1,
+				}},
+			}},
+		}
+	}
+	if g.PacketRate == 0 {
+		g.PacketRate = 30
+	}
+	if g.PayloadSize == 0 {
+		g.PayloadSize = 1000
+	}
+
+	if g.Mode == ModePublish && !strings.Contains(g.URL, "%d") && g.Clients > 1 {
+		return nil, fmt.Errorf("URL must contain %%d when there are multiple publishers")
+	}
+
+	_, err := base.ParseURL(g.clientURL(0))
+	if err != nil {
+		return nil, err
+	}
+
+	g.report = Report{
+		Clients: g.Clients,
+	}
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+
+	for i := range g.Clients {
+		if i != 0 && g.RampUp != 0 {
+			select {
+			case <-time.After(g.RampUp / time.Duration(g.Clients)):
+			case <-ctx.Done():
+			}
+		}
+
+		if ctx.Err() != nil {
+			g.report.Clients = i
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.runClient(ctx, i)
+		}()
+	}
+
+	wg.Wait()
+
+	g.report.Duration = time.Since(start)
+	g.report.Packets = atomic.LoadUint64(&g.packets)
+	g.report.Bytes = atomic.LoadUint64(&g.bytes)
+	g.report.PacketsLost = atomic.LoadUint64(&g.packetsLost)
+
+	if g.setupLatCount != 0 {
+		g.report.SetupLatencyAvg = g.setupLatSum / time.Duration(g.setupLatCount)
+	}
+
+	ret := g.report
+	return &ret, nil
+}
+
+func (g *Generator) clientURL(i int) string {
+	return strings.ReplaceAll(g.URL, "%d", strconv.FormatInt(int64(i), 10))
+}
+
+func (g *Generator) onSetup(lat time.Duration) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.setupLatCount == 0 || lat < g.report.SetupLatencyMin {
+		g.report.SetupLatencyMin = lat
+	}
+	if lat > g.report.SetupLatencyMax {
+		g.report.SetupLatencyMax = lat
+	}
+
+	g.setupLatSum += lat
+	g.setupLatCount++
+}
+
+func (g *Generator) onError(err error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.report.FailedClients++
+
+	if len(g.report.Errors) < maxReportErrors {
+		g.report.Errors = append(g.report.Errors, err.Error())
+	}
+}
+
+func (g *Generator) runClient(ctx context.Context, i int) {
+	c := &gortsplib.Client{
+		Protocol: g.Protocol,
+		OnPacketsLost: func(lost uint64) {
+			atomic.AddUint64(&g.packetsLost, lost)
+		},
+	}
+
+	if g.OnClient != nil {
+		g.OnClient(c)
+	}
+
+	start := time.Now()
+
+	// each publisher needs its own description, since medias are bound to the client.
+	desc := g.Description.Clone()
+
+	var err error
+	if g.Mode == ModePublish {
+		err = c.StartRecording(g.clientURL(i), desc)
+	} else {
+		err = g.startReader(c, i)
+	}
+	if err != nil {
+		g.onError(err)
+		return
+	}
+	defer c.Close()
+
+	g.onSetup(time.Since(start))
+
+	if g.Mode == ModePublish {
+		err = g.publish(ctx, c, desc)
+	} else {
+		err = g.wait(ctx, c)
+	}
+	if err != nil {
+		g.onError(err)
+	}
+}
+
+func (g *Generator) startReader(c *gortsplib.Client, i int) error {
+	u, err := base.ParseURL(g.clientURL(i))
+	if err != nil {
+		return err
+	}
+
+	c.Scheme = u.Scheme
+	c.Host = u.Host
+
+	err = c.Start()
+	if err != nil {
+		return err
+	}
+
+	desc, _, err := c.Describe(u)
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	c.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+		atomic.AddUint64(&g.packets, 1)
+		atomic.AddUint64(&g.bytes, uint64(len(pkt.Payload)))
+	})
+
+	_, err = c.Play(nil)
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (g *Generator) wait(ctx context.Context, c *gortsplib.Client) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (g *Generator) publish(ctx context.Context, c *gortsplib.Client, desc *description.Session) error {
+	t := time.NewTicker(time.Second / time.Duration(g.PacketRate))
+	defer t.Stop()
+
+	payload := make([]byte, g.PayloadSize)
+	seqNum := uint16(0)
+	start := time.Now()
+
+	for {
+		select {
+		case <-t.C:
+			for _, medi := range desc.Medias {
+				forma := medi.Formats[0]
+
+				err := c.WritePacketRTP(medi, &rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         true,
+						PayloadType:    forma.PayloadType(),
+						SequenceNumber: seqNum,
+						Timestamp:      uint32(time.Since(start).Seconds() * float64(forma.ClockRate())),
+					},
+					Payload: payload,
+				})
+				if err != nil {
+					return err
+				}
+
+				atomic.AddUint64(&g.packets, 1)
+				atomic.AddUint64(&g.bytes, uint64(len(payload)))
+			}
+			seqNum++
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package loadgen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+type testServerHandler struct {
+	stream *gortsplib.ServerStream
+}
+
+func (sh *testServerHandler) OnDescribe(
+	_ *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.stream, nil
+}
+
+func (sh *testServerHandler) OnAnnounce(_ *gortsplib.ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func (sh *testServerHandler) OnSetup(
+	ctx *gortsplib.ServerHandlerOnSetupCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	if ctx.Session.State() == gortsplib.ServerSessionStatePreRecord {
+		return &base.Response{
+			StatusCode: base.StatusOK,
+		}, nil, nil
+	}
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, sh.stream, nil
+}
+
+func (sh *testServerHandler) OnPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func (sh *testServerHandler) OnRecord(_ *gortsplib.ServerHandlerOnRecordCtx) (*base.Response, error) {
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func newTestServer(t testing.TB) (*gortsplib.Server, *gortsplib.ServerStream) {
+	h := &testServerHandler{}
+
+	s := &gortsplib.Server{
+		Handler:     h,
+		RTSPAddress: "localhost:8554",
+	}
+	err := s.Start()
+	require.NoError(t, err)
+
+	h.stream = &gortsplib.ServerStream{
+		Server: s,
+		Desc: &description.Session{
+			Medias: []*description.Media{{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					PacketizationMode: 1,
+				}},
+			}},
+		},
+	}
+	err = h.stream.Initialize()
+	require.NoError(t, err)
+
+	return s, h.stream
+}
+
+func writeTestPacket(stream *gortsplib.ServerStream, seqNum uint16) {
+	stream.WritePacketRTP(stream.Desc.Medias[0], &rtp.Packet{ //nolint:errcheck
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: seqNum,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	})
+}
+
+func TestGeneratorRead(t *testing.T) {
+	s, stream := newTestServer(t)
+	defer s.Close()
+	defer stream.Close()
+
+	writerTerminate := make(chan struct{})
+	writerDone := make(chan struct{})
+	defer func() { <-writerDone }()
+	defer close(writerTerminate)
+
+	go func() {
+		defer close(writerDone)
+
+		t := time.NewTicker(20 * time.Millisecond)
+		defer t.Stop()
+
+		seqNum := uint16(0)
+
+		for {
+			select {
+			case <-t.C:
+				writeTestPacket(stream, seqNum)
+				seqNum++
+
+			case <-writerTerminate:
+				return
+			}
+		}
+	}()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer ctxCancel()
+
+	g := &Generator{
+		URL:      "rtsp://localhost:8554/stream",
+		Clients:  3,
+		Protocol: ptrOf(gortsplib.ProtocolTCP),
+	}
+	report, err := g.Run(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, 3, report.Clients)
+	require.Equal(t, 0, report.FailedClients)
+	require.NotZero(t, report.Packets)
+	require.Equal(t, report.Packets*4, report.Bytes)
+	require.NotZero(t, report.SetupLatencyMax)
+	require.LessOrEqual(t, report.SetupLatencyMin, report.SetupLatencyAvg)
+	require.LessOrEqual(t, report.SetupLatencyAvg, report.SetupLatencyMax)
+	require.NotZero(t, report.Throughput())
+}
+
+func TestGeneratorPublish(t *testing.T) {
+	s, stream := newTestServer(t)
+	defer s.Close()
+	defer stream.Close()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer ctxCancel()
+
+	g := &Generator{
+		URL:         "rtsp://localhost:8554/pub%d",
+		Clients:     2,
+		Mode:        ModePublish,
+		PacketRate:  100,
+		PayloadSize: 10,
+	}
+	report, err := g.Run(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, report.Clients)
+	require.Equal(t, 0, report.FailedClients)
+	require.NotZero(t, report.Packets)
+	require.Equal(t, report.Packets*10, report.Bytes)
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	g := &Generator{
+		URL:     "rtsp://localhost:8554/pub",
+		Clients: 2,
+		Mode:    ModePublish,
+	}
+	_, err := g.Run(context.Background())
+	require.EqualError(t, err, "URL must contain %d when there are multiple publishers")
+
+	g = &Generator{
+		URL:     "rtsp://localhost:8554/stream",
+		Clients: 2,
+	}
+	report, err := g.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, report.FailedClients)
+	require.Len(t, report.Errors, 2)
+}
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
+func BenchmarkServerStreamWriteTCP10Readers(b *testing.B) {
+	s, stream := newTestServer(b)
+	defer s.Close()
+	defer stream.Close()
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+
+	g := &Generator{
+		URL:      "rtsp://localhost:8554/stream",
+		Clients:  10,
+		Protocol: ptrOf(gortsplib.ProtocolTCP),
+	}
+
+	genDone := make(chan *Report)
+	go func() {
+		report, _ := g.Run(ctx)
+		genDone <- report
+	}()
+
+	// wait for readers
+	for stream.Stats().BytesSent == 0 {
+		writeTestPacket(stream, 0)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	b.ResetTimer()
+
+	for i := range b.N {
+		writeTestPacket(stream, uint16(i))
+	}
+
+	b.StopTimer()
+
+	ctxCancel()
+	report := <-genDone
+	b.ReportMetric(float64(report.Packets)/float64(b.N), "recv/op")
+}
@@ -0,0 +1,133 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// EBML and Matroska element IDs.
+// Specification: RFC8794, RFC9559
+const (
+	idEBML               = 0x1A45DFA3
+	idEBMLVersion        = 0x4286
+	idEBMLReadVersion    = 0x42F7
+	idEBMLMaxIDLength    = 0x42F2
+	idEBMLMaxSizeLength  = 0x42F3
+	idDocType            = 0x4282
+	idDocTypeVersion     = 0x4287
+	idDocTypeReadVersion = 0x4285
+	idSegment            = 0x18538067
+	idInfo               = 0x1549A966
+	idTimestampScale     = 0x2AD7B1
+	idMuxingApp          = 0x4D80
+	idWritingApp         = 0x5741
+	idTracks             = 0x1654AE6B
+	idTrackEntry         = 0xAE
+	idTrackNumber        = 0xD7
+	idTrackUID           = 0x73C5
+	idTrackType          = 0x83
+	idFlagLacing         = 0x9C
+	idCodecID            = 0x86
+	idCodecPrivate       = 0x63A2
+	idVideo              = 0xE0
+	idPixelWidth         = 0xB0
+	idPixelHeight        = 0xBA
+	idAudio              = 0xE1
+	idSamplingFrequency  = 0xB5
+	idChannels           = 0x9F
+	idCluster            = 0x1F43B675
+	idTimestamp          = 0xE7
+	idSimpleBlock        = 0xA3
+)
+
+// unknownSize is the size of master elements whose size is not known in advance.
+var unknownSize = []byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+type ebmlElement interface {
+	marshalTo(buf []byte) []byte
+}
+
+type ebmlMaster struct {
+	id       uint32
+	children []ebmlElement
+}
+
+func (e ebmlMaster) marshalTo(buf []byte) []byte {
+	var content []byte
+	for _, child := range e.children {
+		content = child.marshalTo(content)
+	}
+
+	buf = appendID(buf, e.id)
+	buf = appendSize(buf, uint64(len(content)))
+	return append(buf, content...)
+}
+
+type ebmlUint struct {
+	id    uint32
+	value uint64
+}
+
+func (e ebmlUint) marshalTo(buf []byte) []byte {
+	n := 1
+	for v := e.value >> 8; v != 0; v >>= 8 {
+		n++
+	}
+
+	buf = appendID(buf, e.id)
+	buf = appendSize(buf, uint64(n))
+	for i := n - 1; i >= 0; i-- {
+		buf = append(buf, byte(e.value>>(8*i)))
+	}
+	return buf
+}
+
+type ebmlFloat struct {
+	id    uint32
+	value float64
+}
+
+func (e ebmlFloat) marshalTo(buf []byte) []byte {
+	buf = appendID(buf, e.id)
+	buf = appendSize(buf, 8)
+	return binary.BigEndian.AppendUint64(buf, math.Float64bits(e.value))
+}
+
+type ebmlBinary struct {
+	id    uint32
+	value []byte
+}
+
+func (e ebmlBinary) marshalTo(buf []byte) []byte {
+	buf = appendID(buf, e.id)
+	buf = appendSize(buf, uint64(len(e.value)))
+	return append(buf, e.value...)
+}
+
+func appendID(buf []byte, id uint32) []byte {
+	switch {
+	case id > 0xFFFFFF:
+		return append(buf, byte(id>>24), byte(id>>16), byte(id>>8), byte(id))
+	case id > 0xFFFF:
+		return append(buf, byte(id>>16), byte(id>>8), byte(id))
+	case id > 0xFF:
+		return append(buf, byte(id>>8), byte(id))
+	default:
+		return append(buf, byte(id))
+	}
+}
+
+// appendSize appends a variable-size integer.
+func appendSize(buf []byte, v uint64) []byte {
+	n := 1
+	// values with all bits set to 1 are reserved
+	for v >= (uint64(1)<<(7*n))-1 {
+		n++
+	}
+
+	v |= uint64(1) << (7 * n)
+	for i := n - 1; i >= 0; i-- {
+		buf = append(buf, byte(v>>(8*i)))
+	}
+	return buf
+}
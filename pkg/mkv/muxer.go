@@ -0,0 +1,298 @@
+// Package mkv contains a Matroska / WebM muxer.
+package mkv
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/opus"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+)
+
+const (
+	// timestamps are expressed in milliseconds.
+	timestampScale = 1000000
+)
+
+// Muxer is a Matroska muxer.
+// It takes frames of one or more tracks and writes
+// a Matroska stream into an io.Writer.
+// When all tracks are VP8, VP9 or Opus, the stream is a WebM stream.
+//
+// The stream is written in live mode (without seeking and without cues),
+// therefore it can be written to files or sent through the network.
+// H264 and H265 parameters are kept in-band and are repeated before every random access unit,
+// therefore resolution changes in the middle of the stream are supported.
+//
+// Timestamps are expressed in clock rate units of each track and
+// must share the same origin (like the ones returned by Client.PacketPTS()).
+// The stream starts with a random access frame of the leading track,
+// that is the first video track, or the first track if there are no video tracks,
+// once parameters of all video tracks are available.
+type Muxer struct {
+	// destination of the stream.
+	W io.Writer
+
+	// tracks.
+	Tracks []*Track
+
+	// maximum duration of clusters.
+	// It defaults to 5 seconds.
+	ClusterMaxDuration time.Duration
+
+	leader           *Track
+	headerWritten    bool
+	start            time.Duration
+	clusterStarted   bool
+	clusterTimestamp int64
+}
+
+// Initialize initializes Muxer.
+func (m *Muxer) Initialize() error {
+	if m.W == nil {
+		return fmt.Errorf("W not provided")
+	}
+
+	if len(m.Tracks) == 0 {
+		return fmt.Errorf("no tracks provided")
+	}
+
+	if m.ClusterMaxDuration == 0 {
+		m.ClusterMaxDuration = 5 * time.Second
+	}
+
+	for i, track := range m.Tracks {
+		err := track.initialize(i + 1)
+		if err != nil {
+			return err
+		}
+
+		if m.leader == nil && track.isVideo {
+			m.leader = track
+		}
+	}
+
+	if m.leader == nil {
+		m.leader = m.Tracks[0]
+	}
+
+	return nil
+}
+
+// WriteH264 writes a H264 access unit.
+// SPS and PPS are automatically added before IDR frames.
+func (m *Muxer) WriteH264(track *Track, pts int64, au [][]byte) error {
+	au, err := track.prepareH264(au)
+	if err != nil {
+		return err
+	}
+
+	if len(au) == 0 {
+		return nil
+	}
+
+	payload, err := h264.AVCC(au).Marshal()
+	if err != nil {
+		return err
+	}
+
+	return m.writeBlock(track, pts, h264.IsRandomAccess(au), payload)
+}
+
+// WriteH265 writes a H265 access unit.
+// VPS, SPS and PPS are automatically added before random access units.
+func (m *Muxer) WriteH265(track *Track, pts int64, au [][]byte) error {
+	au, err := track.prepareH265(au)
+	if err != nil {
+		return err
+	}
+
+	if len(au) == 0 {
+		return nil
+	}
+
+	payload, err := h264.AVCC(au).Marshal()
+	if err != nil {
+		return err
+	}
+
+	return m.writeBlock(track, pts, h265.IsRandomAccess(au), payload)
+}
+
+// WriteVP8 writes a VP8 frame.
+func (m *Muxer) WriteVP8(track *Track, pts int64, frame []byte) error {
+	keyFrame, err := track.parseVP8(frame)
+	if err != nil {
+		return err
+	}
+
+	return m.writeBlock(track, pts, keyFrame, frame)
+}
+
+// WriteVP9 writes a VP9 frame.
+func (m *Muxer) WriteVP9(track *Track, pts int64, frame []byte) error {
+	keyFrame, err := track.parseVP9(frame)
+	if err != nil {
+		return err
+	}
+
+	return m.writeBlock(track, pts, keyFrame, frame)
+}
+
+// WriteOpus writes Opus packets.
+func (m *Muxer) WriteOpus(track *Track, pts int64, packets [][]byte) error {
+	for _, pkt := range packets {
+		err := m.writeBlock(track, pts, true, pkt)
+		if err != nil {
+			return err
+		}
+
+		pts += opus.PacketDuration2(pkt)
+	}
+
+	return nil
+}
+
+func (m *Muxer) allTracksReady() bool {
+	for _, track := range m.Tracks {
+		if !track.isReady() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Muxer) writeHeader() error {
+	docType := "webm"
+	for _, track := range m.Tracks {
+		if !track.isWebMCompatible() {
+			docType = "matroska"
+			break
+		}
+	}
+
+	buf := ebmlMaster{idEBML, []ebmlElement{
+		ebmlUint{idEBMLVersion, 1},
+		ebmlUint{idEBMLReadVersion, 1},
+		ebmlUint{idEBMLMaxIDLength, 4},
+		ebmlUint{idEBMLMaxSizeLength, 8},
+		ebmlBinary{idDocType, []byte(docType)},
+		ebmlUint{idDocTypeVersion, 4},
+		ebmlUint{idDocTypeReadVersion, 2},
+	}}.marshalTo(nil)
+
+	// the segment is written in live mode, therefore its size is unknown
+	buf = appendID(buf, idSegment)
+	buf = append(buf, unknownSize...)
+
+	buf = ebmlMaster{idInfo, []ebmlElement{
+		ebmlUint{idTimestampScale, timestampScale},
+		ebmlBinary{idMuxingApp, []byte("gortsplib")},
+		ebmlBinary{idWritingApp, []byte("gortsplib")},
+	}}.marshalTo(buf)
+
+	entries := make([]ebmlElement, len(m.Tracks))
+
+	for i, track := range m.Tracks {
+		var err error
+		entries[i], err = track.entry()
+		if err != nil {
+			return err
+		}
+	}
+
+	buf = ebmlMaster{idTracks, entries}.marshalTo(buf)
+
+	_, err := m.W.Write(buf)
+	return err
+}
+
+func (m *Muxer) writeBlock(track *Track, pts int64, keyFrame bool, payload []byte) error {
+	if !m.headerWritten {
+		// wait for a random access frame of the leading track
+		// and for parameters of all tracks.
+		if track != m.leader || !keyFrame || !m.allTracksReady() {
+			return nil
+		}
+
+		err := m.writeHeader()
+		if err != nil {
+			return err
+		}
+
+		m.headerWritten = true
+		m.start = mediatime.TimestampToDuration(pts, track.clockRate)
+	}
+
+	if !track.started {
+		// wait for a random access frame
+		if !keyFrame {
+			return nil
+		}
+		track.started = true
+	}
+
+	d := mediatime.TimestampToDuration(pts, track.clockRate) - m.start
+
+	// discard frames before the start of the stream
+	if d < 0 {
+		return nil
+	}
+
+	ts := int64(d / time.Millisecond)
+
+	if !m.clusterStarted ||
+		(track == m.leader && keyFrame &&
+			time.Duration(ts-m.clusterTimestamp)*time.Millisecond >= m.ClusterMaxDuration) ||
+		(ts-m.clusterTimestamp) > math.MaxInt16 || (ts-m.clusterTimestamp) < math.MinInt16 {
+		err := m.writeClusterHeader(ts)
+		if err != nil {
+			return err
+		}
+	}
+
+	rel := int16(ts - m.clusterTimestamp)
+
+	var flags byte
+	if keyFrame {
+		flags = 0x80
+	}
+
+	// track number, relative timestamp, flags
+	header := appendSize(nil, uint64(track.number))
+	header = append(header, byte(rel>>8), byte(rel), flags)
+
+	buf := appendID(nil, idSimpleBlock)
+	buf = appendSize(buf, uint64(len(header)+len(payload)))
+	buf = append(buf, header...)
+
+	_, err := m.W.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.W.Write(payload)
+	return err
+}
+
+func (m *Muxer) writeClusterHeader(ts int64) error {
+	// clusters are written in live mode, therefore their size is unknown
+	buf := appendID(nil, idCluster)
+	buf = append(buf, unknownSize...)
+	buf = ebmlUint{idTimestamp, uint64(ts)}.marshalTo(buf)
+
+	_, err := m.W.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	m.clusterStarted = true
+	m.clusterTimestamp = ts
+
+	return nil
+}
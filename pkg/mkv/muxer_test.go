@@ -0,0 +1,231 @@
+package mkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+type testElement struct {
+	id   uint32
+	data []byte
+}
+
+func readVint(buf []byte, keepMarker bool) (uint64, int) {
+	n := 1
+	for mask := byte(0x80); (buf[0] & mask) == 0; mask >>= 1 {
+		n++
+	}
+
+	v := uint64(buf[0])
+	if !keepMarker {
+		v &= (1 << (8 - n)) - 1
+	}
+
+	allOnes := v == (1<<(8-n))-1
+
+	for i := 1; i < n; i++ {
+		v = v<<8 | uint64(buf[i])
+		if buf[i] != 0xFF {
+			allOnes = false
+		}
+	}
+
+	if !keepMarker && allOnes {
+		return math.MaxUint64, n
+	}
+
+	return v, n
+}
+
+// flatten returns leaf elements, entering into master elements.
+func flatten(buf []byte) []testElement {
+	masters := map[uint32]struct{}{
+		idEBML: {}, idSegment: {}, idInfo: {}, idTracks: {}, idTrackEntry: {},
+		idVideo: {}, idAudio: {}, idCluster: {},
+	}
+
+	var ret []testElement
+
+	for len(buf) > 0 {
+		id, n := readVint(buf, true)
+		buf = buf[n:]
+		size, n := readVint(buf, false)
+		buf = buf[n:]
+
+		if _, ok := masters[uint32(id)]; ok {
+			ret = append(ret, testElement{id: uint32(id)})
+			if size == math.MaxUint64 {
+				continue
+			}
+			ret = append(ret, flatten(buf[:size])...)
+			buf = buf[size:]
+			continue
+		}
+
+		ret = append(ret, testElement{uint32(id), buf[:size]})
+		buf = buf[size:]
+	}
+
+	return ret
+}
+
+func findElements(els []testElement, id uint32) []testElement {
+	var ret []testElement
+	for _, el := range els {
+		if el.id == id {
+			ret = append(ret, el)
+		}
+	}
+	return ret
+}
+
+func TestMuxerH264Opus(t *testing.T) {
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+	}
+
+	audioTrack := &Track{
+		Format: &format.Opus{
+			PayloadTyp:   97,
+			ChannelCount: 2,
+		},
+	}
+
+	var buf bytes.Buffer
+
+	m := &Muxer{
+		W:      &buf,
+		Tracks: []*Track{audioTrack, videoTrack},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	// discarded since the leading track has not started yet
+	err = m.WriteOpus(audioTrack, 0, [][]byte{{0xf8, 1}})
+	require.NoError(t, err)
+
+	// discarded since it is not a random access unit
+	err = m.WriteH264(videoTrack, 0, [][]byte{{1, 0}})
+	require.NoError(t, err)
+
+	require.Equal(t, 0, buf.Len())
+
+	err = m.WriteH264(videoTrack, 90000, [][]byte{testSPS, testPPS, {5, 1}})
+	require.NoError(t, err)
+
+	// two 20ms packets
+	err = m.WriteOpus(audioTrack, 48000+480, [][]byte{{0xf8, 2}, {0xf8, 3}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(videoTrack, 90000+3000, [][]byte{{1, 2}})
+	require.NoError(t, err)
+
+	// IDR without parameters, that are added automatically
+	err = m.WriteH264(videoTrack, 90000+6000, [][]byte{{5, 3}})
+	require.NoError(t, err)
+
+	els := flatten(buf.Bytes())
+
+	require.Equal(t, []byte("matroska"), findElements(els, idDocType)[0].data)
+	require.Equal(t, [][]byte{[]byte("A_OPUS"), []byte("V_MPEG4/ISO/AVC")}, [][]byte{
+		findElements(els, idCodecID)[0].data,
+		findElements(els, idCodecID)[1].data,
+	})
+	require.Equal(t, []byte{0x07, 0x80}, findElements(els, idPixelWidth)[0].data)
+	require.Equal(t, []byte{0x04, 0x38}, findElements(els, idPixelHeight)[0].data)
+	require.Equal(t, h264CodecPrivate(testSPS, testPPS), findElements(els, idCodecPrivate)[1].data)
+
+	require.Len(t, findElements(els, idCluster), 1)
+
+	blocks := findElements(els, idSimpleBlock)
+	require.Len(t, blocks, 5)
+
+	for i, ca := range []struct {
+		track    byte
+		ts       int16
+		keyFrame bool
+	}{
+		{2, 0, true},
+		{1, 10, true},
+		{1, 30, true},
+		{2, 33, false},
+		{2, 66, true},
+	} {
+		require.Equal(t, 0x80|ca.track, blocks[i].data[0])
+		require.Equal(t, ca.ts, int16(binary.BigEndian.Uint16(blocks[i].data[1:])))
+		require.Equal(t, ca.keyFrame, blocks[i].data[3] == 0x80)
+	}
+
+	require.Equal(t, []byte{
+		0, 0, 0, 25,
+	}, blocks[4].data[4:8])
+}
+
+func TestMuxerVP8Clusters(t *testing.T) {
+	track := &Track{
+		Format: &format.VP8{
+			PayloadTyp: 96,
+		},
+	}
+
+	var buf bytes.Buffer
+
+	m := &Muxer{
+		W:      &buf,
+		Tracks: []*Track{track},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	keyFrame := []byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a, 0x80, 0x02, 0xe0, 0x01}
+	interFrame := []byte{0x11, 0x02, 0x00}
+
+	for i := 0; i < 20; i++ {
+		frame := interFrame
+		if (i % 5) == 0 {
+			frame = keyFrame
+		}
+
+		err = m.WriteVP8(track, int64(i)*90000, frame)
+		require.NoError(t, err)
+	}
+
+	els := flatten(buf.Bytes())
+
+	require.Equal(t, []byte("webm"), findElements(els, idDocType)[0].data)
+	require.Equal(t, []byte("V_VP8"), findElements(els, idCodecID)[0].data)
+	require.Equal(t, []byte{0x02, 0x80}, findElements(els, idPixelWidth)[0].data)
+	require.Equal(t, []byte{0x01, 0xe0}, findElements(els, idPixelHeight)[0].data)
+
+	// a new cluster is started on key frames once the maximum duration is reached
+	require.Len(t, findElements(els, idCluster), 4)
+	require.Equal(t, []byte{0x13, 0x88}, findElements(els, idTimestamp)[1].data)
+	require.Len(t, findElements(els, idSimpleBlock), 20)
+}
+
+func TestMuxerErrors(t *testing.T) {
+	m := &Muxer{
+		W:      &bytes.Buffer{},
+		Tracks: []*Track{{Format: &format.G711{}}},
+	}
+	err := m.Initialize()
+	require.EqualError(t, err, "unsupported format: *format.G711")
+}
@@ -0,0 +1,341 @@
+package mkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	amp4 "github.com/abema/go-mp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/vp9"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func h264CodecPrivate(sps []byte, pps []byte) []byte {
+	// AVCDecoderConfigurationRecord
+	// Specification: ISO 14496-15, section 5.3.3.1
+	buf := []byte{1, sps[1], sps[2], sps[3], 0xFC | 3, 0xE0 | 1}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(sps)))
+	buf = append(buf, sps...)
+	buf = append(buf, 1)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(pps)))
+	return append(buf, pps...)
+}
+
+func h265CodecPrivate(vps []byte, sps []byte, pps []byte) ([]byte, error) {
+	var spsp h265.SPS
+	err := spsp.Unmarshal(sps)
+	if err != nil {
+		return nil, err
+	}
+
+	// HEVCDecoderConfigurationRecord
+	// Specification: ISO 14496-15, section 8.3.3.1
+	var buf bytes.Buffer
+	_, err = amp4.Marshal(&buf, &amp4.HvcC{
+		ConfigurationVersion:        1,
+		GeneralProfileIdc:           spsp.ProfileTierLevel.GeneralProfileIdc,
+		GeneralProfileCompatibility: spsp.ProfileTierLevel.GeneralProfileCompatibilityFlag,
+		GeneralConstraintIndicator: [6]uint8{
+			sps[7], sps[8], sps[9],
+			sps[10], sps[11], sps[12],
+		},
+		GeneralLevelIdc:      spsp.ProfileTierLevel.GeneralLevelIdc,
+		ChromaFormatIdc:      uint8(spsp.ChromaFormatIdc),
+		BitDepthLumaMinus8:   uint8(spsp.BitDepthLumaMinus8),
+		BitDepthChromaMinus8: uint8(spsp.BitDepthChromaMinus8),
+		NumTemporalLayers:    1,
+		LengthSizeMinusOne:   3,
+		NumOfNaluArrays:      3,
+		NaluArrays: []amp4.HEVCNaluArray{
+			{
+				NaluType: byte(h265.NALUType_VPS_NUT),
+				NumNalus: 1,
+				Nalus:    []amp4.HEVCNalu{{Length: uint16(len(vps)), NALUnit: vps}},
+			},
+			{
+				NaluType: byte(h265.NALUType_SPS_NUT),
+				NumNalus: 1,
+				Nalus:    []amp4.HEVCNalu{{Length: uint16(len(sps)), NALUnit: sps}},
+			},
+			{
+				NaluType: byte(h265.NALUType_PPS_NUT),
+				NumNalus: 1,
+				Nalus:    []amp4.HEVCNalu{{Length: uint16(len(pps)), NALUnit: pps}},
+			},
+		},
+	}, amp4.Context{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func opusCodecPrivate(channelCount int) []byte {
+	// OpusHead
+	// Specification: RFC7845, section 5.1
+	buf := []byte{'O', 'p', 'u', 's', 'H', 'e', 'a', 'd', 1, byte(channelCount)}
+	buf = binary.LittleEndian.AppendUint16(buf, 0)     // pre-skip
+	buf = binary.LittleEndian.AppendUint32(buf, 48000) // input sample rate
+	buf = binary.LittleEndian.AppendUint16(buf, 0)     // output gain
+	return append(buf, 0)                              // channel mapping family
+}
+
+// Track is a track of a Muxer.
+type Track struct {
+	// format of the track.
+	// Supported formats are H264, H265, VP8, VP9 and Opus.
+	Format format.Format
+
+	number    int
+	clockRate int
+	isVideo   bool
+	vps       []byte
+	sps       []byte
+	pps       []byte
+	width     int
+	height    int
+	started   bool
+}
+
+func (t *Track) initialize(number int) error {
+	t.number = number
+	t.clockRate = t.Format.ClockRate()
+
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		t.isVideo = true
+		t.sps, t.pps = forma.SafeParams()
+
+	case *format.H265:
+		t.isVideo = true
+		t.vps, t.sps, t.pps = forma.SafeParams()
+
+	case *format.VP8, *format.VP9:
+		t.isVideo = true
+
+	case *format.Opus:
+
+	default:
+		return fmt.Errorf("unsupported format: %T", t.Format)
+	}
+
+	return nil
+}
+
+// isReady returns whether the track header can be written.
+func (t *Track) isReady() bool {
+	switch t.Format.(type) {
+	case *format.H264:
+		return t.sps != nil && t.pps != nil
+
+	case *format.H265:
+		return t.vps != nil && t.sps != nil && t.pps != nil
+
+	case *format.VP8, *format.VP9:
+		return t.width != 0
+
+	default: // *format.Opus
+		return true
+	}
+}
+
+func (t *Track) isWebMCompatible() bool {
+	switch t.Format.(type) {
+	case *format.VP8, *format.VP9, *format.Opus:
+		return true
+	}
+	return false
+}
+
+func (t *Track) entry() (ebmlElement, error) {
+	children := []ebmlElement{
+		ebmlUint{idTrackNumber, uint64(t.number)},
+		ebmlUint{idTrackUID, uint64(t.number)},
+	}
+
+	if t.isVideo {
+		children = append(children, ebmlUint{idTrackType, 1})
+	} else {
+		children = append(children, ebmlUint{idTrackType, 2})
+	}
+
+	children = append(children, ebmlUint{idFlagLacing, 0})
+
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		children = append(children,
+			ebmlBinary{idCodecID, []byte("V_MPEG4/ISO/AVC")},
+			ebmlBinary{idCodecPrivate, h264CodecPrivate(t.sps, t.pps)})
+
+	case *format.H265:
+		cp, err := h265CodecPrivate(t.vps, t.sps, t.pps)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children,
+			ebmlBinary{idCodecID, []byte("V_MPEGH/ISO/HEVC")},
+			ebmlBinary{idCodecPrivate, cp})
+
+	case *format.VP8:
+		children = append(children, ebmlBinary{idCodecID, []byte("V_VP8")})
+
+	case *format.VP9:
+		children = append(children, ebmlBinary{idCodecID, []byte("V_VP9")})
+
+	case *format.Opus:
+		children = append(children,
+			ebmlBinary{idCodecID, []byte("A_OPUS")},
+			ebmlBinary{idCodecPrivate, opusCodecPrivate(forma.ChannelCount)},
+			ebmlMaster{idAudio, []ebmlElement{
+				ebmlFloat{idSamplingFrequency, 48000},
+				ebmlUint{idChannels, uint64(forma.ChannelCount)},
+			}})
+	}
+
+	if t.isVideo {
+		children = append(children, ebmlMaster{idVideo, []ebmlElement{
+			ebmlUint{idPixelWidth, uint64(t.width)},
+			ebmlUint{idPixelHeight, uint64(t.height)},
+		}})
+	}
+
+	return ebmlMaster{idTrackEntry, children}, nil
+}
+
+// prepareH264 removes AUDs, stores parameters and
+// adds them before random access units.
+func (t *Track) prepareH264(au [][]byte) ([][]byte, error) {
+	filtered := make([][]byte, 0, len(au)+2)
+	randomAccess := false
+
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			t.sps = nalu
+			continue
+
+		case h264.NALUTypePPS:
+			t.pps = nalu
+			continue
+
+		case h264.NALUTypeAccessUnitDelimiter:
+			continue
+
+		case h264.NALUTypeIDR:
+			randomAccess = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if randomAccess && t.sps != nil && t.pps != nil {
+		filtered = append([][]byte{t.sps, t.pps}, filtered...)
+
+		if t.width == 0 {
+			var spsp h264.SPS
+			err := spsp.Unmarshal(t.sps)
+			if err != nil {
+				return nil, err
+			}
+			t.width = spsp.Width()
+			t.height = spsp.Height()
+		}
+	}
+
+	return filtered, nil
+}
+
+// prepareH265 removes AUDs, stores parameters and
+// adds them before random access units.
+func (t *Track) prepareH265(au [][]byte) ([][]byte, error) {
+	filtered := make([][]byte, 0, len(au)+3)
+	randomAccess := false
+
+	for _, nalu := range au {
+		switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+		case h265.NALUType_VPS_NUT:
+			t.vps = nalu
+			continue
+
+		case h265.NALUType_SPS_NUT:
+			t.sps = nalu
+			continue
+
+		case h265.NALUType_PPS_NUT:
+			t.pps = nalu
+			continue
+
+		case h265.NALUType_AUD_NUT:
+			continue
+
+		case h265.NALUType_IDR_W_RADL, h265.NALUType_IDR_N_LP, h265.NALUType_CRA_NUT:
+			randomAccess = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if randomAccess && t.vps != nil && t.sps != nil && t.pps != nil {
+		filtered = append([][]byte{t.vps, t.sps, t.pps}, filtered...)
+
+		if t.width == 0 {
+			var spsp h265.SPS
+			err := spsp.Unmarshal(t.sps)
+			if err != nil {
+				return nil, err
+			}
+			t.width = spsp.Width()
+			t.height = spsp.Height()
+		}
+	}
+
+	return filtered, nil
+}
+
+// parseVP8 returns whether the frame is a key frame and fills dimensions.
+// Specification: RFC6386, section 9.1
+func (t *Track) parseVP8(frame []byte) (bool, error) {
+	if len(frame) < 3 {
+		return false, fmt.Errorf("invalid VP8 frame")
+	}
+
+	if (frame[0] & 0x01) != 0 {
+		return false, nil
+	}
+
+	if len(frame) < 10 || frame[3] != 0x9D || frame[4] != 0x01 || frame[5] != 0x2A {
+		return false, fmt.Errorf("invalid VP8 key frame")
+	}
+
+	if t.width == 0 {
+		t.width = int(binary.LittleEndian.Uint16(frame[6:]) & 0x3FFF)
+		t.height = int(binary.LittleEndian.Uint16(frame[8:]) & 0x3FFF)
+	}
+
+	return true, nil
+}
+
+// parseVP9 returns whether the frame is a key frame and fills dimensions.
+func (t *Track) parseVP9(frame []byte) (bool, error) {
+	var h vp9.Header
+	err := h.Unmarshal(frame)
+	if err != nil {
+		return false, err
+	}
+
+	if h.NonKeyFrame {
+		return false, nil
+	}
+
+	if t.width == 0 {
+		t.width = h.Width()
+		t.height = h.Height()
+	}
+
+	return true, nil
+}
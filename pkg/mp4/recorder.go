@@ -0,0 +1,316 @@
+// Package mp4 contains a MP4 recorder.
+package mp4
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/pmp4"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+)
+
+// Recorder writes tracks into MP4 files.
+// Files are written with the moov box before media data ("faststart"),
+// therefore they can be played while they are being downloaded.
+// Media data is stored into a temporary file until the MP4 file is finalized.
+//
+// Timestamps are expressed in clock rate units of each track and
+// must share the same origin (like the ones returned by Client.PacketPTS()).
+// Files start with a random access sample of the leading track,
+// that is the first video track, or the first track if there are no video tracks.
+type Recorder struct {
+	// tracks.
+	Tracks []*Track
+
+	// returns the path of a new file.
+	// It is called every time a file is created.
+	Path func(start time.Time) string
+
+	// maximum duration of a file.
+	// When it is reached, a new file is created.
+	// It defaults to zero, that means unlimited.
+	MaxDuration time.Duration
+
+	// maximum size of media data of a file.
+	// When it is reached, a new file is created.
+	// It defaults to zero, that means unlimited.
+	MaxSize int64
+
+	// called when a file has been completely written.
+	OnFileComplete func(path string)
+
+	timeNow func() time.Time
+
+	leader    *Track
+	started   bool
+	fileStart time.Duration
+	path      string
+	tmp       *os.File
+	tmpSize   int64
+}
+
+// Initialize initializes Recorder.
+func (r *Recorder) Initialize() error {
+	if len(r.Tracks) == 0 {
+		return fmt.Errorf("no tracks provided")
+	}
+
+	if r.Path == nil {
+		return fmt.Errorf("Path not provided")
+	}
+
+	if r.OnFileComplete == nil {
+		r.OnFileComplete = func(string) {}
+	}
+	if r.timeNow == nil {
+		r.timeNow = time.Now
+	}
+
+	for _, track := range r.Tracks {
+		err := track.initialize()
+		if err != nil {
+			return err
+		}
+
+		if r.leader == nil && track.isVideo {
+			r.leader = track
+		}
+	}
+
+	if r.leader == nil {
+		r.leader = r.Tracks[0]
+	}
+
+	return nil
+}
+
+// Close finalizes the current file.
+func (r *Recorder) Close() error {
+	if !r.started {
+		return nil
+	}
+
+	r.started = false
+	return r.finalizeFile()
+}
+
+// WriteH264 writes a H264 access unit.
+// DTS is computed automatically.
+// SPS and PPS are automatically added before IDR frames.
+func (r *Recorder) WriteH264(track *Track, pts int64, au [][]byte) error {
+	au = track.prepareH264(au)
+	if len(au) == 0 {
+		return nil
+	}
+
+	randomAccess := h264.IsRandomAccess(au)
+
+	dts, ok, err := track.h264DTS.Extract(au, pts)
+	if err != nil || !ok {
+		return err
+	}
+
+	payload, err := h264.AVCC(au).Marshal()
+	if err != nil {
+		return err
+	}
+
+	return r.writeSample(track, pts, dts, randomAccess, payload)
+}
+
+// WriteH265 writes a H265 access unit.
+// DTS is computed automatically.
+// VPS, SPS and PPS are automatically added before random access units.
+func (r *Recorder) WriteH265(track *Track, pts int64, au [][]byte) error {
+	au = track.prepareH265(au)
+	if len(au) == 0 {
+		return nil
+	}
+
+	randomAccess := h265.IsRandomAccess(au)
+
+	dts, ok, err := track.h265DTS.Extract(au, pts)
+	if err != nil || !ok {
+		return err
+	}
+
+	payload, err := h264.AVCC(au).Marshal()
+	if err != nil {
+		return err
+	}
+
+	return r.writeSample(track, pts, dts, randomAccess, payload)
+}
+
+// WriteMPEG4Audio writes MPEG-4 Audio access units.
+func (r *Recorder) WriteMPEG4Audio(track *Track, pts int64, aus [][]byte) error {
+	for i, au := range aus {
+		dts := pts + int64(i)*mpeg4audio.SamplesPerAccessUnit
+
+		err := r.writeSample(track, dts, dts, true, au)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Recorder) writeSample(track *Track, pts int64, dts int64, randomAccess bool, payload []byte) error {
+	if track == r.leader {
+		switch {
+		case !r.started:
+			// wait for a random access sample
+			if !randomAccess {
+				return nil
+			}
+
+			err := r.createFile(pts)
+			if err != nil {
+				return err
+			}
+			r.started = true
+
+		case randomAccess && r.fileIsFull(pts):
+			// the sample that starts the new file must not be part of the current one
+			track.complete(dts)
+
+			err := r.finalizeFile()
+			if err != nil {
+				r.started = false
+				return err
+			}
+
+			err = r.createFile(pts)
+			if err != nil {
+				r.started = false
+				return err
+			}
+		}
+	} else if !r.started {
+		return nil
+	}
+
+	offset := r.tmpSize
+	tmp := r.tmp
+
+	_, err := tmp.Write(payload)
+	if err != nil {
+		return err
+	}
+	r.tmpSize += int64(len(payload))
+
+	payloadSize := uint32(len(payload))
+
+	track.push(dts, &pmp4.Sample{
+		PTSOffset:       int32(pts - dts),
+		IsNonSyncSample: !randomAccess,
+		PayloadSize:     payloadSize,
+		GetPayload: func() ([]byte, error) {
+			buf := make([]byte, payloadSize)
+			_, err2 := tmp.ReadAt(buf, offset)
+			return buf, err2
+		},
+	})
+
+	return nil
+}
+
+func (r *Recorder) fileIsFull(leaderPTS int64) bool {
+	if r.MaxDuration != 0 &&
+		mediatime.TimestampToDuration(leaderPTS, r.leader.clockRate)-r.fileStart >= r.MaxDuration {
+		return true
+	}
+
+	return r.MaxSize != 0 && r.tmpSize >= r.MaxSize
+}
+
+func (r *Recorder) createFile(leaderPTS int64) error {
+	r.path = r.Path(r.timeNow())
+
+	var err error
+	r.tmp, err = os.Create(r.path + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	r.fileStart = mediatime.TimestampToDuration(leaderPTS, r.leader.clockRate)
+	r.tmpSize = 0
+
+	return nil
+}
+
+func (r *Recorder) finalizeFile() error {
+	defer func() {
+		r.tmp.Close()
+		os.Remove(r.tmp.Name())
+	}()
+
+	var pres pmp4.Presentation
+
+	for _, track := range r.Tracks {
+		samples := track.popSamples()
+		if samples == nil {
+			continue
+		}
+
+		codec, err := track.codec()
+		if err != nil {
+			return err
+		}
+
+		// edit list, that takes into account the difference between
+		// the beginning of the track and the beginning of the file.
+		timeOffset := mediatime.DurationToTimestamp(
+			mediatime.TimestampToDuration(track.firstDTS, track.clockRate)-r.fileStart, track.clockRate)
+
+		pres.Tracks = append(pres.Tracks, &pmp4.Track{
+			ID:         len(pres.Tracks) + 1,
+			TimeScale:  uint32(track.clockRate),
+			TimeOffset: int32(timeOffset),
+			Codec:      codec,
+			Samples:    samples,
+		})
+	}
+
+	if pres.Tracks == nil {
+		return nil
+	}
+
+	err := writePresentation(r.path, &pres)
+	if err != nil {
+		return err
+	}
+
+	r.OnFileComplete(r.path)
+
+	return nil
+}
+
+func writePresentation(path string, pres *pmp4.Presentation) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+
+	err = pres.Marshal(bw)
+	if err != nil {
+		return err
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,176 @@
+package mp4
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/pmp4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+	}
+
+	audioTrack := &Track{
+		Format: &format.MPEG4Audio{
+			PayloadTyp: 97,
+			Config: &mpeg4audio.AudioSpecificConfig{
+				Type:         2,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		},
+	}
+
+	var completed []string
+	n := 0
+
+	r := &Recorder{
+		Tracks: []*Track{audioTrack, videoTrack},
+		Path: func(_ time.Time) string {
+			n++
+			return filepath.Join(dir, strconv.FormatInt(int64(n), 10)+".mp4")
+		},
+		MaxDuration: 1 * time.Second,
+		OnFileComplete: func(path string) {
+			completed = append(completed, path)
+		},
+	}
+	err := r.Initialize()
+	require.NoError(t, err)
+
+	// discarded since the leading track has not started yet
+	err = r.WriteMPEG4Audio(audioTrack, 0, [][]byte{{1, 2}})
+	require.NoError(t, err)
+
+	// discarded since it is not a random access unit
+	err = r.WriteH264(videoTrack, 0, [][]byte{{1, 0}})
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		var au [][]byte
+		if (i % 10) == 0 {
+			au = [][]byte{testSPS, testPPS, {5, byte(i)}}
+		} else {
+			au = [][]byte{{1, byte(i)}}
+		}
+
+		pts := int64(90000 + i*9000)
+
+		err = r.WriteH264(videoTrack, pts, au)
+		require.NoError(t, err)
+
+		err = r.WriteMPEG4Audio(audioTrack, 44100+int64(i)*4410+441, [][]byte{{3, byte(i)}})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{filepath.Join(dir, "1.mp4")}, completed)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{filepath.Join(dir, "1.mp4"), filepath.Join(dir, "2.mp4")}, completed)
+
+	for _, path := range completed {
+		byts, err2 := os.ReadFile(path)
+		require.NoError(t, err2)
+
+		// moov is placed before mdat
+		require.Equal(t, "ftyp", string(byts[4:8]))
+		ftypSize := int(byts[0])<<24 | int(byts[1])<<16 | int(byts[2])<<8 | int(byts[3])
+		require.Equal(t, "moov", string(byts[ftypSize+4:ftypSize+8]))
+
+		_, err2 = os.Stat(path + ".tmp")
+		require.True(t, os.IsNotExist(err2))
+
+		f, err2 := os.Open(path)
+		require.NoError(t, err2)
+		defer f.Close()
+
+		var pres pmp4.Presentation
+		err2 = pres.Unmarshal(f)
+		require.NoError(t, err2)
+
+		require.Len(t, pres.Tracks, 2)
+		require.Equal(t, uint32(44100), pres.Tracks[0].TimeScale)
+		require.Equal(t, int32(441), pres.Tracks[0].TimeOffset)
+		require.Len(t, pres.Tracks[0].Samples, 10)
+		require.Equal(t, uint32(90000), pres.Tracks[1].TimeScale)
+		require.Equal(t, int32(0), pres.Tracks[1].TimeOffset)
+		require.Len(t, pres.Tracks[1].Samples, 10)
+		require.False(t, pres.Tracks[1].Samples[0].IsNonSyncSample)
+		require.True(t, pres.Tracks[1].Samples[1].IsNonSyncSample)
+		require.Equal(t, uint32(9000), pres.Tracks[1].Samples[0].Duration)
+
+		pl, err2 := pres.Tracks[1].Samples[1].GetPayload()
+		require.NoError(t, err2)
+		require.Equal(t, []byte{0, 0, 0, 2, 1}, pl[:5])
+	}
+}
+
+func TestRecorderMaxSize(t *testing.T) {
+	dir := t.TempDir()
+
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+			SPS:               testSPS,
+			PPS:               testPPS,
+		},
+	}
+
+	var completed []string
+	n := 0
+
+	r := &Recorder{
+		Tracks: []*Track{videoTrack},
+		Path: func(_ time.Time) string {
+			n++
+			return filepath.Join(dir, strconv.FormatInt(int64(n), 10)+".mp4")
+		},
+		MaxSize: 50,
+		OnFileComplete: func(path string) {
+			completed = append(completed, path)
+		},
+	}
+	err := r.Initialize()
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = r.WriteH264(videoTrack, int64(i*3000), [][]byte{{5, byte(i)}})
+		require.NoError(t, err)
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	// each sample contains SPS, PPS and IDR and has a size of 43 bytes,
+	// therefore files are rotated every 2 samples.
+	require.Len(t, completed, 5)
+}
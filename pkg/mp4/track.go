@@ -0,0 +1,124 @@
+package mp4
+
+import (
+	"fmt"
+
+	mcmp4 "github.com/bluenviron/mediacommon/v2/pkg/formats/mp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/pmp4"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/paramsets"
+)
+
+// Track is a track of a Recorder.
+type Track struct {
+	// format of the track.
+	// Supported formats are H264, H265 and MPEG-4 Audio.
+	Format format.Format
+
+	clockRate    int
+	isVideo      bool
+	h264Params   paramsets.H264
+	h265Params   paramsets.H265
+	h264DTS      mediatime.H264DTSExtractor
+	h265DTS      mediatime.H265DTSExtractor
+	lastDuration uint32
+	firstDTS     int64
+	samples      []*pmp4.Sample
+	pending      *pmp4.Sample
+	pendingDTS   int64
+}
+
+func (t *Track) initialize() error {
+	t.clockRate = t.Format.ClockRate()
+
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		t.isVideo = true
+		t.h264Params.SPS, t.h264Params.PPS = forma.SafeParams()
+
+	case *format.H265:
+		t.isVideo = true
+		t.h265Params.VPS, t.h265Params.SPS, t.h265Params.PPS = forma.SafeParams()
+
+	case *format.MPEG4Audio:
+		if forma.Config == nil {
+			return fmt.Errorf("MPEG-4 Audio config is missing")
+		}
+
+	default:
+		return fmt.Errorf("unsupported format: %T", t.Format)
+	}
+
+	return nil
+}
+
+func (t *Track) codec() (mcmp4.Codec, error) {
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		if t.h264Params.SPS == nil || t.h264Params.PPS == nil {
+			return nil, fmt.Errorf("H264 parameters are missing")
+		}
+		return &mcmp4.CodecH264{SPS: t.h264Params.SPS, PPS: t.h264Params.PPS}, nil
+
+	case *format.H265:
+		if t.h265Params.VPS == nil || t.h265Params.SPS == nil || t.h265Params.PPS == nil {
+			return nil, fmt.Errorf("H265 parameters are missing")
+		}
+		return &mcmp4.CodecH265{VPS: t.h265Params.VPS, SPS: t.h265Params.SPS, PPS: t.h265Params.PPS}, nil
+
+	default: // *format.MPEG4Audio
+		return &mcmp4.CodecMPEG4Audio{Config: *forma.(*format.MPEG4Audio).Config}, nil
+	}
+}
+
+// prepareH264 removes AUDs, stores parameters and
+// adds them before random access units.
+func (t *Track) prepareH264(au [][]byte) [][]byte {
+	return t.h264Params.Normalize(au)
+}
+
+// prepareH265 removes AUDs, stores parameters and
+// adds them before random access units.
+func (t *Track) prepareH265(au [][]byte) [][]byte {
+	return t.h265Params.Normalize(au)
+}
+
+// complete completes the pending sample, if any,
+// by using the DTS of the next one.
+func (t *Track) complete(nextDTS int64) {
+	if t.pending != nil {
+		t.pending.Duration = uint32(nextDTS - t.pendingDTS)
+		t.lastDuration = t.pending.Duration
+		t.samples = append(t.samples, t.pending)
+		t.pending = nil
+	}
+}
+
+// push adds a sample whose duration is not known yet,
+// completing the previous one.
+func (t *Track) push(dts int64, sample *pmp4.Sample) {
+	t.complete(dts)
+
+	if t.samples == nil {
+		t.firstDTS = dts
+	}
+
+	t.pending = sample
+	t.pendingDTS = dts
+}
+
+// popSamples returns all the samples of the current file.
+// The duration of the pending sample is assumed to be equal to the previous one.
+func (t *Track) popSamples() []*pmp4.Sample {
+	if t.pending != nil {
+		t.pending.Duration = t.lastDuration
+		t.samples = append(t.samples, t.pending)
+		t.pending = nil
+	}
+
+	samples := t.samples
+	t.samples = nil
+	return samples
+}
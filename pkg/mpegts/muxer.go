@@ -0,0 +1,349 @@
+// Package mpegts contains a MPEG-TS muxer.
+package mpegts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/asticode/go-astits"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+const (
+	mpegtsClockRate = 90000
+	streamIDVideo   = 224
+	streamIDAudio   = 192
+	firstPID        = 256
+	opusIdentifier  = 'O'<<24 | 'p'<<16 | 'u'<<8 | 's'
+
+	// PCR is placed slightly before DTS in order to give decoders
+	// the time to receive the whole access unit.
+	dtsPCRDiff = mpegtsClockRate / 10
+)
+
+// Muxer is a MPEG-TS muxer.
+// It takes access units of one or more tracks and writes
+// a MPEG-TS stream into an io.Writer.
+//
+// Timestamps are expressed in clock rate units of each track and
+// must share the same origin (like the ones returned by Client.PacketPTS()).
+type Muxer struct {
+	// destination of the stream.
+	W io.Writer
+
+	// tracks.
+	Tracks []*Track
+
+	// track that carries the PCR.
+	// It defaults to the first video track, or the first track if there are no video tracks.
+	PCRTrack *Track
+
+	// maximum interval between two PCRs.
+	// A PCR is also written with every random access unit of PCRTrack.
+	// It defaults to 100 milliseconds.
+	PCRInterval time.Duration
+
+	mux         *astits.Muxer
+	pcrInterval int64
+	pcrWritten  bool
+	lastPCRDTS  int64
+}
+
+// Initialize initializes Muxer.
+func (m *Muxer) Initialize() error {
+	if m.W == nil {
+		return fmt.Errorf("W not provided")
+	}
+
+	if len(m.Tracks) == 0 {
+		return fmt.Errorf("no tracks provided")
+	}
+
+	if m.PCRInterval == 0 {
+		m.PCRInterval = 100 * time.Millisecond
+	}
+
+	usedPIDs := make(map[uint16]struct{})
+
+	for _, track := range m.Tracks {
+		err := track.initialize()
+		if err != nil {
+			return err
+		}
+
+		if track.PID != 0 {
+			if _, ok := usedPIDs[track.PID]; ok {
+				return fmt.Errorf("PID %d is used by multiple tracks", track.PID)
+			}
+			usedPIDs[track.PID] = struct{}{}
+		}
+	}
+
+	nextPID := uint16(firstPID)
+
+	for _, track := range m.Tracks {
+		if track.PID == 0 {
+			for {
+				if _, ok := usedPIDs[nextPID]; !ok {
+					break
+				}
+				nextPID++
+			}
+			track.PID = nextPID
+			usedPIDs[nextPID] = struct{}{}
+		}
+
+		if m.PCRTrack == nil && track.isVideo {
+			m.PCRTrack = track
+		}
+	}
+
+	if m.PCRTrack == nil {
+		m.PCRTrack = m.Tracks[0]
+	} else if !m.hasTrack(m.PCRTrack) {
+		return fmt.Errorf("PCRTrack is not part of Tracks")
+	}
+
+	m.pcrInterval = int64(m.PCRInterval) * mpegtsClockRate / int64(time.Second)
+
+	m.mux = astits.NewMuxer(context.Background(), m.W)
+
+	for _, track := range m.Tracks {
+		err := m.mux.AddElementaryStream(track.elementaryStream())
+		if err != nil {
+			return err
+		}
+	}
+
+	m.mux.SetPCRPID(m.PCRTrack.PID)
+
+	return nil
+}
+
+func (m *Muxer) hasTrack(track *Track) bool {
+	for _, t := range m.Tracks {
+		if t == track {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTables writes PAT and PMT.
+// Tables are written periodically in any case; this can be used to make the stream
+// decodable starting from the current position, for instance at the beginning of HLS segments.
+func (m *Muxer) WriteTables() error {
+	_, err := m.mux.WriteTables()
+	return err
+}
+
+// WriteH264 writes a H264 access unit.
+// SPS and PPS are automatically added before IDR frames.
+func (m *Muxer) WriteH264(track *Track, pts int64, dts int64, au [][]byte) error {
+	au = track.prepareH264(au)
+	if len(au) == 0 {
+		return nil
+	}
+
+	randomAccess := h264.IsRandomAccess(au)
+
+	// prepend an AUD. This is required by video.js, iOS, QuickTime
+	au = append([][]byte{
+		{byte(h264.NALUTypeAccessUnitDelimiter), 240},
+	}, au...)
+
+	enc, err := h264.AnnexB(au).Marshal()
+	if err != nil {
+		return err
+	}
+
+	return m.writeVideo(track, pts, dts, randomAccess, enc)
+}
+
+// WriteH265 writes a H265 access unit.
+// VPS, SPS and PPS are automatically added before random access units.
+func (m *Muxer) WriteH265(track *Track, pts int64, dts int64, au [][]byte) error {
+	au = track.prepareH265(au)
+	if len(au) == 0 {
+		return nil
+	}
+
+	randomAccess := h265.IsRandomAccess(au)
+
+	// prepend an AUD. This is required by video.js, iOS, QuickTime
+	au = append([][]byte{
+		{byte(h265.NALUType_AUD_NUT) << 1, 1, 0x50},
+	}, au...)
+
+	enc, err := h264.AnnexB(au).Marshal()
+	if err != nil {
+		return err
+	}
+
+	return m.writeVideo(track, pts, dts, randomAccess, enc)
+}
+
+// WriteMPEG4Audio writes MPEG-4 Audio access units.
+func (m *Muxer) WriteMPEG4Audio(track *Track, pts int64, aus [][]byte) error {
+	conf := track.Format.(*format.MPEG4Audio).Config
+
+	pkts := make(mpeg4audio.ADTSPackets, len(aus))
+
+	for i, au := range aus {
+		pkts[i] = &mpeg4audio.ADTSPacket{
+			Type:         conf.Type,
+			SampleRate:   conf.SampleRate,
+			ChannelCount: conf.ChannelCount,
+			AU:           au,
+		}
+	}
+
+	enc, err := pkts.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return m.writeAudio(track, pts, enc)
+}
+
+// WriteOpus writes Opus packets.
+func (m *Muxer) WriteOpus(track *Track, pts int64, packets [][]byte) error {
+	n := 0
+	for _, packet := range packets {
+		n += opusControlHeaderSize(len(packet)) + len(packet)
+	}
+
+	enc := make([]byte, n)
+	n = 0
+
+	for _, packet := range packets {
+		n += marshalOpusControlHeader(len(packet), enc[n:])
+		n += copy(enc[n:], packet)
+	}
+
+	return m.writeAudio(track, pts, enc)
+}
+
+func (m *Muxer) writeAudio(track *Track, pts int64, data []byte) error {
+	pts = mediatime.MultiplyAndDivide(pts, mpegtsClockRate, int64(track.clockRate))
+
+	af := &astits.PacketAdaptationField{
+		RandomAccessIndicator: true,
+	}
+	m.fillPCR(track, pts, false, af)
+
+	_, err := m.mux.WriteData(&astits.MuxerData{
+		PID:             track.PID,
+		AdaptationField: af,
+		PES: &astits.PESData{
+			Header: &astits.PESHeader{
+				OptionalHeader: &astits.PESOptionalHeader{
+					MarkerBits:      2,
+					PTSDTSIndicator: astits.PTSDTSIndicatorOnlyPTS,
+					PTS:             &astits.ClockReference{Base: pts},
+				},
+				StreamID: streamIDAudio,
+			},
+			Data: data,
+		},
+	})
+	return err
+}
+
+func (m *Muxer) writeVideo(track *Track, pts int64, dts int64, randomAccess bool, data []byte) error {
+	pts = mediatime.MultiplyAndDivide(pts, mpegtsClockRate, int64(track.clockRate))
+	dts = mediatime.MultiplyAndDivide(dts, mpegtsClockRate, int64(track.clockRate))
+
+	var af *astits.PacketAdaptationField
+
+	if randomAccess {
+		af = &astits.PacketAdaptationField{
+			RandomAccessIndicator: true,
+		}
+	}
+
+	if af == nil && track == m.PCRTrack && m.needsPCR(dts, randomAccess) {
+		af = &astits.PacketAdaptationField{}
+	}
+
+	if af != nil {
+		m.fillPCR(track, dts, randomAccess, af)
+	}
+
+	oh := &astits.PESOptionalHeader{
+		MarkerBits: 2,
+	}
+
+	if dts == pts {
+		oh.PTSDTSIndicator = astits.PTSDTSIndicatorOnlyPTS
+		oh.PTS = &astits.ClockReference{Base: pts}
+	} else {
+		oh.PTSDTSIndicator = astits.PTSDTSIndicatorBothPresent
+		oh.DTS = &astits.ClockReference{Base: dts}
+		oh.PTS = &astits.ClockReference{Base: pts}
+	}
+
+	_, err := m.mux.WriteData(&astits.MuxerData{
+		PID:             track.PID,
+		AdaptationField: af,
+		PES: &astits.PESData{
+			Header: &astits.PESHeader{
+				OptionalHeader: oh,
+				StreamID:       streamIDVideo,
+			},
+			Data: data,
+		},
+	})
+	return err
+}
+
+func (m *Muxer) needsPCR(dts int64, randomAccess bool) bool {
+	return randomAccess || !m.pcrWritten || (dts-m.lastPCRDTS) >= m.pcrInterval
+}
+
+// fillPCR adds a PCR to the adaptation field when needed.
+func (m *Muxer) fillPCR(track *Track, dts int64, randomAccess bool, af *astits.PacketAdaptationField) {
+	if track != m.PCRTrack || !m.needsPCR(dts, randomAccess) {
+		return
+	}
+
+	// PCR can't be negative.
+	pcr := dts - dtsPCRDiff
+	if pcr < 0 {
+		pcr = 0
+	}
+
+	af.HasPCR = true
+	af.PCR = &astits.ClockReference{Base: pcr}
+	m.pcrWritten = true
+	m.lastPCRDTS = dts
+}
+
+func opusControlHeaderSize(payloadSize int) int {
+	return 2 + payloadSize/255 + 1
+}
+
+// marshalOpusControlHeader writes a opus_control_header without trims and extensions.
+// Specification: ETSI TS Opus 0.1.3-draft
+func marshalOpusControlHeader(payloadSize int, buf []byte) int {
+	buf[0] = 0b01111111
+	buf[1] = 0b111 << 5
+	n := 2
+
+	for i := 0; i < payloadSize/255; i++ {
+		buf[n] = 255
+		n++
+	}
+
+	buf[n] = byte(payloadSize % 255)
+	n++
+
+	return n
+}
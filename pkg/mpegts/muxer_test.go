@@ -0,0 +1,262 @@
+package mpegts
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astits"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	mcmpegts "github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+func readPCRs(t *testing.T, buf []byte, pid uint16) []int64 {
+	dem := astits.NewDemuxer(t.Context(), bytes.NewReader(buf))
+	var pcrs []int64
+
+	for {
+		pkt, err := dem.NextPacket()
+		if err != nil {
+			break
+		}
+
+		if pkt.Header.PID == pid && pkt.AdaptationField != nil && pkt.AdaptationField.HasPCR {
+			pcrs = append(pcrs, pkt.AdaptationField.PCR.Base)
+		}
+	}
+
+	return pcrs
+}
+
+func TestMuxer(t *testing.T) {
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+	}
+
+	audioTrack := &Track{
+		Format: &format.MPEG4Audio{
+			PayloadTyp: 97,
+			Config: &mpeg4audio.AudioSpecificConfig{
+				Type:         2,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		},
+		PID: 300,
+	}
+
+	var buf bytes.Buffer
+
+	m := &Muxer{
+		W:           &buf,
+		Tracks:      []*Track{audioTrack, videoTrack},
+		PCRInterval: 200 * time.Millisecond,
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	require.Equal(t, videoTrack, m.PCRTrack)
+	require.Equal(t, uint16(256), videoTrack.PID)
+
+	// IDR with parameters, that are stored
+	err = m.WriteH264(videoTrack, 90000, 90000, [][]byte{testSPS, testPPS, {5, 1}})
+	require.NoError(t, err)
+
+	for i := 1; i < 10; i++ {
+		err = m.WriteH264(videoTrack, int64(90000+i*9000), int64(90000+i*9000), [][]byte{{1, byte(i)}})
+		require.NoError(t, err)
+	}
+
+	// IDR without parameters, that are added automatically
+	err = m.WriteH264(videoTrack, 180000, 180000, [][]byte{{5, 2}})
+	require.NoError(t, err)
+
+	err = m.WriteMPEG4Audio(audioTrack, 44100, [][]byte{{1, 2}, {3, 4}})
+	require.NoError(t, err)
+
+	// PCRs: 2 IDRs + every 200ms of non-IDR frames
+	require.Len(t, readPCRs(t, buf.Bytes(), videoTrack.PID), 6)
+
+	r := &mcmpegts.Reader{R: bytes.NewReader(buf.Bytes())}
+	err = r.Initialize()
+	require.NoError(t, err)
+
+	tracks := r.Tracks()
+	require.Len(t, tracks, 2)
+	require.Equal(t, uint16(300), tracks[0].PID)
+	require.Equal(t, uint16(256), tracks[1].PID)
+
+	var videoAUs [][][]byte
+	var videoPTSs []int64
+
+	r.OnDataH264(tracks[1], func(pts int64, _ int64, au [][]byte) error {
+		videoAUs = append(videoAUs, au)
+		videoPTSs = append(videoPTSs, pts)
+		return nil
+	})
+
+	var audioAUs [][]byte
+	var audioPTS int64
+
+	r.OnDataMPEG4Audio(tracks[0], func(pts int64, aus [][]byte) error {
+		audioAUs = aus
+		audioPTS = pts
+		return nil
+	})
+
+	for {
+		err = r.Read()
+		if err != nil {
+			break
+		}
+	}
+
+	require.Len(t, videoAUs, 11)
+	require.Equal(t, [][]byte{testSPS, testPPS, {5, 1}}, videoAUs[0])
+	require.Equal(t, [][]byte{{1, 1}}, videoAUs[1])
+	require.Equal(t, [][]byte{testSPS, testPPS, {5, 2}}, videoAUs[10])
+	require.Equal(t, int64(90000), videoPTSs[0])
+	require.Equal(t, int64(180000), videoPTSs[10])
+
+	require.Equal(t, [][]byte{{1, 2}, {3, 4}}, audioAUs)
+	require.Equal(t, int64(90000), audioPTS)
+}
+
+func TestMuxerOpus(t *testing.T) {
+	track := &Track{
+		Format: &format.Opus{
+			PayloadTyp:   96,
+			ChannelCount: 2,
+		},
+	}
+
+	var buf bytes.Buffer
+
+	m := &Muxer{
+		W:      &buf,
+		Tracks: []*Track{track},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	err = m.WriteOpus(track, 48000, [][]byte{{1, 2, 3}, bytes.Repeat([]byte{4}, 300)})
+	require.NoError(t, err)
+
+	r := &mcmpegts.Reader{R: bytes.NewReader(buf.Bytes())}
+	err = r.Initialize()
+	require.NoError(t, err)
+
+	tracks := r.Tracks()
+	require.Len(t, tracks, 1)
+	require.Equal(t, &mcmpegts.CodecOpus{ChannelCount: 2}, tracks[0].Codec)
+
+	var packets [][]byte
+	var pts int64
+
+	r.OnDataOpus(tracks[0], func(pts2 int64, packets2 [][]byte) error {
+		packets = packets2
+		pts = pts2
+		return nil
+	})
+
+	for {
+		err = r.Read()
+		if err != nil {
+			break
+		}
+	}
+
+	require.Equal(t, [][]byte{{1, 2, 3}, bytes.Repeat([]byte{4}, 300)}, packets)
+	require.Equal(t, int64(90000), pts)
+}
+
+func TestMuxerPCRStart(t *testing.T) {
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+	}
+
+	var buf bytes.Buffer
+
+	m := &Muxer{
+		W:      &buf,
+		Tracks: []*Track{videoTrack},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	err = m.WriteH264(videoTrack, 0, 0, [][]byte{testSPS, testPPS, {5, 1}})
+	require.NoError(t, err)
+
+	err = m.WriteH264(videoTrack, 90000, 90000, [][]byte{{5, 2}})
+	require.NoError(t, err)
+
+	require.Equal(t, []int64{0, 81000}, readPCRs(t, buf.Bytes(), videoTrack.PID))
+}
+
+func TestMuxerErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		m    *Muxer
+		err  string
+	}{
+		{
+			"no tracks",
+			&Muxer{W: &bytes.Buffer{}},
+			"no tracks provided",
+		},
+		{
+			"unsupported format",
+			&Muxer{
+				W:      &bytes.Buffer{},
+				Tracks: []*Track{{Format: &format.G711{}}},
+			},
+			"unsupported format: *format.G711",
+		},
+		{
+			"duplicate PID",
+			&Muxer{
+				W: &bytes.Buffer{},
+				Tracks: []*Track{
+					{Format: &format.H264{PayloadTyp: 96}, PID: 300},
+					{Format: &format.H264{PayloadTyp: 97}, PID: 300},
+				},
+			},
+			"PID 300 is used by multiple tracks",
+		},
+		{
+			"PCR track not in tracks",
+			&Muxer{
+				W:        &bytes.Buffer{},
+				Tracks:   []*Track{{Format: &format.H264{PayloadTyp: 96}}},
+				PCRTrack: &Track{Format: &format.H264{PayloadTyp: 96}},
+			},
+			"PCRTrack is not part of Tracks",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := ca.m.Initialize()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
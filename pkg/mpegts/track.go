@@ -0,0 +1,113 @@
+package mpegts
+
+import (
+	"fmt"
+
+	"github.com/asticode/go-astits"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/paramsets"
+)
+
+// Track is a track of a Muxer.
+type Track struct {
+	// format of the track.
+	// Supported formats are H264, H265, MPEG-4 Audio and Opus.
+	Format format.Format
+
+	// PID of the track.
+	// It defaults to an automatically assigned PID.
+	PID uint16
+
+	clockRate            int
+	isVideo              bool
+	h264Params           paramsets.H264
+	h265Params           paramsets.H265
+	randomAccessReceived bool
+}
+
+func (t *Track) initialize() error {
+	t.clockRate = t.Format.ClockRate()
+
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		t.isVideo = true
+		t.h264Params.SPS, t.h264Params.PPS = forma.SafeParams()
+
+	case *format.H265:
+		t.isVideo = true
+		t.h265Params.VPS, t.h265Params.SPS, t.h265Params.PPS = forma.SafeParams()
+
+	case *format.MPEG4Audio:
+		if forma.Config == nil {
+			return fmt.Errorf("MPEG-4 Audio config is missing")
+		}
+
+	case *format.Opus:
+
+	default:
+		return fmt.Errorf("unsupported format: %T", t.Format)
+	}
+
+	return nil
+}
+
+func (t *Track) elementaryStream() astits.PMTElementaryStream {
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		return astits.PMTElementaryStream{
+			ElementaryPID: t.PID,
+			StreamType:    astits.StreamTypeH264Video,
+		}
+
+	case *format.H265:
+		return astits.PMTElementaryStream{
+			ElementaryPID: t.PID,
+			StreamType:    astits.StreamTypeH265Video,
+		}
+
+	case *format.MPEG4Audio:
+		return astits.PMTElementaryStream{
+			ElementaryPID: t.PID,
+			StreamType:    astits.StreamTypeAACAudio,
+		}
+
+	default: // *format.Opus
+		// Specification: ETSI TS Opus 0.1.3-draft
+		return astits.PMTElementaryStream{
+			ElementaryPID: t.PID,
+			StreamType:    astits.StreamTypePrivateData,
+			ElementaryStreamDescriptors: []*astits.Descriptor{
+				{
+					// length must be different than zero.
+					Length: 1,
+					Tag:    astits.DescriptorTagRegistration,
+					Registration: &astits.DescriptorRegistration{
+						FormatIdentifier: opusIdentifier,
+					},
+				},
+				{
+					// length must be different than zero.
+					Length: 1,
+					Tag:    astits.DescriptorTagExtension,
+					Extension: &astits.DescriptorExtension{
+						Tag:     0x80,
+						Unknown: &[]uint8{uint8(forma.(*format.Opus).ChannelCount)},
+					},
+				},
+			},
+		}
+	}
+}
+
+// prepareH264 removes AUDs, stores parameters and
+// adds them before random access units.
+func (t *Track) prepareH264(au [][]byte) [][]byte {
+	return t.h264Params.Normalize(au)
+}
+
+// prepareH265 removes AUDs, stores parameters and
+// adds them before random access units.
+func (t *Track) prepareH265(au [][]byte) [][]byte {
+	return t.h265Params.Normalize(au)
+}
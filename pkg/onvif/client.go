@@ -0,0 +1,281 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+const (
+	soapMaxResponseSize = 1024 * 1024
+)
+
+func xmlEscape(s string) string {
+	var buf strings.Builder
+	xml.EscapeText(&buf, []byte(s)) //nolint:errcheck
+	return buf.String()
+}
+
+type soapFault struct {
+	Reason string `xml:"Reason>Text"`
+}
+
+type soapEnvelope struct {
+	Body struct {
+		Fault   *soapFault `xml:"Fault"`
+		Content []byte     `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+type getCapabilitiesResponse struct {
+	MediaXAddr string `xml:"Capabilities>Media>XAddr"`
+}
+
+type getProfilesResponse struct {
+	Profiles []struct {
+		Token    string `xml:"token,attr"`
+		Name     string `xml:"Name"`
+		Encoding string `xml:"VideoEncoderConfiguration>Encoding"`
+		Width    int    `xml:"VideoEncoderConfiguration>Resolution>Width"`
+		Height   int    `xml:"VideoEncoderConfiguration>Resolution>Height"`
+	} `xml:"Profiles"`
+}
+
+type getStreamURIResponse struct {
+	URI string `xml:"MediaUri>Uri"`
+}
+
+// Profile is a media profile of a device.
+type Profile struct {
+	// token of the profile, to be passed to GetStreamURI().
+	Token string
+
+	// name of the profile.
+	Name string
+
+	// video encoding (JPEG, MPEG4, H264, H265).
+	VideoEncoding string
+
+	// video resolution.
+	Width  int
+	Height int
+}
+
+// Client is a client of the ONVIF device and media services.
+// Requests are authenticated with a WS-Security UsernameToken.
+// Specification: ONVIF Media Service Specification
+type Client struct {
+	// URL of the device service,
+	// for instance http://192.168.1.10/onvif/device_service.
+	URL string
+
+	// credentials.
+	Username string
+	Password string
+
+	// HTTP client.
+	// It defaults to a client with a timeout of 10 seconds.
+	HTTPClient *http.Client
+
+	mediaURL string
+}
+
+// Initialize initializes Client.
+func (c *Client) Initialize() error {
+	if c.URL == "" {
+		return fmt.Errorf("URL not provided")
+	}
+
+	_, err := url.Parse(c.URL)
+	if err != nil {
+		return err
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	return nil
+}
+
+// GetProfiles returns the media profiles of the device.
+func (c *Client) GetProfiles(ctx context.Context) ([]*Profile, error) {
+	mediaURL, err := c.getMediaURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res getProfilesResponse
+	err = c.do(ctx, mediaURL,
+		`<GetProfiles xmlns="http://www.onvif.org/ver10/media/wsdl"/>`, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]*Profile, len(res.Profiles))
+
+	for i, p := range res.Profiles {
+		profiles[i] = &Profile{
+			Token:         p.Token,
+			Name:          p.Name,
+			VideoEncoding: p.Encoding,
+			Width:         p.Width,
+			Height:        p.Height,
+		}
+	}
+
+	return profiles, nil
+}
+
+// GetStreamURI returns the RTSP URL of a profile.
+// Credentials of the Client are added to the URL,
+// therefore it can be used directly with gortsplib.Client.
+func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (*base.URL, error) {
+	mediaURL, err := c.getMediaURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res getStreamURIResponse
+	err = c.do(ctx, mediaURL,
+		`<GetStreamUri xmlns="http://www.onvif.org/ver10/media/wsdl">`+
+			`<StreamSetup>`+
+			`<Stream xmlns="http://www.onvif.org/ver10/schema">RTP-Unicast</Stream>`+
+			`<Transport xmlns="http://www.onvif.org/ver10/schema"><Protocol>RTSP</Protocol></Transport>`+
+			`</StreamSetup>`+
+			`<ProfileToken>`+xmlEscape(profileToken)+`</ProfileToken>`+
+			`</GetStreamUri>`, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := base.ParseURL(strings.TrimSpace(res.URI))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Username != "" && u.User == nil {
+		u.User = url.UserPassword(c.Username, c.Password)
+	}
+
+	return u, nil
+}
+
+func (c *Client) getMediaURL(ctx context.Context) (string, error) {
+	if c.mediaURL != "" {
+		return c.mediaURL, nil
+	}
+
+	var res getCapabilitiesResponse
+	err := c.do(ctx, c.URL,
+		`<GetCapabilities xmlns="http://www.onvif.org/ver10/device/wsdl">`+
+			`<Category>Media</Category>`+
+			`</GetCapabilities>`, &res)
+	if err != nil {
+		return "", err
+	}
+
+	if res.MediaXAddr == "" {
+		return "", fmt.Errorf("media service not available")
+	}
+
+	c.mediaURL = strings.TrimSpace(res.MediaXAddr)
+	return c.mediaURL, nil
+}
+
+func (c *Client) securityHeader() (string, error) {
+	if c.Username == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	created := time.Now().UTC().Format(time.RFC3339)
+
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(created))
+	h.Write([]byte(c.Password))
+	digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	return `<s:Header>` +
+		`<Security s:mustUnderstand="1"` +
+		` xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd">` +
+		`<UsernameToken>` +
+		`<Username>` + xmlEscape(c.Username) + `</Username>` +
+		`<Password Type="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0` +
+		`#PasswordDigest">` + digest + `</Password>` +
+		`<Nonce EncodingType="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0` +
+		`#Base64Binary">` + base64.StdEncoding.EncodeToString(nonce) + `</Nonce>` +
+		`<Created xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">` +
+		created + `</Created>` +
+		`</UsernameToken>` +
+		`</Security>` +
+		`</s:Header>`, nil
+}
+
+func (c *Client) do(ctx context.Context, u string, body string, res interface{}) error {
+	header, err := c.securityHeader()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader([]byte(
+		`<?xml version="1.0" encoding="UTF-8"?>`+
+			`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">`+
+			header+
+			`<s:Body>`+body+`</s:Body>`+
+			`</s:Envelope>`)))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+
+	httpRes, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	buf, err := io.ReadAll(io.LimitReader(httpRes.Body, soapMaxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	var env soapEnvelope
+	err = xml.Unmarshal(buf, &env)
+	if err != nil {
+		if httpRes.StatusCode != http.StatusOK {
+			return fmt.Errorf("bad status code: %d", httpRes.StatusCode)
+		}
+		return err
+	}
+
+	if env.Body.Fault != nil {
+		return fmt.Errorf("SOAP fault: %s", strings.TrimSpace(env.Body.Fault.Reason))
+	}
+
+	if httpRes.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad status code: %d", httpRes.StatusCode)
+	}
+
+	return xml.Unmarshal(env.Body.Content, res)
+}
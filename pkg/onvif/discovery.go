@@ -0,0 +1,161 @@
+// Package onvif contains utilities to discover ONVIF cameras, retrieve their stream URLs
+// and replay their recordings.
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	discoveryAddress = "239.255.255.250:3702"
+	discoveryMaxSize = 65535
+)
+
+const probeTemplate = `<?xml version="1.0" encoding="UTF-8"?>` +
+	`<e:Envelope xmlns:e="http://www.w3.org/2003/05/soap-envelope"` +
+	` xmlns:w="http://schemas.xmlsoap.org/ws/2004/08/addressing"` +
+	` xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery"` +
+	` xmlns:dn="http://www.onvif.org/ver10/network/wsdl">` +
+	`<e:Header>` +
+	`<w:MessageID>%s</w:MessageID>` +
+	`<w:To e:mustUnderstand="true">urn:schemas-xmlsoap-org:ws:2005:04:discovery</w:To>` +
+	`<w:Action e:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2005/04/discovery/Probe</w:Action>` +
+	`</e:Header>` +
+	`<e:Body><d:Probe><d:Types>dn:NetworkVideoTransmitter</d:Types></d:Probe></e:Body>` +
+	`</e:Envelope>`
+
+type probeMatches struct {
+	RelatesTo string `xml:"Header>RelatesTo"`
+	Matches   []struct {
+		Address string `xml:"EndpointReference>Address"`
+		Types   string `xml:"Types"`
+		Scopes  string `xml:"Scopes"`
+		XAddrs  string `xml:"XAddrs"`
+	} `xml:"Body>ProbeMatches>ProbeMatch"`
+}
+
+// Device is a device found by Discoverer.
+type Device struct {
+	// endpoint reference, that uniquely identifies the device.
+	EndpointReference string
+
+	// types implemented by the device.
+	Types []string
+
+	// scopes of the device, containing name, location and hardware.
+	Scopes []string
+
+	// URLs of the device service.
+	// They can be used as Client.URL.
+	XAddrs []string
+}
+
+// Discoverer finds ONVIF cameras in the local network with WS-Discovery.
+// Specification: ONVIF Core Specification, section 7.3
+type Discoverer struct {
+	// time spent waiting for responses.
+	// It defaults to 3 seconds.
+	Timeout time.Duration
+
+	// address where probes are sent.
+	// It defaults to the WS-Discovery multicast address.
+	Address string
+}
+
+// Discover sends a probe and returns devices that replied.
+func (d *Discoverer) Discover(ctx context.Context) ([]*Device, error) {
+	timeout := d.Timeout
+	if timeout == 0 {
+		timeout = 3 * time.Second
+	}
+
+	address := d.Address
+	if address == "" {
+		address = discoveryAddress
+	}
+
+	addr, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer pc.Close()
+
+	messageID := "uuid:" + uuid.New().String()
+
+	_, err = pc.WriteTo([]byte(fmt.Sprintf(probeTemplate, messageID)), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	err = pc.SetReadDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	// unblock ReadFrom when the context is canceled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pc.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	var devices []*Device
+	found := make(map[string]struct{})
+	buf := make([]byte, discoveryMaxSize)
+
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return devices, nil
+			}
+			return nil, err
+		}
+
+		var res probeMatches
+		err = xml.Unmarshal(buf[:n], &res)
+		if err != nil || res.RelatesTo != messageID {
+			continue
+		}
+
+		for _, m := range res.Matches {
+			if _, ok := found[m.Address]; ok {
+				continue
+			}
+			found[m.Address] = struct{}{}
+
+			devices = append(devices, &Device{
+				EndpointReference: m.Address,
+				Types:             strings.Fields(m.Types),
+				Scopes:            strings.Fields(m.Scopes),
+				XAddrs:            strings.Fields(m.XAddrs),
+			})
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package onvif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/ntp"
+)
+
+const (
+	// ReplayExtensionProfile is the profile of the RTP header extension used in ONVIF replay.
+	ReplayExtensionProfile = 0xABAC
+
+	// ReplayRequire is the value of the Require header that enables ONVIF replay.
+	ReplayRequire = "onvif-replay"
+
+	replayExtensionSize = 12
+)
+
+// ReplayExtension is the RTP header extension attached to packets during ONVIF replay.
+// It contains the absolute time of the frame the packet belongs to.
+// Specification: ONVIF Streaming Specification
+type ReplayExtension struct {
+	// absolute time of the frame.
+	NTP time.Time
+
+	// whether the frame can be decoded without previous frames.
+	CleanPoint bool
+
+	// whether the frame is the last one of a contiguous section of the recording.
+	End bool
+
+	// whether there's a discontinuity between this frame and the previous one.
+	Discontinuity bool
+
+	// whether the packet is the last one of the stream.
+	Terminal bool
+
+	// lowest byte of the CSeq of the request that started the stream.
+	CSeq uint8
+}
+
+// Unmarshal decodes the extension from a RTP header.
+func (e *ReplayExtension) Unmarshal(h *rtp.Header) error {
+	if !h.Extension || h.ExtensionProfile != ReplayExtensionProfile {
+		return fmt.Errorf("replay extension not found")
+	}
+
+	buf := h.GetExtension(0)
+	if len(buf) < replayExtensionSize {
+		return fmt.Errorf("invalid replay extension size (%d)", len(buf))
+	}
+
+	e.NTP = ntp.Decode(binary.BigEndian.Uint64(buf))
+	e.CleanPoint = (buf[8] & 0x80) != 0
+	e.End = (buf[8] & 0x40) != 0
+	e.Discontinuity = (buf[8] & 0x20) != 0
+	e.Terminal = (buf[8] & 0x10) != 0
+	e.CSeq = buf[9]
+
+	return nil
+}
+
+// Marshal encodes the extension into a RTP header.
+// Other extensions of the header are removed.
+func (e ReplayExtension) Marshal(h *rtp.Header) {
+	buf := make([]byte, replayExtensionSize)
+	binary.BigEndian.PutUint64(buf, ntp.Encode(e.NTP))
+
+	if e.CleanPoint {
+		buf[8] |= 0x80
+	}
+	if e.End {
+		buf[8] |= 0x40
+	}
+	if e.Discontinuity {
+		buf[8] |= 0x20
+	}
+	if e.Terminal {
+		buf[8] |= 0x10
+	}
+	buf[9] = e.CSeq
+
+	h.Extension = true
+	h.ExtensionProfile = ReplayExtensionProfile
+	h.Extensions = nil
+	h.SetExtension(0, buf) //nolint:errcheck
+}
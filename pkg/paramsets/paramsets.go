@@ -0,0 +1,140 @@
+// Package paramsets contains utilities to normalize in-band parameter sets of H264 and H265 streams.
+package paramsets
+
+import (
+	"bytes"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+)
+
+// Policy is the policy used to handle parameter sets.
+type Policy int
+
+// policies.
+const (
+	// parameter sets are removed from access units and
+	// inserted before every random access unit.
+	// This is needed by formats that require in-band parameters, like MPEG-TS.
+	PolicyInject Policy = iota
+
+	// parameter sets are removed from access units.
+	// This is needed by formats that deliver parameters out-of-band, like MP4.
+	PolicyRemove
+
+	// parameter sets are kept in access units when they differ from the last known ones,
+	// and are removed when they are duplicates.
+	PolicyDeduplicate
+)
+
+// handle processes a parameter set and returns whether it must be kept in the access unit.
+func handle(policy Policy, cur *[]byte, nalu []byte) bool {
+	if policy == PolicyDeduplicate && bytes.Equal(*cur, nalu) {
+		return false
+	}
+
+	*cur = nalu
+	return policy == PolicyDeduplicate
+}
+
+// H264 normalizes parameter sets of H264 access units.
+type H264 struct {
+	// policy.
+	// It defaults to PolicyInject.
+	Policy Policy
+
+	// SPS and PPS.
+	// They can be filled with out-of-band parameters,
+	// and are updated with in-band ones.
+	SPS []byte
+	PPS []byte
+}
+
+// Normalize normalizes parameter sets of an access unit.
+// Access unit delimiters are removed too.
+func (n *H264) Normalize(au [][]byte) [][]byte {
+	filtered := make([][]byte, 0, len(au)+2)
+	randomAccess := false
+
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			if !handle(n.Policy, &n.SPS, nalu) {
+				continue
+			}
+
+		case h264.NALUTypePPS:
+			if !handle(n.Policy, &n.PPS, nalu) {
+				continue
+			}
+
+		case h264.NALUTypeAccessUnitDelimiter:
+			continue
+
+		case h264.NALUTypeIDR:
+			randomAccess = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if n.Policy == PolicyInject && randomAccess && n.SPS != nil && n.PPS != nil {
+		filtered = append([][]byte{n.SPS, n.PPS}, filtered...)
+	}
+
+	return filtered
+}
+
+// H265 normalizes parameter sets of H265 access units.
+type H265 struct {
+	// policy.
+	// It defaults to PolicyInject.
+	Policy Policy
+
+	// VPS, SPS and PPS.
+	// They can be filled with out-of-band parameters,
+	// and are updated with in-band ones.
+	VPS []byte
+	SPS []byte
+	PPS []byte
+}
+
+// Normalize normalizes parameter sets of an access unit.
+// Access unit delimiters are removed too.
+func (n *H265) Normalize(au [][]byte) [][]byte {
+	filtered := make([][]byte, 0, len(au)+3)
+	randomAccess := false
+
+	for _, nalu := range au {
+		switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+		case h265.NALUType_VPS_NUT:
+			if !handle(n.Policy, &n.VPS, nalu) {
+				continue
+			}
+
+		case h265.NALUType_SPS_NUT:
+			if !handle(n.Policy, &n.SPS, nalu) {
+				continue
+			}
+
+		case h265.NALUType_PPS_NUT:
+			if !handle(n.Policy, &n.PPS, nalu) {
+				continue
+			}
+
+		case h265.NALUType_AUD_NUT:
+			continue
+
+		case h265.NALUType_IDR_W_RADL, h265.NALUType_IDR_N_LP, h265.NALUType_CRA_NUT:
+			randomAccess = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if n.Policy == PolicyInject && randomAccess && n.VPS != nil && n.SPS != nil && n.PPS != nil {
+		filtered = append([][]byte{n.VPS, n.SPS, n.PPS}, filtered...)
+	}
+
+	return filtered
+}
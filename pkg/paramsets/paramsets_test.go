@@ -0,0 +1,96 @@
+package paramsets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestH264(t *testing.T) {
+	sps := []byte{0x67, 0x01}
+	pps := []byte{0x68, 0x02}
+	sps2 := []byte{0x67, 0x03}
+	aud := []byte{0x09, 0xf0}
+	idr := []byte{0x65, 0x04}
+	nonIDR := []byte{0x41, 0x05}
+
+	for _, ca := range []struct {
+		name   string
+		policy Policy
+		out    [][][]byte
+	}{
+		{
+			"inject",
+			PolicyInject,
+			[][][]byte{
+				{sps, pps, idr},
+				{nonIDR},
+				{sps, pps, idr},
+				{sps2, pps, idr},
+			},
+		},
+		{
+			"remove",
+			PolicyRemove,
+			[][][]byte{
+				{idr},
+				{nonIDR},
+				{idr},
+				{idr},
+			},
+		},
+		{
+			"deduplicate",
+			PolicyDeduplicate,
+			[][][]byte{
+				{idr},
+				{nonIDR},
+				{idr},
+				{sps2, idr},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			n := &H264{
+				Policy: ca.policy,
+				SPS:    sps,
+				PPS:    pps,
+			}
+
+			var out [][][]byte
+
+			for _, au := range [][][]byte{
+				{aud, sps, pps, idr},
+				{aud, nonIDR},
+				{idr},
+				{sps2, pps, sps2, idr},
+			} {
+				out = append(out, n.Normalize(au))
+			}
+
+			require.Equal(t, ca.out, out)
+			require.Equal(t, sps2, n.SPS)
+			require.Equal(t, pps, n.PPS)
+		})
+	}
+}
+
+func TestH265(t *testing.T) {
+	vps := []byte{0x40, 0x01, 0x01}
+	sps := []byte{0x42, 0x01, 0x02}
+	pps := []byte{0x44, 0x01, 0x03}
+	aud := []byte{0x46, 0x01, 0x50}
+	idr := []byte{0x26, 0x01, 0x04}
+	nonIDR := []byte{0x02, 0x01, 0x05}
+
+	n := &H265{}
+
+	require.Equal(t, [][]byte{nonIDR}, n.Normalize([][]byte{aud, nonIDR}))
+
+	// parameters are not injected until they are all known
+	require.Equal(t, [][]byte{idr}, n.Normalize([][]byte{idr}))
+
+	require.Equal(t, [][]byte{vps, sps, pps, idr}, n.Normalize([][]byte{vps, sps, pps, idr}))
+	require.Equal(t, [][]byte{vps, sps, pps, idr}, n.Normalize([][]byte{idr}))
+	require.Equal(t, [][]byte{nonIDR}, n.Normalize([][]byte{nonIDR}))
+}
@@ -0,0 +1,133 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// UDPPacket is a UDP datagram read from a capture.
+type UDPPacket struct {
+	NTP     time.Time
+	Src     *net.UDPAddr
+	Dst     *net.UDPAddr
+	Payload []byte
+}
+
+// Reader reads UDP datagrams from a PCAP capture written by Writer.
+// Only captures with the raw IP link type are supported.
+type Reader struct {
+	// source of the capture.
+	R io.Reader
+
+	byteOrder binary.ByteOrder
+}
+
+// Initialize initializes Reader and reads the file header.
+func (r *Reader) Initialize() error {
+	var header [24]byte
+	_, err := io.ReadFull(r.R, header[:])
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(header[0:]) == magicMicroseconds:
+		r.byteOrder = binary.LittleEndian
+
+	case binary.BigEndian.Uint32(header[0:]) == magicMicroseconds:
+		r.byteOrder = binary.BigEndian
+
+	default:
+		return fmt.Errorf("invalid magic number")
+	}
+
+	if linkType := r.byteOrder.Uint32(header[20:]); linkType != linkTypeRaw {
+		return fmt.Errorf("unsupported link type: %d", linkType)
+	}
+
+	return nil
+}
+
+// ReadUDP reads a UDP datagram.
+// Records that do not contain UDP datagrams are skipped.
+// It returns io.EOF when the capture is over.
+func (r *Reader) ReadUDP() (*UDPPacket, error) {
+	for {
+		var header [16]byte
+		_, err := io.ReadFull(r.R, header[:])
+		if err != nil {
+			return nil, err
+		}
+
+		l := r.byteOrder.Uint32(header[8:])
+		if l > snapLen {
+			return nil, fmt.Errorf("record is too big")
+		}
+
+		buf := make([]byte, l)
+		_, err = io.ReadFull(r.R, buf)
+		if err != nil {
+			return nil, err
+		}
+
+		ntp := time.Unix(int64(r.byteOrder.Uint32(header[0:])), int64(r.byteOrder.Uint32(header[4:]))*1000)
+
+		pkt, ok := parseIPUDP(buf)
+		if !ok {
+			continue
+		}
+
+		pkt.NTP = ntp
+		return pkt, nil
+	}
+}
+
+func parseIPUDP(buf []byte) (*UDPPacket, bool) {
+	if len(buf) < 1 {
+		return nil, false
+	}
+
+	var src net.IP
+	var dst net.IP
+	var ipHeaderSize int
+
+	switch buf[0] >> 4 {
+	case 4:
+		ipHeaderSize = int(buf[0]&0x0F) * 4
+		if len(buf) < ipHeaderSize || ipHeaderSize < ipv4HeaderSize || buf[9] != 17 {
+			return nil, false
+		}
+		src = net.IP(buf[12:16])
+		dst = net.IP(buf[16:20])
+
+	case 6:
+		ipHeaderSize = ipv6HeaderSize
+		if len(buf) < ipHeaderSize || buf[6] != 17 {
+			return nil, false
+		}
+		src = net.IP(buf[8:24])
+		dst = net.IP(buf[24:40])
+
+	default:
+		return nil, false
+	}
+
+	udp := buf[ipHeaderSize:]
+	if len(udp) < udpHeaderSize {
+		return nil, false
+	}
+
+	l := int(binary.BigEndian.Uint16(udp[4:]))
+	if l < udpHeaderSize || l > len(udp) {
+		return nil, false
+	}
+
+	return &UDPPacket{
+		Src:     &net.UDPAddr{IP: src, Port: int(binary.BigEndian.Uint16(udp[0:]))},
+		Dst:     &net.UDPAddr{IP: dst, Port: int(binary.BigEndian.Uint16(udp[2:]))},
+		Payload: udp[udpHeaderSize:l],
+	}, true
+}
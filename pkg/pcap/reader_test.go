@@ -0,0 +1,63 @@
+package pcap
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := &Writer{W: &buf}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	ntp := time.Date(2008, 5, 20, 22, 15, 20, 500000000, time.UTC)
+
+	err = w.WriteUDP(
+		ntp,
+		&net.UDPAddr{IP: net.ParseIP("192.168.1.2"), Port: 5000},
+		&net.UDPAddr{IP: net.ParseIP("192.168.1.3"), Port: 6000},
+		[]byte{1, 2, 3, 4},
+	)
+	require.NoError(t, err)
+
+	err = w.WriteUDP(
+		ntp,
+		&net.UDPAddr{IP: net.ParseIP("::1"), Port: 5000},
+		&net.UDPAddr{IP: net.ParseIP("::2"), Port: 6000},
+		[]byte{5, 6},
+	)
+	require.NoError(t, err)
+
+	r := &Reader{R: &buf}
+	err = r.Initialize()
+	require.NoError(t, err)
+
+	pkt, err := r.ReadUDP()
+	require.NoError(t, err)
+	require.True(t, ntp.Equal(pkt.NTP))
+	require.Equal(t, "192.168.1.2:5000", pkt.Src.String())
+	require.Equal(t, "192.168.1.3:6000", pkt.Dst.String())
+	require.Equal(t, []byte{1, 2, 3, 4}, pkt.Payload)
+
+	pkt, err = r.ReadUDP()
+	require.NoError(t, err)
+	require.Equal(t, "[::1]:5000", pkt.Src.String())
+	require.Equal(t, "[::2]:6000", pkt.Dst.String())
+	require.Equal(t, []byte{5, 6}, pkt.Payload)
+
+	_, err = r.ReadUDP()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReaderInvalidHeader(t *testing.T) {
+	r := &Reader{R: bytes.NewReader(make([]byte, 24))}
+	err := r.Initialize()
+	require.EqualError(t, err, "invalid magic number")
+}
@@ -0,0 +1,150 @@
+// Package pcap contains a reader and a writer of PCAP captures.
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	magicMicroseconds = 0xa1b2c3d4
+	snapLen           = 65535
+	linkTypeRaw       = 101
+	ipv4HeaderSize    = 20
+	ipv6HeaderSize    = 40
+	udpHeaderSize     = 8
+)
+
+func checksumSum(sum uint32, data []byte) uint32 {
+	for i := 0; i < len(data); i += 2 {
+		if i+1 < len(data) {
+			sum += uint32(data[i])<<8 | uint32(data[i+1])
+		} else {
+			sum += uint32(data[i]) << 8
+		}
+	}
+	return sum
+}
+
+func checksumFold(sum uint32) uint16 {
+	for (sum >> 16) != 0 {
+		sum = (sum & 0xFFFF) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
+
+func ipv4Checksum(header []byte) uint16 {
+	return checksumFold(checksumSum(0, header))
+}
+
+// udpIPv6Checksum computes the checksum of a UDP datagram carried by IPv6,
+// that is mandatory (RFC 8200, section 8.1).
+func udpIPv6Checksum(src net.IP, dst net.IP, udp []byte) uint16 {
+	// pseudo-header
+	sum := checksumSum(0, src)
+	sum = checksumSum(sum, dst)
+	sum += uint32(len(udp))
+	sum += 17 // UDP
+
+	sum = checksumSum(sum, udp)
+
+	c := checksumFold(sum)
+	if c == 0 {
+		c = 0xFFFF
+	}
+	return c
+}
+
+// Writer writes UDP datagrams into a PCAP capture,
+// that can be opened with Wireshark or tcpdump.
+// Specification: https://datatracker.ietf.org/doc/html/draft-ietf-opsawg-pcap
+type Writer struct {
+	// destination of the capture.
+	W io.Writer
+
+	mutex sync.Mutex
+	buf   []byte
+}
+
+// Initialize initializes Writer and writes the file header.
+func (w *Writer) Initialize() error {
+	var header [24]byte
+	binary.LittleEndian.PutUint32(header[0:], magicMicroseconds)
+	binary.LittleEndian.PutUint16(header[4:], 2) // major version
+	binary.LittleEndian.PutUint16(header[6:], 4) // minor version
+	binary.LittleEndian.PutUint32(header[16:], snapLen)
+	binary.LittleEndian.PutUint32(header[20:], linkTypeRaw)
+
+	_, err := w.W.Write(header[:])
+	return err
+}
+
+// WriteUDP writes a UDP datagram.
+// It can be called from multiple goroutines.
+func (w *Writer) WriteUDP(ntp time.Time, src *net.UDPAddr, dst *net.UDPAddr, payload []byte) error {
+	src4 := src.IP.To4()
+	dst4 := dst.IP.To4()
+	isIPv4 := src4 != nil && dst4 != nil
+
+	ipHeaderSize := ipv6HeaderSize
+	if isIPv4 {
+		ipHeaderSize = ipv4HeaderSize
+	}
+
+	l := ipHeaderSize + udpHeaderSize + len(payload)
+	if l > snapLen {
+		return fmt.Errorf("payload is too big")
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if cap(w.buf) < (16 + l) {
+		w.buf = make([]byte, 16+l)
+	}
+	buf := w.buf[:16+l]
+	clear(buf)
+
+	// record header
+	binary.LittleEndian.PutUint32(buf[0:], uint32(ntp.Unix()))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(ntp.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(l))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(l))
+
+	ip := buf[16:]
+
+	if isIPv4 {
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:], uint16(l))
+		ip[6] = 0x40 // don't fragment
+		ip[8] = 64   // TTL
+		ip[9] = 17   // UDP
+		copy(ip[12:], src4)
+		copy(ip[16:], dst4)
+		binary.BigEndian.PutUint16(ip[10:], ipv4Checksum(ip[:ipv4HeaderSize]))
+	} else {
+		ip[0] = 0x60
+		binary.BigEndian.PutUint16(ip[4:], uint16(udpHeaderSize+len(payload)))
+		ip[6] = 17 // UDP
+		ip[7] = 64 // hop limit
+		copy(ip[8:], src.IP.To16())
+		copy(ip[24:], dst.IP.To16())
+	}
+
+	udp := ip[ipHeaderSize:]
+	binary.BigEndian.PutUint16(udp[0:], uint16(src.Port))
+	binary.BigEndian.PutUint16(udp[2:], uint16(dst.Port))
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpHeaderSize+len(payload)))
+	copy(udp[udpHeaderSize:], payload)
+
+	if !isIPv4 {
+		binary.BigEndian.PutUint16(udp[6:], udpIPv6Checksum(ip[8:24], ip[24:40], udp))
+	}
+
+	_, err := w.W.Write(buf)
+	return err
+}
@@ -0,0 +1,51 @@
+package pcap
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := &Writer{W: &buf}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	err = w.WriteUDP(
+		time.Date(2008, 5, 20, 22, 15, 20, 500000000, time.UTC),
+		&net.UDPAddr{IP: net.ParseIP("192.168.1.2"), Port: 5000},
+		&net.UDPAddr{IP: net.ParseIP("192.168.1.3"), Port: 6000},
+		[]byte{1, 2, 3, 4},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{
+		0xd4, 0xc3, 0xb2, 0xa1, 0x02, 0x00, 0x04, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0x00, 0x00, 0x65, 0x00, 0x00, 0x00,
+		0x78, 0x4d, 0x33, 0x48, 0x20, 0xa1, 0x07, 0x00,
+		0x20, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00,
+		0x45, 0x00, 0x00, 0x20, 0x00, 0x00, 0x40, 0x00,
+		0x40, 0x11, 0xb7, 0x77, 0xc0, 0xa8, 0x01, 0x02,
+		0xc0, 0xa8, 0x01, 0x03, 0x13, 0x88, 0x17, 0x70,
+		0x00, 0x0c, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+	}, buf.Bytes())
+
+	buf.Reset()
+
+	err = w.WriteUDP(
+		time.Date(2008, 5, 20, 22, 15, 20, 500000000, time.UTC),
+		&net.UDPAddr{IP: net.ParseIP("::1"), Port: 5000},
+		&net.UDPAddr{IP: net.ParseIP("::2"), Port: 6000},
+		[]byte{1, 2, 3, 4},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 16+40+8+4, buf.Len())
+	require.Equal(t, byte(0x60), buf.Bytes()[16])
+	require.Equal(t, []byte{0xd0, 0xd5}, buf.Bytes()[16+40+6:16+40+8])
+}
@@ -0,0 +1,104 @@
+// Package ptssmoother contains a utility to remove jitter and jumps from timestamps of frames.
+package ptssmoother
+
+import (
+	"math"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+)
+
+// Smoother is a filter that removes jitter and jumps from timestamps of frames,
+// producing monotonic and smoothed timestamps.
+//
+// Jitter is absorbed by following input timestamps slowly,
+// while jumps (for instance, backward timestamps after a NTP resync of the camera)
+// are compensated by continuing from the last outgoing timestamp.
+//
+// Timestamps must be provided in presentation order.
+// Frames of streams with B-frames should be processed after being reordered.
+type Smoother struct {
+	// clock rate of timestamps.
+	ClockRate int
+
+	// maximum difference between the timestamp of a frame and the expected one
+	// that is considered jitter. Greater differences are considered jumps.
+	// It defaults to 500ms.
+	MaxJitter time.Duration
+
+	// weight given to the difference between the timestamp of a frame and the expected one.
+	// Lower values produce smoother timestamps, higher values follow input timestamps more closely.
+	// It defaults to 0.1.
+	Gain float64
+
+	// called when a jump is detected, with the difference between
+	// the timestamp of the frame and the expected one.
+	OnJump func(diff int64)
+
+	maxJitter   int64
+	initialized bool
+	lastIn      int64
+	lastOut     float64
+	interval    float64
+	offset      int64
+}
+
+// Initialize initializes Smoother.
+func (s *Smoother) Initialize() {
+	if s.MaxJitter == 0 {
+		s.MaxJitter = 500 * time.Millisecond
+	}
+	if s.Gain == 0 {
+		s.Gain = 0.1
+	}
+	if s.OnJump == nil {
+		s.OnJump = func(int64) {}
+	}
+
+	s.maxJitter = mediatime.DurationToTimestamp(s.MaxJitter, s.ClockRate)
+}
+
+// Process processes the timestamp of a frame and returns the smoothed timestamp.
+func (s *Smoother) Process(pts int64) int64 {
+	if !s.initialized {
+		s.initialized = true
+		s.lastIn = pts
+		s.lastOut = float64(pts)
+		return pts
+	}
+
+	target := float64(pts + s.offset)
+	expected := s.lastOut + s.interval
+
+	if diff := target - expected; math.Abs(diff) > float64(s.maxJitter) {
+		s.OnJump(int64(diff))
+
+		// continue from the last outgoing timestamp
+		s.offset = int64(math.Round(expected)) - pts
+		target = float64(pts + s.offset)
+	} else if d := float64(pts - s.lastIn); d > 0 {
+		// update the estimated frame interval
+		if s.interval == 0 {
+			s.interval = d
+		} else {
+			s.interval += (d - s.interval) * s.Gain
+		}
+	}
+
+	out := expected + (target-expected)*s.Gain
+
+	// the first interval is not known in advance
+	if expected == s.lastOut {
+		out = target
+	}
+
+	// make sure that timestamps are monotonic
+	if math.Round(out) <= math.Round(s.lastOut) {
+		out = math.Round(s.lastOut) + 1
+	}
+
+	s.lastIn = pts
+	s.lastOut = out
+
+	return int64(math.Round(out))
+}
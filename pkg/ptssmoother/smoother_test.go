@@ -0,0 +1,84 @@
+package ptssmoother
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmootherJitter(t *testing.T) {
+	s := &Smoother{
+		ClockRate: 90000,
+	}
+	s.Initialize()
+
+	var prev int64
+
+	for i := range int64(200) {
+		jitter := int64(900)
+		if (i % 2) == 0 {
+			jitter = -900
+		}
+
+		out := s.Process(i*3000 + jitter)
+		if i != 0 {
+			require.Greater(t, out, prev)
+		}
+		prev = out
+
+		if i >= 100 {
+			require.InDelta(t, i*3000, out, 200)
+		}
+	}
+}
+
+func TestSmootherJump(t *testing.T) {
+	var jumps []int64
+
+	s := &Smoother{
+		ClockRate: 90000,
+		OnJump: func(diff int64) {
+			jumps = append(jumps, diff)
+		},
+	}
+	s.Initialize()
+
+	var out []int64
+
+	for i := range int64(5) {
+		out = append(out, s.Process(1000000+i*3000))
+	}
+
+	// camera clock is moved 10 seconds backward
+	for i := range int64(5) {
+		out = append(out, s.Process(1000000-900000+(5+i)*3000))
+	}
+
+	require.Equal(t, []int64{
+		1000000,
+		1003000,
+		1006000,
+		1009000,
+		1012000,
+		1015000,
+		1018000,
+		1021000,
+		1024000,
+		1027000,
+	}, out)
+
+	require.Equal(t, []int64{-900000}, jumps)
+}
+
+func TestSmootherBackward(t *testing.T) {
+	s := &Smoother{
+		ClockRate: 90000,
+	}
+	s.Initialize()
+
+	require.Equal(t, int64(3000), s.Process(3000))
+	require.Equal(t, int64(6000), s.Process(6000))
+
+	// timestamps are always increasing
+	require.Greater(t, s.Process(5000), int64(6000))
+}
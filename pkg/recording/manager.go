@@ -0,0 +1,428 @@
+// Package recording contains a recording manager.
+package recording
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+)
+
+// SegmentFormat is the container format of segments.
+type SegmentFormat int
+
+// segment formats.
+const (
+	SegmentFormatMP4 SegmentFormat = iota
+	SegmentFormatMPEGTS
+)
+
+// Mode is a recording mode.
+type Mode int
+
+// recording modes.
+const (
+	// segments are written continuously.
+	ModeContinuous Mode = iota
+
+	// segments are written only around marks,
+	// that are usually generated by motion detectors or other external triggers.
+	ModeTriggered
+)
+
+// Manager writes tracks into rotating segments and maintains an index of them.
+//
+// In continuous mode, segments are written continuously.
+// In triggered mode, segments are written only when a mark is received,
+// and they contain PreTrigger before the mark and PostTrigger after the last mark.
+//
+// Timestamps are expressed in clock rate units of each track and
+// must share the same origin (like the ones returned by Client.PacketPTS()).
+// Segments start with a random access unit of the leading track,
+// that is the first video track, or the first track if there are no video tracks.
+type Manager struct {
+	// tracks.
+	Tracks []*Track
+
+	// container format of segments.
+	// It defaults to SegmentFormatMP4.
+	SegmentFormat SegmentFormat
+
+	// recording mode.
+	// It defaults to ModeContinuous.
+	Mode Mode
+
+	// returns the path of a new segment.
+	// It is called every time a segment is created.
+	Path func(start time.Time) string
+
+	// maximum duration of a segment.
+	// When it is reached, a new segment is created.
+	// It defaults to 10 minutes.
+	SegmentDuration time.Duration
+
+	// in triggered mode, minimum duration of the media that is recorded before a mark.
+	// Since segments start with a random access unit, the actual duration can be longer.
+	// It defaults to zero, that means that recording starts from the last random access unit.
+	PreTrigger time.Duration
+
+	// in triggered mode, duration of the media that is recorded after the last mark.
+	// It defaults to 10 seconds.
+	PostTrigger time.Duration
+
+	// called when a segment has been completely written.
+	// It must not call methods of Manager.
+	OnSegmentComplete func(seg *Segment)
+
+	timeNow func() time.Time
+
+	mutex          sync.Mutex
+	leader         *Track
+	baseSet        bool
+	baseTime       time.Time
+	basePTS        int64
+	lastLeaderPTS  int64
+	preTrigger     int64
+	postTrigger    int64
+	segmentMaxSize int64
+	pendingMarks   []Mark
+	triggered      bool
+	triggerActive  bool
+	triggerEnd     int64
+	buffer         []*entry
+	writer         segmentWriter
+	segment        *Segment
+	segmentPTS     int64
+	index          []*Segment
+}
+
+// Initialize initializes Manager.
+func (m *Manager) Initialize() error {
+	if len(m.Tracks) == 0 {
+		return fmt.Errorf("no tracks provided")
+	}
+
+	if m.Path == nil {
+		return fmt.Errorf("Path not provided")
+	}
+
+	if m.SegmentFormat != SegmentFormatMP4 && m.SegmentFormat != SegmentFormatMPEGTS {
+		return fmt.Errorf("invalid segment format: %v", m.SegmentFormat)
+	}
+
+	if m.Mode != ModeContinuous && m.Mode != ModeTriggered {
+		return fmt.Errorf("invalid mode: %v", m.Mode)
+	}
+
+	if m.SegmentDuration == 0 {
+		m.SegmentDuration = 10 * time.Minute
+	}
+	if m.PostTrigger == 0 {
+		m.PostTrigger = 10 * time.Second
+	}
+	if m.OnSegmentComplete == nil {
+		m.OnSegmentComplete = func(*Segment) {}
+	}
+	if m.timeNow == nil {
+		m.timeNow = time.Now
+	}
+
+	for _, track := range m.Tracks {
+		err := track.initialize()
+		if err != nil {
+			return err
+		}
+
+		if m.leader == nil && track.isVideo {
+			m.leader = track
+		}
+	}
+
+	if m.leader == nil {
+		m.leader = m.Tracks[0]
+	}
+
+	m.preTrigger = mediatime.DurationToTimestamp(m.PreTrigger, m.leader.clockRate)
+	m.postTrigger = mediatime.DurationToTimestamp(m.PostTrigger, m.leader.clockRate)
+	m.segmentMaxSize = mediatime.DurationToTimestamp(m.SegmentDuration, m.leader.clockRate)
+
+	return nil
+}
+
+// Close finalizes the current segment.
+func (m *Manager) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.buffer = nil
+
+	if m.writer == nil {
+		return nil
+	}
+
+	return m.closeSegment(m.lastLeaderPTS)
+}
+
+// Mark attaches an external event to the current segment.
+// In triggered mode, it also starts recording, or extends the current recording by PostTrigger.
+// It can be called from any goroutine.
+func (m *Manager) Mark(label string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mark := Mark{
+		Time:  m.timeNow(),
+		Label: label,
+	}
+
+	if m.segment != nil {
+		m.segment.Marks = append(m.segment.Marks, mark)
+	} else {
+		m.pendingMarks = append(m.pendingMarks, mark)
+	}
+
+	if m.Mode == ModeTriggered {
+		m.triggered = true
+	}
+}
+
+// Segments returns the index, that contains all completed segments.
+func (m *Manager) Segments() []*Segment {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ret := make([]*Segment, len(m.index))
+	copy(ret, m.index)
+	return ret
+}
+
+// Find returns the completed segment that contains the given absolute time.
+// It returns nil if the time is not covered by any segment.
+func (m *Manager) Find(t time.Time) *Segment {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, seg := range m.index {
+		if seg.Contains(t) {
+			return seg
+		}
+	}
+
+	return nil
+}
+
+// WriteH264 writes a H264 access unit.
+// SPS and PPS are automatically added before IDR frames.
+func (m *Manager) WriteH264(track *Track, pts int64, au [][]byte) error {
+	au = track.addH264Params(au)
+	randomAccess := h264.IsRandomAccess(au)
+
+	dts, ok, err := track.h264DTS.Extract(au, pts)
+	if err != nil || !ok {
+		return err
+	}
+
+	return m.write(&entry{
+		track:        track,
+		pts:          pts,
+		dts:          dts,
+		au:           au,
+		randomAccess: randomAccess,
+	})
+}
+
+// WriteH265 writes a H265 access unit.
+// VPS, SPS and PPS are automatically added before random access units.
+func (m *Manager) WriteH265(track *Track, pts int64, au [][]byte) error {
+	au = track.addH265Params(au)
+	randomAccess := h265.IsRandomAccess(au)
+
+	dts, ok, err := track.h265DTS.Extract(au, pts)
+	if err != nil || !ok {
+		return err
+	}
+
+	return m.write(&entry{
+		track:        track,
+		pts:          pts,
+		dts:          dts,
+		au:           au,
+		randomAccess: randomAccess,
+	})
+}
+
+// WriteMPEG4Audio writes MPEG-4 Audio access units.
+func (m *Manager) WriteMPEG4Audio(track *Track, pts int64, aus [][]byte) error {
+	return m.write(&entry{
+		track:        track,
+		pts:          pts,
+		dts:          pts,
+		au:           aus,
+		randomAccess: true,
+	})
+}
+
+func (m *Manager) write(e *entry) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if e.track == m.leader {
+		if !m.baseSet {
+			m.baseSet = true
+			m.baseTime = m.timeNow()
+			m.basePTS = e.pts
+		}
+
+		m.lastLeaderPTS = e.pts
+
+		if m.triggered {
+			m.triggered = false
+			m.triggerActive = true
+			m.triggerEnd = e.pts + m.postTrigger
+		}
+	}
+
+	if m.Mode == ModeContinuous {
+		return m.writeEntry(e)
+	}
+
+	if m.writer != nil {
+		// recording ends with the first random access unit after the end of the trigger,
+		// in order to allow the next segment to start from it.
+		if e.track == m.leader && e.randomAccess && e.pts >= m.triggerEnd {
+			m.triggerActive = false
+
+			err := m.closeSegment(e.pts)
+			if err != nil {
+				return err
+			}
+		} else {
+			return m.writeEntry(e)
+		}
+	}
+
+	m.bufferEntry(e)
+
+	if m.buffer != nil && m.triggerActive {
+		buffer := m.buffer
+		m.buffer = nil
+
+		for _, be := range buffer {
+			err := m.writeEntry(be)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// bufferEntry stores an entry while recording is not active,
+// in order to be able to record the media that precedes a mark.
+func (m *Manager) bufferEntry(e *entry) {
+	if e.track == m.leader && e.randomAccess {
+		m.buffer = append(m.buffer, e)
+
+		// remove entries that are not needed to cover PreTrigger,
+		// while making sure that the buffer starts with a random access unit.
+		start := 0
+
+		for i, be := range m.buffer {
+			if be.track == m.leader && be.randomAccess && be.pts <= e.pts-m.preTrigger {
+				start = i
+			}
+		}
+
+		m.buffer = m.buffer[start:]
+		return
+	}
+
+	// the buffer must start with a random access unit of the leading track.
+	if m.buffer == nil {
+		return
+	}
+
+	m.buffer = append(m.buffer, e)
+}
+
+func (m *Manager) writeEntry(e *entry) error {
+	if e.track == m.leader && e.randomAccess {
+		switch {
+		case m.writer == nil:
+			err := m.createSegment(e.pts)
+			if err != nil {
+				return err
+			}
+
+		case (e.pts - m.segmentPTS) >= m.segmentMaxSize:
+			err := m.closeSegment(e.pts)
+			if err != nil {
+				return err
+			}
+
+			err = m.createSegment(e.pts)
+			if err != nil {
+				return err
+			}
+		}
+
+		m.segment.KeyFrames = append(m.segment.KeyFrames,
+			mediatime.TimestampToDuration(e.pts-m.segmentPTS, m.leader.clockRate))
+	} else if m.writer == nil {
+		return nil
+	}
+
+	return m.writer.write(e)
+}
+
+func (m *Manager) createSegment(leaderPTS int64) error {
+	start := m.baseTime.Add(mediatime.TimestampToDuration(leaderPTS-m.basePTS, m.leader.clockRate))
+	path := m.Path(start)
+
+	var w segmentWriter
+	if m.SegmentFormat == SegmentFormatMPEGTS {
+		w = &segmentWriterMPEGTS{}
+	} else {
+		w = &segmentWriterMP4{}
+	}
+
+	err := w.initialize(path, m.Tracks)
+	if err != nil {
+		return err
+	}
+
+	m.writer = w
+	m.segmentPTS = leaderPTS
+	m.segment = &Segment{
+		Path:  path,
+		Start: start,
+		Marks: m.pendingMarks,
+	}
+	m.pendingMarks = nil
+
+	return nil
+}
+
+func (m *Manager) closeSegment(endPTS int64) error {
+	w := m.writer
+	seg := m.segment
+	m.writer = nil
+	m.segment = nil
+
+	err := w.close()
+	if err != nil {
+		return err
+	}
+
+	seg.Duration = mediatime.TimestampToDuration(endPTS-m.segmentPTS, m.leader.clockRate)
+	m.index = append(m.index, seg)
+
+	m.OnSegmentComplete(seg)
+
+	return nil
+}
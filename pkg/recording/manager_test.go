@@ -0,0 +1,202 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/pmp4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+var testTime = time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testTracks() (*Track, *Track) {
+	videoTrack := &Track{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+			SPS:               testSPS,
+			PPS:               testPPS,
+		},
+	}
+
+	audioTrack := &Track{
+		Format: &format.MPEG4Audio{
+			PayloadTyp: 97,
+			Config: &mpeg4audio.AudioSpecificConfig{
+				Type:         2,
+				SampleRate:   44100,
+				ChannelCount: 2,
+			},
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		},
+	}
+
+	return videoTrack, audioTrack
+}
+
+// writeFrames writes frames at 10 FPS, with a random access unit every gop frames.
+func writeFrames(t *testing.T, m *Manager, videoTrack *Track, audioTrack *Track,
+	from int, to int, gop int,
+) {
+	for i := from; i < to; i++ {
+		var au [][]byte
+		if (i % gop) == 0 {
+			au = [][]byte{{5, byte(i)}}
+		} else {
+			au = [][]byte{{1, byte(i)}}
+		}
+
+		err := m.WriteH264(videoTrack, int64(i*9000), au)
+		require.NoError(t, err)
+
+		err = m.WriteMPEG4Audio(audioTrack, int64(i*4410), [][]byte{{3, byte(i)}})
+		require.NoError(t, err)
+	}
+}
+
+func TestManagerContinuous(t *testing.T) {
+	for _, ca := range []string{"mp4", "mpegts"} {
+		t.Run(ca, func(t *testing.T) {
+			dir := t.TempDir()
+			videoTrack, audioTrack := testTracks()
+
+			var completed []*Segment
+			n := 0
+
+			m := &Manager{
+				Tracks: []*Track{audioTrack, videoTrack},
+				SegmentFormat: func() SegmentFormat {
+					if ca == "mp4" {
+						return SegmentFormatMP4
+					}
+					return SegmentFormatMPEGTS
+				}(),
+				Path: func(_ time.Time) string {
+					n++
+					return filepath.Join(dir, strconv.FormatInt(int64(n), 10)+"."+ca)
+				},
+				SegmentDuration: 1 * time.Second,
+				OnSegmentComplete: func(seg *Segment) {
+					completed = append(completed, seg)
+				},
+				timeNow: func() time.Time {
+					return testTime
+				},
+			}
+			err := m.Initialize()
+			require.NoError(t, err)
+
+			// discarded since it is not a random access unit
+			err = m.WriteH264(videoTrack, 0, [][]byte{{1, 0}})
+			require.NoError(t, err)
+
+			m.Mark("before")
+
+			writeFrames(t, m, videoTrack, audioTrack, 0, 30, 5)
+
+			err = m.Close()
+			require.NoError(t, err)
+
+			require.Equal(t, completed, m.Segments())
+			require.Len(t, completed, 3)
+
+			for i, seg := range completed {
+				require.Equal(t, filepath.Join(dir, strconv.FormatInt(int64(i+1), 10)+"."+ca), seg.Path)
+				require.Equal(t, testTime.Add(time.Duration(i)*time.Second), seg.Start)
+				require.Equal(t, []time.Duration{0, 500 * time.Millisecond}, seg.KeyFrames)
+
+				byts, err2 := os.ReadFile(seg.Path)
+				require.NoError(t, err2)
+
+				if ca == "mp4" {
+					require.Equal(t, "ftyp", string(byts[4:8]))
+				} else {
+					require.Equal(t, byte(0x47), byts[0])
+					require.Zero(t, len(byts)%188)
+				}
+			}
+
+			require.Equal(t, 1*time.Second, completed[0].Duration)
+			require.Equal(t, 900*time.Millisecond, completed[2].Duration)
+			require.Equal(t, []Mark{{Time: testTime, Label: "before"}}, completed[0].Marks)
+
+			seg := m.Find(testTime.Add(1700 * time.Millisecond))
+			require.Equal(t, completed[1], seg)
+			require.Equal(t, 500*time.Millisecond, seg.KeyFrameBefore(testTime.Add(1700*time.Millisecond)))
+
+			require.Nil(t, m.Find(testTime.Add(10*time.Second)))
+		})
+	}
+}
+
+func TestManagerTriggered(t *testing.T) {
+	dir := t.TempDir()
+	videoTrack, audioTrack := testTracks()
+
+	var completed []*Segment
+
+	m := &Manager{
+		Tracks: []*Track{videoTrack, audioTrack},
+		Mode:   ModeTriggered,
+		Path: func(_ time.Time) string {
+			return filepath.Join(dir, "1.mp4")
+		},
+		PreTrigger:  1 * time.Second,
+		PostTrigger: 1 * time.Second,
+		OnSegmentComplete: func(seg *Segment) {
+			completed = append(completed, seg)
+		},
+		timeNow: func() time.Time {
+			return testTime
+		},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	writeFrames(t, m, videoTrack, audioTrack, 0, 25, 10)
+
+	require.Empty(t, completed)
+
+	m.Mark("motion")
+
+	writeFrames(t, m, videoTrack, audioTrack, 25, 60, 10)
+
+	err = m.Close()
+	require.NoError(t, err)
+
+	require.Len(t, completed, 1)
+	require.Equal(t, testTime.Add(1*time.Second), completed[0].Start)
+	require.Equal(t, 3*time.Second, completed[0].Duration)
+	require.Equal(t, []time.Duration{0, 1 * time.Second, 2 * time.Second}, completed[0].KeyFrames)
+	require.Equal(t, []Mark{{Time: testTime, Label: "motion"}}, completed[0].Marks)
+
+	f, err := os.Open(completed[0].Path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var pres pmp4.Presentation
+	err = pres.Unmarshal(f)
+	require.NoError(t, err)
+
+	require.Len(t, pres.Tracks, 2)
+	require.Len(t, pres.Tracks[0].Samples, 30)
+	require.False(t, pres.Tracks[0].Samples[0].IsNonSyncSample)
+}
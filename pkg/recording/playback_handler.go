@@ -0,0 +1,308 @@
+package recording
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	mcmp4 "github.com/bluenviron/mediacommon/v2/pkg/formats/mp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/pmp4"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+)
+
+func readPresentation(path string) (*os.File, *pmp4.Presentation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pres pmp4.Presentation
+	err = pres.Unmarshal(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return f, &pres, nil
+}
+
+func codecToFormat(codec mcmp4.Codec, payloadType uint8) (format.Format, error) {
+	switch codec := codec.(type) {
+	case *mcmp4.CodecH264:
+		return &format.H264{
+			PayloadTyp:        payloadType,
+			PacketizationMode: 1,
+			SPS:               codec.SPS,
+			PPS:               codec.PPS,
+		}, nil
+
+	case *mcmp4.CodecH265:
+		return &format.H265{
+			PayloadTyp: payloadType,
+			VPS:        codec.VPS,
+			SPS:        codec.SPS,
+			PPS:        codec.PPS,
+		}, nil
+
+	case *mcmp4.CodecMPEG4Audio:
+		return &format.MPEG4Audio{
+			PayloadTyp:       payloadType,
+			Config:           &codec.Config,
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported codec: %T", codec)
+	}
+}
+
+// segmentsDescription returns the description of a recording,
+// that is read from its first segment.
+func segmentsDescription(segs []*Segment) (*description.Session, error) {
+	f, pres, err := readPresentation(segs[0].Path)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	desc := &description.Session{}
+
+	for i, track := range pres.Tracks {
+		forma, err := codecToFormat(track.Codec, uint8(96+i))
+		if err != nil {
+			return nil, err
+		}
+
+		typ := description.MediaTypeVideo
+		if _, ok := forma.(*format.MPEG4Audio); ok {
+			typ = description.MediaTypeAudio
+		}
+
+		desc.Medias = append(desc.Medias, &description.Media{
+			Type: typ,
+			// the server uses trackID=number when describing streams.
+			Control: "trackID=" + strconv.FormatInt(int64(i), 10),
+			Formats: []format.Format{forma},
+		})
+	}
+
+	return desc, nil
+}
+
+// PlaybackHandler is a ServerHandler that serves recordings as RTSP streams.
+// Each session gets its own stream, therefore each reader can start playback
+// from a different position, that is provided with the Range header of PLAY requests,
+// in NPT (relative to the start of the recording) or clock (absolute) units.
+// Playback starts from the random access unit that precedes the requested position.
+// Gaps between segments are skipped.
+//
+// Segments must be in MP4 format.
+//
+// In order to release resources of sessions, PlaybackHandler must be the handler of the server,
+// or OnSessionClose must be called by the handler of the server.
+type PlaybackHandler struct {
+	// parent server.
+	Server *gortsplib.Server
+
+	// returns the segments of the recording associated with a path,
+	// sorted by start time, like the ones returned by Manager.Segments().
+	// It returns nil if the path doesn't exist.
+	// It is also called when playback reaches the last segment, in order to find new segments.
+	Segments func(path string) []*Segment
+
+	mutex    sync.Mutex
+	sessions map[*gortsplib.ServerSession]*playbackSession
+}
+
+// Initialize initializes PlaybackHandler.
+func (h *PlaybackHandler) Initialize() error {
+	if h.Server == nil {
+		return fmt.Errorf("Server not provided")
+	}
+
+	if h.Segments == nil {
+		return fmt.Errorf("Segments not provided")
+	}
+
+	h.sessions = make(map[*gortsplib.ServerSession]*playbackSession)
+
+	return nil
+}
+
+// Close closes all sessions.
+func (h *PlaybackHandler) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for ss, ps := range h.sessions {
+		ps.close()
+		delete(h.sessions, ss)
+	}
+}
+
+// OnDescribe implements gortsplib.ServerHandlerOnDescribe.
+func (h *PlaybackHandler) OnDescribe(
+	ctx *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	segs := h.Segments(ctx.Path)
+	if len(segs) == 0 {
+		return &base.Response{
+			StatusCode: base.StatusNotFound,
+		}, nil, nil
+	}
+
+	desc, err := segmentsDescription(segs)
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusInternalServerError,
+		}, nil, err
+	}
+
+	byts, err := desc.Marshal()
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusInternalServerError,
+		}, nil, err
+	}
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+		Body:       byts,
+	}, nil, nil
+}
+
+// OnSetup implements gortsplib.ServerHandlerOnSetup.
+func (h *PlaybackHandler) OnSetup(
+	ctx *gortsplib.ServerHandlerOnSetupCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if ps, ok := h.sessions[ctx.Session]; ok {
+		return &base.Response{
+			StatusCode: base.StatusOK,
+		}, ps.stream, nil
+	}
+
+	segs := h.Segments(ctx.Path)
+	if len(segs) == 0 {
+		return &base.Response{
+			StatusCode: base.StatusNotFound,
+		}, nil, nil
+	}
+
+	desc, err := segmentsDescription(segs)
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusInternalServerError,
+		}, nil, err
+	}
+
+	ps := &playbackSession{
+		h:       h,
+		session: ctx.Session,
+		path:    ctx.Path,
+		desc:    desc,
+		segs:    segs,
+	}
+	err = ps.initialize()
+	if err != nil {
+		return &base.Response{
+			StatusCode: base.StatusInternalServerError,
+		}, nil, err
+	}
+
+	h.sessions[ctx.Session] = ps
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, ps.stream, nil
+}
+
+// OnPlay implements gortsplib.ServerHandlerOnPlay.
+func (h *PlaybackHandler) OnPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	h.mutex.Lock()
+	ps, ok := h.sessions[ctx.Session]
+	h.mutex.Unlock()
+
+	if !ok {
+		return &base.Response{
+			StatusCode: base.StatusSessionNotFound,
+		}, nil
+	}
+
+	var start *time.Time
+
+	if v, ok2 := ctx.Request.Header["Range"]; ok2 {
+		var ra headers.Range
+		err := ra.Unmarshal(v)
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusBadRequest,
+			}, err
+		}
+
+		switch val := ra.Value.(type) {
+		case *headers.RangeNPT:
+			t := ps.segs[0].Start.Add(val.Start)
+			start = &t
+
+		case *headers.RangeUTC:
+			start = &val.Start
+
+		default:
+			return &base.Response{
+				StatusCode: base.StatusInvalidRange,
+			}, nil
+		}
+	}
+
+	pos := ps.play(start)
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+		Header: base.Header{
+			"Range": headers.Range{
+				Value: &headers.RangeNPT{
+					Start: pos.Sub(ps.segs[0].Start),
+				},
+			}.Marshal(),
+		},
+	}, nil
+}
+
+// OnPause implements gortsplib.ServerHandlerOnPause.
+func (h *PlaybackHandler) OnPause(ctx *gortsplib.ServerHandlerOnPauseCtx) (*base.Response, error) {
+	h.mutex.Lock()
+	ps, ok := h.sessions[ctx.Session]
+	h.mutex.Unlock()
+
+	if ok {
+		ps.pause()
+	}
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+// OnSessionClose implements gortsplib.ServerHandlerOnSessionClose.
+func (h *PlaybackHandler) OnSessionClose(ctx *gortsplib.ServerHandlerOnSessionCloseCtx) {
+	h.mutex.Lock()
+	ps, ok := h.sessions[ctx.Session]
+	delete(h.sessions, ctx.Session)
+	h.mutex.Unlock()
+
+	if ok {
+		ps.close()
+	}
+}
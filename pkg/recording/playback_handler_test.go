@@ -0,0 +1,131 @@
+package recording
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+)
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
+func TestPlaybackHandler(t *testing.T) {
+	dir := t.TempDir()
+	videoTrack, audioTrack := testTracks()
+	n := 0
+
+	m := &Manager{
+		Tracks: []*Track{videoTrack, audioTrack},
+		Path: func(_ time.Time) string {
+			n++
+			return filepath.Join(dir, strconv.FormatInt(int64(n), 10)+".mp4")
+		},
+		SegmentDuration: 1 * time.Second,
+		timeNow: func() time.Time {
+			return testTime
+		},
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	writeFrames(t, m, videoTrack, audioTrack, 0, 30, 5)
+
+	err = m.Close()
+	require.NoError(t, err)
+
+	h := &PlaybackHandler{
+		Segments: func(path string) []*Segment {
+			if path != "/recording" {
+				return nil
+			}
+			return m.Segments()
+		},
+	}
+
+	s := &gortsplib.Server{
+		Handler:     h,
+		RTSPAddress: "localhost:8554",
+	}
+
+	h.Server = s
+	err = h.Initialize()
+	require.NoError(t, err)
+	defer h.Close()
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	u, err := base.ParseURL("rtsp://localhost:8554/recording")
+	require.NoError(t, err)
+
+	c := gortsplib.Client{
+		Scheme:   u.Scheme,
+		Host:     u.Host,
+		Protocol: ptrOf(gortsplib.ProtocolTCP),
+	}
+	err = c.Start()
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+	require.Len(t, desc.Medias, 2)
+
+	var forma *format.H264
+	medi := desc.FindFormat(&forma)
+	require.NotNil(t, medi)
+	require.Equal(t, testSPS, forma.SPS)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	dec, err := forma.CreateDecoder()
+	require.NoError(t, err)
+
+	recv := make(chan [][]byte, 100)
+
+	c.OnPacketRTP(medi, forma, func(pkt *rtp.Packet) {
+		au, err2 := dec.Decode(pkt)
+		if err2 != nil {
+			if !errors.Is(err2, rtph264.ErrMorePacketsNeeded) {
+				require.NoError(t, err2)
+			}
+			return
+		}
+		recv <- au
+	})
+
+	start := 1700 * time.Millisecond
+
+	res, err := c.Play(&headers.Range{
+		Value: &headers.RangeNPT{
+			Start: start,
+		},
+	})
+	require.NoError(t, err)
+
+	var ra headers.Range
+	err = ra.Unmarshal(res.Header["Range"])
+	require.NoError(t, err)
+	require.Equal(t, &headers.RangeNPT{Start: 1500 * time.Millisecond}, ra.Value)
+
+	// playback starts from the random access unit that precedes the requested position
+	au := <-recv
+	require.Equal(t, []byte{5, 15}, au[len(au)-1])
+
+	au = <-recv
+	require.Equal(t, [][]byte{{1, 16}}, au)
+}
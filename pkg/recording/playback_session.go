@@ -0,0 +1,366 @@
+package recording
+
+import (
+	"context"
+	"crypto/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	mcmp4 "github.com/bluenviron/mediacommon/v2/pkg/formats/mp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/pmp4"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+type playbackEncoder interface {
+	Encode([][]byte) ([]*rtp.Packet, error)
+}
+
+type playbackSample struct {
+	media  int
+	sample *pmp4.Sample
+	pts    time.Duration
+	dts    time.Duration
+}
+
+// seekSegments returns the index of the segment that contains t
+// and the position of the random access unit that precedes t.
+func seekSegments(segs []*Segment, t time.Time) (int, time.Time) {
+	for i, seg := range segs {
+		if t.Before(seg.Start.Add(seg.Duration)) {
+			if t.Before(seg.Start) {
+				return i, seg.Start
+			}
+			return i, seg.Start.Add(seg.KeyFrameBefore(t))
+		}
+	}
+
+	return len(segs), t
+}
+
+// findMedia returns the index of the first unused media whose format matches the codec.
+// Since tracks without samples are not written into segments,
+// tracks can't be associated with medias by their index.
+func findMedia(desc *description.Session, codec mcmp4.Codec, used map[int]struct{}) int {
+	for i, medi := range desc.Medias {
+		if _, ok := used[i]; ok {
+			continue
+		}
+
+		switch medi.Formats[0].(type) {
+		case *format.H264:
+			if _, ok := codec.(*mcmp4.CodecH264); ok {
+				return i
+			}
+
+		case *format.H265:
+			if _, ok := codec.(*mcmp4.CodecH265); ok {
+				return i
+			}
+
+		case *format.MPEG4Audio:
+			if _, ok := codec.(*mcmp4.CodecMPEG4Audio); ok {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+type playbackSession struct {
+	h       *PlaybackHandler
+	session *gortsplib.ServerSession
+	path    string
+	desc    *description.Session
+	segs    []*Segment
+
+	stream    *gortsplib.ServerStream
+	mutex     sync.Mutex
+	position  time.Time
+	ctxCancel func()
+	done      chan struct{}
+}
+
+func (ps *playbackSession) initialize() error {
+	ps.stream = &gortsplib.ServerStream{
+		Server: ps.h.Server,
+		Desc:   ps.desc,
+	}
+	err := ps.stream.Initialize()
+	if err != nil {
+		return err
+	}
+
+	ps.position = ps.segs[0].Start
+
+	return nil
+}
+
+func (ps *playbackSession) close() {
+	ps.pause()
+	ps.stream.Close()
+}
+
+// play starts playback from the given position, or from the last position if nil.
+// It returns the actual starting position.
+func (ps *playbackSession) play(start *time.Time) time.Time {
+	ps.pause()
+
+	ps.mutex.Lock()
+	if start != nil {
+		ps.position = *start
+	}
+	segIndex, pos := seekSegments(ps.segs, ps.position)
+	ps.position = pos
+	ps.mutex.Unlock()
+
+	var ctx context.Context
+	ctx, ps.ctxCancel = context.WithCancel(context.Background())
+	ps.done = make(chan struct{})
+
+	go ps.run(ctx, segIndex, pos)
+
+	return pos
+}
+
+func (ps *playbackSession) pause() {
+	if ps.ctxCancel != nil {
+		ps.ctxCancel()
+		<-ps.done
+		ps.ctxCancel = nil
+	}
+}
+
+func (ps *playbackSession) run(ctx context.Context, segIndex int, start time.Time) {
+	defer close(ps.done)
+
+	err := ps.runInner(ctx, segIndex, start)
+	if err != nil && ctx.Err() == nil {
+		ps.session.Close()
+	}
+}
+
+func (ps *playbackSession) runInner(ctx context.Context, segIndex int, start time.Time) error {
+	encoders := make([]playbackEncoder, len(ps.desc.Medias))
+	rtpStarts := make([]uint32, len(ps.desc.Medias))
+
+	for i, medi := range ps.desc.Medias {
+		var err error
+
+		switch forma := medi.Formats[0].(type) {
+		case *format.H264:
+			encoders[i], err = forma.CreateEncoder()
+
+		case *format.H265:
+			encoders[i], err = forma.CreateEncoder()
+
+		case *format.MPEG4Audio:
+			encoders[i], err = forma.CreateEncoder()
+		}
+		if err != nil {
+			return err
+		}
+
+		rtpStarts[i], err = randUint32()
+		if err != nil {
+			return err
+		}
+	}
+
+	pr := &playbackReader{
+		ps:         ps,
+		ctx:        ctx,
+		encoders:   encoders,
+		rtpStarts:  rtpStarts,
+		wallStart:  time.Now(),
+		mediaStart: start,
+	}
+
+	segs := ps.segs
+	prevEnd := start
+
+	for i := segIndex; ; i++ {
+		if i >= len(segs) {
+			// look for segments that have been completed in the meanwhile
+			var newSegs []*Segment
+			for _, seg := range ps.h.Segments(ps.path) {
+				if len(segs) == 0 || seg.Start.After(segs[len(segs)-1].Start) {
+					newSegs = append(newSegs, seg)
+				}
+			}
+
+			if newSegs == nil {
+				return nil
+			}
+
+			segs = append(segs[:len(segs):len(segs)], newSegs...)
+		}
+
+		seg := segs[i]
+
+		// skip gaps between segments
+		if seg.Start.After(prevEnd) {
+			pr.skipped += seg.Start.Sub(prevEnd)
+		}
+
+		from := seg.Start
+		if i == segIndex && start.After(from) {
+			from = start
+		}
+
+		err := pr.readSegment(seg, from.Sub(seg.Start))
+		if err != nil {
+			return err
+		}
+
+		prevEnd = seg.Start.Add(seg.Duration)
+	}
+}
+
+type playbackReader struct {
+	ps         *playbackSession
+	ctx        context.Context
+	encoders   []playbackEncoder
+	rtpStarts  []uint32
+	wallStart  time.Time
+	mediaStart time.Time
+	skipped    time.Duration
+}
+
+func (pr *playbackReader) readSegment(seg *Segment, offset time.Duration) error {
+	f, pres, err := readPresentation(seg.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var samples []*playbackSample
+	used := make(map[int]struct{})
+
+	for _, track := range pres.Tracks {
+		i := findMedia(pr.ps.desc, track.Codec, used)
+		if i < 0 {
+			continue
+		}
+		used[i] = struct{}{}
+
+		_, isAudio := pr.ps.desc.Medias[i].Formats[0].(*format.MPEG4Audio)
+		started := false
+		dts := int64(track.TimeOffset)
+
+		for _, sample := range track.Samples {
+			pts := dts + int64(sample.PTSOffset)
+			ptsDur := mediatime.TimestampToDuration(pts, int(track.TimeScale))
+
+			// start from the first random access unit after the offset
+			if !started && ptsDur >= offset && (isAudio || !sample.IsNonSyncSample) {
+				started = true
+			}
+
+			if started {
+				samples = append(samples, &playbackSample{
+					media:  i,
+					sample: sample,
+					pts:    ptsDur,
+					dts:    mediatime.TimestampToDuration(dts, int(track.TimeScale)),
+				})
+			}
+
+			dts += int64(sample.Duration)
+		}
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].dts < samples[j].dts
+	})
+
+	for _, s := range samples {
+		err = pr.writeSample(seg, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pr *playbackReader) writeSample(seg *Segment, s *playbackSample) error {
+	dtsTime := seg.Start.Add(s.dts)
+	ptsTime := seg.Start.Add(s.pts)
+
+	// sleep until the sample has to be sent
+	wait := time.Until(pr.wallStart.Add(dtsTime.Sub(pr.mediaStart) - pr.skipped))
+	if wait > 0 {
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-pr.ctx.Done():
+			t.Stop()
+			return pr.ctx.Err()
+		}
+	} else if pr.ctx.Err() != nil {
+		return pr.ctx.Err()
+	}
+
+	payload, err := s.sample.GetPayload()
+	if err != nil {
+		return err
+	}
+
+	medi := pr.ps.desc.Medias[s.media]
+	forma := medi.Formats[0]
+
+	var au [][]byte
+
+	if _, ok := forma.(*format.MPEG4Audio); ok {
+		au = [][]byte{payload}
+	} else {
+		var avcc h264.AVCC
+		err = avcc.Unmarshal(payload)
+		if err != nil {
+			return err
+		}
+		au = avcc
+	}
+
+	pkts, err := pr.encoders[s.media].Encode(au)
+	if err != nil {
+		return err
+	}
+
+	// regenerate timestamps in order to make them continuous
+	ts := pr.rtpStarts[s.media] +
+		uint32(mediatime.DurationToTimestamp(ptsTime.Sub(pr.mediaStart)-pr.skipped, forma.ClockRate()))
+
+	for _, pkt := range pkts {
+		pkt.Timestamp += ts
+
+		// original time of the sample is used as NTP timestamp.
+		err = pr.ps.stream.WritePacketRTPWithNTP(medi, pkt, ptsTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	pr.ps.mutex.Lock()
+	pr.ps.position = dtsTime
+	pr.ps.mutex.Unlock()
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package recording
+
+import (
+	"time"
+)
+
+// Mark is an external event attached to a segment.
+type Mark struct {
+	// absolute time of the event.
+	Time time.Time
+
+	// label of the event.
+	Label string
+}
+
+// Segment is an entry of the index of a Manager.
+type Segment struct {
+	// path of the segment.
+	Path string
+
+	// absolute time of the first sample.
+	Start time.Time
+
+	// duration of the segment.
+	Duration time.Duration
+
+	// offsets of random access units of the leading track,
+	// relative to Start.
+	KeyFrames []time.Duration
+
+	// marks received while the segment was being written.
+	Marks []Mark
+}
+
+// Contains checks whether the segment contains the given absolute time.
+func (s *Segment) Contains(t time.Time) bool {
+	return !t.Before(s.Start) && t.Before(s.Start.Add(s.Duration))
+}
+
+// KeyFrameBefore returns the offset of the last random access unit
+// that precedes or is equal to the given absolute time.
+// It can be used to start playback from a given time.
+func (s *Segment) KeyFrameBefore(t time.Time) time.Duration {
+	offset := t.Sub(s.Start)
+	ret := time.Duration(0)
+
+	for _, kf := range s.KeyFrames {
+		if kf > offset {
+			break
+		}
+		ret = kf
+	}
+
+	return ret
+}
@@ -0,0 +1,132 @@
+package recording
+
+import (
+	"bufio"
+	"os"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/mp4"
+	"github.com/bluenviron/gortsplib/v5/pkg/mpegts"
+)
+
+// entry is an access unit, or a group of MPEG-4 Audio access units.
+type entry struct {
+	track        *Track
+	pts          int64
+	dts          int64
+	au           [][]byte
+	randomAccess bool
+}
+
+type segmentWriter interface {
+	initialize(path string, tracks []*Track) error
+	write(e *entry) error
+	close() error
+}
+
+type segmentWriterMP4 struct {
+	tracks map[*Track]*mp4.Track
+	rec    *mp4.Recorder
+}
+
+func (w *segmentWriterMP4) initialize(path string, tracks []*Track) error {
+	w.tracks = make(map[*Track]*mp4.Track)
+	mp4Tracks := make([]*mp4.Track, len(tracks))
+
+	for i, track := range tracks {
+		mp4Tracks[i] = &mp4.Track{Format: track.Format}
+		w.tracks[track] = mp4Tracks[i]
+	}
+
+	w.rec = &mp4.Recorder{
+		Tracks: mp4Tracks,
+		Path: func(_ time.Time) string {
+			return path
+		},
+	}
+	return w.rec.Initialize()
+}
+
+func (w *segmentWriterMP4) write(e *entry) error {
+	switch e.track.Format.(type) {
+	case *format.H264:
+		return w.rec.WriteH264(w.tracks[e.track], e.pts, e.au)
+
+	case *format.H265:
+		return w.rec.WriteH265(w.tracks[e.track], e.pts, e.au)
+
+	default: // *format.MPEG4Audio
+		return w.rec.WriteMPEG4Audio(w.tracks[e.track], e.pts, e.au)
+	}
+}
+
+func (w *segmentWriterMP4) close() error {
+	return w.rec.Close()
+}
+
+type segmentWriterMPEGTS struct {
+	f      *os.File
+	bw     *bufio.Writer
+	tracks map[*Track]*mpegts.Track
+	mux    *mpegts.Muxer
+}
+
+func (w *segmentWriterMPEGTS) initialize(path string, tracks []*Track) error {
+	var err error
+	w.f, err = os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w.bw = bufio.NewWriter(w.f)
+
+	w.tracks = make(map[*Track]*mpegts.Track)
+	tsTracks := make([]*mpegts.Track, len(tracks))
+
+	for i, track := range tracks {
+		tsTracks[i] = &mpegts.Track{Format: track.Format}
+		w.tracks[track] = tsTracks[i]
+	}
+
+	w.mux = &mpegts.Muxer{
+		W:      w.bw,
+		Tracks: tsTracks,
+	}
+	err = w.mux.Initialize()
+	if err != nil {
+		w.f.Close()
+		return err
+	}
+
+	err = w.mux.WriteTables()
+	if err != nil {
+		w.f.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (w *segmentWriterMPEGTS) write(e *entry) error {
+	switch e.track.Format.(type) {
+	case *format.H264:
+		return w.mux.WriteH264(w.tracks[e.track], e.pts, e.dts, e.au)
+
+	case *format.H265:
+		return w.mux.WriteH265(w.tracks[e.track], e.pts, e.dts, e.au)
+
+	default: // *format.MPEG4Audio
+		return w.mux.WriteMPEG4Audio(w.tracks[e.track], e.pts, e.au)
+	}
+}
+
+func (w *segmentWriterMPEGTS) close() error {
+	err := w.bw.Flush()
+	if err != nil {
+		w.f.Close()
+		return err
+	}
+
+	return w.f.Close()
+}
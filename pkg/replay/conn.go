@@ -0,0 +1,269 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+)
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string {
+	return "tcp"
+}
+
+func (replayAddr) String() string {
+	return "127.0.0.1:554"
+}
+
+// splitSegments splits a recorded byte stream into segments.
+// The first segment contains the bytes that precede the first response.
+// Each following segment starts with a response and contains all the bytes
+// that follow it, until the next response.
+func splitSegments(data []byte) ([][]byte, error) {
+	r := bytes.NewReader(data)
+	br := bufio.NewReader(r)
+	c := conn.NewConn(br, nil)
+
+	segments := [][]byte{nil}
+	start := 0
+	first := true
+
+	for {
+		pos := len(data) - r.Len() - br.Buffered()
+
+		what, err := c.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid recording at offset %d: %w", pos, err)
+		}
+
+		if _, ok := what.(*base.Response); ok {
+			if first {
+				segments[0] = data[:pos]
+				first = false
+			} else {
+				segments = append(segments, data[start:pos])
+			}
+			start = pos
+		}
+	}
+
+	if first {
+		segments[0] = data
+	} else {
+		segments = append(segments, data[start:])
+	}
+
+	return segments, nil
+}
+
+// Conn is a net.Conn that replays a recorded byte stream, sent by a server, to a client.
+//
+// The recording is split into segments, each made of a response and of all the bytes
+// (like interleaved frames) that follow it. The first segment is released when the client connects,
+// each following one is released when the client writes a request. Therefore the client
+// always receives the same bytes in the same order with respect to its requests,
+// independently of timing.
+//
+// Recordings must be made of sessions that use the TCP transport protocol.
+type Conn struct {
+	// recorded bytes sent by the server.
+	Data []byte
+
+	// keep the connection open after the recording is over,
+	// instead of returning io.EOF.
+	KeepOpen bool
+
+	// called when the client writes a request (optional).
+	OnRequest func(req *base.Request)
+
+	segments [][]byte
+	pw       *io.PipeWriter
+
+	mutex        sync.Mutex
+	cond         *sync.Cond
+	buf          []byte
+	released     int
+	closed       bool
+	readDeadline time.Time
+	timer        *time.Timer
+
+	done chan struct{}
+}
+
+// Initialize initializes Conn.
+func (c *Conn) Initialize() error {
+	if c.OnRequest == nil {
+		c.OnRequest = func(*base.Request) {}
+	}
+
+	var err error
+	c.segments, err = splitSegments(c.Data)
+	if err != nil {
+		return err
+	}
+
+	c.cond = sync.NewCond(&c.mutex)
+	c.done = make(chan struct{})
+
+	// release bytes that precede the first response
+	c.buf = append([]byte(nil), c.segments[0]...)
+	c.released = 1
+
+	var pr *io.PipeReader
+	pr, c.pw = io.Pipe()
+
+	go c.runRequestReader(pr)
+
+	return nil
+}
+
+// DialContext can be used as the DialContext function of a Client.
+func (c *Conn) DialContext(_ context.Context, _ string, _ string) (net.Conn, error) {
+	return c, nil
+}
+
+func (c *Conn) runRequestReader(pr *io.PipeReader) {
+	defer close(c.done)
+
+	rc := conn.NewConn(bufio.NewReader(pr), nil)
+
+	for {
+		what, err := rc.Read()
+		if err != nil {
+			return
+		}
+
+		if req, ok := what.(*base.Request); ok {
+			c.OnRequest(req)
+
+			c.mutex.Lock()
+			if c.released < len(c.segments) {
+				c.buf = append(c.buf, c.segments[c.released]...)
+				c.released++
+				c.cond.Broadcast()
+			}
+			c.mutex.Unlock()
+		}
+	}
+}
+
+// Read implements net.Conn.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for {
+		if c.closed {
+			return 0, net.ErrClosed
+		}
+
+		if len(c.buf) != 0 {
+			n := copy(p, c.buf)
+			c.buf = c.buf[n:]
+			return n, nil
+		}
+
+		if c.released == len(c.segments) && !c.KeepOpen {
+			return 0, io.EOF
+		}
+
+		if !c.readDeadline.IsZero() && !time.Now().Before(c.readDeadline) {
+			return 0, os.ErrDeadlineExceeded
+		}
+
+		c.cond.Wait()
+	}
+}
+
+// Write implements net.Conn.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	closed := c.closed
+	c.mutex.Unlock()
+
+	if closed {
+		return 0, net.ErrClosed
+	}
+
+	return c.pw.Write(p)
+}
+
+// Close implements net.Conn.
+func (c *Conn) Close() error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.closed = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.cond.Broadcast()
+	c.mutex.Unlock()
+
+	c.pw.Close()
+	<-c.done
+
+	return nil
+}
+
+// LocalAddr implements net.Conn.
+func (c *Conn) LocalAddr() net.Addr {
+	return replayAddr{}
+}
+
+// RemoteAddr implements net.Conn.
+func (c *Conn) RemoteAddr() net.Addr {
+	return replayAddr{}
+}
+
+// SetDeadline implements net.Conn.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.readDeadline = t
+
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+
+	if !t.IsZero() {
+		c.timer = time.AfterFunc(time.Until(t), func() {
+			c.mutex.Lock()
+			c.cond.Broadcast()
+			c.mutex.Unlock()
+		})
+	}
+
+	c.cond.Broadcast()
+
+	return nil
+}
+
+// SetWriteDeadline implements net.Conn.
+// Writes never block, therefore the deadline is ignored.
+func (c *Conn) SetWriteDeadline(_ time.Time) error {
+	return nil
+}
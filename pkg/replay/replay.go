@@ -0,0 +1,66 @@
+// Package replay contains utilities to feed recorded RTSP/RTP byte streams
+// into clients deterministically, in order to catch regressions against specific servers and cameras.
+package replay
+
+import (
+	"errors"
+	"io"
+	"net"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/pcap"
+)
+
+// FramesFromPCAP reads a capture written by pcap.Writer, for instance
+// through the PacketDump option of Client, and returns packets that are accepted by filter
+// as interleaved frames. The channel of each frame is the destination port of the datagram,
+// that is equal to the interleaved channel in captures of sessions that use the TCP transport protocol.
+// If filter is nil, all packets are returned.
+func FramesFromPCAP(r io.Reader, filter func(pkt *pcap.UDPPacket) bool) ([]*base.InterleavedFrame, error) {
+	pr := &pcap.Reader{R: r}
+	err := pr.Initialize()
+	if err != nil {
+		return nil, err
+	}
+
+	var frames []*base.InterleavedFrame
+
+	for {
+		pkt, err := pr.ReadUDP()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return frames, nil
+			}
+			return nil, err
+		}
+
+		if filter != nil && !filter(pkt) {
+			continue
+		}
+
+		frames = append(frames, &base.InterleavedFrame{
+			Channel: pkt.Dst.Port,
+			Payload: pkt.Payload,
+		})
+	}
+}
+
+// IncomingFrom returns a filter for FramesFromPCAP that accepts packets sent by the given IP.
+func IncomingFrom(ip net.IP) func(pkt *pcap.UDPPacket) bool {
+	return func(pkt *pcap.UDPPacket) bool {
+		return pkt.Src.IP.Equal(ip)
+	}
+}
+
+// AppendFrames appends interleaved frames to a recorded byte stream.
+func AppendFrames(data []byte, frames []*base.InterleavedFrame) ([]byte, error) {
+	for _, fr := range frames {
+		buf, err := fr.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, buf...)
+	}
+
+	return data, nil
+}
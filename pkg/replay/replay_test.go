@@ -0,0 +1,203 @@
+package replay
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/pcap"
+)
+
+func ptrOf[T any](v T) *T {
+	p := new(T)
+	*p = v
+	return p
+}
+
+type receivedPacket struct {
+	media  int
+	seqNum uint16
+	hasNTP bool
+}
+
+// replayFixture replays a recording with a Client and returns the received packets.
+func replayFixture(t *testing.T, data []byte, count int) ([]base.Method, []receivedPacket) {
+	var methods []base.Method
+
+	rc := &Conn{
+		Data:     data,
+		KeepOpen: true,
+		OnRequest: func(req *base.Request) {
+			methods = append(methods, req.Method)
+		},
+	}
+	err := rc.Initialize()
+	require.NoError(t, err)
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:554/stream")
+	require.NoError(t, err)
+
+	c := &gortsplib.Client{
+		Scheme:      u.Scheme,
+		Host:        u.Host,
+		Protocol:    ptrOf(gortsplib.ProtocolTCP),
+		DialContext: rc.DialContext,
+	}
+	err = c.Start()
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan receivedPacket, count)
+
+	c.OnPacketRTPAny(func(medi *description.Media, _ format.Format, pkt *rtp.Packet) {
+		_, hasNTP := c.PacketNTP(medi, pkt)
+		recv <- receivedPacket{
+			media:  indexOf(desc.Medias, medi),
+			seqNum: pkt.SequenceNumber,
+			hasNTP: hasNTP,
+		}
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	packets := make([]receivedPacket, count)
+
+	for i := range count {
+		select {
+		case packets[i] = <-recv:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d packets, expected %d", i, count)
+		}
+	}
+
+	return methods, packets
+}
+
+func indexOf(medias []*description.Media, medi *description.Media) int {
+	for i, m := range medias {
+		if m == medi {
+			return i
+		}
+	}
+	return -1
+}
+
+// corpus contains recordings that reproduce behaviors of real servers and cameras,
+// together with the expected outcome.
+var corpus = []struct {
+	name    string
+	packets []receivedPacket
+}{
+	{
+		"odd_interleaved_ids",
+		[]receivedPacket{{0, 100, false}, {0, 101, false}},
+	},
+	{
+		"no_cseq",
+		[]receivedPacket{{0, 200, false}},
+	},
+	{
+		"no_content_base",
+		[]receivedPacket{{0, 300, false}},
+	},
+	{
+		"absolute_controls",
+		[]receivedPacket{{0, 400, false}, {1, 500, false}},
+	},
+	{
+		// sender reports received before the SSRC is known are discarded
+		"sender_report_before_media",
+		[]receivedPacket{{0, 600, false}, {0, 601, false}},
+	},
+}
+
+func TestCorpus(t *testing.T) {
+	for _, ca := range corpus {
+		t.Run(ca.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "corpus", ca.name+".rtsp"))
+			require.NoError(t, err)
+
+			methods, packets := replayFixture(t, data, len(ca.packets))
+			require.Equal(t, base.Options, methods[0])
+			require.Equal(t, ca.packets, packets)
+		})
+	}
+}
+
+func TestConnDeterministic(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "corpus", "absolute_controls.rtsp"))
+	require.NoError(t, err)
+
+	for range 10 {
+		methods, packets := replayFixture(t, data, 2)
+		require.Equal(t, []base.Method{
+			base.Options,
+			base.Describe,
+			base.Setup,
+			base.Setup,
+			base.Play,
+		}, methods)
+		require.Equal(t, []receivedPacket{{0, 400, false}, {1, 500, false}}, packets)
+	}
+}
+
+func TestConnInvalidRecording(t *testing.T) {
+	rc := &Conn{
+		Data: []byte("RTSP/1.0 200 OK\r\nContent-Length: invalid\r\n\r\n"),
+	}
+	err := rc.Initialize()
+	require.Error(t, err)
+}
+
+func TestFramesFromPCAP(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := &pcap.Writer{W: &buf}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	server := net.ParseIP("192.168.1.2")
+	client := net.ParseIP("192.168.1.3")
+
+	// incoming RTP packet
+	err = w.WriteUDP(time.Now(),
+		&net.UDPAddr{IP: server, Port: 0},
+		&net.UDPAddr{IP: client, Port: 0},
+		[]byte{1, 2, 3, 4})
+	require.NoError(t, err)
+
+	// outgoing RTCP packet
+	err = w.WriteUDP(time.Now(),
+		&net.UDPAddr{IP: client, Port: 1},
+		&net.UDPAddr{IP: server, Port: 1},
+		[]byte{5, 6, 7, 8})
+	require.NoError(t, err)
+
+	frames, err := FramesFromPCAP(&buf, IncomingFrom(server))
+	require.NoError(t, err)
+	require.Equal(t, []*base.InterleavedFrame{{
+		Channel: 0,
+		Payload: []byte{1, 2, 3, 4},
+	}}, frames)
+
+	data, err := AppendFrames([]byte("RTSP/1.0 200 OK\r\n\r\n"), frames)
+	require.NoError(t, err)
+	require.Equal(t, []byte("RTSP/1.0 200 OK\r\n\r\n$\x00\x00\x04\x01\x02\x03\x04"), data)
+}
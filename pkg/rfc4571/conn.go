@@ -0,0 +1,87 @@
+package rfc4571
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pion/rtcp"
+)
+
+// conn contains the parts shared by Receiver and Sender.
+type conn struct {
+	nconn         net.Conn
+	onPacketRTP   func([]byte)
+	onPacketRTCP  func(rtcp.Packet)
+	onDecodeError func(error)
+
+	reader     *Reader
+	writer     *Writer
+	writeMutex sync.Mutex
+	err        error
+
+	done chan struct{}
+}
+
+func (c *conn) initialize() {
+	c.reader = &Reader{R: c.nconn}
+	c.reader.Initialize()
+	c.writer = &Writer{W: c.nconn}
+	c.done = make(chan struct{})
+}
+
+func (c *conn) start() {
+	go c.run()
+}
+
+func (c *conn) close() {
+	c.nconn.Close()
+	<-c.done
+}
+
+func (c *conn) wait() error {
+	<-c.done
+	return c.err
+}
+
+func (c *conn) run() {
+	defer close(c.done)
+
+	for {
+		buf, err := c.reader.Read()
+		if err != nil {
+			c.err = err
+			return
+		}
+
+		if !isRTCPPacket(buf) {
+			c.onPacketRTP(buf)
+			continue
+		}
+
+		pkts, err := rtcp.Unmarshal(buf)
+		if err != nil {
+			c.onDecodeError(err)
+			continue
+		}
+
+		for _, pkt := range pkts {
+			c.onPacketRTCP(pkt)
+		}
+	}
+}
+
+func (c *conn) write(buf []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	return c.writer.Write(buf)
+}
+
+func (c *conn) writePacketRTCP(pkt rtcp.Packet) error {
+	buf, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return c.write(buf)
+}
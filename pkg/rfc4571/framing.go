@@ -0,0 +1,62 @@
+package rfc4571
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// MaxPacketSize is the maximum size of a packet that can be framed.
+const MaxPacketSize = 0xFFFF
+
+// Reader reads packets framed with RFC4571.
+type Reader struct {
+	// underlying reader.
+	R io.Reader
+
+	br *bufio.Reader
+}
+
+// Initialize initializes Reader.
+func (r *Reader) Initialize() {
+	r.br = bufio.NewReader(r.R)
+}
+
+// Read reads a packet.
+func (r *Reader) Read() ([]byte, error) {
+	var header [2]byte
+	_, err := io.ReadFull(r.br, header[:])
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, int(header[0])<<8|int(header[1]))
+	_, err = io.ReadFull(r.br, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// Writer writes packets framed with RFC4571.
+type Writer struct {
+	// underlying writer.
+	W io.Writer
+}
+
+// Write writes a packet.
+// Header and packet are written with a single call to W.Write().
+func (w *Writer) Write(pkt []byte) error {
+	if len(pkt) > MaxPacketSize {
+		return fmt.Errorf("packet size (%d) is greater than maximum allowed (%d)", len(pkt), MaxPacketSize)
+	}
+
+	buf := make([]byte, 2+len(pkt))
+	buf[0] = byte(len(pkt) >> 8)
+	buf[1] = byte(len(pkt))
+	copy(buf[2:], pkt)
+
+	_, err := w.W.Write(buf)
+	return err
+}
@@ -0,0 +1,44 @@
+package rfc4571
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFraming(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := &Writer{W: &buf}
+
+	err := w.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = w.Write([]byte{4, 5})
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{0, 3, 1, 2, 3, 0, 2, 4, 5}, buf.Bytes())
+
+	r := &Reader{R: &buf}
+	r.Initialize()
+
+	pkt, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, pkt)
+
+	pkt, err = r.Read()
+	require.NoError(t, err)
+	require.Equal(t, []byte{4, 5}, pkt)
+
+	_, err = r.Read()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestWriterErrorTooBig(t *testing.T) {
+	w := &Writer{W: io.Discard}
+
+	err := w.Write(make([]byte, MaxPacketSize+1))
+	require.EqualError(t, err, "packet size (65536) is greater than maximum allowed (65535)")
+}
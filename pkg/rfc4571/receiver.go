@@ -0,0 +1,150 @@
+package rfc4571
+
+import (
+	"net"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtpreceiver"
+)
+
+// Receiver reads RTP packets framed with RFC4571 from a connection,
+// for instance the one opened by an encoder that pushes RTP/TCP to a fixed port,
+// and sends back RTCP receiver reports.
+type Receiver struct {
+	// connection.
+	Conn net.Conn
+
+	// format of the stream.
+	Format format.Format
+
+	// local SSRC, used in receiver reports.
+	// It defaults to a random value.
+	LocalSSRC *uint32
+
+	// period of RTCP receiver reports.
+	// It defaults to 10 seconds.
+	ReceiverReportPeriod time.Duration
+
+	// called when a RTP packet is received.
+	OnPacketRTP func(*rtp.Packet)
+
+	// called when a RTCP packet is received.
+	OnPacketRTCP func(rtcp.Packet)
+
+	// called when RTP packets are lost.
+	OnPacketsLost func(lost uint64)
+
+	// called when a non-fatal decode error occurs.
+	OnDecodeError func(error)
+
+	c           conn
+	rtpReceiver *rtpreceiver.Receiver
+}
+
+// Initialize initializes Receiver.
+func (r *Receiver) Initialize() error {
+	if r.LocalSSRC == nil {
+		v, err := randUint32()
+		if err != nil {
+			return err
+		}
+		r.LocalSSRC = &v
+	}
+	if r.ReceiverReportPeriod == 0 {
+		r.ReceiverReportPeriod = 10 * time.Second
+	}
+	if r.OnPacketRTP == nil {
+		r.OnPacketRTP = func(*rtp.Packet) {}
+	}
+	if r.OnPacketRTCP == nil {
+		r.OnPacketRTCP = func(rtcp.Packet) {}
+	}
+	if r.OnPacketsLost == nil {
+		r.OnPacketsLost = func(uint64) {}
+	}
+	if r.OnDecodeError == nil {
+		r.OnDecodeError = func(error) {}
+	}
+
+	r.c = conn{
+		nconn:         r.Conn,
+		onPacketRTP:   r.processPacketRTP,
+		onPacketRTCP:  r.processPacketRTCP,
+		onDecodeError: r.OnDecodeError,
+	}
+	r.c.initialize()
+
+	r.rtpReceiver = &rtpreceiver.Receiver{
+		ClockRate: r.Format.ClockRate(),
+		LocalSSRC: *r.LocalSSRC,
+		Period:    r.ReceiverReportPeriod,
+		WritePacketRTCP: func(pkt rtcp.Packet) {
+			r.c.writePacketRTCP(pkt) //nolint:errcheck
+		},
+	}
+	err := r.rtpReceiver.Initialize()
+	if err != nil {
+		return err
+	}
+
+	r.c.start()
+
+	return nil
+}
+
+// Close closes the connection and waits for all resources to be released.
+func (r *Receiver) Close() {
+	r.c.close()
+	r.rtpReceiver.Close()
+}
+
+// Wait waits until the connection is closed and returns the error that caused the closure.
+func (r *Receiver) Wait() error {
+	return r.c.wait()
+}
+
+// PacketNTP returns the NTP (absolute timestamp) of a RTP packet.
+// The NTP is computed from RTCP sender reports.
+func (r *Receiver) PacketNTP(pkt *rtp.Packet) (time.Time, bool) {
+	return r.rtpReceiver.PacketNTP(pkt.Timestamp)
+}
+
+// Stats returns statistics.
+func (r *Receiver) Stats() *rtpreceiver.Stats {
+	return r.rtpReceiver.Stats()
+}
+
+func (r *Receiver) processPacketRTP(buf []byte) {
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(buf)
+	if err != nil {
+		r.OnDecodeError(err)
+		return
+	}
+
+	pkts, lost, err := r.rtpReceiver.ProcessPacket(&pkt, time.Now(), r.Format.PTSEqualsDTS(&pkt))
+	if err != nil {
+		r.OnDecodeError(err)
+		return
+	}
+
+	if lost != 0 {
+		r.OnPacketsLost(lost)
+	}
+
+	for _, pkt := range pkts {
+		r.OnPacketRTP(pkt)
+	}
+}
+
+func (r *Receiver) processPacketRTCP(pkt rtcp.Packet) {
+	if sr, ok := pkt.(*rtcp.SenderReport); ok {
+		r.rtpReceiver.ProcessSenderReport(sr, time.Now())
+	}
+
+	r.OnPacketRTCP(pkt)
+}
@@ -0,0 +1,120 @@
+package rfc4571
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func TestReceiverSender(t *testing.T) {
+	conn1, conn2 := net.Pipe()
+
+	forma := &format.G711{
+		PayloadTyp:   8,
+		MULaw:        false,
+		SampleRate:   8000,
+		ChannelCount: 1,
+	}
+
+	recv := make(chan *rtp.Packet)
+	receiverReportRecv := make(chan struct{})
+
+	r := &Receiver{
+		Conn:                 conn1,
+		Format:               forma,
+		ReceiverReportPeriod: 100 * time.Millisecond,
+		OnPacketRTP: func(pkt *rtp.Packet) {
+			recv <- pkt
+		},
+	}
+	err := r.Initialize()
+	require.NoError(t, err)
+	defer r.Close()
+
+	s := &Sender{
+		Conn:               conn2,
+		Format:             forma,
+		SenderReportPeriod: 100 * time.Millisecond,
+		OnPacketRTCP: func(pkt rtcp.Packet) {
+			if _, ok := pkt.(*rtcp.ReceiverReport); ok {
+				select {
+				case receiverReportRecv <- struct{}{}:
+				default:
+				}
+			}
+		},
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	ntp := time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)
+
+	err = s.WritePacketRTPWithNTP(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    8,
+			SequenceNumber: 946,
+			Timestamp:      54352,
+			SSRC:           753621,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}, ntp)
+	require.NoError(t, err)
+
+	pkt := <-recv
+	require.Equal(t, uint16(946), pkt.SequenceNumber)
+	require.Equal(t, []byte{1, 2, 3, 4}, pkt.Payload)
+
+	<-receiverReportRecv
+
+	// wait for a sender report
+	for {
+		if _, ok := r.PacketNTP(pkt); ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = s.WritePacketRTPWithNTP(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    8,
+			SequenceNumber: 947,
+			Timestamp:      54352 + 8000,
+			SSRC:           753621,
+		},
+		Payload: []byte{5, 6, 7, 8},
+	}, ntp.Add(time.Second))
+	require.NoError(t, err)
+
+	pkt = <-recv
+	pktNTP, ok := r.PacketNTP(pkt)
+	require.True(t, ok)
+	require.WithinDuration(t, ntp.Add(time.Second), pktNTP, 50*time.Millisecond)
+
+	require.Equal(t, uint32(753621), r.Stats().RemoteSSRC)
+}
+
+func TestReceiverClose(t *testing.T) {
+	conn1, conn2 := net.Pipe()
+
+	r := &Receiver{
+		Conn:   conn1,
+		Format: &format.Opus{PayloadTyp: 96, ChannelCount: 2},
+	}
+	err := r.Initialize()
+	require.NoError(t, err)
+	defer r.Close()
+
+	conn2.Close()
+
+	err = r.Wait()
+	require.Error(t, err)
+}
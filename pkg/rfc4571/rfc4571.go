@@ -0,0 +1,22 @@
+// Package rfc4571 contains utilities to read and write RTP and RTCP packets
+// framed as described in RFC4571, that is, over a TCP connection without RTSP.
+package rfc4571
+
+import (
+	"crypto/rand"
+)
+
+func randUint32() (uint32, error) {
+	var b [4]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// isRTCPPacket checks whether a packet is a RTCP packet,
+// since RTP and RTCP packets are multiplexed on the same connection (RFC5761).
+func isRTCPPacket(payload []byte) bool {
+	return len(payload) >= 2 && (payload[0]>>6) == 2 && payload[1] >= 192 && payload[1] <= 223
+}
@@ -0,0 +1,107 @@
+package rfc4571
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtpsender"
+)
+
+// Sender writes RTP packets framed with RFC4571 into a connection,
+// for instance the one of a receiver that accepts RTP/TCP on a fixed port,
+// and sends RTCP sender reports.
+type Sender struct {
+	// connection.
+	Conn net.Conn
+
+	// format of the stream.
+	Format format.Format
+
+	// period of RTCP sender reports.
+	// It defaults to 10 seconds.
+	SenderReportPeriod time.Duration
+
+	// called when a RTCP packet is received.
+	OnPacketRTCP func(rtcp.Packet)
+
+	// called when a non-fatal decode error occurs.
+	OnDecodeError func(error)
+
+	c         conn
+	rtpSender *rtpsender.Sender
+}
+
+// Initialize initializes Sender.
+func (s *Sender) Initialize() error {
+	if s.SenderReportPeriod == 0 {
+		s.SenderReportPeriod = 10 * time.Second
+	}
+	if s.OnPacketRTCP == nil {
+		s.OnPacketRTCP = func(rtcp.Packet) {}
+	}
+	if s.OnDecodeError == nil {
+		s.OnDecodeError = func(error) {}
+	}
+
+	s.c = conn{
+		nconn: s.Conn,
+		onPacketRTP: func([]byte) {
+			s.OnDecodeError(fmt.Errorf("received unexpected RTP packet"))
+		},
+		onPacketRTCP:  s.OnPacketRTCP,
+		onDecodeError: s.OnDecodeError,
+	}
+	s.c.initialize()
+
+	s.rtpSender = &rtpsender.Sender{
+		ClockRate: s.Format.ClockRate(),
+		Period:    s.SenderReportPeriod,
+		WritePacketRTCP: func(pkt rtcp.Packet) {
+			s.c.writePacketRTCP(pkt) //nolint:errcheck
+		},
+	}
+	s.rtpSender.Initialize()
+
+	s.c.start()
+
+	return nil
+}
+
+// Close closes the connection and waits for all resources to be released.
+func (s *Sender) Close() {
+	s.c.close()
+	s.rtpSender.Close()
+}
+
+// Wait waits until the connection is closed and returns the error that caused the closure.
+func (s *Sender) Wait() error {
+	return s.c.wait()
+}
+
+// WritePacketRTP writes a RTP packet.
+func (s *Sender) WritePacketRTP(pkt *rtp.Packet) error {
+	return s.WritePacketRTPWithNTP(pkt, time.Now())
+}
+
+// WritePacketRTPWithNTP writes a RTP packet.
+// ntp is the absolute timestamp of the packet, and is sent to the receiver through sender reports.
+func (s *Sender) WritePacketRTPWithNTP(pkt *rtp.Packet, ntp time.Time) error {
+	buf, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	s.rtpSender.ProcessPacket(pkt, ntp, s.Format.PTSEqualsDTS(pkt))
+
+	return s.c.write(buf)
+}
+
+// Stats returns statistics.
+func (s *Sender) Stats() *rtpsender.Stats {
+	return s.rtpSender.Stats()
+}
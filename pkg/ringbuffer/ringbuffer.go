@@ -2,32 +2,45 @@
 package ringbuffer
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
 
-// RingBuffer is a ring buffer.
-type RingBuffer struct {
+// RingBuffer is a ring buffer of values of any type.
+type RingBuffer = Typed[any]
+
+// New allocates a RingBuffer.
+func New(size uint64) (*RingBuffer, error) {
+	return NewTyped[any](size)
+}
+
+// Typed is a ring buffer of values of type T.
+// Compared to RingBuffer, values are not boxed into interfaces,
+// therefore pushing them doesn't cause allocations and pulling them doesn't need type assertions.
+type Typed[T any] struct {
 	size       uint64
 	mutex      sync.Mutex
 	cond       *sync.Cond
-	buffer     []any
+	buffer     []T
 	readIndex  uint64
 	writeIndex uint64
+	count      uint64
 	closed     bool
+	overwrite  bool
 }
 
-// New allocates a RingBuffer.
-func New(size uint64) (*RingBuffer, error) {
+// NewTyped allocates a Typed.
+func NewTyped[T any](size uint64) (*Typed[T], error) {
 	// when writeIndex overflows, if size is not a power of
 	// two, only a portion of the buffer is used.
 	if (size & (size - 1)) != 0 {
 		return nil, fmt.Errorf("size must be a power of two")
 	}
 
-	r := &RingBuffer{
+	r := &Typed[T]{
 		size:   size,
-		buffer: make([]any, size),
+		buffer: make([]T, size),
 	}
 
 	r.cond = sync.NewCond(&r.mutex)
@@ -36,42 +49,115 @@ func New(size uint64) (*RingBuffer, error) {
 }
 
 // Close makes Pull() return false.
-func (r *RingBuffer) Close() {
+func (r *Typed[T]) Close() {
 	r.mutex.Lock()
 
 	r.closed = true
 
 	// discard pending data to make Pull() exit immediately
-	for i := uint64(0); i < r.size; i++ {
-		r.buffer[i] = nil
-	}
+	r.clear()
 
 	r.mutex.Unlock()
 	r.cond.Broadcast()
 }
 
 // Reset restores Pull() behavior after a Close().
-func (r *RingBuffer) Reset() {
-	for i := uint64(0); i < r.size; i++ {
-		r.buffer[i] = nil
-	}
+func (r *Typed[T]) Reset() {
+	r.clear()
 
 	r.writeIndex = 0
 	r.readIndex = 0
 	r.closed = false
 }
 
+func (r *Typed[T]) clear() {
+	var zero T
+	for i := uint64(0); i < r.size; i++ {
+		r.buffer[i] = zero
+	}
+	r.count = 0
+}
+
+// SetOverwrite enables or disables the overwrite mode.
+// When enabled, pushing data into a full buffer
+// replaces the oldest entry instead of failing.
+func (r *Typed[T]) SetOverwrite(v bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.overwrite = v
+}
+
+// Len returns the number of entries in the buffer.
+func (r *Typed[T]) Len() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.count
+}
+
+// Cap returns the maximum number of entries in the buffer.
+func (r *Typed[T]) Cap() uint64 {
+	return r.size
+}
+
 // Push pushes data at the end of the buffer.
-func (r *RingBuffer) Push(data any) bool {
+func (r *Typed[T]) Push(data T) bool {
 	r.mutex.Lock()
 
-	if r.buffer[r.writeIndex] != nil {
+	if r.count == r.size {
+		if !r.overwrite {
+			r.mutex.Unlock()
+			return false
+		}
+
+		// buffer is full, therefore writeIndex points to the oldest entry.
+		r.buffer[r.writeIndex] = data
+		r.writeIndex = (r.writeIndex + 1) % r.size
+		r.readIndex = r.writeIndex
+
 		r.mutex.Unlock()
-		return false
+		return true
 	}
 
 	r.buffer[r.writeIndex] = data
 	r.writeIndex = (r.writeIndex + 1) % r.size
+	r.count++
+
+	r.mutex.Unlock()
+
+	r.cond.Broadcast()
+
+	return true
+}
+
+// PushContext pushes data at the end of the buffer.
+// If the buffer is full, it waits until there's space.
+// It returns false when the buffer is closed or the context is canceled.
+// The overwrite mode is ignored.
+func (r *Typed[T]) PushContext(ctx context.Context, data T) bool {
+	stop := context.AfterFunc(ctx, func() {
+		// make sure that the pusher is waiting before waking it up
+		r.mutex.Lock()
+		r.mutex.Unlock() //nolint:staticcheck
+		r.cond.Broadcast()
+	})
+	defer stop()
+
+	r.mutex.Lock()
+
+	for r.count == r.size {
+		if r.closed || ctx.Err() != nil {
+			r.mutex.Unlock()
+			return false
+		}
+
+		r.cond.Wait()
+	}
+
+	r.buffer[r.writeIndex] = data
+	r.writeIndex = (r.writeIndex + 1) % r.size
+	r.count++
 
 	r.mutex.Unlock()
 
@@ -81,22 +167,49 @@ func (r *RingBuffer) Push(data any) bool {
 }
 
 // Pull pulls data from the beginning of the buffer.
-func (r *RingBuffer) Pull() (any, bool) {
-	for {
+func (r *Typed[T]) Pull() (T, bool) {
+	return r.pull(nil)
+}
+
+// PullContext pulls data from the beginning of the buffer.
+// It returns false when the buffer is closed or the context is canceled.
+func (r *Typed[T]) PullContext(ctx context.Context) (T, bool) {
+	stop := context.AfterFunc(ctx, func() {
+		// make sure that the puller is waiting before waking it up
 		r.mutex.Lock()
+		r.mutex.Unlock() //nolint:staticcheck
+		r.cond.Broadcast()
+	})
+	defer stop()
 
-		data := r.buffer[r.readIndex]
+	return r.pull(ctx)
+}
 
-		if data != nil {
-			r.buffer[r.readIndex] = nil
+func (r *Typed[T]) pull(ctx context.Context) (T, bool) {
+	for {
+		r.mutex.Lock()
+
+		if r.count != 0 {
+			data := r.buffer[r.readIndex]
+			var zero T
+			r.buffer[r.readIndex] = zero
 			r.readIndex = (r.readIndex + 1) % r.size
+			r.count--
+			full := r.count == r.size-1
 			r.mutex.Unlock()
+
+			// wake up pushers that are waiting for space
+			if full {
+				r.cond.Broadcast()
+			}
+
 			return data, true
 		}
 
-		if r.closed {
+		if r.closed || (ctx != nil && ctx.Err() != nil) {
 			r.mutex.Unlock()
-			return nil, false
+			var zero T
+			return zero, false
 		}
 
 		r.cond.Wait()
@@ -2,6 +2,7 @@ package ringbuffer
 
 import (
 	"bytes"
+	"context"
 	"testing"
 	"time"
 
@@ -94,6 +95,161 @@ func TestOverflow(t *testing.T) {
 	}
 }
 
+func TestLenCap(t *testing.T) {
+	r, err := New(4)
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, uint64(4), r.Cap())
+	require.Equal(t, uint64(0), r.Len())
+
+	for range 4 {
+		r.Push([]byte{1, 2, 3, 4})
+	}
+	require.Equal(t, uint64(4), r.Len())
+
+	_, ok := r.Pull()
+	require.Equal(t, true, ok)
+	require.Equal(t, uint64(3), r.Len())
+
+	r.Close()
+	require.Equal(t, uint64(0), r.Len())
+}
+
+func TestOverwrite(t *testing.T) {
+	r, err := New(4)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.SetOverwrite(true)
+
+	for i := range 6 {
+		ok := r.Push(i)
+		require.Equal(t, true, ok)
+	}
+
+	require.Equal(t, uint64(4), r.Len())
+
+	for i := 2; i < 6; i++ {
+		data, ok := r.Pull()
+		require.Equal(t, true, ok)
+		require.Equal(t, i, data)
+	}
+
+	require.Equal(t, uint64(0), r.Len())
+}
+
+func TestPullContext(t *testing.T) {
+	r, err := New(1024)
+	require.NoError(t, err)
+	defer r.Close()
+
+	ok := r.Push([]byte{1, 2, 3, 4})
+	require.Equal(t, true, ok)
+
+	data, ok := r.PullContext(context.Background())
+	require.Equal(t, true, ok)
+	require.Equal(t, []byte{1, 2, 3, 4}, data)
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, ok2 := r.PullContext(ctx)
+		require.Equal(t, false, ok2)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	ctxCancel()
+	<-done
+}
+
+func TestPushContext(t *testing.T) {
+	r, err := New(1)
+	require.NoError(t, err)
+	defer r.Close()
+
+	ok := r.PushContext(context.Background(), 1)
+	require.Equal(t, true, ok)
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	ctxCancel()
+
+	ok = r.PushContext(ctx, 2)
+	require.Equal(t, false, ok)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ok2 := r.PushContext(context.Background(), 3)
+		require.Equal(t, true, ok2)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, ok := r.Pull()
+	require.Equal(t, true, ok)
+	require.Equal(t, 1, data)
+	<-done
+
+	data, ok = r.Pull()
+	require.Equal(t, true, ok)
+	require.Equal(t, 3, data)
+}
+
+func TestTyped(t *testing.T) {
+	r, err := NewTyped[int](4)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// zero values are valid entries.
+	for i := range 4 {
+		ok := r.Push(i)
+		require.Equal(t, true, ok)
+	}
+
+	ok := r.Push(4)
+	require.Equal(t, false, ok)
+
+	for i := range 4 {
+		var v int
+		v, ok = r.Pull()
+		require.Equal(t, true, ok)
+		require.Equal(t, i, v)
+	}
+
+	r.Close()
+
+	_, ok = r.Pull()
+	require.Equal(t, false, ok)
+}
+
+func BenchmarkPushPullBoxed(b *testing.B) {
+	r, _ := New(1024)
+	defer r.Close()
+
+	b.ReportAllocs()
+
+	for i := range b.N {
+		r.Push(i + 1000)
+		v, _ := r.Pull()
+		_ = v.(int)
+	}
+}
+
+func BenchmarkPushPullTyped(b *testing.B) {
+	r, _ := NewTyped[int](1024)
+	defer r.Close()
+
+	b.ReportAllocs()
+
+	for i := range b.N {
+		r.Push(i + 1000)
+		r.Pull()
+	}
+}
+
 func BenchmarkPushPullContinuous(b *testing.B) {
 	r, _ := New(1024 * 8)
 	defer r.Close()
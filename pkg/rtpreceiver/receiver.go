@@ -12,7 +12,7 @@ import (
 )
 
 // Receiver is a utility to receive RTP packets. It is in charge of:
-// - removing packets with wrong SSRC
+// - removing packets with wrong SSRC, or resetting state when SSRC changes
 // - removing duplicate packets (when transport is unreliable)
 // - reordering packets (when transport is unrealiable)
 // - counting lost packets
@@ -35,18 +35,36 @@ type Receiver struct {
 	// Period of RTCP receiver reports.
 	Period time.Duration
 
+	// maximum fraction of the RTP bandwidth that can be used by RTCP receiver reports.
+	// When set, reports are skipped until RTP traffic since the last report is large enough,
+	// therefore low-bitrate streams receive reports less frequently than Period.
+	// It defaults to zero, that disables the limit.
+	BandwidthFraction float64
+
 	// time.Now function.
 	TimeNow func() time.Time
 
 	// Called when a RTCP receiver report is ready to be written.
 	WritePacketRTCP func(rtcp.Packet)
 
+	// Whether to accept a SSRC change.
+	// When enabled, packets with a different SSRC are not discarded;
+	// instead, sequence numbers, reorder buffer, jitter and sender report data are reset
+	// and the new SSRC is adopted.
+	// This is common when a camera restarts its encoder.
+	AllowSSRCChange bool
+
+	// Called when the SSRC changes and AllowSSRCChange is true.
+	// It is called before the packet with the new SSRC is returned.
+	OnSSRCChange func(prev uint32, cur uint32)
+
 	mutex sync.RWMutex
 
 	// data from RTP packets
 	firstRTPPacketReceived bool
 	timeInitialized        bool
 	buffer                 []*rtp.Packet
+	out                    []*rtp.Packet
 	absPos                 uint16
 	negativeCount          int
 	sequenceNumberCycles   uint16
@@ -57,13 +75,16 @@ type Receiver struct {
 	totalLost              uint32
 	totalLostSinceReport   uint32
 	totalSinceReport       uint32
+	bytesSinceReport       uint64
 	jitter                 float64
 
 	// data from RTCP packets
-	firstSenderReportReceived  bool
-	lastSenderReportTimeNTP    uint64
-	lastSenderReportTimeRTP    uint32
-	lastSenderReportTimeSystem time.Time
+	firstSenderReportReceived   bool
+	firstSenderReportTimeNTP    uint64
+	firstSenderReportTimeSystem time.Time
+	lastSenderReportTimeNTP     uint64
+	lastSenderReportTimeRTP     uint32
+	lastSenderReportTimeSystem  time.Time
 
 	terminate chan struct{}
 	done      chan struct{}
@@ -156,6 +177,13 @@ func (rr *Receiver) report() rtcp.Packet {
 		report.Reports[0].Delay = uint32(system.Sub(rr.lastSenderReportTimeSystem).Seconds() * 65536)
 	}
 
+	if rr.BandwidthFraction != 0 {
+		if float64(rr.bytesSinceReport)*rr.BandwidthFraction < float64(report.MarshalSize()) {
+			return nil
+		}
+		rr.bytesSinceReport = 0
+	}
+
 	rr.totalLostSinceReport = 0
 	rr.totalSinceReport = 0
 
@@ -164,32 +192,50 @@ func (rr *Receiver) report() rtcp.Packet {
 
 // ProcessPacket processes an incoming RTP packet.
 // It returns reordered packets and number of lost packets.
+// In order to avoid allocations, the returned slice is reused
+// and is valid until the next call to ProcessPacket.
 func (rr *Receiver) ProcessPacket(
 	pkt *rtp.Packet,
 	system time.Time,
 	ptsEqualsDTS bool,
 ) ([]*rtp.Packet, uint64, error) {
+	pkts, lost, prevSSRC, ssrcChanged, err := rr.processPacket(pkt, system, ptsEqualsDTS)
+
+	// call OnSSRCChange without holding the mutex,
+	// in order to allow the callback to call Stats() or PacketNTP().
+	if ssrcChanged && rr.OnSSRCChange != nil {
+		rr.OnSSRCChange(prevSSRC, pkt.SSRC)
+	}
+
+	return pkts, lost, err
+}
+
+func (rr *Receiver) processPacket(
+	pkt *rtp.Packet,
+	system time.Time,
+	ptsEqualsDTS bool,
+) ([]*rtp.Packet, uint64, uint32, bool, error) {
 	rr.mutex.Lock()
 	defer rr.mutex.Unlock()
 
+	rr.bytesSinceReport += uint64(pkt.MarshalSize())
+
 	// first packet
 	if !rr.firstRTPPacketReceived {
-		rr.firstRTPPacketReceived = true
-		rr.totalSinceReport = 1
-		rr.lastValidSeqNum = pkt.SequenceNumber
-		rr.remoteSSRC = pkt.SSRC
+		rr.processFirstPacket(pkt, system, ptsEqualsDTS)
+		return rr.single(pkt), 0, 0, false, nil
+	}
 
-		if ptsEqualsDTS {
-			rr.timeInitialized = true
-			rr.lastTimeRTP = pkt.Timestamp
-			rr.lastTimeSystem = system
+	if pkt.SSRC != rr.remoteSSRC {
+		if !rr.AllowSSRCChange {
+			return nil, 0, 0, false, fmt.Errorf("received packet with wrong SSRC %d, expected %d", pkt.SSRC, rr.remoteSSRC)
 		}
 
-		return []*rtp.Packet{pkt}, 0, nil
-	}
+		prev := rr.remoteSSRC
+		rr.reset()
+		rr.processFirstPacket(pkt, system, ptsEqualsDTS)
 
-	if pkt.SSRC != rr.remoteSSRC {
-		return nil, 0, fmt.Errorf("received packet with wrong SSRC %d, expected %d", pkt.SSRC, rr.remoteSSRC)
+		return rr.single(pkt), 0, prev, true, nil
 	}
 
 	var pkts []*rtp.Packet
@@ -198,7 +244,7 @@ func (rr *Receiver) ProcessPacket(
 	if rr.UnrealiableTransport {
 		pkts, lost = rr.reorder(pkt)
 	} else {
-		pkts = []*rtp.Packet{pkt}
+		pkts = rr.single(pkt)
 		lost = uint64(pkt.SequenceNumber - rr.lastValidSeqNum - 1)
 	}
 
@@ -242,7 +288,38 @@ func (rr *Receiver) ProcessPacket(
 		}
 	}
 
-	return pkts, lost, nil
+	return pkts, lost, 0, false, nil
+}
+
+func (rr *Receiver) processFirstPacket(pkt *rtp.Packet, system time.Time, ptsEqualsDTS bool) {
+	rr.firstRTPPacketReceived = true
+	rr.totalSinceReport = 1
+	rr.lastValidSeqNum = pkt.SequenceNumber
+	rr.remoteSSRC = pkt.SSRC
+
+	if ptsEqualsDTS {
+		rr.timeInitialized = true
+		rr.lastTimeRTP = pkt.Timestamp
+		rr.lastTimeSystem = system
+	}
+}
+
+// reset clears the state associated with the current SSRC.
+// Total lost packets are preserved since they refer to the whole session.
+func (rr *Receiver) reset() {
+	rr.firstRTPPacketReceived = false
+	rr.timeInitialized = false
+	rr.absPos = 0
+	rr.negativeCount = 0
+	rr.sequenceNumberCycles = 0
+	rr.jitter = 0
+	rr.totalLostSinceReport = 0
+	rr.totalSinceReport = 0
+	rr.firstSenderReportReceived = false
+
+	for i := range rr.buffer {
+		rr.buffer[i] = nil
+	}
 }
 
 func (rr *Receiver) reorder(pkt *rtp.Packet) ([]*rtp.Packet, uint64) {
@@ -265,7 +342,7 @@ func (rr *Receiver) reorder(pkt *rtp.Packet) ([]*rtp.Packet, uint64) {
 			}
 
 			// reset position.
-			return []*rtp.Packet{pkt}, 0
+			return rr.single(pkt), 0
 		}
 
 		return nil, 0
@@ -284,20 +361,19 @@ func (rr *Receiver) reorder(pkt *rtp.Packet) ([]*rtp.Packet, uint64) {
 			}
 		}
 
-		ret := make([]*rtp.Packet, n)
-		pos := 0
+		rr.out = rr.out[:0]
 
 		for i := uint16(0); i < uint16(len(rr.buffer)); i++ {
 			p := (rr.absPos + i) & (uint16(len(rr.buffer)) - 1)
 			if rr.buffer[p] != nil {
-				ret[pos], rr.buffer[p] = rr.buffer[p], nil
-				pos++
+				rr.out = append(rr.out, rr.buffer[p])
+				rr.buffer[p] = nil
 			}
 		}
 
-		ret[pos] = pkt
+		rr.out = append(rr.out, pkt)
 
-		return ret, uint64(int(relPos) - n + 1)
+		return rr.out, uint64(int(relPos) - n + 1)
 	}
 
 	// there's a missing packet
@@ -326,18 +402,24 @@ func (rr *Receiver) reorder(pkt *rtp.Packet) ([]*rtp.Packet, uint64) {
 		n++
 	}
 
-	ret := make([]*rtp.Packet, n)
-	ret[0] = pkt
+	rr.out = append(rr.out[:0], pkt)
 	rr.absPos++
 	rr.absPos &= (uint16(len(rr.buffer)) - 1)
 
 	for i := uint16(1); i < n; i++ {
-		ret[i], rr.buffer[rr.absPos] = rr.buffer[rr.absPos], nil
+		rr.out = append(rr.out, rr.buffer[rr.absPos])
+		rr.buffer[rr.absPos] = nil
 		rr.absPos++
 		rr.absPos &= (uint16(len(rr.buffer)) - 1)
 	}
 
-	return ret, 0
+	return rr.out, 0
+}
+
+// single returns a slice that contains a single packet.
+func (rr *Receiver) single(pkt *rtp.Packet) []*rtp.Packet {
+	rr.out = append(rr.out[:0], pkt)
+	return rr.out
 }
 
 // ProcessSenderReport processes an incoming RTCP sender report.
@@ -345,7 +427,12 @@ func (rr *Receiver) ProcessSenderReport(sr *rtcp.SenderReport, system time.Time)
 	rr.mutex.Lock()
 	defer rr.mutex.Unlock()
 
-	rr.firstSenderReportReceived = true
+	if !rr.firstSenderReportReceived {
+		rr.firstSenderReportReceived = true
+		rr.firstSenderReportTimeNTP = sr.NTPTime
+		rr.firstSenderReportTimeSystem = system
+	}
+
 	rr.lastSenderReportTimeNTP = sr.NTPTime
 	rr.lastSenderReportTimeRTP = sr.RTPTime
 	rr.lastSenderReportTimeSystem = system
@@ -370,6 +457,10 @@ func (rr *Receiver) PacketNTP(ts uint32) (time.Time, bool) {
 	return rr.packetNTPUnsafe(ts)
 }
 
+// clockDriftMinPeriod is the minimum period between sender reports
+// that is needed to estimate clock drift.
+const clockDriftMinPeriod = 10 * time.Second
+
 // Stats are statistics.
 type Stats struct {
 	RemoteSSRC         uint32
@@ -377,6 +468,40 @@ type Stats struct {
 	LastRTP            uint32
 	LastNTP            time.Time
 	Jitter             float64
+
+	// difference between the reception time of the last sender report and its NTP timestamp.
+	// It is the one-way delay plus the offset between the remote and the local clock.
+	// It is zero when no sender report has been received.
+	ClockOffset time.Duration
+
+	// drift of the remote clock with respect to the local clock, in parts per million,
+	// estimated by comparing sender reports.
+	// A positive value means that the remote clock is slower than the local one.
+	// It is zero when sender reports don't span enough time.
+	ClockDrift float64
+}
+
+func (rr *Receiver) clockOffsetUnsafe() time.Duration {
+	if !rr.firstSenderReportReceived {
+		return 0
+	}
+	return rr.lastSenderReportTimeSystem.Sub(ntp.Decode(rr.lastSenderReportTimeNTP))
+}
+
+func (rr *Receiver) clockDriftUnsafe() float64 {
+	if !rr.firstSenderReportReceived {
+		return 0
+	}
+
+	elapsed := rr.lastSenderReportTimeSystem.Sub(rr.firstSenderReportTimeSystem)
+	if elapsed < clockDriftMinPeriod {
+		return 0
+	}
+
+	firstOffset := rr.firstSenderReportTimeSystem.Sub(ntp.Decode(rr.firstSenderReportTimeNTP))
+	lastOffset := rr.clockOffsetUnsafe()
+
+	return (lastOffset - firstOffset).Seconds() / elapsed.Seconds() * 1e6
 }
 
 // Stats returns statistics.
@@ -396,5 +521,7 @@ func (rr *Receiver) Stats() *Stats {
 		LastRTP:            rr.lastTimeRTP,
 		LastNTP:            ntp,
 		Jitter:             rr.jitter,
+		ClockOffset:        rr.clockOffsetUnsafe(),
+		ClockDrift:         rr.clockDriftUnsafe(),
 	}
 }
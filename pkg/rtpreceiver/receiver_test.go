@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bluenviron/gortsplib/v5/pkg/ntp"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
@@ -59,6 +60,86 @@ func TestErrorDifferentSSRC(t *testing.T) {
 	require.EqualError(t, err, "received packet with wrong SSRC 754623214, expected 1434523")
 }
 
+func TestSSRCChange(t *testing.T) {
+	var prevSSRC, curSSRC, statsSSRC uint32
+
+	var rr *Receiver
+	rr = &Receiver{
+		ClockRate:            90000,
+		LocalSSRC:            0x65f83afb,
+		UnrealiableTransport: true,
+		Period:               500 * time.Millisecond,
+		AllowSSRCChange:      true,
+		OnSSRCChange: func(prev uint32, cur uint32) {
+			prevSSRC = prev
+			curSSRC = cur
+
+			// the callback is allowed to query the receiver
+			statsSSRC = rr.Stats().RemoteSSRC
+		},
+	}
+	err := rr.Initialize()
+	require.NoError(t, err)
+	defer rr.Close()
+
+	ts := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+	for _, seq := range []uint16{945, 946, 948} {
+		_, _, err = rr.ProcessPacket(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				Timestamp:      0xafb45733,
+				SSRC:           1434523,
+			},
+			Payload: []byte("\x00\x00"),
+		}, ts, true)
+		require.NoError(t, err)
+	}
+
+	// packet with a new SSRC and an unrelated sequence number must be
+	// returned immediately, instead of being discarded or buffered.
+	rtpPkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 12,
+			Timestamp:      0x1234,
+			SSRC:           754623214,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	pkts, lost, err := rr.ProcessPacket(rtpPkt, ts, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), lost)
+	require.Equal(t, []*rtp.Packet{rtpPkt}, pkts)
+	require.Equal(t, uint32(1434523), prevSSRC)
+	require.Equal(t, uint32(754623214), curSSRC)
+	require.Equal(t, uint32(754623214), statsSSRC)
+
+	rtpPkt = &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 13,
+			Timestamp:      0x1234,
+			SSRC:           754623214,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	pkts, lost, err = rr.ProcessPacket(rtpPkt, ts, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), lost)
+	require.Equal(t, []*rtp.Packet{rtpPkt}, pkts)
+
+	require.Equal(t, &Stats{
+		RemoteSSRC:         754623214,
+		LastSequenceNumber: 13,
+		LastRTP:            0x1234,
+	}, rr.Stats())
+}
+
 func TestStatsBeforeData(t *testing.T) {
 	rr := &Receiver{
 		ClockRate: 90000,
@@ -247,12 +328,14 @@ func TestZeroClockRate(t *testing.T) {
 	}
 	ts = time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
 	rr.ProcessSenderReport(&srPkt, ts)
+	clockOffset := ts.Sub(ntp.Decode(srPkt.NTPTime))
 
 	stats = rr.Stats()
 	require.Equal(t, &Stats{
 		RemoteSSRC:         0xba9da416,
 		LastRTP:            0xafb45733,
 		LastSequenceNumber: 945,
+		ClockOffset:        clockOffset,
 	}, stats)
 
 	rtpPkt = rtp.Packet{
@@ -296,6 +379,7 @@ func TestZeroClockRate(t *testing.T) {
 		RemoteSSRC:         0xba9da416,
 		LastRTP:            2947921603,
 		LastSequenceNumber: 947,
+		ClockOffset:        clockOffset,
 	}, stats)
 }
 
@@ -985,3 +1069,139 @@ func TestUnrealiableCustomBufferSize(t *testing.T) {
 		},
 	}}, out)
 }
+
+func TestBandwidthFraction(t *testing.T) {
+	rr := &Receiver{
+		ClockRate:         90000,
+		LocalSSRC:         0x65f83afb,
+		Period:            time.Hour,
+		BandwidthFraction: 0.05,
+		TimeNow: func() time.Time {
+			return time.Date(2008, 0o5, 20, 22, 15, 22, 0, time.UTC)
+		},
+		WritePacketRTCP: func(_ rtcp.Packet) {},
+	}
+	err := rr.Initialize()
+	require.NoError(t, err)
+	defer rr.Close()
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 945,
+			Timestamp:      0xafb45733,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	_, _, err = rr.ProcessPacket(&rtpPkt, time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC), true)
+	require.NoError(t, err)
+
+	require.Nil(t, rr.report())
+
+	rtpPkt.SequenceNumber++
+	rtpPkt.Payload = make([]byte, 700)
+	_, _, err = rr.ProcessPacket(&rtpPkt, time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC), true)
+	require.NoError(t, err)
+
+	require.NotNil(t, rr.report())
+	require.Nil(t, rr.report())
+}
+
+func TestClockOffsetAndDrift(t *testing.T) {
+	rr := &Receiver{
+		ClockRate: 90000,
+		LocalSSRC: 0x65f83afb,
+		Period:    time.Hour,
+	}
+	err := rr.Initialize()
+	require.NoError(t, err)
+	defer rr.Close()
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 945,
+			Timestamp:      0xafb45733,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	_, _, err = rr.ProcessPacket(&rtpPkt, time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC), true)
+	require.NoError(t, err)
+
+	stats := rr.Stats()
+	require.Equal(t, time.Duration(0), stats.ClockOffset)
+	require.Equal(t, float64(0), stats.ClockDrift)
+
+	rr.ProcessSenderReport(&rtcp.SenderReport{
+		SSRC:    0xba9da416,
+		NTPTime: ntp.Encode(time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)),
+		RTPTime: 0xafb45733,
+	}, time.Date(2008, 0o5, 20, 22, 15, 20, 100000000, time.UTC))
+
+	stats = rr.Stats()
+	require.Equal(t, 100*time.Millisecond, stats.ClockOffset)
+	require.Equal(t, float64(0), stats.ClockDrift)
+
+	rr.ProcessSenderReport(&rtcp.SenderReport{
+		SSRC:    0xba9da416,
+		NTPTime: ntp.Encode(time.Date(2008, 0o5, 20, 22, 15, 40, 0, time.UTC)),
+		RTPTime: 0xafb45733 + 20*90000,
+	}, time.Date(2008, 0o5, 20, 22, 15, 40, 120000000, time.UTC))
+
+	stats = rr.Stats()
+	require.Equal(t, 120*time.Millisecond, stats.ClockOffset)
+	require.InDelta(t, 1000, stats.ClockDrift, 1)
+}
+
+func BenchmarkProcessPacket(b *testing.B) {
+	for _, ca := range []string{"reliable", "unrealiable"} {
+		b.Run(ca, func(b *testing.B) {
+			rr := &Receiver{
+				ClockRate:            90000,
+				LocalSSRC:            0x65f83afb,
+				UnrealiableTransport: ca == "unrealiable",
+				Period:               500 * time.Millisecond,
+			}
+			err := rr.Initialize()
+			require.NoError(b, err)
+			defer rr.Close()
+
+			// simulate a stream of 10k packets/sec
+			ts := time.Date(2008, 0o5, 20, 22, 15, 20, 0, time.UTC)
+
+			pkts := make([]rtp.Packet, 1024)
+			for i := range pkts {
+				pkts[i] = rtp.Packet{
+					Header: rtp.Header{
+						Version:     2,
+						Marker:      true,
+						PayloadType: 96,
+						SSRC:        0xba9da416,
+					},
+					Payload: []byte{1, 2, 3, 4},
+				}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := range b.N {
+				pkt := &pkts[i%len(pkts)]
+				pkt.SequenceNumber = uint16(i)
+				pkt.Timestamp = uint32(i) * 9
+
+				var out []*rtp.Packet
+				out, _, err = rr.ProcessPacket(pkt, ts.Add(time.Duration(i)*100*time.Microsecond), true)
+				if err != nil || len(out) != 1 {
+					b.Fatal("unexpected result")
+				}
+			}
+		})
+	}
+}
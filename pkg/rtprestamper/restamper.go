@@ -0,0 +1,163 @@
+// Package rtprestamper contains a utility to rewrite sequence numbers and timestamps of RTP packets.
+package rtprestamper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+)
+
+// Restamper is a utility to rewrite sequence numbers and timestamps of RTP packets
+// of a track, in order to make them continuous across source restarts, gaps and switches.
+//
+// Packets are left untouched until a discontinuity is found.
+// After that, sequence numbers of outgoing packets continue from the last outgoing one,
+// and timestamps are advanced by the time elapsed since the last outgoing packet.
+type Restamper struct {
+	// clock rate of the track.
+	ClockRate int
+
+	// maximum difference between sequence numbers of consecutive packets.
+	// When it is exceeded, the source is considered restarted.
+	// It defaults to zero, that means that sequence number jumps are not detected.
+	MaxSequenceJump uint16
+
+	// maximum difference between the timestamp of a packet
+	// and the one expected from the timestamp of the previous packet and the elapsed time.
+	// When it is exceeded, the source is considered restarted.
+	// It defaults to zero, that means that timestamp jumps are not detected.
+	MaxTimestampJump time.Duration
+
+	// function used to obtain the current time.
+	// It defaults to time.Now.
+	TimeNow func() time.Time
+
+	mutex            sync.Mutex
+	maxTimestampJump int64
+	hasLast          bool
+	discontinuity    bool
+	lastInSeq        uint16
+	lastInTimestamp  uint32
+	lastOutSeq       uint16
+	lastOutTimestamp uint32
+	lastTime         time.Time
+	seqOffset        uint16
+	timestampOffset  uint32
+}
+
+// Initialize initializes Restamper.
+func (r *Restamper) Initialize() {
+	if r.TimeNow == nil {
+		r.TimeNow = time.Now
+	}
+
+	r.maxTimestampJump = mediatime.DurationToTimestamp(r.MaxTimestampJump, r.ClockRate)
+}
+
+// Reset signals that the source has been restarted or replaced.
+// Next packet is considered the continuation of the last outgoing one,
+// whatever its sequence number and timestamp.
+func (r *Restamper) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.discontinuity = true
+}
+
+// ContinueFrom makes next outgoing packets continue from the last outgoing packet of another Restamper.
+// It can be used when switching from a source to another one.
+func (r *Restamper) ContinueFrom(other *Restamper) {
+	other.mutex.Lock()
+	hasLast := other.hasLast
+	lastOutSeq := other.lastOutSeq
+	lastOutTimestamp := other.lastOutTimestamp
+	lastTime := other.lastTime
+	other.mutex.Unlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !hasLast {
+		return
+	}
+
+	r.hasLast = true
+	r.discontinuity = true
+	r.lastOutSeq = lastOutSeq
+	r.lastOutTimestamp = lastOutTimestamp
+	r.lastTime = lastTime
+}
+
+func (r *Restamper) isJump(pkt *rtp.Packet, now time.Time) bool {
+	if r.MaxSequenceJump != 0 {
+		diff := pkt.SequenceNumber - r.lastInSeq
+
+		// allow reordering in both directions
+		if diff > r.MaxSequenceJump && -diff > r.MaxSequenceJump {
+			return true
+		}
+	}
+
+	if r.maxTimestampJump != 0 {
+		expected := r.lastInTimestamp + uint32(mediatime.DurationToTimestamp(now.Sub(r.lastTime), r.ClockRate))
+		diff := int64(int32(pkt.Timestamp - expected))
+
+		if diff > r.maxTimestampJump || diff < -r.maxTimestampJump {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Process rewrites sequence number and timestamp of a packet.
+// The input packet is not modified; when rewriting is needed, a copy is returned.
+func (r *Restamper) Process(pkt *rtp.Packet) *rtp.Packet {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.TimeNow()
+
+	if r.hasLast && (r.discontinuity || r.isJump(pkt, now)) {
+		elapsed := uint32(mediatime.DurationToTimestamp(now.Sub(r.lastTime), r.ClockRate))
+
+		r.seqOffset = r.lastOutSeq + 1 - pkt.SequenceNumber
+		r.timestampOffset = r.lastOutTimestamp + elapsed - pkt.Timestamp
+		r.discontinuity = false
+	}
+
+	r.hasLast = true
+	r.lastInSeq = pkt.SequenceNumber
+	r.lastInTimestamp = pkt.Timestamp
+	r.lastTime = now
+
+	if r.seqOffset != 0 || r.timestampOffset != 0 {
+		pkt2 := *pkt
+		pkt2.SequenceNumber += r.seqOffset
+		pkt2.Timestamp += r.timestampOffset
+		pkt = &pkt2
+	}
+
+	r.lastOutSeq = pkt.SequenceNumber
+	r.lastOutTimestamp = pkt.Timestamp
+
+	return pkt
+}
+
+// ProcessTimestamp rewrites a timestamp that refers to the same clock of processed packets,
+// like the one of a RTCP sender report.
+// It returns false when the timestamp can't be rewritten yet,
+// since no packet has been processed after a discontinuity.
+func (r *Restamper) ProcessTimestamp(ts uint32) (uint32, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.discontinuity {
+		return 0, false
+	}
+
+	return ts + r.timestampOffset, true
+}
@@ -0,0 +1,141 @@
+package rtprestamper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func testPacket(seq uint16, ts uint32) *rtp.Packet {
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: seq,
+			Timestamp:      ts,
+			SSRC:           0x38F27A2F,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}
+}
+
+func TestRestamper(t *testing.T) {
+	curTime := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	r := &Restamper{
+		ClockRate:        90000,
+		MaxSequenceJump:  1000,
+		MaxTimestampJump: 5 * time.Second,
+		TimeNow:          func() time.Time { return curTime },
+	}
+	r.Initialize()
+
+	in := testPacket(100, 90000)
+	out := r.Process(in)
+	require.Same(t, in, out)
+
+	curTime = curTime.Add(time.Second)
+	out = r.Process(testPacket(101, 180000))
+	require.Equal(t, uint16(101), out.SequenceNumber)
+	require.Equal(t, uint32(180000), out.Timestamp)
+
+	// reordered packet
+	out = r.Process(testPacket(99, 177000))
+	require.Equal(t, uint16(99), out.SequenceNumber)
+	require.Equal(t, uint32(177000), out.Timestamp)
+
+	// sequence number jump
+	curTime = curTime.Add(time.Second)
+	in = testPacket(30000, 270000)
+	out = r.Process(in)
+	require.NotSame(t, in, out)
+	require.Equal(t, uint16(30000), in.SequenceNumber)
+	require.Equal(t, uint16(100), out.SequenceNumber)
+	require.Equal(t, uint32(177000+90000), out.Timestamp)
+	require.Equal(t, []byte{1, 2, 3, 4}, out.Payload)
+
+	curTime = curTime.Add(time.Second)
+	out = r.Process(testPacket(30001, 360000))
+	require.Equal(t, uint16(101), out.SequenceNumber)
+	require.Equal(t, uint32(177000+180000), out.Timestamp)
+
+	// timestamp jump
+	curTime = curTime.Add(time.Second)
+	out = r.Process(testPacket(30002, 4000000000))
+	require.Equal(t, uint16(102), out.SequenceNumber)
+	require.Equal(t, uint32(177000+270000), out.Timestamp)
+
+	// explicit reset
+	r.Reset()
+	curTime = curTime.Add(500 * time.Millisecond)
+	out = r.Process(testPacket(30003, 4000045000))
+	require.Equal(t, uint16(103), out.SequenceNumber)
+	require.Equal(t, uint32(177000+315000), out.Timestamp)
+}
+
+func TestRestamperContinueFrom(t *testing.T) {
+	curTime := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+	timeNow := func() time.Time { return curTime }
+
+	r1 := &Restamper{
+		ClockRate: 90000,
+		TimeNow:   timeNow,
+	}
+	r1.Initialize()
+
+	r2 := &Restamper{
+		ClockRate: 90000,
+		TimeNow:   timeNow,
+	}
+	r2.Initialize()
+
+	// nothing to continue from
+	r2.ContinueFrom(r1)
+
+	out := r1.Process(testPacket(65535, 4294967000))
+	require.Equal(t, uint16(65535), out.SequenceNumber)
+	require.Equal(t, uint32(4294967000), out.Timestamp)
+
+	r2.ContinueFrom(r1)
+
+	curTime = curTime.Add(time.Second)
+	out = r2.Process(testPacket(500, 1000))
+	require.Equal(t, uint16(0), out.SequenceNumber)
+	require.Equal(t, uint32(4294967000+90000-4294967296), out.Timestamp)
+
+	// jumps are not detected when thresholds are not set
+	curTime = curTime.Add(time.Second)
+	out = r2.Process(testPacket(20000, 500000000))
+	require.Equal(t, uint16(19500), out.SequenceNumber)
+	require.Equal(t, uint32(4294967000+90000+500000000-1000-4294967296), out.Timestamp)
+}
+
+func TestRestamperProcessTimestamp(t *testing.T) {
+	curTime := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	r := &Restamper{
+		ClockRate: 90000,
+		TimeNow:   func() time.Time { return curTime },
+	}
+	r.Initialize()
+
+	ts, ok := r.ProcessTimestamp(1000)
+	require.True(t, ok)
+	require.Equal(t, uint32(1000), ts)
+
+	r.Process(testPacket(100, 90000))
+	r.Reset()
+
+	// offset is not known until next packet
+	_, ok = r.ProcessTimestamp(1000)
+	require.False(t, ok)
+
+	curTime = curTime.Add(time.Second)
+	r.Process(testPacket(500, 1000))
+
+	ts, ok = r.ProcessTimestamp(1500)
+	require.True(t, ok)
+	require.Equal(t, uint32(90000+90000+500), ts)
+}
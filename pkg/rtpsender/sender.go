@@ -18,6 +18,12 @@ type Sender struct {
 	TimeNow         func() time.Time
 	WritePacketRTCP func(rtcp.Packet)
 
+	// maximum fraction of the RTP bandwidth that can be used by RTCP sender reports.
+	// When set, reports are skipped until RTP traffic since the last report is large enough,
+	// therefore low-bitrate streams receive reports less frequently than Period.
+	// It defaults to zero, that disables the limit.
+	BandwidthFraction float64
+
 	mutex sync.RWMutex
 
 	// data from RTP packets
@@ -29,6 +35,7 @@ type Sender struct {
 	lastSequenceNumber uint16
 	packetCount        uint32
 	octetCount         uint32
+	bytesSinceReport   uint64
 
 	terminate chan struct{}
 	done      chan struct{}
@@ -73,8 +80,8 @@ func (rs *Sender) run() {
 }
 
 func (rs *Sender) report() rtcp.Packet {
-	rs.mutex.RLock()
-	defer rs.mutex.RUnlock()
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
 
 	if !rs.firstRTPPacketSent || rs.ClockRate == 0 {
 		return nil
@@ -84,13 +91,22 @@ func (rs *Sender) report() rtcp.Packet {
 	ntpTime := rs.lastTimeNTP.Add(systemTimeDiff)
 	rtpTime := rs.lastTimeRTP + uint32(systemTimeDiff.Seconds()*float64(rs.ClockRate))
 
-	return &rtcp.SenderReport{
+	report := &rtcp.SenderReport{
 		SSRC:        rs.localSSRC,
 		NTPTime:     ntp.Encode(ntpTime),
 		RTPTime:     rtpTime,
 		PacketCount: rs.packetCount,
 		OctetCount:  rs.octetCount,
 	}
+
+	if rs.BandwidthFraction != 0 {
+		if float64(rs.bytesSinceReport)*rs.BandwidthFraction < float64(report.MarshalSize()) {
+			return nil
+		}
+		rs.bytesSinceReport = 0
+	}
+
+	return report
 }
 
 // ProcessPacket extracts data from RTP packets.
@@ -110,6 +126,7 @@ func (rs *Sender) ProcessPacket(pkt *rtp.Packet, ntp time.Time, ptsEqualsDTS boo
 
 	rs.packetCount++
 	rs.octetCount += uint32(len(pkt.Payload))
+	rs.bytesSinceReport += uint64(pkt.MarshalSize())
 }
 
 // Stats are statistics.
@@ -165,3 +165,39 @@ func TestSenderZeroClockRate(t *testing.T) {
 		LastNTP:            time.Date(2008, time.May, 20, 22, 15, 20, 0, time.UTC),
 	}, stats)
 }
+
+func TestSenderBandwidthFraction(t *testing.T) {
+	rs := &Sender{
+		ClockRate:         90000,
+		Period:            time.Hour,
+		BandwidthFraction: 0.05,
+		TimeNow: func() time.Time {
+			return time.Date(2008, 5, 20, 22, 16, 20, 0, time.UTC)
+		},
+		WritePacketRTCP: func(_ rtcp.Packet) {},
+	}
+	rs.Initialize()
+	defer rs.Close()
+
+	rtpPkt := rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 946,
+			Timestamp:      1287987768,
+			SSRC:           0xba9da416,
+		},
+		Payload: []byte("\x00\x00"),
+	}
+	rs.ProcessPacket(&rtpPkt, time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC), true)
+
+	require.Nil(t, rs.report())
+
+	rtpPkt.SequenceNumber++
+	rtpPkt.Payload = make([]byte, 600)
+	rs.ProcessPacket(&rtpPkt, time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC), true)
+
+	require.NotNil(t, rs.report())
+	require.Nil(t, rs.report())
+}
@@ -0,0 +1,319 @@
+package rtsptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+// MockClient is a RTSP client test double.
+// It sends requests exactly as they are scripted, without enforcing any state machine,
+// therefore it can be used to test the behavior of servers with unexpected sequences of requests.
+// Medias are setupped with the TCP transport protocol.
+type MockClient struct {
+	// URL of the stream.
+	URL string
+
+	// timeout of read and write operations.
+	// It defaults to 10 seconds.
+	Timeout time.Duration
+
+	u         *base.URL
+	nconn     net.Conn
+	conn      *conn.Conn
+	cseq      int
+	session   string
+	announced bool
+	frames    []*base.InterleavedFrame
+}
+
+// Initialize initializes MockClient and connects to the server.
+func (c *MockClient) Initialize() error {
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	var err error
+	c.u, err = base.ParseURL(c.URL)
+	if err != nil {
+		return err
+	}
+
+	host := c.u.Host
+	if c.u.Port() == "" {
+		host = net.JoinHostPort(c.u.Hostname(), "554")
+	}
+
+	c.nconn, err = net.DialTimeout("tcp", host, c.Timeout)
+	if err != nil {
+		return err
+	}
+
+	c.conn = conn.NewConn(bufio.NewReader(c.nconn), c.nconn)
+
+	return nil
+}
+
+// Close closes the connection, without sending a TEARDOWN request.
+func (c *MockClient) Close() {
+	c.nconn.Close()
+}
+
+// Session returns the session ID received from the server.
+func (c *MockClient) Session() string {
+	return c.session
+}
+
+// WriteRaw writes raw bytes to the connection.
+// It can be used to send malformed requests.
+func (c *MockClient) WriteRaw(byts []byte) error {
+	c.nconn.SetWriteDeadline(time.Now().Add(c.Timeout)) //nolint:errcheck
+	_, err := c.nconn.Write(byts)
+	return err
+}
+
+// Do sends a request and waits for the response.
+// CSeq and Session headers are added when they are missing.
+// Interleaved frames received in the meanwhile are kept and returned by ReadPacketRTP.
+func (c *MockClient) Do(req *base.Request) (*base.Response, error) {
+	if req.Header == nil {
+		req.Header = make(base.Header)
+	}
+
+	if _, ok := req.Header["CSeq"]; !ok {
+		c.cseq++
+		req.Header["CSeq"] = base.HeaderValue{strconv.FormatInt(int64(c.cseq), 10)}
+	}
+
+	if _, ok := req.Header["Session"]; !ok && c.session != "" {
+		req.Header["Session"] = headers.Session{Session: c.session}.Marshal()
+	}
+
+	c.nconn.SetWriteDeadline(time.Now().Add(c.Timeout)) //nolint:errcheck
+	err := c.conn.WriteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.readResponse()
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Equal(res.Header["CSeq"], req.Header["CSeq"]) {
+		return nil, fmt.Errorf("CSeq of response (%v) doesn't match the one of the request (%v)",
+			res.Header["CSeq"], req.Header["CSeq"])
+	}
+
+	if v, ok := res.Header["Session"]; ok {
+		var sx headers.Session
+		err = sx.Unmarshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Session header: %w", err)
+		}
+		c.session = sx.Session
+	}
+
+	return res, nil
+}
+
+func (c *MockClient) readResponse() (*base.Response, error) {
+	for {
+		c.nconn.SetReadDeadline(time.Now().Add(c.Timeout)) //nolint:errcheck
+		what, err := c.conn.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		switch what := what.(type) {
+		case *base.Response:
+			return what, nil
+
+		case *base.InterleavedFrame:
+			c.frames = append(c.frames, what)
+		}
+	}
+}
+
+// Options sends an OPTIONS request.
+func (c *MockClient) Options() (*base.Response, error) {
+	return c.Do(&base.Request{
+		Method: base.Options,
+		URL:    c.u,
+	})
+}
+
+// Describe sends a DESCRIBE request and decodes the description.
+func (c *MockClient) Describe() (*description.Session, *base.Response, error) {
+	res, err := c.Do(&base.Request{
+		Method: base.Describe,
+		URL:    c.u,
+		Header: base.Header{
+			"Accept": base.HeaderValue{"application/sdp"},
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != base.StatusOK {
+		return nil, res, nil
+	}
+
+	var ssd sdp.SessionDescription
+	err = ssd.Unmarshal(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var desc description.Session
+	err = desc.Unmarshal(&ssd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	control, _ := ssd.Attribute("control")
+
+	desc.BaseURL, err = base.ResolveBaseURL(c.u, control, res)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &desc, res, nil
+}
+
+// Announce sends an ANNOUNCE request with the given description.
+// Controls of medias are filled automatically.
+func (c *MockClient) Announce(desc *description.Session) (*base.Response, error) {
+	desc.BaseURL = c.u
+
+	for i, medi := range desc.Medias {
+		medi.Control = "trackID=" + strconv.FormatInt(int64(i), 10)
+	}
+
+	byts, err := desc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	c.announced = true
+
+	return c.Do(&base.Request{
+		Method: base.Announce,
+		URL:    c.u,
+		Header: base.Header{
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: byts,
+	})
+}
+
+// Setup sends a SETUP request for a media of a description,
+// obtained with Describe or passed to Announce.
+// After Announce, medias are setupped in record mode.
+// Interleaved channels are assigned according to the media index.
+func (c *MockClient) Setup(desc *description.Session, mediaIndex int) (*base.Response, error) {
+	u, err := base.ResolveControl(desc.BaseURL, desc.Medias[mediaIndex].Control)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := headers.TransportModePlay
+	if c.announced {
+		mode = headers.TransportModeRecord
+	}
+
+	return c.Do(&base.Request{
+		Method: base.Setup,
+		URL:    u,
+		Header: base.Header{
+			"Transport": headers.Transport{
+				Protocol:       headers.TransportProtocolTCP,
+				Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+				Mode:           &mode,
+				InterleavedIDs: &[2]int{mediaIndex * 2, mediaIndex*2 + 1},
+			}.Marshal(),
+		},
+	})
+}
+
+// Play sends a PLAY request.
+func (c *MockClient) Play() (*base.Response, error) {
+	return c.Do(&base.Request{
+		Method: base.Play,
+		URL:    c.u,
+	})
+}
+
+// Record sends a RECORD request.
+func (c *MockClient) Record() (*base.Response, error) {
+	return c.Do(&base.Request{
+		Method: base.Record,
+		URL:    c.u,
+	})
+}
+
+// Teardown sends a TEARDOWN request.
+func (c *MockClient) Teardown() (*base.Response, error) {
+	return c.Do(&base.Request{
+		Method: base.Teardown,
+		URL:    c.u,
+	})
+}
+
+// WritePacketRTP writes a RTP packet of a media.
+func (c *MockClient) WritePacketRTP(mediaIndex int, pkt *rtp.Packet) error {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	c.nconn.SetWriteDeadline(time.Now().Add(c.Timeout)) //nolint:errcheck
+	return c.conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: mediaIndex * 2,
+		Payload: byts,
+	}, make([]byte, 4+len(byts)))
+}
+
+// ReadPacketRTP reads a RTP packet and returns it with the index of its media.
+// RTCP packets are discarded.
+func (c *MockClient) ReadPacketRTP() (int, *rtp.Packet, error) {
+	for {
+		var fr *base.InterleavedFrame
+
+		if len(c.frames) != 0 {
+			fr = c.frames[0]
+			c.frames = c.frames[1:]
+		} else {
+			c.nconn.SetReadDeadline(time.Now().Add(c.Timeout)) //nolint:errcheck
+			var err error
+			fr, err = c.conn.ReadInterleavedFrame()
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+
+		if (fr.Channel % 2) != 0 {
+			continue
+		}
+
+		var pkt rtp.Packet
+		err := pkt.Unmarshal(fr.Payload)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		return fr.Channel / 2, &pkt, nil
+	}
+}
@@ -0,0 +1,143 @@
+package rtsptest
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func TestMockClientRead(t *testing.T) {
+	s := &MockServer{}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c := &MockClient{
+		URL: "rtsp://" + s.Addr() + "/stream",
+	}
+	err = c.Initialize()
+	require.NoError(t, err)
+	defer c.Close()
+
+	// PLAY before SETUP is refused by the state machine
+	res, err := c.Play()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusMethodNotValidInThisState, res.StatusCode)
+
+	desc, res, err := c.Describe()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	res, err = c.Setup(desc, 0)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.NotEmpty(t, c.Session())
+
+	res, err = c.Play()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	err = s.WritePacketRTP(0, &testRTPPacket)
+	require.NoError(t, err)
+
+	mediaIndex, pkt, err := c.ReadPacketRTP()
+	require.NoError(t, err)
+	require.Equal(t, 0, mediaIndex)
+	require.Equal(t, testRTPPacket.SequenceNumber, pkt.SequenceNumber)
+	require.Equal(t, testRTPPacket.Payload, pkt.Payload)
+
+	res, err = c.Teardown()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+}
+
+func TestMockClientPublish(t *testing.T) {
+	recv := make(chan int)
+
+	s := &MockServer{
+		OnPacketRTP: func(mediaIndex int, _ *rtp.Packet) {
+			recv <- mediaIndex
+		},
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c := &MockClient{
+		URL: "rtsp://" + s.Addr() + "/stream",
+	}
+	err = c.Initialize()
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc := &description.Session{
+		Medias: []*description.Media{
+			{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					PacketizationMode: 1,
+				}},
+			},
+			{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{&format.G711{
+					PayloadTyp:   8,
+					SampleRate:   8000,
+					ChannelCount: 1,
+				}},
+			},
+		},
+	}
+
+	res, err := c.Announce(desc)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	res, err = c.Setup(desc, 0)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	// RECORD before all medias are setupped is refused by the state machine
+	res, err = c.Record()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusMethodNotValidInThisState, res.StatusCode)
+
+	res, err = c.Setup(desc, 1)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	res, err = c.Record()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	err = c.WritePacketRTP(1, &testRTPPacket)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, <-recv)
+}
+
+func TestMockClientMalformedRequest(t *testing.T) {
+	s := &MockServer{}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c := &MockClient{
+		URL: "rtsp://" + s.Addr() + "/stream",
+	}
+	err = c.Initialize()
+	require.NoError(t, err)
+	defer c.Close()
+
+	err = c.WriteRaw([]byte("OPTIONS rtsp://localhost RTSP/9.9\r\n\r\n"))
+	require.NoError(t, err)
+
+	_, err = c.Options()
+	require.Error(t, err)
+}
@@ -0,0 +1,568 @@
+package rtsptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+// MockFaultType is the type of a MockFault.
+type MockFaultType int
+
+// fault types.
+const (
+	// reply with MockFault.StatusCode.
+	MockFaultStatus MockFaultType = iota
+
+	// close the connection without replying.
+	MockFaultClose
+
+	// reply after MockFault.Delay.
+	MockFaultDelay
+
+	// never reply.
+	MockFaultNoResponse
+
+	// reply with bytes that are not a valid RTSP response.
+	MockFaultGarbage
+)
+
+// MockFault is a fault that is injected by MockServer
+// when a request is received.
+type MockFault struct {
+	// method that triggers the fault.
+	// It defaults to any method.
+	Method base.Method
+
+	// type.
+	Type MockFaultType
+
+	// status code of MockFaultStatus.
+	StatusCode base.StatusCode
+
+	// delay of MockFaultDelay.
+	Delay time.Duration
+
+	// number of times the fault is triggered.
+	// It defaults to zero, that means that the fault is always triggered.
+	Times int
+}
+
+type mockServerState int
+
+const (
+	mockServerStateInitial mockServerState = iota
+	mockServerStatePrePlay
+	mockServerStatePlay
+	mockServerStatePreRecord
+	mockServerStateRecord
+)
+
+// MockServer is a RTSP server test double.
+// It serves a canned description with a minimal state machine,
+// that supports reading and publishing with the TCP transport protocol.
+// Responses can be scripted and faults can be injected.
+type MockServer struct {
+	// address to listen on.
+	// It defaults to a random port on localhost.
+	Address string
+
+	// description offered to readers.
+	// It defaults to a single H264 media.
+	Description *description.Session
+
+	// called when a request is received (optional).
+	// If it returns a response, the response is sent in place of the one
+	// generated by the state machine.
+	OnRequest func(req *base.Request) *base.Response
+
+	// faults that are injected when a request is received.
+	// They are evaluated in order, before OnRequest.
+	Faults []MockFault
+
+	// called when a RTP packet is received from a publisher (optional).
+	OnPacketRTP func(mediaIndex int, pkt *rtp.Packet)
+
+	ln       net.Listener
+	sdp      []byte
+	wg       sync.WaitGroup
+	mutex    sync.Mutex
+	conns    map[*mockServerConn]struct{}
+	requests []*base.Request
+	triggers []int
+}
+
+// Initialize initializes MockServer.
+// After it returns, clients can connect.
+func (s *MockServer) Initialize() error {
+	if s.Address == "" {
+		s.Address = "localhost:0"
+	}
+
+	if s.Description == nil {
+		s.Description = &description.Session{
+			Medias: []*description.Media{{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					PacketizationMode: 1,
+				}},
+			}},
+		}
+	}
+
+	if s.OnRequest == nil {
+		s.OnRequest = func(*base.Request) *base.Response { return nil }
+	}
+
+	if s.OnPacketRTP == nil {
+		s.OnPacketRTP = func(int, *rtp.Packet) {}
+	}
+
+	desc := s.Description.Clone()
+	for i, medi := range desc.Medias {
+		medi.Control = "trackID=" + strconv.FormatInt(int64(i), 10)
+	}
+
+	var err error
+	s.sdp, err = desc.Marshal()
+	if err != nil {
+		return err
+	}
+
+	s.ln, err = net.Listen("tcp", s.Address)
+	if err != nil {
+		return err
+	}
+
+	s.conns = make(map[*mockServerConn]struct{})
+	s.triggers = make([]int, len(s.Faults))
+
+	s.wg.Add(1)
+	go s.run()
+
+	return nil
+}
+
+// Close closes MockServer and all its connections.
+func (s *MockServer) Close() {
+	s.ln.Close()
+
+	s.mutex.Lock()
+	for c := range s.conns {
+		c.nconn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+}
+
+// Addr returns the address the server is listening on.
+func (s *MockServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Requests returns all requests received by the server.
+func (s *MockServer) Requests() []*base.Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*base.Request(nil), s.requests...)
+}
+
+// WritePacketRTP writes a RTP packet to all readers that are playing the given media.
+func (s *MockServer) WritePacketRTP(mediaIndex int, pkt *rtp.Packet) error {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for c := range s.conns {
+		c.writePacket(mediaIndex, byts)
+	}
+
+	return nil
+}
+
+func (s *MockServer) run() {
+	defer s.wg.Done()
+
+	for {
+		nconn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &mockServerConn{
+			s:        s,
+			nconn:    nconn,
+			conn:     conn.NewConn(bufio.NewReader(nconn), nconn),
+			channels: make(map[int]int),
+		}
+
+		s.mutex.Lock()
+		s.conns[c] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go c.run()
+	}
+}
+
+// nextFault returns the fault triggered by a request, if any.
+func (s *MockServer) nextFault(req *base.Request) *MockFault {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, req)
+
+	for i := range s.Faults {
+		f := &s.Faults[i]
+
+		if f.Method != "" && f.Method != req.Method {
+			continue
+		}
+
+		if f.Times != 0 && s.triggers[i] >= f.Times {
+			continue
+		}
+
+		s.triggers[i]++
+		return f
+	}
+
+	return nil
+}
+
+type mockServerConn struct {
+	s     *MockServer
+	nconn net.Conn
+	conn  *conn.Conn
+
+	writeMutex sync.Mutex
+	state      mockServerState
+	session    string
+	medias     int
+	channels   map[int]int // media index -> RTP channel
+}
+
+func (c *mockServerConn) run() {
+	defer c.s.wg.Done()
+
+	defer func() {
+		c.s.mutex.Lock()
+		delete(c.s.conns, c)
+		c.s.mutex.Unlock()
+	}()
+
+	defer c.nconn.Close()
+
+	for {
+		what, err := c.conn.Read()
+		if err != nil {
+			return
+		}
+
+		switch what := what.(type) {
+		case *base.Request:
+			if !c.handleRequest(what) {
+				return
+			}
+
+		case *base.InterleavedFrame:
+			c.handleFrame(what)
+		}
+	}
+}
+
+// setState sets the state.
+// The state is written by the connection routine only, therefore it can be read there without locking.
+func (c *mockServerConn) setState(state mockServerState) {
+	c.writeMutex.Lock()
+	c.state = state
+	c.writeMutex.Unlock()
+}
+
+func (c *mockServerConn) handleFrame(fr *base.InterleavedFrame) {
+	if c.state != mockServerStateRecord {
+		return
+	}
+
+	for mediaIndex, channel := range c.channels {
+		if fr.Channel == channel {
+			var pkt rtp.Packet
+			err := pkt.Unmarshal(fr.Payload)
+			if err == nil {
+				c.s.OnPacketRTP(mediaIndex, &pkt)
+			}
+			return
+		}
+	}
+}
+
+// handleRequest handles a request and returns whether the connection must be kept open.
+func (c *mockServerConn) handleRequest(req *base.Request) bool {
+	var res *base.Response
+
+	if f := c.s.nextFault(req); f != nil {
+		switch f.Type {
+		case MockFaultStatus:
+			res = &base.Response{StatusCode: f.StatusCode}
+
+		case MockFaultClose:
+			return false
+
+		case MockFaultDelay:
+			time.Sleep(f.Delay)
+
+		case MockFaultNoResponse:
+			return true
+
+		case MockFaultGarbage:
+			c.writeMutex.Lock()
+			_, err := c.nconn.Write([]byte("GARBAGE\r\n\r\n"))
+			c.writeMutex.Unlock()
+			return err == nil
+		}
+	}
+
+	if res == nil {
+		res = c.s.OnRequest(req)
+	}
+
+	if res == nil {
+		res = c.handleRequestInner(req)
+	}
+
+	if res.Header == nil {
+		res.Header = make(base.Header)
+	}
+	res.Header["CSeq"] = req.Header["CSeq"]
+
+	c.writeMutex.Lock()
+	err := c.conn.WriteResponse(res)
+	c.writeMutex.Unlock()
+
+	return err == nil && req.Method != base.Teardown
+}
+
+func (c *mockServerConn) checkSession(req *base.Request) *base.Response {
+	if c.session == "" {
+		return &base.Response{StatusCode: base.StatusMethodNotValidInThisState}
+	}
+
+	var sx headers.Session
+	err := sx.Unmarshal(req.Header["Session"])
+	if err != nil || sx.Session != c.session {
+		return &base.Response{StatusCode: base.StatusSessionNotFound}
+	}
+
+	return nil
+}
+
+func (c *mockServerConn) sessionHeader() base.Header {
+	return base.Header{
+		"Session": headers.Session{
+			Session: c.session,
+		}.Marshal(),
+	}
+}
+
+func (c *mockServerConn) handleRequestInner(req *base.Request) *base.Response {
+	switch req.Method {
+	case base.Options:
+		return &base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Public": base.HeaderValue{strings.Join([]string{
+					string(base.Describe),
+					string(base.Announce),
+					string(base.Setup),
+					string(base.Play),
+					string(base.Record),
+					string(base.GetParameter),
+					string(base.Teardown),
+				}, ", ")},
+			},
+		}
+
+	case base.Describe:
+		return &base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"application/sdp"},
+				"Content-Base": base.HeaderValue{req.URL.String() + "/"},
+			},
+			Body: c.s.sdp,
+		}
+
+	case base.Announce:
+		if c.state != mockServerStateInitial {
+			return &base.Response{StatusCode: base.StatusMethodNotValidInThisState}
+		}
+
+		var ssd sdp.SessionDescription
+		err := ssd.Unmarshal(req.Body)
+		if err != nil {
+			return &base.Response{StatusCode: base.StatusBadRequest}
+		}
+
+		var desc description.Session
+		err = desc.Unmarshal(&ssd)
+		if err != nil {
+			return &base.Response{StatusCode: base.StatusBadRequest}
+		}
+
+		c.medias = len(desc.Medias)
+		c.setState(mockServerStatePreRecord)
+
+		return &base.Response{StatusCode: base.StatusOK}
+
+	case base.Setup:
+		return c.handleSetup(req)
+
+	case base.Play:
+		if res := c.checkSession(req); res != nil {
+			return res
+		}
+
+		if c.state != mockServerStatePrePlay {
+			return &base.Response{StatusCode: base.StatusMethodNotValidInThisState}
+		}
+
+		c.setState(mockServerStatePlay)
+
+		return &base.Response{StatusCode: base.StatusOK, Header: c.sessionHeader()}
+
+	case base.Record:
+		if res := c.checkSession(req); res != nil {
+			return res
+		}
+
+		if c.state != mockServerStatePreRecord || len(c.channels) != c.medias {
+			return &base.Response{StatusCode: base.StatusMethodNotValidInThisState}
+		}
+
+		c.setState(mockServerStateRecord)
+
+		return &base.Response{StatusCode: base.StatusOK, Header: c.sessionHeader()}
+
+	case base.GetParameter, base.Teardown:
+		if res := c.checkSession(req); res != nil {
+			return res
+		}
+
+		return &base.Response{StatusCode: base.StatusOK, Header: c.sessionHeader()}
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}
+}
+
+// mediaIndex returns the index of the media addressed by a SETUP request.
+func (c *mockServerConn) mediaIndex(req *base.Request) (int, error) {
+	if c.state == mockServerStatePreRecord {
+		// medias of publishers are setupped in order
+		if len(c.channels) >= c.medias {
+			return 0, fmt.Errorf("all medias have already been setupped")
+		}
+		return len(c.channels), nil
+	}
+
+	i := strings.LastIndex(req.URL.Path, "trackID=")
+	if i < 0 {
+		return 0, fmt.Errorf("track ID is missing")
+	}
+
+	index, err := strconv.Atoi(req.URL.Path[i+len("trackID="):])
+	if err != nil || index < 0 || index >= len(c.s.Description.Medias) {
+		return 0, fmt.Errorf("invalid track ID")
+	}
+
+	return index, nil
+}
+
+func (c *mockServerConn) handleSetup(req *base.Request) *base.Response {
+	if c.session != "" {
+		if res := c.checkSession(req); res != nil {
+			return res
+		}
+	}
+
+	if c.state == mockServerStatePlay || c.state == mockServerStateRecord {
+		return &base.Response{StatusCode: base.StatusMethodNotValidInThisState}
+	}
+
+	mediaIndex, err := c.mediaIndex(req)
+	if err != nil {
+		return &base.Response{StatusCode: base.StatusNotFound}
+	}
+
+	var ths headers.Transports
+	err = ths.Unmarshal(req.Header["Transport"])
+	if err != nil {
+		return &base.Response{StatusCode: base.StatusBadRequest}
+	}
+
+	// only the TCP transport protocol is offered.
+	for _, th := range ths {
+		if th.Protocol == headers.TransportProtocolTCP && th.InterleavedIDs != nil {
+			if c.session == "" {
+				c.session = "12345678"
+			}
+
+			if c.state == mockServerStateInitial {
+				c.setState(mockServerStatePrePlay)
+			}
+
+			c.writeMutex.Lock()
+			c.channels[mediaIndex] = th.InterleavedIDs[0]
+			c.writeMutex.Unlock()
+
+			h := c.sessionHeader()
+			h["Transport"] = headers.Transport{
+				Protocol:       headers.TransportProtocolTCP,
+				Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+				InterleavedIDs: th.InterleavedIDs,
+			}.Marshal()
+
+			return &base.Response{StatusCode: base.StatusOK, Header: h}
+		}
+	}
+
+	return &base.Response{StatusCode: base.StatusUnsupportedTransport}
+}
+
+func (c *mockServerConn) writePacket(mediaIndex int, byts []byte) {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if c.state != mockServerStatePlay {
+		return
+	}
+
+	channel, ok := c.channels[mediaIndex]
+	if !ok {
+		return
+	}
+
+	c.conn.WriteInterleavedFrame(&base.InterleavedFrame{ //nolint:errcheck
+		Channel: channel,
+		Payload: byts,
+	}, make([]byte, 4+len(byts)))
+}
@@ -0,0 +1,186 @@
+package rtsptest
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+)
+
+var testRTPPacket = rtp.Packet{
+	Header: rtp.Header{
+		Version:        2,
+		PayloadType:    96,
+		SequenceNumber: 123,
+		Timestamp:      45343,
+		SSRC:           563423,
+	},
+	Payload: []byte{1, 2, 3, 4},
+}
+
+func TestMockServerRead(t *testing.T) {
+	s := &MockServer{}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	u, err := base.ParseURL("rtsp://" + s.Addr() + "/stream")
+	require.NoError(t, err)
+
+	c := &gortsplib.Client{
+		Scheme:   u.Scheme,
+		Host:     u.Host,
+		Protocol: ptrOf(gortsplib.ProtocolTCP),
+	}
+	err = c.Start()
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+	require.Len(t, desc.Medias, 1)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan *rtp.Packet)
+
+	c.OnPacketRTP(desc.Medias[0], desc.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+		recv <- pkt
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	err = s.WritePacketRTP(0, &testRTPPacket)
+	require.NoError(t, err)
+
+	pkt := <-recv
+	require.Equal(t, testRTPPacket.Payload, pkt.Payload)
+
+	var methods []base.Method
+	for _, req := range s.Requests() {
+		methods = append(methods, req.Method)
+	}
+	require.Equal(t, []base.Method{base.Options, base.Describe, base.Setup, base.Play}, methods)
+}
+
+func TestMockServerPublish(t *testing.T) {
+	recv := make(chan *rtp.Packet)
+
+	s := &MockServer{
+		OnPacketRTP: func(mediaIndex int, pkt *rtp.Packet) {
+			require.Equal(t, 0, mediaIndex)
+			recv <- pkt
+		},
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	desc := &description.Session{
+		Medias: []*description.Media{{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				PacketizationMode: 1,
+			}},
+		}},
+	}
+
+	c := &gortsplib.Client{
+		Protocol: ptrOf(gortsplib.ProtocolTCP),
+	}
+	err = c.StartRecording("rtsp://"+s.Addr()+"/stream", desc)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// the client modifies the SSRC of written packets
+	pkt := testRTPPacket
+	err = c.WritePacketRTP(desc.Medias[0], &pkt)
+	require.NoError(t, err)
+
+	recvPkt := <-recv
+	require.Equal(t, testRTPPacket.Payload, recvPkt.Payload)
+}
+
+func TestMockServerFaults(t *testing.T) {
+	s := &MockServer{
+		Faults: []MockFault{
+			{
+				Method:     base.Describe,
+				Type:       MockFaultStatus,
+				StatusCode: base.StatusServiceUnavailable,
+				Times:      1,
+			},
+			{
+				Method: base.Setup,
+				Type:   MockFaultClose,
+			},
+		},
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	u, err := base.ParseURL("rtsp://" + s.Addr() + "/stream")
+	require.NoError(t, err)
+
+	c := &gortsplib.Client{
+		Scheme:   u.Scheme,
+		Host:     u.Host,
+		Protocol: ptrOf(gortsplib.ProtocolTCP),
+	}
+	err = c.Start()
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, _, err = c.Describe(u)
+	require.Equal(t, liberrors.ErrClientBadStatusCode{
+		Code:    base.StatusServiceUnavailable,
+		Message: "Service Unavailable",
+	}, err)
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.Error(t, err)
+}
+
+func TestMockServerOnRequest(t *testing.T) {
+	s := &MockServer{
+		OnRequest: func(req *base.Request) *base.Response {
+			if req.Method == base.Describe {
+				return &base.Response{
+					StatusCode: base.StatusNotFound,
+				}
+			}
+			return nil
+		},
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c := &MockClient{
+		URL: "rtsp://" + s.Addr() + "/stream",
+	}
+	err = c.Initialize()
+	require.NoError(t, err)
+	defer c.Close()
+
+	res, err := c.Options()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	_, res, err = c.Describe()
+	require.NoError(t, err)
+	require.Equal(t, base.StatusNotFound, res.StatusCode)
+}
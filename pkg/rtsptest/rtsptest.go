@@ -0,0 +1,9 @@
+// Package rtsptest contains test doubles that allow to test RTSP logic
+// without spinning up real servers and clients.
+package rtsptest
+
+func ptrOf[T any](v T) *T {
+	p := new(T)
+	*p = v
+	return p
+}
@@ -0,0 +1,352 @@
+package sap
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/multicast"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+const (
+	udpMaxPayloadSize = 1472
+)
+
+// Session is a session announced with SAP.
+type Session struct {
+	// address of the originating source.
+	Source net.IP
+
+	// hash of the announcement.
+	MessageIDHash uint16
+
+	// SDP of the session.
+	SDP *sdp.SessionDescription
+
+	// description of the session.
+	Description *description.Session
+
+	// reader of the session.
+	// It is filled only when Listener.AutoStart is true.
+	Reader *gortsplib.SDPReader
+
+	key      string
+	payload  string
+	lastSeen time.Time
+}
+
+func (s *Session) close() {
+	if s.Reader != nil {
+		s.Reader.Close()
+	}
+}
+
+// Listener listens for SAP announcements, keeps track of the announced sessions
+// and optionally starts reading them through SDPReader.
+type Listener struct {
+	// address to listen on.
+	// When the address is a multicast address, the multicast group is joined.
+	// It defaults to DefaultAddress.
+	Address string
+
+	// interface used to join multicast groups.
+	// It defaults to all multicast-capable interfaces.
+	MulticastInterface *net.Interface
+
+	// whether to start reading announced sessions automatically.
+	AutoStart bool
+
+	// time after which sessions that are not announced again are removed.
+	// It defaults to 1 hour.
+	Timeout time.Duration
+
+	// function used to initialize UDP listeners.
+	// It defaults to net.ListenPacket.
+	ListenPacket func(network, address string) (net.PacketConn, error)
+
+	// called when a session is announced.
+	// When AutoStart is true, callbacks of Session.Reader can be set here,
+	// since reading starts after the function returns.
+	OnAnnounce func(*Session)
+
+	// called when a session is deleted or expires.
+	OnDelete func(*Session)
+
+	// called when a non-fatal decode error occurs.
+	OnDecodeError func(error)
+
+	checkPeriod time.Duration
+
+	pc       net.PacketConn
+	mutex    sync.RWMutex
+	sessions map[string]*Session
+
+	chPacket  chan []byte
+	terminate chan struct{}
+	done      chan struct{}
+	readDone  chan struct{}
+}
+
+// Initialize initializes Listener.
+func (l *Listener) Initialize() error {
+	if l.Address == "" {
+		l.Address = DefaultAddress
+	}
+	if l.Timeout == 0 {
+		l.Timeout = 1 * time.Hour
+	}
+	if l.ListenPacket == nil {
+		l.ListenPacket = net.ListenPacket
+	}
+	if l.OnAnnounce == nil {
+		l.OnAnnounce = func(*Session) {}
+	}
+	if l.OnDelete == nil {
+		l.OnDelete = func(*Session) {}
+	}
+	if l.OnDecodeError == nil {
+		l.OnDecodeError = func(error) {}
+	}
+	if l.checkPeriod == 0 {
+		l.checkPeriod = 10 * time.Second
+	}
+
+	host, _, err := net.SplitHostPort(l.Address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsMulticast() {
+		if l.MulticastInterface != nil {
+			l.pc, err = multicast.NewSingleConn(l.MulticastInterface, l.Address, l.ListenPacket)
+		} else {
+			l.pc, err = multicast.NewMultiConn(l.Address, true, l.ListenPacket)
+		}
+	} else {
+		l.pc, err = l.ListenPacket("udp", l.Address)
+	}
+	if err != nil {
+		return err
+	}
+
+	l.sessions = make(map[string]*Session)
+	l.chPacket = make(chan []byte)
+	l.terminate = make(chan struct{})
+	l.done = make(chan struct{})
+	l.readDone = make(chan struct{})
+
+	go l.runReader()
+	go l.run()
+
+	return nil
+}
+
+// Close closes the Listener and all the readers of sessions.
+func (l *Listener) Close() {
+	close(l.terminate)
+	l.pc.Close()
+	<-l.readDone
+	<-l.done
+}
+
+// LocalAddr returns the address the Listener is listening on.
+func (l *Listener) LocalAddr() net.Addr {
+	return l.pc.LocalAddr()
+}
+
+// Sessions returns the sessions that are currently announced.
+func (l *Listener) Sessions() []*Session {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	ret := make([]*Session, 0, len(l.sessions))
+	for _, s := range l.sessions {
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+func (l *Listener) runReader() {
+	defer close(l.readDone)
+
+	for {
+		buf := make([]byte, udpMaxPayloadSize+1)
+		n, _, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		select {
+		case l.chPacket <- buf[:n]:
+		case <-l.terminate:
+			return
+		}
+	}
+}
+
+func (l *Listener) run() {
+	defer close(l.done)
+
+	t := time.NewTicker(l.checkPeriod)
+	defer t.Stop()
+
+	for {
+		select {
+		case buf := <-l.chPacket:
+			err := l.handlePacket(buf)
+			if err != nil {
+				l.OnDecodeError(err)
+			}
+
+		case <-t.C:
+			l.removeExpired()
+
+		case <-l.terminate:
+			l.mutex.Lock()
+			for _, s := range l.sessions {
+				s.close()
+			}
+			l.sessions = nil
+			l.mutex.Unlock()
+			return
+		}
+	}
+}
+
+func (l *Listener) handlePacket(buf []byte) error {
+	var pkt Packet
+	err := pkt.Unmarshal(buf)
+	if err != nil {
+		return err
+	}
+
+	if pkt.PayloadType != "" && pkt.PayloadType != "application/sdp" {
+		return fmt.Errorf("unsupported payload type: %s", pkt.PayloadType)
+	}
+
+	key := sessionKey(&pkt)
+
+	l.mutex.RLock()
+	existing, ok := l.sessions[key]
+	l.mutex.RUnlock()
+
+	if pkt.Deletion {
+		if ok {
+			l.removeSession(existing)
+		}
+		return nil
+	}
+
+	if ok {
+		if existing.payload == string(pkt.Payload) {
+			existing.lastSeen = time.Now()
+			return nil
+		}
+
+		// the session has been modified
+		l.removeSession(existing)
+	}
+
+	var sd sdp.SessionDescription
+	err = sd.Unmarshal(pkt.Payload)
+	if err != nil {
+		return err
+	}
+
+	return l.addSession(key, &pkt, &sd)
+}
+
+func (l *Listener) addSession(key string, pkt *Packet, sd *sdp.SessionDescription) error {
+	s := &Session{
+		Source:        pkt.Source,
+		MessageIDHash: pkt.MessageIDHash,
+		SDP:           sd,
+		key:           key,
+		payload:       string(pkt.Payload),
+		lastSeen:      time.Now(),
+	}
+
+	if l.AutoStart {
+		s.Reader = &gortsplib.SDPReader{
+			SDP:                sd,
+			MulticastInterface: l.MulticastInterface,
+			ListenPacket:       l.ListenPacket,
+			OnDecodeError:      gortsplib.ClientOnDecodeErrorFunc(l.OnDecodeError),
+		}
+		err := s.Reader.Initialize()
+		if err != nil {
+			return fmt.Errorf("unable to read session: %w", err)
+		}
+
+		s.Description = s.Reader.Description()
+	} else {
+		var desc description.Session
+		err := desc.Unmarshal(sd)
+		if err != nil {
+			return err
+		}
+
+		s.Description = &desc
+	}
+
+	l.mutex.Lock()
+	l.sessions[key] = s
+	l.mutex.Unlock()
+
+	l.OnAnnounce(s)
+
+	if s.Reader != nil {
+		s.Reader.Start()
+	}
+
+	return nil
+}
+
+func (l *Listener) removeSession(s *Session) {
+	l.mutex.Lock()
+	delete(l.sessions, s.key)
+	l.mutex.Unlock()
+
+	s.close()
+	l.OnDelete(s)
+}
+
+func (l *Listener) removeExpired() {
+	now := time.Now()
+
+	for _, s := range l.Sessions() {
+		if now.Sub(s.lastSeen) >= l.Timeout {
+			l.removeSession(s)
+		}
+	}
+}
+
+// sessionKey returns the key that identifies an announcement.
+// When the message ID hash is not provided, the SDP origin is used,
+// since it is present in both announcements and deletions.
+func sessionKey(pkt *Packet) string {
+	key := pkt.Source.String() + "/" + strconv.FormatUint(uint64(pkt.MessageIDHash), 10)
+
+	if pkt.MessageIDHash == 0 {
+		for _, line := range strings.Split(string(pkt.Payload), "\n") {
+			if !strings.HasPrefix(line, "o=") {
+				continue
+			}
+
+			// use username, session ID and address, excluding the session version
+			fields := strings.Fields(line[2:])
+			if len(fields) == 6 {
+				key += "/" + fields[0] + "/" + fields[1] + "/" + fields[5]
+			}
+			break
+		}
+	}
+
+	return key
+}
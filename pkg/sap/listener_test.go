@@ -0,0 +1,142 @@
+package sap
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSDP = []byte("v=0\r\n" +
+	"o=- 123 1 IN IP4 127.0.0.1\r\n" +
+	"s=Stream\r\n" +
+	"c=IN IP4 127.0.0.1\r\n" +
+	"t=0 0\r\n" +
+	"m=video 35470 RTP/AVP 96\r\n" +
+	"a=rtpmap:96 H264/90000\r\n" +
+	"a=fmtp:96 packetization-mode=1\r\n")
+
+func writeSAPPacket(t *testing.T, conn net.Conn, pkt Packet) {
+	byts, err := pkt.Marshal()
+	require.NoError(t, err)
+	_, err = conn.Write(byts)
+	require.NoError(t, err)
+}
+
+func TestListener(t *testing.T) {
+	announced := make(chan *Session, 1)
+	deleted := make(chan *Session, 1)
+	packetRecv := make(chan *rtp.Packet, 1)
+
+	l := &Listener{
+		Address:   "127.0.0.1:0",
+		AutoStart: true,
+		OnAnnounce: func(s *Session) {
+			s.Reader.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+				packetRecv <- pkt
+			})
+			announced <- s
+		},
+		OnDelete: func(s *Session) {
+			deleted <- s
+		},
+	}
+	err := l.Initialize()
+	require.NoError(t, err)
+	defer l.Close()
+
+	conn, err := net.Dial("udp", l.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	writeSAPPacket(t, conn, Packet{
+		MessageIDHash: 0x4567,
+		Source:        net.IPv4(127, 0, 0, 1),
+		PayloadType:   "application/sdp",
+		Payload:       testSDP,
+	})
+
+	s := <-announced
+	require.Equal(t, uint16(0x4567), s.MessageIDHash)
+	require.Len(t, s.Description.Medias, 1)
+	require.Equal(t, description.MediaTypeVideo, s.Description.Medias[0].Type)
+	require.Len(t, l.Sessions(), 1)
+
+	// announcements are repeated periodically
+	writeSAPPacket(t, conn, Packet{
+		MessageIDHash: 0x4567,
+		Source:        net.IPv4(127, 0, 0, 1),
+		Payload:       testSDP,
+	})
+
+	rtpConn, err := net.Dial("udp", "127.0.0.1:35470")
+	require.NoError(t, err)
+	defer rtpConn.Close()
+
+	byts, err := (&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{5, 1, 2, 3},
+	}).Marshal()
+	require.NoError(t, err)
+	_, err = rtpConn.Write(byts)
+	require.NoError(t, err)
+
+	pkt := <-packetRecv
+	require.Equal(t, uint16(123), pkt.SequenceNumber)
+
+	writeSAPPacket(t, conn, Packet{
+		Deletion:      true,
+		MessageIDHash: 0x4567,
+		Source:        net.IPv4(127, 0, 0, 1),
+		Payload:       []byte("o=- 123 1 IN IP4 127.0.0.1\r\n"),
+	})
+
+	require.Equal(t, s, <-deleted)
+	require.Empty(t, l.Sessions())
+
+	select {
+	case <-announced:
+		t.Errorf("unexpected announcement")
+	default:
+	}
+}
+
+func TestListenerTimeout(t *testing.T) {
+	deleted := make(chan *Session, 1)
+
+	l := &Listener{
+		Address:     "127.0.0.1:0",
+		Timeout:     100 * time.Millisecond,
+		checkPeriod: 50 * time.Millisecond,
+		OnDelete: func(s *Session) {
+			deleted <- s
+		},
+	}
+	err := l.Initialize()
+	require.NoError(t, err)
+	defer l.Close()
+
+	conn, err := net.Dial("udp", l.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	writeSAPPacket(t, conn, Packet{
+		Source:  net.IPv4(127, 0, 0, 1),
+		Payload: testSDP,
+	})
+
+	s := <-deleted
+	require.Nil(t, s.Reader)
+	require.Equal(t, "Stream", string(s.SDP.SessionName))
+}
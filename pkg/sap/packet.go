@@ -0,0 +1,151 @@
+package sap
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net"
+)
+
+const (
+	maxDecompressedSize = 1024 * 1024
+)
+
+// Packet is a SAP packet.
+type Packet struct {
+	// whether the packet is a session deletion instead of an announcement.
+	Deletion bool
+
+	// hash that, together with Source, identifies the announcement.
+	MessageIDHash uint16
+
+	// address of the originating source.
+	Source net.IP
+
+	// authentication data.
+	Auth []byte
+
+	// MIME type of the payload.
+	// When empty, the payload is a SDP.
+	PayloadType string
+
+	// payload.
+	Payload []byte
+}
+
+// Unmarshal decodes a Packet.
+// Compressed packets are decompressed, while encrypted packets are not supported.
+func (p *Packet) Unmarshal(buf []byte) error {
+	if len(buf) < 4 {
+		return fmt.Errorf("buffer is too short")
+	}
+
+	version := buf[0] >> 5
+	if version != 1 {
+		return fmt.Errorf("unsupported version: %d", version)
+	}
+
+	isIPv6 := (buf[0] & 0b10000) != 0
+	p.Deletion = (buf[0] & 0b100) != 0
+	encrypted := (buf[0] & 0b10) != 0
+	compressed := (buf[0] & 0b1) != 0
+	authLen := int(buf[1]) * 4
+	p.MessageIDHash = uint16(buf[2])<<8 | uint16(buf[3])
+	buf = buf[4:]
+
+	if encrypted {
+		return fmt.Errorf("encrypted packets are not supported")
+	}
+
+	ipLen := 4
+	if isIPv6 {
+		ipLen = 16
+	}
+
+	if len(buf) < (ipLen + authLen) {
+		return fmt.Errorf("buffer is too short")
+	}
+
+	p.Source = net.IP(append([]byte(nil), buf[:ipLen]...))
+	buf = buf[ipLen:]
+
+	if authLen != 0 {
+		p.Auth = append([]byte(nil), buf[:authLen]...)
+	} else {
+		p.Auth = nil
+	}
+	buf = buf[authLen:]
+
+	if compressed {
+		zr, err := zlib.NewReader(bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("unable to decompress payload: %w", err)
+		}
+		defer zr.Close()
+
+		buf, err = io.ReadAll(io.LimitReader(zr, maxDecompressedSize))
+		if err != nil {
+			return fmt.Errorf("unable to decompress payload: %w", err)
+		}
+	}
+
+	// the payload type is optional and is omitted when the payload is a SDP
+	// (or, in case of deletions, a SDP origin line)
+	p.PayloadType = ""
+	if !bytes.HasPrefix(buf, []byte("v=")) && !bytes.HasPrefix(buf, []byte("o=")) {
+		i := bytes.IndexByte(buf, 0)
+		if i < 0 {
+			return fmt.Errorf("payload type is not terminated")
+		}
+
+		p.PayloadType = string(buf[:i])
+		buf = buf[i+1:]
+	}
+
+	p.Payload = append([]byte(nil), buf...)
+
+	return nil
+}
+
+// Marshal encodes a Packet.
+func (p Packet) Marshal() ([]byte, error) {
+	ip := p.Source.To4()
+	isIPv6 := false
+	if ip == nil {
+		ip = p.Source.To16()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source")
+		}
+		isIPv6 = true
+	}
+
+	if (len(p.Auth) % 4) != 0 {
+		return nil, fmt.Errorf("authentication data length must be a multiple of 4")
+	}
+
+	if len(p.Auth) > 255*4 {
+		return nil, fmt.Errorf("authentication data is too long")
+	}
+
+	buf := []byte{1 << 5, byte(len(p.Auth) / 4), byte(p.MessageIDHash >> 8), byte(p.MessageIDHash)}
+
+	if isIPv6 {
+		buf[0] |= 0b10000
+	}
+	if p.Deletion {
+		buf[0] |= 0b100
+	}
+
+	buf = append(buf, ip...)
+	buf = append(buf, p.Auth...)
+
+	if p.PayloadType != "" {
+		buf = append(buf, []byte(p.PayloadType)...)
+		buf = append(buf, 0)
+	}
+
+	buf = append(buf, p.Payload...)
+
+	return buf, nil
+}
@@ -0,0 +1,124 @@
+package sap
+
+import (
+	"bytes"
+	"compress/zlib"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var casesPacket = []struct {
+	name string
+	byts []byte
+	pkt  Packet
+}{
+	{
+		"announcement",
+		append([]byte{
+			0x20, 0x00, 0x12, 0x34, 192, 168, 2, 10,
+		}, []byte("v=0\r\no=- 1 1 IN IP4 192.168.2.10\r\n")...),
+		Packet{
+			MessageIDHash: 0x1234,
+			Source:        net.IPv4(192, 168, 2, 10).To4(),
+			Payload:       []byte("v=0\r\no=- 1 1 IN IP4 192.168.2.10\r\n"),
+		},
+	},
+	{
+		"deletion with payload type and auth",
+		append([]byte{
+			0x24, 0x01, 0x00, 0x01, 10, 0, 0, 1,
+			1, 2, 3, 4,
+		}, []byte("application/sdp\x00o=- 1 1 IN IP4 10.0.0.1\r\n")...),
+		Packet{
+			Deletion:      true,
+			MessageIDHash: 1,
+			Source:        net.IPv4(10, 0, 0, 1).To4(),
+			Auth:          []byte{1, 2, 3, 4},
+			PayloadType:   "application/sdp",
+			Payload:       []byte("o=- 1 1 IN IP4 10.0.0.1\r\n"),
+		},
+	},
+	{
+		"ipv6",
+		append([]byte{
+			0x30, 0x00, 0x00, 0x02,
+			0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+		}, []byte("v=0\r\n")...),
+		Packet{
+			MessageIDHash: 2,
+			Source:        net.ParseIP("2001:db8::1"),
+			Payload:       []byte("v=0\r\n"),
+		},
+	},
+}
+
+func TestPacketUnmarshal(t *testing.T) {
+	for _, ca := range casesPacket {
+		t.Run(ca.name, func(t *testing.T) {
+			var pkt Packet
+			err := pkt.Unmarshal(ca.byts)
+			require.NoError(t, err)
+			require.Equal(t, ca.pkt, pkt)
+		})
+	}
+}
+
+func TestPacketMarshal(t *testing.T) {
+	for _, ca := range casesPacket {
+		t.Run(ca.name, func(t *testing.T) {
+			byts, err := ca.pkt.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, ca.byts, byts)
+		})
+	}
+}
+
+func TestPacketUnmarshalCompressed(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, err := zw.Write([]byte("v=0\r\ns=test\r\n"))
+	require.NoError(t, err)
+	zw.Close()
+
+	var pkt Packet
+	err = pkt.Unmarshal(append([]byte{0x21, 0x00, 0x00, 0x05, 127, 0, 0, 1}, buf.Bytes()...))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v=0\r\ns=test\r\n"), pkt.Payload)
+}
+
+func TestPacketUnmarshalErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		byts []byte
+		err  string
+	}{
+		{
+			"too short",
+			[]byte{0x20, 0x00},
+			"buffer is too short",
+		},
+		{
+			"version",
+			[]byte{0x40, 0x00, 0x00, 0x00, 127, 0, 0, 1},
+			"unsupported version: 2",
+		},
+		{
+			"encrypted",
+			[]byte{0x22, 0x00, 0x00, 0x00, 127, 0, 0, 1},
+			"encrypted packets are not supported",
+		},
+		{
+			"payload type not terminated",
+			append([]byte{0x20, 0x00, 0x00, 0x00, 127, 0, 0, 1}, []byte("application/sdp")...),
+			"payload type is not terminated",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var pkt Packet
+			err := pkt.Unmarshal(ca.byts)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,6 @@
+// Package sap contains utilities to discover streams announced with the
+// Session Announcement Protocol (SAP, RFC2974) and to read them without RTSP.
+package sap
+
+// DefaultAddress is the address of global scope IPv4 SAP announcements.
+const DefaultAddress = "224.2.127.254:9875"
@@ -0,0 +1,109 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph265"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpmjpeg"
+)
+
+// ReadClient finds the first supported format of the given media,
+// initializes the Snapshotter and writes into it the packets read by the Client.
+// It must be called after Client.Setup() and before Client.Play().
+func (s *Snapshotter) ReadClient(c *gortsplib.Client, medi *description.Media) error {
+	var cb func(pkt *rtp.Packet, pts int64) error
+
+	for _, forma := range medi.Formats {
+		switch forma := forma.(type) {
+		case *format.H264:
+			dec, err := forma.CreateDecoder()
+			if err != nil {
+				return err
+			}
+
+			cb = func(pkt *rtp.Packet, pts int64) error {
+				au, err := dec.Decode(pkt)
+				if err != nil {
+					if errors.Is(err, rtph264.ErrNonStartingPacketAndNoPrevious) ||
+						errors.Is(err, rtph264.ErrMorePacketsNeeded) {
+						return nil
+					}
+					return err
+				}
+
+				return s.WriteH264(pts, au)
+			}
+
+		case *format.H265:
+			dec, err := forma.CreateDecoder()
+			if err != nil {
+				return err
+			}
+
+			cb = func(pkt *rtp.Packet, pts int64) error {
+				au, err := dec.Decode(pkt)
+				if err != nil {
+					if errors.Is(err, rtph265.ErrNonStartingPacketAndNoPrevious) ||
+						errors.Is(err, rtph265.ErrMorePacketsNeeded) {
+						return nil
+					}
+					return err
+				}
+
+				return s.WriteH265(pts, au)
+			}
+
+		case *format.MJPEG:
+			dec, err := forma.CreateDecoder()
+			if err != nil {
+				return err
+			}
+
+			cb = func(pkt *rtp.Packet, pts int64) error {
+				frame, err := dec.Decode(pkt)
+				if err != nil {
+					if errors.Is(err, rtpmjpeg.ErrNonStartingPacketAndNoPrevious) ||
+						errors.Is(err, rtpmjpeg.ErrMorePacketsNeeded) {
+						return nil
+					}
+					return err
+				}
+
+				return s.WriteMJPEG(pts, frame)
+			}
+
+		default:
+			continue
+		}
+
+		s.Format = forma
+
+		err := s.Initialize()
+		if err != nil {
+			return err
+		}
+
+		c.OnPacketRTP(medi, forma, func(pkt *rtp.Packet) {
+			pts, ok := c.PacketPTS(medi, pkt)
+			if !ok {
+				return
+			}
+
+			err2 := cb(pkt, pts)
+			if err2 != nil {
+				s.OnError(err2)
+			}
+		})
+
+		return nil
+	}
+
+	return fmt.Errorf("no supported formats found")
+}
@@ -0,0 +1,242 @@
+// Package snapshot contains a utility to extract periodic JPEG snapshots from video tracks.
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// Decoder decodes access units into images.
+//
+// With H264 and H265, the decoder receives random access units only,
+// with parameters prepended, therefore each access unit can be decoded independently.
+// With MJPEG, the decoder receives a single JPEG frame.
+//
+// Decode can return a nil image when the frame cannot be decoded yet.
+type Decoder interface {
+	Decode(au [][]byte) (image.Image, error)
+}
+
+// JPEGDecoder is a Decoder for MJPEG tracks, that uses the standard library.
+type JPEGDecoder struct{}
+
+// Decode implements Decoder.
+func (JPEGDecoder) Decode(au [][]byte) (image.Image, error) {
+	if len(au) != 1 {
+		return nil, fmt.Errorf("expected a single JPEG frame, got %d", len(au))
+	}
+	return jpeg.Decode(bytes.NewReader(au[0]))
+}
+
+// Snapshotter extracts periodic JPEG snapshots from a H264, H265 or MJPEG track.
+// Only random access units are decoded, in order to minimize CPU usage.
+//
+// Timestamps are expressed in clock rate units of the track
+// (like the ones returned by Client.PacketPTS()).
+type Snapshotter struct {
+	// format of the track.
+	// Supported formats are H264, H265 and MJPEG.
+	Format format.Format
+
+	// decoder.
+	// It is mandatory with H264 and H265, while it defaults to JPEGDecoder with MJPEG.
+	Decoder Decoder
+
+	// minimum interval between two snapshots.
+	// It defaults to 10 seconds.
+	Interval time.Duration
+
+	// JPEG quality, between 1 and 100.
+	// It defaults to jpeg.DefaultQuality.
+	Quality int
+
+	// called when a snapshot is produced. Optional.
+	OnSnapshot func(pts int64, buf []byte)
+
+	// called when an error occurs while processing packets read by ReadClient().
+	// It defaults to a function that prints errors.
+	OnError func(err error)
+
+	interval int64
+	vps      []byte
+	sps      []byte
+	pps      []byte
+
+	mutex   sync.RWMutex
+	last    []byte
+	lastPTS int64
+}
+
+// Initialize initializes Snapshotter.
+func (s *Snapshotter) Initialize() error {
+	switch forma := s.Format.(type) {
+	case *format.H264:
+		s.sps, s.pps = forma.SafeParams()
+
+	case *format.H265:
+		s.vps, s.sps, s.pps = forma.SafeParams()
+
+	case *format.MJPEG:
+		if s.Decoder == nil {
+			s.Decoder = JPEGDecoder{}
+		}
+
+	case nil:
+		return fmt.Errorf("Format not provided")
+
+	default:
+		return fmt.Errorf("unsupported format: %T", forma)
+	}
+
+	if s.Decoder == nil {
+		return fmt.Errorf("Decoder not provided")
+	}
+
+	if s.Interval == 0 {
+		s.Interval = 10 * time.Second
+	}
+	if s.Quality == 0 {
+		s.Quality = jpeg.DefaultQuality
+	}
+	if s.OnError == nil {
+		s.OnError = func(err error) {
+			log.Printf("%v", err)
+		}
+	}
+
+	s.interval = mediatime.DurationToTimestamp(s.Interval, s.Format.ClockRate())
+
+	return nil
+}
+
+// Last returns the last snapshot and its timestamp.
+// It returns nil if no snapshot has been produced yet.
+func (s *Snapshotter) Last() ([]byte, int64) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.last, s.lastPTS
+}
+
+func (s *Snapshotter) isDue(pts int64) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.last == nil || (pts-s.lastPTS) >= s.interval
+}
+
+// WriteH264 writes a H264 access unit.
+func (s *Snapshotter) WriteH264(pts int64, au [][]byte) error {
+	filtered := make([][]byte, 0, len(au)+2)
+	randomAccess := false
+
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			s.sps = nalu
+			continue
+
+		case h264.NALUTypePPS:
+			s.pps = nalu
+			continue
+
+		case h264.NALUTypeAccessUnitDelimiter:
+			continue
+
+		case h264.NALUTypeIDR:
+			randomAccess = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if !randomAccess || s.sps == nil || s.pps == nil || !s.isDue(pts) {
+		return nil
+	}
+
+	return s.decode(pts, append([][]byte{s.sps, s.pps}, filtered...))
+}
+
+// WriteH265 writes a H265 access unit.
+func (s *Snapshotter) WriteH265(pts int64, au [][]byte) error {
+	filtered := make([][]byte, 0, len(au)+3)
+	randomAccess := false
+
+	for _, nalu := range au {
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+
+		switch typ {
+		case h265.NALUType_VPS_NUT:
+			s.vps = nalu
+			continue
+
+		case h265.NALUType_SPS_NUT:
+			s.sps = nalu
+			continue
+
+		case h265.NALUType_PPS_NUT:
+			s.pps = nalu
+			continue
+
+		case h265.NALUType_AUD_NUT:
+			continue
+
+		case h265.NALUType_IDR_W_RADL, h265.NALUType_IDR_N_LP, h265.NALUType_CRA_NUT:
+			randomAccess = true
+		}
+
+		filtered = append(filtered, nalu)
+	}
+
+	if !randomAccess || s.vps == nil || s.sps == nil || s.pps == nil || !s.isDue(pts) {
+		return nil
+	}
+
+	return s.decode(pts, append([][]byte{s.vps, s.sps, s.pps}, filtered...))
+}
+
+// WriteMJPEG writes a MJPEG frame.
+func (s *Snapshotter) WriteMJPEG(pts int64, frame []byte) error {
+	if !s.isDue(pts) {
+		return nil
+	}
+
+	return s.decode(pts, [][]byte{frame})
+}
+
+func (s *Snapshotter) decode(pts int64, au [][]byte) error {
+	img, err := s.Decoder.Decode(au)
+	if err != nil {
+		return err
+	}
+
+	if img == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: s.Quality})
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.last = buf.Bytes()
+	s.lastPTS = pts
+	s.mutex.Unlock()
+
+	if s.OnSnapshot != nil {
+		s.OnSnapshot(pts, buf.Bytes())
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package snapshot
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+var testSPS = []byte{
+	0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+	0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9,
+	0x20,
+}
+
+var testPPS = []byte{0x08, 0x06, 0x07, 0x08}
+
+type testDecoder struct {
+	aus [][][]byte
+}
+
+func (d *testDecoder) Decode(au [][]byte) (image.Image, error) {
+	d.aus = append(d.aus, au)
+	return image.NewRGBA(image.Rect(0, 0, 16, 16)), nil
+}
+
+func TestSnapshotterH264(t *testing.T) {
+	dec := &testDecoder{}
+	var snapshots []int64
+
+	s := &Snapshotter{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+		Decoder: dec,
+		OnSnapshot: func(pts int64, buf []byte) {
+			_, err := jpeg.Decode(bytes.NewReader(buf))
+			require.NoError(t, err)
+			snapshots = append(snapshots, pts)
+		},
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	// parameters are not available yet
+	err = s.WriteH264(0, [][]byte{{5, 1}})
+	require.NoError(t, err)
+
+	err = s.WriteH264(90000, [][]byte{testSPS, testPPS, {5, 2}})
+	require.NoError(t, err)
+
+	// non-IDR frames are not decoded
+	err = s.WriteH264(90000*12, [][]byte{{1, 1}})
+	require.NoError(t, err)
+
+	// interval is not elapsed yet
+	err = s.WriteH264(90000*5, [][]byte{{5, 3}})
+	require.NoError(t, err)
+
+	err = s.WriteH264(90000*11, [][]byte{{9, 240}, {5, 4}})
+	require.NoError(t, err)
+
+	require.Equal(t, [][][]byte{
+		{testSPS, testPPS, {5, 2}},
+		{testSPS, testPPS, {5, 4}},
+	}, dec.aus)
+
+	require.Equal(t, []int64{90000, 90000 * 11}, snapshots)
+
+	last, lastPTS := s.Last()
+	require.NotNil(t, last)
+	require.Equal(t, int64(90000*11), lastPTS)
+}
+
+func TestSnapshotterMJPEG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+
+	var frame bytes.Buffer
+	err := jpeg.Encode(&frame, img, nil)
+	require.NoError(t, err)
+
+	s := &Snapshotter{
+		Format: &format.MJPEG{},
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	last, _ := s.Last()
+	require.Nil(t, last)
+
+	err = s.WriteMJPEG(0, frame.Bytes())
+	require.NoError(t, err)
+
+	last, _ = s.Last()
+	dec, err := jpeg.Decode(bytes.NewReader(last))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 32, 32), dec.Bounds())
+
+	err = s.WriteMJPEG(0, []byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = s.WriteMJPEG(90000*10, []byte{1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestSnapshotterErrors(t *testing.T) {
+	s := &Snapshotter{
+		Format: &format.H264{},
+	}
+	err := s.Initialize()
+	require.EqualError(t, err, "Decoder not provided")
+
+	s = &Snapshotter{
+		Format: &format.Opus{},
+	}
+	err = s.Initialize()
+	require.EqualError(t, err, "unsupported format: *format.Opus")
+}
@@ -0,0 +1,196 @@
+// Package trackmetrics contains a utility to estimate bitrate, frame rate and key frame interval of a track.
+package trackmetrics
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// Metrics are track measurements.
+type Metrics struct {
+	// bitrate of RTP payloads, in bits per second.
+	Bitrate float64
+	// frame rate, estimated from RTP timestamps.
+	// In case of audio tracks, this is the rate of packets with distinct timestamps.
+	FrameRate float64
+	// interval between the last two key frames.
+	// It is available for H264 and H265 tracks only.
+	KeyFrameInterval time.Duration
+}
+
+func relativeChange(prev float64, cur float64) float64 {
+	if prev == 0 {
+		if cur == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Abs(cur-prev) / prev
+}
+
+// Estimator is a utility to estimate bitrate, frame rate and key frame interval of a track.
+// Measurements are computed over consecutive windows and are updated when packets are received.
+type Estimator struct {
+	// track format.
+	Format format.Format
+
+	// duration of the measurement window.
+	// It defaults to 1 second.
+	Window time.Duration
+
+	// minimum relative change of a measurement that triggers OnChange.
+	// It defaults to 0.1 (10%).
+	Threshold float64
+
+	// called when a window ends and at least one measurement changed by more than Threshold
+	// with respect to the last reported value, or when measurements are available for the first time.
+	OnChange func(Metrics)
+
+	mutex            sync.Mutex
+	clockRate        int64
+	detectKeyFrames  bool
+	timeInitialized  bool
+	lastRTP          uint32
+	curTS            int64
+	keyFrameTS       int64
+	keyFrameReceived bool
+	windowStarted    bool
+	windowStart      time.Time
+	windowBytes      uint64
+	windowFrames     int64
+	windowMinTS      int64
+	windowMaxTS      int64
+	metrics          Metrics
+	reported         *Metrics
+}
+
+// Initialize initializes Estimator.
+func (e *Estimator) Initialize() {
+	if e.Window == 0 {
+		e.Window = 1 * time.Second
+	}
+	if e.Threshold == 0 {
+		e.Threshold = 0.1
+	}
+	if e.OnChange == nil {
+		e.OnChange = func(Metrics) {}
+	}
+
+	e.clockRate = int64(e.Format.ClockRate())
+
+	switch e.Format.(type) {
+	case *format.H264, *format.H265:
+		e.detectKeyFrames = true
+	}
+}
+
+// ProcessPacket processes a received RTP packet.
+func (e *Estimator) ProcessPacket(pkt *rtp.Packet, now time.Time) {
+	changed, metrics := e.processPacket(pkt, now)
+
+	if changed {
+		e.OnChange(metrics)
+	}
+}
+
+func (e *Estimator) processPacket(pkt *rtp.Packet, now time.Time) (bool, Metrics) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var changed bool
+	var metrics Metrics
+
+	if e.windowStarted {
+		elapsed := now.Sub(e.windowStart)
+		if elapsed >= e.Window {
+			changed, metrics = e.closeWindow(elapsed)
+		}
+	}
+
+	newFrame := false
+
+	if !e.timeInitialized {
+		e.timeInitialized = true
+		e.lastRTP = pkt.Timestamp
+		newFrame = true
+	} else if pkt.Timestamp != e.lastRTP {
+		// timestamps are unwrapped in order to support overflows.
+		// B-frames produce negative differences.
+		e.curTS += int64(int32(pkt.Timestamp - e.lastRTP))
+		e.lastRTP = pkt.Timestamp
+		newFrame = true
+	}
+
+	if !e.windowStarted {
+		e.windowStarted = true
+		e.windowStart = now
+		e.windowMinTS = e.curTS
+		e.windowMaxTS = e.curTS
+		newFrame = true
+	}
+
+	e.windowBytes += uint64(len(pkt.Payload))
+
+	if newFrame {
+		e.windowFrames++
+
+		if e.curTS < e.windowMinTS {
+			e.windowMinTS = e.curTS
+		}
+		if e.curTS > e.windowMaxTS {
+			e.windowMaxTS = e.curTS
+		}
+	}
+
+	// parameters and key frames share the same timestamp, therefore they are counted once.
+	if e.detectKeyFrames && e.Format.PTSEqualsDTS(pkt) &&
+		(!e.keyFrameReceived || e.curTS != e.keyFrameTS) {
+		if e.keyFrameReceived && e.curTS > e.keyFrameTS {
+			e.metrics.KeyFrameInterval = time.Duration(
+				(e.curTS - e.keyFrameTS) * int64(time.Second) / e.clockRate)
+		}
+		e.keyFrameReceived = true
+		e.keyFrameTS = e.curTS
+	}
+
+	return changed, metrics
+}
+
+func (e *Estimator) closeWindow(elapsed time.Duration) (bool, Metrics) {
+	e.metrics.Bitrate = float64(e.windowBytes*8) / elapsed.Seconds()
+
+	if span := e.windowMaxTS - e.windowMinTS; span > 0 {
+		e.metrics.FrameRate = float64(e.windowFrames-1) * float64(e.clockRate) / float64(span)
+	} else {
+		e.metrics.FrameRate = 0
+	}
+
+	e.windowStarted = false
+	e.windowBytes = 0
+	e.windowFrames = 0
+
+	if e.reported != nil &&
+		relativeChange(e.reported.Bitrate, e.metrics.Bitrate) <= e.Threshold &&
+		relativeChange(e.reported.FrameRate, e.metrics.FrameRate) <= e.Threshold &&
+		relativeChange(float64(e.reported.KeyFrameInterval), float64(e.metrics.KeyFrameInterval)) <= e.Threshold {
+		return false, Metrics{}
+	}
+
+	reported := e.metrics
+	e.reported = &reported
+
+	return true, reported
+}
+
+// Metrics returns measurements of the last completed window.
+func (e *Estimator) Metrics() Metrics {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.metrics
+}
@@ -0,0 +1,86 @@
+package trackmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func TestEstimator(t *testing.T) {
+	var reports []Metrics
+
+	e := &Estimator{
+		Format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+		OnChange: func(m Metrics) {
+			reports = append(reports, m)
+		},
+	}
+	e.Initialize()
+
+	now := time.Date(2008, 5, 20, 22, 15, 20, 0, time.UTC)
+	ts := uint32(0xFFFFFFFF - 3600*10)
+
+	writeFrame := func(key bool, size int) {
+		var payload []byte
+		if key {
+			payload = append([]byte{5}, make([]byte, size-1)...)
+		} else {
+			payload = append([]byte{1}, make([]byte, size-1)...)
+		}
+
+		e.ProcessPacket(&rtp.Packet{
+			Header: rtp.Header{
+				Version:   2,
+				Marker:    true,
+				Timestamp: ts,
+			},
+			Payload: payload,
+		}, now)
+
+		ts += 3600
+		now = now.Add(40 * time.Millisecond)
+	}
+
+	// 25 FPS, key frame every 10 frames, 250 bytes per frame
+	for i := 0; i < 50; i++ {
+		writeFrame((i%10) == 0, 250)
+	}
+
+	require.Equal(t, Metrics{
+		Bitrate:          50000,
+		FrameRate:        25,
+		KeyFrameInterval: 400 * time.Millisecond,
+	}, e.Metrics())
+
+	// measurements didn't change, therefore there's a single report
+	require.Equal(t, []Metrics{{
+		Bitrate:          50000,
+		FrameRate:        25,
+		KeyFrameInterval: 400 * time.Millisecond,
+	}}, reports)
+
+	// bitrate increases
+	for i := 50; i < 76; i++ {
+		writeFrame((i%10) == 0, 500)
+	}
+
+	require.Equal(t, []Metrics{
+		{
+			Bitrate:          50000,
+			FrameRate:        25,
+			KeyFrameInterval: 400 * time.Millisecond,
+		},
+		{
+			Bitrate:          100000,
+			FrameRate:        25,
+			KeyFrameInterval: 400 * time.Millisecond,
+		},
+	}, reports)
+}
@@ -0,0 +1,132 @@
+// Package webrtcbridge contains utilities to exchange tracks with pion/webrtc.
+package webrtcbridge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	psdp "github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// CodecCapability returns the WebRTC codec capability that corresponds to a format.
+// H264 tracks are always advertised with packetization-mode=1,
+// since OutgoingTrack converts packets into this mode.
+func CodecCapability(forma format.Format) (webrtc.RTPCodecCapability, error) {
+	switch forma := forma.(type) {
+	case *format.AV1:
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeAV1,
+			ClockRate: 90000,
+		}, nil
+
+	case *format.VP9:
+		profileID := 0
+		if forma.ProfileID != nil {
+			profileID = *forma.ProfileID
+		}
+
+		return webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeVP9,
+			ClockRate:   90000,
+			SDPFmtpLine: "profile-id=" + strconv.FormatInt(int64(profileID), 10),
+		}, nil
+
+	case *format.VP8:
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeVP8,
+			ClockRate: 90000,
+		}, nil
+
+	case *format.H265:
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeH265,
+			ClockRate: 90000,
+		}, nil
+
+	case *format.H264:
+		return webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeH264,
+			ClockRate:   90000,
+			SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+		}, nil
+
+	case *format.Opus:
+		fmtp := "minptime=10;useinbandfec=1"
+		if forma.ChannelCount == 2 {
+			fmtp += ";stereo=1;sprop-stereo=1"
+		}
+
+		return webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeOpus,
+			ClockRate:   48000,
+			Channels:    2,
+			SDPFmtpLine: fmtp,
+		}, nil
+
+	case *format.G722:
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeG722,
+			ClockRate: 8000,
+		}, nil
+
+	case *format.G711:
+		if forma.SampleRate != 8000 || forma.ChannelCount != 1 {
+			return webrtc.RTPCodecCapability{}, fmt.Errorf("WebRTC supports G711 with 8000Hz and 1 channel only")
+		}
+
+		if forma.MULaw {
+			return webrtc.RTPCodecCapability{
+				MimeType:  webrtc.MimeTypePCMU,
+				ClockRate: 8000,
+			}, nil
+		}
+
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypePCMA,
+			ClockRate: 8000,
+		}, nil
+	}
+
+	return webrtc.RTPCodecCapability{}, fmt.Errorf("unsupported format: %T", forma)
+}
+
+// FormatFromCodec returns the format that corresponds to a WebRTC codec.
+// The payload type of the format is the one of the codec.
+func FormatFromCodec(codec webrtc.RTPCodecParameters) (format.Format, error) {
+	tmp := strings.SplitN(codec.MimeType, "/", 2)
+	if len(tmp) != 2 {
+		return nil, fmt.Errorf("invalid MIME type: %v", codec.MimeType)
+	}
+
+	mediaType := strings.ToLower(tmp[0])
+	pt := strconv.FormatUint(uint64(codec.PayloadType), 10)
+
+	rtpMap := pt + " " + tmp[1] + "/" + strconv.FormatUint(uint64(codec.ClockRate), 10)
+	if mediaType == "audio" && codec.Channels > 1 {
+		rtpMap += "/" + strconv.FormatUint(uint64(codec.Channels), 10)
+	}
+
+	md := &psdp.MediaDescription{
+		MediaName: psdp.MediaName{
+			Media:   mediaType,
+			Formats: []string{pt},
+		},
+		Attributes: []psdp.Attribute{{
+			Key:   "rtpmap",
+			Value: rtpMap,
+		}},
+	}
+
+	if codec.SDPFmtpLine != "" {
+		md.Attributes = append(md.Attributes, psdp.Attribute{
+			Key:   "fmtp",
+			Value: pt + " " + codec.SDPFmtpLine,
+		})
+	}
+
+	return format.Unmarshal(md, pt)
+}
@@ -0,0 +1,50 @@
+package webrtcbridge
+
+import (
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+// IncomingTrack converts RTP packets received from a WebRTC peer
+// into RTP packets of a format that can be used with Client and ServerStream.
+type IncomingTrack struct {
+	// codec of the remote track (for instance, the one returned by TrackRemote.Codec()).
+	Codec webrtc.RTPCodecParameters
+
+	// payload type of outgoing packets.
+	// It defaults to the payload type of the codec.
+	PayloadType uint8
+
+	// format of outgoing packets.
+	// It is filled by Initialize().
+	Format format.Format
+}
+
+// Initialize initializes IncomingTrack.
+func (t *IncomingTrack) Initialize() error {
+	if t.PayloadType == 0 {
+		t.PayloadType = uint8(t.Codec.PayloadType)
+	}
+
+	codec := t.Codec
+	codec.PayloadType = webrtc.PayloadType(t.PayloadType)
+
+	var err error
+	t.Format, err = FormatFromCodec(codec)
+	return err
+}
+
+// ProcessPacket converts a RTP packet (for instance, the one returned by TrackRemote.ReadRTP()).
+// The packet is modified in place.
+func (t *IncomingTrack) ProcessPacket(pkt *rtp.Packet) *rtp.Packet {
+	pkt.PayloadType = t.PayloadType
+
+	// remove header extensions, that are specific to the WebRTC session
+	pkt.Extension = false
+	pkt.Extensions = nil
+	pkt.ExtensionProfile = 0
+
+	return pkt
+}
@@ -0,0 +1,199 @@
+package webrtcbridge
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph265"
+)
+
+const (
+	// 1200 (WebRTC MTU) - 12 (RTP header)
+	webrtcPayloadMaxSize = 1188
+)
+
+// OutgoingTrack converts RTP packets of a format into RTP packets
+// that can be sent to WebRTC peers, and writes them into a local track.
+//
+// H264 and H265 packets are re-packetized in order to fit into the WebRTC MTU,
+// H264 packets are converted into packetization-mode=1 and
+// parameters are added before random access units, since browsers require them in-band.
+// The payload type and SSRC are set by pion/webrtc when packets are written.
+type OutgoingTrack struct {
+	// format of incoming packets.
+	Format format.Format
+
+	// track ID.
+	// It defaults to the media type.
+	ID string
+
+	// stream ID.
+	// It defaults to "gortsplib".
+	StreamID string
+
+	// local track.
+	// It is filled by Initialize().
+	Track *webrtc.TrackLocalStaticRTP
+
+	h264Decoder *rtph264.Decoder
+	h264Encoder *rtph264.Encoder
+	h265Decoder *rtph265.Decoder
+	h265Encoder *rtph265.Encoder
+}
+
+// Initialize initializes OutgoingTrack.
+func (t *OutgoingTrack) Initialize() error {
+	capa, err := CodecCapability(t.Format)
+	if err != nil {
+		return err
+	}
+
+	if t.ID == "" {
+		t.ID = strings.SplitN(capa.MimeType, "/", 2)[0]
+	}
+	if t.StreamID == "" {
+		t.StreamID = "gortsplib"
+	}
+
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		t.h264Decoder, err = forma.CreateDecoder()
+		if err != nil {
+			return err
+		}
+
+		t.h264Encoder = &rtph264.Encoder{
+			PayloadType:       96,
+			PacketizationMode: 1,
+			PayloadMaxSize:    webrtcPayloadMaxSize,
+		}
+		err = t.h264Encoder.Init()
+		if err != nil {
+			return err
+		}
+
+	case *format.H265:
+		t.h265Decoder, err = forma.CreateDecoder()
+		if err != nil {
+			return err
+		}
+
+		t.h265Encoder = &rtph265.Encoder{
+			PayloadType:    96,
+			PayloadMaxSize: webrtcPayloadMaxSize,
+		}
+		err = t.h265Encoder.Init()
+		if err != nil {
+			return err
+		}
+	}
+
+	t.Track, err = webrtc.NewTrackLocalStaticRTP(capa, t.ID, t.StreamID)
+	return err
+}
+
+// ProcessPacket converts a RTP packet into zero or more RTP packets
+// suitable for WebRTC peers.
+func (t *OutgoingTrack) ProcessPacket(pkt *rtp.Packet) ([]*rtp.Packet, error) {
+	switch forma := t.Format.(type) {
+	case *format.H264:
+		au, err := t.h264Decoder.Decode(pkt)
+		if err != nil {
+			if errors.Is(err, rtph264.ErrNonStartingPacketAndNoPrevious) ||
+				errors.Is(err, rtph264.ErrMorePacketsNeeded) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		if h264.IsRandomAccess(au) && !h264ContainsParams(au) {
+			sps, pps := forma.SafeParams()
+			if sps != nil && pps != nil {
+				au = append([][]byte{sps, pps}, au...)
+			}
+		}
+
+		pkts, err := t.h264Encoder.Encode(au)
+		if err != nil {
+			return nil, err
+		}
+
+		setTimestamp(pkts, pkt.Timestamp)
+		return pkts, nil
+
+	case *format.H265:
+		au, err := t.h265Decoder.Decode(pkt)
+		if err != nil {
+			if errors.Is(err, rtph265.ErrNonStartingPacketAndNoPrevious) ||
+				errors.Is(err, rtph265.ErrMorePacketsNeeded) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		if h265.IsRandomAccess(au) && !h265ContainsParams(au) {
+			vps, sps, pps := forma.SafeParams()
+			if vps != nil && sps != nil && pps != nil {
+				au = append([][]byte{vps, sps, pps}, au...)
+			}
+		}
+
+		pkts, err := t.h265Encoder.Encode(au)
+		if err != nil {
+			return nil, err
+		}
+
+		setTimestamp(pkts, pkt.Timestamp)
+		return pkts, nil
+	}
+
+	return []*rtp.Packet{pkt}, nil
+}
+
+// WritePacketRTP converts a RTP packet and writes it into the local track.
+func (t *OutgoingTrack) WritePacketRTP(pkt *rtp.Packet) error {
+	pkts, err := t.ProcessPacket(pkt)
+	if err != nil {
+		return err
+	}
+
+	for _, out := range pkts {
+		err = t.Track.WriteRTP(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setTimestamp(pkts []*rtp.Packet, ts uint32) {
+	for _, pkt := range pkts {
+		pkt.Timestamp = ts
+	}
+}
+
+func h264ContainsParams(au [][]byte) bool {
+	for _, nalu := range au {
+		if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeSPS {
+			return true
+		}
+	}
+	return false
+}
+
+func h265ContainsParams(au [][]byte) bool {
+	for _, nalu := range au {
+		if h265.NALUType((nalu[0]>>1)&0b111111) == h265.NALUType_SPS_NUT {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package webrtcbridge
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+func TestCodecCapability(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		forma format.Format
+		capa  webrtc.RTPCodecCapability
+	}{
+		{
+			"h264",
+			&format.H264{PayloadTyp: 96, PacketizationMode: 0},
+			webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeH264,
+				ClockRate:   90000,
+				SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+			},
+		},
+		{
+			"opus",
+			&format.Opus{PayloadTyp: 111, ChannelCount: 2},
+			webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeOpus,
+				ClockRate:   48000,
+				Channels:    2,
+				SDPFmtpLine: "minptime=10;useinbandfec=1;stereo=1;sprop-stereo=1",
+			},
+		},
+		{
+			"pcmu",
+			&format.G711{PayloadTyp: 0, MULaw: true, SampleRate: 8000, ChannelCount: 1},
+			webrtc.RTPCodecCapability{
+				MimeType:  webrtc.MimeTypePCMU,
+				ClockRate: 8000,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			capa, err := CodecCapability(ca.forma)
+			require.NoError(t, err)
+			require.Equal(t, ca.capa, capa)
+		})
+	}
+
+	_, err := CodecCapability(&format.G711{MULaw: true, SampleRate: 16000, ChannelCount: 1})
+	require.EqualError(t, err, "WebRTC supports G711 with 8000Hz and 1 channel only")
+}
+
+func TestIncomingTrack(t *testing.T) {
+	tr := &IncomingTrack{
+		Codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeH264,
+				ClockRate:   90000,
+				SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+			},
+			PayloadType: 102,
+		},
+		PayloadType: 96,
+	}
+	err := tr.Initialize()
+	require.NoError(t, err)
+
+	require.Equal(t, &format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+	}, tr.Format)
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:     2,
+			PayloadType: 102,
+		},
+		Payload: []byte{1, 2},
+	}
+	err = pkt.SetExtension(1, []byte{1})
+	require.NoError(t, err)
+
+	pkt = tr.ProcessPacket(pkt)
+	require.Equal(t, uint8(96), pkt.PayloadType)
+	require.False(t, pkt.Extension)
+
+	tr = &IncomingTrack{
+		Codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeOpus,
+				ClockRate:   48000,
+				Channels:    2,
+				SDPFmtpLine: "minptime=10;useinbandfec=1;sprop-stereo=1",
+			},
+			PayloadType: 111,
+		},
+	}
+	err = tr.Initialize()
+	require.NoError(t, err)
+
+	require.Equal(t, &format.Opus{
+		PayloadTyp:   111,
+		ChannelCount: 2,
+	}, tr.Format)
+}
+
+func TestOutgoingTrackH264(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+		SPS:               []byte{0x67, 1, 2, 3},
+		PPS:               []byte{0x68, 4},
+	}
+
+	tr := &OutgoingTrack{
+		Format: forma,
+	}
+	err := tr.Initialize()
+	require.NoError(t, err)
+	require.Equal(t, "video", tr.Track.ID())
+
+	enc, err := forma.CreateEncoder()
+	require.NoError(t, err)
+
+	// an IDR that is larger than the WebRTC MTU
+	idr := append([]byte{0x65}, bytes.Repeat([]byte{1}, 1400)...)
+
+	pkts, err := enc.Encode([][]byte{idr})
+	require.NoError(t, err)
+	require.Len(t, pkts, 1)
+
+	pkts[0].Timestamp = 123456
+
+	out, err := tr.ProcessPacket(pkts[0])
+	require.NoError(t, err)
+	require.Len(t, out, 3)
+
+	for _, pkt := range out {
+		require.Equal(t, uint32(123456), pkt.Timestamp)
+
+		byts, err2 := pkt.Marshal()
+		require.NoError(t, err2)
+		require.LessOrEqual(t, len(byts), 1200)
+	}
+
+	// parameters are added before the IDR
+	dec, err := forma.CreateDecoder()
+	require.NoError(t, err)
+
+	var au [][]byte
+	for _, pkt := range out {
+		au, err = dec.Decode(pkt)
+	}
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{forma.SPS, forma.PPS, idr}, au)
+
+	// packets are written without errors even if there are no bindings
+	err = tr.WritePacketRTP(pkts[0])
+	require.NoError(t, err)
+}
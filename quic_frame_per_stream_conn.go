@@ -0,0 +1,301 @@
+package gortsplib
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/quic"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+const (
+	quicALPN         = "rtsp"
+	quicCloseTimeout = 1 * time.Second
+	quicMaxFrameSize = 4 + 0xFFFF
+)
+
+func quicTLSConfig(tlsConfig *tls.Config) *tls.Config {
+	tlsConfig = tlsConfig.Clone()
+	if len(tlsConfig.NextProtos) == 0 {
+		tlsConfig.NextProtos = []string{quicALPN}
+	}
+	tlsConfig.MinVersion = tls.VersionTLS13
+	return tlsConfig
+}
+
+// quicFramePerStreamConn is a net.Conn that carries RTSP over a QUIC connection.
+// Requests and responses are sent through a bidirectional stream (control stream),
+// while each interleaved frame is sent through a dedicated unidirectional stream (frame-per-stream),
+// therefore the loss of a frame doesn't delay the following ones (no head-of-line blocking)
+// and frames can be received in a different order than the one in which they were sent.
+// Frames are not sent as QUIC datagrams (RFC 9221) since golang.org/x/net/quic doesn't support them,
+// therefore lost frames are retransmitted.
+type quicFramePerStreamConn struct {
+	qconn   *quic.Conn
+	control *quic.Stream
+	onClose func()
+
+	ctx           context.Context
+	ctxCancel     func()
+	failOnce      sync.Once
+	err           error
+	closeOnce     sync.Once
+	wg            sync.WaitGroup
+	pr            *io.PipeReader
+	pw            *io.PipeWriter
+	chunks        chan []byte
+	cur           []byte
+	deadlineMutex sync.Mutex
+	readDeadline  time.Time
+}
+
+func newQUICFramePerStreamConn(qconn *quic.Conn, control *quic.Stream, onClose func()) *quicFramePerStreamConn {
+	c := &quicFramePerStreamConn{
+		qconn:   qconn,
+		control: control,
+		onClose: onClose,
+	}
+
+	c.ctx, c.ctxCancel = context.WithCancel(context.Background())
+	c.control.SetReadContext(c.ctx)
+	c.control.SetWriteContext(c.ctx)
+	c.pr, c.pw = io.Pipe()
+	c.chunks = make(chan []byte)
+
+	c.wg.Add(3)
+	go c.runWriter()
+	go c.runControlReader()
+	go c.runStreamAcceptor()
+
+	return c
+}
+
+func (c *quicFramePerStreamConn) fail(err error) {
+	c.failOnce.Do(func() {
+		c.err = err
+		c.ctxCancel()
+	})
+}
+
+// Close implements net.Conn.
+func (c *quicFramePerStreamConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.fail(net.ErrClosed)
+		c.pw.Close()
+		c.qconn.Abort(nil)
+		c.wg.Wait()
+
+		if c.onClose != nil {
+			c.onClose()
+		}
+	})
+	return nil
+}
+
+// Read implements net.Conn.
+func (c *quicFramePerStreamConn) Read(b []byte) (int, error) {
+	if len(c.cur) == 0 {
+		c.deadlineMutex.Lock()
+		deadline := c.readDeadline
+		c.deadlineMutex.Unlock()
+
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			t := time.NewTimer(time.Until(deadline))
+			defer t.Stop()
+			timeout = t.C
+		}
+
+		select {
+		case c.cur = <-c.chunks:
+		case <-timeout:
+			return 0, os.ErrDeadlineExceeded
+		case <-c.ctx.Done():
+			return 0, c.err
+		}
+	}
+
+	n := copy(b, c.cur)
+	c.cur = c.cur[n:]
+	return n, nil
+}
+
+// Write implements net.Conn.
+func (c *quicFramePerStreamConn) Write(b []byte) (int, error) {
+	return c.pw.Write(b)
+}
+
+// LocalAddr implements net.Conn.
+// Addresses are returned as TCP addresses, like the ones of the other connections,
+// since they identify a reliable, stream-oriented channel.
+func (c *quicFramePerStreamConn) LocalAddr() net.Addr {
+	return net.TCPAddrFromAddrPort(c.qconn.LocalAddr())
+}
+
+// RemoteAddr implements net.Conn.
+func (c *quicFramePerStreamConn) RemoteAddr() net.Addr {
+	return net.TCPAddrFromAddrPort(c.qconn.RemoteAddr())
+}
+
+// SetDeadline implements net.Conn.
+func (c *quicFramePerStreamConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+func (c *quicFramePerStreamConn) SetReadDeadline(t time.Time) error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline implements net.Conn.
+func (c *quicFramePerStreamConn) SetWriteDeadline(_ time.Time) error {
+	return nil
+}
+
+// runWriter splits written data into messages and frames,
+// and routes them to the control stream or to dedicated streams.
+func (c *quicFramePerStreamConn) runWriter() {
+	defer c.wg.Done()
+
+	br := bufio.NewReader(c.pr)
+
+	for {
+		err := c.writeNext(br)
+		if err != nil {
+			c.pr.CloseWithError(err)
+			c.fail(err)
+			return
+		}
+	}
+}
+
+func (c *quicFramePerStreamConn) writeNext(br *bufio.Reader) error {
+	byts, err := br.Peek(1)
+	if err != nil {
+		return err
+	}
+
+	if byts[0] == base.InterleavedFrameMagicByte {
+		var fr base.InterleavedFrame
+		err = fr.Unmarshal(br)
+		if err != nil {
+			return err
+		}
+
+		var buf []byte
+		buf, err = fr.Marshal()
+		if err != nil {
+			return err
+		}
+
+		var s *quic.Stream
+		s, err = c.qconn.NewSendOnlyStream(c.ctx)
+		if err != nil {
+			return err
+		}
+
+		s.SetWriteContext(c.ctx)
+		_, err = s.Write(buf)
+		s.CloseWrite()
+		return err
+	}
+
+	buf, err := quicReadMessage(br)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.control.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	return c.control.Flush()
+}
+
+func (c *quicFramePerStreamConn) runControlReader() {
+	defer c.wg.Done()
+
+	br := bufio.NewReader(c.control)
+
+	for {
+		buf, err := quicReadMessage(br)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		select {
+		case c.chunks <- buf:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *quicFramePerStreamConn) runStreamAcceptor() {
+	defer c.wg.Done()
+
+	for {
+		s, err := c.qconn.AcceptStream(c.ctx)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		c.wg.Add(1)
+		go c.readFrame(s)
+	}
+}
+
+func (c *quicFramePerStreamConn) readFrame(s *quic.Stream) {
+	defer c.wg.Done()
+
+	s.SetReadContext(c.ctx)
+	buf, err := io.ReadAll(io.LimitReader(s, quicMaxFrameSize))
+	s.CloseRead()
+
+	if err != nil || len(buf) < 4 || buf[0] != base.InterleavedFrameMagicByte ||
+		len(buf) != 4+int(uint16(buf[2])<<8|uint16(buf[3])) {
+		return
+	}
+
+	select {
+	case c.chunks <- buf:
+	case <-c.ctx.Done():
+	}
+}
+
+// quicReadMessage reads a request or a response and returns it in binary format.
+func quicReadMessage(br *bufio.Reader) ([]byte, error) {
+	byts, err := br.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(byts) == "RTSP" {
+		var res base.Response
+		err = res.Unmarshal(br)
+		if err != nil {
+			return nil, err
+		}
+		return res.Marshal()
+	}
+
+	var req base.Request
+	err = req.Unmarshal(br)
+	if err != nil {
+		return nil, err
+	}
+	return req.Marshal()
+}
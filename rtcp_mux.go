@@ -0,0 +1,35 @@
+package gortsplib
+
+// isRTCPPacket checks whether a packet is a RTCP packet,
+// by using the first two bytes of the header, as described in RFC5761, section 4.
+// This works with SRTP and SRTCP too, since these bytes are not encrypted.
+func isRTCPPacket(payload []byte) bool {
+	return len(payload) >= 2 && (payload[0]>>6) == 2 && payload[1] >= 192 && payload[1] <= 223
+}
+
+// isRTPPacket checks whether a packet is a RTP packet.
+func isRTPPacket(payload []byte) bool {
+	return len(payload) >= 2 && (payload[0]>>6) == 2 && (payload[1] < 192 || payload[1] > 223)
+}
+
+// some devices multiplex RTP and RTCP packets on the same port (RFC5761).
+// These functions route packets received on a RTP or RTCP port to the right callback,
+// defaulting to the callback associated with the port when the packet is not recognized.
+
+func demuxRTPPort(rtpFunc readFunc, rtcpFunc readFunc) readFunc {
+	return func(payload []byte) bool {
+		if isRTCPPacket(payload) {
+			return rtcpFunc(payload)
+		}
+		return rtpFunc(payload)
+	}
+}
+
+func demuxRTCPPort(rtpFunc readFunc, rtcpFunc readFunc) readFunc {
+	return func(payload []byte) bool {
+		if isRTPPacket(payload) {
+			return rtpFunc(payload)
+		}
+		return rtcpFunc(payload)
+	}
+}
@@ -0,0 +1,146 @@
+package gortsplib
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+)
+
+type rtpRefragmenterCodec int
+
+const (
+	rtpRefragmenterCodecNone rtpRefragmenterCodec = iota
+	rtpRefragmenterCodecH264
+	rtpRefragmenterCodecH265
+)
+
+// rtpRefragmenter splits RTP packets that are bigger than a maximum size.
+// Since splitting increases the number of packets,
+// sequence numbers of all subsequent packets are shifted.
+type rtpRefragmenter struct {
+	format  format.Format
+	maxSize int
+
+	codec     rtpRefragmenterCodec
+	seqOffset uint16
+}
+
+func (r *rtpRefragmenter) initialize() {
+	switch r.format.(type) {
+	case *format.H264:
+		r.codec = rtpRefragmenterCodecH264
+
+	case *format.H265:
+		r.codec = rtpRefragmenterCodecH265
+	}
+}
+
+// process returns the packets to send in place of pkt.
+// pkt is never modified.
+func (r *rtpRefragmenter) process(pkt *rtp.Packet) ([]*rtp.Packet, error) {
+	if pkt.MarshalSize() <= r.maxSize {
+		if r.seqOffset == 0 {
+			return []*rtp.Packet{pkt}, nil
+		}
+
+		out := &rtp.Packet{
+			Header:  pkt.Header,
+			Payload: pkt.Payload,
+		}
+		out.SequenceNumber += r.seqOffset
+		return []*rtp.Packet{out}, nil
+	}
+
+	var prefix []byte // FU indicator or payload header
+	var fuHeader byte
+	var data []byte
+
+	switch r.codec {
+	case rtpRefragmenterCodecH264:
+		if len(pkt.Payload) < 2 {
+			return nil, fmt.Errorf("invalid payload")
+		}
+
+		typ := pkt.Payload[0] & 0x1F
+
+		switch {
+		case typ >= 1 && typ <= 23: // single NALU
+			prefix = []byte{(pkt.Payload[0] & 0xE0) | 28}
+			fuHeader = 0x80 | 0x40 | typ
+			data = pkt.Payload[1:]
+
+		case typ == 28: // FU-A
+			prefix = pkt.Payload[:1]
+			fuHeader = pkt.Payload[1]
+			data = pkt.Payload[2:]
+
+		default:
+			return nil, fmt.Errorf("unable to split H264 packets of type %d", typ)
+		}
+
+	case rtpRefragmenterCodecH265:
+		if len(pkt.Payload) < 3 {
+			return nil, fmt.Errorf("invalid payload")
+		}
+
+		typ := (pkt.Payload[0] >> 1) & 0x3F
+
+		switch {
+		case typ < 48: // single NALU
+			prefix = []byte{(pkt.Payload[0] & 0x81) | (49 << 1), pkt.Payload[1]}
+			fuHeader = 0x80 | 0x40 | typ
+			data = pkt.Payload[2:]
+
+		case typ == 49: // FU
+			prefix = pkt.Payload[:2]
+			fuHeader = pkt.Payload[2]
+			data = pkt.Payload[3:]
+
+		default:
+			return nil, fmt.Errorf("unable to split H265 packets of type %d", typ)
+		}
+
+	default:
+		return nil, fmt.Errorf("packet size (%d) is greater than maximum (%d) and codec doesn't support splitting",
+			pkt.MarshalSize(), r.maxSize)
+	}
+
+	maxDataSize := r.maxSize - pkt.Header.MarshalSize() - len(prefix) - 1
+	if maxDataSize <= 0 {
+		return nil, fmt.Errorf("maximum size (%d) is too small", r.maxSize)
+	}
+
+	count := (len(data) + maxDataSize - 1) / maxDataSize
+	ret := make([]*rtp.Packet, count)
+
+	for i := range count {
+		chunk := data[i*maxDataSize : min((i+1)*maxDataSize, len(data))]
+
+		h := fuHeader & 0x3F // remove start and end bits
+		if i == 0 {
+			h |= fuHeader & 0x80
+		}
+		if i == count-1 {
+			h |= fuHeader & 0x40
+		}
+
+		payload := make([]byte, len(prefix)+1+len(chunk))
+		n := copy(payload, prefix)
+		payload[n] = h
+		copy(payload[n+1:], chunk)
+
+		out := &rtp.Packet{
+			Header:  pkt.Header,
+			Payload: payload,
+		}
+		out.SequenceNumber += r.seqOffset + uint16(i)
+		out.Marker = pkt.Marker && i == count-1
+		ret[i] = out
+	}
+
+	r.seqOffset += uint16(count - 1)
+
+	return ret, nil
+}
@@ -0,0 +1,113 @@
+package gortsplib
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph265"
+)
+
+func TestRTPRefragmenter(t *testing.T) {
+	for _, ca := range []string{"h264", "h265"} {
+		t.Run(ca, func(t *testing.T) {
+			var forma format.Format
+			var nalu []byte
+			var decode func(*rtp.Packet) ([][]byte, error)
+
+			if ca == "h264" {
+				forma = &format.H264{PayloadTyp: 96, PacketizationMode: 1}
+				nalu = append([]byte{0x65}, bytes.Repeat([]byte{1, 2, 3, 4}, 100)...)
+
+				dec, err := forma.(*format.H264).CreateDecoder()
+				require.NoError(t, err)
+				decode = func(pkt *rtp.Packet) ([][]byte, error) {
+					au, err2 := dec.Decode(pkt)
+					if errors.Is(err2, rtph264.ErrMorePacketsNeeded) {
+						return nil, nil
+					}
+					return au, err2
+				}
+			} else {
+				forma = &format.H265{PayloadTyp: 96}
+				nalu = append([]byte{0x26, 0x01}, bytes.Repeat([]byte{1, 2, 3, 4}, 100)...)
+
+				dec, err := forma.(*format.H265).CreateDecoder()
+				require.NoError(t, err)
+				decode = func(pkt *rtp.Packet) ([][]byte, error) {
+					au, err2 := dec.Decode(pkt)
+					if errors.Is(err2, rtph265.ErrMorePacketsNeeded) {
+						return nil, nil
+					}
+					return au, err2
+				}
+			}
+
+			r := &rtpRefragmenter{
+				format:  forma,
+				maxSize: 100,
+			}
+			r.initialize()
+
+			pkts, err := r.process(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 100,
+					Timestamp:      1000,
+				},
+				Payload: nalu,
+			})
+			require.NoError(t, err)
+			require.Greater(t, len(pkts), 1)
+
+			var au [][]byte
+
+			for i, pkt := range pkts {
+				require.LessOrEqual(t, pkt.MarshalSize(), 100)
+				require.Equal(t, uint16(100+i), pkt.SequenceNumber)
+				require.Equal(t, i == len(pkts)-1, pkt.Marker)
+
+				au, err = decode(pkt)
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, [][]byte{nalu}, au)
+
+			// sequence numbers of following packets are shifted
+			pkts2, err := r.process(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 101,
+					Timestamp:      2000,
+				},
+				Payload: nalu[:20],
+			})
+			require.NoError(t, err)
+			require.Len(t, pkts2, 1)
+			require.Equal(t, uint16(100+len(pkts)), pkts2[0].SequenceNumber)
+		})
+	}
+}
+
+func TestRTPRefragmenterUnsupported(t *testing.T) {
+	r := &rtpRefragmenter{
+		format:  &format.Opus{PayloadTyp: 96, ChannelCount: 2},
+		maxSize: 100,
+	}
+	r.initialize()
+
+	_, err := r.process(&rtp.Packet{
+		Header:  rtp.Header{Version: 2, PayloadType: 96},
+		Payload: make([]byte, 200),
+	})
+	require.EqualError(t, err, "packet size (212) is greater than maximum (100) and codec doesn't support splitting")
+}
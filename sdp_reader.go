@@ -0,0 +1,522 @@
+package gortsplib
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	psdp "github.com/pion/sdp/v3"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v5/pkg/multicast"
+	"github.com/bluenviron/gortsplib/v5/pkg/readbuffer"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtpreceiver"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtptime"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+func sdpConnectionAddress(ci *psdp.ConnectionInformation) (net.IP, error) {
+	if ci == nil || ci.Address == nil || ci.Address.Address == "" {
+		return nil, fmt.Errorf("connection address not provided")
+	}
+
+	// remove TTL and number of addresses
+	addr := strings.Split(ci.Address.Address, "/")[0]
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		ips, err := net.LookupIP(addr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to solve connection address: %w", err)
+		}
+		ip = ips[0]
+	}
+
+	return ip, nil
+}
+
+func sdpRTCPPort(md *psdp.MediaDescription) (int, error) {
+	for _, attr := range md.Attributes {
+		if attr.Key == "rtcp" {
+			// https://datatracker.ietf.org/doc/html/rfc3605
+			fields := strings.Fields(attr.Value)
+			if len(fields) == 0 {
+				return 0, fmt.Errorf("invalid rtcp attribute")
+			}
+
+			tmp, err := strconv.ParseUint(fields[0], 10, 16)
+			if err != nil {
+				return 0, fmt.Errorf("invalid rtcp attribute: %w", err)
+			}
+			return int(tmp), nil
+		}
+	}
+
+	return md.MediaName.Port.Value + 1, nil
+}
+
+type sdpReaderFormat struct {
+	sm          *sdpReaderMedia
+	format      format.Format
+	onPacketRTP OnPacketRTPFunc
+
+	rtpReceiver *rtpreceiver.Receiver
+}
+
+func (sf *sdpReaderFormat) initialize() error {
+	sf.onPacketRTP = func(*rtp.Packet) {}
+
+	sf.rtpReceiver = &rtpreceiver.Receiver{
+		ClockRate:            sf.format.ClockRate(),
+		LocalSSRC:            sf.sm.localSSRC,
+		UnrealiableTransport: true,
+		Period:               sf.sm.r.receiverReportPeriod,
+		TimeNow:              sf.sm.r.timeNow,
+		WritePacketRTCP:      sf.sm.writePacketRTCP,
+	}
+	return sf.rtpReceiver.Initialize()
+}
+
+func (sf *sdpReaderFormat) close() {
+	sf.rtpReceiver.Close()
+}
+
+func (sf *sdpReaderFormat) readPacketRTP(pkt *rtp.Packet) {
+	pkts, lost, err := sf.rtpReceiver.ProcessPacket(pkt, sf.sm.r.timeNow(), sf.format.PTSEqualsDTS(pkt))
+	if err != nil {
+		sf.sm.r.OnDecodeError(err)
+		return
+	}
+
+	if lost != 0 {
+		sf.sm.r.OnPacketsLost(lost)
+	}
+
+	for _, pkt := range pkts {
+		sf.onPacketRTP(pkt)
+	}
+}
+
+type sdpReaderMedia struct {
+	r         *SDPReader
+	media     *description.Media
+	ip        net.IP
+	rtpPort   int
+	rtcpPort  int
+	localSSRC uint32
+
+	rtpConn      net.PacketConn
+	rtcpConn     net.PacketConn
+	formats      map[uint8]*sdpReaderFormat
+	onPacketRTCP OnPacketRTCPFunc
+
+	mutex     sync.Mutex
+	rtcpAddr  *net.UDPAddr
+	rtpDone   chan struct{}
+	rtcpDone  chan struct{}
+	isStarted bool
+}
+
+func (sm *sdpReaderMedia) listen(port int) (net.PacketConn, error) {
+	var pc net.PacketConn
+
+	address := net.JoinHostPort(sm.ip.String(), strconv.FormatInt(int64(port), 10))
+
+	if sm.ip.IsMulticast() {
+		var err error
+		if sm.r.MulticastInterface != nil {
+			pc, err = multicast.NewSingleConn(sm.r.MulticastInterface, address, sm.r.ListenPacket)
+		} else {
+			pc, err = multicast.NewMultiConn(address, false, sm.r.ListenPacket)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		pc, err = sm.r.ListenPacket(restrictNetwork("udp",
+			net.JoinHostPort("", strconv.FormatInt(int64(port), 10))))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sm.r.UDPReadBufferSize != 0 {
+		err := readbuffer.SetReadBuffer(pc.(packetConn), sm.r.UDPReadBufferSize)
+		if err != nil {
+			pc.Close()
+			return nil, err
+		}
+	}
+
+	return pc, nil
+}
+
+func (sm *sdpReaderMedia) initialize() error {
+	sm.onPacketRTCP = func(rtcp.Packet) {}
+
+	if sm.ip.IsMulticast() {
+		sm.rtcpAddr = &net.UDPAddr{IP: sm.ip, Port: sm.rtcpPort}
+	}
+
+	var err error
+	sm.localSSRC, err = randUint32()
+	if err != nil {
+		return err
+	}
+
+	sm.rtpConn, err = sm.listen(sm.rtpPort)
+	if err != nil {
+		return err
+	}
+
+	sm.rtcpConn, err = sm.listen(sm.rtcpPort)
+	if err != nil {
+		sm.rtpConn.Close()
+		return err
+	}
+
+	sm.formats = make(map[uint8]*sdpReaderFormat)
+
+	for _, forma := range sm.media.Formats {
+		sf := &sdpReaderFormat{
+			sm:     sm,
+			format: forma,
+		}
+		err = sf.initialize()
+		if err != nil {
+			sm.close()
+			return err
+		}
+		sm.formats[forma.PayloadType()] = sf
+	}
+
+	return nil
+}
+
+func (sm *sdpReaderMedia) close() {
+	sm.rtpConn.Close()
+	sm.rtcpConn.Close()
+
+	if sm.isStarted {
+		<-sm.rtpDone
+		<-sm.rtcpDone
+	}
+
+	for _, sf := range sm.formats {
+		sf.close()
+	}
+}
+
+func (sm *sdpReaderMedia) start() {
+	sm.isStarted = true
+	sm.rtpDone = make(chan struct{})
+	sm.rtcpDone = make(chan struct{})
+
+	go sm.runReader(sm.rtpConn, sm.rtpDone, sm.readPacketRTP)
+	go sm.runReader(sm.rtcpConn, sm.rtcpDone, sm.readPacketRTCP)
+}
+
+func (sm *sdpReaderMedia) runReader(pc net.PacketConn, done chan struct{}, cb func([]byte, *net.UDPAddr)) {
+	defer close(done)
+
+	buf := make([]byte, udpMaxPayloadSize+1)
+
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if n == len(buf) {
+			sm.r.OnDecodeError(liberrors.ErrClientRTPPacketTooBigUDP{})
+			continue
+		}
+
+		// copy the packet, since it's passed to callbacks.
+		cb(append([]byte(nil), buf[:n]...), addr.(*net.UDPAddr))
+	}
+}
+
+func (sm *sdpReaderMedia) readPacketRTP(payload []byte, _ *net.UDPAddr) {
+	var pkt rtp.Packet
+	err := pkt.Unmarshal(payload)
+	if err != nil {
+		sm.r.OnDecodeError(err)
+		return
+	}
+
+	sf, ok := sm.formats[pkt.PayloadType]
+	if !ok {
+		sm.r.OnDecodeError(liberrors.ErrClientRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+		return
+	}
+
+	sf.readPacketRTP(&pkt)
+}
+
+func (sm *sdpReaderMedia) readPacketRTCP(payload []byte, addr *net.UDPAddr) {
+	packets, err := rtcp.Unmarshal(payload)
+	if err != nil {
+		sm.r.OnDecodeError(err)
+		return
+	}
+
+	// when the stream is unicast, send receiver reports to the sender of RTCP packets.
+	if !sm.ip.IsMulticast() {
+		sm.mutex.Lock()
+		sm.rtcpAddr = addr
+		sm.mutex.Unlock()
+	}
+
+	now := sm.r.timeNow()
+
+	for _, pkt := range packets {
+		if sr, ok := pkt.(*rtcp.SenderReport); ok {
+			for _, sf := range sm.formats {
+				if stats := sf.rtpReceiver.Stats(); stats != nil && stats.RemoteSSRC == sr.SSRC {
+					sf.rtpReceiver.ProcessSenderReport(sr, now)
+				}
+			}
+		}
+
+		sm.onPacketRTCP(pkt)
+	}
+}
+
+func (sm *sdpReaderMedia) writePacketRTCP(pkt rtcp.Packet) {
+	sm.mutex.Lock()
+	addr := sm.rtcpAddr
+	sm.mutex.Unlock()
+
+	if addr == nil {
+		return
+	}
+
+	buf, err := pkt.Marshal()
+	if err != nil {
+		return
+	}
+
+	sm.rtcpConn.WriteTo(buf, addr) //nolint:errcheck
+}
+
+// SDPReader reads a stream described by a SDP through plain RTP/UDP,
+// without any RTSP control channel.
+// This allows to read streams that are usually opened by passing a .sdp file to players.
+//
+// Medias are received on the address and port specified in the connection
+// and media lines of the SDP. When the address is a multicast address, the
+// multicast group is joined, otherwise packets are received on all interfaces.
+type SDPReader struct {
+	//
+	// target (required)
+	//
+	// parsed SDP, containing connection addresses and media ports.
+	SDP *sdp.SessionDescription
+
+	//
+	// parameters (all optional)
+	//
+	// interface used to join multicast groups.
+	// It defaults to all multicast-capable interfaces.
+	MulticastInterface *net.Interface
+	// Size of the UDP read buffer.
+	// It defaults to the operating system default value.
+	UDPReadBufferSize int
+
+	//
+	// system functions (all optional)
+	//
+	// function used to initialize UDP listeners.
+	// It defaults to net.ListenPacket.
+	ListenPacket func(network, address string) (net.PacketConn, error)
+
+	//
+	// callbacks (all optional)
+	//
+	// called when the reader detects lost packets.
+	OnPacketsLost ClientOnPacketsLostFunc
+	// called when a non-fatal decode error occurs.
+	OnDecodeError ClientOnDecodeErrorFunc
+
+	//
+	// private
+	//
+
+	timeNow              func() time.Time
+	receiverReportPeriod time.Duration
+
+	desc        *description.Session
+	medias      map[*description.Media]*sdpReaderMedia
+	timeDecoder *rtptime.GlobalDecoder
+}
+
+// Initialize initializes SDPReader and opens UDP listeners.
+// Callbacks can be set after Initialize() and before Start().
+func (r *SDPReader) Initialize() error {
+	if r.SDP == nil {
+		return fmt.Errorf("SDP not provided")
+	}
+
+	if r.ListenPacket == nil {
+		r.ListenPacket = net.ListenPacket
+	}
+	if r.OnPacketsLost == nil {
+		r.OnPacketsLost = func(lost uint64) {
+			log.Printf("%d RTP %s lost",
+				lost,
+				func() string {
+					if lost == 1 {
+						return "packet"
+					}
+					return "packets"
+				}())
+		}
+	}
+	if r.OnDecodeError == nil {
+		r.OnDecodeError = func(err error) {
+			log.Println(err.Error())
+		}
+	}
+	if r.timeNow == nil {
+		r.timeNow = time.Now
+	}
+	if r.receiverReportPeriod == 0 {
+		r.receiverReportPeriod = 5 * time.Second
+	}
+
+	var desc description.Session
+	err := desc.Unmarshal(r.SDP)
+	if err != nil {
+		return err
+	}
+	r.desc = &desc
+
+	r.timeDecoder = &rtptime.GlobalDecoder{}
+	r.timeDecoder.Initialize()
+
+	r.medias = make(map[*description.Media]*sdpReaderMedia)
+
+	for i, md := range r.SDP.MediaDescriptions {
+		ci := md.ConnectionInformation
+		if ci == nil {
+			ci = r.SDP.ConnectionInformation
+		}
+
+		var ip net.IP
+		ip, err = sdpConnectionAddress(ci)
+		if err != nil {
+			r.Close()
+			return err
+		}
+
+		if md.MediaName.Port.Value == 0 {
+			r.Close()
+			return fmt.Errorf("media %d has no port", i+1)
+		}
+
+		var rtcpPort int
+		rtcpPort, err = sdpRTCPPort(md)
+		if err != nil {
+			r.Close()
+			return err
+		}
+
+		sm := &sdpReaderMedia{
+			r:        r,
+			media:    desc.Medias[i],
+			ip:       ip,
+			rtpPort:  md.MediaName.Port.Value,
+			rtcpPort: rtcpPort,
+		}
+		err = sm.initialize()
+		if err != nil {
+			r.Close()
+			return err
+		}
+
+		r.medias[sm.media] = sm
+	}
+
+	return nil
+}
+
+// Start starts reading packets.
+func (r *SDPReader) Start() {
+	for _, sm := range r.medias {
+		sm.start()
+	}
+}
+
+// Close closes all UDP listeners.
+func (r *SDPReader) Close() {
+	for _, sm := range r.medias {
+		sm.close()
+	}
+	r.medias = nil
+}
+
+// Description returns the session description.
+func (r *SDPReader) Description() *description.Session {
+	return r.desc
+}
+
+// OnPacketRTPAny sets a callback that is called when a RTP packet is read from any media.
+func (r *SDPReader) OnPacketRTPAny(cb OnPacketRTPAnyFunc) {
+	for _, sm := range r.medias {
+		cmedia := sm.media
+		for _, forma := range sm.media.Formats {
+			r.OnPacketRTP(sm.media, forma, func(pkt *rtp.Packet) {
+				cb(cmedia, forma, pkt)
+			})
+		}
+	}
+}
+
+// OnPacketRTCPAny sets a callback that is called when a RTCP packet is read from any media.
+func (r *SDPReader) OnPacketRTCPAny(cb OnPacketRTCPAnyFunc) {
+	for _, sm := range r.medias {
+		cmedia := sm.media
+		r.OnPacketRTCP(sm.media, func(pkt rtcp.Packet) {
+			cb(cmedia, pkt)
+		})
+	}
+}
+
+// OnPacketRTP sets a callback that is called when a RTP packet is read.
+func (r *SDPReader) OnPacketRTP(medi *description.Media, forma format.Format, cb OnPacketRTPFunc) {
+	sm := r.medias[medi]
+	sf := sm.formats[forma.PayloadType()]
+	sf.onPacketRTP = cb
+}
+
+// OnPacketRTCP sets a callback that is called when a RTCP packet is read.
+func (r *SDPReader) OnPacketRTCP(medi *description.Media, cb OnPacketRTCPFunc) {
+	sm := r.medias[medi]
+	sm.onPacketRTCP = cb
+}
+
+// PacketPTS returns the PTS (presentation timestamp) of an incoming RTP packet.
+// It is computed by decoding the packet timestamp and sychronizing it with other tracks.
+func (r *SDPReader) PacketPTS(medi *description.Media, pkt *rtp.Packet) (int64, bool) {
+	sm := r.medias[medi]
+	sf := sm.formats[pkt.PayloadType]
+	return r.timeDecoder.Decode(sf.format, pkt)
+}
+
+// PacketNTP returns the NTP (absolute timestamp) of an incoming RTP packet.
+// The NTP is computed from RTCP sender reports.
+func (r *SDPReader) PacketNTP(medi *description.Media, pkt *rtp.Packet) (time.Time, bool) {
+	sm := r.medias[medi]
+	sf := sm.formats[pkt.PayloadType]
+	return sf.rtpReceiver.PacketNTP(pkt.Timestamp)
+}
@@ -0,0 +1,147 @@
+package gortsplib
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+func TestSDPReader(t *testing.T) {
+	var sd sdp.SessionDescription
+	err := sd.Unmarshal([]byte("v=0\r\n" +
+		"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+		"s=Stream\r\n" +
+		"c=IN IP4 127.0.0.1\r\n" +
+		"t=0 0\r\n" +
+		"m=video 35466 RTP/AVP 96\r\n" +
+		"a=rtpmap:96 H264/90000\r\n" +
+		"a=fmtp:96 packetization-mode=1\r\n"))
+	require.NoError(t, err)
+
+	r := &SDPReader{
+		SDP: &sd,
+	}
+	err = r.Initialize()
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Len(t, r.Description().Medias, 1)
+	require.Equal(t, description.MediaTypeVideo, r.Description().Medias[0].Type)
+
+	type recvPacket struct {
+		medi  *description.Media
+		forma format.Format
+		pkt   *rtp.Packet
+		ptsOK bool
+	}
+
+	packetRecv := make(chan recvPacket, 1)
+
+	r.OnPacketRTPAny(func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+		_, ok := r.PacketPTS(medi, pkt)
+		packetRecv <- recvPacket{medi, forma, pkt, ok}
+	})
+
+	r.Start()
+
+	l, err := net.Dial("udp", "127.0.0.1:35466")
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = l.Write(testRTPPacketMarshaled)
+	require.NoError(t, err)
+
+	var expected rtp.Packet
+	err = expected.Unmarshal(testRTPPacketMarshaled)
+	require.NoError(t, err)
+
+	recv := <-packetRecv
+	require.Equal(t, r.Description().Medias[0], recv.medi)
+	require.Equal(t, 96, int(recv.forma.PayloadType()))
+	require.Equal(t, &expected, recv.pkt)
+	require.True(t, recv.ptsOK)
+}
+
+func TestSDPReaderErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		sdp  string
+		err  string
+	}{
+		{
+			"missing connection address",
+			"v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=Stream\r\n" +
+				"t=0 0\r\n" +
+				"m=video 35466 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n",
+			"connection address not provided",
+		},
+		{
+			"missing port",
+			"v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=Stream\r\n" +
+				"c=IN IP4 127.0.0.1\r\n" +
+				"t=0 0\r\n" +
+				"m=video 0 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n",
+			"media 1 has no port",
+		},
+		{
+			"empty rtcp attribute",
+			"v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=Stream\r\n" +
+				"c=IN IP4 127.0.0.1\r\n" +
+				"t=0 0\r\n" +
+				"m=video 35466 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp\r\n",
+			"invalid rtcp attribute",
+		},
+		{
+			"empty rtcp attribute value",
+			"v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=Stream\r\n" +
+				"c=IN IP4 127.0.0.1\r\n" +
+				"t=0 0\r\n" +
+				"m=video 35466 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp:\r\n",
+			"invalid rtcp attribute",
+		},
+		{
+			"invalid rtcp attribute",
+			"v=0\r\n" +
+				"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+				"s=Stream\r\n" +
+				"c=IN IP4 127.0.0.1\r\n" +
+				"t=0 0\r\n" +
+				"m=video 35466 RTP/AVP 96\r\n" +
+				"a=rtpmap:96 H264/90000\r\n" +
+				"a=rtcp:abc\r\n",
+			"invalid rtcp attribute: strconv.ParseUint: parsing \"abc\": invalid syntax",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var sd sdp.SessionDescription
+			err := sd.Unmarshal([]byte(ca.sdp))
+			require.NoError(t, err)
+
+			r := &SDPReader{
+				SDP: &sd,
+			}
+			err = r.Initialize()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	psdp "github.com/pion/sdp/v3"
+	"golang.org/x/net/ipv4"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtpsender"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+const (
+	sdpWriterMulticastTTL = 16
+)
+
+type sdpWriterFormat struct {
+	sm        *sdpWriterMedia
+	format    format.Format
+	localSSRC uint32
+
+	rtpSender *rtpsender.Sender
+
+	mutex sync.Mutex
+	buf   []byte
+}
+
+func (sf *sdpWriterFormat) initialize() {
+	sf.buf = make([]byte, sf.sm.w.MaxPacketSize)
+
+	sf.rtpSender = &rtpsender.Sender{
+		ClockRate: sf.format.ClockRate(),
+		Period:    sf.sm.w.senderReportPeriod,
+		TimeNow:   sf.sm.w.timeNow,
+		WritePacketRTCP: func(pkt rtcp.Packet) {
+			if !sf.sm.w.DisableRTCPSenderReports {
+				sf.sm.writePacketRTCP(pkt) //nolint:errcheck
+			}
+		},
+	}
+	sf.rtpSender.Initialize()
+}
+
+func (sf *sdpWriterFormat) close() {
+	sf.rtpSender.Close()
+}
+
+func (sf *sdpWriterFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time) error {
+	pkt.SSRC = sf.localSSRC
+
+	sf.rtpSender.ProcessPacket(pkt, ntp, sf.format.PTSEqualsDTS(pkt))
+
+	// the buffer is reused in order to avoid an allocation for each packet.
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	n, err := pkt.MarshalTo(sf.buf)
+	if err != nil {
+		return err
+	}
+
+	_, err = sf.sm.w.pc.WriteTo(sf.buf[:n], sf.sm.rtpAddr)
+	return err
+}
+
+type sdpWriterMedia struct {
+	w        *SDPWriter
+	media    *description.Media
+	rtpAddr  *net.UDPAddr
+	rtcpAddr *net.UDPAddr
+
+	formats map[uint8]*sdpWriterFormat
+}
+
+func (sm *sdpWriterMedia) initialize() error {
+	localSSRCs, err := generateLocalSSRCs(nil, sm.media.Formats)
+	if err != nil {
+		return err
+	}
+
+	sm.formats = make(map[uint8]*sdpWriterFormat)
+
+	for _, forma := range sm.media.Formats {
+		sf := &sdpWriterFormat{
+			sm:        sm,
+			format:    forma,
+			localSSRC: localSSRCs[forma.PayloadType()],
+		}
+		sf.initialize()
+		sm.formats[forma.PayloadType()] = sf
+	}
+
+	return nil
+}
+
+func (sm *sdpWriterMedia) close() {
+	for _, sf := range sm.formats {
+		sf.close()
+	}
+}
+
+func (sm *sdpWriterMedia) writePacketRTCP(pkt rtcp.Packet) error {
+	buf, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if len(buf) > sm.w.MaxPacketSize {
+		return fmt.Errorf("RTCP packet size (%d) is greater than maximum allowed (%d)",
+			len(buf), sm.w.MaxPacketSize)
+	}
+
+	_, err = sm.w.pc.WriteTo(buf, sm.rtcpAddr)
+	return err
+}
+
+// SDPWriter sends medias to fixed UDP destinations through plain RTP/UDP,
+// without any RTSP control channel, together with periodic RTCP sender reports.
+// The SDP that describes the stream can be passed to receivers
+// (for instance, FFmpeg or GStreamer) in order to read it.
+type SDPWriter struct {
+	//
+	// target (required)
+	//
+	// medias to send.
+	Desc *description.Session
+	// destination host. It can be a unicast or multicast address.
+	Host string
+	// destination RTP ports, one for each media.
+	// RTCP packets are sent to the RTP port + 1.
+	Ports []int
+
+	//
+	// parameters (all optional)
+	//
+	// maximum size of outgoing RTP / RTCP packets.
+	// This must be less than the UDP MTU (1472 bytes).
+	// It defaults to 1472.
+	MaxPacketSize int
+	// disable automatic RTCP sender reports.
+	DisableRTCPSenderReports bool
+
+	//
+	// system functions (all optional)
+	//
+	// function used to initialize the UDP socket.
+	// It defaults to net.ListenPacket.
+	ListenPacket func(network, address string) (net.PacketConn, error)
+
+	//
+	// private
+	//
+
+	timeNow            func() time.Time
+	senderReportPeriod time.Duration
+
+	ip     net.IP
+	pc     net.PacketConn
+	medias map[*description.Media]*sdpWriterMedia
+}
+
+// Initialize initializes SDPWriter.
+func (w *SDPWriter) Initialize() error {
+	if w.Desc == nil {
+		return fmt.Errorf("Desc not provided")
+	}
+	if len(w.Ports) != len(w.Desc.Medias) {
+		return fmt.Errorf("a port must be provided for each media")
+	}
+
+	if w.MaxPacketSize == 0 {
+		w.MaxPacketSize = udpMaxPayloadSize
+	} else if w.MaxPacketSize > udpMaxPayloadSize {
+		return fmt.Errorf("MaxPacketSize must be less than %d", udpMaxPayloadSize)
+	}
+	if w.ListenPacket == nil {
+		w.ListenPacket = net.ListenPacket
+	}
+	if w.timeNow == nil {
+		w.timeNow = time.Now
+	}
+	if w.senderReportPeriod == 0 {
+		w.senderReportPeriod = 10 * time.Second
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(w.Host, "0"))
+	if err != nil {
+		return err
+	}
+	w.ip = addr.IP
+
+	w.pc, err = w.ListenPacket(restrictNetwork("udp", ":0"))
+	if err != nil {
+		return err
+	}
+
+	if w.ip.IsMulticast() && w.ip.To4() != nil {
+		err = ipv4.NewPacketConn(w.pc).SetMulticastTTL(sdpWriterMulticastTTL)
+		if err != nil {
+			w.pc.Close()
+			return err
+		}
+	}
+
+	w.medias = make(map[*description.Media]*sdpWriterMedia)
+
+	for i, medi := range w.Desc.Medias {
+		sm := &sdpWriterMedia{
+			w:        w,
+			media:    medi,
+			rtpAddr:  &net.UDPAddr{IP: w.ip, Port: w.Ports[i]},
+			rtcpAddr: &net.UDPAddr{IP: w.ip, Port: w.Ports[i] + 1},
+		}
+		err = sm.initialize()
+		if err != nil {
+			w.Close()
+			return err
+		}
+		w.medias[medi] = sm
+	}
+
+	return nil
+}
+
+// Close closes SDPWriter.
+func (w *SDPWriter) Close() {
+	for _, sm := range w.medias {
+		sm.close()
+	}
+	w.medias = nil
+
+	w.pc.Close()
+}
+
+// SDP returns the SDP that describes the stream,
+// containing destination address and ports.
+func (w *SDPWriter) SDP() ([]byte, error) {
+	byts, err := w.Desc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	var sd sdp.SessionDescription
+	err = sd.Unmarshal(byts)
+	if err != nil {
+		return nil, err
+	}
+
+	addressType := "IP4"
+	if w.ip.To4() == nil {
+		addressType = "IP6"
+	}
+
+	address := w.ip.String()
+	if w.ip.IsMulticast() && w.ip.To4() != nil {
+		// TTL is mandatory for IPv4 multicast addresses
+		address += "/" + strconv.FormatInt(sdpWriterMulticastTTL, 10)
+	}
+
+	sd.ConnectionInformation = &psdp.ConnectionInformation{
+		NetworkType: "IN",
+		AddressType: addressType,
+		Address:     &psdp.Address{Address: address},
+	}
+
+	for i, md := range sd.MediaDescriptions {
+		md.MediaName.Port = psdp.RangedPort{Value: w.Ports[i]}
+
+		// control attributes are meaningless without RTSP
+		for j, attr := range md.Attributes {
+			if attr.Key == "control" {
+				md.Attributes = append(md.Attributes[:j], md.Attributes[j+1:]...)
+				break
+			}
+		}
+	}
+
+	return sd.Marshal()
+}
+
+// WritePacketRTP writes a RTP packet.
+func (w *SDPWriter) WritePacketRTP(medi *description.Media, pkt *rtp.Packet) error {
+	return w.WritePacketRTPWithNTP(medi, pkt, w.timeNow())
+}
+
+// WritePacketRTPWithNTP writes a RTP packet.
+// ntp is the absolute timestamp of the packet, and is sent with periodic RTCP sender reports.
+func (w *SDPWriter) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet, ntp time.Time) error {
+	sm := w.medias[medi]
+	sf := sm.formats[pkt.PayloadType]
+	return sf.writePacketRTP(pkt, ntp)
+}
+
+// WritePacketRTCP writes a RTCP packet.
+func (w *SDPWriter) WritePacketRTCP(medi *description.Media, pkt rtcp.Packet) error {
+	sm := w.medias[medi]
+	return sm.writePacketRTCP(pkt)
+}
@@ -0,0 +1,69 @@
+package gortsplib
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+)
+
+func TestSDPWriter(t *testing.T) {
+	desc := &description.Session{
+		Medias: []*description.Media{testH264Media},
+	}
+
+	w := &SDPWriter{
+		Desc:  desc,
+		Host:  "127.0.0.1",
+		Ports: []int{35466},
+	}
+	err := w.Initialize()
+	require.NoError(t, err)
+	defer w.Close()
+
+	byts, err := w.SDP()
+	require.NoError(t, err)
+
+	var sd sdp.SessionDescription
+	err = sd.Unmarshal(byts)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", sd.ConnectionInformation.Address.Address)
+	require.Equal(t, 35466, sd.MediaDescriptions[0].MediaName.Port.Value)
+
+	r := &SDPReader{
+		SDP: &sd,
+	}
+	err = r.Initialize()
+	require.NoError(t, err)
+	defer r.Close()
+
+	packetRecv := make(chan *rtp.Packet)
+
+	r.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+		packetRecv <- pkt
+	})
+
+	r.Start()
+
+	pkt := testRTPPacket
+	err = w.WritePacketRTP(testH264Media, &pkt)
+	require.NoError(t, err)
+
+	recv := <-packetRecv
+	require.Equal(t, pkt.SSRC, recv.SSRC)
+	require.Equal(t, testRTPPacket.Payload, recv.Payload)
+
+	pkt2 := testRTPPacket
+	pkt2.SequenceNumber++
+	pkt2.Payload = []byte{1, 2}
+	err = w.WritePacketRTP(testH264Media, &pkt2)
+	require.NoError(t, err)
+
+	recv = <-packetRecv
+	require.Equal(t, pkt2.SequenceNumber, recv.SequenceNumber)
+	require.Equal(t, []byte{1, 2}, recv.Payload)
+}
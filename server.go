@@ -8,11 +8,13 @@ import (
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/auth"
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v5/pkg/pcap"
 )
 
 const (
@@ -61,6 +63,11 @@ type chGetMulticastIPReq struct {
 	res chan net.IP
 }
 
+type serverExpiredSession struct {
+	res  *base.Response
+	time time.Time
+}
+
 // Server is a RTSP server.
 type Server struct {
 	//
@@ -75,6 +82,19 @@ type Server struct {
 	// a port to send and receive RTCP packets with the UDP transport.
 	// If UDPRTPAddress and UDPRTCPAddress are filled, the server can support the UDP transport.
 	UDPRTCPAddress string
+	// range of ports used to send and receive RTP and RTCP packets with the UDP transport.
+	// It is an alternative to UDPRTPAddress and UDPRTCPAddress.
+	// Sessions share a single pair of ports; additional pairs are allocated from the range
+	// only when the same client address is used by multiple sessions.
+	UDPPortMin int
+	UDPPortMax int
+	// validate addresses of UDP clients before sending packets to them.
+	// When enabled, the client ports advertised in SETUP are not trusted:
+	// packets are sent only after a packet has been received from the IP of the client,
+	// and the source port of that packet is used in place of the advertised one.
+	// This prevents sending media to spoofed destinations and supports clients behind NAT.
+	// It defaults to false.
+	ValidateUDPAddresses bool
 	// a range of multicast IPs to use with the UDP-multicast transport.
 	// If MulticastIPRange, MulticastRTPPort, MulticastRTCPPort are filled, the server
 	// can support the UDP-multicast transport.
@@ -96,8 +116,38 @@ type Server struct {
 	// read timeout of idle connections and sessions.
 	// It defaults to 60 seconds.
 	IdleTimeout time.Duration
+	// maximum lifetime of sessions.
+	// When it is reached, sessions are closed.
+	// It defaults to zero (unlimited).
+	MaxSessionDuration time.Duration
+	// maximum time that sessions can spend without playing or recording.
+	// When it is reached, sessions are closed.
+	// It defaults to zero (unlimited).
+	MaxSessionIdleTime time.Duration
+	// maximum bytes that sessions can receive or send.
+	// When it is reached, sessions are closed.
+	// It defaults to zero (unlimited).
+	MaxSessionBytes uint64
+	// TCP keepalive settings of the connections.
+	// Keepalive probes are sent when the connection is idle and allow to detect
+	// half-open connections (for instance, when the other side has lost power)
+	// within Idle + Interval * Count, instead of the operating system default.
+	// It defaults to nil (Go defaults: probes every 15 seconds).
+	TCPKeepAlive *net.KeepAliveConfig
+	// linger policy of the connections, that is the time to wait,
+	// when the connection is closed, for unsent data to be delivered.
+	// Zero discards unsent data and resets the connection.
+	// It is rounded down to seconds.
+	// It defaults to nil (operating system default).
+	TCPLinger *time.Duration
 	// a TLS configuration to accept TLS (RTSPS) connections.
 	TLSConfig *tls.Config
+	// the UDP address used to accept RTSP-over-QUIC connections (experimental).
+	// Media is sent and received with the TCP transport, with a dedicated QUIC stream
+	// for each frame (frame-per-stream).
+	// It requires TLSConfig.
+	// It defaults to empty (disabled).
+	QUICAddress string
 	// Size of the UDP read buffer.
 	// This can be increased to reduce packet losses.
 	// It defaults to the operating system default value.
@@ -105,15 +155,103 @@ type Server struct {
 	// Size of the queue of outgoing packets.
 	// It defaults to 256.
 	WriteQueueSize int
+	// maximum amount of bytes that can be buffered by all sessions together,
+	// including packets in write queues and packets buffered for paused sessions.
+	// It allows to keep memory usage predictable.
+	// It defaults to zero (unlimited).
+	MaxBufferedBytes uint64
+	// policy applied when MaxBufferedBytes is reached.
+	// It defaults to MemoryBudgetPolicyDrop.
+	MemoryBudgetPolicy MemoryBudgetPolicy
+	// model used to process incoming packets.
+	// It defaults to PacketProcessingDefault.
+	PacketProcessing PacketProcessing
+	// Size of the queue of incoming packets,
+	// used when PacketProcessing is not PacketProcessingDefault.
+	// It defaults to 256.
+	ReadQueueSize int
 	// maximum size of outgoing RTP / RTCP packets.
 	// This must be less than the UDP MTU (1472 bytes).
 	// It defaults to 1472.
 	MaxPacketSize int
+	// maximum size of outgoing RTP packets sent in interleaved frames (TCP transport).
+	// Bigger packets are split when the codec allows it (H264, H265),
+	// and sequence numbers of following packets are shifted.
+	// This is not applied to secure sessions.
+	// It defaults to zero (MaxPacketSize).
+	MaxInterleavedFrameSize int
+	// if greater than zero, media received while a reading session is paused
+	// is buffered, up to this duration, and it is sent when the session resumes,
+	// instead of being skipped. Buffers start with a random access unit,
+	// therefore they can be longer than this duration.
+	// Sequence numbers and timestamps of packets sent after a pause are rewritten
+	// in order to be contiguous with the ones of packets sent before the pause.
+	// This is not applied to multicast and secure sessions.
+	// It defaults to zero (disabled).
+	PauseBufferDuration time.Duration
+	// cache SDP documents generated in response to DESCRIBE requests,
+	// in order to avoid generating and marshaling them again for every reader.
+	// Documents are cached by stream and by the options requested by readers
+	// (multicast, back channels).
+	// ServerStream.InvalidateDescription() must be called when codec parameters of a stream change.
+	// This is not applied to secure streams, since their documents contain
+	// MIKEY messages that are generated for each request.
+	// It defaults to false.
+	DescribeCacheEnable bool
 	// disable automatic RTCP sender reports.
 	DisableRTCPSenderReports bool
+	// period of RTCP sender and receiver reports.
+	// It defaults to 10 seconds.
+	RTCPReportPeriod time.Duration
+	// fraction of the RTP bandwidth that can be used by RTCP reports (RFC3550, section 6.2).
+	// When set, reports are skipped until the RTP traffic is big enough,
+	// reducing RTCP overhead of low-bitrate streams.
+	// It defaults to zero, that means that reports are sent at every period.
+	RTCPBandwidthFraction float64
+	// accept SSRC changes of streams published by clients, resetting sequence numbers,
+	// reorder buffer and jitter of the format, instead of discarding packets.
+	// Changes are notified through ServerHandlerOnSSRCChange.
+	// It defaults to false.
+	AllowSSRCChange bool
+	// minimum relative change of bitrate, frame rate or key frame interval
+	// of an incoming format that triggers ServerHandlerOnTrackMetricsChange.
+	// It defaults to 0.1 (10%).
+	TrackMetricsThreshold float64
 	// authentication methods.
 	// It defaults to plain and digest+MD5.
 	AuthMethods []auth.VerifyMethod
+	// realm used in authentication challenges.
+	// It defaults to "ipcam".
+	AuthRealm string
+	// value of the Server header, that is added to every response.
+	// It defaults to "gortsplib".
+	ServerHeader string
+	// a filter that decides whether clients are allowed to access the server.
+	// It is evaluated when connections are accepted and before requests are handled.
+	// It defaults to nil (all clients are allowed).
+	AccessFilter AccessFilter
+	// a filter that decides whether medias can be setupped.
+	// It defaults to nil (all medias are allowed).
+	MediaFilter MediaFilter
+	// maximum number of medias that can be setupped in a session.
+	// When it is reached, SETUP requests are replied with StatusForbidden.
+	// It defaults to zero (unlimited).
+	MaxMediasPerSession int
+	// a capture where all RTP and RTCP packets, sent and received, are written.
+	// Packets exchanged through TCP are written as UDP datagrams whose ports
+	// are equal to the interleaved channel.
+	// This is meant for diagnostic purposes.
+	// It defaults to nil.
+	PacketDump *pcap.Writer
+	// level of conformance to the specification that is required from clients.
+	// It defaults to base.ConformanceDefault.
+	Conformance base.Conformance
+	// limits enforced when reading requests, responses and interleaved frames from clients.
+	// Zero values are replaced by default values.
+	Limits base.Limits
+	// a sink that receives an audit trail of connection and session events.
+	// It defaults to nil.
+	AuditSink AuditSink
 
 	//
 	// handler (optional)
@@ -137,22 +275,29 @@ type Server struct {
 	//
 
 	timeNow              func() time.Time
+	memoryBudget         *serverMemoryBudget
 	senderReportPeriod   time.Duration
 	receiverReportPeriod time.Duration
 	checkStreamPeriod    time.Duration
 
-	ctx              context.Context
-	ctxCancel        func()
-	wg               sync.WaitGroup
-	multicastNet     *net.IPNet
-	multicastNextIP  net.IP
-	tcpListener      *serverTCPListener
-	udpRTPListener   *serverUDPListener
-	udpRTCPListener  *serverUDPListener
-	conns            map[*ServerConn]struct{}
-	httpReadChannels map[*ServerConn]chan error
-	sessions         map[string]*ServerSession
-	closeError       error
+	ctx               context.Context
+	ctxCancel         func()
+	wg                sync.WaitGroup
+	confMutex         sync.RWMutex
+	multicastNet      *net.IPNet
+	multicastNextIP   net.IP
+	tcpListener       *serverTCPListener
+	quicListener      *serverQUICListener
+	udpRTPListener    *serverUDPListener
+	udpRTCPListener   *serverUDPListener
+	udpPairsMutex     sync.Mutex
+	udpPairs          []*serverUDPListenerPair
+	conns             map[*ServerConn]struct{}
+	httpReadChannels  map[*ServerConn]chan error
+	sessions          map[string]*ServerSession
+	expiredSessions   map[string]serverExpiredSession
+	closeError        error
+	auditSessionCount atomic.Uint64
 
 	// in
 	chNewConn           chan net.Conn
@@ -181,16 +326,38 @@ func (s *Server) Start() error {
 	} else if (s.WriteQueueSize & (s.WriteQueueSize - 1)) != 0 {
 		return fmt.Errorf("WriteQueueSize (%d) must be a power of two", s.WriteQueueSize)
 	}
+	if s.ReadQueueSize == 0 {
+		s.ReadQueueSize = 256
+	} else if (s.ReadQueueSize & (s.ReadQueueSize - 1)) != 0 {
+		return fmt.Errorf("ReadQueueSize (%d) must be a power of two", s.ReadQueueSize)
+	}
 	if s.MaxPacketSize == 0 {
 		s.MaxPacketSize = udpMaxPayloadSize
 	} else if s.MaxPacketSize > udpMaxPayloadSize {
 		return fmt.Errorf("MaxPacketSize (%d) must be less than %d", s.MaxPacketSize, udpMaxPayloadSize)
 	}
+	err := s.Limits.Validate()
+	if err != nil {
+		return err
+	}
 	if len(s.AuthMethods) == 0 {
 		// disable VerifyMethodDigestSHA256 unless explicitly set
 		// since it prevents FFmpeg from authenticating
 		s.AuthMethods = []auth.VerifyMethod{auth.VerifyMethodBasic, auth.VerifyMethodDigestMD5}
 	}
+	if s.AuthRealm == "" {
+		s.AuthRealm = serverAuthRealm
+	}
+	if s.ServerHeader == "" {
+		s.ServerHeader = serverHeader
+	}
+	if s.MemoryBudgetPolicy != MemoryBudgetPolicyDrop &&
+		s.MemoryBudgetPolicy != MemoryBudgetPolicyCloseSession {
+		return fmt.Errorf("invalid memory budget policy: %v", s.MemoryBudgetPolicy)
+	}
+	s.memoryBudget = &serverMemoryBudget{
+		max: s.MaxBufferedBytes,
+	}
 
 	// system functions
 	if s.Listen == nil {
@@ -204,6 +371,14 @@ func (s *Server) Start() error {
 	if s.timeNow == nil {
 		s.timeNow = time.Now
 	}
+	if s.RTCPReportPeriod != 0 {
+		if s.senderReportPeriod == 0 {
+			s.senderReportPeriod = s.RTCPReportPeriod
+		}
+		if s.receiverReportPeriod == 0 {
+			s.receiverReportPeriod = s.RTCPReportPeriod
+		}
+	}
 	if s.senderReportPeriod == 0 {
 		s.senderReportPeriod = 10 * time.Second
 	}
@@ -218,6 +393,10 @@ func (s *Server) Start() error {
 		return fmt.Errorf("RTSPAddress not provided")
 	}
 
+	if s.QUICAddress != "" && s.TLSConfig == nil {
+		return fmt.Errorf("QUICAddress requires TLSConfig")
+	}
+
 	if (s.UDPRTPAddress != "" && s.UDPRTCPAddress == "") ||
 		(s.UDPRTPAddress == "" && s.UDPRTCPAddress != "") {
 		return fmt.Errorf("UDPRTPAddress and UDPRTCPAddress must be used together")
@@ -245,7 +424,8 @@ func (s *Server) Start() error {
 		s.udpRTPListener = &serverUDPListener{
 			readBufferSize:  s.UDPReadBufferSize,
 			listenPacket:    s.ListenPacket,
-			writeTimeout:    s.WriteTimeout,
+			writeTimeout:    s.writeTimeout,
+			packetDump:      s.PacketDump,
 			multicastEnable: false,
 			address:         s.UDPRTPAddress,
 		}
@@ -257,7 +437,8 @@ func (s *Server) Start() error {
 		s.udpRTCPListener = &serverUDPListener{
 			readBufferSize:  s.UDPReadBufferSize,
 			listenPacket:    s.ListenPacket,
-			writeTimeout:    s.WriteTimeout,
+			writeTimeout:    s.writeTimeout,
+			packetDump:      s.PacketDump,
 			multicastEnable: false,
 			address:         s.UDPRTCPAddress,
 		}
@@ -268,6 +449,34 @@ func (s *Server) Start() error {
 		}
 	}
 
+	if s.UDPPortMin != 0 || s.UDPPortMax != 0 {
+		if s.UDPRTPAddress != "" {
+			return fmt.Errorf("UDPPortMin and UDPPortMax cannot be used together with UDPRTPAddress and UDPRTCPAddress")
+		}
+
+		if (s.UDPPortMin % 2) != 0 {
+			return fmt.Errorf("UDPPortMin (%d) must be even", s.UDPPortMin)
+		}
+
+		if s.UDPPortMax <= s.UDPPortMin {
+			return fmt.Errorf("UDPPortMax (%d) must be greater than UDPPortMin (%d)", s.UDPPortMax, s.UDPPortMin)
+		}
+
+		var err error
+		s.udpRTPListener, s.udpRTCPListener, err = s.createUDPListenerPairInRange()
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.udpRTPListener != nil {
+		s.udpPairs = []*serverUDPListenerPair{{
+			rtp:     s.udpRTPListener,
+			rtcp:    s.udpRTCPListener,
+			clients: make(map[clientAddr]struct{}),
+		}}
+	}
+
 	if s.MulticastIPRange != "" && (s.MulticastRTPPort == 0 || s.MulticastRTCPPort == 0) ||
 		(s.MulticastRTPPort != 0 && (s.MulticastRTCPPort == 0 || s.MulticastIPRange == "")) ||
 		s.MulticastRTCPPort != 0 && (s.MulticastRTPPort == 0 || s.MulticastIPRange == "") {
@@ -322,6 +531,7 @@ func (s *Server) Start() error {
 	s.conns = make(map[*ServerConn]struct{})
 	s.httpReadChannels = make(map[*ServerConn]chan error)
 	s.sessions = make(map[string]*ServerSession)
+	s.expiredSessions = make(map[string]serverExpiredSession)
 	s.chNewConn = make(chan net.Conn)
 	s.chAcceptErr = make(chan error)
 	s.chCloseConn = make(chan *ServerConn)
@@ -331,7 +541,7 @@ func (s *Server) Start() error {
 	s.chGetMulticastIP = make(chan chGetMulticastIPReq)
 
 	s.tcpListener = &serverTCPListener{s: s}
-	err := s.tcpListener.initialize()
+	err = s.tcpListener.initialize()
 	if err != nil {
 		if s.udpRTPListener != nil {
 			s.udpRTPListener.close()
@@ -343,6 +553,22 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	if s.QUICAddress != "" {
+		s.quicListener = &serverQUICListener{s: s}
+		err = s.quicListener.initialize()
+		if err != nil {
+			s.tcpListener.close()
+			if s.udpRTPListener != nil {
+				s.udpRTPListener.close()
+			}
+			if s.udpRTCPListener != nil {
+				s.udpRTCPListener.close()
+			}
+			s.ctxCancel()
+			return err
+		}
+	}
+
 	s.wg.Add(1)
 	go s.run()
 
@@ -362,11 +588,101 @@ func (s *Server) Wait() error {
 	return s.closeError
 }
 
+// MemoryStats returns statistics about memory used by buffers.
+func (s *Server) MemoryStats() *ServerMemoryStats {
+	return s.memoryBudget.stats()
+}
+
 // NetListener returns the underlying net.Listener
 func (s *Server) NetListener() net.Listener {
 	return s.tcpListener.ln
 }
 
+// SetTLSConfig replaces the TLS configuration while the server is running.
+// The new configuration is used by connections accepted from now on,
+// while existing connections keep using the previous one.
+// This allows to rotate certificates without dropping sessions.
+// It is not possible to enable or disable TLS at runtime.
+func (s *Server) SetTLSConfig(tlsConfig *tls.Config) error {
+	s.confMutex.Lock()
+	defer s.confMutex.Unlock()
+
+	if (tlsConfig == nil) != (s.TLSConfig == nil) {
+		return fmt.Errorf("TLS cannot be enabled or disabled at runtime")
+	}
+
+	s.TLSConfig = tlsConfig
+	return nil
+}
+
+// SetAuthRealm replaces the authentication realm while the server is running.
+func (s *Server) SetAuthRealm(realm string) {
+	s.confMutex.Lock()
+	defer s.confMutex.Unlock()
+
+	s.AuthRealm = realm
+}
+
+// SetReadTimeout replaces the read timeout while the server is running.
+func (s *Server) SetReadTimeout(v time.Duration) {
+	s.confMutex.Lock()
+	defer s.confMutex.Unlock()
+
+	s.ReadTimeout = v
+}
+
+// SetWriteTimeout replaces the write timeout while the server is running.
+func (s *Server) SetWriteTimeout(v time.Duration) {
+	s.confMutex.Lock()
+	defer s.confMutex.Unlock()
+
+	s.WriteTimeout = v
+}
+
+// SetIdleTimeout replaces the idle timeout while the server is running.
+// The Session header of new responses reflects the new value.
+func (s *Server) SetIdleTimeout(v time.Duration) {
+	s.confMutex.Lock()
+	defer s.confMutex.Unlock()
+
+	s.IdleTimeout = v
+}
+
+func (s *Server) tlsConfig() *tls.Config {
+	s.confMutex.RLock()
+	defer s.confMutex.RUnlock()
+
+	return s.TLSConfig
+}
+
+func (s *Server) authRealm() string {
+	s.confMutex.RLock()
+	defer s.confMutex.RUnlock()
+
+	return s.AuthRealm
+}
+
+func (s *Server) readTimeout() time.Duration {
+	s.confMutex.RLock()
+	defer s.confMutex.RUnlock()
+
+	return s.ReadTimeout
+}
+
+func (s *Server) writeTimeout() time.Duration {
+	s.confMutex.RLock()
+	defer s.confMutex.RUnlock()
+
+	return s.WriteTimeout
+}
+
+func (s *Server) idleTimeout() time.Duration {
+	s.confMutex.RLock()
+	defer s.confMutex.RUnlock()
+
+	return s.IdleTimeout
+}
+
 func (s *Server) run() {
 	defer s.wg.Done()
 
@@ -376,6 +692,19 @@ func (s *Server) run() {
 
 	s.tcpListener.close()
 
+	if s.quicListener != nil {
+		s.quicListener.close()
+	}
+
+	s.udpPairsMutex.Lock()
+	if len(s.udpPairs) > 1 {
+		for _, p := range s.udpPairs[1:] {
+			p.close()
+		}
+		s.udpPairs = s.udpPairs[:1]
+	}
+	s.udpPairsMutex.Unlock()
+
 	if s.udpRTCPListener != nil {
 		s.udpRTCPListener.close()
 	}
@@ -392,10 +721,21 @@ func (s *Server) runInner() error {
 			return err
 
 		case nconn := <-s.chNewConn:
+			if s.AccessFilter != nil {
+				ip := nconn.RemoteAddr().(*net.TCPAddr).IP
+				if s.AccessFilter.FilterAccess(ip, nil) != base.StatusOK {
+					nconn.Close()
+					continue
+				}
+			}
+
 			sc := &ServerConn{
 				s:     s,
 				nconn: nconn,
 			}
+			if _, ok := nconn.(*quicFramePerStreamConn); ok {
+				sc.tunnel = TunnelQUIC
+			}
 			sc.initialize()
 			s.conns[sc] = struct{}{}
 
@@ -448,6 +788,14 @@ func (s *Server) runInner() error {
 					continue
 				}
 			} else {
+				if es, ok2 := s.expiredSessions[req.id]; ok2 && !req.create {
+					req.res <- sessionRequestRes{
+						res: cloneExpiryResponse(es.res),
+						err: liberrors.ErrServerSessionExpired{},
+					}
+					continue
+				}
+
 				if !req.create {
 					req.res <- sessionRequestRes{
 						res: &base.Response{
@@ -475,6 +823,10 @@ func (s *Server) runInner() error {
 			delete(s.sessions, ss.secretID)
 			ss.Close()
 
+			if ss.expiredRes != nil {
+				s.addExpiredSession(ss)
+			}
+
 		case req := <-s.chGetMulticastIP:
 			ip32 := uint32(s.multicastNextIP[0])<<24 | uint32(s.multicastNextIP[1])<<16 |
 				uint32(s.multicastNextIP[2])<<8 | uint32(s.multicastNextIP[3])
@@ -495,6 +847,25 @@ func (s *Server) runInner() error {
 	}
 }
 
+// addExpiredSession remembers an expired session, in order to reply to clients
+// that still refer to it with the response set in SetExpiry().
+// Sessions are forgotten after IdleTimeout, since clients are expected
+// to send requests at least once every IdleTimeout.
+func (s *Server) addExpiredSession(ss *ServerSession) {
+	now := s.timeNow()
+
+	for id, es := range s.expiredSessions {
+		if now.Sub(es.time) >= s.IdleTimeout {
+			delete(s.expiredSessions, id)
+		}
+	}
+
+	s.expiredSessions[ss.secretID] = serverExpiredSession{
+		res:  ss.expiredRes,
+		time: now,
+	}
+}
+
 // StartAndWait starts the server and waits until a fatal error.
 func (s *Server) StartAndWait() error {
 	err := s.Start()
@@ -0,0 +1,148 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+// AccessFilter decides whether clients are allowed to access the server.
+type AccessFilter interface {
+	// FilterAccess is called when a connection is accepted, with a nil request,
+	// and before each request is handled.
+	// It returns StatusOK to allow access, or another status code to deny it.
+	// When access is denied at accept time, the connection is closed without any response.
+	// It must be safe for concurrent use.
+	FilterAccess(ip net.IP, req *base.Request) base.StatusCode
+}
+
+// AccessFilterFunc is a function that implements AccessFilter.
+type AccessFilterFunc func(ip net.IP, req *base.Request) base.StatusCode
+
+// FilterAccess implements AccessFilter.
+func (f AccessFilterFunc) FilterAccess(ip net.IP, req *base.Request) base.StatusCode {
+	return f(ip, req)
+}
+
+func parseIPNetworks(entries []string) ([]*net.IPNet, error) {
+	ret := make([]*net.IPNet, len(entries))
+
+	for i, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP: %v", entry)
+			}
+
+			if ip4 := ip.To4(); ip4 != nil {
+				ret[i] = &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+			} else {
+				ret[i] = &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+			}
+			continue
+		}
+
+		_, ipnet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = ipnet
+	}
+
+	return ret, nil
+}
+
+func ipNetworksContain(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPAccessFilter is an AccessFilter based on IP allowlists and denylists.
+type IPAccessFilter struct {
+	// IPs or CIDR networks that are allowed.
+	// It defaults to nil (all IPs are allowed).
+	Allow []string
+
+	// IPs or CIDR networks that are denied.
+	// Denials have precedence over allowances.
+	// It defaults to nil.
+	Deny []string
+
+	// header containing the IP of the client, set by proxies.
+	// It is read from the first entry, like X-Forwarded-For.
+	// It defaults to "" (the header is not used).
+	ForwardedHeader string
+
+	// IPs or CIDR networks of proxies that are trusted to set ForwardedHeader.
+	TrustedProxies []string
+
+	// status code of responses to denied requests.
+	// It defaults to StatusForbidden.
+	DenyStatusCode base.StatusCode
+
+	allow          []*net.IPNet
+	deny           []*net.IPNet
+	trustedProxies []*net.IPNet
+}
+
+// Initialize initializes IPAccessFilter.
+func (f *IPAccessFilter) Initialize() error {
+	var err error
+
+	f.allow, err = parseIPNetworks(f.Allow)
+	if err != nil {
+		return err
+	}
+
+	f.deny, err = parseIPNetworks(f.Deny)
+	if err != nil {
+		return err
+	}
+
+	f.trustedProxies, err = parseIPNetworks(f.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	if f.DenyStatusCode == 0 {
+		f.DenyStatusCode = base.StatusForbidden
+	}
+
+	return nil
+}
+
+// FilterAccess implements AccessFilter.
+func (f *IPAccessFilter) FilterAccess(ip net.IP, req *base.Request) base.StatusCode {
+	if f.ForwardedHeader != "" && ipNetworksContain(f.trustedProxies, ip) {
+		// the IP of the client is not known until a request is received.
+		if req == nil {
+			return base.StatusOK
+		}
+
+		for k, v := range req.Header {
+			if strings.EqualFold(k, f.ForwardedHeader) && len(v) != 0 {
+				first := strings.TrimSpace(strings.Split(v[0], ",")[0])
+				if fip := net.ParseIP(first); fip != nil {
+					ip = fip
+				}
+				break
+			}
+		}
+	}
+
+	if ipNetworksContain(f.deny, ip) {
+		return f.DenyStatusCode
+	}
+
+	if f.allow != nil && !ipNetworksContain(f.allow, ip) {
+		return f.DenyStatusCode
+	}
+
+	return base.StatusOK
+}
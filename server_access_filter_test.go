@@ -0,0 +1,129 @@
+package gortsplib
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+)
+
+func TestIPAccessFilter(t *testing.T) {
+	f := &IPAccessFilter{
+		Allow:           []string{"192.168.0.0/16", "10.0.0.1"},
+		Deny:            []string{"192.168.5.0/24"},
+		ForwardedHeader: "X-Forwarded-For",
+		TrustedProxies:  []string{"10.0.0.1"},
+	}
+	err := f.Initialize()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		name string
+		ip   string
+		req  *base.Request
+		code base.StatusCode
+	}{
+		{
+			"allowed",
+			"192.168.1.1",
+			nil,
+			base.StatusOK,
+		},
+		{
+			"denied",
+			"192.168.5.1",
+			nil,
+			base.StatusForbidden,
+		},
+		{
+			"not allowed",
+			"172.16.0.1",
+			nil,
+			base.StatusForbidden,
+		},
+		{
+			"proxy at accept time",
+			"10.0.0.1",
+			nil,
+			base.StatusOK,
+		},
+		{
+			"proxy allowed",
+			"10.0.0.1",
+			&base.Request{Header: base.Header{"X-Forwarded-For": base.HeaderValue{"192.168.1.1, 10.0.0.1"}}},
+			base.StatusOK,
+		},
+		{
+			"proxy denied",
+			"10.0.0.1",
+			&base.Request{Header: base.Header{"X-Forwarded-For": base.HeaderValue{"192.168.5.1"}}},
+			base.StatusForbidden,
+		},
+		{
+			"untrusted proxy",
+			"192.168.1.1",
+			&base.Request{Header: base.Header{"X-Forwarded-For": base.HeaderValue{"192.168.5.1"}}},
+			base.StatusOK,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.code, f.FilterAccess(net.ParseIP(ca.ip), ca.req))
+		})
+	}
+
+	f = &IPAccessFilter{
+		Allow: []string{"invalid"},
+	}
+	err = f.Initialize()
+	require.EqualError(t, err, "invalid IP: invalid")
+}
+
+func TestServerAccessFilter(t *testing.T) {
+	for _, ca := range []string{"accept", "request"} {
+		t.Run(ca, func(t *testing.T) {
+			s := &Server{
+				Handler: &testServerHandler{
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						t.Error("should not happen")
+						return nil, nil, nil
+					},
+				},
+				RTSPAddress: "localhost:8554",
+				AccessFilter: AccessFilterFunc(func(_ net.IP, req *base.Request) base.StatusCode {
+					if ca == "accept" || req != nil {
+						return base.StatusForbidden
+					}
+					return base.StatusOK
+				}),
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+			res, err := writeReqReadRes(conn, base.Request{
+				Method: base.Describe,
+				URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+				Header: base.Header{
+					"CSeq": base.HeaderValue{"1"},
+				},
+			})
+
+			if ca == "accept" {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, base.StatusForbidden, res.StatusCode)
+			}
+		})
+	}
+}
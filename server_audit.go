@@ -0,0 +1,240 @@
+package gortsplib
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+)
+
+// AuditEventType is the type of an audit event.
+type AuditEventType string
+
+// audit event types.
+const (
+	AuditEventConnOpen     AuditEventType = "conn_open"
+	AuditEventConnClose    AuditEventType = "conn_close"
+	AuditEventAuth         AuditEventType = "auth"
+	AuditEventDescribe     AuditEventType = "describe"
+	AuditEventAnnounce     AuditEventType = "announce"
+	AuditEventSetup        AuditEventType = "setup"
+	AuditEventPlay         AuditEventType = "play"
+	AuditEventRecord       AuditEventType = "record"
+	AuditEventPause        AuditEventType = "pause"
+	AuditEventTeardown     AuditEventType = "teardown"
+	AuditEventSessionClose AuditEventType = "session_close"
+)
+
+// AuditEvent is an entry of the audit log.
+type AuditEvent struct {
+	// time of the event.
+	Time time.Time `json:"time"`
+
+	// type of the event.
+	Type AuditEventType `json:"type"`
+
+	// address of the client.
+	RemoteAddr string `json:"remoteAddr"`
+
+	// sequential number of the session.
+	// This is not the session ID, that must not be shared.
+	// It is zero when the event is not associated with a session.
+	Session uint64 `json:"session,omitempty"`
+
+	// user provided in the Authorization header.
+	User string `json:"user,omitempty"`
+
+	// path of the request.
+	Path string `json:"path,omitempty"`
+
+	// transport protocol (SETUP only).
+	Transport string `json:"transport,omitempty"`
+
+	// status code of the response (requests only).
+	StatusCode base.StatusCode `json:"statusCode,omitempty"`
+
+	// whether authentication succeeded (auth only).
+	Success bool `json:"success,omitempty"`
+
+	// bytes received (conn_close and session_close only).
+	BytesReceived uint64 `json:"bytesReceived,omitempty"`
+
+	// bytes sent (conn_close and session_close only).
+	BytesSent uint64 `json:"bytesSent,omitempty"`
+
+	// error that caused the closure of the connection or session,
+	// or error returned by the request.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink receives audit events.
+// WriteAuditEvent may be called by multiple goroutines concurrently
+// and must not block for long, since it's called by connection and session routines.
+type AuditSink interface {
+	WriteAuditEvent(*AuditEvent)
+}
+
+// AuditSinkFunc is a function that implements AuditSink.
+type AuditSinkFunc func(*AuditEvent)
+
+// WriteAuditEvent implements AuditSink.
+func (f AuditSinkFunc) WriteAuditEvent(e *AuditEvent) {
+	f(e)
+}
+
+// AuditSinkJSON is an AuditSink that writes events as JSON lines.
+type AuditSinkJSON struct {
+	// destination of events.
+	Writer io.Writer
+
+	mutex sync.Mutex
+}
+
+// WriteAuditEvent implements AuditSink.
+func (s *AuditSinkJSON) WriteAuditEvent(e *AuditEvent) {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	buf = append(buf, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.Writer.Write(buf) //nolint:errcheck
+}
+
+var auditEventTypeByMethod = map[base.Method]AuditEventType{
+	base.Describe: AuditEventDescribe,
+	base.Announce: AuditEventAnnounce,
+	base.Setup:    AuditEventSetup,
+	base.Play:     AuditEventPlay,
+	base.Record:   AuditEventRecord,
+	base.Pause:    AuditEventPause,
+	base.Teardown: AuditEventTeardown,
+}
+
+func auditUser(req *base.Request) string {
+	var auth headers.Authorization
+	err := auth.Unmarshal(req.Header["Authorization"])
+	if err != nil {
+		return ""
+	}
+	return auth.Username
+}
+
+func auditError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (sc *ServerConn) auditConn(typ AuditEventType, err error) {
+	if sc.s.AuditSink == nil {
+		return
+	}
+
+	e := &AuditEvent{
+		Time:       sc.s.timeNow(),
+		Type:       typ,
+		RemoteAddr: sc.remoteAddr.String(),
+	}
+
+	if typ == AuditEventConnClose {
+		stats := sc.Stats()
+		e.BytesReceived = stats.BytesReceived
+		e.BytesSent = stats.BytesSent
+		e.Error = auditError(err)
+	}
+
+	sc.s.AuditSink.WriteAuditEvent(e)
+}
+
+func (sc *ServerConn) auditRequest(
+	req *base.Request,
+	res *base.Response,
+	err error,
+	prevSession *ServerSession,
+) {
+	if sc.s.AuditSink == nil {
+		return
+	}
+
+	// the session is detached from the connection after TEARDOWN.
+	var session uint64
+	switch {
+	case sc.session != nil:
+		session = sc.session.auditNumber
+	case prevSession != nil:
+		session = prevSession.auditNumber
+	}
+
+	user := auditUser(req)
+
+	if user != "" {
+		var eerr liberrors.ErrServerAuth
+		failed := errors.As(err, &eerr)
+
+		// successful authentications are logged once per connection.
+		if failed || !sc.auditAuthLogged {
+			sc.auditAuthLogged = !failed
+			sc.s.AuditSink.WriteAuditEvent(&AuditEvent{
+				Time:       sc.s.timeNow(),
+				Type:       AuditEventAuth,
+				RemoteAddr: sc.remoteAddr.String(),
+				Session:    session,
+				User:       user,
+				Success:    !failed,
+			})
+		}
+	}
+
+	typ, ok := auditEventTypeByMethod[req.Method]
+	if !ok || req.URL == nil {
+		return
+	}
+
+	e := &AuditEvent{
+		Time:       sc.s.timeNow(),
+		Type:       typ,
+		RemoteAddr: sc.remoteAddr.String(),
+		Session:    session,
+		User:       user,
+		Path:       req.URL.Path,
+		StatusCode: res.StatusCode,
+		Error:      auditError(err),
+	}
+
+	if typ == AuditEventSetup && sc.session != nil {
+		if tr := sc.session.Transport(); tr != nil {
+			e.Transport = tr.Protocol.String()
+		}
+	}
+
+	sc.s.AuditSink.WriteAuditEvent(e)
+}
+
+func (ss *ServerSession) auditClose(err error) {
+	if ss.s.AuditSink == nil {
+		return
+	}
+
+	stats := ss.Stats()
+
+	ss.s.AuditSink.WriteAuditEvent(&AuditEvent{
+		Time:          ss.s.timeNow(),
+		Type:          AuditEventSessionClose,
+		RemoteAddr:    ss.author.remoteAddr.String(),
+		Session:       ss.auditNumber,
+		Path:          ss.Path(),
+		BytesReceived: stats.BytesReceived,
+		BytesSent:     stats.BytesSent,
+		Error:         auditError(err),
+	})
+}
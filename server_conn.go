@@ -9,7 +9,6 @@ import (
 	gourl "net/url"
 	"slices"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -123,13 +122,16 @@ type ServerConn struct {
 	ctx              context.Context
 	ctxCancel        func()
 	propsMutex       sync.RWMutex
+	userDataMutex    sync.RWMutex
 	userData         any
 	remoteAddr       *net.TCPAddr
 	bc               *bytecounter.ByteCounter
 	conn             *conn.Conn
 	session          *ServerSession
 	authNonce        string
+	auditAuthLogged  bool
 	httpReadBuf      *bufio.Reader
+	clientInfo       ServerConnClientInfo
 	httpReadTunnelID string
 
 	// in
@@ -144,8 +146,8 @@ type ServerConn struct {
 func (sc *ServerConn) initialize() {
 	ctx, ctxCancel := context.WithCancel(sc.s.ctx)
 
-	if sc.s.TLSConfig != nil && sc.tunnel == TunnelNone {
-		sc.nconn = tls.Server(sc.nconn, sc.s.TLSConfig)
+	if sc.s.tlsConfig() != nil && sc.tunnel == TunnelNone {
+		sc.nconn = tls.Server(sc.nconn, sc.s.tlsConfig())
 	}
 
 	sc.bc = bytecounter.New(sc.nconn, nil, nil)
@@ -171,16 +173,40 @@ func (sc *ServerConn) NetConn() net.Conn {
 	return sc.nconn
 }
 
+// ServerName returns the server name requested by the client with TLS SNI.
+// It returns an empty string when TLS is not in use or the client did not provide any name.
+func (sc *ServerConn) ServerName() string {
+	if tc, ok := sc.nconn.(*tls.Conn); ok {
+		return tc.ConnectionState().ServerName
+	}
+	return ""
+}
+
 // SetUserData sets some user data associated with the connection.
+// It can be called from any goroutine.
 func (sc *ServerConn) SetUserData(v any) {
+	sc.userDataMutex.Lock()
+	defer sc.userDataMutex.Unlock()
+
 	sc.userData = v
 }
 
 // UserData returns some user data associated with the connection.
+// It can be called from any goroutine.
 func (sc *ServerConn) UserData() any {
+	sc.userDataMutex.RLock()
+	defer sc.userDataMutex.RUnlock()
+
 	return sc.userData
 }
 
+// ServerConnUserData returns the user data associated with a connection,
+// converted into the requested type.
+func ServerConnUserData[T any](sc *ServerConn) (T, bool) {
+	v, ok := sc.UserData().(T)
+	return v, ok
+}
+
 // Session returns the associated session.
 func (sc *ServerConn) Session() *ServerSession {
 	sc.propsMutex.RLock()
@@ -199,6 +225,28 @@ func (sc *ServerConn) Transport() *ConnTransport {
 	}
 }
 
+// ClientInfo returns informations that the client provided about itself.
+func (sc *ServerConn) ClientInfo() *ServerConnClientInfo {
+	sc.propsMutex.RLock()
+	defer sc.propsMutex.RUnlock()
+
+	ret := sc.clientInfo
+	return &ret
+}
+
+func (sc *ServerConn) updateClientInfo(req *base.Request) {
+	sc.propsMutex.Lock()
+	defer sc.propsMutex.Unlock()
+
+	if ua, ok := req.Header["User-Agent"]; ok && len(ua) == 1 {
+		sc.clientInfo.UserAgent = ua[0]
+	}
+
+	if req.Method == base.Options {
+		sc.clientInfo.Supported = parseHeaderList(req.Header["Supported"])
+	}
+}
+
 // Stats returns connection statistics.
 func (sc *ServerConn) Stats() *ConnStats {
 	return &ConnStats{
@@ -232,7 +280,7 @@ func (sc *ServerConn) VerifyCredentials(
 		expectedUser,
 		expectedPass,
 		sc.s.AuthMethods,
-		serverAuthRealm,
+		sc.s.authRealm(),
 		sc.authNonce)
 
 	return (err == nil)
@@ -241,7 +289,7 @@ func (sc *ServerConn) VerifyCredentials(
 func (sc *ServerConn) handleAuthError(req *base.Request, res *base.Response) error {
 	// if credentials have not been provided, clear error and send the WWW-Authenticate header.
 	if !credentialsProvided(req) {
-		res.Header["WWW-Authenticate"] = auth.GenerateWWWAuthenticate(sc.s.AuthMethods, serverAuthRealm, sc.authNonce)
+		res.Header["WWW-Authenticate"] = auth.GenerateWWWAuthenticate(sc.s.AuthMethods, sc.s.authRealm(), sc.authNonce)
 		return nil
 	}
 
@@ -261,6 +309,8 @@ func (sc *ServerConn) run() {
 	defer sc.s.wg.Done()
 	defer close(sc.done)
 
+	sc.auditConn(AuditEventConnOpen, nil)
+
 	if h, ok := sc.s.Handler.(ServerHandlerOnConnOpen); ok {
 		h.OnConnOpen(&ServerHandlerOnConnOpenCtx{
 			Conn: sc,
@@ -288,6 +338,8 @@ func (sc *ServerConn) run() {
 
 	sc.s.closeConn(sc)
 
+	sc.auditConn(AuditEventConnClose, err)
+
 	if h, ok := sc.s.Handler.(ServerHandlerOnConnClose); ok {
 		h.OnConnClose(&ServerHandlerOnConnCloseCtx{
 			Conn:  sc,
@@ -329,6 +381,14 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 		}, liberrors.ErrServerInvalidPath{}
 	}
 
+	if sc.s.AccessFilter != nil {
+		if code := sc.s.AccessFilter.FilterAccess(sc.ip(), req); code != base.StatusOK {
+			return &base.Response{
+				StatusCode: code,
+			}, liberrors.ErrServerAccessDenied{IP: sc.ip()}
+		}
+	}
+
 	sxID := getSessionID(req.Header)
 
 	var path string
@@ -345,35 +405,14 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 			return sc.handleRequestInSession(sxID, req, false)
 		}
 
-		var methods []string
-		if _, ok := sc.s.Handler.(ServerHandlerOnDescribe); ok {
-			methods = append(methods, string(base.Describe))
+		if req.URL != nil {
+			path, _ = getPathAndQuery(req.URL, false)
 		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnAnnounce); ok {
-			methods = append(methods, string(base.Announce))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnSetup); ok {
-			methods = append(methods, string(base.Setup))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnPlay); ok {
-			methods = append(methods, string(base.Play))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnRecord); ok {
-			methods = append(methods, string(base.Record))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnPause); ok {
-			methods = append(methods, string(base.Pause))
-		}
-		methods = append(methods, string(base.GetParameter))
-		if _, ok := sc.s.Handler.(ServerHandlerOnSetParameter); ok {
-			methods = append(methods, string(base.SetParameter))
-		}
-		methods = append(methods, string(base.Teardown))
 
 		return &base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
-				"Public": base.HeaderValue{strings.Join(methods, ", ")},
+				"Public": serverPublicHeader(sc.s.Handler, sc, req, path),
 			},
 		}, nil
 
@@ -402,13 +441,10 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 					return res, err
 				}
 
-				var desc *description.Session
-				desc, err = prepareForDescribe(
-					stream.Desc,
+				var byts []byte
+				byts, err = stream.marshalDescription(
 					checkMulticastEnabled(sc.s.MulticastIPRange, query),
 					checkBackChannelsEnabled(req.Header),
-					sc.s.TLSConfig != nil,
-					stream.medias,
 				)
 				if err != nil {
 					return &base.Response{
@@ -416,14 +452,6 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 					}, err
 				}
 
-				var byts []byte
-				byts, err = desc.Marshal()
-				if err != nil {
-					return &base.Response{
-						StatusCode: base.StatusInternalServerError,
-					}, err
-				}
-
 				res.Body = byts
 			}
 
@@ -493,6 +521,22 @@ func (sc *ServerConn) handleRequestInner(req *base.Request) (*base.Response, err
 				Query:   query,
 			})
 		}
+
+	default:
+		if h, ok := sc.s.Handler.(ServerHandlerOnCustomRequest); ok && slices.Contains(h.CustomMethods(), req.Method) {
+			if sxID != "" {
+				return sc.handleRequestInSession(sxID, req, false)
+			}
+
+			path, query = getPathAndQuery(req.URL, false)
+
+			return h.OnCustomRequest(&ServerHandlerOnCustomRequestCtx{
+				Conn:    sc,
+				Request: req,
+				Path:    path,
+				Query:   query,
+			})
+		}
 	}
 
 	return &base.Response{
@@ -505,6 +549,10 @@ func (sc *ServerConn) handleRequestOuter(req *base.Request) error {
 		h.OnRequest(sc, req)
 	}
 
+	prevSession := sc.session
+
+	sc.updateClientInfo(req)
+
 	res, err := sc.handleRequestInner(req)
 
 	if res.Header == nil {
@@ -517,6 +565,8 @@ func (sc *ServerConn) handleRequestOuter(req *base.Request) error {
 		err = sc.handleAuthError(req, res)
 	}
 
+	sc.auditRequest(req, res, err, prevSession)
+
 	// add cseq
 	var eerr2 liberrors.ErrServerCSeqMissing
 	if !errors.As(err, &eerr2) {
@@ -524,13 +574,13 @@ func (sc *ServerConn) handleRequestOuter(req *base.Request) error {
 	}
 
 	// add server
-	res.Header["Server"] = base.HeaderValue{serverHeader}
+	res.Header["Server"] = base.HeaderValue{sc.s.ServerHeader}
 
 	if h, ok := sc.s.Handler.(ServerHandlerOnResponse); ok {
 		h.OnResponse(sc, res)
 	}
 
-	sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.WriteTimeout))
+	sc.nconn.SetWriteDeadline(time.Now().Add(sc.s.writeTimeout()))
 	err2 := sc.conn.WriteResponse(res)
 	if err == nil && err2 != nil {
 		err = err2
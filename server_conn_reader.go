@@ -93,7 +93,13 @@ func (cr *serverConnReader) runInner() error {
 		}
 	}
 
-	cr.sc.conn = conn.NewConn(bufio.NewReader(rw), rw)
+	cr.sc.conn = conn.NewConn(bufio.NewReaderSize(rw, cr.sc.s.Limits.ReaderSize()), rw)
+	cr.sc.conn.SetLimits(&cr.sc.s.Limits)
+	cr.sc.conn.SetConformance(cr.sc.s.Conformance)
+
+	if h, ok := cr.sc.s.Handler.(ServerHandlerOnCustomRequest); ok {
+		cr.sc.conn.SetCustomMethods(h.CustomMethods())
+	}
 
 	readFunc := cr.readFuncStandard
 
@@ -150,7 +156,7 @@ func (cr *serverConnReader) handleTunneling(in io.ReadWriter) (io.ReadWriter, er
 			}
 			var buf2 bytes.Buffer
 			res.Write(&buf2) //nolint:errcheck
-			cr.sc.nconn.SetWriteDeadline(time.Now().Add(cr.sc.s.WriteTimeout))
+			cr.sc.nconn.SetWriteDeadline(time.Now().Add(cr.sc.s.writeTimeout()))
 			_, err = in.Write(buf2.Bytes())
 			if err != nil {
 				return nil, err
@@ -189,7 +195,7 @@ func (cr *serverConnReader) handleTunneling(in io.ReadWriter) (io.ReadWriter, er
 			}
 			var buf2 bytes.Buffer
 			res.Write(&buf2) //nolint:errcheck
-			cr.sc.nconn.SetWriteDeadline(time.Now().Add(cr.sc.s.WriteTimeout))
+			cr.sc.nconn.SetWriteDeadline(time.Now().Add(cr.sc.s.writeTimeout()))
 			_, err = in.Write(buf2.Bytes())
 			if err != nil {
 				return nil, err
@@ -209,7 +215,7 @@ func (cr *serverConnReader) readFuncStandard() error {
 		if cr.sc.session != nil && cr.sc.session.state == ServerSessionStateRecord {
 			cr.sc.nconn.SetReadDeadline(time.Time{})
 		} else {
-			cr.sc.nconn.SetReadDeadline(time.Now().Add(cr.sc.s.IdleTimeout))
+			cr.sc.nconn.SetReadDeadline(time.Now().Add(cr.sc.s.idleTimeout()))
 		}
 
 		what, err := cr.sc.conn.Read()
@@ -247,9 +253,9 @@ func (cr *serverConnReader) readFuncTCP() error {
 
 	for {
 		if cr.sc.session.state == ServerSessionStateRecord {
-			cr.sc.nconn.SetReadDeadline(time.Now().Add(cr.sc.s.ReadTimeout))
+			cr.sc.nconn.SetReadDeadline(time.Now().Add(cr.sc.s.readTimeout()))
 		} else {
-			cr.sc.nconn.SetReadDeadline(time.Now().Add(cr.sc.s.IdleTimeout))
+			cr.sc.nconn.SetReadDeadline(time.Now().Add(cr.sc.s.idleTimeout()))
 		}
 
 		what, err := cr.sc.conn.Read()
@@ -277,6 +283,8 @@ func (cr *serverConnReader) readFuncTCP() error {
 			return liberrors.ErrServerUnexpectedResponse{}
 
 		case *base.InterleavedFrame:
+			dumpPacketTCP(cr.sc.s.PacketDump, cr.sc.nconn, false, what.Channel, what.Payload)
+
 			if cb, ok := cr.sc.session.tcpCallbackByChannel[what.Channel]; ok {
 				cb(what.Payload)
 			}
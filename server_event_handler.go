@@ -0,0 +1,408 @@
+package gortsplib
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+)
+
+// ServerEvent is an event emitted by a ServerEventHandler.
+type ServerEvent interface {
+	isServerEvent()
+}
+
+type serverEventRes struct {
+	res    *base.Response
+	stream *ServerStream
+	err    error
+}
+
+type serverEventReply struct {
+	ch chan serverEventRes
+}
+
+func (r *serverEventReply) initialize() {
+	r.ch = make(chan serverEventRes, 1)
+}
+
+func (r *serverEventReply) reply(res serverEventRes) {
+	select {
+	case r.ch <- res:
+	default:
+		panic("Reply() called twice")
+	}
+}
+
+// ServerEventConnOpen is emitted when a connection is opened.
+type ServerEventConnOpen struct {
+	Ctx *ServerHandlerOnConnOpenCtx
+}
+
+// ServerEventConnClose is emitted when a connection is closed.
+type ServerEventConnClose struct {
+	Ctx *ServerHandlerOnConnCloseCtx
+}
+
+// ServerEventSessionOpen is emitted when a session is opened.
+type ServerEventSessionOpen struct {
+	Ctx *ServerHandlerOnSessionOpenCtx
+}
+
+// ServerEventSessionClose is emitted when a session is closed.
+type ServerEventSessionClose struct {
+	Ctx *ServerHandlerOnSessionCloseCtx
+}
+
+// ServerEventSessionStateChange is emitted when the state of a session changes.
+type ServerEventSessionStateChange struct {
+	Ctx *ServerHandlerOnSessionStateChangeCtx
+}
+
+// ServerEventDescribe is emitted when receiving a DESCRIBE request.
+// Reply() must be called exactly once.
+type ServerEventDescribe struct {
+	serverEventReply
+	Ctx *ServerHandlerOnDescribeCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventDescribe) Reply(res *base.Response, stream *ServerStream, err error) {
+	e.reply(serverEventRes{res: res, stream: stream, err: err})
+}
+
+// ServerEventAnnounce is emitted when receiving an ANNOUNCE request.
+// Reply() must be called exactly once.
+type ServerEventAnnounce struct {
+	serverEventReply
+	Ctx *ServerHandlerOnAnnounceCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventAnnounce) Reply(res *base.Response, err error) {
+	e.reply(serverEventRes{res: res, err: err})
+}
+
+// ServerEventSetup is emitted when receiving a SETUP request.
+// Reply() must be called exactly once.
+type ServerEventSetup struct {
+	serverEventReply
+	Ctx *ServerHandlerOnSetupCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventSetup) Reply(res *base.Response, stream *ServerStream, err error) {
+	e.reply(serverEventRes{res: res, stream: stream, err: err})
+}
+
+// ServerEventPlay is emitted when receiving a PLAY request.
+// Reply() must be called exactly once.
+type ServerEventPlay struct {
+	serverEventReply
+	Ctx *ServerHandlerOnPlayCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventPlay) Reply(res *base.Response, err error) {
+	e.reply(serverEventRes{res: res, err: err})
+}
+
+// ServerEventRecord is emitted when receiving a RECORD request.
+// Reply() must be called exactly once.
+type ServerEventRecord struct {
+	serverEventReply
+	Ctx *ServerHandlerOnRecordCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventRecord) Reply(res *base.Response, err error) {
+	e.reply(serverEventRes{res: res, err: err})
+}
+
+// ServerEventPause is emitted when receiving a PAUSE request.
+// Reply() must be called exactly once.
+type ServerEventPause struct {
+	serverEventReply
+	Ctx *ServerHandlerOnPauseCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventPause) Reply(res *base.Response, err error) {
+	e.reply(serverEventRes{res: res, err: err})
+}
+
+// ServerEventTeardown is emitted when receiving a TEARDOWN request.
+// Reply() must be called exactly once.
+type ServerEventTeardown struct {
+	serverEventReply
+	Ctx *ServerHandlerOnTeardownCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventTeardown) Reply(res *base.Response, err error) {
+	e.reply(serverEventRes{res: res, err: err})
+}
+
+// ServerEventGetParameter is emitted when receiving a GET_PARAMETER request.
+// Reply() must be called exactly once.
+type ServerEventGetParameter struct {
+	serverEventReply
+	Ctx *ServerHandlerOnGetParameterCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventGetParameter) Reply(res *base.Response, err error) {
+	e.reply(serverEventRes{res: res, err: err})
+}
+
+// ServerEventSetParameter is emitted when receiving a SET_PARAMETER request.
+// Reply() must be called exactly once.
+type ServerEventSetParameter struct {
+	serverEventReply
+	Ctx *ServerHandlerOnSetParameterCtx
+}
+
+// Reply sends the response of the request.
+func (e *ServerEventSetParameter) Reply(res *base.Response, err error) {
+	e.reply(serverEventRes{res: res, err: err})
+}
+
+// ServerEventPacketRTP is a RTP packet received from a session
+// passed to ServerEventHandler.ForwardPacketsRTP().
+// It is sent on the channel returned by ServerEventHandler.PacketsRTP().
+type ServerEventPacketRTP struct {
+	Session *ServerSession
+	Media   *description.Media
+	Format  format.Format
+	Packet  *rtp.Packet
+}
+
+func (ServerEventConnOpen) isServerEvent()           {}
+func (ServerEventConnClose) isServerEvent()          {}
+func (ServerEventSessionOpen) isServerEvent()        {}
+func (ServerEventSessionClose) isServerEvent()       {}
+func (ServerEventSessionStateChange) isServerEvent() {}
+func (ServerEventDescribe) isServerEvent()           {}
+func (ServerEventAnnounce) isServerEvent()           {}
+func (ServerEventSetup) isServerEvent()              {}
+func (ServerEventPlay) isServerEvent()               {}
+func (ServerEventRecord) isServerEvent()             {}
+func (ServerEventPause) isServerEvent()              {}
+func (ServerEventTeardown) isServerEvent()           {}
+func (ServerEventGetParameter) isServerEvent()       {}
+func (ServerEventSetParameter) isServerEvent()       {}
+
+// ServerEventHandler is a ServerHandler that emits events on a channel
+// instead of calling callbacks.
+// It allows to handle a Server inside a select-based state machine.
+// Requests are blocked until the corresponding event is replied.
+//
+// Events are emitted for connection and session events and for requests with a method
+// among DESCRIBE, ANNOUNCE, SETUP, PLAY, RECORD, PAUSE, TEARDOWN, GET_PARAMETER and SET_PARAMETER.
+// Other callbacks (OnRequest, OnResponse, OnCustomRequest and the ones about packets and errors,
+// that are called by reading routines and must not block) are not covered:
+// they can be implemented by a struct that embeds ServerEventHandler.
+type ServerEventHandler struct {
+	// size of the event queue.
+	// It defaults to zero (unbuffered).
+	QueueSize int
+
+	// size of the queue of RTP packets forwarded by ForwardPacketsRTP().
+	// When the queue is full, packets are discarded.
+	// It defaults to 256.
+	PacketQueueSize int
+
+	ctx                 context.Context
+	ctxCancel           func()
+	events              chan ServerEvent
+	packets             chan *ServerEventPacketRTP
+	packetsRTPDiscarded *uint64
+}
+
+// Initialize initializes ServerEventHandler.
+func (h *ServerEventHandler) Initialize() {
+	if h.PacketQueueSize == 0 {
+		h.PacketQueueSize = 256
+	}
+
+	h.ctx, h.ctxCancel = context.WithCancel(context.Background())
+	h.events = make(chan ServerEvent, h.QueueSize)
+	h.packets = make(chan *ServerEventPacketRTP, h.PacketQueueSize)
+	h.packetsRTPDiscarded = new(uint64)
+}
+
+// Close closes the handler.
+// Pending and future requests are replied with an error.
+func (h *ServerEventHandler) Close() {
+	h.ctxCancel()
+}
+
+// Events returns the event channel.
+func (h *ServerEventHandler) Events() <-chan ServerEvent {
+	return h.events
+}
+
+// PacketsRTP returns the channel of RTP packets forwarded by ForwardPacketsRTP().
+func (h *ServerEventHandler) PacketsRTP() <-chan *ServerEventPacketRTP {
+	return h.packets
+}
+
+// PacketsRTPDiscarded returns the number of RTP packets that have been discarded
+// since the packet queue was full.
+func (h *ServerEventHandler) PacketsRTPDiscarded() uint64 {
+	return atomic.LoadUint64(h.packetsRTPDiscarded)
+}
+
+// ForwardPacketsRTP sends every RTP packet received by the session to PacketsRTP().
+// Packets are sent from the reading routine of the session, without blocking it:
+// when the packet queue is full, packets are discarded and counted by PacketsRTPDiscarded().
+// It must be called inside the reply to ServerEventRecord, before Reply().
+func (h *ServerEventHandler) ForwardPacketsRTP(ss *ServerSession) {
+	ss.OnPacketRTPAny(func(medi *description.Media, forma format.Format, pkt *rtp.Packet) {
+		select {
+		case h.packets <- &ServerEventPacketRTP{
+			Session: ss,
+			Media:   medi,
+			Format:  forma,
+			Packet:  pkt,
+		}:
+		default:
+			atomic.AddUint64(h.packetsRTPDiscarded, 1)
+		}
+	})
+}
+
+func (h *ServerEventHandler) emit(e ServerEvent) bool {
+	select {
+	case h.events <- e:
+		return true
+	case <-h.ctx.Done():
+		return false
+	}
+}
+
+func (h *ServerEventHandler) request(e ServerEvent, r *serverEventReply) serverEventRes {
+	if !h.emit(e) {
+		return serverEventRes{
+			res: &base.Response{
+				StatusCode: base.StatusInternalServerError,
+			},
+			err: liberrors.ErrServerTerminated{},
+		}
+	}
+
+	select {
+	case res := <-r.ch:
+		return res
+
+	case <-h.ctx.Done():
+		return serverEventRes{
+			res: &base.Response{
+				StatusCode: base.StatusInternalServerError,
+			},
+			err: liberrors.ErrServerTerminated{},
+		}
+	}
+}
+
+// OnConnOpen implements ServerHandlerOnConnOpen.
+func (h *ServerEventHandler) OnConnOpen(ctx *ServerHandlerOnConnOpenCtx) {
+	h.emit(&ServerEventConnOpen{Ctx: ctx})
+}
+
+// OnConnClose implements ServerHandlerOnConnClose.
+func (h *ServerEventHandler) OnConnClose(ctx *ServerHandlerOnConnCloseCtx) {
+	h.emit(&ServerEventConnClose{Ctx: ctx})
+}
+
+// OnSessionOpen implements ServerHandlerOnSessionOpen.
+func (h *ServerEventHandler) OnSessionOpen(ctx *ServerHandlerOnSessionOpenCtx) {
+	h.emit(&ServerEventSessionOpen{Ctx: ctx})
+}
+
+// OnSessionClose implements ServerHandlerOnSessionClose.
+func (h *ServerEventHandler) OnSessionClose(ctx *ServerHandlerOnSessionCloseCtx) {
+	h.emit(&ServerEventSessionClose{Ctx: ctx})
+}
+
+// OnSessionStateChange implements ServerHandlerOnSessionStateChange.
+func (h *ServerEventHandler) OnSessionStateChange(ctx *ServerHandlerOnSessionStateChangeCtx) {
+	h.emit(&ServerEventSessionStateChange{Ctx: ctx})
+}
+
+// OnDescribe implements ServerHandlerOnDescribe.
+func (h *ServerEventHandler) OnDescribe(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+	e := &ServerEventDescribe{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.stream, res.err
+}
+
+// OnAnnounce implements ServerHandlerOnAnnounce.
+func (h *ServerEventHandler) OnAnnounce(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+	e := &ServerEventAnnounce{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.err
+}
+
+// OnSetup implements ServerHandlerOnSetup.
+func (h *ServerEventHandler) OnSetup(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+	e := &ServerEventSetup{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.stream, res.err
+}
+
+// OnPlay implements ServerHandlerOnPlay.
+func (h *ServerEventHandler) OnPlay(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+	e := &ServerEventPlay{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.err
+}
+
+// OnRecord implements ServerHandlerOnRecord.
+func (h *ServerEventHandler) OnRecord(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+	e := &ServerEventRecord{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.err
+}
+
+// OnPause implements ServerHandlerOnPause.
+func (h *ServerEventHandler) OnPause(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+	e := &ServerEventPause{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.err
+}
+
+// OnTeardown implements ServerHandlerOnTeardown.
+func (h *ServerEventHandler) OnTeardown(ctx *ServerHandlerOnTeardownCtx) (*base.Response, error) {
+	e := &ServerEventTeardown{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.err
+}
+
+// OnGetParameter implements ServerHandlerOnGetParameter.
+func (h *ServerEventHandler) OnGetParameter(ctx *ServerHandlerOnGetParameterCtx) (*base.Response, error) {
+	e := &ServerEventGetParameter{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.err
+}
+
+// OnSetParameter implements ServerHandlerOnSetParameter.
+func (h *ServerEventHandler) OnSetParameter(ctx *ServerHandlerOnSetParameterCtx) (*base.Response, error) {
+	e := &ServerEventSetParameter{Ctx: ctx}
+	e.initialize()
+	res := h.request(e, &e.serverEventReply)
+	return res.res, res.err
+}
@@ -1,8 +1,12 @@
 package gortsplib
 
 import (
+	"github.com/pion/rtp"
+
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/trackmetrics"
 )
 
 // ServerHandler is the interface implemented by all the server handlers.
@@ -52,9 +56,25 @@ type ServerHandlerOnSessionCloseCtx struct {
 // ServerHandlerOnSessionClose can be implemented by a ServerHandler.
 type ServerHandlerOnSessionClose interface {
 	// called when a session is closed.
+	// It is always called after OnConnClose() of the connections
+	// that were closed while associated with the session.
 	OnSessionClose(*ServerHandlerOnSessionCloseCtx)
 }
 
+// ServerHandlerOnSessionStateChangeCtx is the context of OnSessionStateChange.
+type ServerHandlerOnSessionStateChangeCtx struct {
+	Session  *ServerSession
+	Previous ServerSessionState
+	Current  ServerSessionState
+	Request  *base.Request
+}
+
+// ServerHandlerOnSessionStateChange can be implemented by a ServerHandler.
+type ServerHandlerOnSessionStateChange interface {
+	// called when the state of a session changes.
+	OnSessionStateChange(*ServerHandlerOnSessionStateChangeCtx)
+}
+
 // ServerHandlerOnRequest can be implemented by a ServerHandler.
 type ServerHandlerOnRequest interface {
 	// called when receiving a request from a connection.
@@ -69,10 +89,11 @@ type ServerHandlerOnResponse interface {
 
 // ServerHandlerOnDescribeCtx is the context of OnDescribe.
 type ServerHandlerOnDescribeCtx struct {
-	Conn    *ServerConn
-	Request *base.Request
-	Path    string
-	Query   string
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
 }
 
 // ServerHandlerOnDescribe can be implemented by a ServerHandler.
@@ -81,6 +102,54 @@ type ServerHandlerOnDescribe interface {
 	OnDescribe(*ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error)
 }
 
+// ServerMediaRejectReason is the reason why a media of an ANNOUNCE request is rejected.
+type ServerMediaRejectReason int
+
+// reasons why a media is rejected.
+const (
+	// the codec of the media is not supported.
+	// It is mapped to status 415 (Unsupported Media Type).
+	ServerMediaRejectReasonUnsupportedCodec ServerMediaRejectReason = iota
+
+	// the number of medias exceeds the ones that can be received.
+	// It is mapped to status 453 (Not Enough Bandwidth).
+	ServerMediaRejectReasonTooManyMedias
+
+	// the media is not allowed.
+	// It is mapped to status 403 (Forbidden).
+	ServerMediaRejectReasonForbidden
+)
+
+// String implements fmt.Stringer.
+func (r ServerMediaRejectReason) String() string {
+	switch r {
+	case ServerMediaRejectReasonUnsupportedCodec:
+		return "unsupported codec"
+	case ServerMediaRejectReasonTooManyMedias:
+		return "too many medias"
+	case ServerMediaRejectReasonForbidden:
+		return "forbidden"
+	}
+	return "unknown"
+}
+
+// StatusCode returns the status code associated with the reason.
+func (r ServerMediaRejectReason) StatusCode() base.StatusCode {
+	switch r {
+	case ServerMediaRejectReasonUnsupportedCodec:
+		return base.StatusUnsupportedMediaType
+	case ServerMediaRejectReasonTooManyMedias:
+		return base.StatusNotEnoughBandwidth
+	}
+	return base.StatusForbidden
+}
+
+// ServerMediaRejection is the rejection of a media of an ANNOUNCE request.
+type ServerMediaRejection struct {
+	Media  *description.Media
+	Reason ServerMediaRejectReason
+}
+
 // ServerHandlerOnAnnounceCtx is the context of OnAnnounce.
 type ServerHandlerOnAnnounceCtx struct {
 	Session     *ServerSession
@@ -88,7 +157,45 @@ type ServerHandlerOnAnnounceCtx struct {
 	Request     *base.Request
 	Path        string
 	Query       string
+	PathParams  map[string]string
 	Description *description.Session
+
+	rejections []ServerMediaRejection
+}
+
+// RejectMedia rejects a media of the announced description.
+// If the response returned by OnAnnounce is 200 OK and some medias are accepted,
+// the response contains a SDP with accepted medias only, and rejected medias
+// cannot be setupped. If all medias are rejected, the status code of the response
+// is replaced with the one associated with the reason of the first rejection.
+func (ctx *ServerHandlerOnAnnounceCtx) RejectMedia(medi *description.Media, reason ServerMediaRejectReason) {
+	ctx.rejections = append(ctx.rejections, ServerMediaRejection{
+		Media:  medi,
+		Reason: reason,
+	})
+}
+
+// Rejections returns medias that have been rejected.
+func (ctx *ServerHandlerOnAnnounceCtx) Rejections() []ServerMediaRejection {
+	return ctx.rejections
+}
+
+// AcceptedMedias returns medias of the announced description that have not been rejected.
+// It can be used to create a stream that contains accepted medias only.
+func (ctx *ServerHandlerOnAnnounceCtx) AcceptedMedias() []*description.Media {
+	var ret []*description.Media
+
+outer:
+	for _, medi := range ctx.Description.Medias {
+		for _, r := range ctx.rejections {
+			if r.Media == medi {
+				continue outer
+			}
+		}
+		ret = append(ret, medi)
+	}
+
+	return ret
 }
 
 // ServerHandlerOnAnnounce can be implemented by a ServerHandler.
@@ -99,12 +206,13 @@ type ServerHandlerOnAnnounce interface {
 
 // ServerHandlerOnSetupCtx is the context of OnSetup.
 type ServerHandlerOnSetupCtx struct {
-	Session   *ServerSession
-	Conn      *ServerConn
-	Request   *base.Request
-	Path      string
-	Query     string
-	Transport *SessionTransport
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
+	Transport  *SessionTransport
 }
 
 // ServerHandlerOnSetup can be implemented by a ServerHandler.
@@ -119,11 +227,12 @@ type ServerHandlerOnSetup interface {
 
 // ServerHandlerOnPlayCtx is the context of OnPlay.
 type ServerHandlerOnPlayCtx struct {
-	Session *ServerSession
-	Conn    *ServerConn
-	Request *base.Request
-	Path    string
-	Query   string
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
 }
 
 // ServerHandlerOnPlay can be implemented by a ServerHandler.
@@ -134,11 +243,12 @@ type ServerHandlerOnPlay interface {
 
 // ServerHandlerOnRecordCtx is the context of OnRecord.
 type ServerHandlerOnRecordCtx struct {
-	Session *ServerSession
-	Conn    *ServerConn
-	Request *base.Request
-	Path    string
-	Query   string
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
 }
 
 // ServerHandlerOnRecord can be implemented by a ServerHandler.
@@ -149,11 +259,12 @@ type ServerHandlerOnRecord interface {
 
 // ServerHandlerOnPauseCtx is the context of OnPause.
 type ServerHandlerOnPauseCtx struct {
-	Session *ServerSession
-	Conn    *ServerConn
-	Request *base.Request
-	Path    string
-	Query   string
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
 }
 
 // ServerHandlerOnPause can be implemented by a ServerHandler.
@@ -162,13 +273,33 @@ type ServerHandlerOnPause interface {
 	OnPause(*ServerHandlerOnPauseCtx) (*base.Response, error)
 }
 
+// ServerHandlerOnTeardownCtx is the context of OnTeardown.
+type ServerHandlerOnTeardownCtx struct {
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
+	// media that is being removed from the session,
+	// or nil if the whole session is being torn down.
+	Media *description.Media
+}
+
+// ServerHandlerOnTeardown can be implemented by a ServerHandler.
+type ServerHandlerOnTeardown interface {
+	// called when receiving a TEARDOWN request.
+	OnTeardown(*ServerHandlerOnTeardownCtx) (*base.Response, error)
+}
+
 // ServerHandlerOnGetParameterCtx is the context of OnGetParameter.
 type ServerHandlerOnGetParameterCtx struct {
-	Session *ServerSession
-	Conn    *ServerConn
-	Request *base.Request
-	Path    string
-	Query   string
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
 }
 
 // ServerHandlerOnGetParameter can be implemented by a ServerHandler.
@@ -179,11 +310,12 @@ type ServerHandlerOnGetParameter interface {
 
 // ServerHandlerOnSetParameterCtx is the context of OnSetParameter.
 type ServerHandlerOnSetParameterCtx struct {
-	Session *ServerSession
-	Conn    *ServerConn
-	Request *base.Request
-	Path    string
-	Query   string
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
 }
 
 // ServerHandlerOnSetParameter can be implemented by a ServerHandler.
@@ -192,6 +324,26 @@ type ServerHandlerOnSetParameter interface {
 	OnSetParameter(*ServerHandlerOnSetParameterCtx) (*base.Response, error)
 }
 
+// ServerHandlerOnCustomRequestCtx is the context of OnCustomRequest.
+type ServerHandlerOnCustomRequestCtx struct {
+	Session    *ServerSession
+	Conn       *ServerConn
+	Request    *base.Request
+	Path       string
+	Query      string
+	PathParams map[string]string
+}
+
+// ServerHandlerOnCustomRequest can be implemented by a ServerHandler.
+type ServerHandlerOnCustomRequest interface {
+	// returns non-standard methods that are handled by OnCustomRequest.
+	// They are advertised in responses to OPTIONS requests.
+	CustomMethods() []base.Method
+
+	// called when receiving a request whose method is returned by CustomMethods.
+	OnCustomRequest(*ServerHandlerOnCustomRequestCtx) (*base.Response, error)
+}
+
 // ServerHandlerOnPacketsLostCtx is the context of OnPacketsLost.
 type ServerHandlerOnPacketsLostCtx struct {
 	Session *ServerSession
@@ -216,6 +368,22 @@ type ServerHandlerOnDecodeError interface {
 	OnDecodeError(*ServerHandlerOnDecodeErrorCtx)
 }
 
+// ServerHandlerOnUnknownPacketCtx is the context of OnUnknownPacket.
+type ServerHandlerOnUnknownPacketCtx struct {
+	Session *ServerSession
+	Media   *description.Media
+	Packet  *rtp.Packet
+}
+
+// ServerHandlerOnUnknownPacket can be implemented by a ServerHandler.
+type ServerHandlerOnUnknownPacket interface {
+	// called when a RTP packet is received with a payload type
+	// that doesn't match any format of the media, for instance after a codec change.
+	// When implemented, these packets are not reported through OnDecodeError.
+	// It is called by the reading routine and must not block.
+	OnUnknownPacket(*ServerHandlerOnUnknownPacketCtx)
+}
+
 // ServerHandlerOnStreamWriteErrorCtx is the context of OnStreamWriteError.
 type ServerHandlerOnStreamWriteErrorCtx struct {
 	Session *ServerSession
@@ -227,3 +395,33 @@ type ServerHandlerOnStreamWriteError interface {
 	// called when a ServerStream is unable to write packets to a session.
 	OnStreamWriteError(*ServerHandlerOnStreamWriteErrorCtx)
 }
+
+// ServerHandlerOnTrackMetricsChangeCtx is the context of OnTrackMetricsChange.
+type ServerHandlerOnTrackMetricsChangeCtx struct {
+	Session *ServerSession
+	Media   *description.Media
+	Format  format.Format
+	Metrics trackmetrics.Metrics
+}
+
+// ServerHandlerOnTrackMetricsChange can be implemented by a ServerHandler.
+type ServerHandlerOnTrackMetricsChange interface {
+	// called when bitrate, frame rate or key frame interval of an incoming format
+	// change by more than Server.TrackMetricsThreshold.
+	OnTrackMetricsChange(*ServerHandlerOnTrackMetricsChangeCtx)
+}
+
+// ServerHandlerOnSSRCChangeCtx is the context of OnSSRCChange.
+type ServerHandlerOnSSRCChangeCtx struct {
+	Session *ServerSession
+	Media   *description.Media
+	Format  format.Format
+	Prev    uint32
+	Cur     uint32
+}
+
+// ServerHandlerOnSSRCChange can be implemented by a ServerHandler.
+type ServerHandlerOnSSRCChange interface {
+	// called when the SSRC of an incoming format changes and Server.AllowSSRCChange is true.
+	OnSSRCChange(*ServerHandlerOnSSRCChangeCtx)
+}
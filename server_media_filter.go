@@ -0,0 +1,70 @@
+package gortsplib
+
+import (
+	"slices"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+)
+
+// MediaFilter decides whether medias can be setupped by clients.
+type MediaFilter interface {
+	// FilterMedia is called when a client sends a SETUP request, after OnSetup
+	// and before the media is setupped.
+	// It returns StatusOK to allow the media, or another status code to deny it.
+	// Medias of a publisher that are denied do not need to be setupped
+	// in order to start recording.
+	// It must be safe for concurrent use.
+	FilterMedia(ss *ServerSession, medi *description.Media) base.StatusCode
+}
+
+// MediaFilterFunc is a function that implements MediaFilter.
+type MediaFilterFunc func(ss *ServerSession, medi *description.Media) base.StatusCode
+
+// FilterMedia implements MediaFilter.
+func (f MediaFilterFunc) FilterMedia(ss *ServerSession, medi *description.Media) base.StatusCode {
+	return f(ss, medi)
+}
+
+// CodecMediaFilter is a MediaFilter based on codecs.
+// A media is allowed when at least one of its formats is allowed.
+type CodecMediaFilter struct {
+	// codecs that are allowed, as returned by format.Format.Codec().
+	// It defaults to nil (all codecs are allowed).
+	Allow []string
+
+	// codecs that are denied.
+	// Denials have precedence over allowances.
+	// It defaults to nil.
+	Deny []string
+
+	// status code of responses to denied requests.
+	// It defaults to StatusUnsupportedMediaType.
+	DenyStatusCode base.StatusCode
+}
+
+// Initialize initializes CodecMediaFilter.
+func (f *CodecMediaFilter) Initialize() {
+	if f.DenyStatusCode == 0 {
+		f.DenyStatusCode = base.StatusUnsupportedMediaType
+	}
+}
+
+// FilterMedia implements MediaFilter.
+func (f *CodecMediaFilter) FilterMedia(_ *ServerSession, medi *description.Media) base.StatusCode {
+	for _, forma := range medi.Formats {
+		codec := forma.Codec()
+
+		if slices.Contains(f.Deny, codec) {
+			continue
+		}
+
+		if f.Allow != nil && !slices.Contains(f.Allow, codec) {
+			continue
+		}
+
+		return base.StatusOK
+	}
+
+	return f.DenyStatusCode
+}
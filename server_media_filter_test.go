@@ -0,0 +1,162 @@
+package gortsplib
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+)
+
+func TestCodecMediaFilter(t *testing.T) {
+	f := &CodecMediaFilter{
+		Allow: []string{"H264", "G711"},
+		Deny:  []string{"G711"},
+	}
+	f.Initialize()
+
+	for _, ca := range []struct {
+		name string
+		medi *description.Media
+		code base.StatusCode
+	}{
+		{
+			"allowed",
+			testH264Media,
+			base.StatusOK,
+		},
+		{
+			"denied",
+			&description.Media{
+				Type:    description.MediaTypeAudio,
+				Formats: []format.Format{&format.G711{PayloadTyp: 0, MULaw: true, SampleRate: 8000, ChannelCount: 1}},
+			},
+			base.StatusUnsupportedMediaType,
+		},
+		{
+			"not allowed",
+			&description.Media{
+				Type:    description.MediaTypeAudio,
+				Formats: []format.Format{&format.Opus{PayloadTyp: 96, ChannelCount: 2}},
+			},
+			base.StatusUnsupportedMediaType,
+		},
+		{
+			"one format allowed",
+			&description.Media{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{
+					&format.Opus{PayloadTyp: 96, ChannelCount: 2},
+					&format.H264{PayloadTyp: 97, PacketizationMode: 1},
+				},
+			},
+			base.StatusOK,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.code, f.FilterMedia(nil, ca.medi))
+		})
+	}
+}
+
+func TestServerMediaFilter(t *testing.T) {
+	for _, ca := range []string{"filter", "max medias"} {
+		t.Run(ca, func(t *testing.T) {
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(_ *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress: "localhost:8554",
+			}
+
+			if ca == "filter" {
+				f := &CodecMediaFilter{
+					Allow: []string{"H264"},
+				}
+				f.Initialize()
+				s.MediaFilter = f
+			} else {
+				s.MaxMediasPerSession = 1
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+			medias := []*description.Media{
+				{
+					Type:    description.MediaTypeVideo,
+					Formats: []format.Format{testH264Media.Formats[0]},
+				},
+				{
+					Type: description.MediaTypeAudio,
+					Formats: []format.Format{&format.G711{
+						PayloadTyp:   8,
+						MULaw:        false,
+						SampleRate:   8000,
+						ChannelCount: 1,
+					}},
+				},
+			}
+
+			doAnnounce(t, conn, "rtsp://localhost:8554/teststream", medias)
+
+			inTH := &headers.Transport{
+				Protocol:       headers.TransportProtocolTCP,
+				Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+				Mode:           ptrOf(headers.TransportModeRecord),
+				InterleavedIDs: &[2]int{0, 1},
+			}
+
+			res, _ := doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medias[0].Control, inTH, "")
+			session := readSession(t, res)
+
+			inTH.InterleavedIDs = &[2]int{2, 3}
+
+			res, err = writeReqReadRes(conn, base.Request{
+				Method: base.Setup,
+				URL:    mustParseURL("rtsp://localhost:8554/teststream/" + medias[1].Control),
+				Header: base.Header{
+					"CSeq":      base.HeaderValue{"3"},
+					"Transport": inTH.Marshal(),
+					"Session":   base.HeaderValue{session},
+				},
+			})
+			require.NoError(t, err)
+
+			if ca == "filter" {
+				require.Equal(t, base.StatusUnsupportedMediaType, res.StatusCode)
+			} else {
+				require.Equal(t, base.StatusForbidden, res.StatusCode)
+			}
+
+			// denied medias are not needed to start recording
+			doRecord(t, conn, "rtsp://localhost:8554/teststream", session)
+		})
+	}
+}
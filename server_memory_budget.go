@@ -0,0 +1,73 @@
+package gortsplib
+
+import (
+	"sync/atomic"
+)
+
+// MemoryBudgetPolicy is the policy applied when the memory budget of a Server is exhausted.
+type MemoryBudgetPolicy int
+
+// memory budget policies.
+const (
+	// packets that do not fit into the budget are discarded.
+	MemoryBudgetPolicyDrop MemoryBudgetPolicy = iota
+
+	// sessions whose packets do not fit into the budget are closed,
+	// releasing all their buffers.
+	MemoryBudgetPolicyCloseSession
+)
+
+// ServerMemoryStats are statistics about memory used by buffers of a Server.
+type ServerMemoryStats struct {
+	// bytes currently buffered.
+	BufferedBytes uint64
+	// maximum bytes buffered at the same time.
+	PeakBufferedBytes uint64
+	// bytes discarded since they didn't fit into the budget.
+	RejectedBytes uint64
+	// sessions closed since their packets didn't fit into the budget.
+	ClosedSessions uint64
+}
+
+// serverMemoryBudget keeps track of bytes buffered by all sessions of a Server.
+type serverMemoryBudget struct {
+	max uint64
+
+	used           atomic.Uint64
+	peak           atomic.Uint64
+	rejected       atomic.Uint64
+	closedSessions atomic.Uint64
+}
+
+func (b *serverMemoryBudget) acquire(n uint64) bool {
+	for {
+		cur := b.used.Load()
+
+		if b.max != 0 && cur+n > b.max {
+			b.rejected.Add(n)
+			return false
+		}
+
+		if b.used.CompareAndSwap(cur, cur+n) {
+			for {
+				peak := b.peak.Load()
+				if cur+n <= peak || b.peak.CompareAndSwap(peak, cur+n) {
+					return true
+				}
+			}
+		}
+	}
+}
+
+func (b *serverMemoryBudget) release(n uint64) {
+	b.used.Add(^(n - 1))
+}
+
+func (b *serverMemoryBudget) stats() *ServerMemoryStats {
+	return &ServerMemoryStats{
+		BufferedBytes:     b.used.Load(),
+		PeakBufferedBytes: b.peak.Load(),
+		RejectedBytes:     b.rejected.Load(),
+		ClosedSessions:    b.closedSessions.Load(),
+	}
+}
@@ -0,0 +1,111 @@
+package gortsplib
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+// standard methods, in the order in which they are advertised.
+var serverStandardMethods = []base.Method{
+	base.Describe,
+	base.Announce,
+	base.Setup,
+	base.Play,
+	base.Record,
+	base.Pause,
+	base.GetParameter,
+	base.SetParameter,
+	base.Teardown,
+}
+
+// serverHandlerWithMethods is implemented by handlers that dispatch requests to other handlers,
+// and therefore support different methods depending on the request.
+type serverHandlerWithMethods interface {
+	supportedMethods(sc *ServerConn, req *base.Request, path string) []base.Method
+}
+
+func serverHandlerSupportsMethod(h ServerHandler, method base.Method) bool {
+	switch method {
+	case base.Describe:
+		_, ok := h.(ServerHandlerOnDescribe)
+		return ok
+
+	case base.Announce:
+		_, ok := h.(ServerHandlerOnAnnounce)
+		return ok
+
+	case base.Setup:
+		_, ok := h.(ServerHandlerOnSetup)
+		return ok
+
+	case base.Play:
+		_, ok := h.(ServerHandlerOnPlay)
+		return ok
+
+	case base.Record:
+		_, ok := h.(ServerHandlerOnRecord)
+		return ok
+
+	case base.Pause:
+		_, ok := h.(ServerHandlerOnPause)
+		return ok
+
+	case base.SetParameter:
+		_, ok := h.(ServerHandlerOnSetParameter)
+		return ok
+
+	// GET_PARAMETER is used as keepalive and sessions can always be torn down
+	case base.GetParameter, base.Teardown:
+		return true
+	}
+
+	if h, ok := h.(ServerHandlerOnCustomRequest); ok {
+		return slices.Contains(h.CustomMethods(), method)
+	}
+
+	return false
+}
+
+// serverHandlerMethods returns the methods supported by one or more handlers.
+func serverHandlerMethods(handlers ...ServerHandler) []base.Method {
+	var methods []base.Method
+
+	for _, method := range serverStandardMethods {
+		for _, h := range handlers {
+			if serverHandlerSupportsMethod(h, method) {
+				methods = append(methods, method)
+				break
+			}
+		}
+	}
+
+	for _, h := range handlers {
+		if h, ok := h.(ServerHandlerOnCustomRequest); ok {
+			for _, method := range h.CustomMethods() {
+				if !slices.Contains(methods, method) {
+					methods = append(methods, method)
+				}
+			}
+		}
+	}
+
+	return methods
+}
+
+func serverPublicHeader(h ServerHandler, sc *ServerConn, req *base.Request, path string) base.HeaderValue {
+	var methods []base.Method
+	if hm, ok := h.(serverHandlerWithMethods); ok {
+		methods = hm.supportedMethods(sc, req, path)
+	} else {
+		methods = serverHandlerMethods(h)
+	}
+
+	tmp := make([]string, len(methods))
+	for i, method := range methods {
+		tmp[i] = string(method)
+	}
+
+	return base.HeaderValue{strings.Join(tmp, ", ")}
+}
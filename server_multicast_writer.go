@@ -27,7 +27,8 @@ func (h *serverMulticastWriter) initialize() error {
 	rtpl, rtcpl, err := createUDPListenerMulticastPair(
 		h.s.UDPReadBufferSize,
 		h.s.ListenPacket,
-		h.s.WriteTimeout,
+		h.s.writeTimeout,
+		h.s.PacketDump,
 		h.s.MulticastRTPPort,
 		h.s.MulticastRTCPPort,
 		ip,
@@ -0,0 +1,227 @@
+package gortsplib
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/gortsplib/v5/internal/mediatime"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+)
+
+type serverPausedPacket struct {
+	pkt   *rtp.Packet
+	size  uint64
+	time  time.Time
+	start bool
+}
+
+// serverPauseBuffer stores packets of a format that are received while a session is paused,
+// and rewrites sequence numbers and timestamps of packets sent to the session,
+// in order to make them contiguous across pauses.
+type serverPauseBuffer struct {
+	clockRate            int
+	maxDuration          time.Duration
+	memoryBudget         *serverMemoryBudget
+	onMemoryBudgetExceed func() error
+
+	mutex     sync.Mutex
+	paused    bool
+	packets   []*serverPausedPacket
+	hasPrevTS bool
+	prevTS    uint32
+	hasLast   bool
+	lastSeq   uint16
+	resync    bool
+	seqOffset uint16
+	tsOffset  uint32
+	saveFirst bool
+	firstSeq  *uint16
+	firstTS   *uint32
+}
+
+func (b *serverPauseBuffer) pause() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.paused = true
+	b.releasePackets(b.packets)
+	b.packets = nil
+	b.hasPrevTS = false
+}
+
+// clear stops buffering and discards buffered packets.
+func (b *serverPauseBuffer) clear() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.paused = false
+	b.releasePackets(b.packets)
+	b.packets = nil
+}
+
+func (b *serverPauseBuffer) releasePackets(packets []*serverPausedPacket) {
+	for _, p := range packets {
+		b.memoryBudget.release(p.size)
+	}
+}
+
+// push stores a packet received while the session is paused.
+// The buffer always starts with a random access unit,
+// therefore it can exceed maxDuration until the next random access unit is received.
+func (b *serverPauseBuffer) push(pkt *rtp.Packet, now time.Time, randomAccess bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.paused {
+		return
+	}
+
+	// a random access unit starts with the first packet that has its timestamp
+	start := randomAccess && (!b.hasPrevTS || pkt.Timestamp != b.prevTS)
+	b.hasPrevTS = true
+	b.prevTS = pkt.Timestamp
+
+	if len(b.packets) == 0 && !start {
+		return
+	}
+
+	// when a random access unit is received, remove packets that are older than maxDuration,
+	// while making sure that the buffer starts with a random access unit.
+	if start {
+		minTime := now.Add(-b.maxDuration)
+		i := len(b.packets)
+
+		for j, p := range b.packets {
+			if p.start && !p.time.Before(minTime) {
+				i = j
+				break
+			}
+		}
+
+		b.releasePackets(b.packets[:i])
+		b.packets = b.packets[i:]
+	}
+
+	size := uint64(pkt.MarshalSize())
+
+	// when the memory budget is exceeded, the buffer is discarded,
+	// and buffering restarts from the next random access unit.
+	if !b.memoryBudget.acquire(size) {
+		b.releasePackets(b.packets)
+		b.packets = nil
+		b.onMemoryBudgetExceed() //nolint:errcheck
+		return
+	}
+
+	b.packets = append(b.packets, &serverPausedPacket{
+		pkt:   pkt.Clone(),
+		size:  size,
+		time:  now,
+		start: start,
+	})
+}
+
+// startTime returns the reception time of the first buffered packet.
+func (b *serverPauseBuffer) startTime() (time.Time, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.packets) == 0 {
+		return time.Time{}, false
+	}
+	return b.packets[0].time, true
+}
+
+// resume stops buffering and returns buffered packets received after minTime.
+// Timestamps of next packets are shifted back by shift, sequence numbers
+// of next packets are made contiguous with the ones of packets sent before the pause.
+func (b *serverPauseBuffer) resume(minTime time.Time, shift time.Duration) []*serverPausedPacket {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.paused = false
+	b.resync = true
+	b.tsOffset -= uint32(mediatime.DurationToTimestamp(shift, b.clockRate))
+
+	packets := b.packets
+	b.packets = nil
+
+	// packets are moved into the write queue, that keeps track of them separately.
+	b.releasePackets(packets)
+
+	// discard packets that precede the start of the replay,
+	// while making sure that the replay starts with a random access unit.
+	i := len(packets)
+	for j, p := range packets {
+		if p.start && !p.time.Before(minTime) {
+			i = j
+			break
+		}
+	}
+
+	// save sequence number and timestamp of the first replayed packet,
+	// in order to use them in the RTP-Info header.
+	b.saveFirst = i < len(packets)
+
+	return packets[i:]
+}
+
+// rewrite returns the packet that has to be sent to the session in place of pkt.
+func (b *serverPauseBuffer) rewrite(pkt *rtp.Packet) *rtp.Packet {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.resync {
+		b.resync = false
+		if b.hasLast {
+			b.seqOffset = b.lastSeq + 1 - pkt.SequenceNumber
+		}
+	}
+
+	if b.seqOffset != 0 || b.tsOffset != 0 {
+		pkt2 := *pkt
+		pkt2.SequenceNumber += b.seqOffset
+		pkt2.Timestamp += b.tsOffset
+		pkt = &pkt2
+	}
+
+	if b.saveFirst {
+		b.saveFirst = false
+		seq := pkt.SequenceNumber
+		ts := pkt.Timestamp
+		b.firstSeq = &seq
+		b.firstTS = &ts
+	}
+
+	b.hasLast = true
+	b.lastSeq = pkt.SequenceNumber
+
+	return pkt
+}
+
+// adjustRTPInfoEntry makes a RTP-Info entry, generated from the stream,
+// consistent with the packets sent to the session.
+func (b *serverPauseBuffer) adjustRTPInfoEntry(entry *headers.RTPInfoEntry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.firstSeq != nil {
+		entry.SequenceNumber = b.firstSeq
+		entry.Timestamp = b.firstTS
+		b.firstSeq = nil
+		b.firstTS = nil
+		return
+	}
+
+	if b.hasLast && entry.SequenceNumber != nil {
+		seq := b.lastSeq + 1
+		entry.SequenceNumber = &seq
+	}
+
+	if entry.Timestamp != nil {
+		ts := *entry.Timestamp + b.tsOffset
+		entry.Timestamp = &ts
+	}
+}
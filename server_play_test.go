@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/tls"
+	"io"
 	"net"
 	"strconv"
 	"strings"
@@ -23,8 +24,10 @@ import (
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
 	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v5/pkg/mikey"
 	"github.com/bluenviron/gortsplib/v5/pkg/ntp"
+	"github.com/bluenviron/gortsplib/v5/pkg/onvif"
 	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
 )
 
@@ -2227,6 +2230,115 @@ func TestServerPlayUDPChangeConn(t *testing.T) {
 	}()
 }
 
+func TestServerPlayValidateUDPAddresses(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		UDPRTPAddress:        "127.0.0.1:8000",
+		UDPRTCPAddress:       "127.0.0.1:8001",
+		RTSPAddress:          "localhost:8554",
+		ValidateUDPAddresses: true,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	// advertised port
+	l1, err := net.ListenPacket("udp", "127.0.0.1:35466")
+	require.NoError(t, err)
+	defer l1.Close()
+
+	// real port, like the one assigned by a NAT
+	l2, err := net.ListenPacket("udp", "127.0.0.1:35470")
+	require.NoError(t, err)
+	defer l2.Close()
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Delivery:    ptrOf(headers.TransportDeliveryUnicast),
+		Mode:        ptrOf(headers.TransportModePlay),
+		Protocol:    headers.TransportProtocolUDP,
+		ClientPorts: &[2]int{35466, 35467},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	writePkt := func() {
+		err2 := stream.WritePacketRTP(stream.Desc.Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:     2,
+				PayloadType: 96,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		})
+		require.NoError(t, err2)
+	}
+
+	// packets are not sent before validation
+	writePkt()
+
+	l1.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	buf := make([]byte, 2048)
+	_, _, err = l1.ReadFrom(buf)
+	require.Error(t, err)
+
+	_, err = l2.WriteTo(mustMarshalPacketRTP(&rtp.Packet{Header: rtp.Header{Version: 2}}),
+		&net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8000})
+	require.NoError(t, err)
+
+	// packets are sent to the learned address
+	for {
+		writePkt()
+
+		l2.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		var n int
+		n, _, err = l2.ReadFrom(buf)
+		if err == nil {
+			var pkt rtp.Packet
+			err = pkt.Unmarshal(buf[:n])
+			require.NoError(t, err)
+			require.Equal(t, []byte{1, 2, 3, 4}, pkt.Payload)
+			break
+		}
+	}
+}
+
 func TestServerPlayPartialMedias(t *testing.T) {
 	var stream *ServerStream
 
@@ -2806,3 +2918,1179 @@ func TestServerPlayBackChannel(t *testing.T) {
 		})
 	}
 }
+
+func TestServerPlayMoveReaders(t *testing.T) {
+	var stream *ServerStream
+	var serverSession *ServerSession
+
+	s := &Server{
+		RTSPAddress: "localhost:8554",
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				serverSession = ctx.Session
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	desc := &description.Session{Medias: []*description.Media{testH264Media}}
+
+	primary := &ServerStream{
+		Server: s,
+		Desc:   desc,
+	}
+	err = primary.Initialize()
+	require.NoError(t, err)
+	defer primary.Close()
+
+	stream = primary
+
+	backup := &ServerStream{
+		Server: s,
+		Desc:   desc,
+	}
+	err = backup.Initialize()
+	require.NoError(t, err)
+	defer backup.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc2 := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Mode:           ptrOf(headers.TransportModePlay),
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc2.BaseURL, desc2.Medias[0]).String(), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	readPacket := func() *rtp.Packet {
+		f, err2 := conn.ReadInterleavedFrame()
+		require.NoError(t, err2)
+		require.Equal(t, 0, f.Channel)
+
+		var pkt rtp.Packet
+		err2 = pkt.Unmarshal(f.Payload)
+		require.NoError(t, err2)
+		return &pkt
+	}
+
+	err = primary.WritePacketRTP(desc.Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 100,
+			Timestamp:      1000,
+		},
+		Payload: []byte{5, 1},
+	})
+	require.NoError(t, err)
+
+	pkt1 := readPacket()
+
+	err = backup.MoveReaders(primary)
+	require.EqualError(t, err, "destination stream already has readers")
+
+	err = primary.MoveReaders(backup)
+	require.NoError(t, err)
+
+	stream = backup
+	require.Equal(t, backup, serverSession.Stream())
+
+	// packets of the primary stream are not received anymore
+	err = primary.WritePacketRTP(desc.Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 101,
+			Timestamp:      1000,
+		},
+		Payload: []byte{5, 3},
+	})
+	require.NoError(t, err)
+
+	for i := range 2 {
+		err = backup.WritePacketRTP(desc.Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: 5000 + uint16(i),
+				Timestamp:      900000 + uint32(i)*3000,
+			},
+			Payload: []byte{5, 2},
+		})
+		require.NoError(t, err)
+	}
+
+	pkt2 := readPacket()
+	require.Equal(t, pkt1.SSRC, pkt2.SSRC)
+	require.Equal(t, uint16(101), pkt2.SequenceNumber)
+	require.GreaterOrEqual(t, pkt2.Timestamp, uint32(1000))
+	require.Less(t, pkt2.Timestamp, uint32(1000+90000))
+	require.Equal(t, []byte{5, 2}, pkt2.Payload)
+
+	pkt3 := readPacket()
+	require.Equal(t, pkt1.SSRC, pkt3.SSRC)
+	require.Equal(t, uint16(102), pkt3.SequenceNumber)
+	require.Equal(t, pkt2.Timestamp+3000, pkt3.Timestamp)
+}
+
+func TestServerPlayStreamGroup(t *testing.T) {
+	var stream *ServerStream
+	var serverSession *ServerSession
+
+	s := &Server{
+		RTSPAddress: "localhost:8554",
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				serverSession = ctx.Session
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	desc := &description.Session{Medias: []*description.Media{testH264Media}}
+
+	low := &ServerStream{
+		Server: s,
+		Desc:   desc,
+	}
+	err = low.Initialize()
+	require.NoError(t, err)
+	defer low.Close()
+
+	high := &ServerStream{
+		Server: s,
+		Desc:   desc,
+	}
+	err = high.Initialize()
+	require.NoError(t, err)
+	defer high.Close()
+
+	err = (&ServerStreamGroup{
+		Streams: []*ServerStream{low, {Desc: &description.Session{}}},
+	}).Initialize()
+	require.EqualError(t, err, "streams have different descriptions")
+
+	group := &ServerStreamGroup{
+		Streams: []*ServerStream{low, high},
+	}
+	err = group.Initialize()
+	require.NoError(t, err)
+
+	stream = low
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc2 := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Mode:           ptrOf(headers.TransportModePlay),
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc2.BaseURL, desc2.Medias[0]).String(), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	require.Equal(t, 0, group.Index(serverSession))
+
+	readPacket := func() *rtp.Packet {
+		f, err2 := conn.ReadInterleavedFrame()
+		require.NoError(t, err2)
+		require.Equal(t, 0, f.Channel)
+
+		var pkt rtp.Packet
+		err2 = pkt.Unmarshal(f.Payload)
+		require.NoError(t, err2)
+		return &pkt
+	}
+
+	writePacket := func(st *ServerStream, seq uint16, ts uint32, payload []byte) {
+		err2 := st.WritePacketRTP(desc.Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				Timestamp:      ts,
+			},
+			Payload: payload,
+		})
+		require.NoError(t, err2)
+	}
+
+	writePacket(low, 100, 1000, []byte{5, 1})
+
+	pkt1 := readPacket()
+
+	err = group.Switch(serverSession, 2)
+	require.EqualError(t, err, "invalid stream index: 2")
+
+	err = group.Switch(serverSession, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, group.Index(serverSession))
+	require.Equal(t, high, serverSession.Stream())
+
+	// packets of the low quality stream are not received anymore
+	writePacket(low, 101, 4000, []byte{5, 3})
+
+	writePacket(high, 5000, 900000, []byte{5, 2})
+	writePacket(high, 5001, 903000, []byte{5, 2})
+
+	pkt2 := readPacket()
+	require.Equal(t, pkt1.SSRC, pkt2.SSRC)
+	require.Equal(t, uint16(101), pkt2.SequenceNumber)
+	require.GreaterOrEqual(t, pkt2.Timestamp, uint32(1000))
+	require.Less(t, pkt2.Timestamp, uint32(1000+90000))
+	require.Equal(t, []byte{5, 2}, pkt2.Payload)
+
+	pkt3 := readPacket()
+	require.Equal(t, pkt1.SSRC, pkt3.SSRC)
+	require.Equal(t, uint16(102), pkt3.SequenceNumber)
+	require.Equal(t, pkt2.Timestamp+3000, pkt3.Timestamp)
+
+	writeSenderReport := func(st *ServerStream, rtpTime uint32) {
+		err2 := st.WritePacketRTCP(desc.Medias[0], &rtcp.SenderReport{
+			SSRC:        st.medias[desc.Medias[0]].formats[96].localSSRC,
+			NTPTime:     ntp.Encode(time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)),
+			RTPTime:     rtpTime,
+			PacketCount: 2,
+			OctetCount:  4,
+		})
+		require.NoError(t, err2)
+	}
+
+	// sender reports are rewritten
+	writeSenderReport(high, 906000)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 1, f.Channel)
+
+	pkts, err := rtcp.Unmarshal(f.Payload)
+	require.NoError(t, err)
+	require.Equal(t, []rtcp.Packet{&rtcp.SenderReport{
+		SSRC:        pkt1.SSRC,
+		NTPTime:     ntp.Encode(time.Date(2017, 8, 12, 15, 30, 0, 0, time.UTC)),
+		RTPTime:     pkt3.Timestamp + 3000,
+		PacketCount: 2,
+		OctetCount:  4,
+	}}, pkts)
+
+	err = group.Switch(serverSession, 0)
+	require.NoError(t, err)
+
+	// sender reports are not sent until the offset is known
+	writeSenderReport(low, 7000)
+
+	writePacket(low, 102, 7000, []byte{5, 4})
+
+	pkt4 := readPacket()
+	require.Equal(t, pkt1.SSRC, pkt4.SSRC)
+	require.Equal(t, uint16(103), pkt4.SequenceNumber)
+	require.Equal(t, []byte{5, 4}, pkt4.Payload)
+}
+
+func TestServerPlayMoveReadersConcurrent(t *testing.T) {
+	s := &Server{
+		RTSPAddress: "localhost:8554",
+		Handler:     &testServerHandler{},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	desc := &description.Session{Medias: []*description.Media{testH264Media}}
+
+	st1 := &ServerStream{
+		Server: s,
+		Desc:   desc,
+	}
+	err = st1.Initialize()
+	require.NoError(t, err)
+	defer st1.Close()
+
+	st2 := &ServerStream{
+		Server: s,
+		Desc:   desc,
+	}
+	err = st2.Initialize()
+	require.NoError(t, err)
+	defer st2.Close()
+
+	// moves in opposite directions must not deadlock
+	var wg sync.WaitGroup
+
+	for _, pair := range [][2]*ServerStream{{st1, st2}, {st2, st1}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				pair[0].MoveReaders(pair[1]) //nolint:errcheck
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestServerPlayTeardownMedia(t *testing.T) {
+	var stream *ServerStream
+	var serverSession *ServerSession
+	teardownMedias := make(chan *description.Media, 2)
+
+	s := &Server{
+		RTSPAddress: "localhost:8554",
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				serverSession = ctx.Session
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onTeardown: func(ctx *ServerHandlerOnTeardownCtx) (*base.Response, error) {
+				require.Equal(t, "/teststream", ctx.Path)
+				teardownMedias <- ctx.Media
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc: &description.Session{Medias: []*description.Media{
+			{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{testH264Media.Formats[0]},
+			},
+			{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{testH264Media.Formats[0]},
+			},
+		}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModePlay),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	session := readSession(t, res)
+
+	inTH.InterleavedIDs = &[2]int{2, 3}
+
+	doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[1]).String(), inTH, session)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	require.Equal(t, stream.Desc.Medias, serverSession.Medias())
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Teardown,
+		URL:    mediaURL(t, desc.BaseURL, desc.Medias[0]),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"1"},
+			"Session": base.HeaderValue{session},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, session, readSession(t, res))
+
+	require.Equal(t, stream.Desc.Medias[0], <-teardownMedias)
+	require.Equal(t, []*description.Media{stream.Desc.Medias[1]}, serverSession.Medias())
+
+	err = stream.WritePacketRTP(stream.Desc.Medias[0], &testRTPPacket)
+	require.NoError(t, err)
+
+	err = stream.WritePacketRTP(stream.Desc.Medias[1], &testRTPPacket)
+	require.NoError(t, err)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 2, f.Channel)
+
+	doTeardown(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	require.Equal(t, (*description.Media)(nil), <-teardownMedias)
+}
+
+func TestServerPlayTryWritePacketRTP(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		RTSPAddress:    "localhost:8554",
+		WriteQueueSize: 1,
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	br := bufio.NewReader(nconn)
+	conn := conn.NewConn(br, nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModePlay),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	session := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	pkt := testRTPPacket
+	pkt.Payload = bytes.Repeat([]byte{1}, 1400)
+
+	// the client is not reading, therefore the queue of the session gets full
+	for {
+		err = stream.TryWritePacketRTP(stream.Desc.Medias[0], &pkt)
+		if err != nil {
+			require.Equal(t, liberrors.ErrServerWouldBlock{}, err)
+			break
+		}
+	}
+
+	for {
+		err = stream.WritePacketRTPWithTimeout(stream.Desc.Medias[0], &pkt, 100*time.Millisecond)
+		if err != nil {
+			require.Equal(t, liberrors.ErrServerWouldBlock{}, err)
+			break
+		}
+	}
+
+	go io.Copy(io.Discard, br) //nolint:errcheck
+
+	err = stream.WritePacketRTPWithTimeout(stream.Desc.Medias[0], &pkt, 5*time.Second)
+	require.NoError(t, err)
+}
+
+func TestServerPlayTransformPacketRTP(t *testing.T) {
+	var stream *ServerStream
+	var firstSession *ServerSession
+	var mutex sync.Mutex
+
+	s := &Server{
+		RTSPAddress: "localhost:8554",
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				if firstSession == nil {
+					firstSession = ctx.Session
+				}
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+		TransformPacketRTP: func(
+			ss *ServerSession,
+			medi *description.Media,
+			forma format.Format,
+			pkt *rtp.Packet,
+		) *rtp.Packet {
+			require.Equal(t, testH264Media, medi)
+			require.Equal(t, testH264Media.Formats[0], forma)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			// rewrite packets of the first reader
+			if ss == firstSession {
+				pkt.Payload = []byte{1, 2}
+				return pkt
+			}
+
+			// discard odd packets of the other readers
+			if (pkt.SequenceNumber % 2) != 0 {
+				return nil
+			}
+			return pkt
+		},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	recv1 := make(chan *rtp.Packet, 4)
+
+	c1 := Client{
+		Protocol: ptrOf(ProtocolTCP),
+	}
+	err = readAll(&c1, "rtsp://localhost:8554/teststream", func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+		recv1 <- pkt
+	})
+	require.NoError(t, err)
+	defer c1.Close()
+
+	recv2 := make(chan *rtp.Packet, 4)
+
+	c2 := Client{
+		Protocol: ptrOf(ProtocolTCP),
+	}
+	err = readAll(&c2, "rtsp://localhost:8554/teststream", func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+		recv2 <- pkt
+	})
+	require.NoError(t, err)
+	defer c2.Close()
+
+	for i := range uint16(4) {
+		pkt := testRTPPacket
+		pkt.SequenceNumber = 1000 + i
+		err = stream.WritePacketRTP(stream.Desc.Medias[0], &pkt)
+		require.NoError(t, err)
+
+		// the original packet is left untouched
+		require.Equal(t, []byte{5, 2, 3, 4}, pkt.Payload)
+	}
+
+	for range 4 {
+		pkt := <-recv1
+		require.Equal(t, []byte{1, 2}, pkt.Payload)
+	}
+
+	for i := range uint16(2) {
+		pkt := <-recv2
+		require.Equal(t, 1000+i*2, pkt.SequenceNumber)
+		require.Equal(t, []byte{5, 2, 3, 4}, pkt.Payload)
+	}
+}
+
+func TestServerPlayTransformPacketRTPMulticast(t *testing.T) {
+	var stream *ServerStream
+	listenIP := multicastCapableIP(t)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+		},
+		RTSPAddress:       listenIP + ":8554",
+		MulticastIPRange:  "224.1.0.0/16",
+		MulticastRTPPort:  8000,
+		MulticastRTCPPort: 8001,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+		TransformPacketRTP: func(_ *ServerSession, _ *description.Media, _ format.Format, pkt *rtp.Packet) *rtp.Packet {
+			return pkt
+		},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	c := Client{
+		Protocol: ptrOf(ProtocolUDPMulticast),
+	}
+	err = readAll(&c, "rtsp://"+listenIP+":8554/teststream", nil)
+	require.ErrorIs(t, err, liberrors.ErrTransport)
+}
+
+func TestServerPlaySessionStateChange(t *testing.T) {
+	var stream *ServerStream
+	var session *ServerSession
+	var changes []ServerHandlerOnSessionStateChangeCtx
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onSessionStateChange: func(ctx *ServerHandlerOnSessionStateChangeCtx) {
+				session = ctx.Session
+				changes = append(changes, *ctx)
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPause: func(_ *ServerHandlerOnPauseCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Mode:           ptrOf(headers.TransportModePlay),
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	ses := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", ses)
+
+	doPause(t, conn, "rtsp://localhost:8554/teststream", ses)
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Record,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"1"},
+			"Session": base.HeaderValue{ses},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusBadRequest, res.StatusCode)
+
+	require.Len(t, changes, 3)
+
+	require.Equal(t, ServerSessionStateInitial, changes[0].Previous)
+	require.Equal(t, ServerSessionStatePrePlay, changes[0].Current)
+	require.Equal(t, base.Setup, changes[0].Request.Method)
+
+	require.Equal(t, ServerSessionStatePrePlay, changes[1].Previous)
+	require.Equal(t, ServerSessionStatePlay, changes[1].Current)
+	require.Equal(t, base.Play, changes[1].Request.Method)
+
+	require.Equal(t, ServerSessionStatePlay, changes[2].Previous)
+	require.Equal(t, ServerSessionStatePrePlay, changes[2].Current)
+	require.Equal(t, base.Pause, changes[2].Request.Method)
+
+	last := session.LastStateChange()
+	require.Equal(t, ServerSessionStatePrePlay, last.Current)
+	require.Equal(t, base.Pause, last.Request.Method)
+	require.Equal(t, ServerSessionStatePrePlay, session.State())
+}
+
+func TestServerPlayPauseBuffer(t *testing.T) {
+	var stream *ServerStream
+	curTime := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	var curTimeMutex sync.Mutex
+
+	setTime := func(t time.Time) {
+		curTimeMutex.Lock()
+		defer curTimeMutex.Unlock()
+		curTime = t
+	}
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onPause: func(_ *ServerHandlerOnPauseCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress:              "localhost:8554",
+		PauseBufferDuration:      1 * time.Second,
+		DisableRTCPSenderReports: true,
+		timeNow: func() time.Time {
+			curTimeMutex.Lock()
+			defer curTimeMutex.Unlock()
+			return curTime
+		},
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Mode:           ptrOf(headers.TransportModePlay),
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	ses := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", ses)
+
+	writePacket := func(seq uint16, ts uint32, idr bool) {
+		payload := []byte{1, byte(seq)}
+		if idr {
+			payload = []byte{5, byte(seq)}
+		}
+
+		err2 := stream.WritePacketRTP(stream.Desc.Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				Timestamp:      ts,
+			},
+			Payload: payload,
+		})
+		require.NoError(t, err2)
+	}
+
+	readPacket := func() *rtp.Packet {
+		f, err2 := conn.ReadInterleavedFrame()
+		require.NoError(t, err2)
+		require.Equal(t, 0, f.Channel)
+
+		var pkt rtp.Packet
+		err2 = pkt.Unmarshal(f.Payload)
+		require.NoError(t, err2)
+		return &pkt
+	}
+
+	writePacket(100, 10000, true)
+
+	pkt := readPacket()
+	require.Equal(t, uint16(100), pkt.SequenceNumber)
+	require.Equal(t, uint32(10000), pkt.Timestamp)
+
+	doPause(t, conn, "rtsp://localhost:8554/teststream", ses)
+
+	// discarded since the buffer must start with a random access unit
+	setTime(curTime.Add(100 * time.Millisecond))
+	writePacket(101, 19000, false)
+
+	setTime(curTime.Add(100 * time.Millisecond))
+	writePacket(102, 28000, true)
+	writePacket(103, 37000, false)
+
+	// the previous random access unit is older than PauseBufferDuration and it is removed
+	setTime(curTime.Add(2 * time.Second))
+	writePacket(104, 208000, true)
+
+	setTime(curTime.Add(100 * time.Millisecond))
+	writePacket(105, 217000, false)
+
+	res = doPlay(t, conn, "rtsp://localhost:8554/teststream", ses)
+
+	var ri headers.RTPInfo
+	err = ri.Unmarshal(res.Header["RTP-Info"])
+	require.NoError(t, err)
+	require.Equal(t, uint16(101), *ri[0].SequenceNumber)
+	require.Equal(t, uint32(10000), *ri[0].Timestamp)
+
+	// buffered packets are sent with timestamps shifted back by the time elapsed
+	// between the pause and the first buffered random access unit.
+	pkt = readPacket()
+	require.Equal(t, uint16(101), pkt.SequenceNumber)
+	require.Equal(t, uint32(10000), pkt.Timestamp)
+	require.Equal(t, []byte{5, 104}, pkt.Payload)
+
+	pkt = readPacket()
+	require.Equal(t, uint16(102), pkt.SequenceNumber)
+	require.Equal(t, uint32(19000), pkt.Timestamp)
+	require.Equal(t, []byte{1, 105}, pkt.Payload)
+
+	writePacket(106, 226000, false)
+
+	pkt = readPacket()
+	require.Equal(t, uint16(103), pkt.SequenceNumber)
+	require.Equal(t, uint32(28000), pkt.Timestamp)
+}
+
+func TestServerPlayMemoryBudget(t *testing.T) {
+	var stream *ServerStream
+	sessionClosed := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+				require.Equal(t, liberrors.ErrServerMemoryBudgetExceeded{}, ctx.Error)
+				close(sessionClosed)
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress:              "localhost:8554",
+		MaxBufferedBytes:         50,
+		MemoryBudgetPolicy:       MemoryBudgetPolicyCloseSession,
+		DisableRTCPSenderReports: true,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Mode:           ptrOf(headers.TransportModePlay),
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	ses := readSession(t, res)
+
+	doPlay(t, conn, "rtsp://localhost:8554/teststream", ses)
+
+	writePacket := func(payload []byte) {
+		err2 := stream.WritePacketRTP(stream.Desc.Medias[0], &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 100,
+				Timestamp:      10000,
+			},
+			Payload: payload,
+		})
+		require.NoError(t, err2)
+	}
+
+	writePacket([]byte{5, 1})
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 0, f.Channel)
+
+	writePacket(bytes.Repeat([]byte{1}, 100))
+
+	<-sessionClosed
+
+	require.Equal(t, &ServerMemoryStats{
+		BufferedBytes:     0,
+		PeakBufferedBytes: 14,
+		RejectedBytes:     112,
+		ClosedSessions:    1,
+	}, s.MemoryStats())
+}
+
+func TestServerPlayRateControl(t *testing.T) {
+	var stream *ServerStream
+	var session *ServerSession
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				require.False(t, ctx.Session.RateControl())
+				session = ctx.Session
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress:              "localhost:8554",
+		DisableRTCPSenderReports: true,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Mode:           ptrOf(headers.TransportModePlay),
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	ses := readSession(t, res)
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: base.Play,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":         base.HeaderValue{"5"},
+			"Session":      base.HeaderValue{ses},
+			"Require":      base.HeaderValue{"onvif-replay"},
+			"Rate-Control": base.HeaderValue{"no"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, base.HeaderValue{"no"}, res.Header["Rate-Control"])
+
+	ntpTime := time.Date(2018, 5, 20, 8, 17, 15, 0, time.UTC)
+
+	err = session.WritePacketRTPWithReplayExtension(stream.Desc.Medias[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 100,
+			Timestamp:      10000,
+		},
+		Payload: []byte{5, 1},
+	}, onvif.ReplayExtension{
+		NTP:        ntpTime,
+		CleanPoint: true,
+	})
+	require.NoError(t, err)
+
+	f, err := conn.ReadInterleavedFrame()
+	require.NoError(t, err)
+	require.Equal(t, 0, f.Channel)
+
+	var pkt rtp.Packet
+	err = pkt.Unmarshal(f.Payload)
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 1}, pkt.Payload)
+
+	var ext onvif.ReplayExtension
+	err = ext.Unmarshal(&pkt.Header)
+	require.NoError(t, err)
+	ext.NTP = ext.NTP.UTC()
+	require.Equal(t, onvif.ReplayExtension{
+		NTP:        ntpTime,
+		CleanPoint: true,
+		CSeq:       5,
+	}, ext)
+}
@@ -0,0 +1,68 @@
+package gortsplib
+
+import (
+	"context"
+
+	"golang.org/x/net/quic"
+)
+
+type serverQUICListener struct {
+	s *Server
+
+	ep *quic.Endpoint
+}
+
+func (sl *serverQUICListener) initialize() error {
+	network, address := restrictNetwork("udp", sl.s.QUICAddress)
+
+	var err error
+	sl.ep, err = quic.Listen(network, address, &quic.Config{
+		TLSConfig: quicTLSConfig(sl.s.tlsConfig()),
+	})
+	if err != nil {
+		return err
+	}
+
+	sl.s.wg.Add(1)
+	go sl.run()
+
+	return nil
+}
+
+func (sl *serverQUICListener) close() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), quicCloseTimeout)
+	defer ctxCancel()
+	sl.ep.Close(ctx) //nolint:errcheck
+}
+
+func (sl *serverQUICListener) run() {
+	defer sl.s.wg.Done()
+
+	for {
+		qconn, err := sl.ep.Accept(sl.s.ctx)
+		if err != nil {
+			sl.s.acceptErr(err)
+			return
+		}
+
+		sl.s.wg.Add(1)
+		go sl.handleConn(qconn)
+	}
+}
+
+// handleConn waits for the control stream, that is opened by the client
+// together with the first request.
+func (sl *serverQUICListener) handleConn(qconn *quic.Conn) {
+	defer sl.s.wg.Done()
+
+	ctx, ctxCancel := context.WithTimeout(sl.s.ctx, sl.s.readTimeout())
+	defer ctxCancel()
+
+	control, err := qconn.AcceptStream(ctx)
+	if err != nil {
+		qconn.Abort(nil)
+		return
+	}
+
+	sl.s.newConn(newQUICFramePerStreamConn(qconn, control, nil))
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/tls"
 	"net"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -539,6 +540,108 @@ func TestServerRecordErrorRecordPartialMedias(t *testing.T) {
 	require.EqualError(t, err, "not all announced medias have been setup")
 }
 
+func TestServerRecordRejectMedias(t *testing.T) {
+	for _, ca := range []string{"partial", "all"} {
+		t.Run(ca, func(t *testing.T) {
+			var accepted []*description.Media
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						if ca == "all" {
+							ctx.RejectMedia(ctx.Description.Medias[0], ServerMediaRejectReasonUnsupportedCodec)
+						}
+						ctx.RejectMedia(ctx.Description.Medias[1], ServerMediaRejectReasonTooManyMedias)
+						accepted = ctx.AcceptedMedias()
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(_ *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress: "localhost:8554",
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+			medias := []*description.Media{
+				{
+					Type:    description.MediaTypeVideo,
+					Formats: []format.Format{testH264Media.Formats[0]},
+				},
+				{
+					Type: description.MediaTypeAudio,
+					Formats: []format.Format{&format.G711{
+						PayloadTyp:   8,
+						MULaw:        false,
+						SampleRate:   8000,
+						ChannelCount: 1,
+					}},
+				},
+			}
+
+			res, err := writeReqReadRes(conn, base.Request{
+				Method: base.Announce,
+				URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+				Header: base.Header{
+					"CSeq":         base.HeaderValue{"1"},
+					"Content-Type": base.HeaderValue{"application/sdp"},
+				},
+				Body: mediasToSDP(medias),
+			})
+			require.NoError(t, err)
+
+			if ca == "all" {
+				require.Equal(t, base.StatusUnsupportedMediaType, res.StatusCode)
+				require.Empty(t, accepted)
+				return
+			}
+
+			require.Equal(t, base.StatusOK, res.StatusCode)
+			require.Len(t, accepted, 1)
+
+			var ssd sdp.SessionDescription
+			err = ssd.Unmarshal(res.Body)
+			require.NoError(t, err)
+
+			var desc description.Session
+			err = desc.Unmarshal(&ssd)
+			require.NoError(t, err)
+			require.Len(t, desc.Medias, 1)
+			require.Equal(t, description.MediaTypeVideo, desc.Medias[0].Type)
+
+			inTH := &headers.Transport{
+				Protocol:       headers.TransportProtocolTCP,
+				Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+				Mode:           ptrOf(headers.TransportModeRecord),
+				InterleavedIDs: &[2]int{0, 1},
+			}
+
+			// only accepted medias need to be setupped
+			res, _ = doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medias[0].Control, inTH, "")
+
+			doRecord(t, conn, "rtsp://localhost:8554/teststream", readSession(t, res))
+		})
+	}
+}
+
 func TestServerRecord(t *testing.T) {
 	for _, ca := range []struct {
 		scheme    string
@@ -1609,6 +1712,201 @@ func TestServerRecordDecodeErrors(t *testing.T) {
 	}
 }
 
+type testServerHandlerUnknownPacket struct {
+	testServerHandler
+	onUnknownPacket func(*ServerHandlerOnUnknownPacketCtx)
+}
+
+func (sh *testServerHandlerUnknownPacket) OnUnknownPacket(ctx *ServerHandlerOnUnknownPacketCtx) {
+	sh.onUnknownPacket(ctx)
+}
+
+func TestServerRecordUnknownPacket(t *testing.T) {
+	packetRecv := make(chan struct{})
+
+	var session *ServerSession
+
+	s := &Server{
+		Handler: &testServerHandlerUnknownPacket{
+			testServerHandler: testServerHandler{
+				onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil
+				},
+				onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil, nil
+				},
+				onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+					session = ctx.Session
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil
+				},
+				onDecodeError: func(ctx *ServerHandlerOnDecodeErrorCtx) {
+					t.Errorf("unexpected decode error: %v", ctx.Error)
+				},
+			},
+			onUnknownPacket: func(ctx *ServerHandlerOnUnknownPacketCtx) {
+				require.Equal(t, uint8(111), ctx.Packet.PayloadType)
+				require.Equal(t, []byte{1, 2, 3, 4}, ctx.Packet.Payload)
+				require.Equal(t, description.MediaTypeApplication, ctx.Media.Type)
+				close(packetRecv)
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	medias := []*description.Media{{
+		Type: description.MediaTypeApplication,
+		Formats: []format.Format{&format.Generic{
+			PayloadTyp: 97,
+			RTPMa:      "private/90000",
+		}},
+	}}
+
+	doAnnounce(t, conn, "rtsp://localhost:8554/teststream", medias)
+
+	inTH := &headers.Transport{
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModeRecord),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medias[0].Control, inTH, "")
+
+	doRecord(t, conn, "rtsp://localhost:8554/teststream", readSession(t, res))
+
+	err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+		Channel: 0,
+		Payload: mustMarshalPacketRTP(&rtp.Packet{
+			Header: rtp.Header{
+				Version:     2,
+				PayloadType: 111,
+			},
+			Payload: []byte{1, 2, 3, 4},
+		}),
+	}, make([]byte, 1024))
+	require.NoError(t, err)
+
+	<-packetRecv
+
+	st := session.Stats()
+	require.Equal(t, uint64(1), st.RTPPacketsUnknownPayloadType)
+	require.Equal(t, uint64(0), st.RTPPacketsInError)
+}
+
+type testServerHandlerSSRCChange struct {
+	testServerHandler
+	onSSRCChange func(*ServerHandlerOnSSRCChangeCtx)
+}
+
+func (sh *testServerHandlerSSRCChange) OnSSRCChange(ctx *ServerHandlerOnSSRCChangeCtx) {
+	sh.onSSRCChange(ctx)
+}
+
+func TestServerRecordSSRCChange(t *testing.T) {
+	changeRecv := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandlerSSRCChange{
+			testServerHandler: testServerHandler{
+				onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil
+				},
+				onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil, nil
+				},
+				onRecord: func(_ *ServerHandlerOnRecordCtx) (*base.Response, error) {
+					return &base.Response{
+						StatusCode: base.StatusOK,
+					}, nil
+				},
+				onDecodeError: func(ctx *ServerHandlerOnDecodeErrorCtx) {
+					t.Errorf("unexpected decode error: %v", ctx.Error)
+				},
+			},
+			onSSRCChange: func(ctx *ServerHandlerOnSSRCChangeCtx) {
+				require.Equal(t, uint32(1000), ctx.Prev)
+				require.Equal(t, uint32(2000), ctx.Cur)
+				require.Equal(t, description.MediaTypeApplication, ctx.Media.Type)
+
+				// the callback is allowed to query the session
+				st := ctx.Session.Stats()
+				require.Equal(t, uint32(2000), st.Medias[ctx.Media].Formats[ctx.Format].RemoteSSRC)
+
+				close(changeRecv)
+			},
+		},
+		RTSPAddress:     "localhost:8554",
+		AllowSSRCChange: true,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	medias := []*description.Media{{
+		Type: description.MediaTypeApplication,
+		Formats: []format.Format{&format.Generic{
+			PayloadTyp: 97,
+			RTPMa:      "private/90000",
+		}},
+	}}
+
+	doAnnounce(t, conn, "rtsp://localhost:8554/teststream", medias)
+
+	inTH := &headers.Transport{
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModeRecord),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medias[0].Control, inTH, "")
+
+	doRecord(t, conn, "rtsp://localhost:8554/teststream", readSession(t, res))
+
+	for _, ssrc := range []uint32{1000, 2000} {
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: mustMarshalPacketRTP(&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    97,
+					SequenceNumber: 100,
+					SSRC:           ssrc,
+				},
+				Payload: []byte{1, 2, 3, 4},
+			}),
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+	}
+
+	<-changeRecv
+}
+
 func TestServerRecordPacketNTP(t *testing.T) {
 	recv := make(chan struct{})
 	first := false
@@ -1804,3 +2102,126 @@ func TestServerRecordPausePause(t *testing.T) {
 
 	doPause(t, conn, "rtsp://localhost:8554/teststream", session)
 }
+
+func TestServerRecordPacketProcessing(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		mode PacketProcessing
+	}{
+		{"single", PacketProcessingSingle},
+		{"per media", PacketProcessingPerMedia},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			received := make(map[int][]uint16)
+			var order []int
+			done := make(chan struct{})
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil, nil
+					},
+					onRecord: func(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+						ctx.Session.OnPacketRTPAny(func(medi *description.Media, _ format.Format, pkt *rtp.Packet) {
+							i, _ := strconv.Atoi(medi.Control[len("trackID="):])
+
+							mutex.Lock()
+							defer mutex.Unlock()
+
+							received[i] = append(received[i], pkt.SequenceNumber)
+							order = append(order, i)
+
+							if len(order) == 20 {
+								close(done)
+							}
+						})
+
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, nil
+					},
+				},
+				RTSPAddress:      "localhost:8554",
+				PacketProcessing: ca.mode,
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+			medias := []*description.Media{
+				testH264Media,
+				{
+					Type:    description.MediaTypeAudio,
+					Formats: []format.Format{&format.Opus{PayloadTyp: 97, ChannelCount: 2}},
+				},
+			}
+
+			doAnnounce(t, conn, "rtsp://localhost:8554/teststream", medias)
+
+			var session string
+
+			for i, medi := range medias {
+				inTH := &headers.Transport{
+					Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+					Mode:           ptrOf(headers.TransportModeRecord),
+					Protocol:       headers.TransportProtocolTCP,
+					InterleavedIDs: &[2]int{i * 2, i*2 + 1},
+				}
+
+				res, _ := doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medi.Control, inTH, session)
+				session = readSession(t, res)
+			}
+
+			doRecord(t, conn, "rtsp://localhost:8554/teststream", session)
+
+			for n := range 10 {
+				for i := range medias {
+					err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+						Channel: i * 2,
+						Payload: mustMarshalPacketRTP(&rtp.Packet{
+							Header: rtp.Header{
+								Version:        2,
+								Marker:         true,
+								PayloadType:    medias[i].Formats[0].PayloadType(),
+								SequenceNumber: uint16(n),
+								SSRC:           uint32(i),
+							},
+							Payload: []byte{1, 2, 3, 4},
+						}),
+					}, make([]byte, 1024))
+					require.NoError(t, err)
+				}
+			}
+
+			<-done
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			for i := range medias {
+				require.Equal(t, []uint16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, received[i])
+			}
+
+			// with a single routine, packets are processed in the order of arrival.
+			if ca.mode == PacketProcessingSingle {
+				for n, i := range order {
+					require.Equal(t, n%2, i)
+				}
+			}
+		})
+	}
+}
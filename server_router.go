@@ -0,0 +1,414 @@
+package gortsplib
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+)
+
+type serverRouteSegmentType int
+
+const (
+	serverRouteSegmentTypeLiteral serverRouteSegmentType = iota
+	serverRouteSegmentTypeParam
+	serverRouteSegmentTypeWildcard
+	serverRouteSegmentTypeRemaining
+)
+
+type serverRouteSegment struct {
+	typ  serverRouteSegmentType
+	name string
+}
+
+// ServerRoute is a route of ServerRouter.
+type ServerRoute struct {
+	// host name that requests must be addressed to.
+	// The host name is taken from TLS SNI, or from the request URL when SNI is not available.
+	// It can start with "*." in order to match all subdomains.
+	// It defaults to any host name.
+	Host string
+
+	// path pattern, for instance "/cam/{id}/stream".
+	// A segment can be:
+	// - a literal, that must match the request exactly;
+	// - "{name}", that matches a single segment and stores it in PathParams;
+	// - "*", that matches a single segment without storing it;
+	// - "{name...}", that matches all remaining segments and stores them in PathParams.
+	//   It can only be used as last segment.
+	Pattern string
+
+	// handler of requests whose path matches Pattern.
+	// It can implement ServerHandlerOnDescribe, ServerHandlerOnAnnounce, ServerHandlerOnSetup,
+	// ServerHandlerOnPlay, ServerHandlerOnRecord, ServerHandlerOnPause, ServerHandlerOnTeardown,
+	// ServerHandlerOnGetParameter, ServerHandlerOnSetParameter and ServerHandlerOnCustomRequest.
+	// Responses to OPTIONS requests advertise the methods supported by the handler.
+	Handler ServerHandler
+
+	segments []serverRouteSegment
+}
+
+func (r *ServerRoute) initialize() error {
+	if !strings.HasPrefix(r.Pattern, "/") {
+		return fmt.Errorf("pattern '%s' does not start with a slash", r.Pattern)
+	}
+
+	if r.Handler == nil {
+		return fmt.Errorf("handler of pattern '%s' not provided", r.Pattern)
+	}
+
+	parts := strings.Split(r.Pattern[1:], "/")
+	r.segments = make([]serverRouteSegment, len(parts))
+	names := make(map[string]struct{})
+
+	for i, part := range parts {
+		switch {
+		case part == "*":
+			r.segments[i] = serverRouteSegment{typ: serverRouteSegmentTypeWildcard}
+
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name := part[1 : len(part)-1]
+			typ := serverRouteSegmentTypeParam
+
+			if strings.HasSuffix(name, "...") {
+				if i != len(parts)-1 {
+					return fmt.Errorf("pattern '%s' contains '%s' before the last segment", r.Pattern, part)
+				}
+				name = name[:len(name)-3]
+				typ = serverRouteSegmentTypeRemaining
+			}
+
+			if name == "" {
+				return fmt.Errorf("pattern '%s' contains an unnamed variable", r.Pattern)
+			}
+
+			if _, ok := names[name]; ok {
+				return fmt.Errorf("pattern '%s' contains variable '%s' multiple times", r.Pattern, name)
+			}
+			names[name] = struct{}{}
+
+			r.segments[i] = serverRouteSegment{typ: typ, name: name}
+
+		default:
+			if strings.ContainsAny(part, "{}") {
+				return fmt.Errorf("pattern '%s' contains an invalid segment: '%s'", r.Pattern, part)
+			}
+			r.segments[i] = serverRouteSegment{typ: serverRouteSegmentTypeLiteral, name: part}
+		}
+	}
+
+	return nil
+}
+
+func (r *ServerRoute) matchHost(host string) bool {
+	if r.Host == "" {
+		return true
+	}
+
+	if strings.HasPrefix(r.Host, "*.") {
+		return strings.HasSuffix(strings.ToLower(host), strings.ToLower(r.Host[1:]))
+	}
+
+	return strings.EqualFold(host, r.Host)
+}
+
+func (r *ServerRoute) match(host string, path string) (map[string]string, bool) {
+	if !r.matchHost(host) {
+		return nil, false
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+
+	parts := strings.Split(path[1:], "/")
+	params := make(map[string]string)
+
+	for i, seg := range r.segments {
+		if seg.typ == serverRouteSegmentTypeRemaining {
+			if i >= len(parts) {
+				return nil, false
+			}
+			params[seg.name] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+
+		if i >= len(parts) {
+			return nil, false
+		}
+
+		switch seg.typ {
+		case serverRouteSegmentTypeLiteral:
+			if parts[i] != seg.name {
+				return nil, false
+			}
+
+		case serverRouteSegmentTypeParam:
+			if parts[i] == "" {
+				return nil, false
+			}
+			params[seg.name] = parts[i]
+
+		case serverRouteSegmentTypeWildcard:
+			if parts[i] == "" {
+				return nil, false
+			}
+		}
+	}
+
+	if len(parts) != len(r.segments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// ServerRouter is a ServerHandler that dispatches requests to other handlers,
+// depending on the request host and path.
+// Variables extracted from the path are stored into the PathParams field of handler contexts.
+//
+// Routes are evaluated in order and the first matching one is used.
+// Requests that do not match any route are answered with 404 Not Found.
+//
+// In order to receive events that are not bound to a path (OnConnOpen, OnSessionClose, ...),
+// ServerRouter can be embedded into another handler.
+type ServerRouter struct {
+	// routes.
+	Routes []*ServerRoute
+}
+
+// Initialize initializes ServerRouter.
+// It must be called before Server.Start().
+func (r *ServerRouter) Initialize() error {
+	for _, route := range r.Routes {
+		err := route.initialize()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func requestHost(sc *ServerConn, req *base.Request) string {
+	if sc != nil {
+		if name := sc.ServerName(); name != "" {
+			return name
+		}
+	}
+
+	if req != nil && req.URL != nil {
+		return req.URL.Hostname()
+	}
+
+	return ""
+}
+
+func (r *ServerRouter) find(sc *ServerConn, req *base.Request, path string) (ServerHandler, map[string]string) {
+	host := requestHost(sc, req)
+
+	for _, route := range r.Routes {
+		if params, ok := route.match(host, path); ok {
+			return route.Handler, params
+		}
+	}
+	return nil, nil
+}
+
+// OnDescribe implements ServerHandlerOnDescribe.
+func (r *ServerRouter) OnDescribe(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnDescribe); ok {
+		ctx.PathParams = params
+		return h.OnDescribe(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil, nil
+}
+
+// OnAnnounce implements ServerHandlerOnAnnounce.
+func (r *ServerRouter) OnAnnounce(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnAnnounce); ok {
+		ctx.PathParams = params
+		return h.OnAnnounce(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil
+}
+
+// OnSetup implements ServerHandlerOnSetup.
+func (r *ServerRouter) OnSetup(ctx *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnSetup); ok {
+		ctx.PathParams = params
+		return h.OnSetup(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil, nil
+}
+
+// OnPlay implements ServerHandlerOnPlay.
+func (r *ServerRouter) OnPlay(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnPlay); ok {
+		ctx.PathParams = params
+		return h.OnPlay(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil
+}
+
+// OnRecord implements ServerHandlerOnRecord.
+func (r *ServerRouter) OnRecord(ctx *ServerHandlerOnRecordCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnRecord); ok {
+		ctx.PathParams = params
+		return h.OnRecord(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil
+}
+
+// OnPause implements ServerHandlerOnPause.
+func (r *ServerRouter) OnPause(ctx *ServerHandlerOnPauseCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnPause); ok {
+		ctx.PathParams = params
+		return h.OnPause(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil
+}
+
+// OnTeardown implements ServerHandlerOnTeardown.
+func (r *ServerRouter) OnTeardown(ctx *ServerHandlerOnTeardownCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h != nil {
+		if h, ok := h.(ServerHandlerOnTeardown); ok {
+			ctx.PathParams = params
+			return h.OnTeardown(ctx)
+		}
+	}
+
+	// sessions can always be torn down
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+// OnGetParameter implements ServerHandlerOnGetParameter.
+func (r *ServerRouter) OnGetParameter(ctx *ServerHandlerOnGetParameterCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h != nil {
+		if h, ok := h.(ServerHandlerOnGetParameter); ok {
+			ctx.PathParams = params
+			return h.OnGetParameter(ctx)
+		}
+	}
+
+	// GET_PARAMETER is used like a ping when reading, and sometimes
+	// also when publishing; reply with 200
+	if ctx.Session != nil {
+		return &base.Response{
+			StatusCode: base.StatusOK,
+			Header: base.Header{
+				"Content-Type": base.HeaderValue{"text/parameters"},
+			},
+			Body: []byte{},
+		}, nil
+	}
+
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil
+}
+
+// OnSetParameter implements ServerHandlerOnSetParameter.
+func (r *ServerRouter) OnSetParameter(ctx *ServerHandlerOnSetParameterCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnSetParameter); ok {
+		ctx.PathParams = params
+		return h.OnSetParameter(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil
+}
+
+// CustomMethods implements ServerHandlerOnCustomRequest.
+func (r *ServerRouter) CustomMethods() []base.Method {
+	var methods []base.Method
+
+	for _, route := range r.Routes {
+		if h, ok := route.Handler.(ServerHandlerOnCustomRequest); ok {
+			for _, method := range h.CustomMethods() {
+				if !slices.Contains(methods, method) {
+					methods = append(methods, method)
+				}
+			}
+		}
+	}
+
+	return methods
+}
+
+// OnCustomRequest implements ServerHandlerOnCustomRequest.
+func (r *ServerRouter) OnCustomRequest(ctx *ServerHandlerOnCustomRequestCtx) (*base.Response, error) {
+	h, params := r.find(ctx.Conn, ctx.Request, ctx.Path)
+	if h == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+
+	if h, ok := h.(ServerHandlerOnCustomRequest); ok && slices.Contains(h.CustomMethods(), ctx.Request.Method) {
+		ctx.PathParams = params
+		return h.OnCustomRequest(ctx)
+	}
+
+	return &base.Response{StatusCode: base.StatusNotImplemented}, nil
+}
+
+// supportedMethods returns the methods supported by the handler of the route
+// that matches the request, or by all handlers when no route matches.
+func (r *ServerRouter) supportedMethods(sc *ServerConn, req *base.Request, path string) []base.Method {
+	if req.URL != nil {
+		if h, _ := r.find(sc, req, path); h != nil {
+			return serverHandlerMethods(h)
+		}
+	}
+
+	handlers := make([]ServerHandler, len(r.Routes))
+	for i, route := range r.Routes {
+		handlers[i] = route.Handler
+	}
+
+	return serverHandlerMethods(handlers...)
+}
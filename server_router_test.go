@@ -0,0 +1,309 @@
+package gortsplib
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/conn"
+)
+
+func TestServerRouteMatch(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		pattern string
+		path    string
+		params  map[string]string
+	}{
+		{
+			"literal",
+			"/mystream",
+			"/mystream",
+			map[string]string{},
+		},
+		{
+			"literal mismatch",
+			"/mystream",
+			"/otherstream",
+			nil,
+		},
+		{
+			"variable",
+			"/cam/{id}/stream",
+			"/cam/12/stream",
+			map[string]string{"id": "12"},
+		},
+		{
+			"variable too many segments",
+			"/cam/{id}/stream",
+			"/cam/12/stream/other",
+			nil,
+		},
+		{
+			"variable empty",
+			"/cam/{id}/stream",
+			"/cam//stream",
+			nil,
+		},
+		{
+			"wildcard",
+			"/cam/*/{channel}",
+			"/cam/12/main",
+			map[string]string{"channel": "main"},
+		},
+		{
+			"remaining",
+			"/vod/{file...}",
+			"/vod/dir/sub/file.mp4",
+			map[string]string{"file": "dir/sub/file.mp4"},
+		},
+		{
+			"remaining missing",
+			"/vod/{file...}",
+			"/vod",
+			nil,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			r := &ServerRoute{
+				Pattern: ca.pattern,
+				Handler: &testServerHandler{},
+			}
+			err := r.initialize()
+			require.NoError(t, err)
+
+			params, ok := r.match("localhost", ca.path)
+			require.Equal(t, ca.params != nil, ok)
+			require.Equal(t, ca.params, params)
+		})
+	}
+}
+
+func TestServerRouteErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		pattern string
+		err     string
+	}{
+		{
+			"no leading slash",
+			"cam/{id}",
+			"pattern 'cam/{id}' does not start with a slash",
+		},
+		{
+			"remaining not last",
+			"/cam/{rest...}/stream",
+			"pattern '/cam/{rest...}/stream' contains '{rest...}' before the last segment",
+		},
+		{
+			"unnamed",
+			"/cam/{}",
+			"pattern '/cam/{}' contains an unnamed variable",
+		},
+		{
+			"duplicate",
+			"/cam/{id}/{id}",
+			"pattern '/cam/{id}/{id}' contains variable 'id' multiple times",
+		},
+		{
+			"invalid segment",
+			"/cam/a{id}",
+			"pattern '/cam/a{id}' contains an invalid segment: 'a{id}'",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			r := &ServerRouter{
+				Routes: []*ServerRoute{{
+					Pattern: ca.pattern,
+					Handler: &testServerHandler{},
+				}},
+			}
+			err := r.Initialize()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+func TestServerRouter(t *testing.T) {
+	var params map[string]string
+
+	router := &ServerRouter{
+		Routes: []*ServerRoute{
+			{
+				Pattern: "/cam/{id}/{stream}",
+				Handler: &testServerHandler{
+					onDescribe: func(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						params = ctx.PathParams
+						return &base.Response{
+							StatusCode: base.StatusOK,
+							Body:       []byte("v=0\r\n"),
+						}, nil, nil
+					},
+				},
+			},
+			{
+				Pattern: "/nodescribe",
+				Handler: struct{}{},
+			},
+		},
+	}
+	err := router.Initialize()
+	require.NoError(t, err)
+
+	s := &Server{
+		Handler:     router,
+		RTSPAddress: "localhost:8554",
+	}
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	for i, ca := range []struct {
+		path   string
+		status base.StatusCode
+	}{
+		{"/cam/12/main", base.StatusOK},
+		{"/cam/12", base.StatusNotFound},
+		{"/nodescribe", base.StatusNotImplemented},
+	} {
+		var res *base.Response
+		res, err = writeReqReadRes(conn, base.Request{
+			Method: base.Describe,
+			URL:    mustParseURL("rtsp://localhost:8554" + ca.path),
+			Header: base.Header{
+				"CSeq": base.HeaderValue{string(rune('1' + i))},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, ca.status, res.StatusCode)
+	}
+
+	require.Equal(t, map[string]string{"id": "12", "stream": "main"}, params)
+
+	for i, ca := range []struct {
+		url    string
+		public string
+	}{
+		{"rtsp://localhost:8554/nodescribe", "GET_PARAMETER, TEARDOWN"},
+		{"", "DESCRIBE, ANNOUNCE, SETUP, PLAY, RECORD, PAUSE, GET_PARAMETER, SET_PARAMETER, TEARDOWN"},
+	} {
+		req := base.Request{
+			Method: base.Options,
+			Header: base.Header{
+				"CSeq": base.HeaderValue{string(rune('4' + i))},
+			},
+		}
+		if ca.url != "" {
+			req.URL = mustParseURL(ca.url)
+		}
+
+		var res *base.Response
+		res, err = writeReqReadRes(conn, req)
+		require.NoError(t, err)
+		require.Equal(t, base.StatusOK, res.StatusCode)
+		require.Equal(t, base.HeaderValue{ca.public}, res.Header["Public"])
+	}
+}
+
+func TestServerRouterVirtualHosts(t *testing.T) {
+	for _, ca := range []string{"url", "sni"} {
+		t.Run(ca, func(t *testing.T) {
+			newHandler := func(name string) ServerHandler {
+				return &testServerHandler{
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+							Body:       []byte(name),
+						}, nil, nil
+					},
+				}
+			}
+
+			router := &ServerRouter{
+				Routes: []*ServerRoute{
+					{
+						Host:    "first.example.com",
+						Pattern: "/{path...}",
+						Handler: newHandler("first"),
+					},
+					{
+						Host:    "*.tenant.example.com",
+						Pattern: "/{path...}",
+						Handler: newHandler("tenant"),
+					},
+				},
+			}
+			err := router.Initialize()
+			require.NoError(t, err)
+
+			s := &Server{
+				Handler:     router,
+				RTSPAddress: "localhost:8554",
+			}
+
+			if ca == "sni" {
+				var cert tls.Certificate
+				cert, err = tls.X509KeyPair(serverCert, serverKey)
+				require.NoError(t, err)
+				s.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+			}
+
+			err = s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			for _, host := range []string{"first.example.com", "cam1.tenant.example.com", "other.example.com"} {
+				func() {
+					var nconn net.Conn
+					nconn, err = net.Dial("tcp", "localhost:8554")
+					require.NoError(t, err)
+					defer nconn.Close()
+
+					u := "rtsp://" + host + ":8554/mystream"
+
+					if ca == "sni" {
+						nconn = tls.Client(nconn, &tls.Config{
+							ServerName:         host,
+							InsecureSkipVerify: true,
+						})
+						u = "rtsps://localhost:8554/mystream"
+					}
+
+					conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+					var res *base.Response
+					res, err = writeReqReadRes(conn, base.Request{
+						Method: base.Describe,
+						URL:    mustParseURL(u),
+						Header: base.Header{
+							"CSeq": base.HeaderValue{"1"},
+						},
+					})
+					require.NoError(t, err)
+
+					switch host {
+					case "first.example.com":
+						require.Equal(t, base.StatusOK, res.StatusCode)
+						require.Equal(t, []byte("first"), res.Body)
+
+					case "cam1.tenant.example.com":
+						require.Equal(t, base.StatusOK, res.StatusCode)
+						require.Equal(t, []byte("tenant"), res.Body)
+
+					default:
+						require.Equal(t, base.StatusNotFound, res.StatusCode)
+					}
+				}()
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log"
+	"maps"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,10 +25,12 @@ import (
 	"github.com/bluenviron/gortsplib/v5/pkg/headers"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v5/pkg/mikey"
+	"github.com/bluenviron/gortsplib/v5/pkg/onvif"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpreceiver"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpsender"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtptime"
 	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
+	"github.com/bluenviron/gortsplib/v5/pkg/trackmetrics"
 )
 
 type readFunc func([]byte) bool
@@ -182,6 +186,43 @@ func findMediaByTrackID(medias []*description.Media, trackID string) *descriptio
 	return medias[id]
 }
 
+// findTeardownMedia returns the media a TEARDOWN request is addressed to,
+// or nil if the request is addressed to the whole session.
+func (ss *ServerSession) findTeardownMedia(u *base.URL) (*description.Media, string, string) {
+	// a session with a single media is always torn down entirely
+	if len(ss.setuppedMedias) > 1 {
+		var medi *description.Media
+		var path string
+		var query string
+
+		switch ss.state {
+		case ServerSessionStatePrePlay, ServerSessionStatePlay:
+			if stringsReverseIndex(u.Path, "/trackID=") >= 0 || stringsReverseIndex(u.RawQuery, "/trackID=") >= 0 {
+				var trackID string
+				var err error
+				path, query, trackID, err = getPathAndQueryAndTrackID(u)
+				if err == nil && path == ss.setuppedPath {
+					medi = findMediaByTrackID(ss.Stream().Desc.Medias, trackID)
+				}
+			}
+
+		case ServerSessionStatePreRecord, ServerSessionStateRecord:
+			path = ss.setuppedPath
+			query = ss.setuppedQuery
+			medi = findMediaByURL(ss.announcedDesc.Medias, path, query, u)
+		}
+
+		if medi != nil {
+			if _, ok := ss.setuppedMedias[medi]; ok {
+				return medi, path, query
+			}
+		}
+	}
+
+	path, query := getPathAndQuery(u, false)
+	return nil, path, query
+}
+
 func isTransportSupported(sc *ServerConn, tr *headers.Transport) bool {
 	if tr.Protocol == headers.TransportProtocolUDP {
 		// prevent using UDP/UDP-multicast when listeners are disabled
@@ -199,13 +240,13 @@ func isTransportSupported(sc *ServerConn, tr *headers.Transport) bool {
 		}
 
 		// prevent using unsecure UDP with RTSPS
-		if !isSecure(tr.Profile) && sc.s.TLSConfig != nil {
+		if !isSecure(tr.Profile) && sc.s.tlsConfig() != nil {
 			return false
 		}
 	}
 
 	// prevent using secure profiles with plain RTSP, since keys are in plain
-	if isSecure(tr.Profile) && sc.s.TLSConfig == nil {
+	if isSecure(tr.Profile) && sc.s.tlsConfig() == nil {
 		return false
 	}
 
@@ -266,11 +307,20 @@ func generateRTPInfo(
 ) (headers.RTPInfo, bool) {
 	var ri headers.RTPInfo
 
+	// restampers are set while holding the lock of the stream
+	stream.mutex.RLock()
+	defer stream.mutex.RUnlock()
+
 	for _, sm := range mediasOrdered {
 		ssm := stream.medias[sm.media]
 		entry := generateRTPInfoEntry(ssm, now)
 		if entry == nil {
 			entry = &headers.RTPInfoEntry{}
+		} else if sf := sm.formats[sm.media.Formats[0].PayloadType()]; sf.restamper != nil {
+			// sequence numbers and timestamps of the stream are rewritten
+			entry = &headers.RTPInfoEntry{}
+		} else if sf.pauseBuffer != nil {
+			sf.pauseBuffer.adjustRTPInfoEntry(entry)
 		}
 
 		entry.URL = (&base.URL{
@@ -319,20 +369,43 @@ func (s ServerSessionState) String() string {
 	return "unknown"
 }
 
+// ServerSessionStateChange is a state change of a ServerSession.
+type ServerSessionStateChange struct {
+	// state before the change.
+	Previous ServerSessionState
+	// state after the change.
+	Current ServerSessionState
+	// request that caused the change.
+	Request *base.Request
+	// time of the change.
+	Time time.Time
+}
+
 // ServerSession is a server-side RTSP session.
 type ServerSession struct {
 	s      *Server
 	author *ServerConn
 
 	secretID              string // must not be shared, allows to take ownership of the session
+	auditNumber           uint64
 	ctx                   context.Context
 	ctxCancel             func()
 	propsMutex            sync.RWMutex
 	conns                 map[*ServerConn]struct{}
+	closedConns           []*ServerConn
+	userDataMutex         sync.RWMutex
 	userData              any
+	expiryMutex           sync.Mutex
+	expiry                time.Time
+	expiryRes             *base.Response
+	expiryTimer           *time.Timer
+	expiredRes            *base.Response
 	state                 ServerSessionState
+	lastStateChange       *ServerSessionStateChange
 	setuppedMedias        map[*description.Media]*serverSessionMedia
 	setuppedMediasOrdered []*serverSessionMedia
+	tornDownMedias        []*serverSessionMedia
+	deniedMedias          map[*description.Media]struct{}
 	tcpCallbackByChannel  map[int]readFunc
 	setuppedTransport     *SessionTransport
 	setuppedStream        *ServerStream // play
@@ -343,9 +416,14 @@ type ServerSession struct {
 	announcedDesc         *description.Session // record
 	udpLastPacketTime     *int64               // record
 	udpCheckStreamTimer   *time.Timer
+	limitsTimer           *time.Timer
+	createdTime           time.Time
+	lastActiveTime        time.Time
 	writerMutex           sync.RWMutex
 	writer                *asyncprocessor.Processor
+	readProcessor         *asyncprocessor.Processor
 	timeDecoder           *rtptime.GlobalDecoder
+	pausedAt              time.Time
 	tcpFrame              *base.InterleavedFrame
 	tcpBuffer             []byte
 
@@ -354,6 +432,40 @@ type ServerSession struct {
 	chRemoveConn       chan *ServerConn
 	chAsyncStartWriter chan struct{}
 	chWriterError      chan error
+	chExpiryChanged    chan struct{}
+
+	queuedBytes          atomic.Int64
+	memoryBudgetExceeded atomic.Bool
+	rateControlDisabled  atomic.Bool
+	playCSeq             atomic.Uint32
+}
+
+// applyMediaRejections removes rejected medias from an announced description
+// and fills the response accordingly.
+func applyMediaRejections(res *base.Response, ctx *ServerHandlerOnAnnounceCtx) *base.Response {
+	accepted := ctx.AcceptedMedias()
+
+	if len(accepted) == 0 {
+		return &base.Response{
+			StatusCode: ctx.rejections[0].Reason.StatusCode(),
+			Header:     res.Header,
+		}
+	}
+
+	ctx.Description.Medias = accepted
+
+	if res.Body == nil {
+		byts, err := ctx.Description.Marshal()
+		if err == nil {
+			if res.Header == nil {
+				res.Header = make(base.Header)
+			}
+			res.Header["Content-Type"] = base.HeaderValue{"application/sdp"}
+			res.Body = byts
+		}
+	}
+
+	return res
 }
 
 func (ss *ServerSession) initialize() {
@@ -363,16 +475,28 @@ func (ss *ServerSession) initialize() {
 	secretID := strings.ReplaceAll(uuid.New().String(), "-", "")
 
 	ss.secretID = secretID
+	ss.auditNumber = ss.s.auditSessionCount.Add(1)
 	ss.ctx = ctx
 	ss.ctxCancel = ctxCancel
 	ss.conns = make(map[*ServerConn]struct{})
+	ss.deniedMedias = make(map[*description.Media]struct{})
 	ss.lastRequestTime = ss.s.timeNow()
 	ss.udpCheckStreamTimer = emptyTimer()
+	ss.createdTime = ss.lastRequestTime
+	ss.lastActiveTime = ss.lastRequestTime
+
+	if ss.s.MaxSessionDuration != 0 || ss.s.MaxSessionIdleTime != 0 || ss.s.MaxSessionBytes != 0 {
+		ss.limitsTimer = time.NewTimer(ss.s.checkStreamPeriod)
+	} else {
+		ss.limitsTimer = emptyTimer()
+	}
 
 	ss.chHandleRequest = make(chan sessionRequestReq)
 	ss.chRemoveConn = make(chan *ServerConn)
 	ss.chAsyncStartWriter = make(chan struct{})
 	ss.chWriterError = make(chan error)
+	ss.chExpiryChanged = make(chan struct{}, 1)
+	ss.expiryTimer = emptyTimer()
 
 	ss.s.wg.Add(1)
 	go ss.run()
@@ -391,6 +515,17 @@ func (ss *ServerSession) State() ServerSessionState {
 	return ss.state
 }
 
+// LastStateChange returns the last state change of the session,
+// including the request that caused it.
+// It returns nil if the session is still in its initial state.
+// This is useful to find out why a request is refused with ErrServerInvalidState.
+func (ss *ServerSession) LastStateChange() *ServerSessionStateChange {
+	ss.propsMutex.RLock()
+	defer ss.propsMutex.RUnlock()
+
+	return ss.lastStateChange
+}
+
 // Stream returns the stream associated with the session.
 func (ss *ServerSession) Stream() *ServerStream {
 	ss.propsMutex.RLock()
@@ -399,6 +534,21 @@ func (ss *ServerSession) Stream() *ServerStream {
 	return ss.setuppedStream
 }
 
+// streamDo calls a function of the associated stream.
+// If the session has been moved to another stream in the meanwhile,
+// the function is called again on the new stream.
+func (ss *ServerSession) streamDo(cb func(*ServerStream, *ServerSession) bool) {
+	st := ss.Stream()
+
+	for !cb(st, ss) {
+		newSt := ss.Stream()
+		if newSt == st {
+			return
+		}
+		st = newSt
+	}
+}
+
 // Path returns the path sent during SETUP or ANNOUNCE.
 func (ss *ServerSession) Path() string {
 	ss.propsMutex.RLock()
@@ -436,15 +586,84 @@ func (ss *ServerSession) Medias() []*description.Media {
 }
 
 // SetUserData sets some user data associated with the session.
+// It can be called from any goroutine.
 func (ss *ServerSession) SetUserData(v any) {
+	ss.userDataMutex.Lock()
+	defer ss.userDataMutex.Unlock()
+
 	ss.userData = v
 }
 
 // UserData returns some user data associated with the session.
+// It can be called from any goroutine.
 func (ss *ServerSession) UserData() any {
+	ss.userDataMutex.RLock()
+	defer ss.userDataMutex.RUnlock()
+
 	return ss.userData
 }
 
+// ServerSessionUserData returns the user data associated with a session,
+// converted into the requested type.
+func ServerSessionUserData[T any](ss *ServerSession) (T, bool) {
+	v, ok := ss.UserData().(T)
+	return v, ok
+}
+
+// SetExpiry sets the time after which the session is torn down,
+// for instance because the credentials used to open it are valid for a limited time.
+// res is the response sent to requests that refer to the session after its expiry
+// (for instance, keepalives). If nil, it defaults to 403 Forbidden.
+// A zero time removes the expiry.
+// It can be called from any goroutine, including handlers.
+func (ss *ServerSession) SetExpiry(t time.Time, res *base.Response) {
+	if res == nil {
+		res = &base.Response{
+			StatusCode: base.StatusForbidden,
+		}
+	}
+
+	ss.expiryMutex.Lock()
+	ss.expiry = t
+	ss.expiryRes = res
+	ss.expiryMutex.Unlock()
+
+	select {
+	case ss.chExpiryChanged <- struct{}{}:
+	default:
+	}
+}
+
+// Expiry returns the time after which the session is torn down.
+// It is zero if the session doesn't expire.
+// It can be called from any goroutine.
+func (ss *ServerSession) Expiry() time.Time {
+	ss.expiryMutex.Lock()
+	defer ss.expiryMutex.Unlock()
+
+	return ss.expiry
+}
+
+// cloneExpiryResponse returns a copy of the expiry response,
+// since the header is modified before the response is written.
+func cloneExpiryResponse(res *base.Response) *base.Response {
+	c := *res
+	c.Header = maps.Clone(res.Header)
+	return &c
+}
+
+// expired returns the response to requests if the session is expired, otherwise nil.
+func (ss *ServerSession) expired() *base.Response {
+	ss.expiryMutex.Lock()
+	defer ss.expiryMutex.Unlock()
+
+	if ss.expiry.IsZero() || ss.s.timeNow().Before(ss.expiry) {
+		return nil
+	}
+
+	return cloneExpiryResponse(ss.expiryRes)
+}
+
 // Transport returns transport details.
 // This is non-nil only if SETUP has been called at least once.
 func (ss *ServerSession) Transport() *SessionTransport {
@@ -454,6 +673,15 @@ func (ss *ServerSession) Transport() *SessionTransport {
 	return ss.setuppedTransport
 }
 
+// RateControl returns whether data must be sent at the nominal rate.
+// It is false when the client sent "Rate-Control: no" in the last PLAY request,
+// asking to receive data as fast as the handler provides it (ONVIF replay).
+// In this case, packets written to the session are not discarded when the write queue is full:
+// writes wait until there's space or the write timeout expires.
+func (ss *ServerSession) RateControl() bool {
+	return !ss.rateControlDisabled.Load()
+}
+
 // Stats returns server session statistics.
 func (ss *ServerSession) Stats() *SessionStats {
 	ss.propsMutex.RLock()
@@ -464,12 +692,13 @@ func (ss *ServerSession) Stats() *SessionStats {
 
 		for med, sm := range ss.setuppedMedias {
 			ret[med] = SessionStatsMedia{
-				BytesReceived:       atomic.LoadUint64(sm.bytesReceived),
-				BytesSent:           atomic.LoadUint64(sm.bytesSent),
-				RTPPacketsInError:   atomic.LoadUint64(sm.rtpPacketsInError),
-				RTCPPacketsReceived: atomic.LoadUint64(sm.rtcpPacketsReceived),
-				RTCPPacketsSent:     atomic.LoadUint64(sm.rtcpPacketsSent),
-				RTCPPacketsInError:  atomic.LoadUint64(sm.rtcpPacketsInError),
+				BytesReceived:                atomic.LoadUint64(sm.bytesReceived),
+				BytesSent:                    atomic.LoadUint64(sm.bytesSent),
+				RTPPacketsInError:            atomic.LoadUint64(sm.rtpPacketsInError),
+				RTPPacketsUnknownPayloadType: atomic.LoadUint64(sm.rtpPacketsUnknownPayloadType),
+				RTCPPacketsReceived:          atomic.LoadUint64(sm.rtcpPacketsReceived),
+				RTCPPacketsSent:              atomic.LoadUint64(sm.rtcpPacketsSent),
+				RTCPPacketsInError:           atomic.LoadUint64(sm.rtcpPacketsInError),
 				Formats: func() map[format.Format]SessionStatsFormat {
 					ret := make(map[format.Format]SessionStatsFormat, len(sm.formats))
 
@@ -492,6 +721,12 @@ func (ss *ServerSession) Stats() *SessionStats {
 							}
 							return nil
 						}()
+						metrics := func() trackmetrics.Metrics {
+							if fo.metrics != nil {
+								return fo.metrics.Metrics()
+							}
+							return trackmetrics.Metrics{}
+						}()
 
 						ret[fo.format] = SessionStatsFormat{ //nolint:dupl
 							RTPPacketsReceived: atomic.LoadUint64(fo.rtpPacketsReceived),
@@ -537,6 +772,21 @@ func (ss *ServerSession) Stats() *SessionStats {
 								}
 								return 0
 							}(),
+							RemoteClockOffset: func() time.Duration {
+								if recvStats != nil {
+									return recvStats.ClockOffset
+								}
+								return 0
+							}(),
+							RemoteClockDrift: func() float64 {
+								if recvStats != nil {
+									return recvStats.ClockDrift
+								}
+								return 0
+							}(),
+							Bitrate:          metrics.Bitrate,
+							FrameRate:        metrics.FrameRate,
+							KeyFrameInterval: metrics.KeyFrameInterval,
 						}
 					}
 
@@ -597,6 +847,13 @@ func (ss *ServerSession) Stats() *SessionStats {
 			}
 			return v
 		}(),
+		RTPPacketsUnknownPayloadType: func() uint64 {
+			v := uint64(0)
+			for _, ms := range mediaStats {
+				v += ms.RTPPacketsUnknownPayloadType
+			}
+			return v
+		}(),
 		RTPPacketsJitter: func() float64 {
 			v := float64(0)
 			n := float64(0)
@@ -647,6 +904,105 @@ func (ss *ServerSession) onStreamWriteError(err error) {
 	}
 }
 
+// startPauseBuffers starts buffering packets received while the session is paused.
+func (ss *ServerSession) startPauseBuffers() {
+	started := false
+
+	for _, sm := range ss.setuppedMedias {
+		for _, sf := range sm.formats {
+			if sf.pauseBuffer != nil {
+				sf.pauseBuffer.pause()
+				started = true
+			}
+		}
+	}
+
+	if started {
+		ss.pausedAt = ss.s.timeNow()
+	}
+}
+
+// replayPauseBuffers sends packets received while the session was paused.
+// It is called by ServerStream while writes are blocked.
+// Timestamps of all formats are shifted by the same duration,
+// in order to preserve synchronization.
+func (ss *ServerSession) replayPauseBuffers() {
+	// the replay starts with the most recent among the first buffered packets of each format,
+	// that is usually a random access unit of a video format.
+	var start time.Time
+
+	for _, sm := range ss.setuppedMedias {
+		for _, sf := range sm.formats {
+			if sf.pauseBuffer != nil {
+				if t, ok := sf.pauseBuffer.startTime(); ok && t.After(start) {
+					start = t
+				}
+			}
+		}
+	}
+
+	if start.IsZero() {
+		start = ss.s.timeNow()
+	}
+
+	shift := start.Sub(ss.pausedAt)
+	ss.pausedAt = time.Time{}
+
+	type replayedPacket struct {
+		sf *serverSessionFormat
+		p  *serverPausedPacket
+	}
+
+	var packets []replayedPacket
+
+	for _, sm := range ss.setuppedMedias {
+		for _, sf := range sm.formats {
+			if sf.pauseBuffer != nil {
+				for _, p := range sf.pauseBuffer.resume(start, shift) {
+					packets = append(packets, replayedPacket{sf, p})
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(packets, func(a, b replayedPacket) int {
+		return a.p.time.Compare(b.p.time)
+	})
+
+	for _, rp := range packets {
+		err := rp.sf.writePacketRTPRewritten(rp.p.pkt, time.Time{})
+		if err != nil {
+			ss.onStreamWriteError(err)
+			return
+		}
+	}
+}
+
+// setState changes the state of the session.
+// propsMutex must be locked, and onStateChange must be called after unlocking it.
+func (ss *ServerSession) setState(state ServerSessionState, req *base.Request) *ServerSessionStateChange {
+	change := &ServerSessionStateChange{
+		Previous: ss.state,
+		Current:  state,
+		Request:  req,
+		Time:     ss.s.timeNow(),
+	}
+	ss.state = state
+	ss.lastStateChange = change
+	return change
+}
+
+func (ss *ServerSession) onStateChange(change *ServerSessionStateChange) {
+	if h, ok := ss.s.Handler.(ServerHandlerOnSessionStateChange); ok {
+		h.OnSessionStateChange(&ServerHandlerOnSessionStateChangeCtx{
+			Session:  ss,
+			Previous: change.Previous,
+			Current:  change.Current,
+			Request:  change.Request,
+		})
+	}
+}
+
 func (ss *ServerSession) checkState(allowed map[ServerSessionState]struct{}) error {
 	if _, ok := allowed[ss.state]; ok {
 		return nil
@@ -698,6 +1054,31 @@ func (ss *ServerSession) destroyWriter() {
 	ss.writerMutex.Lock()
 	ss.writer = nil
 	ss.writerMutex.Unlock()
+
+	// release packets that have been discarded by the writer
+	ss.s.memoryBudget.release(uint64(ss.queuedBytes.Swap(0)))
+}
+
+// onMemoryBudgetExceeded applies the memory budget policy,
+// when data of the session does not fit into the memory budget.
+func (ss *ServerSession) onMemoryBudgetExceeded() error {
+	err := liberrors.ErrServerMemoryBudgetExceeded{}
+
+	if ss.s.MemoryBudgetPolicy == MemoryBudgetPolicyCloseSession &&
+		ss.memoryBudgetExceeded.CompareAndSwap(false, true) {
+		ss.s.memoryBudget.closedSessions.Add(1)
+
+		// this is called by routines that write to the session,
+		// therefore the error must be sent asynchronously.
+		go func() {
+			select {
+			case ss.chWriterError <- err:
+			case <-ss.ctx.Done():
+			}
+		}()
+	}
+
+	return err
 }
 
 func (ss *ServerSession) run() {
@@ -714,6 +1095,10 @@ func (ss *ServerSession) run() {
 
 	ss.ctxCancel()
 
+	if _, ok := err.(liberrors.ErrServerSessionExpired); ok {
+		ss.expiredRes = ss.expired()
+	}
+
 	// close all associated connections, both UDP and TCP
 	// except for the one that called TEARDOWN
 	// (that is detached from the session just after the request)
@@ -726,9 +1111,9 @@ func (ss *ServerSession) run() {
 		sc.removeSession(ss)
 	}
 
-	if ss.setuppedStream != nil {
-		ss.setuppedStream.readerSetInactive(ss)
-		ss.setuppedStream.readerRemove(ss)
+	if ss.Stream() != nil {
+		ss.streamDo((*ServerStream).readerSetInactive)
+		ss.streamDo((*ServerStream).readerRemove)
 	}
 
 	ss.propsMutex.Lock()
@@ -737,14 +1122,30 @@ func (ss *ServerSession) run() {
 		sm.close()
 	}
 
+	for _, sm := range ss.tornDownMedias {
+		sm.close()
+	}
+
 	ss.propsMutex.Unlock()
 
+	if ss.readProcessor != nil {
+		ss.readProcessor.Close()
+	}
+
 	if ss.writer != nil {
 		ss.destroyWriter()
 	}
 
 	ss.s.closeSession(ss)
 
+	// make sure that OnConnClose() of connections that were
+	// detached because they were closed is called before OnSessionClose()
+	for _, sc := range ss.closedConns {
+		<-sc.done
+	}
+
+	ss.auditClose(err)
+
 	if h, ok := ss.s.Handler.(ServerHandlerOnSessionClose); ok {
 		h.OnSessionClose(&ServerHandlerOnSessionCloseCtx{
 			Session: ss,
@@ -757,20 +1158,38 @@ func (ss *ServerSession) runInner() error {
 	for {
 		select {
 		case req := <-ss.chHandleRequest:
+			if res := ss.expired(); res != nil {
+				req.res <- sessionRequestRes{
+					res: res,
+					err: liberrors.ErrServerSessionExpired{},
+				}
+				return liberrors.ErrServerSessionExpired{}
+			}
+
 			ss.lastRequestTime = ss.s.timeNow()
 
 			if _, ok := ss.conns[req.sc]; !ok {
 				ss.conns[req.sc] = struct{}{}
 			}
 
+			// a TEARDOWN addressed to a single media doesn't close the session.
+			isTeardown := req.req.Method == base.Teardown
+			if isTeardown {
+				medi, _, _ := ss.findTeardownMedia(req.req.URL)
+				isTeardown = (medi == nil)
+			}
+
+			if ss.state == ServerSessionStatePlay || ss.state == ServerSessionStateRecord {
+				ss.lastActiveTime = ss.lastRequestTime
+			}
+
 			res, err := ss.handleRequestInner(req.sc, req.req)
 
 			returnedSession := ss
 
 			if err == nil || isSwitchReadFuncError(err) {
 				// ANNOUNCE responses don't contain the session header.
-				if req.req.Method != base.Announce &&
-					req.req.Method != base.Teardown {
+				if req.req.Method != base.Announce && !isTeardown {
 					if res.Header == nil {
 						res.Header = make(base.Header)
 					}
@@ -778,7 +1197,7 @@ func (ss *ServerSession) runInner() error {
 					// Media Foundation-based software, like Windows Media Player,
 					// send keepalives at an interval equal the timeout value.
 					// prevent timeouts by subtracting 5 seconds from the value.
-					timeout := max(int(ss.s.IdleTimeout/time.Second)-5, 1)
+					timeout := max(int(ss.s.idleTimeout()/time.Second)-5, 1)
 
 					res.Header["Session"] = headers.Session{
 						Session: ss.secretID,
@@ -787,13 +1206,13 @@ func (ss *ServerSession) runInner() error {
 				}
 
 				// after a TEARDOWN, session must be unpaired with the connection
-				if req.req.Method == base.Teardown {
+				if isTeardown && res.StatusCode == base.StatusOK {
 					delete(ss.conns, req.sc)
 					returnedSession = nil
 				}
 			}
 
-			savedMethod := req.req.Method
+			teardownOK := isTeardown && res.StatusCode == base.StatusOK
 
 			req.res <- sessionRequestRes{
 				res: res,
@@ -801,12 +1220,13 @@ func (ss *ServerSession) runInner() error {
 				ss:  returnedSession,
 			}
 
-			if (err == nil || isSwitchReadFuncError(err)) && savedMethod == base.Teardown {
+			if (err == nil || isSwitchReadFuncError(err)) && teardownOK {
 				return liberrors.ErrServerSessionTornDown{Author: req.sc.NetConn().RemoteAddr()}
 			}
 
 		case sc := <-ss.chRemoveConn:
 			delete(ss.conns, sc)
+			ss.addClosedConn(sc)
 
 			// if session is not in state RECORD or PLAY, or transport is TCP,
 			// and there are no associated connections,
@@ -832,18 +1252,42 @@ func (ss *ServerSession) runInner() error {
 
 			// in case of RECORD, timeout happens when no RTP or RTCP packets are being received
 			if ss.state == ServerSessionStateRecord {
-				if now.Sub(time.Unix(lft, 0)) >= ss.s.ReadTimeout {
+				if now.Sub(time.Unix(lft, 0)) >= ss.s.readTimeout() {
 					return liberrors.ErrServerSessionTimedOut{}
 				}
 
 				// in case of PLAY, timeout happens when no RTSP keepalives and no RTCP packets are being received
-			} else if now.Sub(ss.lastRequestTime) >= ss.s.IdleTimeout &&
-				now.Sub(time.Unix(lft, 0)) >= ss.s.IdleTimeout {
+			} else if now.Sub(ss.lastRequestTime) >= ss.s.idleTimeout() &&
+				now.Sub(time.Unix(lft, 0)) >= ss.s.idleTimeout() {
 				return liberrors.ErrServerSessionTimedOut{}
 			}
 
 			ss.udpCheckStreamTimer = time.NewTimer(ss.s.checkStreamPeriod)
 
+		case <-ss.chExpiryChanged:
+			ss.expiryTimer.Stop()
+
+			if expiry := ss.Expiry(); !expiry.IsZero() {
+				ss.expiryTimer = time.NewTimer(max(expiry.Sub(ss.s.timeNow()), 0))
+			} else {
+				ss.expiryTimer = emptyTimer()
+			}
+
+		case <-ss.expiryTimer.C:
+			if ss.expired() != nil {
+				return liberrors.ErrServerSessionExpired{}
+			}
+
+			ss.expiryTimer = time.NewTimer(ss.s.checkStreamPeriod)
+
+		case <-ss.limitsTimer.C:
+			err := ss.checkLimits()
+			if err != nil {
+				return err
+			}
+
+			ss.limitsTimer = time.NewTimer(ss.s.checkStreamPeriod)
+
 		case err := <-ss.chWriterError:
 			return err
 
@@ -853,6 +1297,34 @@ func (ss *ServerSession) runInner() error {
 	}
 }
 
+func (ss *ServerSession) checkLimits() error {
+	now := ss.s.timeNow()
+
+	if ss.s.MaxSessionDuration != 0 && now.Sub(ss.createdTime) >= ss.s.MaxSessionDuration {
+		return liberrors.ErrServerSessionLimitReached{Limit: "maximum duration"}
+	}
+
+	if ss.state == ServerSessionStatePlay || ss.state == ServerSessionStateRecord {
+		ss.lastActiveTime = now
+	} else if ss.s.MaxSessionIdleTime != 0 && now.Sub(ss.lastActiveTime) >= ss.s.MaxSessionIdleTime {
+		return liberrors.ErrServerSessionLimitReached{Limit: "maximum idle time"}
+	}
+
+	if ss.s.MaxSessionBytes != 0 {
+		var bytes uint64
+
+		for _, sm := range ss.setuppedMedias {
+			bytes += atomic.LoadUint64(sm.bytesReceived) + atomic.LoadUint64(sm.bytesSent)
+		}
+
+		if bytes >= ss.s.MaxSessionBytes {
+			return liberrors.ErrServerSessionLimitReached{Limit: "maximum bytes"}
+		}
+	}
+
+	return nil
+}
+
 func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (*base.Response, error) {
 	if ss.tcpConn != nil && sc != ss.tcpConn {
 		return &base.Response{
@@ -872,35 +1344,14 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 	switch req.Method {
 	case base.Options:
-		var methods []string
-		if _, ok := sc.s.Handler.(ServerHandlerOnDescribe); ok {
-			methods = append(methods, string(base.Describe))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnAnnounce); ok {
-			methods = append(methods, string(base.Announce))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnSetup); ok {
-			methods = append(methods, string(base.Setup))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnPlay); ok {
-			methods = append(methods, string(base.Play))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnRecord); ok {
-			methods = append(methods, string(base.Record))
-		}
-		if _, ok := sc.s.Handler.(ServerHandlerOnPause); ok {
-			methods = append(methods, string(base.Pause))
-		}
-		methods = append(methods, string(base.GetParameter))
-		if _, ok := sc.s.Handler.(ServerHandlerOnSetParameter); ok {
-			methods = append(methods, string(base.SetParameter))
+		if req.URL != nil {
+			path, _ = getPathAndQuery(req.URL, false)
 		}
-		methods = append(methods, string(base.Teardown))
 
 		return &base.Response{
 			StatusCode: base.StatusOK,
 			Header: base.Header{
-				"Public": base.HeaderValue{strings.Join(methods, ", ")},
+				"Public": serverPublicHeader(sc.s.Handler, sc, req, path),
 			},
 		}, nil
 
@@ -949,22 +1400,30 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, liberrors.ErrServerSDPInvalid{Err: fmt.Errorf("back channels cannot be recorded")}
 		}
 
-		res, err := ss.s.Handler.(ServerHandlerOnAnnounce).OnAnnounce(&ServerHandlerOnAnnounceCtx{
+		ctx := &ServerHandlerOnAnnounceCtx{
 			Session:     ss,
 			Conn:        sc,
 			Request:     req,
 			Path:        path,
 			Query:       query,
 			Description: &desc,
-		})
+		}
+
+		res, err := ss.s.Handler.(ServerHandlerOnAnnounce).OnAnnounce(ctx)
+
+		if res.StatusCode == base.StatusOK && len(ctx.rejections) != 0 {
+			res = applyMediaRejections(res, ctx)
+		}
 
 		if res.StatusCode == base.StatusOK {
 			ss.propsMutex.Lock()
-			ss.state = ServerSessionStatePreRecord
+			change := ss.setState(ServerSessionStatePreRecord, req)
 			ss.setuppedPath = path
 			ss.setuppedQuery = query
 			ss.announcedDesc = &desc
 			ss.propsMutex.Unlock()
+
+			ss.onStateChange(change)
 		}
 
 		return res, err
@@ -1150,7 +1609,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 				}
 
 				if ss.state == ServerSessionStatePrePlay {
-					if stream != ss.setuppedStream {
+					if stream != ss.Stream() {
 						panic("stream cannot be different than the one returned in previous OnSetup call")
 					}
 				}
@@ -1172,12 +1631,35 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 				}, liberrors.ErrServerMediaAlreadySetup{}
 			}
 
+			if ss.s.MaxMediasPerSession != 0 && len(ss.setuppedMedias) >= ss.s.MaxMediasPerSession {
+				ss.deniedMedias[medi] = struct{}{}
+				return &base.Response{
+					StatusCode: base.StatusForbidden,
+				}, nil
+			}
+
+			if ss.s.MediaFilter != nil {
+				if code := ss.s.MediaFilter.FilterMedia(ss, medi); code != base.StatusOK {
+					ss.deniedMedias[medi] = struct{}{}
+					return &base.Response{
+						StatusCode: code,
+					}, nil
+				}
+			}
+
 			if ss.state == ServerSessionStateInitial {
 				err = stream.readerAdd(ss,
 					inTH.ClientPorts,
 					protocol,
+					inTH.Profile,
 				)
 				if err != nil {
+					if _, ok := err.(liberrors.ErrServerStreamTransformUnsupported); ok {
+						return &base.Response{
+							StatusCode: base.StatusUnsupportedTransport,
+						}, err
+					}
+
 					return &base.Response{
 						StatusCode: base.StatusBadRequest,
 					}, err
@@ -1222,7 +1704,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 
 			var srtpOutCtx *wrappedSRTPContext
 
-			if ss.s.TLSConfig != nil {
+			if ss.s.tlsConfig() != nil {
 				if ss.state == ServerSessionStatePreRecord || medi.IsBackChannel {
 					srtpOutKey := make([]byte, srtpKeyLength)
 					_, err = rand.Read(srtpOutKey)
@@ -1251,6 +1733,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			var udpRTPWriteAddr *net.UDPAddr
 			var udpRTCPReadPort int
 			var udpRTCPWriteAddr *net.UDPAddr
+			var udpPair *serverUDPListenerPair
 			var tcpChannel int
 
 			switch protocol {
@@ -1276,7 +1759,14 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 					de := headers.TransportDeliveryUnicast
 					th.Delivery = &de
 					th.ClientPorts = inTH.ClientPorts
-					th.ServerPorts = &[2]int{sc.s.udpRTPListener.port(), sc.s.udpRTCPListener.port()}
+					udpPair, err = ss.s.udpPairAcquire(ss.author.ip(), udpRTPReadPort)
+					if err != nil {
+						return &base.Response{
+							StatusCode: base.StatusServiceUnavailable,
+						}, err
+					}
+
+					th.ServerPorts = &[2]int{udpPair.rtp.port(), udpPair.rtcp.port()}
 				} else {
 					de := headers.TransportDeliveryMulticast
 					th.Delivery = &de
@@ -1318,6 +1808,7 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 				udpRTPWriteAddr:  udpRTPWriteAddr,
 				udpRTCPReadPort:  udpRTCPReadPort,
 				udpRTCPWriteAddr: udpRTCPWriteAddr,
+				udpPair:          udpPair,
 				tcpChannel:       tcpChannel,
 				onPacketRTCP:     func(_ rtcp.Packet) {},
 			}
@@ -1327,17 +1818,27 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 				ss.setuppedMedias = make(map[*description.Media]*serverSessionMedia)
 			}
 			ss.setuppedMedias[medi] = sm
+			delete(ss.deniedMedias, medi)
 			ss.setuppedMediasOrdered = append(ss.setuppedMediasOrdered, sm)
 
+			var change *ServerSessionStateChange
+
 			if ss.state == ServerSessionStateInitial {
-				ss.state = ServerSessionStatePrePlay
+				change = ss.setState(ServerSessionStatePrePlay, req)
 				ss.setuppedPath = path
 				ss.setuppedQuery = query
-				ss.setuppedStream = stream
+				// the stream may have been already set by ServerStream.MoveReaders()
+				if ss.setuppedStream == nil {
+					ss.setuppedStream = stream
+				}
 			}
 
 			ss.propsMutex.Unlock()
 
+			if change != nil {
+				ss.onStateChange(change)
+			}
+
 			res.Header["Transport"] = th.Marshal()
 
 			if isSecure(inTH.Profile) {
@@ -1386,6 +1887,33 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, liberrors.ErrServerPathHasChanged{Prev: ss.setuppedPath, Cur: path}
 		}
 
+		rateControl := true
+
+		if h, ok := req.Header["Rate-Control"]; ok {
+			var rc headers.RateControl
+			err = rc.Unmarshal(h)
+			if err != nil {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, liberrors.ErrServerRateControlHeaderInvalid{Err: err}
+			}
+			rateControl = rc.Value
+		}
+
+		// data of multicast sessions is shared with other readers and can't be sent faster.
+		if ss.setuppedTransport.Protocol == ProtocolUDPMulticast {
+			rateControl = true
+		}
+
+		prevRateControl := ss.RateControl()
+		ss.rateControlDisabled.Store(!rateControl)
+
+		if cseq, ok := req.Header["CSeq"]; ok && len(cseq) == 1 {
+			if v, err2 := strconv.ParseUint(strings.TrimSpace(cseq[0]), 10, 32); err2 == nil {
+				ss.playCSeq.Store(uint32(v))
+			}
+		}
+
 		if ss.state != ServerSessionStatePlay &&
 			ss.setuppedTransport.Protocol != ProtocolUDPMulticast {
 			ss.createWriter()
@@ -1400,11 +1928,22 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		})
 
 		if res.StatusCode == base.StatusOK {
+			if _, ok := req.Header["Rate-Control"]; ok {
+				if res.Header == nil {
+					res.Header = make(base.Header)
+				}
+				if _, ok2 := res.Header["Rate-Control"]; !ok2 {
+					res.Header["Rate-Control"] = headers.RateControl{Value: rateControl}.Marshal()
+				}
+			}
+
 			if ss.state != ServerSessionStatePlay {
 				ss.propsMutex.Lock()
-				ss.state = ServerSessionStatePlay
+				change := ss.setState(ServerSessionStatePlay, req)
 				ss.propsMutex.Unlock()
 
+				ss.onStateChange(change)
+
 				v := ss.s.timeNow().Unix()
 				ss.udpLastPacketTime = &v
 
@@ -1440,12 +1979,12 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 					// after the response has been sent
 				}
 
-				ss.setuppedStream.readerSetActive(ss)
+				ss.streamDo((*ServerStream).readerSetActive)
 
 				rtpInfo, ok := generateRTPInfo(
 					ss.s.timeNow(),
 					ss.setuppedMediasOrdered,
-					ss.setuppedStream,
+					ss.Stream(),
 					ss.setuppedPath,
 					req.URL)
 
@@ -1457,6 +1996,8 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 				}
 			}
 		} else {
+			ss.rateControlDisabled.Store(!prevRateControl)
+
 			if ss.state != ServerSessionStatePlay &&
 				ss.setuppedTransport.Protocol != ProtocolUDPMulticast {
 				ss.destroyWriter()
@@ -1475,7 +2016,8 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 			}, err
 		}
 
-		if len(ss.setuppedMedias) != len(ss.announcedDesc.Medias) {
+		// medias denied during SETUP are not needed.
+		if (len(ss.setuppedMedias) + len(ss.deniedMedias)) != len(ss.announcedDesc.Medias) {
 			return &base.Response{
 				StatusCode: base.StatusBadRequest,
 			}, liberrors.ErrServerNotAllAnnouncedMediasSetup{}
@@ -1498,7 +2040,11 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		})
 
 		if res.StatusCode == base.StatusOK {
-			ss.state = ServerSessionStateRecord
+			ss.propsMutex.Lock()
+			change := ss.setState(ServerSessionStateRecord, req)
+			ss.propsMutex.Unlock()
+
+			ss.onStateChange(change)
 
 			v := ss.s.timeNow().Unix()
 			ss.udpLastPacketTime = &v
@@ -1564,8 +2110,12 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 					ss.destroyWriter()
 				}
 
-				if ss.setuppedStream != nil {
-					ss.setuppedStream.readerSetInactive(ss)
+				if ss.state == ServerSessionStatePlay {
+					ss.startPauseBuffers()
+				}
+
+				if ss.Stream() != nil {
+					ss.streamDo((*ServerStream).readerSetInactive)
 				}
 
 				for _, sm := range ss.setuppedMedias {
@@ -1577,9 +2127,11 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 				switch ss.state {
 				case ServerSessionStatePlay:
 					ss.propsMutex.Lock()
-					ss.state = ServerSessionStatePrePlay
+					change := ss.setState(ServerSessionStatePrePlay, req)
 					ss.propsMutex.Unlock()
 
+					ss.onStateChange(change)
+
 					switch ss.setuppedTransport.Protocol {
 					case ProtocolUDP:
 						ss.udpCheckStreamTimer = emptyTimer()
@@ -1603,8 +2155,10 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 					}
 
 					ss.propsMutex.Lock()
-					ss.state = ServerSessionStatePreRecord
+					change := ss.setState(ServerSessionStatePreRecord, req)
 					ss.propsMutex.Unlock()
+
+					ss.onStateChange(change)
 				}
 			}
 		}
@@ -1612,6 +2166,30 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 		return res, err
 
 	case base.Teardown:
+		medi, path, query := ss.findTeardownMedia(req.URL)
+
+		if h, ok := sc.s.Handler.(ServerHandlerOnTeardown); ok {
+			res, err := h.OnTeardown(&ServerHandlerOnTeardownCtx{
+				Session: ss,
+				Conn:    sc,
+				Request: req,
+				Path:    path,
+				Query:   query,
+				Media:   medi,
+			})
+			if res.StatusCode != base.StatusOK {
+				return res, err
+			}
+		}
+
+		if medi != nil {
+			ss.removeMedia(medi)
+
+			return &base.Response{
+				StatusCode: base.StatusOK,
+			}, nil
+		}
+
 		var err error
 		if (ss.state == ServerSessionStatePlay || ss.state == ServerSessionStateRecord) &&
 			ss.setuppedTransport.Protocol == ProtocolTCP {
@@ -1653,6 +2231,19 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 				Query:   query,
 			})
 		}
+
+	default:
+		if h, ok := sc.s.Handler.(ServerHandlerOnCustomRequest); ok && slices.Contains(h.CustomMethods(), req.Method) {
+			path, query = getPathAndQuery(req.URL, false)
+
+			return h.OnCustomRequest(&ServerHandlerOnCustomRequestCtx{
+				Session: ss,
+				Conn:    sc,
+				Request: req,
+				Path:    path,
+				Query:   query,
+			})
+		}
 	}
 
 	return &base.Response{
@@ -1660,6 +2251,36 @@ func (ss *ServerSession) handleRequestInner(sc *ServerConn, req *base.Request) (
 	}, nil
 }
 
+// removeMedia removes a media from the session, while other medias keep working.
+func (ss *ServerSession) removeMedia(medi *description.Media) {
+	sm := ss.setuppedMedias[medi]
+	active := ss.state == ServerSessionStatePlay || ss.state == ServerSessionStateRecord
+
+	// stop the stream from writing to the session while medias are changed
+	if active && ss.Stream() != nil {
+		ss.streamDo((*ServerStream).readerSetInactive)
+	}
+
+	sm.tearDown()
+
+	ss.propsMutex.Lock()
+	delete(ss.setuppedMedias, medi)
+	for i, sm2 := range ss.setuppedMediasOrdered {
+		if sm2 == sm {
+			ss.setuppedMediasOrdered = append(ss.setuppedMediasOrdered[:i], ss.setuppedMediasOrdered[i+1:]...)
+			break
+		}
+	}
+	// the media is closed together with the session,
+	// since its callbacks may still be in use.
+	ss.tornDownMedias = append(ss.tornDownMedias, sm)
+	ss.propsMutex.Unlock()
+
+	if active && ss.Stream() != nil {
+		ss.streamDo((*ServerStream).readerSetActive)
+	}
+}
+
 func (ss *ServerSession) isChannelPairInUse(channel int) bool {
 	for _, sm := range ss.setuppedMedias {
 		if (sm.tcpChannel+1) == channel || sm.tcpChannel == channel || sm.tcpChannel == (channel+1) {
@@ -1719,6 +2340,22 @@ func (ss *ServerSession) WritePacketRTP(medi *description.Media, pkt *rtp.Packet
 	return sf.writePacketRTP(pkt)
 }
 
+// WritePacketRTPWithReplayExtension writes a RTP packet to the session,
+// attaching the ONVIF replay RTP header extension, that contains the absolute time of the frame.
+// The CSeq field of the extension is filled with the one of the last PLAY request.
+func (ss *ServerSession) WritePacketRTPWithReplayExtension(
+	medi *description.Media,
+	pkt *rtp.Packet,
+	ext onvif.ReplayExtension,
+) error {
+	ext.CSeq = uint8(ss.playCSeq.Load())
+
+	pkt2 := *pkt
+	ext.Marshal(&pkt2.Header)
+
+	return ss.WritePacketRTP(medi, &pkt2)
+}
+
 // WritePacketRTCP writes a RTCP packet to the session.
 func (ss *ServerSession) WritePacketRTCP(medi *description.Media, pkt rtcp.Packet) error {
 	sm := ss.setuppedMedias[medi]
@@ -1767,6 +2404,20 @@ func (ss *ServerSession) handleRequestNoWait(req sessionRequestReq) {
 	}
 }
 
+func (ss *ServerSession) addClosedConn(sc *ServerConn) {
+	// forget connections that are already done
+	n := 0
+	for _, csc := range ss.closedConns {
+		select {
+		case <-csc.done:
+		default:
+			ss.closedConns[n] = csc
+			n++
+		}
+	}
+	ss.closedConns = append(ss.closedConns[:n], sc)
+}
+
 func (ss *ServerSession) removeConn(sc *ServerConn) {
 	select {
 	case ss.chRemoveConn <- sc:
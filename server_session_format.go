@@ -11,6 +11,8 @@ import (
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpreceiver"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtprestamper"
+	"github.com/bluenviron/gortsplib/v5/pkg/trackmetrics"
 )
 
 type serverSessionFormat struct {
@@ -20,6 +22,11 @@ type serverSessionFormat struct {
 	onPacketRTP OnPacketRTPFunc
 
 	rtpReceiver           *rtpreceiver.Receiver
+	metrics               *trackmetrics.Estimator
+	refragmenter          *rtpRefragmenter
+	pauseBuffer           *serverPauseBuffer
+	restamper             *rtprestamper.Restamper // play, after a switch inside a ServerStreamGroup
+	restampSSRC           uint32
 	writePacketRTPInQueue func([]byte) error
 	rtpPacketsReceived    *uint64
 	rtpPacketsSent        *uint64
@@ -40,27 +47,83 @@ func (sf *serverSessionFormat) initialize() {
 		sf.writePacketRTPInQueue = sf.writePacketRTPInQueueTCP
 	}
 
+	if !udp && sf.sm.ss.s.MaxInterleavedFrameSize != 0 &&
+		sf.sm.ss.state != ServerSessionStatePreRecord &&
+		!isSecure(sf.sm.ss.setuppedTransport.Profile) {
+		sf.refragmenter = &rtpRefragmenter{
+			format:  sf.format,
+			maxSize: sf.sm.ss.s.MaxInterleavedFrameSize,
+		}
+		sf.refragmenter.initialize()
+	}
+
+	if sf.sm.ss.s.PauseBufferDuration > 0 &&
+		sf.sm.ss.state != ServerSessionStatePreRecord &&
+		!sf.sm.media.IsBackChannel &&
+		sf.sm.ss.setuppedTransport.Protocol != ProtocolUDPMulticast &&
+		!isSecure(sf.sm.ss.setuppedTransport.Profile) {
+		sf.pauseBuffer = &serverPauseBuffer{
+			clockRate:            sf.format.ClockRate(),
+			maxDuration:          sf.sm.ss.s.PauseBufferDuration,
+			memoryBudget:         sf.sm.ss.s.memoryBudget,
+			onMemoryBudgetExceed: sf.sm.ss.onMemoryBudgetExceeded,
+		}
+	}
+
 	if sf.sm.ss.state == ServerSessionStatePreRecord || sf.sm.media.IsBackChannel {
 		sf.rtpReceiver = &rtpreceiver.Receiver{
 			ClockRate:            sf.format.ClockRate(),
 			LocalSSRC:            sf.localSSRC,
 			UnrealiableTransport: udp,
 			Period:               sf.sm.ss.s.receiverReportPeriod,
+			BandwidthFraction:    sf.sm.ss.s.RTCPBandwidthFraction,
 			TimeNow:              sf.sm.ss.s.timeNow,
 			WritePacketRTCP: func(pkt rtcp.Packet) {
 				if udp {
 					sf.sm.ss.WritePacketRTCP(sf.sm.media, pkt) //nolint:errcheck
 				}
 			},
+			AllowSSRCChange: sf.sm.ss.s.AllowSSRCChange,
+			OnSSRCChange: func(prev uint32, cur uint32) {
+				if h, ok := sf.sm.ss.s.Handler.(ServerHandlerOnSSRCChange); ok {
+					h.OnSSRCChange(&ServerHandlerOnSSRCChangeCtx{
+						Session: sf.sm.ss,
+						Media:   sf.sm.media,
+						Format:  sf.format,
+						Prev:    prev,
+						Cur:     cur,
+					})
+				}
+			},
 		}
 		err := sf.rtpReceiver.Initialize()
 		if err != nil {
 			panic(err)
 		}
+
+		sf.metrics = &trackmetrics.Estimator{
+			Format:    sf.format,
+			Threshold: sf.sm.ss.s.TrackMetricsThreshold,
+			OnChange: func(metrics trackmetrics.Metrics) {
+				if h, ok := sf.sm.ss.s.Handler.(ServerHandlerOnTrackMetricsChange); ok {
+					h.OnTrackMetricsChange(&ServerHandlerOnTrackMetricsChangeCtx{
+						Session: sf.sm.ss,
+						Media:   sf.sm.media,
+						Format:  sf.format,
+						Metrics: metrics,
+					})
+				}
+			},
+		}
+		sf.metrics.Initialize()
 	}
 }
 
 func (sf *serverSessionFormat) close() {
+	if sf.pauseBuffer != nil {
+		sf.pauseBuffer.clear()
+	}
+
 	if sf.rtpReceiver != nil {
 		sf.rtpReceiver.Close()
 		sf.rtpReceiver = nil
@@ -107,6 +170,7 @@ func (sf *serverSessionFormat) readPacketRTP(pkt *rtp.Packet, now time.Time) {
 	atomic.AddUint64(sf.rtpPacketsReceived, uint64(len(pkts)))
 
 	for _, pkt := range pkts {
+		sf.metrics.ProcessPacket(pkt, now)
 		sf.onPacketRTP(pkt)
 	}
 }
@@ -114,6 +178,12 @@ func (sf *serverSessionFormat) readPacketRTP(pkt *rtp.Packet, now time.Time) {
 func (sf *serverSessionFormat) writePacketRTP(pkt *rtp.Packet) error {
 	pkt.SSRC = sf.localSSRC
 
+	deadline := sf.rateControlDeadline(time.Time{})
+
+	if sf.refragmenter != nil {
+		return sf.writePacketRTPRefragmented(pkt, deadline)
+	}
+
 	maxPlainPacketSize := sf.sm.ss.s.MaxPacketSize
 	if isSecure(sf.sm.ss.setuppedTransport.Profile) {
 		maxPlainPacketSize -= srtpOverhead
@@ -136,12 +206,94 @@ func (sf *serverSessionFormat) writePacketRTP(pkt *rtp.Packet) error {
 	}
 
 	if isSecure(sf.sm.ss.setuppedTransport.Profile) {
-		return sf.writePacketRTPEncoded(encr)
+		return sf.writePacketRTPEncoded(encr, deadline)
+	}
+	return sf.writePacketRTPEncoded(plain, deadline)
+}
+
+// rateControlDeadline returns the deadline of writes.
+// When rate control is disabled, data is provided as fast as possible,
+// therefore packets are not discarded when the write queue is full,
+// but writes wait until there's space or the write timeout expires.
+func (sf *serverSessionFormat) rateControlDeadline(deadline time.Time) time.Time {
+	if deadline.IsZero() && sf.sm.ss.rateControlDisabled.Load() {
+		return time.Now().Add(sf.sm.ss.s.writeTimeout())
+	}
+	return deadline
+}
+
+func (sf *serverSessionFormat) writePacketRTPRefragmented(pkt *rtp.Packet, deadline time.Time) error {
+	pkts, err := sf.refragmenter.process(pkt)
+	if err != nil {
+		return err
+	}
+
+	for _, pkt := range pkts {
+		var buf []byte
+		buf, err = pkt.Marshal()
+		if err != nil {
+			return err
+		}
+
+		err = sf.writePacketRTPEncoded(buf, deadline)
+		if err != nil {
+			return err
+		}
 	}
-	return sf.writePacketRTPEncoded(plain)
+
+	return nil
+}
+
+// restamp makes a packet of the current stream contiguous with
+// the ones previously sent to the session by another stream of a group.
+func (sf *serverSessionFormat) restamp(pkt *rtp.Packet) *rtp.Packet {
+	pkt = sf.restamper.Process(pkt)
+
+	if pkt.SSRC != sf.restampSSRC {
+		pkt2 := *pkt
+		pkt2.SSRC = sf.restampSSRC
+		pkt = &pkt2
+	}
+
+	return pkt
+}
+
+// restampSenderReport makes a sender report of the current stream consistent with
+// packets sent to the session after a switch inside a group.
+// It returns nil when no packet has been sent to the session after the switch yet.
+func (sf *serverSessionFormat) restampSenderReport(sr *rtcp.SenderReport) *rtcp.SenderReport {
+	rtpTime, ok := sf.restamper.ProcessTimestamp(sr.RTPTime)
+	if !ok {
+		return nil
+	}
+
+	sr2 := *sr
+	sr2.SSRC = sf.restampSSRC
+	sr2.RTPTime = rtpTime
+	return &sr2
 }
 
-func (sf *serverSessionFormat) writePacketRTPEncoded(payload []byte) error {
+// writePacketRTPRewritten writes a RTP packet of the stream,
+// after rewriting its sequence number and timestamp.
+func (sf *serverSessionFormat) writePacketRTPRewritten(pkt *rtp.Packet, deadline time.Time) error {
+	pkt = sf.pauseBuffer.rewrite(pkt)
+
+	if sf.refragmenter != nil {
+		return sf.writePacketRTPRefragmented(pkt, deadline)
+	}
+
+	buf, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return sf.writePacketRTPEncoded(buf, deadline)
+}
+
+// writePacketRTPEncoded writes an encoded RTP packet.
+// If deadline is not zero, the packet is not discarded when the write queue is full:
+// the function waits until there's space or the deadline expires.
+func (sf *serverSessionFormat) writePacketRTPEncoded(payload []byte, deadline time.Time) error {
 	sf.sm.ss.writerMutex.RLock()
 	defer sf.sm.ss.writerMutex.RUnlock()
 
@@ -149,10 +301,33 @@ func (sf *serverSessionFormat) writePacketRTPEncoded(payload []byte) error {
 		return nil
 	}
 
-	ok := sf.sm.ss.writer.Push(func() error {
+	n := uint64(len(payload))
+
+	if !sf.sm.ss.s.memoryBudget.acquire(n) {
+		return sf.sm.ss.onMemoryBudgetExceeded()
+	}
+	sf.sm.ss.queuedBytes.Add(int64(n))
+
+	cb := func() error {
+		sf.sm.ss.queuedBytes.Add(-int64(n))
+		sf.sm.ss.s.memoryBudget.release(n)
 		return sf.writePacketRTPInQueue(payload)
-	})
+	}
+
+	if !deadline.IsZero() {
+		ok := sf.sm.ss.writer.PushDeadline(cb, deadline)
+		if !ok {
+			sf.sm.ss.queuedBytes.Add(-int64(n))
+			sf.sm.ss.s.memoryBudget.release(n)
+			return liberrors.ErrServerWouldBlock{}
+		}
+		return nil
+	}
+
+	ok := sf.sm.ss.writer.Push(cb)
 	if !ok {
+		sf.sm.ss.queuedBytes.Add(-int64(n))
+		sf.sm.ss.s.memoryBudget.release(n)
 		return liberrors.ErrServerWriteQueueFull{}
 	}
 
@@ -160,7 +335,12 @@ func (sf *serverSessionFormat) writePacketRTPEncoded(payload []byte) error {
 }
 
 func (sf *serverSessionFormat) writePacketRTPInQueueUDP(payload []byte) error {
-	err := sf.sm.ss.s.udpRTPListener.write(payload, sf.sm.udpRTPWriteAddr)
+	addr := sf.sm.udpWriteAddr(sf.sm.udpRTPWriteAddr, &sf.sm.udpRTPLearned)
+	if addr == nil {
+		return nil
+	}
+
+	err := sf.sm.udpPair.rtp.write(payload, addr)
 	if err != nil {
 		return err
 	}
@@ -173,12 +353,14 @@ func (sf *serverSessionFormat) writePacketRTPInQueueUDP(payload []byte) error {
 func (sf *serverSessionFormat) writePacketRTPInQueueTCP(payload []byte) error {
 	sf.sm.ss.tcpFrame.Channel = sf.sm.tcpChannel
 	sf.sm.ss.tcpFrame.Payload = payload
-	sf.sm.ss.tcpConn.nconn.SetWriteDeadline(time.Now().Add(sf.sm.ss.s.WriteTimeout))
+	sf.sm.ss.tcpConn.nconn.SetWriteDeadline(time.Now().Add(sf.sm.ss.s.writeTimeout()))
 	err := sf.sm.ss.tcpConn.conn.WriteInterleavedFrame(sf.sm.ss.tcpFrame, sf.sm.ss.tcpBuffer)
 	if err != nil {
 		return err
 	}
 
+	dumpPacketTCP(sf.sm.ss.s.PacketDump, sf.sm.ss.tcpConn.nconn, true, sf.sm.tcpChannel, payload)
+
 	atomic.AddUint64(sf.sm.bytesSent, uint64(len(payload)))
 	atomic.AddUint64(sf.rtpPacketsSent, 1)
 	return nil
@@ -10,6 +10,7 @@ import (
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/gortsplib/v5/internal/asyncprocessor"
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 )
@@ -24,29 +25,50 @@ type serverSessionMedia struct {
 	udpRTPWriteAddr  *net.UDPAddr
 	udpRTCPReadPort  int
 	udpRTCPWriteAddr *net.UDPAddr
+	udpPair          *serverUDPListenerPair
+	udpRTPPending    *serverUDPPendingClient
+	udpRTCPPending   *serverUDPPendingClient
+	udpRTPLearned    atomic.Pointer[net.UDPAddr]
+	udpRTCPLearned   atomic.Pointer[net.UDPAddr]
 	tcpChannel       int
+	readProcessor    *asyncprocessor.Processor
 	onPacketRTCP     OnPacketRTCPFunc
 
-	formats                map[uint8]*serverSessionFormat // record only
-	writePacketRTCPInQueue func([]byte) error
-	bytesReceived          *uint64
-	bytesSent              *uint64
-	rtpPacketsInError      *uint64
-	rtcpPacketsReceived    *uint64
-	rtcpPacketsSent        *uint64
-	rtcpPacketsInError     *uint64
+	formats                      map[uint8]*serverSessionFormat // record only
+	writePacketRTCPInQueue       func([]byte) error
+	bytesReceived                *uint64
+	bytesSent                    *uint64
+	rtpPacketsInError            *uint64
+	rtpPacketsUnknownPayloadType *uint64
+	rtcpPacketsReceived          *uint64
+	rtcpPacketsSent              *uint64
+	rtcpPacketsInError           *uint64
+	tornDown                     *int32
 }
 
 func (sm *serverSessionMedia) initialize() {
 	sm.bytesReceived = new(uint64)
 	sm.bytesSent = new(uint64)
 	sm.rtpPacketsInError = new(uint64)
+	sm.rtpPacketsUnknownPayloadType = new(uint64)
 	sm.rtcpPacketsReceived = new(uint64)
 	sm.rtcpPacketsSent = new(uint64)
 	sm.rtcpPacketsInError = new(uint64)
+	sm.tornDown = new(int32)
 
 	sm.formats = make(map[uint8]*serverSessionFormat)
 
+	switch sm.ss.s.PacketProcessing {
+	case PacketProcessingSingle:
+		if sm.ss.readProcessor == nil {
+			sm.ss.readProcessor = newReadProcessor(sm.ss.s.ReadQueueSize)
+		}
+		sm.readProcessor = sm.ss.readProcessor
+
+	case PacketProcessingPerMedia:
+		sm.readProcessor = newReadProcessor(sm.ss.s.ReadQueueSize)
+	}
+
 	for _, forma := range sm.media.Formats {
 		f := &serverSessionFormat{
 			sm:          sm,
@@ -70,11 +92,15 @@ func (sm *serverSessionMedia) initialize() {
 		}
 
 		if sm.ss.state == ServerSessionStateInitial || sm.ss.state == ServerSessionStatePrePlay {
-			sm.ss.tcpCallbackByChannel[sm.tcpChannel] = sm.readPacketRTPTCPPlay
-			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.readPacketRTCPTCPPlay
+			sm.ss.tcpCallbackByChannel[sm.tcpChannel] = sm.ifNotTornDown(
+				sm.asyncReadFunc(sm.readPacketRTPTCPPlay, false))
+			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.ifNotTornDown(
+				sm.asyncReadFunc(sm.readPacketRTCPTCPPlay, true))
 		} else {
-			sm.ss.tcpCallbackByChannel[sm.tcpChannel] = sm.readPacketRTPTCPRecord
-			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.readPacketRTCPTCPRecord
+			sm.ss.tcpCallbackByChannel[sm.tcpChannel] = sm.ifNotTornDown(
+				sm.asyncReadFunc(sm.readPacketRTPTCPRecord, false))
+			sm.ss.tcpCallbackByChannel[sm.tcpChannel+1] = sm.ifNotTornDown(
+				sm.asyncReadFunc(sm.readPacketRTCPTCPRecord, true))
 		}
 	}
 }
@@ -82,20 +108,76 @@ func (sm *serverSessionMedia) initialize() {
 func (sm *serverSessionMedia) close() {
 	sm.stop()
 
+	if sm.udpPair != nil {
+		sm.ss.s.udpPairRelease(sm.udpPair, sm.ss.author.ip(), sm.udpRTPReadPort)
+	}
+
+	if sm.ss.s.PacketProcessing == PacketProcessingPerMedia {
+		sm.readProcessor.Close()
+	}
+
 	for _, forma := range sm.formats {
 		forma.close()
 	}
 }
 
+// tearDown stops the media after it has been removed from the session by a TEARDOWN request.
+func (sm *serverSessionMedia) tearDown() {
+	atomic.StoreInt32(sm.tornDown, 1)
+	sm.stop()
+}
+
+// TCP callbacks can't be removed while the connection is reading,
+// therefore they are disabled.
+func (sm *serverSessionMedia) ifNotTornDown(cb readFunc) readFunc {
+	return func(payload []byte) bool {
+		if atomic.LoadInt32(sm.tornDown) != 0 {
+			return false
+		}
+		return cb(payload)
+	}
+}
+
+// asyncReadFunc moves processing of incoming packets
+// to the routine selected by PacketProcessing.
+func (sm *serverSessionMedia) asyncReadFunc(cb readFunc, isRTCP bool) readFunc {
+	if sm.readProcessor == nil {
+		return cb
+	}
+
+	return asyncReadFunc(sm.readProcessor, cb, func() {
+		if isRTCP {
+			sm.onPacketRTCPDecodeError(liberrors.ErrServerReadQueueFull{})
+		} else {
+			sm.onPacketRTPDecodeError(liberrors.ErrServerReadQueueFull{})
+		}
+	})
+}
+
 func (sm *serverSessionMedia) start() error {
 	switch sm.ss.setuppedTransport.Protocol {
 	case ProtocolUDP, ProtocolUDPMulticast:
 		if sm.ss.setuppedTransport.Protocol == ProtocolUDP {
 			if sm.ss.state == ServerSessionStatePlay {
+				// RTCP receiver reports may be sent to the RTP port too.
+				readRTP := sm.readPacketRTPUDPDiscard
 				if sm.media.IsBackChannel {
-					sm.ss.s.udpRTPListener.addClient(sm.ss.author.ip(), sm.udpRTPReadPort, sm.readPacketRTPUDPPlay)
+					readRTP = sm.asyncReadFunc(sm.readPacketRTPUDPPlay, false)
 				}
-				sm.ss.s.udpRTCPListener.addClient(sm.ss.author.ip(), sm.udpRTCPReadPort, sm.readPacketRTCPUDPPlay)
+				readRTCP := sm.asyncReadFunc(sm.readPacketRTCPUDPPlay, true)
+				sm.udpRTPPending = sm.addUDPClient(sm.udpPair.rtp, sm.udpRTPReadPort, &sm.udpRTPLearned,
+					demuxRTPPort(readRTP, readRTCP))
+				sm.udpRTCPPending = sm.addUDPClient(sm.udpPair.rtcp, sm.udpRTCPReadPort, &sm.udpRTCPLearned,
+					demuxRTCPPort(readRTP, readRTCP))
+			} else if sm.ss.s.ValidateUDPAddresses {
+				readRTP := sm.asyncReadFunc(sm.readPacketRTPUDPRecord, false)
+				readRTCP := sm.asyncReadFunc(sm.readPacketRTCPUDPRecord, true)
+
+				// packets are not sent to addresses that have not been validated.
+				sm.udpRTPPending = sm.addUDPClient(sm.udpPair.rtp, sm.udpRTPReadPort, &sm.udpRTPLearned,
+					demuxRTPPort(readRTP, readRTCP))
+				sm.udpRTCPPending = sm.addUDPClient(sm.udpPair.rtcp, sm.udpRTCPReadPort, &sm.udpRTCPLearned,
+					demuxRTCPPort(readRTP, readRTCP))
 			} else {
 				// open the firewall by sending empty packets to the remote part.
 				buf, _ := (&rtp.Packet{Header: rtp.Header{Version: 2}}).Marshal()
@@ -107,7 +189,7 @@ func (sm *serverSessionMedia) start() error {
 					}
 					buf = encr
 				}
-				err := sm.ss.s.udpRTPListener.write(buf, sm.udpRTPWriteAddr)
+				err := sm.udpPair.rtp.write(buf, sm.udpRTPWriteAddr)
 				if err != nil {
 					return err
 				}
@@ -121,13 +203,18 @@ func (sm *serverSessionMedia) start() error {
 					}
 					buf = encr
 				}
-				err = sm.ss.s.udpRTCPListener.write(buf, sm.udpRTCPWriteAddr)
+				err = sm.udpPair.rtcp.write(buf, sm.udpRTCPWriteAddr)
 				if err != nil {
 					return err
 				}
 
-				sm.ss.s.udpRTPListener.addClient(sm.ss.author.ip(), sm.udpRTPReadPort, sm.readPacketRTPUDPRecord)
-				sm.ss.s.udpRTCPListener.addClient(sm.ss.author.ip(), sm.udpRTCPReadPort, sm.readPacketRTCPUDPRecord)
+				readRTP := sm.asyncReadFunc(sm.readPacketRTPUDPRecord, false)
+				readRTCP := sm.asyncReadFunc(sm.readPacketRTCPUDPRecord, true)
+
+				sm.udpPair.rtp.addClient(sm.ss.author.ip(), sm.udpRTPReadPort,
+					demuxRTPPort(readRTP, readRTCP))
+				sm.udpPair.rtcp.addClient(sm.ss.author.ip(), sm.udpRTCPReadPort,
+					demuxRTCPPort(readRTP, readRTCP))
 			}
 		}
 	}
@@ -137,9 +224,73 @@ func (sm *serverSessionMedia) start() error {
 
 func (sm *serverSessionMedia) stop() {
 	if sm.ss.setuppedTransport.Protocol == ProtocolUDP {
-		sm.ss.s.udpRTPListener.removeClient(sm.ss.author.ip(), sm.udpRTPReadPort)
-		sm.ss.s.udpRTCPListener.removeClient(sm.ss.author.ip(), sm.udpRTCPReadPort)
+		sm.removeUDPClient(sm.udpPair.rtp, sm.udpRTPReadPort, &sm.udpRTPLearned, sm.udpRTPPending)
+		sm.removeUDPClient(sm.udpPair.rtcp, sm.udpRTCPReadPort, &sm.udpRTCPLearned, sm.udpRTCPPending)
+		sm.udpRTPPending = nil
+		sm.udpRTCPPending = nil
+	}
+}
+
+// addUDPClient registers the client on a listener.
+// When address validation is enabled, the client is registered
+// once a packet is received from its IP, and the source port of the packet
+// is used in place of the one advertised in SETUP.
+func (sm *serverSessionMedia) addUDPClient(
+	l *serverUDPListener,
+	port int,
+	learned *atomic.Pointer[net.UDPAddr],
+	cb readFunc,
+) *serverUDPPendingClient {
+	if !sm.ss.s.ValidateUDPAddresses {
+		l.addClient(sm.ss.author.ip(), port, cb)
+		return nil
+	}
+
+	if addr := learned.Load(); addr != nil {
+		l.addClient(sm.ss.author.ip(), addr.Port, cb)
+		return nil
 	}
+
+	pc := &serverUDPPendingClient{
+		onFirstPacket: func(addr *net.UDPAddr) readFunc {
+			learned.Store(addr)
+			return cb
+		},
+	}
+	l.addPendingClient(sm.ss.author.ip(), pc)
+	return pc
+}
+
+func (sm *serverSessionMedia) removeUDPClient(
+	l *serverUDPListener,
+	port int,
+	learned *atomic.Pointer[net.UDPAddr],
+	pc *serverUDPPendingClient,
+) {
+	if !sm.ss.s.ValidateUDPAddresses {
+		l.removeClient(sm.ss.author.ip(), port)
+		return
+	}
+
+	if pc != nil {
+		l.removePendingClient(sm.ss.author.ip(), pc)
+	}
+
+	if addr := learned.Load(); addr != nil {
+		l.removeClient(sm.ss.author.ip(), addr.Port)
+	}
+}
+
+// udpWriteAddr returns the address packets are sent to,
+// or nil if the address has not been validated yet.
+func (sm *serverSessionMedia) udpWriteAddr(
+	advertised *net.UDPAddr,
+	learned *atomic.Pointer[net.UDPAddr],
+) *net.UDPAddr {
+	if !sm.ss.s.ValidateUDPAddresses {
+		return advertised
+	}
+	return learned.Load()
 }
 
 func (sm *serverSessionMedia) findFormatByRemoteSSRC(ssrc uint32) *serverSessionFormat {
@@ -199,7 +350,7 @@ func (sm *serverSessionMedia) readPacketRTPUDPPlay(payload []byte) bool {
 
 	forma, ok := sm.formats[pkt.PayloadType]
 	if !ok {
-		sm.onPacketRTPDecodeError(liberrors.ErrServerRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+		sm.onPacketRTPUnknownPayloadType(pkt)
 		return false
 	}
 
@@ -208,6 +359,10 @@ func (sm *serverSessionMedia) readPacketRTPUDPPlay(payload []byte) bool {
 	return true
 }
 
+func (sm *serverSessionMedia) readPacketRTPUDPDiscard(_ []byte) bool {
+	return false
+}
+
 func (sm *serverSessionMedia) readPacketRTCPUDPPlay(payload []byte) bool {
 	atomic.AddUint64(sm.bytesReceived, uint64(len(payload)))
 
@@ -250,7 +405,7 @@ func (sm *serverSessionMedia) readPacketRTPUDPRecord(payload []byte) bool {
 
 	forma, ok := sm.formats[pkt.PayloadType]
 	if !ok {
-		sm.onPacketRTPDecodeError(liberrors.ErrServerRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+		sm.onPacketRTPUnknownPayloadType(pkt)
 		return false
 	}
 
@@ -310,7 +465,7 @@ func (sm *serverSessionMedia) readPacketRTPTCPPlay(payload []byte) bool {
 
 	forma, ok := sm.formats[pkt.PayloadType]
 	if !ok {
-		sm.onPacketRTPDecodeError(liberrors.ErrServerRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+		sm.onPacketRTPUnknownPayloadType(pkt)
 		return false
 	}
 
@@ -353,7 +508,7 @@ func (sm *serverSessionMedia) readPacketRTPTCPRecord(payload []byte) bool {
 
 	forma, ok := sm.formats[pkt.PayloadType]
 	if !ok {
-		sm.onPacketRTPDecodeError(liberrors.ErrServerRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+		sm.onPacketRTPUnknownPayloadType(pkt)
 		return false
 	}
 
@@ -394,6 +549,20 @@ func (sm *serverSessionMedia) readPacketRTCPTCPRecord(payload []byte) bool {
 	return true
 }
 
+func (sm *serverSessionMedia) onPacketRTPUnknownPayloadType(pkt *rtp.Packet) {
+	atomic.AddUint64(sm.rtpPacketsUnknownPayloadType, 1)
+
+	if h, ok := sm.ss.s.Handler.(ServerHandlerOnUnknownPacket); ok {
+		h.OnUnknownPacket(&ServerHandlerOnUnknownPacketCtx{
+			Session: sm.ss,
+			Media:   sm.media,
+			Packet:  pkt,
+		})
+	} else {
+		sm.onPacketRTPDecodeError(liberrors.ErrServerRTPPacketUnknownPayloadType{PayloadType: pkt.PayloadType})
+	}
+}
+
 func (sm *serverSessionMedia) onPacketRTPDecodeError(err error) {
 	atomic.AddUint64(sm.rtpPacketsInError, 1)
 
@@ -469,7 +638,12 @@ func (sm *serverSessionMedia) writePacketRTCPEncoded(payload []byte) error {
 }
 
 func (sm *serverSessionMedia) writePacketRTCPInQueueUDP(payload []byte) error {
-	err := sm.ss.s.udpRTCPListener.write(payload, sm.udpRTCPWriteAddr)
+	addr := sm.udpWriteAddr(sm.udpRTCPWriteAddr, &sm.udpRTCPLearned)
+	if addr == nil {
+		return nil
+	}
+
+	err := sm.udpPair.rtcp.write(payload, addr)
 	if err != nil {
 		return err
 	}
@@ -482,12 +656,14 @@ func (sm *serverSessionMedia) writePacketRTCPInQueueUDP(payload []byte) error {
 func (sm *serverSessionMedia) writePacketRTCPInQueueTCP(payload []byte) error {
 	sm.ss.tcpFrame.Channel = sm.tcpChannel + 1
 	sm.ss.tcpFrame.Payload = payload
-	sm.ss.tcpConn.nconn.SetWriteDeadline(time.Now().Add(sm.ss.s.WriteTimeout))
+	sm.ss.tcpConn.nconn.SetWriteDeadline(time.Now().Add(sm.ss.s.writeTimeout()))
 	err := sm.ss.tcpConn.conn.WriteInterleavedFrame(sm.ss.tcpFrame, sm.ss.tcpBuffer)
 	if err != nil {
 		return err
 	}
 
+	dumpPacketTCP(sm.ss.s.PacketDump, sm.ss.tcpConn.nconn, true, sm.tcpChannel+1, payload)
+
 	atomic.AddUint64(sm.bytesSent, uint64(len(payload)))
 	atomic.AddUint64(sm.rtcpPacketsSent, 1)
 	return nil
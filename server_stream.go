@@ -12,7 +12,9 @@ import (
 
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtprestamper"
 )
 
 func serverStreamExtractExistingSSRCs(medias map[*description.Media]*serverStreamMedia) []uint32 {
@@ -25,6 +27,28 @@ func serverStreamExtractExistingSSRCs(medias map[*description.Media]*serverStrea
 	return ret
 }
 
+var serverStreamNextID atomic.Uint64
+
+// serverStreamLockPair locks two streams, always in the same order,
+// in order to prevent deadlocks between moves of readers in opposite directions.
+func serverStreamLockPair(a *ServerStream, b *ServerStream) {
+	if b.id < a.id {
+		a, b = b, a
+	}
+	a.mutex.Lock()
+	b.mutex.Lock()
+}
+
+func serverStreamUnlockPair(a *ServerStream, b *ServerStream) {
+	a.mutex.Unlock()
+	b.mutex.Unlock()
+}
+
+type serverStreamDescribeCacheKey struct {
+	multicast    bool
+	backChannels bool
+}
+
 // ServerStream represents a data stream.
 // This is in charge of
 // - storing stream description and statistics
@@ -34,12 +58,25 @@ type ServerStream struct {
 	Server *Server
 	Desc   *description.Session
 
+	// called before a RTP packet is sent to a reader.
+	// It receives a copy of the packet, that can be modified and returned,
+	// or it can return nil in order to discard the packet for that reader.
+	// When set, readers that use UDP-multicast or a secure profile are rejected,
+	// since their packets are shared or encrypted once for all readers.
+	// It is called with the lock of the stream held, therefore it must not call methods of the stream.
+	TransformPacketRTP func(ss *ServerSession, medi *description.Media, forma format.Format, pkt *rtp.Packet) *rtp.Packet
+
+	id                   uint64
 	mutex                sync.RWMutex
 	readers              map[*ServerSession]struct{}
 	multicastReaderCount int
 	activeUnicastReaders map[*ServerSession]struct{}
+	pausedUnicastReaders map[*ServerSession]struct{}
 	medias               map[*description.Media]*serverStreamMedia
 	closed               bool
+
+	describeCacheMutex sync.Mutex
+	describeCache      map[serverStreamDescribeCacheKey][]byte
 }
 
 // Initialize initializes a ServerStream.
@@ -48,8 +85,10 @@ func (st *ServerStream) Initialize() error {
 		return fmt.Errorf("server not present or not initialized")
 	}
 
+	st.id = serverStreamNextID.Add(1)
 	st.readers = make(map[*ServerSession]struct{})
 	st.activeUnicastReaders = make(map[*ServerSession]struct{})
+	st.pausedUnicastReaders = make(map[*ServerSession]struct{})
 
 	st.medias = make(map[*description.Media]*serverStreamMedia, len(st.Desc.Medias))
 
@@ -67,7 +106,7 @@ func (st *ServerStream) Initialize() error {
 
 		var srtpOutCtx *wrappedSRTPContext
 
-		if st.Server.TLSConfig != nil {
+		if st.Server.tlsConfig() != nil {
 			srtpOutKey := make([]byte, srtpKeyLength)
 			_, err = rand.Read(srtpOutKey)
 			if err != nil {
@@ -120,6 +159,60 @@ func (st *ServerStream) Close() {
 	}
 }
 
+// InvalidateDescription discards the SDP documents cached for DESCRIBE requests.
+// It must be called when codec parameters of formats of the stream change,
+// if Server.DescribeCacheEnable is true.
+func (st *ServerStream) InvalidateDescription() {
+	st.describeCacheMutex.Lock()
+	defer st.describeCacheMutex.Unlock()
+
+	st.describeCache = nil
+}
+
+// marshalDescription returns the SDP document sent in response to DESCRIBE requests.
+func (st *ServerStream) marshalDescription(multicast bool, backChannels bool) ([]byte, error) {
+	secure := st.Server.tlsConfig() != nil
+
+	// documents of secure streams contain MIKEY messages, that must be generated for each request.
+	if !st.Server.DescribeCacheEnable || secure {
+		desc, err := prepareForDescribe(st.Desc, multicast, backChannels, secure, st.medias)
+		if err != nil {
+			return nil, err
+		}
+
+		return desc.Marshal()
+	}
+
+	key := serverStreamDescribeCacheKey{
+		multicast:    multicast,
+		backChannels: backChannels,
+	}
+
+	st.describeCacheMutex.Lock()
+	defer st.describeCacheMutex.Unlock()
+
+	if byts, ok := st.describeCache[key]; ok {
+		return byts, nil
+	}
+
+	desc, err := prepareForDescribe(st.Desc, multicast, backChannels, false, st.medias)
+	if err != nil {
+		return nil, err
+	}
+
+	byts, err := desc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	if st.describeCache == nil {
+		st.describeCache = make(map[serverStreamDescribeCacheKey][]byte)
+	}
+	st.describeCache[key] = byts
+
+	return byts, nil
+}
+
 // Stats returns stream statistics.
 func (st *ServerStream) Stats() *ServerStreamStats {
 	mediaStats := func() map[*description.Media]ServerStreamStatsMedia {
@@ -179,6 +272,7 @@ func (st *ServerStream) readerAdd(
 	ss *ServerSession,
 	clientPorts *[2]int,
 	protocol Protocol,
+	profile headers.TransportProfile,
 ) error {
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
@@ -187,6 +281,10 @@ func (st *ServerStream) readerAdd(
 		return liberrors.ErrServerStreamClosed{}
 	}
 
+	if st.TransformPacketRTP != nil && (protocol == ProtocolUDPMulticast || isSecure(profile)) {
+		return liberrors.ErrServerStreamTransformUnsupported{}
+	}
+
 	switch protocol {
 	case ProtocolUDP:
 		// check whether UDP ports and IP are already assigned to another reader
@@ -223,15 +321,20 @@ func (st *ServerStream) readerAdd(
 	return nil
 }
 
-func (st *ServerStream) readerRemove(ss *ServerSession) {
+func (st *ServerStream) readerRemove(ss *ServerSession) bool {
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
 
 	if st.closed {
-		return
+		return true
+	}
+
+	if _, ok := st.readers[ss]; !ok {
+		return false
 	}
 
 	delete(st.readers, ss)
+	delete(st.pausedUnicastReaders, ss)
 
 	if ss.setuppedTransport.Protocol == ProtocolUDPMulticast {
 		st.multicastReaderCount--
@@ -242,14 +345,20 @@ func (st *ServerStream) readerRemove(ss *ServerSession) {
 			}
 		}
 	}
+
+	return true
 }
 
-func (st *ServerStream) readerSetActive(ss *ServerSession) {
+func (st *ServerStream) readerSetActive(ss *ServerSession) bool {
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
 
 	if st.closed {
-		return
+		return true
+	}
+
+	if _, ok := st.readers[ss]; !ok {
+		return false
 	}
 
 	if ss.setuppedTransport.Protocol == ProtocolUDPMulticast {
@@ -259,16 +368,27 @@ func (st *ServerStream) readerSetActive(ss *ServerSession) {
 				ss.author.ip(), streamMedia.multicastWriter.rtcpl.port(), sm.readPacketRTCPUDPPlay)
 		}
 	} else {
+		if _, ok := st.pausedUnicastReaders[ss]; ok {
+			delete(st.pausedUnicastReaders, ss)
+			ss.replayPauseBuffers()
+		}
+
 		st.activeUnicastReaders[ss] = struct{}{}
 	}
+
+	return true
 }
 
-func (st *ServerStream) readerSetInactive(ss *ServerSession) {
+func (st *ServerStream) readerSetInactive(ss *ServerSession) bool {
 	st.mutex.Lock()
 	defer st.mutex.Unlock()
 
 	if st.closed {
-		return
+		return true
+	}
+
+	if _, ok := st.readers[ss]; !ok {
+		return false
 	}
 
 	if ss.setuppedTransport.Protocol == ProtocolUDPMulticast {
@@ -278,7 +398,139 @@ func (st *ServerStream) readerSetInactive(ss *ServerSession) {
 		}
 	} else {
 		delete(st.activeUnicastReaders, ss)
+
+		if !ss.pausedAt.IsZero() {
+			st.pausedUnicastReaders[ss] = struct{}{}
+		}
 	}
+
+	return true
+}
+
+// MoveReaders moves all readers of the stream to another stream, without interrupting them.
+// It can be used to switch readers from a primary source to a backup one.
+//
+// The destination stream must share the same description (Desc) and must not have readers.
+// SSRCs, SRTP contexts and multicast groups are transferred to the destination stream,
+// and sequence numbers and timestamps of packets written to the destination stream
+// are rewritten in order to be contiguous with the ones of packets written to this stream.
+//
+// After the call, OnSetup() must return the destination stream
+// for the paths that were associated with this stream.
+func (st *ServerStream) MoveReaders(dest *ServerStream) error {
+	if dest == st {
+		return fmt.Errorf("destination stream is the same as the source stream")
+	}
+
+	if dest.Desc != st.Desc {
+		return fmt.Errorf("destination stream has a different description")
+	}
+
+	serverStreamLockPair(st, dest)
+	defer serverStreamUnlockPair(st, dest)
+
+	if st.closed || dest.closed {
+		return liberrors.ErrServerStreamClosed{}
+	}
+
+	if len(dest.readers) != 0 {
+		return fmt.Errorf("destination stream already has readers")
+	}
+
+	if dest.TransformPacketRTP != nil {
+		for ss := range st.readers {
+			if ss.setuppedTransport.Protocol == ProtocolUDPMulticast || isSecure(ss.setuppedTransport.Profile) {
+				return liberrors.ErrServerStreamTransformUnsupported{}
+			}
+		}
+	}
+
+	for medi, srcMedia := range st.medias {
+		destMedia := dest.medias[medi]
+
+		for pt, srcFormat := range srcMedia.formats {
+			destMedia.formats[pt].moveFrom(srcFormat)
+		}
+
+		destMedia.localSSRCs, srcMedia.localSSRCs = srcMedia.localSSRCs, destMedia.localSSRCs
+		destMedia.srtpOutCtx, srcMedia.srtpOutCtx = srcMedia.srtpOutCtx, destMedia.srtpOutCtx
+		destMedia.multicastWriter, srcMedia.multicastWriter = srcMedia.multicastWriter, nil
+	}
+
+	dest.multicastReaderCount, st.multicastReaderCount = st.multicastReaderCount, 0
+	dest.readers, st.readers = st.readers, dest.readers
+	dest.activeUnicastReaders, st.activeUnicastReaders = st.activeUnicastReaders, dest.activeUnicastReaders
+	dest.pausedUnicastReaders, st.pausedUnicastReaders = st.pausedUnicastReaders, dest.pausedUnicastReaders
+
+	for ss := range dest.readers {
+		ss.propsMutex.Lock()
+		ss.setuppedStream = dest
+		ss.propsMutex.Unlock()
+	}
+
+	return nil
+}
+
+// moveReader moves a single reader to another stream that shares the same description.
+// Sequence numbers, timestamps and SSRCs of packets sent to the reader are rewritten
+// in order to be contiguous with the ones of packets previously sent to the reader.
+func (st *ServerStream) moveReader(ss *ServerSession, dest *ServerStream) error {
+	serverStreamLockPair(st, dest)
+	defer serverStreamUnlockPair(st, dest)
+
+	if st.closed || dest.closed {
+		return liberrors.ErrServerStreamClosed{}
+	}
+
+	if _, ok := st.readers[ss]; !ok {
+		return fmt.Errorf("session is not reading the stream")
+	}
+
+	if ss.setuppedTransport.Protocol == ProtocolUDPMulticast {
+		return fmt.Errorf("sessions that use UDP-multicast can't be moved")
+	}
+
+	if isSecure(ss.setuppedTransport.Profile) {
+		return fmt.Errorf("sessions that use a secure profile can't be moved")
+	}
+
+	delete(st.readers, ss)
+	dest.readers[ss] = struct{}{}
+
+	if _, ok := st.activeUnicastReaders[ss]; ok {
+		delete(st.activeUnicastReaders, ss)
+		dest.activeUnicastReaders[ss] = struct{}{}
+	}
+
+	if _, ok := st.pausedUnicastReaders[ss]; ok {
+		delete(st.pausedUnicastReaders, ss)
+		dest.pausedUnicastReaders[ss] = struct{}{}
+	}
+
+	ss.propsMutex.Lock()
+	defer ss.propsMutex.Unlock()
+
+	for medi, rsm := range ss.setuppedMedias {
+		for pt, rsf := range rsm.formats {
+			if rsf.restamper == nil {
+				srcFormat := st.medias[medi].formats[pt]
+
+				rsf.restamper = &rtprestamper.Restamper{
+					ClockRate: rsf.format.ClockRate(),
+					TimeNow:   st.Server.timeNow,
+				}
+				rsf.restamper.Initialize()
+				rsf.restamper.ContinueFrom(srcFormat.rtpRestamper)
+				rsf.restampSSRC = srcFormat.localSSRC
+			} else {
+				rsf.restamper.Reset()
+			}
+		}
+	}
+
+	ss.setuppedStream = dest
+
+	return nil
 }
 
 // WritePacketRTP writes a RTP packet to all the readers of the stream.
@@ -289,6 +541,26 @@ func (st *ServerStream) WritePacketRTP(medi *description.Media, pkt *rtp.Packet)
 // WritePacketRTPWithNTP writes a RTP packet to all the readers of the stream.
 // ntp is the absolute timestamp of the packet, and is sent with periodic RTCP sender reports.
 func (st *ServerStream) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.Packet, ntp time.Time) error {
+	return st.writePacketRTP(medi, pkt, ntp, time.Time{})
+}
+
+// TryWritePacketRTP writes a RTP packet to all the readers of the stream.
+// If the write queue of a reader is full, the packet is discarded for that reader
+// and ErrServerWouldBlock is returned, allowing the caller to implement its own dropping strategy.
+// The packet is still sent to the other readers.
+func (st *ServerStream) TryWritePacketRTP(medi *description.Media, pkt *rtp.Packet) error {
+	return st.writePacketRTP(medi, pkt, st.Server.timeNow(), time.Now())
+}
+
+// WritePacketRTPWithTimeout writes a RTP packet to all the readers of the stream.
+// If the write queue of a reader is full, it waits until there's space or the timeout expires,
+// then it returns ErrServerWouldBlock.
+// The timeout is shared among readers.
+func (st *ServerStream) WritePacketRTPWithTimeout(medi *description.Media, pkt *rtp.Packet, timeout time.Duration) error {
+	return st.writePacketRTP(medi, pkt, st.Server.timeNow(), time.Now().Add(timeout))
+}
+
+func (st *ServerStream) writePacketRTP(medi *description.Media, pkt *rtp.Packet, ntp time.Time, deadline time.Time) error {
 	st.mutex.RLock()
 	defer st.mutex.RUnlock()
 
@@ -298,7 +570,7 @@ func (st *ServerStream) WritePacketRTPWithNTP(medi *description.Media, pkt *rtp.
 
 	sm := st.medias[medi]
 	sf := sm.formats[pkt.PayloadType]
-	return sf.writePacketRTP(pkt, ntp)
+	return sf.writePacketRTP(pkt, ntp, deadline)
 }
 
 // WritePacketRTCP writes a RTCP packet to all the readers of the stream.
@@ -9,6 +9,8 @@ import (
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
+	"github.com/bluenviron/gortsplib/v5/pkg/rtprestamper"
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpsender"
 )
 
@@ -28,15 +30,25 @@ type serverStreamFormat struct {
 
 	rtpSender      *rtpsender.Sender
 	rtpPacketsSent *uint64
+
+	// rewrites sequence numbers and timestamps when readers are moved from another stream.
+	rtpRestamper *rtprestamper.Restamper
 }
 
 func (sf *serverStreamFormat) initialize() {
 	sf.rtpPacketsSent = new(uint64)
 
-	sf.rtpSender = &rtpsender.Sender{
+	sf.rtpRestamper = &rtprestamper.Restamper{
 		ClockRate: sf.format.ClockRate(),
-		Period:    sf.sm.st.Server.senderReportPeriod,
 		TimeNow:   sf.sm.st.Server.timeNow,
+	}
+	sf.rtpRestamper.Initialize()
+
+	sf.rtpSender = &rtpsender.Sender{
+		ClockRate:         sf.format.ClockRate(),
+		Period:            sf.sm.st.Server.senderReportPeriod,
+		BandwidthFraction: sf.sm.st.Server.RTCPBandwidthFraction,
+		TimeNow:           sf.sm.st.Server.timeNow,
 		WritePacketRTCP: func(pkt rtcp.Packet) {
 			if !sf.sm.st.Server.DisableRTCPSenderReports {
 				sf.sm.st.WritePacketRTCP(sf.sm.media, pkt) //nolint:errcheck
@@ -52,10 +64,44 @@ func (sf *serverStreamFormat) close() {
 	}
 }
 
-func (sf *serverStreamFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time) error {
+// moveFrom makes the sequence numbers and timestamps of next packets
+// contiguous with the ones of packets previously written by another format.
+func (sf *serverStreamFormat) moveFrom(src *serverStreamFormat) {
+	sf.rtpRestamper.ContinueFrom(src.rtpRestamper)
+	sf.localSSRC, src.localSSRC = src.localSSRC, sf.localSSRC
+}
+
+// readerPacket returns the packet that has to be sent to a reader,
+// after applying the transform of the stream and the restamper of the reader.
+// It returns nil when the packet must be discarded.
+func (sf *serverStreamFormat) readerPacket(r *ServerSession, rsf *serverSessionFormat, pkt *rtp.Packet) *rtp.Packet {
+	rpkt := pkt
+
+	if sf.sm.st.TransformPacketRTP != nil {
+		rpkt = sf.sm.st.TransformPacketRTP(r, sf.sm.media, sf.format, pkt.Clone())
+		if rpkt == nil {
+			return nil
+		}
+	}
+
+	if rsf.restamper != nil {
+		rpkt = rsf.restamp(rpkt)
+	}
+
+	return rpkt
+}
+
+// writePacketRTP writes a RTP packet to all readers.
+// If deadline is not zero, packets are not discarded when the write queue of a reader is full:
+// the function waits until there's space or the deadline expires,
+// then it returns ErrServerWouldBlock.
+func (sf *serverStreamFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time, deadline time.Time) error {
+	pkt = sf.rtpRestamper.Process(pkt)
 	pkt.SSRC = sf.localSSRC
 
-	sf.rtpSender.ProcessPacket(pkt, ntp, sf.format.PTSEqualsDTS(pkt))
+	randomAccess := sf.format.PTSEqualsDTS(pkt)
+
+	sf.rtpSender.ProcessPacket(pkt, ntp, randomAccess)
 
 	maxPlainPacketSize := sf.sm.st.Server.MaxPacketSize
 	if sf.sm.srtpOutCtx != nil {
@@ -81,33 +127,76 @@ func (sf *serverStreamFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time) err
 	encrLen := uint64(len(encr))
 	plainLen := uint64(len(plain))
 
+	wouldBlock := false
+
 	// send unicast
 	for r := range sf.sm.st.activeUnicastReaders {
 		if rsm, ok := r.setuppedMedias[sf.sm.media]; ok {
 			rsf := rsm.formats[pkt.PayloadType]
 
-			if isSecure(r.setuppedTransport.Profile) {
-				err = rsf.writePacketRTPEncoded(encr)
+			rpkt := sf.readerPacket(r, rsf, pkt)
+			if rpkt == nil {
+				continue
+			}
+
+			rplain := plain
+			rplainLen := plainLen
+
+			if rpkt != pkt {
+				rplain, err = rpkt.Marshal()
 				if err != nil {
 					r.onStreamWriteError(err)
 					continue
 				}
+				rplainLen = uint64(len(rplain))
+			}
 
-				atomic.AddUint64(sf.sm.bytesSent, encrLen)
+			var n uint64
+			rdeadline := rsf.rateControlDeadline(deadline)
+
+			if rsf.pauseBuffer != nil {
+				err = rsf.writePacketRTPRewritten(rpkt, rdeadline)
+				n = rplainLen
+			} else if rsf.refragmenter != nil {
+				err = rsf.writePacketRTPRefragmented(rpkt, rdeadline)
+				n = rplainLen
+			} else if isSecure(r.setuppedTransport.Profile) {
+				err = rsf.writePacketRTPEncoded(encr, rdeadline)
+				n = encrLen
 			} else {
-				err = rsf.writePacketRTPEncoded(plain)
-				if err != nil {
+				err = rsf.writePacketRTPEncoded(rplain, rdeadline)
+				n = rplainLen
+			}
+
+			if err != nil {
+				if _, ok2 := err.(liberrors.ErrServerWouldBlock); ok2 {
+					wouldBlock = true
+				} else {
 					r.onStreamWriteError(err)
-					continue
 				}
-
-				atomic.AddUint64(sf.sm.bytesSent, plainLen)
+				continue
 			}
 
+			atomic.AddUint64(sf.sm.bytesSent, n)
 			atomic.AddUint64(sf.rtpPacketsSent, 1)
 		}
 	}
 
+	// store packets of paused readers
+	if len(sf.sm.st.pausedUnicastReaders) != 0 {
+		now := sf.sm.st.Server.timeNow()
+
+		for r := range sf.sm.st.pausedUnicastReaders {
+			if rsm, ok := r.setuppedMedias[sf.sm.media]; ok {
+				if rsf := rsm.formats[pkt.PayloadType]; rsf.pauseBuffer != nil {
+					if rpkt := sf.readerPacket(r, rsf, pkt); rpkt != nil {
+						rsf.pauseBuffer.push(rpkt, now, randomAccess)
+					}
+				}
+			}
+		}
+	}
+
 	// send multicast
 	if sf.sm.multicastWriter != nil {
 		if sf.sm.srtpOutCtx != nil {
@@ -129,5 +218,9 @@ func (sf *serverStreamFormat) writePacketRTP(pkt *rtp.Packet, ntp time.Time) err
 		atomic.AddUint64(sf.rtpPacketsSent, 1)
 	}
 
+	if wouldBlock {
+		return liberrors.ErrServerWouldBlock{}
+	}
+
 	return nil
 }
@@ -0,0 +1,70 @@
+package gortsplib
+
+import (
+	"fmt"
+)
+
+// ServerStreamGroup is a group of streams that carry the same content
+// at different qualities (simulcast).
+// Readers of a stream of the group can be switched to another stream of the group
+// while they are reading, for instance depending on their available bandwidth.
+//
+// Streams must share the same description (Desc), therefore qualities must be encoded
+// with the same formats, and codec parameters must be sent in-band.
+// Sequence numbers, timestamps and SSRCs of packets sent to switched readers are rewritten
+// in order to be contiguous with the ones of packets previously sent to them.
+// RTCP sender reports sent to switched readers are rewritten in the same way,
+// and they are not sent until a packet of the new stream has been sent to the reader.
+type ServerStreamGroup struct {
+	// streams of the group, ordered from the lowest quality to the highest one.
+	Streams []*ServerStream
+}
+
+// Initialize initializes a ServerStreamGroup.
+func (g *ServerStreamGroup) Initialize() error {
+	if len(g.Streams) == 0 {
+		return fmt.Errorf("no streams provided")
+	}
+
+	for _, st := range g.Streams[1:] {
+		if st.Desc != g.Streams[0].Desc {
+			return fmt.Errorf("streams have different descriptions")
+		}
+	}
+
+	return nil
+}
+
+// Index returns the index of the stream that a session is reading,
+// or -1 if the session is not reading any stream of the group.
+func (g *ServerStreamGroup) Index(ss *ServerSession) int {
+	st := ss.Stream()
+
+	for i, cur := range g.Streams {
+		if cur == st {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Switch moves a session to the stream with the given index, without interrupting it.
+// Sessions that use UDP-multicast or a secure profile can't be switched,
+// since they receive packets that are shared or encrypted once for all readers.
+func (g *ServerStreamGroup) Switch(ss *ServerSession, index int) error {
+	if index < 0 || index >= len(g.Streams) {
+		return fmt.Errorf("invalid stream index: %d", index)
+	}
+
+	cur := g.Index(ss)
+	if cur < 0 {
+		return fmt.Errorf("session is not reading a stream of the group")
+	}
+
+	if cur == index {
+		return nil
+	}
+
+	return g.Streams[cur].moveReader(ss, g.Streams[index])
+}
@@ -75,27 +75,56 @@ func (sm *serverStreamMedia) writePacketRTCP(pkt rtcp.Packet) error {
 	encrLen := uint64(len(encr))
 	plainLen := uint64(len(plain))
 
+	// sender reports sent to readers that have been moved to another stream of a group
+	// are rewritten, since they refer to sequence numbers and timestamps of the stream.
+	sr, _ := pkt.(*rtcp.SenderReport)
+	var srFormat *serverStreamFormat
+	if sr != nil {
+		for _, sf := range sm.formats {
+			if sf.localSSRC == sr.SSRC {
+				srFormat = sf
+				break
+			}
+		}
+	}
+
 	// send unicast
 	for r := range sm.st.activeUnicastReaders {
 		if sm, ok := r.setuppedMedias[sm.media]; ok {
-			if isSecure(r.setuppedTransport.Profile) {
-				err = sm.writePacketRTCPEncoded(encr)
-				if err != nil {
-					r.onStreamWriteError(err)
+			var rsf *serverSessionFormat
+			if srFormat != nil {
+				rsf = sm.formats[srFormat.format.PayloadType()]
+			}
+
+			var buf []byte
+
+			switch {
+			case isSecure(r.setuppedTransport.Profile):
+				buf = encr
+
+			case rsf != nil && rsf.restamper != nil:
+				sr2 := rsf.restampSenderReport(sr)
+				if sr2 == nil {
 					continue
 				}
 
-				atomic.AddUint64(sm.bytesSent, encrLen)
-			} else {
-				err = sm.writePacketRTCPEncoded(plain)
+				buf, err = sr2.Marshal()
 				if err != nil {
 					r.onStreamWriteError(err)
 					continue
 				}
 
-				atomic.AddUint64(sm.bytesSent, plainLen)
+			default:
+				buf = plain
+			}
+
+			err = sm.writePacketRTCPEncoded(buf)
+			if err != nil {
+				r.onStreamWriteError(err)
+				continue
 			}
 
+			atomic.AddUint64(sm.bytesSent, uint64(len(buf)))
 			atomic.AddUint64(sm.rtcpPacketsSent, 1)
 		}
 	}
@@ -37,6 +37,12 @@ func (sl *serverTCPListener) run() {
 			return
 		}
 
+		err = setTCPConnOptions(nconn, sl.s.TCPKeepAlive, sl.s.TCPLinger)
+		if err != nil {
+			nconn.Close()
+			continue
+		}
+
 		sl.s.newConn(nconn)
 	}
 }
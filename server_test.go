@@ -2,22 +2,27 @@ package gortsplib
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/auth"
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 	"github.com/bluenviron/gortsplib/v5/pkg/conn"
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
 	"github.com/bluenviron/gortsplib/v5/pkg/headers"
 	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
@@ -120,20 +125,22 @@ func doDescribe(t *testing.T, conn *conn.Conn, backChannels bool) *description.S
 }
 
 type testServerHandler struct {
-	onConnOpen     func(*ServerHandlerOnConnOpenCtx)
-	onConnClose    func(*ServerHandlerOnConnCloseCtx)
-	onSessionOpen  func(*ServerHandlerOnSessionOpenCtx)
-	onSessionClose func(*ServerHandlerOnSessionCloseCtx)
-	onDescribe     func(*ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error)
-	onAnnounce     func(*ServerHandlerOnAnnounceCtx) (*base.Response, error)
-	onSetup        func(*ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error)
-	onPlay         func(*ServerHandlerOnPlayCtx) (*base.Response, error)
-	onRecord       func(*ServerHandlerOnRecordCtx) (*base.Response, error)
-	onPause        func(*ServerHandlerOnPauseCtx) (*base.Response, error)
-	onSetParameter func(*ServerHandlerOnSetParameterCtx) (*base.Response, error)
-	onGetParameter func(*ServerHandlerOnGetParameterCtx) (*base.Response, error)
-	onPacketsLost  func(*ServerHandlerOnPacketsLostCtx)
-	onDecodeError  func(*ServerHandlerOnDecodeErrorCtx)
+	onConnOpen           func(*ServerHandlerOnConnOpenCtx)
+	onConnClose          func(*ServerHandlerOnConnCloseCtx)
+	onSessionOpen        func(*ServerHandlerOnSessionOpenCtx)
+	onSessionClose       func(*ServerHandlerOnSessionCloseCtx)
+	onSessionStateChange func(*ServerHandlerOnSessionStateChangeCtx)
+	onDescribe           func(*ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error)
+	onAnnounce           func(*ServerHandlerOnAnnounceCtx) (*base.Response, error)
+	onSetup              func(*ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error)
+	onPlay               func(*ServerHandlerOnPlayCtx) (*base.Response, error)
+	onRecord             func(*ServerHandlerOnRecordCtx) (*base.Response, error)
+	onPause              func(*ServerHandlerOnPauseCtx) (*base.Response, error)
+	onTeardown           func(*ServerHandlerOnTeardownCtx) (*base.Response, error)
+	onSetParameter       func(*ServerHandlerOnSetParameterCtx) (*base.Response, error)
+	onGetParameter       func(*ServerHandlerOnGetParameterCtx) (*base.Response, error)
+	onPacketsLost        func(*ServerHandlerOnPacketsLostCtx)
+	onDecodeError        func(*ServerHandlerOnDecodeErrorCtx)
 }
 
 func (sh *testServerHandler) OnConnOpen(ctx *ServerHandlerOnConnOpenCtx) {
@@ -160,6 +167,12 @@ func (sh *testServerHandler) OnSessionClose(ctx *ServerHandlerOnSessionCloseCtx)
 	}
 }
 
+func (sh *testServerHandler) OnSessionStateChange(ctx *ServerHandlerOnSessionStateChangeCtx) {
+	if sh.onSessionStateChange != nil {
+		sh.onSessionStateChange(ctx)
+	}
+}
+
 func (sh *testServerHandler) OnDescribe(ctx *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
 	if sh.onDescribe != nil {
 		return sh.onDescribe(ctx)
@@ -202,6 +215,13 @@ func (sh *testServerHandler) OnPause(ctx *ServerHandlerOnPauseCtx) (*base.Respon
 	return nil, fmt.Errorf("unimplemented")
 }
 
+func (sh *testServerHandler) OnTeardown(ctx *ServerHandlerOnTeardownCtx) (*base.Response, error) {
+	if sh.onTeardown != nil {
+		return sh.onTeardown(ctx)
+	}
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
 func (sh *testServerHandler) OnSetParameter(ctx *ServerHandlerOnSetParameterCtx) (*base.Response, error) {
 	if sh.onSetParameter != nil {
 		return sh.onSetParameter(ctx)
@@ -274,6 +294,87 @@ func TestServerErrorInvalidUDPPorts(t *testing.T) {
 		err := s.Start()
 		require.Error(t, err)
 	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		s := &Server{
+			UDPPortMin:  8004,
+			UDPPortMax:  8002,
+			RTSPAddress: "localhost:8554",
+		}
+		err := s.Start()
+		require.EqualError(t, err, "UDPPortMax (8002) must be greater than UDPPortMin (8004)")
+	})
+}
+
+func TestServerUDPPortRange(t *testing.T) {
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(_ *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+		UDPPortMin:  35000,
+		UDPPortMax:  35010,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	medias := []*description.Media{testH264Media}
+
+	setup := func(path string) (net.Conn, [2]int) {
+		nconn, err2 := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err2)
+		conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+		doAnnounce(t, conn, "rtsp://localhost:8554/"+path, medias)
+
+		inTH := &headers.Transport{
+			Delivery:    ptrOf(headers.TransportDeliveryUnicast),
+			Mode:        ptrOf(headers.TransportModeRecord),
+			Protocol:    headers.TransportProtocolUDP,
+			ClientPorts: &[2]int{35466, 35467},
+		}
+
+		_, th := doSetup(t, conn, "rtsp://localhost:8554/"+path+"/"+medias[0].Control, inTH, "")
+
+		return nconn, *th.ServerPorts
+	}
+
+	nconn1, ports1 := setup("stream1")
+	defer nconn1.Close()
+	require.Equal(t, [2]int{35000, 35001}, ports1)
+
+	// the same client address can't be used twice with the same ports.
+	nconn2, ports2 := setup("stream2")
+	require.Equal(t, [2]int{35002, 35003}, ports2)
+
+	nconn2.Close()
+
+	// the additional pair is released when unused.
+	for {
+		s.udpPairsMutex.Lock()
+		l := len(s.udpPairs)
+		s.udpPairsMutex.Unlock()
+
+		if l == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	nconn3, ports3 := setup("stream3")
+	defer nconn3.Close()
+	require.Equal(t, [2]int{35002, 35003}, ports3)
 }
 
 func TestServerConnClose(t *testing.T) {
@@ -313,6 +414,174 @@ func TestServerConnClose(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestServerTCPConnOptions(t *testing.T) {
+	keepAlive := &net.KeepAliveConfig{
+		Enable:   true,
+		Idle:     1 * time.Second,
+		Interval: 1 * time.Second,
+		Count:    3,
+	}
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusNotFound,
+				}, nil, nil
+			},
+		},
+		RTSPAddress:  "localhost:8554",
+		TCPKeepAlive: keepAlive,
+		TCPLinger:    ptrOf(time.Duration(0)),
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	dialed := make(chan net.Conn, 1)
+
+	c := Client{
+		Scheme:       u.Scheme,
+		Host:         u.Host,
+		TCPKeepAlive: keepAlive,
+		TCPLinger:    ptrOf(1 * time.Second),
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			nconn, err2 := (&net.Dialer{}).DialContext(ctx, network, address)
+			if err2 == nil {
+				dialed <- nconn
+			}
+			return nconn, err2
+		},
+	}
+
+	err = c.Start()
+	require.NoError(t, err)
+	defer c.Close()
+
+	res, err := c.Options(u)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	nconn := <-dialed
+	_, ok := nconn.(*net.TCPConn)
+	require.True(t, ok)
+}
+
+func TestServerPeerInfo(t *testing.T) {
+	var sc *ServerConn
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onConnOpen: func(ctx *ServerHandlerOnConnOpenCtx) {
+				sc = ctx.Conn
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusNotFound,
+				}, nil, nil
+			},
+		},
+		RTSPAddress:  "localhost:8554",
+		ServerHeader: "MyCamera/1.2.3",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	c := Client{
+		Scheme:    u.Scheme,
+		Host:      u.Host,
+		UserAgent: "MyRecorder/4.5",
+		OnRequest: func(req *base.Request) {
+			if req.Method == base.Options {
+				req.Header["Supported"] = base.HeaderValue{"play.basic, setup.rtp.rtcp.mux"}
+			}
+		},
+	}
+
+	err = c.Start()
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Options(u)
+	require.NoError(t, err)
+
+	info := c.ServerInfo()
+	require.Equal(t, "MyCamera/1.2.3", info.Server)
+	require.Contains(t, info.Methods, base.Describe)
+
+	require.Equal(t, &ServerConnClientInfo{
+		UserAgent: "MyRecorder/4.5",
+		Supported: []string{"play.basic", "setup.rtp.rtcp.mux"},
+	}, sc.ClientInfo())
+}
+
+func TestServerDescribeCache(t *testing.T) {
+	var stream *ServerStream
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+		},
+		RTSPAddress:         "localhost:8554",
+		DescribeCacheEnable: true,
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	forma := &format.H264{
+		PayloadTyp:        96,
+		SPS:               testH264Media.Formats[0].(*format.H264).SPS,
+		PPS:               testH264Media.Formats[0].(*format.H264).PPS,
+		PacketizationMode: 1,
+	}
+
+	stream = &ServerStream{
+		Server: s,
+		Desc: &description.Session{Medias: []*description.Media{{
+			Type:    description.MediaTypeVideo,
+			Formats: []format.Format{forma},
+		}}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+	require.Equal(t, forma.PPS, desc.Medias[0].Formats[0].(*format.H264).PPS)
+
+	newPPS := []byte{0x08, 0x06, 0x07, 0x08}
+	forma.SafeSetParams(forma.SPS, newPPS)
+
+	// the cached document is returned until it is invalidated
+	desc = doDescribe(t, conn, false)
+	require.NotEqual(t, newPPS, desc.Medias[0].Formats[0].(*format.H264).PPS)
+
+	stream.InvalidateDescription()
+
+	desc = doDescribe(t, conn, false)
+	require.Equal(t, newPPS, desc.Medias[0].Formats[0].(*format.H264).PPS)
+}
+
 func TestServerCSeq(t *testing.T) {
 	s := &Server{
 		RTSPAddress: "localhost:8554",
@@ -534,10 +803,78 @@ func TestServerErrorMethodNotImplemented(t *testing.T) {
 			})
 			require.NoError(t, err)
 			require.Equal(t, base.StatusOK, res.StatusCode)
+			require.Equal(t, base.HeaderValue{"DESCRIBE, SETUP, GET_PARAMETER, TEARDOWN"}, res.Header["Public"])
 		})
 	}
 }
 
+type testServerCustomMethod struct {
+	path string
+}
+
+func (s *testServerCustomMethod) CustomMethods() []base.Method {
+	return []base.Method{"MYMETHOD"}
+}
+
+func (s *testServerCustomMethod) OnCustomRequest(
+	ctx *ServerHandlerOnCustomRequestCtx,
+) (*base.Response, error) {
+	s.path = ctx.Path
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func TestServerCustomMethod(t *testing.T) {
+	h := &testServerCustomMethod{}
+
+	s := &Server{
+		Handler:     h,
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	res, err := writeReqReadRes(conn, base.Request{
+		Method: base.Options,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"1"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, base.HeaderValue{"GET_PARAMETER, TEARDOWN, MYMETHOD"}, res.Header["Public"])
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: "MYMETHOD",
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"2"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+	require.Equal(t, "/teststream", h.path)
+
+	res, err = writeReqReadRes(conn, base.Request{
+		Method: "MYOTHERMETHOD",
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq": base.HeaderValue{"3"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusNotImplemented, res.StatusCode)
+}
+
 func TestServerErrorTCPTwoConnOneSession(t *testing.T) {
 	var stream *ServerStream
 
@@ -1085,69 +1422,444 @@ func TestServerAuthFail(t *testing.T) {
 	require.Error(t, err)
 }
 
-func TestServerSessionClose(t *testing.T) {
-	var stream *ServerStream
-	var session *ServerSession
+func TestServerAuditSink(t *testing.T) {
+	var mutex sync.Mutex
+	var events []*AuditEvent
+
 	connClosed := make(chan struct{})
+	sessionClosed := make(chan struct{})
 
 	s := &Server{
 		Handler: &testServerHandler{
-			onSessionOpen: func(ctx *ServerHandlerOnSessionOpenCtx) {
-				session = ctx.Session
-			},
 			onConnClose: func(_ *ServerHandlerOnConnCloseCtx) {
 				close(connClosed)
 			},
-			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+			onSessionClose: func(_ *ServerHandlerOnSessionCloseCtx) {
+				close(sessionClosed)
+			},
+			onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				ok := ctx.Conn.VerifyCredentials(ctx.Request, "myuser", "mypass")
+				if !ok {
+					return &base.Response{
+						StatusCode: base.StatusUnauthorized,
+					}, liberrors.ErrServerAuth{}
+				}
+
 				return &base.Response{
 					StatusCode: base.StatusOK,
-				}, stream, nil
+				}, nil
 			},
 			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
 				return &base.Response{
 					StatusCode: base.StatusOK,
-				}, stream, nil
+				}, nil, nil
+			},
+			onRecord: func(_ *ServerHandlerOnRecordCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
 			},
 		},
 		RTSPAddress: "localhost:8554",
+		AuthMethods: []auth.VerifyMethod{auth.VerifyMethodBasic},
+		AuditSink: AuditSinkFunc(func(e *AuditEvent) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			events = append(events, e)
+		}),
 	}
 
 	err := s.Start()
 	require.NoError(t, err)
 	defer s.Close()
 
-	stream = &ServerStream{
-		Server: s,
-		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
-	}
-	err = stream.Initialize()
-	require.NoError(t, err)
-	defer stream.Close()
-
 	nconn, err := net.Dial("tcp", "localhost:8554")
 	require.NoError(t, err)
-	defer nconn.Close()
 	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
 
-	desc := doDescribe(t, conn, false)
+	medias := []*description.Media{testH264Media}
 
-	inTH := &headers.Transport{
-		Protocol:       headers.TransportProtocolTCP,
-		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
-		Mode:           ptrOf(headers.TransportModePlay),
-		InterleavedIDs: &[2]int{0, 1},
+	req := base.Request{
+		Method: base.Announce,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":         base.HeaderValue{"1"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: mediasToSDP(medias),
 	}
 
-	doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
-
-	session.Close()
-	session.Close()
+	res, err := writeReqReadRes(conn, req)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusUnauthorized, res.StatusCode)
 
-	select {
-	case <-connClosed:
-	case <-time.After(2 * time.Second):
-		t.Errorf("should not happen")
-	}
+	sender := &auth.Sender{
+		WWWAuth: res.Header["WWW-Authenticate"],
+		User:    "myuser",
+		Pass:    "mypass",
+	}
+	err = sender.Initialize()
+	require.NoError(t, err)
+
+	sender.AddAuthorization(&req)
+	res, err = writeReqReadRes(conn, req)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	inTH := &headers.Transport{
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModeRecord),
+		Protocol:       headers.TransportProtocolTCP,
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ = doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medias[0].Control, inTH, "")
+
+	var session headers.Session
+	err = session.Unmarshal(res.Header["Session"])
+	require.NoError(t, err)
+
+	doRecord(t, conn, "rtsp://localhost:8554/teststream", session.Session)
+	doTeardown(t, conn, "rtsp://localhost:8554/teststream", session.Session)
+
+	<-sessionClosed
+
+	nconn.Close()
+	<-connClosed
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	types := make([]AuditEventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+
+	// session_close and teardown are emitted by different routines.
+	require.Equal(t, []AuditEventType{
+		AuditEventConnOpen,
+		AuditEventAnnounce,
+		AuditEventAuth,
+		AuditEventAnnounce,
+		AuditEventSetup,
+		AuditEventRecord,
+	}, types[:6])
+	require.ElementsMatch(t, []AuditEventType{
+		AuditEventTeardown,
+		AuditEventSessionClose,
+	}, types[6:8])
+	require.Equal(t, AuditEventConnClose, types[8])
+
+	require.Equal(t, base.StatusUnauthorized, events[1].StatusCode)
+	require.Equal(t, "myuser", events[2].User)
+	require.True(t, events[2].Success)
+	require.Equal(t, "/teststream", events[3].Path)
+	require.NotZero(t, events[3].Session)
+	require.Equal(t, "TCP", events[4].Transport)
+	require.NotZero(t, events[8].BytesReceived)
+	require.NotZero(t, events[8].BytesSent)
+
+	for _, e := range events[3:8] {
+		require.Equal(t, events[3].Session, e.Session)
+	}
+}
+
+func TestServerSetConfig(t *testing.T) {
+	s := &Server{
+		Handler: &testServerHandler{
+			onAnnounce: func(ctx *ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+				ok := ctx.Conn.VerifyCredentials(ctx.Request, "myuser", "mypass")
+				if !ok {
+					return &base.Response{
+						StatusCode: http.StatusUnauthorized,
+					}, liberrors.ErrServerAuth{}
+				}
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	err = s.SetTLSConfig(&tls.Config{})
+	require.EqualError(t, err, "TLS cannot be enabled or disabled at runtime")
+
+	s.SetAuthRealm("myrealm")
+	s.SetReadTimeout(5 * time.Second)
+	s.SetWriteTimeout(5 * time.Second)
+	s.SetIdleTimeout(30 * time.Second)
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	medias := []*description.Media{testH264Media}
+
+	req := base.Request{
+		Method: base.Announce,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":         base.HeaderValue{"1"},
+			"Content-Type": base.HeaderValue{"application/sdp"},
+		},
+		Body: mediasToSDP(medias),
+	}
+
+	res, err := writeReqReadRes(conn, req)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusUnauthorized, res.StatusCode)
+	require.Contains(t, res.Header["WWW-Authenticate"][0], `realm="myrealm"`)
+
+	sender := &auth.Sender{
+		WWWAuth: res.Header["WWW-Authenticate"],
+		User:    "myuser",
+		Pass:    "mypass",
+	}
+	err = sender.Initialize()
+	require.NoError(t, err)
+
+	sender.AddAuthorization(&req)
+
+	res, err = writeReqReadRes(conn, req)
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+}
+
+func TestServerEventHandler(t *testing.T) {
+	h := &ServerEventHandler{}
+	h.Initialize()
+	defer h.Close()
+
+	s := &Server{
+		Handler:     h,
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream := &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	done := make(chan struct{})
+	connClosed := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for e := range h.Events() {
+			switch e := e.(type) {
+			case *ServerEventConnOpen:
+				require.NotNil(t, e.Ctx.Conn)
+
+			case *ServerEventDescribe:
+				require.Equal(t, "/teststream", e.Ctx.Path)
+				e.Reply(&base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil)
+
+			case *ServerEventConnClose:
+				close(connClosed)
+				return
+			}
+		}
+	}()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+	require.Len(t, desc.Medias, 1)
+
+	nconn.Close()
+	<-connClosed
+	<-done
+}
+
+func TestServerEventHandlerRecord(t *testing.T) {
+	h := &ServerEventHandler{
+		PacketQueueSize: 1,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	s := &Server{
+		Handler:     h,
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	done := make(chan struct{})
+	sessionClosed := make(chan struct{})
+	states := make(chan ServerSessionState, 10)
+
+	go func() {
+		defer close(done)
+
+		for e := range h.Events() {
+			switch e := e.(type) {
+			case *ServerEventAnnounce:
+				e.Reply(&base.Response{
+					StatusCode: base.StatusOK,
+				}, nil)
+
+			case *ServerEventSetup:
+				e.Reply(&base.Response{
+					StatusCode: base.StatusOK,
+				}, nil, nil)
+
+			case *ServerEventRecord:
+				h.ForwardPacketsRTP(e.Ctx.Session)
+				e.Reply(&base.Response{
+					StatusCode: base.StatusOK,
+				}, nil)
+
+			case *ServerEventSessionStateChange:
+				states <- e.Ctx.Current
+
+			case *ServerEventTeardown:
+				e.Reply(&base.Response{
+					StatusCode: base.StatusOK,
+				}, nil)
+
+			case *ServerEventSessionClose:
+				close(sessionClosed)
+
+			case *ServerEventConnClose:
+				return
+			}
+		}
+	}()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	medias := []*description.Media{testH264Media}
+
+	doAnnounce(t, conn, "rtsp://localhost:8554/teststream", medias)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModeRecord),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn, "rtsp://localhost:8554/teststream/"+medias[0].Control, inTH, "")
+
+	session := readSession(t, res)
+
+	doRecord(t, conn, "rtsp://localhost:8554/teststream", session)
+
+	require.Equal(t, ServerSessionStatePreRecord, <-states)
+	require.Equal(t, ServerSessionStateRecord, <-states)
+
+	// packets that exceed the queue are discarded without blocking the session
+	for range 3 {
+		err = conn.WriteInterleavedFrame(&base.InterleavedFrame{
+			Channel: 0,
+			Payload: testRTPPacketMarshaled,
+		}, make([]byte, 1024))
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return h.PacketsRTPDiscarded() == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	pkt := <-h.PacketsRTP()
+	require.Same(t, pkt.Session.AnnouncedDescription().Medias[0], pkt.Media)
+	pkt.Packet.SSRC = testRTPPacket.SSRC
+	require.Equal(t, &testRTPPacket, pkt.Packet)
+
+	doTeardown(t, conn, "rtsp://localhost:8554/teststream", session)
+	<-sessionClosed
+
+	nconn.Close()
+	<-done
+}
+
+func TestServerSessionClose(t *testing.T) {
+	var stream *ServerStream
+	var session *ServerSession
+	connClosed := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onSessionOpen: func(ctx *ServerHandlerOnSessionOpenCtx) {
+				session = ctx.Session
+			},
+			onConnClose: func(_ *ServerHandlerOnConnCloseCtx) {
+				close(connClosed)
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModePlay),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	session.Close()
+	session.Close()
+
+	select {
+	case <-connClosed:
+	case <-time.After(2 * time.Second):
+		t.Errorf("should not happen")
+	}
 
 	_, err = writeReqReadRes(conn, base.Request{
 		Method: base.Options,
@@ -1177,19 +1889,109 @@ func TestServerSessionAutoClose(t *testing.T) {
 							StatusCode: base.StatusOK,
 						}, stream, nil
 					},
-					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
-						if ca == "200" {
-							return &base.Response{
-								StatusCode: base.StatusOK,
-							}, stream, nil
-						}
-
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						if ca == "200" {
+							return &base.Response{
+								StatusCode: base.StatusOK,
+							}, stream, nil
+						}
+
+						return &base.Response{
+							StatusCode: base.StatusBadRequest,
+						}, nil, fmt.Errorf("error")
+					},
+				},
+				RTSPAddress: "localhost:8554",
+			}
+
+			err := s.Start()
+			require.NoError(t, err)
+			defer s.Close()
+
+			stream = &ServerStream{
+				Server: s,
+				Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+			}
+			err = stream.Initialize()
+			require.NoError(t, err)
+			defer stream.Close()
+
+			nconn, err := net.Dial("tcp", "localhost:8554")
+			require.NoError(t, err)
+			conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+			desc := doDescribe(t, conn, false)
+
+			inTH := &headers.Transport{
+				Protocol:       headers.TransportProtocolTCP,
+				Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+				Mode:           ptrOf(headers.TransportModePlay),
+				InterleavedIDs: &[2]int{0, 1},
+			}
+
+			res, err := writeReqReadRes(conn, base.Request{
+				Method: base.Setup,
+				URL:    mediaURL(t, desc.BaseURL, desc.Medias[0]),
+				Header: base.Header{
+					"CSeq":      base.HeaderValue{"1"},
+					"Transport": inTH.Marshal(),
+				},
+			})
+			require.NoError(t, err)
+
+			if ca == "200" {
+				require.Equal(t, base.StatusOK, res.StatusCode)
+			} else {
+				require.Equal(t, base.StatusBadRequest, res.StatusCode)
+			}
+
+			nconn.Close()
+
+			<-sessionClosed
+		})
+	}
+}
+
+func TestServerSessionLimits(t *testing.T) {
+	for _, ca := range []string{
+		"duration", "idle time", "bytes",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var stream *ServerStream
+			sessionClosed := make(chan error, 1)
+
+			s := &Server{
+				Handler: &testServerHandler{
+					onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+						sessionClosed <- ctx.Error
+					},
+					onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+						return &base.Response{
+							StatusCode: base.StatusOK,
+						}, stream, nil
+					},
+					onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
 						return &base.Response{
-							StatusCode: base.StatusBadRequest,
-						}, nil, fmt.Errorf("error")
+							StatusCode: base.StatusOK,
+						}, nil
 					},
 				},
-				RTSPAddress: "localhost:8554",
+				RTSPAddress:       "localhost:8554",
+				checkStreamPeriod: 50 * time.Millisecond,
+			}
+
+			switch ca {
+			case "duration":
+				s.MaxSessionDuration = 300 * time.Millisecond
+			case "idle time":
+				s.MaxSessionIdleTime = 300 * time.Millisecond
+			case "bytes":
+				s.MaxSessionBytes = 100
 			}
 
 			err := s.Start()
@@ -1206,6 +2008,7 @@ func TestServerSessionAutoClose(t *testing.T) {
 
 			nconn, err := net.Dial("tcp", "localhost:8554")
 			require.NoError(t, err)
+			defer nconn.Close()
 			conn := conn.NewConn(bufio.NewReader(nconn), nconn)
 
 			desc := doDescribe(t, conn, false)
@@ -1217,29 +2020,221 @@ func TestServerSessionAutoClose(t *testing.T) {
 				InterleavedIDs: &[2]int{0, 1},
 			}
 
-			res, err := writeReqReadRes(conn, base.Request{
-				Method: base.Setup,
-				URL:    mediaURL(t, desc.BaseURL, desc.Medias[0]),
-				Header: base.Header{
-					"CSeq":      base.HeaderValue{"1"},
-					"Transport": inTH.Marshal(),
-				},
-			})
+			res, _ := doSetup(t, conn, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+			var session headers.Session
+			err = session.Unmarshal(res.Header["Session"])
 			require.NoError(t, err)
 
-			if ca == "200" {
-				require.Equal(t, base.StatusOK, res.StatusCode)
-			} else {
-				require.Equal(t, base.StatusBadRequest, res.StatusCode)
+			if ca != "idle time" {
+				doPlay(t, conn, "rtsp://localhost:8554/teststream", session.Session)
 			}
 
-			nconn.Close()
+			if ca == "bytes" {
+				for range 20 {
+					err = stream.WritePacketRTP(stream.Desc.Medias[0], &rtp.Packet{
+						Header: rtp.Header{
+							Version:     2,
+							PayloadType: 96,
+						},
+						Payload: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+					})
+					require.NoError(t, err)
+				}
+			}
 
-			<-sessionClosed
+			err = <-sessionClosed
+			require.EqualError(t, err, "session limit reached: maximum "+ca)
 		})
 	}
 }
 
+func TestServerSessionExpiry(t *testing.T) {
+	var stream *ServerStream
+	sessionClosed := make(chan error, 1)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+				sessionClosed <- ctx.Error
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(ctx *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				ctx.Session.SetExpiry(time.Now().Add(300*time.Millisecond), &base.Response{
+					StatusCode:    base.StatusPaymentRequired,
+					StatusMessage: "Trial expired",
+				})
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn1 := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn1, false)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModePlay),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, _ := doSetup(t, conn1, mediaURL(t, desc.BaseURL, desc.Medias[0]).String(), inTH, "")
+
+	var session headers.Session
+	err = session.Unmarshal(res.Header["Session"])
+	require.NoError(t, err)
+
+	doPlay(t, conn1, "rtsp://localhost:8554/teststream", session.Session)
+
+	err = <-sessionClosed
+	require.EqualError(t, err, "session expired")
+
+	// requests that refer to the expired session receive the configured response
+	nconn2, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	defer nconn2.Close()
+	conn2 := conn.NewConn(bufio.NewReader(nconn2), nconn2)
+
+	res, err = writeReqReadRes(conn2, base.Request{
+		Method: base.GetParameter,
+		URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+		Header: base.Header{
+			"CSeq":    base.HeaderValue{"1"},
+			"Session": base.HeaderValue{session.Session},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusPaymentRequired, res.StatusCode)
+	require.Equal(t, "Trial expired", res.StatusMessage)
+}
+
+func TestServerSessionCloseOrder(t *testing.T) {
+	var stream *ServerStream
+	var mutex sync.Mutex
+	var events []string
+	sessionClosed := make(chan struct{})
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onConnOpen: func(ctx *ServerHandlerOnConnOpenCtx) {
+				ctx.Conn.SetUserData("myconn")
+			},
+			onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+				v, ok := ServerConnUserData[string](ctx.Conn)
+				require.True(t, ok)
+				require.Equal(t, "myconn", v)
+
+				// give the session the chance to close first
+				time.Sleep(100 * time.Millisecond)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				events = append(events, "conn")
+			},
+			onSessionOpen: func(ctx *ServerHandlerOnSessionOpenCtx) {
+				ctx.Session.SetUserData(123)
+			},
+			onSessionClose: func(ctx *ServerHandlerOnSessionCloseCtx) {
+				_, ok := ServerSessionUserData[string](ctx.Session)
+				require.False(t, ok)
+				v, ok := ServerSessionUserData[int](ctx.Session)
+				require.True(t, ok)
+				require.Equal(t, 123, v)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				events = append(events, "session")
+				close(sessionClosed)
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	nconn, err := net.Dial("tcp", "localhost:8554")
+	require.NoError(t, err)
+	conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+	desc := doDescribe(t, conn, false)
+
+	inTH := &headers.Transport{
+		Protocol:       headers.TransportProtocolTCP,
+		Delivery:       ptrOf(headers.TransportDeliveryUnicast),
+		Mode:           ptrOf(headers.TransportModePlay),
+		InterleavedIDs: &[2]int{0, 1},
+	}
+
+	res, err := writeReqReadRes(conn, base.Request{
+		Method: base.Setup,
+		URL:    mediaURL(t, desc.BaseURL, desc.Medias[0]),
+		Header: base.Header{
+			"CSeq":      base.HeaderValue{"1"},
+			"Transport": inTH.Marshal(),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, base.StatusOK, res.StatusCode)
+
+	nconn.Close()
+
+	<-sessionClosed
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, []string{"conn", "session"}, events)
+}
+
 func TestServerSessionTeardown(t *testing.T) {
 	var stream *ServerStream
 
@@ -1488,3 +2483,184 @@ func TestServerTunnelWebSocket(t *testing.T) {
 		})
 	}
 }
+
+func TestServerTunnelQUIC(t *testing.T) {
+	var stream *ServerStream
+	var sc *ServerConn
+
+	cert, err := tls.X509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	s := &Server{
+		Handler: &testServerHandler{
+			onConnOpen: func(ctx *ServerHandlerOnConnOpenCtx) {
+				sc = ctx.Conn
+			},
+			onDescribe: func(_ *ServerHandlerOnDescribeCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *ServerHandlerOnSetupCtx) (*base.Response, *ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "localhost:8554",
+		QUICAddress: "localhost:8554",
+		TLSConfig:   &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+
+	err = s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = &ServerStream{
+		Server: s,
+		Desc:   &description.Session{Medias: []*description.Media{testH264Media}},
+	}
+	err = stream.Initialize()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	packetRecv := make(chan struct{})
+
+	c := Client{
+		Tunnel: TunnelQUIC,
+		TLSConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	err = readAll(&c, "rtsp://localhost:8554/teststream",
+		func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+			require.Equal(t, testRTPPacket.Payload, pkt.Payload)
+			close(packetRecv)
+		})
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Equal(t, TunnelQUIC, sc.Transport().Tunnel)
+	require.Equal(t, ProtocolTCP, c.Transport().Session.Protocol)
+
+	pkt := testRTPPacket
+	err = stream.WritePacketRTP(stream.Desc.Medias[0], &pkt)
+	require.NoError(t, err)
+
+	<-packetRecv
+}
+
+func TestServerErrorQUICWithoutTLS(t *testing.T) {
+	s := &Server{
+		Handler:     &testServerHandler{},
+		RTSPAddress: "localhost:8554",
+		QUICAddress: "localhost:8554",
+	}
+
+	err := s.Start()
+	require.EqualError(t, err, "QUICAddress requires TLSConfig")
+}
+
+func TestServerReadLimits(t *testing.T) {
+	t.Run("negative", func(t *testing.T) {
+		s := &Server{
+			Handler:     &testServerHandler{},
+			RTSPAddress: "localhost:8554",
+			Limits: base.Limits{
+				MaxBodySize: -1,
+			},
+		}
+
+		err := s.Start()
+		require.EqualError(t, err, "MaxBodySize can't be negative")
+	})
+
+	t.Run("header count", func(t *testing.T) {
+		connClosed := make(chan error, 1)
+
+		s := &Server{
+			Handler: &testServerHandler{
+				onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+					connClosed <- ctx.Error
+				},
+			},
+			RTSPAddress: "localhost:8554",
+			Limits: base.Limits{
+				MaxHeaderCount: 2,
+			},
+		}
+
+		err := s.Start()
+		require.NoError(t, err)
+		defer s.Close()
+
+		nconn, err := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err)
+		defer nconn.Close()
+
+		_, err = nconn.Write([]byte("OPTIONS rtsp://localhost:8554/teststream RTSP/1.0\r\n" +
+			"CSeq: 1\r\n" +
+			"User-Agent: test\r\n" +
+			"Accept: application/sdp\r\n" +
+			"\r\n"))
+		require.NoError(t, err)
+
+		err = <-connClosed
+		require.ErrorAs(t, err, &base.ErrHeaderCountExceeded{})
+	})
+
+	t.Run("header value longer than default buffer", func(t *testing.T) {
+		connClosed := make(chan error, 1)
+
+		s := &Server{
+			Handler: &testServerHandler{
+				onConnClose: func(ctx *ServerHandlerOnConnCloseCtx) {
+					connClosed <- ctx.Error
+				},
+			},
+			RTSPAddress: "localhost:8554",
+			Limits: base.Limits{
+				MaxHeaderValueLength: 8192,
+			},
+		}
+
+		err := s.Start()
+		require.NoError(t, err)
+		defer s.Close()
+
+		nconn, err := net.Dial("tcp", "localhost:8554")
+		require.NoError(t, err)
+		defer nconn.Close()
+		conn := conn.NewConn(bufio.NewReader(nconn), nconn)
+
+		res, err := writeReqReadRes(conn, base.Request{
+			Method: base.Options,
+			URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+			Header: base.Header{
+				"CSeq":       base.HeaderValue{"1"},
+				"User-Agent": base.HeaderValue{strings.Repeat("a", 8000)},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, base.StatusOK, res.StatusCode)
+
+		err = conn.WriteRequest(&base.Request{
+			Method: base.Options,
+			URL:    mustParseURL("rtsp://localhost:8554/teststream"),
+			Header: base.Header{
+				"CSeq":       base.HeaderValue{"2"},
+				"User-Agent": base.HeaderValue{strings.Repeat("a", 8192)},
+			},
+		})
+		require.NoError(t, err)
+
+		err = <-connClosed
+		require.Equal(t, base.ErrHeaderValueTooLong{Max: 8192}, err)
+	})
+}
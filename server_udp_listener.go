@@ -7,9 +7,16 @@ import (
 	"time"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/multicast"
+	"github.com/bluenviron/gortsplib/v5/pkg/pcap"
 	"github.com/bluenviron/gortsplib/v5/pkg/readbuffer"
 )
 
+// serverUDPPendingClient is a client whose port is not known yet,
+// and is learned from the first packet received from its IP.
+type serverUDPPendingClient struct {
+	onFirstPacket func(addr *net.UDPAddr) readFunc
+}
+
 type clientAddr struct {
 	ip   [net.IPv6len]byte // use a fixed-size array to enable the equality operator
 	port int
@@ -29,7 +36,8 @@ func (p *clientAddr) fill(ip net.IP, port int) {
 func createUDPListenerMulticastPair(
 	readBufferSize int,
 	listenPacket func(network, address string) (net.PacketConn, error),
-	writeTimeout time.Duration,
+	writeTimeout func() time.Duration,
+	packetDump *pcap.Writer,
 	multicastRTPPort int,
 	multicastRTCPPort int,
 	ip net.IP,
@@ -38,6 +46,7 @@ func createUDPListenerMulticastPair(
 		readBufferSize:  readBufferSize,
 		listenPacket:    listenPacket,
 		writeTimeout:    writeTimeout,
+		packetDump:      packetDump,
 		multicastEnable: true,
 		address:         net.JoinHostPort(ip.String(), strconv.FormatInt(int64(multicastRTPPort), 10)),
 	}
@@ -50,6 +59,7 @@ func createUDPListenerMulticastPair(
 		readBufferSize:  readBufferSize,
 		listenPacket:    listenPacket,
 		writeTimeout:    writeTimeout,
+		packetDump:      packetDump,
 		multicastEnable: true,
 		address:         net.JoinHostPort(ip.String(), strconv.FormatInt(int64(multicastRTCPPort), 10)),
 	}
@@ -65,7 +75,8 @@ func createUDPListenerMulticastPair(
 type serverUDPListener struct {
 	readBufferSize  int
 	listenPacket    func(network, address string) (net.PacketConn, error)
-	writeTimeout    time.Duration
+	writeTimeout    func() time.Duration
+	packetDump      *pcap.Writer
 	multicastEnable bool
 	address         string
 
@@ -73,6 +84,7 @@ type serverUDPListener struct {
 	listenIP     net.IP
 	clientsMutex sync.RWMutex
 	clients      map[clientAddr]readFunc
+	pending      map[clientAddr][]*serverUDPPendingClient
 
 	done chan struct{}
 }
@@ -108,6 +120,7 @@ func (u *serverUDPListener) initialize() error {
 	}
 
 	u.clients = make(map[clientAddr]readFunc)
+	u.pending = make(map[clientAddr][]*serverUDPPendingClient)
 	u.done = make(chan struct{})
 
 	go u.run()
@@ -146,7 +159,7 @@ func (u *serverUDPListener) run() {
 		}
 		addr := addr2.(*net.UDPAddr)
 
-		func() {
+		handle := func() bool {
 			u.clientsMutex.RLock()
 			defer u.clientsMutex.RUnlock()
 
@@ -154,22 +167,34 @@ func (u *serverUDPListener) run() {
 			ca.fill(addr.IP, addr.Port)
 			cb, ok := u.clients[ca]
 			if !ok {
-				return
+				return false
 			}
 
+			dumpPacketUDP(u.packetDump, addr, u.pc.LocalAddr(), buf[:n])
+
 			if cb(buf[:n]) {
 				createNewBuffer()
 			}
-		}()
+			return true
+		}
+
+		if !handle() && u.claimPendingClient(addr) {
+			handle()
+		}
 	}
 }
 
 func (u *serverUDPListener) write(buf []byte, addr *net.UDPAddr) error {
 	// no mutex is needed here since Write() has an internal lock.
 	// https://github.com/golang/go/issues/27203#issuecomment-534386117
-	u.pc.SetWriteDeadline(time.Now().Add(u.writeTimeout))
+	u.pc.SetWriteDeadline(time.Now().Add(u.writeTimeout()))
 	_, err := u.pc.WriteTo(buf, addr)
-	return err
+	if err != nil {
+		return err
+	}
+
+	dumpPacketUDP(u.packetDump, u.pc.LocalAddr(), addr, buf)
+	return nil
 }
 
 func (u *serverUDPListener) addClient(ip net.IP, port int, cb readFunc) {
@@ -191,3 +216,60 @@ func (u *serverUDPListener) removeClient(ip net.IP, port int) {
 
 	delete(u.clients, addr)
 }
+
+func (u *serverUDPListener) addPendingClient(ip net.IP, pc *serverUDPPendingClient) {
+	var addr clientAddr
+	addr.fill(ip, 0)
+
+	u.clientsMutex.Lock()
+	defer u.clientsMutex.Unlock()
+
+	u.pending[addr] = append(u.pending[addr], pc)
+}
+
+func (u *serverUDPListener) removePendingClient(ip net.IP, pc *serverUDPPendingClient) {
+	var addr clientAddr
+	addr.fill(ip, 0)
+
+	u.clientsMutex.Lock()
+	defer u.clientsMutex.Unlock()
+
+	for i, pc2 := range u.pending[addr] {
+		if pc2 == pc {
+			u.pending[addr] = append(u.pending[addr][:i], u.pending[addr][i+1:]...)
+			break
+		}
+	}
+
+	if len(u.pending[addr]) == 0 {
+		delete(u.pending, addr)
+	}
+}
+
+// claimPendingClient assigns the source address of a packet
+// to the first pending client with the same IP.
+func (u *serverUDPListener) claimPendingClient(addr *net.UDPAddr) bool {
+	var key clientAddr
+	key.fill(addr.IP, 0)
+
+	u.clientsMutex.Lock()
+	defer u.clientsMutex.Unlock()
+
+	pcs, ok := u.pending[key]
+	if !ok {
+		return false
+	}
+
+	pc := pcs[0]
+	if len(pcs) == 1 {
+		delete(u.pending, key)
+	} else {
+		u.pending[key] = pcs[1:]
+	}
+
+	var ca clientAddr
+	ca.fill(addr.IP, addr.Port)
+	u.clients[ca] = pc.onFirstPacket(addr)
+
+	return true
+}
@@ -0,0 +1,139 @@
+package gortsplib
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+type serverUDPListenerPair struct {
+	rtp  *serverUDPListener
+	rtcp *serverUDPListener
+
+	// client addresses (IP and RTP port) that are using the pair.
+	clients map[clientAddr]struct{}
+}
+
+func (p *serverUDPListenerPair) close() {
+	p.rtcp.close()
+	p.rtp.close()
+}
+
+func (s *Server) udpPortRangeEnabled() bool {
+	return s.UDPPortMin != 0
+}
+
+// createUDPListenerPairInRange creates a pair of listeners
+// with the first pair of consecutive ports that are free.
+func (s *Server) createUDPListenerPairInRange() (*serverUDPListener, *serverUDPListener, error) {
+	host, _, err := net.SplitHostPort(s.RTSPAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isUsed := func(port int) bool {
+		for _, p := range s.udpPairs {
+			if p.rtp.port() == port {
+				return true
+			}
+		}
+		return false
+	}
+
+	for rtpPort := s.UDPPortMin; rtpPort < s.UDPPortMax; rtpPort += 2 {
+		if isUsed(rtpPort) {
+			continue
+		}
+
+		rtpl := &serverUDPListener{
+			readBufferSize: s.UDPReadBufferSize,
+			listenPacket:   s.ListenPacket,
+			writeTimeout:   s.writeTimeout,
+			packetDump:     s.PacketDump,
+			address:        net.JoinHostPort(host, strconv.FormatInt(int64(rtpPort), 10)),
+		}
+		err = rtpl.initialize()
+		if err != nil {
+			continue
+		}
+
+		rtcpl := &serverUDPListener{
+			readBufferSize: s.UDPReadBufferSize,
+			listenPacket:   s.ListenPacket,
+			writeTimeout:   s.writeTimeout,
+			packetDump:     s.PacketDump,
+			address:        net.JoinHostPort(host, strconv.FormatInt(int64(rtpPort+1), 10)),
+		}
+		err = rtcpl.initialize()
+		if err != nil {
+			rtpl.close()
+			continue
+		}
+
+		return rtpl, rtcpl, nil
+	}
+
+	return nil, nil, fmt.Errorf("no free UDP ports in range %d-%d", s.UDPPortMin, s.UDPPortMax)
+}
+
+// udpPairAcquire returns a pair of listeners that can be used by a client.
+// The same pair is shared among sessions, unless the client address is already in use.
+func (s *Server) udpPairAcquire(ip net.IP, rtpPort int) (*serverUDPListenerPair, error) {
+	s.udpPairsMutex.Lock()
+	defer s.udpPairsMutex.Unlock()
+
+	var ca clientAddr
+	ca.fill(ip, rtpPort)
+
+	for _, p := range s.udpPairs {
+		if _, ok := p.clients[ca]; !ok {
+			p.clients[ca] = struct{}{}
+			return p, nil
+		}
+	}
+
+	// without a range, address conflicts are handled by sessions and streams.
+	if !s.udpPortRangeEnabled() {
+		p := s.udpPairs[0]
+		p.clients[ca] = struct{}{}
+		return p, nil
+	}
+
+	rtpl, rtcpl, err := s.createUDPListenerPairInRange()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &serverUDPListenerPair{
+		rtp:     rtpl,
+		rtcp:    rtcpl,
+		clients: map[clientAddr]struct{}{ca: {}},
+	}
+	s.udpPairs = append(s.udpPairs, p)
+
+	return p, nil
+}
+
+// udpPairRelease releases a pair of listeners.
+// Additional pairs are closed when they are not used anymore.
+func (s *Server) udpPairRelease(p *serverUDPListenerPair, ip net.IP, rtpPort int) {
+	s.udpPairsMutex.Lock()
+	defer s.udpPairsMutex.Unlock()
+
+	var ca clientAddr
+	ca.fill(ip, rtpPort)
+	delete(p.clients, ca)
+
+	if len(p.clients) != 0 || p == s.udpPairs[0] {
+		return
+	}
+
+	for i, p2 := range s.udpPairs {
+		if p2 == p {
+			s.udpPairs = append(s.udpPairs[:i], s.udpPairs[i+1:]...)
+			break
+		}
+	}
+
+	p.close()
+}
@@ -27,6 +27,18 @@ type SessionStatsFormat struct {
 	RTPPacketsLastRTP uint32
 	// last NTP time of incoming/outgoing NTP packets
 	RTPPacketsLastNTP time.Time
+	// estimated one-way delay of incoming RTP packets, computed with RTCP sender reports.
+	// It includes the offset between the remote and the local clock.
+	RemoteClockOffset time.Duration
+	// estimated drift of the remote clock with respect to the local clock, in parts per million.
+	// A positive value means that the remote clock is slower than the local one.
+	RemoteClockDrift float64
+	// bitrate of incoming RTP payloads, in bits per second
+	Bitrate float64
+	// frame rate of incoming RTP packets, estimated from timestamps
+	FrameRate float64
+	// interval between the last two incoming key frames (H264 and H265 only)
+	KeyFrameInterval time.Duration
 }
 
 // SessionStatsMedia are session media statistics.
@@ -37,6 +49,8 @@ type SessionStatsMedia struct {
 	BytesSent uint64
 	// number of RTP packets that could not be processed
 	RTPPacketsInError uint64
+	// number of RTP packets with a payload type that doesn't match any format
+	RTPPacketsUnknownPayloadType uint64
 	// number of RTCP packets correctly received and processed
 	RTCPPacketsReceived uint64
 	// number of sent RTCP packets
@@ -62,6 +76,8 @@ type SessionStats struct {
 	RTPPacketsLost uint64
 	// number of RTP packets that could not be processed
 	RTPPacketsInError uint64
+	// number of RTP packets with a payload type that doesn't match any format
+	RTPPacketsUnknownPayloadType uint64
 	// mean jitter of received RTP packets
 	RTPPacketsJitter float64
 	// number of RTCP packets correctly received and processed
@@ -0,0 +1,36 @@
+package gortsplib
+
+import (
+	"net"
+	"time"
+)
+
+// setTCPConnOptions applies keepalive and linger settings to a TCP connection.
+// Connections that are not TCP connections (for instance, custom ones) are left untouched.
+func setTCPConnOptions(nconn net.Conn, keepAlive *net.KeepAliveConfig, linger *time.Duration) error {
+	tc, ok := nconn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	if keepAlive != nil {
+		err := tc.SetKeepAliveConfig(*keepAlive)
+		if err != nil {
+			return err
+		}
+	}
+
+	if linger != nil {
+		sec := -1
+		if *linger >= 0 {
+			sec = int(*linger / time.Second)
+		}
+
+		err := tc.SetLinger(sec)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
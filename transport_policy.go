@@ -0,0 +1,78 @@
+package gortsplib
+
+import (
+	"slices"
+)
+
+// TransportCapabilities describes the transport protocols that can be used
+// in a given context.
+type TransportCapabilities struct {
+	// whether the session is used to record.
+	Record bool
+
+	// whether media is encrypted (RTSPS with the SAVP profile).
+	Secure bool
+
+	// whether the connection is tunneled.
+	Tunneled bool
+
+	// protocols that can be used, in the order in which they are tried by default.
+	Protocols []Protocol
+}
+
+// Supports returns whether a protocol can be used.
+func (c TransportCapabilities) Supports(p Protocol) bool {
+	return slices.Contains(c.Protocols, p)
+}
+
+// TransportPolicy chooses the transport protocols used by a session.
+type TransportPolicy interface {
+	// Protocols returns the protocols to try, in order of preference.
+	// The first one is tried first; the following ones are used
+	// when the first one is refused by the server or when no packets are received with it.
+	Protocols(caps TransportCapabilities) []Protocol
+}
+
+// TransportPolicyAuto is a TransportPolicy that tries the protocols
+// that can be used in their default order (first UDP, then TCP).
+type TransportPolicyAuto struct{}
+
+// Protocols implements TransportPolicy.
+func (TransportPolicyAuto) Protocols(caps TransportCapabilities) []Protocol {
+	var ret []Protocol
+	for _, p := range caps.Protocols {
+		if p != ProtocolUDPMulticast {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// TransportPolicyFixed is a TransportPolicy that always uses a single protocol,
+// even when it is not reported by capabilities.
+type TransportPolicyFixed struct {
+	Protocol Protocol
+}
+
+// Protocols implements TransportPolicy.
+func (p TransportPolicyFixed) Protocols(_ TransportCapabilities) []Protocol {
+	return []Protocol{p.Protocol}
+}
+
+// TransportPolicyOrdered is a TransportPolicy that tries protocols in the given order,
+// skipping the ones that can't be used.
+type TransportPolicyOrdered struct {
+	// protocols, in order of preference.
+	Order []Protocol
+}
+
+// Protocols implements TransportPolicy.
+func (p TransportPolicyOrdered) Protocols(caps TransportCapabilities) []Protocol {
+	var ret []Protocol
+	for _, pr := range p.Order {
+		if caps.Supports(pr) {
+			ret = append(ret, pr)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,94 @@
+package gortsplib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/rtsptest"
+)
+
+func TestTransportPolicy(t *testing.T) {
+	caps := TransportCapabilities{
+		Protocols: []Protocol{ProtocolUDP, ProtocolUDPMulticast, ProtocolTCP},
+	}
+
+	tcpOnly := TransportCapabilities{
+		Secure:    true,
+		Protocols: []Protocol{ProtocolTCP},
+	}
+
+	for _, ca := range []struct {
+		name   string
+		policy TransportPolicy
+		caps   TransportCapabilities
+		out    []Protocol
+	}{
+		{
+			"auto",
+			TransportPolicyAuto{},
+			caps,
+			[]Protocol{ProtocolUDP, ProtocolTCP},
+		},
+		{
+			"auto tcp only",
+			TransportPolicyAuto{},
+			tcpOnly,
+			[]Protocol{ProtocolTCP},
+		},
+		{
+			"fixed",
+			TransportPolicyFixed{Protocol: ProtocolUDP},
+			tcpOnly,
+			[]Protocol{ProtocolUDP},
+		},
+		{
+			"ordered",
+			TransportPolicyOrdered{Order: []Protocol{ProtocolTCP, ProtocolUDPMulticast}},
+			caps,
+			[]Protocol{ProtocolTCP, ProtocolUDPMulticast},
+		},
+		{
+			"ordered unsupported",
+			TransportPolicyOrdered{Order: []Protocol{ProtocolUDP, ProtocolUDPMulticast}},
+			tcpOnly,
+			nil,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.out, ca.policy.Protocols(ca.caps))
+		})
+	}
+}
+
+func TestClientTransportPolicy(t *testing.T) {
+	t.Run("ordered", func(t *testing.T) {
+		s := &rtsptest.MockServer{
+			Address: "localhost:8554",
+		}
+		err := s.Initialize()
+		require.NoError(t, err)
+		defer s.Close()
+
+		c := Client{
+			TransportPolicy: TransportPolicyOrdered{Order: []Protocol{ProtocolTCP, ProtocolUDP}},
+		}
+
+		err = readAll(&c, "rtsp://localhost:8554/teststream", nil)
+		require.NoError(t, err)
+		defer c.Close()
+
+		require.Equal(t, ProtocolTCP, c.Transport().Session.Protocol)
+		require.False(t, c.canSwitchToTCP(c.Transport().Session.Profile))
+	})
+
+	t.Run("protocol conflict", func(t *testing.T) {
+		c := Client{
+			Protocol:        ptrOf(ProtocolTCP),
+			TransportPolicy: TransportPolicyAuto{},
+		}
+
+		err := c.Start()
+		require.EqualError(t, err, "Protocol and TransportPolicy can't be used together")
+	})
+}